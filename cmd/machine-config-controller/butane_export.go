@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/controller/butane"
+)
+
+var butaneExportCmd = &cobra.Command{
+	Use:   "butane-export MACHINECONFIG...",
+	Short: "Prints MachineConfigs as Butane configs with their file contents decoded, for review",
+	Long:  "Reads MachineConfigs in YAML or JSON, e.g. from 'oc get machineconfig rendered-worker-<hash> -o yaml', and prints them as Butane configs of the openshift variant, separated by '---'.",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   executeButaneExport,
+}
+
+func init() {
+	rootCmd.AddCommand(butaneExportCmd)
+}
+
+func runButaneExport(_ *cobra.Command, args []string) error {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	for i, path := range args {
+		mc := &mcfgv1.MachineConfig{}
+		if err := readManifest(path, mc); err != nil {
+			return err
+		}
+		data, err := butane.ExportMachineConfig(mc)
+		if err != nil {
+			return fmt.Errorf("failed to export %s: %w", path, err)
+		}
+		if i > 0 {
+			fmt.Println("---")
+		}
+		if _, err := os.Stdout.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func executeButaneExport(cmd *cobra.Command, args []string) {
+	if err := runButaneExport(cmd, args); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
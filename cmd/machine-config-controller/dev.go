@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/clients"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/server"
+	"github.com/spf13/cobra"
+)
+
+// devTemplatesDir is the template tree of a checkout of the repository, the
+// default --templates of --dev when run from its root.
+const devTemplatesDir = "templates"
+
+// setupDevMode adjusts the defaults of the start command to run the
+// controller on a development machine against a remote cluster: it takes the
+// lock of the in-cluster controller, so that the two never run at the same
+// time, and renders the templates of the checkout.
+func setupDevMode(cmd *cobra.Command) string {
+	kubeconfig := startOpts.kubeconfig
+	if kubeconfig == "" {
+		kubeconfig = os.Getenv("KUBECONFIG")
+	}
+	if kubeconfig == "" {
+		glog.Exitf("--dev requires --kubeconfig or $KUBECONFIG")
+	}
+	if !cmd.Flag("resourcelock-namespace").Changed {
+		startOpts.resourceLockNamespace = ctrlcommon.MCONamespace
+	}
+	if !cmd.Flag("templates").Changed {
+		rootOpts.templates = devTemplatesDir
+	}
+	if _, err := os.Stat(rootOpts.templates); err != nil {
+		glog.Exitf("--dev: templates not found, run from the root of the repository or set --templates: %v", err)
+	}
+	glog.Warningf("Running in development mode against %s: scale down the machine-config-operator and machine-config-controller "+
+		"deployments of %s so that this controller gets their lock and they don't revert its changes", kubeconfig, ctrlcommon.MCONamespace)
+	return kubeconfig
+}
+
+// startDevServer serves the configs of the pools on localhost:port like the
+// MachineConfigServer, without TLS and with a kubeconfig without credentials.
+func startDevServer(cb *clients.Builder, kubeconfig string, port int) {
+	cs, err := server.NewDevClusterServer(kubeconfig, cb.GetBuilderConfig().Host)
+	if err != nil {
+		glog.Exitf("Creating the development MachineConfigServer: %v", err)
+	}
+	go server.NewLocalAPIServer(server.NewServerAPIHandler(cs), port).Serve()
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+	"github.com/golang/glog"
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/constants"
+	"github.com/openshift/machine-config-operator/pkg/controller/template"
+)
+
+var (
+	renderCmd = &cobra.Command{
+		Use:   "render",
+		Short: "Renders the templates for a ControllerConfig into MachineConfigs, without a cluster",
+		Long:  "",
+		Args:  cobra.MaximumNArgs(0),
+		Run:   executeRender,
+	}
+
+	renderOpts struct {
+		controllerConfig string
+		pullSecret       string
+		featureGate      string
+		overlays         string
+		zoneVIPs         string
+		pools            []string
+		constants        string
+		out              string
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(renderCmd)
+	renderCmd.PersistentFlags().StringVar(&renderOpts.controllerConfig, "controller-config", "", "Path to the ControllerConfig to render the templates for, in YAML or JSON, e.g. from 'oc get controllerconfig machine-config-controller -o yaml'")
+	renderCmd.PersistentFlags().StringVar(&renderOpts.pullSecret, "pull-secret", "", "Path to the pull secret, in .dockerconfigjson format, defaults to an empty one")
+	renderCmd.PersistentFlags().StringVar(&renderOpts.featureGate, "feature-gate", "", "Path to the cluster FeatureGate, in YAML or JSON")
+	renderCmd.PersistentFlags().StringVar(&renderOpts.overlays, "overlays", "", fmt.Sprintf("Path to the %s ConfigMap, in YAML or JSON", template.TemplateOverlaysConfigMapName))
+	renderCmd.PersistentFlags().StringVar(&renderOpts.zoneVIPs, "zone-vips", "", fmt.Sprintf("Path to the %s ConfigMap, in YAML or JSON", template.ZoneVIPsConfigMapName))
+	renderCmd.PersistentFlags().StringArrayVar(&renderOpts.pools, "pool", nil, "Path to a MachineConfigPool whose template variables to render with, in YAML or JSON, may be repeated")
+	renderCmd.PersistentFlags().StringVar(&renderOpts.constants, "constants", "", "Path to the overrides of the template constants, as a JSON object like the "+template.ConstantsConfigMapKey+" of the images ConfigMap")
+	renderCmd.PersistentFlags().StringVar(&renderOpts.out, "out", "", "The directory to write the rendered MachineConfigs to")
+}
+
+// readManifest unmarshals the YAML or JSON file at path into obj.
+func readManifest(path string, obj interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, obj); err != nil {
+		return errors.Wrapf(err, "failed to parse %s", path)
+	}
+	return nil
+}
+
+func runRender(_ *cobra.Command, _ []string) error {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	if renderOpts.controllerConfig == "" || renderOpts.out == "" {
+		return fmt.Errorf("--controller-config and --out are required")
+	}
+	config := &mcfgv1.ControllerConfig{}
+	if err := readManifest(renderOpts.controllerConfig, config); err != nil {
+		return err
+	}
+
+	pullSecret := []byte("{}")
+	if renderOpts.pullSecret != "" {
+		var err error
+		if pullSecret, err = ioutil.ReadFile(renderOpts.pullSecret); err != nil {
+			return err
+		}
+	}
+
+	var featureGate *configv1.FeatureGate
+	if renderOpts.featureGate != "" {
+		featureGate = &configv1.FeatureGate{}
+		if err := readManifest(renderOpts.featureGate, featureGate); err != nil {
+			return err
+		}
+	}
+
+	var overlays []template.TemplateOverlay
+	if renderOpts.overlays != "" {
+		cm := &corev1.ConfigMap{}
+		if err := readManifest(renderOpts.overlays, cm); err != nil {
+			return err
+		}
+		var err error
+		if overlays, err = template.ParseTemplateOverlays(cm.Data, rootOpts.templates); err != nil {
+			return err
+		}
+	}
+
+	var zoneVIPs []template.ZoneVIPs
+	if renderOpts.zoneVIPs != "" {
+		cm := &corev1.ConfigMap{}
+		if err := readManifest(renderOpts.zoneVIPs, cm); err != nil {
+			return err
+		}
+		var err error
+		if zoneVIPs, err = template.ParseZoneVIPs([]byte(cm.Data[template.ZoneVIPsConfigMapKey])); err != nil {
+			return errors.Wrapf(err, "invalid zone VIPs %s", renderOpts.zoneVIPs)
+		}
+	}
+
+	var pools []*mcfgv1.MachineConfigPool
+	for _, path := range renderOpts.pools {
+		pool := &mcfgv1.MachineConfigPool{}
+		if err := readManifest(path, pool); err != nil {
+			return err
+		}
+		pools = append(pools, pool)
+	}
+
+	if renderOpts.constants != "" {
+		data, err := ioutil.ReadFile(renderOpts.constants)
+		if err != nil {
+			return err
+		}
+		overrides, err := constants.ParseConstantOverrides(data)
+		if err != nil {
+			return errors.Wrapf(err, "invalid constants %s", renderOpts.constants)
+		}
+		constants.SetConstantOverrides(overrides)
+	}
+
+	mcs, err := template.RunRender(rootOpts.templates, config, pullSecret, featureGate, overlays, zoneVIPs, pools)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(renderOpts.out, 0755); err != nil {
+		return err
+	}
+	for _, mc := range mcs {
+		mc.APIVersion = mcfgv1.SchemeGroupVersion.String()
+		mc.Kind = "MachineConfig"
+		data, err := yaml.Marshal(mc)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(renderOpts.out, mc.Name+".yaml")
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+		glog.Infof("Wrote %s", path)
+	}
+	return nil
+}
+
+func executeRender(cmd *cobra.Command, args []string) {
+	err := runRender(cmd, args)
+	if err != nil {
+		fmt.Printf("error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/clients"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/controller/node"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var (
+	simulateRolloutCmd = &cobra.Command{
+		Use:   "simulate-rollout",
+		Short: "Predicts how the target config of a pool is rolled out, without changing anything",
+		Long:  "",
+		Run:   runSimulateRolloutCmd,
+	}
+
+	simulateRolloutOpts struct {
+		kubeconfig         string
+		pool               string
+		maxUnavailable     string
+		nodeUpdateDuration time.Duration
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(simulateRolloutCmd)
+	simulateRolloutCmd.PersistentFlags().StringVar(&simulateRolloutOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access the cluster, defaults to $KUBECONFIG or the in-cluster config")
+	simulateRolloutCmd.PersistentFlags().StringVar(&simulateRolloutOpts.pool, "pool", "worker", "MachineConfigPool to simulate the rollout of")
+	simulateRolloutCmd.PersistentFlags().StringVar(&simulateRolloutOpts.maxUnavailable, "max-unavailable", "", "Disruption budget to simulate with, as a number or percentage, instead of the maxUnavailable of the pool")
+	simulateRolloutCmd.PersistentFlags().DurationVar(&simulateRolloutOpts.nodeUpdateDuration, "node-update-duration", node.DefaultNodeUpdateDuration, "Estimated time to drain, update and reboot a single node")
+}
+
+func runSimulateRolloutCmd(cmd *cobra.Command, args []string) {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	cb, err := clients.NewBuilder(simulateRolloutOpts.kubeconfig)
+	if err != nil {
+		glog.Fatalf("Creating clients: %v", err)
+	}
+	mcfgClient := cb.MachineConfigClientOrDie(componentName)
+	kubeClient := cb.KubeClientOrDie(componentName)
+
+	poolList, err := mcfgClient.MachineconfigurationV1().MachineConfigPools().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		glog.Fatalf("Listing MachineConfigPools: %v", err)
+	}
+	nodeList, err := kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		glog.Fatalf("Listing nodes: %v", err)
+	}
+
+	var maxUnavailable *intstrutil.IntOrString
+	if simulateRolloutOpts.maxUnavailable != "" {
+		v := intstrutil.Parse(simulateRolloutOpts.maxUnavailable)
+		maxUnavailable = &v
+	}
+
+	pools := make([]*mcfgv1.MachineConfigPool, 0, len(poolList.Items))
+	for i := range poolList.Items {
+		pools = append(pools, &poolList.Items[i])
+	}
+	nodes := make([]*corev1.Node, 0, len(nodeList.Items))
+	for i := range nodeList.Items {
+		nodes = append(nodes, &nodeList.Items[i])
+	}
+
+	plan, err := node.SimulateRollout(simulateRolloutOpts.pool, pools, nodes, maxUnavailable, simulateRolloutOpts.nodeUpdateDuration)
+	if err != nil {
+		glog.Fatalf("Simulating rollout: %v", err)
+	}
+	printRolloutPlan(plan)
+}
+
+func printRolloutPlan(plan *node.RolloutPlan) {
+	fmt.Printf("Pool %s targeting %s, maxUnavailable %d\n", plan.Pool, plan.TargetConfig, plan.MaxUnavailable)
+	if plan.Paused {
+		fmt.Printf("The pool is paused, the rollout starts once it's unpaused.\n")
+	}
+	if len(plan.InProgress) > 0 {
+		fmt.Printf("Already updating: %s\n", strings.Join(plan.InProgress, ", "))
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "BATCH\tSTART\tNODES\n")
+	for i, batch := range plan.Batches {
+		fmt.Fprintf(w, "%d\t+%s\t%s\n", i+1, plan.BatchStarts[i], strings.Join(batch, ", "))
+	}
+	w.Flush()
+	if len(plan.Blocked) > 0 {
+		fmt.Printf("Not expected to complete: %s\n", strings.Join(plan.Blocked, ", "))
+	}
+	fmt.Printf("Estimated duration: %s\n", plan.EstimatedDuration)
+}
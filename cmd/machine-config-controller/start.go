@@ -3,20 +3,37 @@ package main
 import (
 	"context"
 	"flag"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/openshift/machine-config-operator/cmd/common"
 	"github.com/openshift/machine-config-operator/internal/clients"
+	"github.com/openshift/machine-config-operator/pkg/controller/bootloader"
+	"github.com/openshift/machine-config-operator/pkg/controller/bootstrapcredentials"
+	"github.com/openshift/machine-config-operator/pkg/controller/butane"
+	"github.com/openshift/machine-config-operator/pkg/controller/cgroupmode"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	containerruntimeconfig "github.com/openshift/machine-config-operator/pkg/controller/container-runtime-config"
+	"github.com/openshift/machine-config-operator/pkg/controller/freeze"
+	"github.com/openshift/machine-config-operator/pkg/controller/hostmtu"
 	kubeletconfig "github.com/openshift/machine-config-operator/pkg/controller/kubelet-config"
+	"github.com/openshift/machine-config-operator/pkg/controller/lowlatency"
+	"github.com/openshift/machine-config-operator/pkg/controller/machineconfigprofile"
+	"github.com/openshift/machine-config-operator/pkg/controller/maintenancetask"
 	"github.com/openshift/machine-config-operator/pkg/controller/node"
 	"github.com/openshift/machine-config-operator/pkg/controller/render"
+	"github.com/openshift/machine-config-operator/pkg/controller/storage"
 	"github.com/openshift/machine-config-operator/pkg/controller/template"
+	"github.com/openshift/machine-config-operator/pkg/controller/updatewave"
+	"github.com/openshift/machine-config-operator/pkg/controller/workloadpartitioning"
 	"github.com/openshift/machine-config-operator/pkg/version"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/leaderelection"
 )
 
@@ -31,8 +48,15 @@ var (
 	startOpts struct {
 		kubeconfig               string
 		templates                string
+		templatesImage           string
 		promMetricsListenAddress string
+		webhookListenAddress     string
+		webhookTLSCert           string
+		webhookTLSKey            string
 		resourceLockNamespace    string
+		dev                      bool
+		devServerPort            int
+		tuning                   ctrlcommon.ControllerTuning
 	}
 )
 
@@ -40,7 +64,19 @@ func init() {
 	rootCmd.AddCommand(startCmd)
 	startCmd.PersistentFlags().StringVar(&startOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access a remote cluster (testing only)")
 	startCmd.PersistentFlags().StringVar(&startOpts.resourceLockNamespace, "resourcelock-namespace", metav1.NamespaceSystem, "Path to the template files used for creating MachineConfig objects")
+	startCmd.PersistentFlags().StringVar(&startOpts.templatesImage, "templates-image", "", "Digest-pinned image or OCI artifact to fetch the template files from instead of --templates")
 	startCmd.PersistentFlags().StringVar(&startOpts.promMetricsListenAddress, "metrics-listen-address", "127.0.0.1:8797", "Listen address for prometheus metrics listener")
+	startCmd.PersistentFlags().StringVar(&startOpts.webhookListenAddress, "webhook-listen-address", ":9443", "Listen address for the MachineConfigFreeze admission webhook, empty to not serve it")
+	startCmd.PersistentFlags().StringVar(&startOpts.webhookTLSCert, "webhook-tls-cert", "/etc/tls/private/tls.crt", "Serving certificate of the MachineConfigFreeze admission webhook")
+	startCmd.PersistentFlags().StringVar(&startOpts.webhookTLSKey, "webhook-tls-key", "/etc/tls/private/tls.key", "Serving key of the MachineConfigFreeze admission webhook")
+	startCmd.PersistentFlags().BoolVar(&startOpts.dev, "dev", false, "Run outside of the cluster against --kubeconfig, taking over from the in-cluster controller (development only)")
+	startCmd.PersistentFlags().IntVar(&startOpts.devServerPort, "dev-server-port", 0, "With --dev, also serve the configs of the pools like the MachineConfigServer on this localhost port, without TLS")
+	defaults := ctrlcommon.DefaultControllerTuning
+	startCmd.PersistentFlags().DurationVar(&startOpts.tuning.ResyncPeriod, "resync-period", defaults.ResyncPeriod, "Minimum period of the informer resyncs, jittered up to twice it")
+	startCmd.PersistentFlags().DurationVar(&startOpts.tuning.QueueBaseDelay, "queue-base-delay", defaults.QueueBaseDelay, "Initial delay of the retries of an object by the controllers")
+	startCmd.PersistentFlags().DurationVar(&startOpts.tuning.QueueMaxDelay, "queue-max-delay", defaults.QueueMaxDelay, "Maximum delay of the retries of an object by the controllers")
+	startCmd.PersistentFlags().IntVar(&startOpts.tuning.QueueQPS, "queue-qps", defaults.QueueQPS, "Objects processed per second by each controller")
+	startCmd.PersistentFlags().IntVar(&startOpts.tuning.QueueBurst, "queue-burst", defaults.QueueBurst, "Objects each controller may process in a burst above --queue-qps")
 }
 
 func runStartCmd(cmd *cobra.Command, args []string) {
@@ -50,21 +86,47 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 	// To help debugging, immediately log version
 	glog.Infof("Version: %+v (%s)", version.Raw, version.Hash)
 
-	cb, err := clients.NewBuilder(startOpts.kubeconfig)
+	if err := ctrlcommon.SetControllerTuning(startOpts.tuning); err != nil {
+		glog.Exitf("Invalid controller tuning: %v", err)
+	}
+
+	kubeconfig := startOpts.kubeconfig
+	if startOpts.dev {
+		kubeconfig = setupDevMode(cmd)
+	} else if startOpts.devServerPort != 0 {
+		glog.Exitf("--dev-server-port requires --dev")
+	}
+
+	cb, err := clients.NewBuilder(kubeconfig)
 	if err != nil {
 		ctrlcommon.WriteTerminationError(errors.Wrapf(err, "Creating clients"))
 	}
+	if startOpts.devServerPort != 0 {
+		startDevServer(cb, kubeconfig, startOpts.devServerPort)
+	}
+	// The apiserver calls the webhook through the service, which selects
+	// every replica, so it's served regardless of leader election.  With
+	// --dev the in-cluster controller keeps serving it.
+	if !startOpts.dev && startOpts.webhookListenAddress != "" {
+		go freeze.StartWebhook(startOpts.webhookListenAddress, startOpts.webhookTLSCert, startOpts.webhookTLSKey)
+	}
 	run := func(ctx context.Context) {
 		ctrlctx := ctrlcommon.CreateControllerContext(cb, ctx.Done(), componentName)
 
 		// Start the metrics handler
 		go ctrlcommon.StartMetricsListener(startOpts.promMetricsListenAddress, ctrlctx.Stop)
 
-		controllers := createControllers(ctrlctx)
+		templatesDir := rootOpts.templates
+		if startOpts.templatesImage != "" {
+			templatesDir = fetchTemplates(ctx, ctrlctx, startOpts.templatesImage, rootOpts.templates)
+		}
+
+		controllers := createControllers(ctrlctx, templatesDir)
 
 		// Start the shared factory informers that you need to use in your controller
 		ctrlctx.InformerFactory.Start(ctrlctx.Stop)
 		ctrlctx.KubeInformerFactory.Start(ctrlctx.Stop)
+		ctrlctx.KubeMCONamespacedInformerFactory.Start(ctrlctx.Stop)
 		ctrlctx.OpenShiftConfigKubeNamespacedInformerFactory.Start(ctrlctx.Stop)
 		ctrlctx.ConfigInformerFactory.Start(ctrlctx.Stop)
 		ctrlctx.OperatorInformerFactory.Start(ctrlctx.Stop)
@@ -95,33 +157,75 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 	panic("unreachable")
 }
 
-func createControllers(ctx *ctrlcommon.ControllerContext) []ctrlcommon.Controller {
+// fetchTemplatesBackoff retries fetching the templates image for about ten
+// minutes before giving up.
+var fetchTemplatesBackoff = wait.Backoff{
+	Duration: 5 * time.Second,
+	Factor:   2,
+	Cap:      2 * time.Minute,
+	Steps:    10,
+}
+
+// fetchTemplates returns the directory of the template tree of image.  The
+// baked-in templates aren't a safe fallback, as rendering them would revert
+// the fixes the image carries, so the controller exits if the image can't be
+// fetched, and the operator reports Degraded as it doesn't become available.
+func fetchTemplates(ctx context.Context, ctrlctx *ctrlcommon.ControllerContext, image, baked string) string {
+	var dir string
+	var lastErr error
+	err := wait.ExponentialBackoff(fetchTemplatesBackoff, func() (bool, error) {
+		dir, lastErr = fetchTemplatesImage(ctx, ctrlctx, image, baked)
+		if lastErr != nil {
+			glog.Warningf("Could not fetch templates image %s, retrying: %v", image, lastErr)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		ctrlcommon.WriteTerminationError(errors.Wrapf(lastErr, "fetching templates image %s", image))
+	}
+	return dir
+}
+
+func fetchTemplatesImage(ctx context.Context, ctrlctx *ctrlcommon.ControllerContext, image, baked string) (string, error) {
+	kubeClient := ctrlctx.ClientBuilder.KubeClientOrDie("template-controller")
+	secret, err := kubeClient.CoreV1().Secrets("openshift-config").Get(ctx, "pull-secret", metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "getting the pull secret")
+	}
+	return template.FetchTemplatesImageWithPullSecret(ctx, image, secret.Data[corev1.DockerConfigJsonKey], filepath.Join(os.TempDir(), "mcc-templates"), baked)
+}
+
+func createControllers(ctx *ctrlcommon.ControllerContext, templatesDir string) []ctrlcommon.Controller {
 	var controllers []ctrlcommon.Controller
 
 	controllers = append(controllers,
 		// Our primary MCs come from here
 		template.New(
-			rootOpts.templates,
+			templatesDir,
 			ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs(),
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
 			ctx.OpenShiftConfigKubeNamespacedInformerFactory.Core().V1().Secrets(),
 			ctx.ConfigInformerFactory.Config().V1().FeatureGates(),
+			ctx.KubeMCONamespacedInformerFactory.Core().V1().ConfigMaps(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
 			ctx.ClientBuilder.KubeClientOrDie("template-controller"),
 			ctx.ClientBuilder.MachineConfigClientOrDie("template-controller"),
 		),
 		// Add all "sub-renderers here"
 		kubeletconfig.New(
-			rootOpts.templates,
+			templatesDir,
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
 			ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs(),
 			ctx.InformerFactory.Machineconfiguration().V1().KubeletConfigs(),
 			ctx.ConfigInformerFactory.Config().V1().FeatureGates(),
 			ctx.ConfigInformerFactory.Config().V1().APIServers(),
+			ctx.KubeInformerFactory.Core().V1().Nodes(),
 			ctx.ClientBuilder.KubeClientOrDie("kubelet-config-controller"),
 			ctx.ClientBuilder.MachineConfigClientOrDie("kubelet-config-controller"),
 		),
 		containerruntimeconfig.New(
-			rootOpts.templates,
+			templatesDir,
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
 			ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs(),
 			ctx.InformerFactory.Machineconfiguration().V1().ContainerRuntimeConfigs(),
@@ -132,6 +236,79 @@ func createControllers(ctx *ctrlcommon.ControllerContext) []ctrlcommon.Controlle
 			ctx.ClientBuilder.MachineConfigClientOrDie("container-runtime-config-controller"),
 			ctx.ClientBuilder.ConfigClientOrDie("container-runtime-config-controller"),
 		),
+		butane.New(
+			ctx.KubeMCONamespacedInformerFactory.Core().V1().ConfigMaps(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
+			ctx.ClientBuilder.KubeClientOrDie("butane-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("butane-controller"),
+		),
+		storage.New(
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
+			ctx.ClientBuilder.KubeClientOrDie("storage-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("storage-controller"),
+		),
+		hostmtu.New(
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
+			ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs(),
+			ctx.ClientBuilder.KubeClientOrDie("host-mtu-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("host-mtu-controller"),
+		),
+		workloadpartitioning.New(
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
+			ctx.InformerFactory.Machineconfiguration().V1().KubeletConfigs(),
+			ctx.ClientBuilder.KubeClientOrDie("workload-partitioning-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("workload-partitioning-controller"),
+		),
+		lowlatency.New(
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
+			ctx.InformerFactory.Machineconfiguration().V1().KubeletConfigs(),
+			ctx.ClientBuilder.KubeClientOrDie("low-latency-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("low-latency-controller"),
+		),
+		cgroupmode.New(
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
+			ctx.KubeInformerFactory.Core().V1().Nodes(),
+			ctx.ClientBuilder.KubeClientOrDie("cgroup-mode-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("cgroup-mode-controller"),
+		),
+		bootloader.New(
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
+			ctx.KubeMCONamespacedInformerFactory.Core().V1().Secrets(),
+			ctx.ClientBuilder.KubeClientOrDie("bootloader-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("bootloader-controller"),
+		),
+		bootstrapcredentials.New(
+			ctx.KubeMCONamespacedInformerFactory.Core().V1().Secrets(),
+			ctx.KubeMCONamespacedInformerFactory.Core().V1().ConfigMaps(),
+			ctx.ClientBuilder.KubeClientOrDie("bootstrap-credentials-controller"),
+		),
+		maintenancetask.New(
+			ctx.InformerFactory.Machineconfiguration().V1().MaintenanceTasks(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
+			ctx.ClientBuilder.KubeClientOrDie("maintenance-task-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("maintenance-task-controller"),
+		),
+		machineconfigprofile.New(
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigProfiles(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
+			ctx.InformerFactory.Machineconfiguration().V1().KubeletConfigs(),
+			ctx.ClientBuilder.KubeClientOrDie("machine-config-profile-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("machine-config-profile-controller"),
+		),
+		updatewave.New(
+			ctx.InformerFactory.Machineconfiguration().V1().UpdateWaves(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.ClientBuilder.KubeClientOrDie("update-wave-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("update-wave-controller"),
+		),
 		// The renderer creates "rendered" MCs from the MC fragments generated by
 		// the above sub-controllers, which are then consumed by the node controller
 		render.New(
@@ -147,6 +324,7 @@ func createControllers(ctx *ctrlcommon.ControllerContext) []ctrlcommon.Controlle
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
 			ctx.KubeInformerFactory.Core().V1().Nodes(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigFreezes(),
 			ctx.ConfigInformerFactory.Config().V1().Schedulers(),
 			ctx.ClientBuilder.KubeClientOrDie("node-update-controller"),
 			ctx.ClientBuilder.MachineConfigClientOrDie("node-update-controller"),
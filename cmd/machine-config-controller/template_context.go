@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/pkg/controller/template"
+	"github.com/spf13/cobra"
+)
+
+var (
+	templateContextCmd = &cobra.Command{
+		Use:   "template-context",
+		Short: "Prints the fields and functions available to the templates",
+		Long:  "",
+		Run:   runTemplateContextCmd,
+	}
+
+	templateContextOpts struct {
+		schema bool
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(templateContextCmd)
+	templateContextCmd.PersistentFlags().BoolVar(&templateContextOpts.schema, "schema", false, "Print a JSON schema of the template context, e.g. for editor tooling, instead of a listing")
+}
+
+func runTemplateContextCmd(cmd *cobra.Command, args []string) {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	schema := template.NewContextSchema()
+	if templateContextOpts.schema {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(schema); err != nil {
+			glog.Fatalf("Encoding the template context schema: %v", err)
+		}
+		return
+	}
+	printTemplateContext(schema)
+}
+
+func printTemplateContext(schema *template.ContextSchema) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "FIELD\tTYPE\n")
+	printContextFields(w, "", schema.Fields)
+	w.Flush()
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "FUNCTION\tPARAMETERS\tRESULT\n")
+	for _, f := range schema.Functions {
+		params := f.Params
+		if f.Variadic && len(params) > 0 {
+			params = append(params[:len(params)-1:len(params)-1], "..."+strings.TrimPrefix(params[len(params)-1], "[]"))
+		}
+		result := f.Result
+		if f.Fails {
+			result = fmt.Sprintf("(%s, error)", result)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", f.Name, strings.Join(params, ", "), result)
+	}
+	w.Flush()
+	fmt.Printf("\nThe sprig functions are available too: %s\n", strings.Join(schema.SprigFunctions, ", "))
+}
+
+func printContextFields(w *tabwriter.Writer, prefix string, fields []template.ContextField) {
+	for _, f := range fields {
+		path := prefix + "." + f.Name
+		fmt.Fprintf(w, "%s\t%s\n", path, f.Type)
+		printContextFields(w, path, f.Fields)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemon "github.com/openshift/machine-config-operator/pkg/daemon"
+	errors "github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var offlineManifestCmd = &cobra.Command{
+	Use:                   "offline-manifest",
+	DisableFlagsInUseLine: true,
+	Short:                 "List the images and binaries a rendered MachineConfig needs, for mirroring into air-gapped clusters",
+	Args:                  cobra.MaximumNArgs(0),
+	Run:                   executeOfflineManifest,
+}
+
+var offlineManifestOpts struct {
+	machineConfig  string
+	dest           string
+	requireDigests bool
+}
+
+// init executes upon import
+func init() {
+	rootCmd.AddCommand(offlineManifestCmd)
+	offlineManifestCmd.PersistentFlags().StringVar(&offlineManifestOpts.machineConfig, "machineconfig", "", "Path to the rendered MachineConfig, in YAML or JSON, e.g. from 'oc get mc rendered-worker-<hash> -o yaml'")
+	offlineManifestCmd.PersistentFlags().StringVar(&offlineManifestOpts.dest, "dest", "", "Path to write the manifest to, defaults to stdout")
+	offlineManifestCmd.PersistentFlags().BoolVar(&offlineManifestOpts.requireDigests, "require-digests", false, "Fail if any image is not referenced by digest")
+	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
+}
+
+func runOfflineManifest(_ *cobra.Command, _ []string) error {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	if offlineManifestOpts.machineConfig == "" {
+		return fmt.Errorf("--machineconfig is required")
+	}
+	data, err := ioutil.ReadFile(offlineManifestOpts.machineConfig)
+	if err != nil {
+		return err
+	}
+	var mc mcfgv1.MachineConfig
+	if err := yaml.Unmarshal(data, &mc); err != nil {
+		return errors.Wrapf(err, "failed to parse MachineConfig")
+	}
+
+	manifest, err := daemon.BuildOfflineManifest(&mc)
+	if err != nil {
+		return errors.Wrapf(err, "failed to build offline manifest of MachineConfig %s", mc.Name)
+	}
+	if unpinned := manifest.Unpinned(); offlineManifestOpts.requireDigests && len(unpinned) > 0 {
+		return fmt.Errorf("images not referenced by digest: %s", strings.Join(unpinned, ", "))
+	}
+	out, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if offlineManifestOpts.dest == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	return ioutil.WriteFile(offlineManifestOpts.dest, out, 0644)
+}
+
+func executeOfflineManifest(cmd *cobra.Command, args []string) {
+	err := runOfflineManifest(cmd, args)
+	if err != nil {
+		fmt.Printf("error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ghodss/yaml"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemon "github.com/openshift/machine-config-operator/pkg/daemon"
+	errors "github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var renderContainerfileCmd = &cobra.Command{
+	Use:                   "render-containerfile",
+	DisableFlagsInUseLine: true,
+	Short:                 "Render a MachineConfig into a config layer Containerfile for image mode hosts",
+	Args:                  cobra.MaximumNArgs(0),
+	Run:                   executeRenderContainerfile,
+}
+
+var renderContainerfileOpts struct {
+	machineConfig string
+	baseImage     string
+	dest          string
+}
+
+// init executes upon import
+func init() {
+	rootCmd.AddCommand(renderContainerfileCmd)
+	renderContainerfileCmd.PersistentFlags().StringVar(&renderContainerfileOpts.machineConfig, "machineconfig", "", "Path to the (rendered) MachineConfig to render, in YAML or JSON, e.g. from 'oc get mc rendered-worker-<hash> -o yaml'")
+	renderContainerfileCmd.PersistentFlags().StringVar(&renderContainerfileOpts.baseImage, "base-image", "", "Image to layer the config on, defaults to the osImageURL of the MachineConfig")
+	renderContainerfileCmd.PersistentFlags().StringVar(&renderContainerfileOpts.dest, "dest", "", "Path to write the Containerfile to, defaults to stdout")
+	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
+}
+
+func runRenderContainerfile(_ *cobra.Command, _ []string) error {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	if renderContainerfileOpts.machineConfig == "" {
+		return fmt.Errorf("--machineconfig is required")
+	}
+	data, err := ioutil.ReadFile(renderContainerfileOpts.machineConfig)
+	if err != nil {
+		return err
+	}
+	var mc mcfgv1.MachineConfig
+	if err := yaml.Unmarshal(data, &mc); err != nil {
+		return errors.Wrapf(err, "failed to parse MachineConfig")
+	}
+
+	containerfile, err := daemon.RenderContainerfile(&mc, renderContainerfileOpts.baseImage)
+	if err != nil {
+		return errors.Wrapf(err, "failed to render MachineConfig %s", mc.Name)
+	}
+	if renderContainerfileOpts.dest == "" {
+		_, err = os.Stdout.Write(containerfile)
+		return err
+	}
+	return ioutil.WriteFile(renderContainerfileOpts.dest, containerfile, 0644)
+}
+
+func executeRenderContainerfile(cmd *cobra.Command, args []string) {
+	err := runRenderContainerfile(cmd, args)
+	if err != nil {
+		fmt.Printf("error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/clients"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemon "github.com/openshift/machine-config-operator/pkg/daemon"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	errors "github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var reprovisionCmd = &cobra.Command{
+	Use:                   "reprovision",
+	DisableFlagsInUseLine: true,
+	Short:                 "Re-apply the files, units, SSH keys and kernel arguments of a rendered MachineConfig to the node from scratch",
+	Args:                  cobra.MaximumNArgs(0),
+	Run:                   executeReprovision,
+}
+
+var reprovisionOpts struct {
+	config     string
+	kubeconfig string
+	nodeName   string
+	rootMount  string
+	skipReboot bool
+}
+
+// init executes upon import
+func init() {
+	rootCmd.AddCommand(reprovisionCmd)
+	reprovisionCmd.PersistentFlags().StringVar(&reprovisionOpts.config, "config", "", "Path or http(s) URL of the rendered MachineConfig, in YAML or JSON. Defaults to the config the daemon last applied")
+	reprovisionCmd.PersistentFlags().StringVar(&reprovisionOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to read the node's current config with when --config is passed. Defaults to $KUBECONFIG, then the in-cluster config")
+	reprovisionCmd.PersistentFlags().StringVar(&reprovisionOpts.nodeName, "node-name", "", "Name of the node, to check --config against its current config. Defaults to $NODE_NAME")
+	reprovisionCmd.PersistentFlags().StringVar(&reprovisionOpts.rootMount, "root-mount", "/", "Where the node's root filesystem is mounted, e.g. /rootfs in the machine-config-daemon pod")
+	reprovisionCmd.PersistentFlags().BoolVar(&reprovisionOpts.skipReboot, "skip-reboot", false, "Skips the reboot after reprovisioning")
+	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
+}
+
+func runReprovision(_ *cobra.Command, _ []string) error {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	// the config is read before chrooting, so its path is relative to where the command runs
+	var mc *mcfgv1.MachineConfig
+	if reprovisionOpts.config != "" {
+		var err error
+		if mc, err = daemon.LoadMachineConfig(reprovisionOpts.config); err != nil {
+			return errors.Wrapf(err, "failed to load MachineConfig")
+		}
+		if err := checkNodeCurrentConfig(mc); err != nil {
+			return err
+		}
+	}
+
+	if reprovisionOpts.rootMount != "/" {
+		glog.Infof(`Calling chroot("%s")`, reprovisionOpts.rootMount)
+		if err := syscall.Chroot(reprovisionOpts.rootMount); err != nil {
+			return errors.Wrapf(err, "unable to chroot to %s", reprovisionOpts.rootMount)
+		}
+		if err := os.Chdir("/"); err != nil {
+			return errors.Wrapf(err, "unable to change directory to /")
+		}
+	}
+
+	exitCh := make(chan error)
+	defer close(exitCh)
+
+	dn, err := daemon.New(daemon.NewNodeUpdaterClient(), exitCh)
+	if err != nil {
+		return err
+	}
+
+	return dn.RunReprovision(mc, reprovisionOpts.skipReboot)
+}
+
+// checkNodeCurrentConfig checks that a config passed to reprovision is the
+// current config of the node: reprovisioning stores it as the current config
+// on disk, which would otherwise no longer match the node's annotation.
+func checkNodeCurrentConfig(mc *mcfgv1.MachineConfig) error {
+	nodeName := reprovisionOpts.nodeName
+	if nodeName == "" {
+		nodeName = os.Getenv("NODE_NAME")
+	}
+	if nodeName == "" {
+		return fmt.Errorf("--node-name or NODE_NAME is required with --config, to check the config against the current config of the node")
+	}
+
+	cb, err := clients.NewBuilder(reprovisionOpts.kubeconfig)
+	if err != nil {
+		return errors.Wrapf(err, "failed to initialize ClientBuilder")
+	}
+	kubeClient, err := cb.KubeClient(componentName)
+	if err != nil {
+		return errors.Wrapf(err, "cannot initialize kubeClient")
+	}
+	node, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get node %s", nodeName)
+	}
+
+	current := node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey]
+	if mc.GetName() != current {
+		return fmt.Errorf("config %s is not the current config %q of node %s; reprovision only repairs a node in its current config, update the node to another config through its pool", mc.GetName(), current, nodeName)
+	}
+	return nil
+}
+
+func executeReprovision(cmd *cobra.Command, args []string) {
+	err := runReprovision(cmd, args)
+	if err != nil {
+		fmt.Printf("error: %v\n", err)
+		os.Exit(1)
+	}
+}
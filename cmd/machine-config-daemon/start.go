@@ -9,6 +9,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/google/renameio"
 	"k8s.io/client-go/tools/clientcmd"
@@ -41,6 +42,12 @@ var (
 		kubeletHealthzEnabled  bool
 		kubeletHealthzEndpoint string
 		promMetricsURL         string
+		healthURL              string
+		transientErrorRetries  int
+		transientErrorBackoff  time.Duration
+		imagePullAuthFile      string
+		podmanURL              string
+		driftAttribution       string
 	}
 )
 
@@ -54,6 +61,12 @@ func init() {
 	startCmd.PersistentFlags().BoolVar(&startOpts.kubeletHealthzEnabled, "kubelet-healthz-enabled", true, "kubelet healthz endpoint monitoring")
 	startCmd.PersistentFlags().StringVar(&startOpts.kubeletHealthzEndpoint, "kubelet-healthz-endpoint", "http://localhost:10248/healthz", "healthz endpoint to check health")
 	startCmd.PersistentFlags().StringVar(&startOpts.promMetricsURL, "metrics-url", "127.0.0.1:8797", "URL for prometheus metrics listener")
+	startCmd.PersistentFlags().StringVar(&startOpts.healthURL, "health-url", daemon.DefaultHealthBindAddress, "URL for the listener serving the daemon's state as JSON on /health, e.g. 0.0.0.0:8798 to expose it on the node")
+	startCmd.PersistentFlags().IntVar(&startOpts.transientErrorRetries, "transient-error-retries", daemon.DefaultRetryPolicy().Budget, "Number of times a transient sync error is retried before marking the node Degraded")
+	startCmd.PersistentFlags().DurationVar(&startOpts.transientErrorBackoff, "transient-error-backoff", daemon.DefaultRetryPolicy().InitialBackoff, "Initial delay before retrying a transient sync error, doubled on every attempt")
+	startCmd.PersistentFlags().StringVar(&startOpts.imagePullAuthFile, "image-pull-auth-file", daemon.DefaultImageRuntimeOptions().AuthFile, "Registry auth file used when pulling the OS image")
+	startCmd.PersistentFlags().StringVar(&startOpts.podmanURL, "podman-url", "", "URL of the podman service used for the daemon's own image operations, e.g. unix:///run/podman/podman.sock. Defaults to running podman locally")
+	startCmd.PersistentFlags().StringVar(&startOpts.driftAttribution, "drift-attribution", daemon.DriftAttributionNone, "How to find the process which modified a file when config drift is detected: none, fanotify or auditd (requires auditd running on the node)")
 }
 
 // bindPodMounts ensures that the daemon can still see e.g. /run/secrets/kubernetes.io
@@ -130,6 +143,12 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 		glog.Fatalf("Unable to change directory to /: %s", err)
 	}
 
+	// Fail fast with what to fix on nodes the daemon can't manage, rather
+	// than on their first update.
+	if err := daemon.CheckPrerequisites().Err(); err != nil {
+		ctrlcommon.WriteTerminationError(err)
+	}
+
 	if startOpts.nodeName == "" {
 		name, ok := os.LookupEnv("NODE_NAME")
 		if !ok || name == "" {
@@ -138,6 +157,17 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 		startOpts.nodeName = name
 	}
 
+	if err := daemon.SetImageRuntimeOptions(daemon.ImageRuntimeOptions{
+		AuthFile:  startOpts.imagePullAuthFile,
+		PodmanURL: startOpts.podmanURL,
+	}); err != nil {
+		glog.Fatalf("Invalid image runtime options: %v", err)
+	}
+
+	if err := daemon.SetDriftAttributionMode(startOpts.driftAttribution); err != nil {
+		glog.Fatalf("Invalid drift attribution: %v", err)
+	}
+
 	// This channel is used to signal Run() something failed and to jump ship.
 	// It's purely a chan<- in the Daemon struct for goroutines to write to, and
 	// a <-chan in Run() for the main thread to listen on.
@@ -204,6 +234,8 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 
 	// Start local metrics listener
 	go daemon.StartMetricsListener(startOpts.promMetricsURL, stopCh)
+	// Start local health listener
+	go daemon.StartHealthListener(startOpts.healthURL, stopCh)
 
 	ctx := ctrlcommon.CreateControllerContext(cb, stopCh, componentName)
 	// create the daemon instance. this also initializes kube client items
@@ -211,10 +243,16 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 	dn.ClusterConnect(
 		startOpts.nodeName,
 		kubeClient,
+		cb.MachineConfigClientOrDie(componentName),
 		ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
 		ctx.KubeInformerFactory.Core().V1().Nodes(),
 		startOpts.kubeletHealthzEnabled,
 		startOpts.kubeletHealthzEndpoint,
+		daemon.RetryPolicy{
+			Budget:         startOpts.transientErrorRetries,
+			InitialBackoff: startOpts.transientErrorBackoff,
+			MaxBackoff:     daemon.DefaultRetryPolicy().MaxBackoff,
+		},
 	)
 
 	ctx.KubeInformerFactory.Start(stopCh)
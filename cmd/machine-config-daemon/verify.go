@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ghodss/yaml"
+	daemon "github.com/openshift/machine-config-operator/pkg/daemon"
+	errors "github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:                   "verify",
+	DisableFlagsInUseLine: true,
+	Short:                 "Audit the files, units, kernel arguments and OS image of the node against a rendered MachineConfig",
+	Args:                  cobra.MaximumNArgs(0),
+	Run:                   executeVerify,
+}
+
+var verifyOpts struct {
+	config string
+	root   string
+	output string
+}
+
+// verifyNonCompliantExitCode is the exit code when the node drifted from the config,
+// to tell it apart from failing to run the audit.
+const verifyNonCompliantExitCode = 2
+
+// init executes upon import
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.PersistentFlags().StringVar(&verifyOpts.config, "config", "", "Path or http(s) URL of the rendered MachineConfig, in YAML or JSON")
+	verifyCmd.PersistentFlags().StringVar(&verifyOpts.root, "root", "/", "Path the root filesystem of the node is mounted at, e.g. /host in a debug pod")
+	verifyCmd.PersistentFlags().StringVar(&verifyOpts.output, "output", "json", "Format of the report, json or yaml")
+	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
+}
+
+func runVerify(_ *cobra.Command, _ []string) (*daemon.VerifyReport, error) {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	if verifyOpts.config == "" {
+		return nil, fmt.Errorf("--config is required")
+	}
+	if verifyOpts.output != "json" && verifyOpts.output != "yaml" {
+		return nil, fmt.Errorf("unsupported --output %q, must be json or yaml", verifyOpts.output)
+	}
+	mc, err := daemon.LoadMachineConfig(verifyOpts.config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load MachineConfig")
+	}
+
+	report, err := daemon.VerifyNode(mc, daemon.VerifyOptions{Root: verifyOpts.root})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to verify MachineConfig %s", mc.Name)
+	}
+	var out []byte
+	if verifyOpts.output == "yaml" {
+		out, err = yaml.Marshal(report)
+	} else {
+		out, err = json.MarshalIndent(report, "", "  ")
+		out = append(out, '\n')
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = os.Stdout.Write(out)
+	return report, err
+}
+
+func executeVerify(cmd *cobra.Command, args []string) {
+	report, err := runVerify(cmd, args)
+	if err != nil {
+		fmt.Printf("error: %v\n", err)
+		os.Exit(1)
+	}
+	if !report.Compliant {
+		os.Exit(verifyNonCompliantExitCode)
+	}
+}
@@ -56,6 +56,7 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 			ctrlcommon.MCONamespace, componentName,
 			startOpts.imagesFile,
 			ctrlctx.NamespacedInformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctrlctx.NamespacedInformerFactory.Machineconfiguration().V1().MachineConfigFreezes(),
 			ctrlctx.NamespacedInformerFactory.Machineconfiguration().V1().MachineConfigs(),
 			ctrlctx.NamespacedInformerFactory.Machineconfiguration().V1().ControllerConfigs(),
 			ctrlctx.KubeNamespacedInformerFactory.Core().V1().ServiceAccounts(),
@@ -70,6 +71,7 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 			ctrlctx.ConfigInformerFactory.Config().V1().Networks(),
 			ctrlctx.ConfigInformerFactory.Config().V1().Proxies(),
 			ctrlctx.ConfigInformerFactory.Config().V1().DNSes(),
+			ctrlctx.ConfigInformerFactory.Config().V1().Images(),
 			ctrlctx.ClientBuilder.MachineConfigClientOrDie(componentName),
 			ctrlctx.ClientBuilder.KubeClientOrDie(componentName),
 			ctrlctx.ClientBuilder.APIExtClientOrDie(componentName),
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/clients"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/controller/node"
+	"github.com/openshift/machine-config-operator/pkg/server"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	nodeConfigCmd = &cobra.Command{
+		Use:   "node-config",
+		Short: "Prints the Ignition config a node should have, as served to provision it",
+		Long:  "",
+		Run:   runNodeConfigCmd,
+	}
+
+	nodeConfigOpts struct {
+		kubeconfig string
+		node       string
+		output     string
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(nodeConfigCmd)
+	nodeConfigCmd.PersistentFlags().StringVar(&nodeConfigOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access the cluster, defaults to $KUBECONFIG or the in-cluster config")
+	nodeConfigCmd.PersistentFlags().StringVar(&nodeConfigOpts.node, "node", "", "Node to print the config of")
+	nodeConfigCmd.PersistentFlags().StringVarP(&nodeConfigOpts.output, "output", "o", "ignition", "Output format: ignition for the Ignition config, json for the config with its sources, or summary")
+}
+
+func runNodeConfigCmd(cmd *cobra.Command, args []string) {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	if nodeConfigOpts.node == "" {
+		glog.Exitf("--node cannot be empty")
+	}
+
+	cb, err := clients.NewBuilder(nodeConfigOpts.kubeconfig)
+	if err != nil {
+		glog.Fatalf("Creating clients: %v", err)
+	}
+	mcfgClient := cb.MachineConfigClientOrDie(componentName)
+	kubeClient := cb.KubeClientOrDie(componentName)
+
+	n, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), nodeConfigOpts.node, metav1.GetOptions{})
+	if err != nil {
+		glog.Fatalf("Getting node: %v", err)
+	}
+	poolList, err := mcfgClient.MachineconfigurationV1().MachineConfigPools().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		glog.Fatalf("Listing MachineConfigPools: %v", err)
+	}
+	pools := make([]*mcfgv1.MachineConfigPool, 0, len(poolList.Items))
+	for i := range poolList.Items {
+		pools = append(pools, &poolList.Items[i])
+	}
+	pool, err := node.GetPrimaryPoolForNode(pools, n)
+	if err != nil {
+		glog.Fatalf("Getting the pool of node %s: %v", n.Name, err)
+	}
+	if pool == nil {
+		glog.Fatalf("Node %s isn't managed by any MachineConfigPool", n.Name)
+	}
+
+	nc, err := server.GetNodeConfig(n, pool, func(name string) (*mcfgv1.MachineConfig, error) {
+		return mcfgClient.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), name, metav1.GetOptions{})
+	})
+	if err != nil {
+		glog.Fatalf("Getting the config of node %s: %v", n.Name, err)
+	}
+
+	switch nodeConfigOpts.output {
+	case "ignition":
+		printJSON(nc.Ignition)
+	case "json":
+		printJSON(nc)
+	case "summary":
+		printNodeConfigSummary(nc)
+	default:
+		glog.Exitf("Unknown output format %q", nodeConfigOpts.output)
+	}
+}
+
+func printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		glog.Fatalf("Marshalling config: %v", err)
+	}
+	fmt.Println(string(data))
+}
+
+func printNodeConfigSummary(nc *server.NodeConfig) {
+	fmt.Printf("Node %s (%s) in pool %s\n", nc.Node, nc.Architecture, nc.Pool)
+	fmt.Printf("Config: %s\n", nc.MachineConfig)
+	if nc.CurrentMachineConfig != nc.MachineConfig {
+		fmt.Printf("Currently in: %s\n", nc.CurrentMachineConfig)
+	}
+	if len(nc.Sources) == 0 {
+		fmt.Printf("The pool no longer lists the sources of %s\n", nc.MachineConfig)
+	} else {
+		fmt.Printf("Sources: %s\n", strings.Join(nc.Sources, ", "))
+	}
+	fmt.Printf("Kernel arguments: %s\n", strings.Join(nc.KernelArguments, " "))
+	fmt.Printf("Extensions: %s\n", strings.Join(nc.Extensions, ", "))
+	fmt.Printf("Kernel type: %s, FIPS: %t\n", nc.KernelType, nc.FIPS)
+
+	var entries []string
+	for _, f := range nc.Ignition.Storage.Files {
+		entries = append(entries, "file\t"+f.Path+"\t"+nc.Origins[f.Path])
+	}
+	for _, u := range nc.Ignition.Systemd.Units {
+		entries = append(entries, "unit\t"+u.Name+"\t"+nc.Origins[u.Name])
+	}
+	sort.Strings(entries)
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "KIND\tNAME\tSOURCE\n")
+	for _, e := range entries {
+		fmt.Fprintln(w, e)
+	}
+	w.Flush()
+}
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/golang/glog"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
@@ -19,8 +24,10 @@ var (
 	}
 
 	startOpts struct {
-		kubeconfig   string
-		apiserverURL string
+		kubeconfig      string
+		apiserverURL    string
+		shutdownDelay   time.Duration
+		shutdownTimeout time.Duration
 	}
 )
 
@@ -28,6 +35,8 @@ func init() {
 	rootCmd.AddCommand(startCmd)
 	startCmd.PersistentFlags().StringVar(&startOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access a remote cluster (testing only)")
 	startCmd.PersistentFlags().StringVar(&startOpts.apiserverURL, "apiserver-url", "", "URL for apiserver; Used to generate kubeconfig")
+	startCmd.PersistentFlags().DurationVar(&startOpts.shutdownDelay, "shutdown-delay", 30*time.Second, "How long to keep serving with a failing /healthz after SIGTERM, for the load balancers to stop sending connections")
+	startCmd.PersistentFlags().DurationVar(&startOpts.shutdownTimeout, "shutdown-timeout", 30*time.Second, "How long to wait for the requests in flight to complete after the shutdown delay")
 }
 
 func runStartCmd(cmd *cobra.Command, args []string) {
@@ -50,9 +59,39 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 	secureServer := server.NewAPIServer(apiHandler, rootOpts.sport, false, rootOpts.cert, rootOpts.key)
 	insecureServer := server.NewAPIServer(apiHandler, rootOpts.isport, true, "", "")
 
-	stopCh := make(chan struct{})
 	go secureServer.Serve()
 	go insecureServer.Serve()
-	<-stopCh
-	panic("not possible")
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, os.Interrupt)
+	sig := <-sigCh
+	shutdown(sig, secureServer, insecureServer)
+}
+
+// shutdown takes the servers out of rotation and stops them once the
+// requests in flight are served, so that machines fetching their config
+// while the server is rolled out don't get their connection reset:
+// /healthz fails for the shutdown delay, which the readiness probe and the
+// load balancer health checks pick up, and then the servers stop accepting
+// connections and finish the requests in flight.
+func shutdown(sig os.Signal, servers ...*server.APIServer) {
+	glog.Infof("Got %v, draining for %v before shutting down", sig, startOpts.shutdownDelay)
+	for _, s := range servers {
+		s.Drain()
+	}
+	time.Sleep(startOpts.shutdownDelay)
+
+	glog.Infof("Shutting down, waiting up to %v for the requests in flight", startOpts.shutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), startOpts.shutdownTimeout)
+	defer cancel()
+	errs := make(chan error, len(servers))
+	for _, s := range servers {
+		go func(s *server.APIServer) { errs <- s.Shutdown(ctx) }(s)
+	}
+	for range servers {
+		if err := <-errs; err != nil {
+			glog.Warningf("Requests in flight not completed: %v", err)
+		}
+	}
+	glog.Info("Machine Config Server stopped")
 }
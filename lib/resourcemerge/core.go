@@ -81,7 +81,7 @@ func ensurePodSpec(modified *bool, existing *corev1.PodSpec, required corev1.Pod
 
 	setStringIfSet(modified, &existing.ServiceAccountName, required.ServiceAccountName)
 	setBool(modified, &existing.HostNetwork, required.HostNetwork)
-	mergeMap(modified, &existing.NodeSelector, required.NodeSelector)
+	setStringMap(modified, &existing.NodeSelector, required.NodeSelector)
 	ensurePodSecurityContextPtr(modified, &existing.SecurityContext, required.SecurityContext)
 	ensureAffinityPtr(modified, &existing.Affinity, required.Affinity)
 	ensureTolerations(modified, &existing.Tolerations, required.Tolerations)
@@ -312,6 +312,15 @@ func setStringSliceIfSet(modified *bool, existing *[]string, required []string)
 	}
 }
 
+// setStringMap sets the map to the required one, dropping the keys no longer
+// required.
+func setStringMap(modified *bool, existing *map[string]string, required map[string]string) {
+	if !equality.Semantic.DeepEqual(required, *existing) {
+		*existing = required
+		*modified = true
+	}
+}
+
 func setResourceListIfSet(modified *bool, existing *corev1.ResourceList, required corev1.ResourceList) {
 	if required == nil {
 		return
@@ -338,23 +347,13 @@ func mergeStringSlice(modified *bool, existing *[]string, required []string) {
 	}
 }
 
+// ensureTolerations sets the tolerations to the required ones, compared by
+// their full value: tolerations sharing a key with different effects or
+// operators are distinct, and tolerations no longer required are dropped.
 func ensureTolerations(modified *bool, existing *[]corev1.Toleration, required []corev1.Toleration) {
-	for ridx := range required {
-		found := false
-		for eidx := range *existing {
-			if required[ridx].Key == (*existing)[eidx].Key {
-				found = true
-				if !equality.Semantic.DeepEqual((*existing)[eidx], required[ridx]) {
-					*modified = true
-					(*existing)[eidx] = required[ridx]
-				}
-				break
-			}
-		}
-		if !found {
-			*modified = true
-			*existing = append(*existing, required[ridx])
-		}
+	if !equality.Semantic.DeepEqual(*existing, required) {
+		*modified = true
+		*existing = required
 	}
 }
 
@@ -131,3 +131,52 @@ func TestEnsureContainer(t *testing.T) {
 		})
 	}
 }
+
+func TestEnsureTolerations(t *testing.T) {
+	master := corev1.Toleration{Key: "node-role.kubernetes.io/master", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}
+	masterNoExecute := corev1.Toleration{Key: "node-role.kubernetes.io/master", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute}
+	infra := corev1.Toleration{Key: "node-role.kubernetes.io/infra", Operator: corev1.TolerationOpExists}
+
+	tests := []struct {
+		name             string
+		existing         []corev1.Toleration
+		required         []corev1.Toleration
+		expectedModified bool
+	}{
+		{
+			name:     "unchanged",
+			existing: []corev1.Toleration{master, infra},
+			required: []corev1.Toleration{master, infra},
+		},
+		{
+			name:     "tolerations sharing a key",
+			existing: []corev1.Toleration{master, masterNoExecute},
+			required: []corev1.Toleration{master, masterNoExecute},
+		},
+		{
+			name:             "added",
+			existing:         []corev1.Toleration{master},
+			required:         []corev1.Toleration{master, infra},
+			expectedModified: true,
+		},
+		{
+			name:             "removed",
+			existing:         []corev1.Toleration{master, infra},
+			required:         []corev1.Toleration{master},
+			expectedModified: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			modified := false
+			existing := append([]corev1.Toleration{}, test.existing...)
+			ensureTolerations(&modified, &existing, test.required)
+			if modified != test.expectedModified {
+				t.Fatalf("expected modified %v, got %v", test.expectedModified, modified)
+			}
+			if !equality.Semantic.DeepEqual(existing, test.required) {
+				t.Fatalf("expected %v, got %v", test.required, existing)
+			}
+		})
+	}
+}
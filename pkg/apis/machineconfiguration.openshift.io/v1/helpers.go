@@ -196,3 +196,139 @@ func IsControllerConfigCompleted(ccName string, ccGetter func(string) (*Controll
 	}
 	return fmt.Errorf("ControllerConfig has not completed: completed(%v) running(%v) failing(%v)", completed, running, failing)
 }
+
+// NewUpdateWaveCondition creates a new UpdateWave condition.
+func NewUpdateWaveCondition(condType UpdateWaveConditionType, status corev1.ConditionStatus, reason, message string) *UpdateWaveCondition {
+	return &UpdateWaveCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// GetUpdateWaveCondition returns the condition with the provided type.
+func GetUpdateWaveCondition(status UpdateWaveStatus, condType UpdateWaveConditionType) *UpdateWaveCondition {
+	for i := range status.Conditions {
+		c := status.Conditions[i]
+		if c.Type == condType {
+			return &c
+		}
+	}
+	return nil
+}
+
+// SetUpdateWaveCondition updates the UpdateWave to include the provided condition. If the condition that
+// we are about to add already exists with the same status, reason and message then we are not going to update.
+func SetUpdateWaveCondition(status *UpdateWaveStatus, condition UpdateWaveCondition) {
+	currentCond := GetUpdateWaveCondition(*status, condition.Type)
+	if currentCond != nil && currentCond.Status == condition.Status && currentCond.Reason == condition.Reason && currentCond.Message == condition.Message {
+		return
+	}
+	// Do not update lastTransitionTime if the status of the condition doesn't change.
+	if currentCond != nil && currentCond.Status == condition.Status {
+		condition.LastTransitionTime = currentCond.LastTransitionTime
+	}
+	var newConditions []UpdateWaveCondition
+	for _, c := range status.Conditions {
+		if c.Type != condition.Type {
+			newConditions = append(newConditions, c)
+		}
+	}
+	status.Conditions = append(newConditions, condition)
+}
+
+// IsUpdateWaveConditionTrue returns true when the conditionType is present and set to `ConditionTrue`
+func IsUpdateWaveConditionTrue(conditions []UpdateWaveCondition, conditionType UpdateWaveConditionType) bool {
+	for _, condition := range conditions {
+		if condition.Type == conditionType {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// NewMaintenanceTaskCondition creates a new MaintenanceTask condition.
+func NewMaintenanceTaskCondition(condType MaintenanceTaskConditionType, status corev1.ConditionStatus, reason, message string) *MaintenanceTaskCondition {
+	return &MaintenanceTaskCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// GetMaintenanceTaskCondition returns the condition with the provided type.
+func GetMaintenanceTaskCondition(status MaintenanceTaskStatus, condType MaintenanceTaskConditionType) *MaintenanceTaskCondition {
+	for i := range status.Conditions {
+		c := status.Conditions[i]
+		if c.Type == condType {
+			return &c
+		}
+	}
+	return nil
+}
+
+// SetMaintenanceTaskCondition updates the MaintenanceTask to include the provided condition. If the condition that
+// we are about to add already exists with the same status, reason and message then we are not going to update.
+func SetMaintenanceTaskCondition(status *MaintenanceTaskStatus, condition MaintenanceTaskCondition) {
+	currentCond := GetMaintenanceTaskCondition(*status, condition.Type)
+	if currentCond != nil && currentCond.Status == condition.Status && currentCond.Reason == condition.Reason && currentCond.Message == condition.Message {
+		return
+	}
+	// Do not update lastTransitionTime if the status of the condition doesn't change.
+	if currentCond != nil && currentCond.Status == condition.Status {
+		condition.LastTransitionTime = currentCond.LastTransitionTime
+	}
+	var newConditions []MaintenanceTaskCondition
+	for _, c := range status.Conditions {
+		if c.Type != condition.Type {
+			newConditions = append(newConditions, c)
+		}
+	}
+	status.Conditions = append(newConditions, condition)
+}
+
+// NewMachineConfigProfileCondition creates a new MachineConfigProfile condition.
+func NewMachineConfigProfileCondition(condType MachineConfigProfileConditionType, status corev1.ConditionStatus, reason, message string) *MachineConfigProfileCondition {
+	return &MachineConfigProfileCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// GetMachineConfigProfileCondition returns the condition with the provided type.
+func GetMachineConfigProfileCondition(status MachineConfigProfileStatus, condType MachineConfigProfileConditionType) *MachineConfigProfileCondition {
+	for i := range status.Conditions {
+		c := status.Conditions[i]
+		if c.Type == condType {
+			return &c
+		}
+	}
+	return nil
+}
+
+// SetMachineConfigProfileCondition updates the MachineConfigProfile to include the provided condition. If the condition that
+// we are about to add already exists with the same status, reason and message then we are not going to update.
+func SetMachineConfigProfileCondition(status *MachineConfigProfileStatus, condition MachineConfigProfileCondition) {
+	currentCond := GetMachineConfigProfileCondition(*status, condition.Type)
+	if currentCond != nil && currentCond.Status == condition.Status && currentCond.Reason == condition.Reason && currentCond.Message == condition.Message {
+		return
+	}
+	// Do not update lastTransitionTime if the status of the condition doesn't change.
+	if currentCond != nil && currentCond.Status == condition.Status {
+		condition.LastTransitionTime = currentCond.LastTransitionTime
+	}
+	var newConditions []MachineConfigProfileCondition
+	for _, c := range status.Conditions {
+		if c.Type != condition.Type {
+			newConditions = append(newConditions, c)
+		}
+	}
+	status.Conditions = append(newConditions, condition)
+}
@@ -34,6 +34,16 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&MachineConfigList{},
 		&MachineConfigPool{},
 		&MachineConfigPoolList{},
+		&UpdateWave{},
+		&UpdateWaveList{},
+		&MaintenanceTask{},
+		&MaintenanceTaskList{},
+		&MachineConfigProfile{},
+		&MachineConfigProfileList{},
+		&MachineConfigFreeze{},
+		&MachineConfigFreezeList{},
+		&MachineConfigNode{},
+		&MachineConfigNodeList{},
 	)
 
 	metav1.AddToGroupVersion(scheme, GroupVersion)
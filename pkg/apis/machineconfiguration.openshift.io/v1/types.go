@@ -34,6 +34,11 @@ type ControllerConfigSpec struct {
 	// clusterDNSIP is the cluster DNS IP address
 	ClusterDNSIP string `json:"clusterDNSIP"`
 
+	// serviceNetwork are the CIDRs of the service network of the cluster, the
+	// one of the primary IP family first.
+	// +optional
+	ServiceNetwork []string `json:"serviceNetwork,omitempty"`
+
 	// cloudProviderConfig is the configuration for the given cloud provider
 	CloudProviderConfig string `json:"cloudProviderConfig"`
 
@@ -43,6 +48,11 @@ type ControllerConfigSpec struct {
 	// etcdDiscoveryDomain is deprecated, use Infra.Status.EtcdDiscoveryDomain instead
 	EtcdDiscoveryDomain string `json:"etcdDiscoveryDomain,omitempty"`
 
+	// architecture is the CPU architecture of the nodes, as reported by
+	// uname -m, e.g. x86_64, aarch64, s390x or ppc64le. It selects the
+	// architecture overlays of the templates.
+	Architecture string `json:"architecture,omitempty"`
+
 	// TODO: Use string for CA data
 
 	// kubeAPIServerServingCAData managed Kubelet to API Server Cert... Rotated automatically
@@ -76,10 +86,20 @@ type ControllerConfigSpec struct {
 	// releaseImage is the image used when installing the cluster
 	ReleaseImage string `json:"releaseImage"`
 
+	// fips is true if the cluster was installed with FIPS mode enabled, as
+	// set by fips in its install-config.
+	// +optional
+	FIPS bool `json:"fips,omitempty"`
+
 	// proxy holds the current proxy configuration for the nodes
 	// +nullable
 	Proxy *configv1.ProxyStatus `json:"proxy"`
 
+	// image holds the hostnames of the integrated image registry, as set in the
+	// status of the cluster image config by the image registry operator
+	// +nullable
+	Image *configv1.ImageStatus `json:"image"`
+
 	// infra holds the infrastructure details
 	// +nullable
 	Infra *configv1.Infrastructure `json:"infra"`
@@ -101,6 +121,11 @@ type ControllerConfigSpec struct {
 	// Network contains additional network related information
 	// +nullable
 	Network *NetworkInfo `json:"network"`
+
+	// cgroupMode is the cgroup mode of the cluster node config, v1 or v2, or
+	// empty if it doesn't set one.
+	// +optional
+	CgroupMode configv1.CgroupMode `json:"cgroupMode,omitempty"`
 }
 
 // IPFamiliesType indicates whether the cluster network is IPv4-only, IPv6-only, or dual-stack
@@ -254,10 +279,471 @@ type MachineConfigPoolSpec struct {
 	// maxUnavailable is greater than one.
 	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
 
+	// storage configures multipath and the iSCSI initiator on the nodes of the pool.
+	// It's rendered into the 99-<pool>-generated-storage MachineConfig.
+	// +optional
+	Storage *PoolStorageConfiguration `json:"storage,omitempty"`
+
+	// hostMTU sets the MTU of host interfaces on the nodes of the pool.
+	// It's rendered into the 99-<pool>-generated-host-mtu MachineConfig.
+	// +optional
+	HostMTU *HostMTUConfiguration `json:"hostMTU,omitempty"`
+
+	// workloadPartitioning pins the management workloads of the nodes of the
+	// pool to a set of CPUs through CRI-O, as on single node and edge clusters.
+	// It's rendered into the 99-<pool>-generated-workload-partitioning MachineConfig.
+	// +optional
+	WorkloadPartitioning *WorkloadPartitioningConfiguration `json:"workloadPartitioning,omitempty"`
+
+	// lowLatency isolates CPUs of the nodes of the pool and allocates huge
+	// pages for latency sensitive workloads, e.g. of telco nodes.  It's
+	// rendered into the 99-<pool>-generated-low-latency MachineConfig.
+	// +optional
+	LowLatency *LowLatencyConfiguration `json:"lowLatency,omitempty"`
+
+	// bootloader protects the GRUB bootloader of the nodes of the pool with a
+	// superuser password.  It's rendered into the 99-<pool>-generated-bootloader
+	// MachineConfig.
+	// +optional
+	Bootloader *BootloaderConfiguration `json:"bootloader,omitempty"`
+
+	// cgroupMode switches the nodes of the pool between cgroup v1 and the
+	// unified cgroup v2 hierarchy.  It's rendered into the
+	// 99-<pool>-generated-cgroup-mode MachineConfig, and the pools switch one
+	// at a time.  The mode of the OS is used if it's empty.
+	// +optional
+	CgroupMode CgroupMode `json:"cgroupMode,omitempty"`
+
+	// rebootTimeout handles the nodes of the pool which don't come back after
+	// rebooting into a new config, instead of waiting for them indefinitely.
+	// +optional
+	RebootTimeout *RebootTimeoutPolicy `json:"rebootTimeout,omitempty"`
+
+	// failureDomain limits how many nodes of each failure domain of the pool,
+	// e.g. a rack sharing a top-of-rack switch or power supply, update at once,
+	// in addition to maxUnavailable.
+	// +optional
+	FailureDomain *FailureDomainPolicy `json:"failureDomain,omitempty"`
+
+	// adaptiveConcurrency starts the rollout of each new config one node at a
+	// time and updates more nodes at once, up to maxUnavailable, while the
+	// updates succeed in time, backing off when they don't.
+	// +optional
+	AdaptiveConcurrency *AdaptiveConcurrencyPolicy `json:"adaptiveConcurrency,omitempty"`
+
+	// preRebootNotification tells the workloads of a node that it's about to
+	// be drained and rebooted for an update, some time ahead, so they can
+	// e.g. stop accepting connections before they are evicted.
+	// +optional
+	PreRebootNotification *PreRebootNotification `json:"preRebootNotification,omitempty"`
+
+	// updateTaint is a taint set on the nodes of the pool while they update,
+	// from before they're drained until they're back and Ready with the new
+	// config, so other schedulers and operators can tell them apart from
+	// nodes cordoned for other reasons.
+	// +optional
+	UpdateTaint *UpdateTaint `json:"updateTaint,omitempty"`
+
+	// postUpdateValidation configures probes the daemon runs on a node after
+	// applying a config, which must pass before the node counts as updated.
+	// +optional
+	PostUpdateValidation *PostUpdateValidation `json:"postUpdateValidation,omitempty"`
+
+	// imagePruning removes the container images nothing uses anymore from
+	// a node once it's updated and validated, keeping the usage of /var
+	// bounded on nodes with small disks.
+	// +optional
+	ImagePruning *ImagePruning `json:"imagePruning,omitempty"`
+
+	// pullSecret restricts the credentials of the cluster pull secret written
+	// to the nodes of the pool, e.g. to those of a mirror registry on less
+	// trusted sites.  All the credentials are written if it's nil.
+	// +optional
+	PullSecret *PoolPullSecret `json:"pullSecret,omitempty"`
+
+	// progressWebhooks are notified when a rollout of the pool starts,
+	// completes or degrades.
+	// +optional
+	ProgressWebhooks []ProgressWebhook `json:"progressWebhooks,omitempty"`
+
 	// The targeted MachineConfig object for the machine config pool.
 	Configuration MachineConfigPoolStatusConfiguration `json:"configuration"`
 }
 
+// PoolStorageConfiguration configures the SAN storage stack of the nodes of a pool.
+type PoolStorageConfiguration struct {
+	// multipath enables device-mapper multipath and multipathd.
+	// +optional
+	Multipath *MultipathConfiguration `json:"multipath,omitempty"`
+
+	// iscsi configures the iSCSI initiator.
+	// +optional
+	ISCSI *ISCSIConfiguration `json:"iscsi,omitempty"`
+}
+
+// MultipathConfiguration configures device-mapper multipath.
+type MultipathConfiguration struct {
+	// rootDevice adds the kernel arguments to boot from a multipathed root
+	// device: rd.multipath=default and root=/dev/disk/by-label/dm-mpath-root.
+	// +optional
+	RootDevice bool `json:"rootDevice,omitempty"`
+
+	// config is the content of /etc/multipath.conf. It defaults to the
+	// configuration written by mpathconf --enable.
+	// +optional
+	Config string `json:"config,omitempty"`
+}
+
+// ISCSIConfiguration configures the iSCSI initiator.
+type ISCSIConfiguration struct {
+	// initiatorNamePrefix is the IQN the initiator names of the nodes start
+	// with, e.g. iqn.2022-01.com.example. Each node is named <prefix>:<hostname>.
+	// +optional
+	InitiatorNamePrefix string `json:"initiatorNamePrefix,omitempty"`
+
+	// config is the content of /etc/iscsi/iscsid.conf.
+	// +optional
+	Config string `json:"config,omitempty"`
+}
+
+// HostMTUConfiguration sets the MTU of host interfaces through NetworkManager.
+type HostMTUConfiguration struct {
+	// mtu is the MTU of the interfaces, between 576 and 9216.
+	// While an MTU migration of the cluster network is in progress, it must
+	// match the machine MTU the migration is moving to.
+	MTU uint32 `json:"mtu"`
+
+	// interfaces are the names of the host interfaces to set the MTU of,
+	// e.g. the uplink of the node such as ens3.
+	Interfaces []string `json:"interfaces"`
+}
+
+// WorkloadPartitioningConfiguration configures the CRI-O workload partitioning
+// of the management workloads.
+type WorkloadPartitioningConfiguration struct {
+	// managementCPUs is the set of CPUs the management workloads run on, in
+	// the cpuset list format, e.g. 0-1,52-53.  The kubelet must reserve the
+	// same CPUs: a KubeletConfig of the pool has to set reservedSystemCPUs
+	// to it.
+	ManagementCPUs string `json:"managementCPUs"`
+
+	// annotationPrefix is the prefix of the pod annotations CRI-O reads the
+	// CPU shares of the containers of a management workload from.  It
+	// defaults to resources.workload.openshift.io.
+	// +optional
+	AnnotationPrefix string `json:"annotationPrefix,omitempty"`
+}
+
+// LowLatencyConfiguration configures the kernel of the nodes for latency
+// sensitive workloads.
+type LowLatencyConfiguration struct {
+	// isolatedCPUs is the set of CPUs the kernel keeps its own tasks and
+	// unpinned processes off, for the workloads pinned to them, in the cpuset
+	// list format, e.g. 2-51,54-103.  It's rendered into the isolcpus kernel
+	// argument.  A KubeletConfig of the pool has to set reservedSystemCPUs,
+	// which the interrupts are moved to, and they mustn't intersect.
+	IsolatedCPUs string `json:"isolatedCPUs"`
+
+	// ticklessCPUs is the set of isolated CPUs which run without the
+	// scheduling-clock tick while they run a single task, in the cpuset list
+	// format.  It's rendered into the nohz_full and rcu_nocbs kernel
+	// arguments.
+	// +optional
+	TicklessCPUs string `json:"ticklessCPUs,omitempty"`
+
+	// hugePages are the huge pages allocated on the nodes at boot.
+	// +optional
+	HugePages *HugePagesConfiguration `json:"hugePages,omitempty"`
+}
+
+// HugePageSize is the size of huge pages.
+type HugePageSize string
+
+const (
+	HugePageSize2M HugePageSize = "2M"
+	HugePageSize1G HugePageSize = "1G"
+)
+
+// HugePagesConfiguration allocates huge pages.
+type HugePagesConfiguration struct {
+	// defaultSize is the size of the huge pages of hugetlbfs mounts which
+	// don't set one, 2M or 1G.  It's the default of the kernel, 2M, if empty.
+	// +optional
+	DefaultSize HugePageSize `json:"defaultSize,omitempty"`
+
+	// pages are the allocations of huge pages, at most one per size and NUMA
+	// node.
+	Pages []HugePagesAllocation `json:"pages"`
+}
+
+// HugePagesAllocation is an allocation of huge pages of a size.
+type HugePagesAllocation struct {
+	// size is the size of the pages, 2M or 1G.
+	Size HugePageSize `json:"size"`
+
+	// count is the number of pages.
+	Count int32 `json:"count"`
+
+	// numaNode is the NUMA node to allocate the pages on, by a systemd unit
+	// early in the boot.  Without it, the kernel allocates them at boot,
+	// spread across the NUMA nodes.
+	// +optional
+	NUMANode *int32 `json:"numaNode,omitempty"`
+}
+
+// BootloaderConfiguration hardens the GRUB bootloader.
+type BootloaderConfiguration struct {
+	// passwordSecret is the name of a Secret in the
+	// openshift-machine-config-operator namespace whose passwordHash key holds
+	// the hash of the password of the GRUB root superuser, as printed by
+	// grub2-mkpasswd-pbkdf2, e.g. grub.pbkdf2.sha512.10000.<salt>.<hash>.
+	// Once set, editing the boot entries, e.g. their kernel arguments, and the
+	// GRUB command line require the password, booting the entries doesn't.
+	PasswordSecret string `json:"passwordSecret"`
+}
+
+// CgroupMode is the cgroup hierarchy the nodes of a pool boot with.
+type CgroupMode string
+
+const (
+	// CgroupModeV1 is the legacy cgroup v1 hierarchy.
+	CgroupModeV1 CgroupMode = "v1"
+
+	// CgroupModeV2 is the unified cgroup v2 hierarchy.
+	CgroupModeV2 CgroupMode = "v2"
+)
+
+// RebootTimeoutPolicy configures how nodes which don't rejoin the cluster
+// after rebooting for an update are handled.
+type RebootTimeoutPolicy struct {
+	// timeout is how long a node updating to a new config may stay NotReady
+	// before it's marked with the MachineConfigRebootTimedOut node condition.
+	Timeout metav1.Duration `json:"timeout"`
+
+	// action is what happens to the rollout once a node timed out.  Stall, the
+	// default, keeps counting the node against maxUnavailable, so the update
+	// of the pool waits until the node comes back or is removed.  Continue
+	// stops counting up to maxUnavailable timed out nodes against it, so the
+	// remaining nodes keep updating.
+	// +optional
+	Action RebootTimeoutAction `json:"action,omitempty"`
+}
+
+// RebootTimeoutAction is what the node controller does once an updating node timed out.
+type RebootTimeoutAction string
+
+const (
+	// RebootTimeoutActionStall stalls the rollout of the pool until the node is back.
+	RebootTimeoutActionStall RebootTimeoutAction = "Stall"
+
+	// RebootTimeoutActionContinue continues the rollout with the nodes which are left.
+	RebootTimeoutActionContinue RebootTimeoutAction = "Continue"
+)
+
+// FailureDomainPolicy groups the nodes of a pool into failure domains by a
+// node label and limits how many nodes of each go unavailable at once.
+type FailureDomainPolicy struct {
+	// labelKey is the node label whose value is the failure domain of a node,
+	// e.g. topology.kubernetes.io/zone or a custom label naming its rack.
+	// Nodes without the label are grouped into one failure domain.
+	LabelKey string `json:"labelKey"`
+
+	// maxUnavailable defines either an integer number or percentage of the
+	// nodes of each failure domain that can go Unavailable during an update.
+	// As for the pool, a percentage is rounded down, and the default and
+	// minimum value is 1.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// AdaptiveConcurrencyPolicy configures the adaptive concurrency of the
+// rollouts of a pool.  The number of nodes updating at once starts at 1 for
+// each new config and doubles every time as many updates as nodes allowed to
+// update at once completed within expectedNodeUpdateDuration.  It's halved
+// whenever a node fails to update or takes longer than that.
+type AdaptiveConcurrencyPolicy struct {
+	// expectedNodeUpdateDuration is how long the update of a node, from the
+	// node controller targeting it until it's done and Ready, should take.
+	ExpectedNodeUpdateDuration metav1.Duration `json:"expectedNodeUpdateDuration"`
+}
+
+// PreRebootNotification configures how the workloads of a node are notified
+// before the node is drained and rebooted for an update.  Notifications are
+// best effort: failing to deliver them doesn't stop the update.
+type PreRebootNotification struct {
+	// leadTime is how long the daemon waits after notifying the workloads
+	// before it drains the node.
+	LeadTime metav1.Duration `json:"leadTime"`
+
+	// podAnnotation is an annotation the daemon sets on the pods running on
+	// the node, with the time the drain starts, in RFC 3339 format, as value.
+	// +optional
+	PodAnnotation string `json:"podAnnotation,omitempty"`
+
+	// url is an http endpoint on the node, e.g. http://127.0.0.1:9090/reboot,
+	// the daemon POSTs a JSON PreRebootNotificationEvent to.  Only loopback
+	// hosts are allowed.
+	// +optional
+	URL string `json:"url,omitempty"`
+}
+
+// PostUpdateValidationFailurePolicy is what the daemon does when the
+// post-update validation of a node fails.
+type PostUpdateValidationFailurePolicy string
+
+const (
+	// PostUpdateValidationFailurePolicyDegrade marks the node Degraded.  The
+	// daemon runs the probes again when it retries, and completes the update
+	// once they pass.
+	PostUpdateValidationFailurePolicyDegrade PostUpdateValidationFailurePolicy = "Degrade"
+
+	// PostUpdateValidationFailurePolicyRollback applies the previous config of
+	// the node again, and marks the node Degraded until the pool targets
+	// another config.
+	PostUpdateValidationFailurePolicyRollback PostUpdateValidationFailurePolicy = "Rollback"
+)
+
+// PostUpdateValidation configures the probes the nodes of a pool must pass
+// after applying a config.
+type PostUpdateValidation struct {
+	// probes are run in order, all of them must pass.
+	Probes []ValidationProbe `json:"probes"`
+
+	// timeout is how long the daemon retries failing probes before the
+	// validation fails.  Defaults to 5 minutes.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// failurePolicy is what the daemon does when the validation fails:
+	// Degrade (the default) or Rollback.
+	// +optional
+	FailurePolicy PostUpdateValidationFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// ImagePruning configures the removal of unused container images from the
+// nodes of a pool after updates.  The images of containers on the node and
+// the images CRI-O pins, e.g. the pause image, are never removed.
+type ImagePruning struct {
+	// pinnedImages are kept too, matched against the tags and digests of
+	// the images, e.g. quay.io/example/agent@sha256:<digest>.  A reference
+	// without a tag or digest matches every tag and digest of the repository.
+	// +optional
+	PinnedImages []string `json:"pinnedImages,omitempty"`
+}
+
+// PoolPullSecret restricts the credentials of the cluster pull secret written
+// to the nodes of a pool.
+type PoolPullSecret struct {
+	// registries are the registries whose credentials are written to the
+	// nodes, as the keys of the auths of the pull secret, e.g.
+	// mirror.example.com:5000.  A registry also matches the credentials of its
+	// repositories, e.g. mirror.example.com:5000/ocp.  The credentials of the
+	// other registries are left out.
+	Registries []string `json:"registries"`
+}
+
+// ProgressWebhook is an http endpoint the controller POSTs a JSON
+// PoolProgressEvent to on the progress of the rollouts of a pool, e.g. to let
+// a pipeline which applied a MachineConfig wait for its rollout.
+type ProgressWebhook struct {
+	// url is the http or https endpoint, e.g.
+	// https://ci.example.com/hooks/mcp.
+	URL string `json:"url"`
+
+	// signingSecret is the name of a Secret in the
+	// openshift-machine-config-operator namespace whose signingKey key holds
+	// the key the requests are signed with.  The X-Machine-Config-Signature
+	// header of the requests is sha256= followed by the hex encoded
+	// HMAC-SHA256 of their body.
+	SigningSecret string `json:"signingSecret"`
+}
+
+// PoolProgressEventType is the progress of a rollout a PoolProgressEvent
+// reports.
+type PoolProgressEventType string
+
+const (
+	// PoolProgressStarted is sent when the pool starts updating its nodes.
+	PoolProgressStarted PoolProgressEventType = "Started"
+
+	// PoolProgressCompleted is sent when all the nodes of the pool are
+	// updated to its target config.
+	PoolProgressCompleted PoolProgressEventType = "Completed"
+
+	// PoolProgressDegraded is sent when the pool becomes Degraded.
+	PoolProgressDegraded PoolProgressEventType = "Degraded"
+)
+
+// PoolProgressEvent is the body of the requests sent to the progress
+// webhooks of a pool.
+type PoolProgressEvent struct {
+	// type is Started, Completed or Degraded.
+	Type PoolProgressEventType `json:"type"`
+
+	// pool is the name of the pool.
+	Pool string `json:"pool"`
+
+	// machineConfig is the name of the config the pool targets.
+	MachineConfig string `json:"machineConfig"`
+
+	// message describes the state of the pool, e.g. why it's degraded.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// time is when the controller observed the change.
+	Time metav1.Time `json:"time"`
+}
+
+// ValidationProbe is a check of the state of a node.  Exactly one of
+// systemdUnit, url and command must be set.
+type ValidationProbe struct {
+	// name identifies the probe in events and in the reason of the node.
+	Name string `json:"name"`
+
+	// systemdUnit is a systemd unit which must be active.
+	// +optional
+	SystemdUnit string `json:"systemdUnit,omitempty"`
+
+	// url is an http endpoint on the node, e.g. http://127.0.0.1:10248/healthz,
+	// which must answer a GET with a 2xx status.  Only loopback hosts are
+	// allowed.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// command is run on the node and must exit with status 0, e.g.
+	// ["/usr/local/bin/check-sriov.sh", "--quiet"].
+	// +optional
+	Command []string `json:"command,omitempty"`
+}
+
+// UpdateTaint is the taint set on the nodes of a pool while they update.
+type UpdateTaint struct {
+	// key is the key of the taint.
+	Key string `json:"key"`
+
+	// value is the value of the taint.
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// effect is the effect of the taint: NoSchedule, PreferNoSchedule or
+	// NoExecute.  NoExecute evicts the pods not tolerating the taint
+	// regardless of their PodDisruptionBudgets.
+	Effect corev1.TaintEffect `json:"effect"`
+}
+
+// PreRebootNotificationEvent is the body of the request sent to the url of a
+// PreRebootNotification.
+type PreRebootNotificationEvent struct {
+	// node is the name of the node about to reboot.
+	Node string `json:"node"`
+
+	// machineConfig is the name of the config the node is updating to.
+	MachineConfig string `json:"machineConfig"`
+
+	// drainTime is when the daemon starts draining the node.
+	DrainTime metav1.Time `json:"drainTime"`
+}
+
 // MachineConfigPoolStatus is the status for MachineConfigPool resource.
 type MachineConfigPoolStatus struct {
 	// observedGeneration represents the generation observed by the controller.
@@ -284,9 +770,84 @@ type MachineConfigPoolStatus struct {
 	// A node is marked degraded if applying a configuration failed..
 	DegradedMachineCount int32 `json:"degradedMachineCount"`
 
+	// degradedReasons aggregates the degraded (or unreconcilable) machines by the reason of their failure.
+	// +optional
+	DegradedReasons []PoolDegradedReason `json:"degradedReasons,omitempty"`
+
 	// conditions represents the latest available observations of current state.
 	// +optional
 	Conditions []MachineConfigPoolCondition `json:"conditions"`
+
+	// drainBlockers lists the PodDisruptionBudgets currently blocking the drain of nodes in the pool.
+	// +optional
+	DrainBlockers []PoolDrainBlocker `json:"drainBlockers,omitempty"`
+
+	// adaptiveConcurrency is the state of the adaptive concurrency of the
+	// rollout of the pool, if enabled.
+	// +optional
+	AdaptiveConcurrency *AdaptiveConcurrencyStatus `json:"adaptiveConcurrency,omitempty"`
+}
+
+// AdaptiveConcurrencyStatus is the state of the adaptive concurrency of the
+// rollout of a pool.
+type AdaptiveConcurrencyStatus struct {
+	// targetConfig is the config being rolled out.  The state starts over for
+	// each new config.
+	TargetConfig string `json:"targetConfig"`
+
+	// limit is the number of nodes allowed to update at once, at most maxUnavailable.
+	Limit int32 `json:"limit"`
+
+	// successes counts the updates completed in time since the limit last changed.
+	Successes int32 `json:"successes"`
+
+	// updatingNodes are the nodes updating to the target config, with when they were targeted.
+	// +optional
+	UpdatingNodes []AdaptiveConcurrencyNode `json:"updatingNodes,omitempty"`
+}
+
+// AdaptiveConcurrencyNode is a node updating under adaptive concurrency.
+type AdaptiveConcurrencyNode struct {
+	// name of the node.
+	Name string `json:"name"`
+
+	// since is when the node was first seen targeting the config.
+	Since metav1.Time `json:"since"`
+
+	// overdue is set once the node took longer to update than expected,
+	// which counted as a failed update.
+	// +optional
+	Overdue bool `json:"overdue,omitempty"`
+}
+
+// PoolDegradedReason counts the machines of a pool failing for the same reason.
+type PoolDegradedReason struct {
+	// reason is the class of the error reported by the machines, e.g. DrainTimeout or OSUpdateError,
+	// or their state (Degraded or Unreconcilable) if the class isn't known.
+	Reason string `json:"reason"`
+
+	// machineCount is the number of machines failing for the reason.
+	MachineCount int32 `json:"machineCount"`
+}
+
+// PoolDrainBlocker is a PodDisruptionBudget which blocks the drain of nodes in a pool.
+type PoolDrainBlocker struct {
+	// namespace of the PodDisruptionBudget.
+	Namespace string `json:"namespace"`
+
+	// name of the PodDisruptionBudget.
+	Name string `json:"name"`
+
+	// owner is the workload of the pods covered by the PodDisruptionBudget, e.g. Deployment/frontend.
+	// +optional
+	Owner string `json:"owner,omitempty"`
+
+	// disruptionsAllowed is the number of pod disruptions the PodDisruptionBudget allowed when the drain was blocked.
+	DisruptionsAllowed int32 `json:"disruptionsAllowed"`
+
+	// nodes are the names of the nodes whose drain is blocked.
+	// +optional
+	Nodes []string `json:"nodes,omitempty"`
 }
 
 // MachineConfigPoolStatusConfiguration stores the current configuration for the pool, and
@@ -342,6 +903,32 @@ const (
 
 	// MachineConfigPoolDegraded is the overall status of the pool based, today, on whether we fail with NodeDegraded or RenderDegraded
 	MachineConfigPoolDegraded MachineConfigPoolConditionType = "Degraded"
+
+	// MachineConfigPoolRebootDeferred means some machines are pending the update, and so the reboot, to the desired machine config
+	// but it's deferred because the pool is paused, node updates are frozen cluster-wide or the pool's maxUnavailable is used up
+	MachineConfigPoolRebootDeferred MachineConfigPoolConditionType = "RebootDeferred"
+
+	// MachineConfigPoolImageMirrorsDegraded means new image mirrors of the ImageContentSourcePolicies failed their health checks,
+	// so the registries config using them isn't rolled out to the pool
+	MachineConfigPoolImageMirrorsDegraded MachineConfigPoolConditionType = "ImageMirrorsDegraded"
+
+	// MachineConfigPoolStorageDegraded means the MachineConfig can't be generated from the storage configuration of the pool
+	MachineConfigPoolStorageDegraded MachineConfigPoolConditionType = "StorageDegraded"
+
+	// MachineConfigPoolHostMTUDegraded means the MachineConfig can't be generated from the host MTU of the pool
+	MachineConfigPoolHostMTUDegraded MachineConfigPoolConditionType = "HostMTUDegraded"
+
+	// MachineConfigPoolWorkloadPartitioningDegraded means the MachineConfig can't be generated from the workload partitioning of the pool
+	MachineConfigPoolWorkloadPartitioningDegraded MachineConfigPoolConditionType = "WorkloadPartitioningDegraded"
+
+	// MachineConfigPoolBootloaderDegraded means the MachineConfig can't be generated from the bootloader configuration of the pool
+	MachineConfigPoolBootloaderDegraded MachineConfigPoolConditionType = "BootloaderDegraded"
+
+	// MachineConfigPoolCgroupModeDegraded means the MachineConfig can't be generated from the cgroup mode of the pool
+	MachineConfigPoolCgroupModeDegraded MachineConfigPoolConditionType = "CgroupModeDegraded"
+
+	// MachineConfigPoolLowLatencyDegraded means the MachineConfig can't be generated from the low latency configuration of the pool
+	MachineConfigPoolLowLatencyDegraded MachineConfigPoolConditionType = "LowLatencyDegraded"
 )
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -473,6 +1060,66 @@ type ContainerRuntimeConfiguration struct {
 	// overlaySize specifies the maximum size of a container image.
 	// This flag can be used to set quota on the size of container images. (default: 10GB)
 	OverlaySize resource.Quantity `json:"overlaySize,omitempty"`
+
+	// conmonCgroup specifies the cgroup conmon, the per-container monitor process
+	// that also writes the container log, is placed in.
+	// Options are "pod", to account it to the pod it monitors, or the name of a
+	// systemd slice ending in ".slice", e.g. "system.slice".
+	// Rotation of container logs by file count is done by the kubelet, see
+	// containerLogMaxFiles in KubeletConfig.
+	ConmonCgroup string `json:"conmonCgroup,omitempty"`
+
+	// shortNameAliases resolve short image names, e.g. "ubi8", to fully qualified
+	// image names, e.g. "registry.example.com/ubi8/ubi", before searching the
+	// unqualified search registries. Aliases must not contain a tag or digest.
+	// +optional
+	ShortNameAliases []ShortNameAlias `json:"shortNameAliases,omitempty"`
+
+	// unqualifiedSearchRegistries are the registries, as host[:port], searched in
+	// order for images given by a short name without an alias. They replace the
+	// containerRuntimeSearchRegistries of the cluster image config for the pool.
+	// +optional
+	UnqualifiedSearchRegistries []string `json:"unqualifiedSearchRegistries,omitempty"`
+
+	// runtimeHandlers are additional CRI-O runtime handlers, e.g. one running
+	// WebAssembly workloads with crun, that RuntimeClasses can refer to by name
+	// in their handler field. The runtime binaries must be installed on the nodes
+	// of the pool.
+	// +optional
+	RuntimeHandlers []ContainerRuntimeHandler `json:"runtimeHandlers,omitempty"`
+}
+
+// ContainerRuntimeHandler defines a CRI-O runtime handler
+type ContainerRuntimeHandler struct {
+	// name is the name of the handler, as referred to by RuntimeClasses. It must
+	// be a DNS label and must not be "runc" or "crun".
+	Name string `json:"name"`
+
+	// runtimePath is the absolute path of the OCI runtime binary, e.g. "/usr/bin/crun".
+	RuntimePath string `json:"runtimePath"`
+
+	// runtimeType is the type of the runtime, "oci" or "vm". Defaults to "oci".
+	// +optional
+	RuntimeType string `json:"runtimeType,omitempty"`
+
+	// runtimeRoot is the absolute path of the directory the runtime keeps the
+	// state of its containers in. Defaults to the runtime's default.
+	// +optional
+	RuntimeRoot string `json:"runtimeRoot,omitempty"`
+
+	// allowedAnnotations are the annotations, e.g. "module.wasm.image/variant",
+	// that pods are allowed to pass to the runtime.
+	// +optional
+	AllowedAnnotations []string `json:"allowedAnnotations,omitempty"`
+}
+
+// ShortNameAlias maps a short image name to the fully qualified image name it resolves to
+type ShortNameAlias struct {
+	// name is the short name, without a registry, tag or digest, e.g. "ubi8".
+	Name string `json:"name"`
+
+	// image is the fully qualified image name, without a tag or digest, e.g. "registry.example.com/ubi8/ubi".
+	Image string `json:"image"`
 }
 
 // ContainerRuntimeConfigStatus defines the observed state of a ContainerRuntimeConfig
@@ -526,3 +1173,512 @@ type ContainerRuntimeConfigList struct {
 
 	Items []ContainerRuntimeConfig `json:"items"`
 }
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// UpdateWave rolls out the config changes of several pools as one unit, a stage
+// of pools at a time: custom pools such as infra first, then worker, and master last.
+type UpdateWave struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec UpdateWaveSpec `json:"spec"`
+	// +optional
+	Status UpdateWaveStatus `json:"status"`
+}
+
+// UpdateWaveSpec defines the desired state of UpdateWave
+type UpdateWaveSpec struct {
+	// machineConfigPools are the names of the pools rolled out by the wave.
+	// They are ordered into stages by the controller, not by this list.
+	MachineConfigPools []string `json:"machineConfigPools"`
+
+	// paused holds the wave: the pools that haven't finished updating are
+	// paused until it's unset. Create a wave paused, then apply the config
+	// changes it rolls out, and unpause it once they are reviewed.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// abort stops the wave for good. The pools that haven't finished updating
+	// stay paused, so that the config changes can be reverted, until the wave
+	// is deleted.
+	// +optional
+	Abort bool `json:"abort,omitempty"`
+}
+
+// UpdateWaveStatus defines the observed state of an UpdateWave
+type UpdateWaveStatus struct {
+	// observedGeneration represents the generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// stages are the pools of the wave in the order they are rolled out.
+	// +optional
+	Stages []UpdateWaveStage `json:"stages,omitempty"`
+
+	// conditions represents the latest available observations of current state.
+	// +optional
+	Conditions []UpdateWaveCondition `json:"conditions"`
+}
+
+// UpdateWaveStage is a set of pools of an UpdateWave rolled out together
+type UpdateWaveStage struct {
+	// machineConfigPools are the names of the pools of the stage.
+	MachineConfigPools []string `json:"machineConfigPools"`
+
+	// state is Pending, Updating or Updated.
+	State UpdateWaveStageState `json:"state"`
+}
+
+// UpdateWaveStageState is the rollout state of a stage of an UpdateWave
+type UpdateWaveStageState string
+
+const (
+	// UpdateWaveStagePending means the pools of the stage wait for the previous stages.
+	UpdateWaveStagePending UpdateWaveStageState = "Pending"
+
+	// UpdateWaveStageUpdating means the pools of the stage are rolling out.
+	UpdateWaveStageUpdating UpdateWaveStageState = "Updating"
+
+	// UpdateWaveStageUpdated means all the pools of the stage are updated.
+	UpdateWaveStageUpdated UpdateWaveStageState = "Updated"
+)
+
+// UpdateWaveCondition contains condition information for an UpdateWave
+type UpdateWaveCondition struct {
+	// type of the condition, currently ('Progressing', 'Completed', 'Degraded').
+	Type UpdateWaveConditionType `json:"type"`
+
+	// status of the condition, one of ('True', 'False', 'Unknown').
+	Status corev1.ConditionStatus `json:"status"`
+
+	// lastTransitionTime is the timestamp corresponding to the last status
+	// change of this condition.
+	// +nullable
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// reason is a brief machine readable explanation for the condition's last
+	// transition.
+	Reason string `json:"reason,omitempty"`
+
+	// message is a human readable description of the details of the last
+	// transition, complementing reason.
+	Message string `json:"message,omitempty"`
+}
+
+// UpdateWaveConditionType valid conditions of an UpdateWave
+type UpdateWaveConditionType string
+
+const (
+	// UpdateWaveProgressing means the wave is rolling out a stage. It is false
+	// with the Paused or Aborted reason while the wave is held or stopped.
+	UpdateWaveProgressing UpdateWaveConditionType = "Progressing"
+
+	// UpdateWaveCompleted means all the stages of the wave are updated and the
+	// wave no longer holds its pools.
+	UpdateWaveCompleted UpdateWaveConditionType = "Completed"
+
+	// UpdateWaveDegraded means the wave can't roll out, e.g. because one of its
+	// pools doesn't exist or belongs to another wave.
+	UpdateWaveDegraded UpdateWaveConditionType = "Degraded"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// UpdateWaveList is a list of UpdateWave resources
+type UpdateWaveList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []UpdateWave `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MaintenanceTask runs a script on a schedule on the nodes of the selected
+// pools, rendered into a systemd service and timer.
+type MaintenanceTask struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec MaintenanceTaskSpec `json:"spec"`
+	// +optional
+	Status MaintenanceTaskStatus `json:"status"`
+}
+
+// MaintenanceTaskSpec defines the desired state of MaintenanceTask
+type MaintenanceTaskSpec struct {
+	// machineConfigPoolSelector selects the pools whose nodes run the task.
+	// A nil selector selects no pools.
+	MachineConfigPoolSelector *metav1.LabelSelector `json:"machineConfigPoolSelector"`
+
+	// schedule is when the task runs, as a systemd calendar event, e.g. daily
+	// or "Mon *-*-* 03:00:00". See systemd.time(7).
+	Schedule string `json:"schedule"`
+
+	// randomizedDelay delays each run by a random time up to the duration, so
+	// that the nodes of a pool don't all run the task at once.
+	// +optional
+	RandomizedDelay *metav1.Duration `json:"randomizedDelay,omitempty"`
+
+	// script is run by bash as root. A run is skipped if the previous one
+	// is still running.
+	Script string `json:"script"`
+}
+
+// MaintenanceTaskStatus defines the observed state of a MaintenanceTask
+type MaintenanceTaskStatus struct {
+	// observedGeneration represents the generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// machineConfigPools are the names of the pools the task is rendered for.
+	// +optional
+	MachineConfigPools []string `json:"machineConfigPools,omitempty"`
+
+	// conditions represents the latest available observations of current state.
+	// +optional
+	Conditions []MaintenanceTaskCondition `json:"conditions"`
+}
+
+// MaintenanceTaskCondition contains condition information for a MaintenanceTask
+type MaintenanceTaskCondition struct {
+	// type of the condition, currently ('Degraded').
+	Type MaintenanceTaskConditionType `json:"type"`
+
+	// status of the condition, one of ('True', 'False', 'Unknown').
+	Status corev1.ConditionStatus `json:"status"`
+
+	// lastTransitionTime is the timestamp corresponding to the last status
+	// change of this condition.
+	// +nullable
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// reason is a brief machine readable explanation for the condition's last
+	// transition.
+	Reason string `json:"reason,omitempty"`
+
+	// message is a human readable description of the details of the last
+	// transition, complementing reason.
+	Message string `json:"message,omitempty"`
+}
+
+// MaintenanceTaskConditionType valid conditions of a MaintenanceTask
+type MaintenanceTaskConditionType string
+
+const (
+	// MaintenanceTaskDegraded means the task is invalid, or it collides with
+	// a file or unit of another MachineConfig of some of its pools and isn't
+	// rendered for them.
+	MaintenanceTaskDegraded MaintenanceTaskConditionType = "Degraded"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MaintenanceTaskList is a list of MaintenanceTask resources
+type MaintenanceTaskList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []MaintenanceTask `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MachineConfigProfile applies a bundle of node settings shipped with the
+// operator, e.g. the kernel arguments, sysctls and kubelet settings of
+// low-latency nodes, to the selected pools.
+type MachineConfigProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec MachineConfigProfileSpec `json:"spec"`
+	// +optional
+	Status MachineConfigProfileStatus `json:"status"`
+}
+
+// MachineConfigProfileSpec defines the desired state of MachineConfigProfile
+type MachineConfigProfileSpec struct {
+	// machineConfigPoolSelector selects the pools the profile applies to.
+	// A nil selector selects no pools.
+	MachineConfigPoolSelector *metav1.LabelSelector `json:"machineConfigPoolSelector"`
+
+	// profile is the bundle of settings to apply.
+	Profile ProfileType `json:"profile"`
+}
+
+// ProfileType is a bundle of node settings of a MachineConfigProfile.
+type ProfileType string
+
+const (
+	// ProfileLowLatency tunes the kernel and the kubelet for latency
+	// sensitive workloads pinned to dedicated CPUs.
+	ProfileLowLatency ProfileType = "low-latency"
+
+	// ProfileHardened enables kernel self-protection settings and makes the
+	// kubelet refuse to run with kernel defaults it doesn't expect.
+	ProfileHardened ProfileType = "hardened"
+
+	// ProfileEdgeMinimal reduces the disk and memory footprint of the
+	// node services on small edge nodes.
+	ProfileEdgeMinimal ProfileType = "edge-minimal"
+)
+
+// MachineConfigProfileStatus defines the observed state of a MachineConfigProfile
+type MachineConfigProfileStatus struct {
+	// observedGeneration represents the generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// machineConfigPools are the names of the pools the profile is applied to.
+	// +optional
+	MachineConfigPools []string `json:"machineConfigPools,omitempty"`
+
+	// profileVersion is the version of the operator whose bundle of the
+	// profile is applied. The bundles change with the operator, and the
+	// pools update when the operator does.
+	// +optional
+	ProfileVersion string `json:"profileVersion,omitempty"`
+
+	// conditions represents the latest available observations of current state.
+	// +optional
+	Conditions []MachineConfigProfileCondition `json:"conditions"`
+}
+
+// MachineConfigProfileCondition contains condition information for a MachineConfigProfile
+type MachineConfigProfileCondition struct {
+	// type of the condition, currently ('Degraded').
+	Type MachineConfigProfileConditionType `json:"type"`
+
+	// status of the condition, one of ('True', 'False', 'Unknown').
+	Status corev1.ConditionStatus `json:"status"`
+
+	// lastTransitionTime is the timestamp corresponding to the last status
+	// change of this condition.
+	// +nullable
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// reason is a brief machine readable explanation for the condition's last
+	// transition.
+	Reason string `json:"reason,omitempty"`
+
+	// message is a human readable description of the details of the last
+	// transition, complementing reason.
+	Message string `json:"message,omitempty"`
+}
+
+// MachineConfigProfileConditionType valid conditions of a MachineConfigProfile
+type MachineConfigProfileConditionType string
+
+const (
+	// MachineConfigProfileDegraded means the profile is invalid, or its
+	// kubelet settings conflict with another KubeletConfig of its pools, and
+	// it isn't applied.
+	MachineConfigProfileDegraded MachineConfigProfileConditionType = "Degraded"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MachineConfigProfileList is a list of MachineConfigProfile resources
+type MachineConfigProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []MachineConfigProfile `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MachineConfigNode is the state of a node as seen by its
+// machine-config-daemon, e.g. its OS deployments, so that it can be
+// inspected without access to the node. The daemon creates it, named after
+// the node, and it's deleted with the node.
+type MachineConfigNode struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec MachineConfigNodeSpec `json:"spec"`
+	// +optional
+	Status MachineConfigNodeStatus `json:"status"`
+}
+
+// MachineConfigNodeSpec defines the desired state of MachineConfigNode
+type MachineConfigNodeSpec struct {
+	// node is the name of the node the status is of.
+	Node string `json:"node"`
+}
+
+// MachineConfigNodeStatus defines the observed state of a MachineConfigNode
+type MachineConfigNodeStatus struct {
+	// lastUpdated is when the daemon last saw the status change.
+	// +nullable
+	LastUpdated metav1.Time `json:"lastUpdated"`
+
+	// bootedDeployment is the OS deployment the node runs.
+	// +optional
+	BootedDeployment *OSDeployment `json:"bootedDeployment,omitempty"`
+
+	// stagedDeployment is the OS deployment the node boots into on its next
+	// reboot, if any.
+	// +optional
+	StagedDeployment *OSDeployment `json:"stagedDeployment,omitempty"`
+
+	// pendingFinalization is true if the staged deployment waits for the
+	// node to reboot to be finalized.
+	// +optional
+	PendingFinalization bool `json:"pendingFinalization,omitempty"`
+
+	// foreignCrioFragments are the CRI-O drop-ins in /etc/crio/crio.conf.d
+	// of the node that aren't part of its MachineConfig.
+	// +optional
+	ForeignCrioFragments []ForeignCrioFragment `json:"foreignCrioFragments,omitempty"`
+
+	// transientErrorRetries is the number of consecutive retries of the
+	// sync after errors that are likely transient. It's reset once a sync
+	// succeeds or the error degrades the node.
+	// +optional
+	TransientErrorRetries int32 `json:"transientErrorRetries,omitempty"`
+
+	// lastTransientError is the error last retried, reset with
+	// transientErrorRetries.
+	// +optional
+	LastTransientError string `json:"lastTransientError,omitempty"`
+}
+
+// ForeignCrioFragment is a CRI-O drop-in on a node that isn't part of its
+// MachineConfig, e.g. written by a third-party agent.
+type ForeignCrioFragment struct {
+	// path is the path of the drop-in on the node.
+	Path string `json:"path"`
+
+	// overrides are the settings of the drop-ins of the MachineConfig the
+	// drop-in overrides, as CRI-O applies the drop-ins in lexical order.
+	// +optional
+	Overrides []CrioSettingOverride `json:"overrides,omitempty"`
+
+	// quarantined is true if the machine-config-daemon moved the drop-in
+	// out of /etc/crio/crio.conf.d because it overrides settings of the
+	// MachineConfig.
+	// +optional
+	Quarantined bool `json:"quarantined,omitempty"`
+}
+
+// CrioSettingOverride is a setting of a CRI-O drop-in of the MachineConfig
+// that a foreign drop-in overrides.
+type CrioSettingOverride struct {
+	// key is the dotted TOML key of the setting, e.g. crio.runtime.log_level.
+	Key string `json:"key"`
+
+	// path is the path of the drop-in of the MachineConfig setting it.
+	Path string `json:"path"`
+
+	// owner is what renders the drop-in of the MachineConfig, e.g.
+	// ContainerRuntimeConfig, or MachineConfig for the ones of the
+	// MachineConfigs of the cluster admin.
+	Owner string `json:"owner"`
+}
+
+// OSDeployment is an OS deployment of a node, as reported by
+// `rpm-ostree status --json`.
+type OSDeployment struct {
+	// osImageURL is the OS image the deployment was rebased to by the
+	// machine-config-daemon, empty if it never was.
+	// +optional
+	OSImageURL string `json:"osImageURL,omitempty"`
+
+	// version is the OS version of the deployment.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// checksum is the checksum of the OSTree commit of the deployment.
+	Checksum string `json:"checksum"`
+
+	// baseChecksum is the checksum of the OSTree commit of the deployment
+	// without its layered packages, if it has any.
+	// +optional
+	BaseChecksum string `json:"baseChecksum,omitempty"`
+
+	// layeredPackages are the packages layered on the OS image, e.g. by
+	// extensions.
+	// +optional
+	LayeredPackages []string `json:"layeredPackages,omitempty"`
+
+	// localPackages are the packages layered on the OS image from local
+	// RPM files.
+	// +optional
+	LocalPackages []string `json:"localPackages,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MachineConfigNodeList is a list of MachineConfigNode resources
+type MachineConfigNodeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []MachineConfigNode `json:"items"`
+}
+
+// MachineConfigFreezeName is the name of the only MachineConfigFreeze the
+// operator acts on.
+const MachineConfigFreezeName = "cluster"
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MachineConfigFreeze halts node updates in all pools while frozen, for
+// incident response.  Only the one named "cluster" is acted on.
+type MachineConfigFreeze struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec MachineConfigFreezeSpec `json:"spec"`
+}
+
+// MachineConfigFreezeSpec is the spec of a MachineConfigFreeze.
+type MachineConfigFreezeSpec struct {
+	// frozen halts node updates in all pools: no node is given a new
+	// desired config until it's unset.  Nodes already updating finish.
+	Frozen bool `json:"frozen"`
+
+	// reason is why node updates are frozen, shown in the status of the
+	// pools and the ClusterOperator.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// frozenBy is the user who froze node updates.  It's set on admission
+	// from the user making the request, any value given is replaced.
+	// +optional
+	FrozenBy string `json:"frozenBy,omitempty"`
+
+	// frozenAt is when node updates were frozen.  It's set on admission
+	// like frozenBy.
+	// +optional
+	// +nullable
+	FrozenAt *metav1.Time `json:"frozenAt,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MachineConfigFreezeList is a list of MachineConfigFreeze resources
+type MachineConfigFreezeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []MachineConfigFreeze `json:"items"`
+}
@@ -13,6 +13,79 @@ import (
 	intstr "k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdaptiveConcurrencyNode) DeepCopyInto(out *AdaptiveConcurrencyNode) {
+	*out = *in
+	in.Since.DeepCopyInto(&out.Since)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdaptiveConcurrencyNode.
+func (in *AdaptiveConcurrencyNode) DeepCopy() *AdaptiveConcurrencyNode {
+	if in == nil {
+		return nil
+	}
+	out := new(AdaptiveConcurrencyNode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdaptiveConcurrencyPolicy) DeepCopyInto(out *AdaptiveConcurrencyPolicy) {
+	*out = *in
+	out.ExpectedNodeUpdateDuration = in.ExpectedNodeUpdateDuration
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdaptiveConcurrencyPolicy.
+func (in *AdaptiveConcurrencyPolicy) DeepCopy() *AdaptiveConcurrencyPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AdaptiveConcurrencyPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdaptiveConcurrencyStatus) DeepCopyInto(out *AdaptiveConcurrencyStatus) {
+	*out = *in
+	if in.UpdatingNodes != nil {
+		in, out := &in.UpdatingNodes, &out.UpdatingNodes
+		*out = make([]AdaptiveConcurrencyNode, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdaptiveConcurrencyStatus.
+func (in *AdaptiveConcurrencyStatus) DeepCopy() *AdaptiveConcurrencyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AdaptiveConcurrencyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootloaderConfiguration) DeepCopyInto(out *BootloaderConfiguration) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootloaderConfiguration.
+func (in *BootloaderConfiguration) DeepCopy() *BootloaderConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(BootloaderConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContainerRuntimeConfig) DeepCopyInto(out *ContainerRuntimeConfig) {
 	*out = *in
@@ -150,6 +223,23 @@ func (in *ContainerRuntimeConfiguration) DeepCopyInto(out *ContainerRuntimeConfi
 	}
 	out.LogSizeMax = in.LogSizeMax.DeepCopy()
 	out.OverlaySize = in.OverlaySize.DeepCopy()
+	if in.ShortNameAliases != nil {
+		in, out := &in.ShortNameAliases, &out.ShortNameAliases
+		*out = make([]ShortNameAlias, len(*in))
+		copy(*out, *in)
+	}
+	if in.UnqualifiedSearchRegistries != nil {
+		in, out := &in.UnqualifiedSearchRegistries, &out.UnqualifiedSearchRegistries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RuntimeHandlers != nil {
+		in, out := &in.RuntimeHandlers, &out.RuntimeHandlers
+		*out = make([]ContainerRuntimeHandler, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -163,6 +253,27 @@ func (in *ContainerRuntimeConfiguration) DeepCopy() *ContainerRuntimeConfigurati
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerRuntimeHandler) DeepCopyInto(out *ContainerRuntimeHandler) {
+	*out = *in
+	if in.AllowedAnnotations != nil {
+		in, out := &in.AllowedAnnotations, &out.AllowedAnnotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerRuntimeHandler.
+func (in *ContainerRuntimeHandler) DeepCopy() *ContainerRuntimeHandler {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerRuntimeHandler)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControllerConfig) DeepCopyInto(out *ControllerConfig) {
 	*out = *in
@@ -227,6 +338,11 @@ func (in *ControllerConfigList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControllerConfigSpec) DeepCopyInto(out *ControllerConfigSpec) {
 	*out = *in
+	if in.ServiceNetwork != nil {
+		in, out := &in.ServiceNetwork, &out.ServiceNetwork
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.KubeAPIServerServingCAData != nil {
 		in, out := &in.KubeAPIServerServingCAData, &out.KubeAPIServerServingCAData
 		*out = make([]byte, len(*in))
@@ -264,6 +380,11 @@ func (in *ControllerConfigSpec) DeepCopyInto(out *ControllerConfigSpec) {
 		*out = new(configv1.ProxyStatus)
 		**out = **in
 	}
+	if in.Image != nil {
+		in, out := &in.Image, &out.Image
+		*out = new(configv1.ImageStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Infra != nil {
 		in, out := &in.Infra, &out.Infra
 		*out = new(configv1.Infrastructure)
@@ -332,6 +453,166 @@ func (in *ControllerConfigStatusCondition) DeepCopy() *ControllerConfigStatusCon
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CrioSettingOverride) DeepCopyInto(out *CrioSettingOverride) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CrioSettingOverride.
+func (in *CrioSettingOverride) DeepCopy() *CrioSettingOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(CrioSettingOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailureDomainPolicy) DeepCopyInto(out *FailureDomainPolicy) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailureDomainPolicy.
+func (in *FailureDomainPolicy) DeepCopy() *FailureDomainPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(FailureDomainPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForeignCrioFragment) DeepCopyInto(out *ForeignCrioFragment) {
+	*out = *in
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = make([]CrioSettingOverride, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForeignCrioFragment.
+func (in *ForeignCrioFragment) DeepCopy() *ForeignCrioFragment {
+	if in == nil {
+		return nil
+	}
+	out := new(ForeignCrioFragment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostMTUConfiguration) DeepCopyInto(out *HostMTUConfiguration) {
+	*out = *in
+	if in.Interfaces != nil {
+		in, out := &in.Interfaces, &out.Interfaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostMTUConfiguration.
+func (in *HostMTUConfiguration) DeepCopy() *HostMTUConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(HostMTUConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HugePagesAllocation) DeepCopyInto(out *HugePagesAllocation) {
+	*out = *in
+	if in.NUMANode != nil {
+		in, out := &in.NUMANode, &out.NUMANode
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HugePagesAllocation.
+func (in *HugePagesAllocation) DeepCopy() *HugePagesAllocation {
+	if in == nil {
+		return nil
+	}
+	out := new(HugePagesAllocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HugePagesConfiguration) DeepCopyInto(out *HugePagesConfiguration) {
+	*out = *in
+	if in.Pages != nil {
+		in, out := &in.Pages, &out.Pages
+		*out = make([]HugePagesAllocation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HugePagesConfiguration.
+func (in *HugePagesConfiguration) DeepCopy() *HugePagesConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(HugePagesConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ISCSIConfiguration) DeepCopyInto(out *ISCSIConfiguration) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ISCSIConfiguration.
+func (in *ISCSIConfiguration) DeepCopy() *ISCSIConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ISCSIConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImagePruning) DeepCopyInto(out *ImagePruning) {
+	*out = *in
+	if in.PinnedImages != nil {
+		in, out := &in.PinnedImages, &out.PinnedImages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagePruning.
+func (in *ImagePruning) DeepCopy() *ImagePruning {
+	if in == nil {
+		return nil
+	}
+	out := new(ImagePruning)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeletConfig) DeepCopyInto(out *KubeletConfig) {
 	*out = *in
@@ -474,6 +755,27 @@ func (in *KubeletConfigStatus) DeepCopy() *KubeletConfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LowLatencyConfiguration) DeepCopyInto(out *LowLatencyConfiguration) {
+	*out = *in
+	if in.HugePages != nil {
+		in, out := &in.HugePages, &out.HugePages
+		*out = new(HugePagesConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LowLatencyConfiguration.
+func (in *LowLatencyConfiguration) DeepCopy() *LowLatencyConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(LowLatencyConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MachineConfig) DeepCopyInto(out *MachineConfig) {
 	*out = *in
@@ -535,27 +837,26 @@ func (in *MachineConfigList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineConfigPool) DeepCopyInto(out *MachineConfigPool) {
+func (in *MachineConfigFreeze) DeepCopyInto(out *MachineConfigFreeze) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPool.
-func (in *MachineConfigPool) DeepCopy() *MachineConfigPool {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigFreeze.
+func (in *MachineConfigFreeze) DeepCopy() *MachineConfigFreeze {
 	if in == nil {
 		return nil
 	}
-	out := new(MachineConfigPool)
+	out := new(MachineConfigFreeze)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MachineConfigPool) DeepCopyObject() runtime.Object {
+func (in *MachineConfigFreeze) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -563,30 +864,13 @@ func (in *MachineConfigPool) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineConfigPoolCondition) DeepCopyInto(out *MachineConfigPoolCondition) {
-	*out = *in
-	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolCondition.
-func (in *MachineConfigPoolCondition) DeepCopy() *MachineConfigPoolCondition {
-	if in == nil {
-		return nil
-	}
-	out := new(MachineConfigPoolCondition)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineConfigPoolList) DeepCopyInto(out *MachineConfigPoolList) {
+func (in *MachineConfigFreezeList) DeepCopyInto(out *MachineConfigFreezeList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]MachineConfigPool, len(*in))
+		*out = make([]MachineConfigFreeze, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -594,18 +878,18 @@ func (in *MachineConfigPoolList) DeepCopyInto(out *MachineConfigPoolList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolList.
-func (in *MachineConfigPoolList) DeepCopy() *MachineConfigPoolList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigFreezeList.
+func (in *MachineConfigFreezeList) DeepCopy() *MachineConfigFreezeList {
 	if in == nil {
 		return nil
 	}
-	out := new(MachineConfigPoolList)
+	out := new(MachineConfigFreezeList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MachineConfigPoolList) DeepCopyObject() runtime.Object {
+func (in *MachineConfigFreezeList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -613,24 +897,303 @@ func (in *MachineConfigPoolList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineConfigPoolSpec) DeepCopyInto(out *MachineConfigPoolSpec) {
+func (in *MachineConfigFreezeSpec) DeepCopyInto(out *MachineConfigFreezeSpec) {
 	*out = *in
-	if in.MachineConfigSelector != nil {
-		in, out := &in.MachineConfigSelector, &out.MachineConfigSelector
-		*out = new(metav1.LabelSelector)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = new(metav1.LabelSelector)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.MaxUnavailable != nil {
-		in, out := &in.MaxUnavailable, &out.MaxUnavailable
-		*out = new(intstr.IntOrString)
-		**out = **in
+	if in.FrozenAt != nil {
+		in, out := &in.FrozenAt, &out.FrozenAt
+		*out = (*in).DeepCopy()
 	}
-	in.Configuration.DeepCopyInto(&out.Configuration)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigFreezeSpec.
+func (in *MachineConfigFreezeSpec) DeepCopy() *MachineConfigFreezeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigFreezeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigNode) DeepCopyInto(out *MachineConfigNode) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigNode.
+func (in *MachineConfigNode) DeepCopy() *MachineConfigNode {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigNode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MachineConfigNode) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigNodeList) DeepCopyInto(out *MachineConfigNodeList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MachineConfigNode, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigNodeList.
+func (in *MachineConfigNodeList) DeepCopy() *MachineConfigNodeList {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigNodeList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MachineConfigNodeList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigNodeSpec) DeepCopyInto(out *MachineConfigNodeSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigNodeSpec.
+func (in *MachineConfigNodeSpec) DeepCopy() *MachineConfigNodeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigNodeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigNodeStatus) DeepCopyInto(out *MachineConfigNodeStatus) {
+	*out = *in
+	in.LastUpdated.DeepCopyInto(&out.LastUpdated)
+	if in.BootedDeployment != nil {
+		in, out := &in.BootedDeployment, &out.BootedDeployment
+		*out = new(OSDeployment)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StagedDeployment != nil {
+		in, out := &in.StagedDeployment, &out.StagedDeployment
+		*out = new(OSDeployment)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ForeignCrioFragments != nil {
+		in, out := &in.ForeignCrioFragments, &out.ForeignCrioFragments
+		*out = make([]ForeignCrioFragment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigNodeStatus.
+func (in *MachineConfigNodeStatus) DeepCopy() *MachineConfigNodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigNodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPool) DeepCopyInto(out *MachineConfigPool) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPool.
+func (in *MachineConfigPool) DeepCopy() *MachineConfigPool {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MachineConfigPool) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolCondition) DeepCopyInto(out *MachineConfigPoolCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolCondition.
+func (in *MachineConfigPoolCondition) DeepCopy() *MachineConfigPoolCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolList) DeepCopyInto(out *MachineConfigPoolList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MachineConfigPool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolList.
+func (in *MachineConfigPoolList) DeepCopy() *MachineConfigPoolList {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MachineConfigPoolList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolSpec) DeepCopyInto(out *MachineConfigPoolSpec) {
+	*out = *in
+	if in.MachineConfigSelector != nil {
+		in, out := &in.MachineConfigSelector, &out.MachineConfigSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(PoolStorageConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HostMTU != nil {
+		in, out := &in.HostMTU, &out.HostMTU
+		*out = new(HostMTUConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WorkloadPartitioning != nil {
+		in, out := &in.WorkloadPartitioning, &out.WorkloadPartitioning
+		*out = new(WorkloadPartitioningConfiguration)
+		**out = **in
+	}
+	if in.LowLatency != nil {
+		in, out := &in.LowLatency, &out.LowLatency
+		*out = new(LowLatencyConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Bootloader != nil {
+		in, out := &in.Bootloader, &out.Bootloader
+		*out = new(BootloaderConfiguration)
+		**out = **in
+	}
+	if in.RebootTimeout != nil {
+		in, out := &in.RebootTimeout, &out.RebootTimeout
+		*out = new(RebootTimeoutPolicy)
+		**out = **in
+	}
+	if in.FailureDomain != nil {
+		in, out := &in.FailureDomain, &out.FailureDomain
+		*out = new(FailureDomainPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdaptiveConcurrency != nil {
+		in, out := &in.AdaptiveConcurrency, &out.AdaptiveConcurrency
+		*out = new(AdaptiveConcurrencyPolicy)
+		**out = **in
+	}
+	if in.PreRebootNotification != nil {
+		in, out := &in.PreRebootNotification, &out.PreRebootNotification
+		*out = new(PreRebootNotification)
+		**out = **in
+	}
+	if in.UpdateTaint != nil {
+		in, out := &in.UpdateTaint, &out.UpdateTaint
+		*out = new(UpdateTaint)
+		**out = **in
+	}
+	if in.PostUpdateValidation != nil {
+		in, out := &in.PostUpdateValidation, &out.PostUpdateValidation
+		*out = new(PostUpdateValidation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImagePruning != nil {
+		in, out := &in.ImagePruning, &out.ImagePruning
+		*out = new(ImagePruning)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PullSecret != nil {
+		in, out := &in.PullSecret, &out.PullSecret
+		*out = new(PoolPullSecret)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProgressWebhooks != nil {
+		in, out := &in.ProgressWebhooks, &out.ProgressWebhooks
+		*out = make([]ProgressWebhook, len(*in))
+		copy(*out, *in)
+	}
+	in.Configuration.DeepCopyInto(&out.Configuration)
 	return
 }
 
@@ -648,6 +1211,11 @@ func (in *MachineConfigPoolSpec) DeepCopy() *MachineConfigPoolSpec {
 func (in *MachineConfigPoolStatus) DeepCopyInto(out *MachineConfigPoolStatus) {
 	*out = *in
 	in.Configuration.DeepCopyInto(&out.Configuration)
+	if in.DegradedReasons != nil {
+		in, out := &in.DegradedReasons, &out.DegradedReasons
+		*out = make([]PoolDegradedReason, len(*in))
+		copy(*out, *in)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]MachineConfigPoolCondition, len(*in))
@@ -655,6 +1223,18 @@ func (in *MachineConfigPoolStatus) DeepCopyInto(out *MachineConfigPoolStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.DrainBlockers != nil {
+		in, out := &in.DrainBlockers, &out.DrainBlockers
+		*out = make([]PoolDrainBlocker, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AdaptiveConcurrency != nil {
+		in, out := &in.AdaptiveConcurrency, &out.AdaptiveConcurrency
+		*out = new(AdaptiveConcurrencyStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -691,38 +1271,313 @@ func (in *MachineConfigPoolStatusConfiguration) DeepCopy() *MachineConfigPoolSta
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineConfigSpec) DeepCopyInto(out *MachineConfigSpec) {
+func (in *MachineConfigProfile) DeepCopyInto(out *MachineConfigProfile) {
 	*out = *in
-	in.Config.DeepCopyInto(&out.Config)
-	if in.KernelArguments != nil {
-		in, out := &in.KernelArguments, &out.KernelArguments
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.Extensions != nil {
-		in, out := &in.Extensions, &out.Extensions
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigSpec.
-func (in *MachineConfigSpec) DeepCopy() *MachineConfigSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigProfile.
+func (in *MachineConfigProfile) DeepCopy() *MachineConfigProfile {
 	if in == nil {
 		return nil
 	}
-	out := new(MachineConfigSpec)
+	out := new(MachineConfigProfile)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MachineConfigProfile) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NetworkInfo) DeepCopyInto(out *NetworkInfo) {
+func (in *MachineConfigProfileCondition) DeepCopyInto(out *MachineConfigProfileCondition) {
 	*out = *in
-	if in.MTUMigration != nil {
-		in, out := &in.MTUMigration, &out.MTUMigration
-		*out = new(configv1.MTUMigration)
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigProfileCondition.
+func (in *MachineConfigProfileCondition) DeepCopy() *MachineConfigProfileCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigProfileCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigProfileList) DeepCopyInto(out *MachineConfigProfileList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MachineConfigProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigProfileList.
+func (in *MachineConfigProfileList) DeepCopy() *MachineConfigProfileList {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigProfileList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MachineConfigProfileList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigProfileSpec) DeepCopyInto(out *MachineConfigProfileSpec) {
+	*out = *in
+	if in.MachineConfigPoolSelector != nil {
+		in, out := &in.MachineConfigPoolSelector, &out.MachineConfigPoolSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigProfileSpec.
+func (in *MachineConfigProfileSpec) DeepCopy() *MachineConfigProfileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigProfileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigProfileStatus) DeepCopyInto(out *MachineConfigProfileStatus) {
+	*out = *in
+	if in.MachineConfigPools != nil {
+		in, out := &in.MachineConfigPools, &out.MachineConfigPools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]MachineConfigProfileCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigProfileStatus.
+func (in *MachineConfigProfileStatus) DeepCopy() *MachineConfigProfileStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigProfileStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigSpec) DeepCopyInto(out *MachineConfigSpec) {
+	*out = *in
+	in.Config.DeepCopyInto(&out.Config)
+	if in.KernelArguments != nil {
+		in, out := &in.KernelArguments, &out.KernelArguments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Extensions != nil {
+		in, out := &in.Extensions, &out.Extensions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigSpec.
+func (in *MachineConfigSpec) DeepCopy() *MachineConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceTask) DeepCopyInto(out *MaintenanceTask) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceTask.
+func (in *MaintenanceTask) DeepCopy() *MaintenanceTask {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceTask)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MaintenanceTask) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceTaskCondition) DeepCopyInto(out *MaintenanceTaskCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceTaskCondition.
+func (in *MaintenanceTaskCondition) DeepCopy() *MaintenanceTaskCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceTaskCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceTaskList) DeepCopyInto(out *MaintenanceTaskList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MaintenanceTask, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceTaskList.
+func (in *MaintenanceTaskList) DeepCopy() *MaintenanceTaskList {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceTaskList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MaintenanceTaskList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceTaskSpec) DeepCopyInto(out *MaintenanceTaskSpec) {
+	*out = *in
+	if in.MachineConfigPoolSelector != nil {
+		in, out := &in.MachineConfigPoolSelector, &out.MachineConfigPoolSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RandomizedDelay != nil {
+		in, out := &in.RandomizedDelay, &out.RandomizedDelay
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceTaskSpec.
+func (in *MaintenanceTaskSpec) DeepCopy() *MaintenanceTaskSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceTaskSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceTaskStatus) DeepCopyInto(out *MaintenanceTaskStatus) {
+	*out = *in
+	if in.MachineConfigPools != nil {
+		in, out := &in.MachineConfigPools, &out.MachineConfigPools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]MaintenanceTaskCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceTaskStatus.
+func (in *MaintenanceTaskStatus) DeepCopy() *MaintenanceTaskStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceTaskStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MultipathConfiguration) DeepCopyInto(out *MultipathConfiguration) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultipathConfiguration.
+func (in *MultipathConfiguration) DeepCopy() *MultipathConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(MultipathConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkInfo) DeepCopyInto(out *NetworkInfo) {
+	*out = *in
+	if in.MTUMigration != nil {
+		in, out := &in.MTUMigration, &out.MTUMigration
+		*out = new(configv1.MTUMigration)
 		(*in).DeepCopyInto(*out)
 	}
 	return
@@ -737,3 +1592,444 @@ func (in *NetworkInfo) DeepCopy() *NetworkInfo {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OSDeployment) DeepCopyInto(out *OSDeployment) {
+	*out = *in
+	if in.LayeredPackages != nil {
+		in, out := &in.LayeredPackages, &out.LayeredPackages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LocalPackages != nil {
+		in, out := &in.LocalPackages, &out.LocalPackages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSDeployment.
+func (in *OSDeployment) DeepCopy() *OSDeployment {
+	if in == nil {
+		return nil
+	}
+	out := new(OSDeployment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolDegradedReason) DeepCopyInto(out *PoolDegradedReason) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolDegradedReason.
+func (in *PoolDegradedReason) DeepCopy() *PoolDegradedReason {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolDegradedReason)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolDrainBlocker) DeepCopyInto(out *PoolDrainBlocker) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolDrainBlocker.
+func (in *PoolDrainBlocker) DeepCopy() *PoolDrainBlocker {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolDrainBlocker)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolProgressEvent) DeepCopyInto(out *PoolProgressEvent) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolProgressEvent.
+func (in *PoolProgressEvent) DeepCopy() *PoolProgressEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolProgressEvent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolPullSecret) DeepCopyInto(out *PoolPullSecret) {
+	*out = *in
+	if in.Registries != nil {
+		in, out := &in.Registries, &out.Registries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolPullSecret.
+func (in *PoolPullSecret) DeepCopy() *PoolPullSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolPullSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolStorageConfiguration) DeepCopyInto(out *PoolStorageConfiguration) {
+	*out = *in
+	if in.Multipath != nil {
+		in, out := &in.Multipath, &out.Multipath
+		*out = new(MultipathConfiguration)
+		**out = **in
+	}
+	if in.ISCSI != nil {
+		in, out := &in.ISCSI, &out.ISCSI
+		*out = new(ISCSIConfiguration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolStorageConfiguration.
+func (in *PoolStorageConfiguration) DeepCopy() *PoolStorageConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolStorageConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostUpdateValidation) DeepCopyInto(out *PostUpdateValidation) {
+	*out = *in
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = make([]ValidationProbe, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostUpdateValidation.
+func (in *PostUpdateValidation) DeepCopy() *PostUpdateValidation {
+	if in == nil {
+		return nil
+	}
+	out := new(PostUpdateValidation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreRebootNotification) DeepCopyInto(out *PreRebootNotification) {
+	*out = *in
+	out.LeadTime = in.LeadTime
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreRebootNotification.
+func (in *PreRebootNotification) DeepCopy() *PreRebootNotification {
+	if in == nil {
+		return nil
+	}
+	out := new(PreRebootNotification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreRebootNotificationEvent) DeepCopyInto(out *PreRebootNotificationEvent) {
+	*out = *in
+	in.DrainTime.DeepCopyInto(&out.DrainTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreRebootNotificationEvent.
+func (in *PreRebootNotificationEvent) DeepCopy() *PreRebootNotificationEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(PreRebootNotificationEvent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProgressWebhook) DeepCopyInto(out *ProgressWebhook) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProgressWebhook.
+func (in *ProgressWebhook) DeepCopy() *ProgressWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(ProgressWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RebootTimeoutPolicy) DeepCopyInto(out *RebootTimeoutPolicy) {
+	*out = *in
+	out.Timeout = in.Timeout
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RebootTimeoutPolicy.
+func (in *RebootTimeoutPolicy) DeepCopy() *RebootTimeoutPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RebootTimeoutPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShortNameAlias) DeepCopyInto(out *ShortNameAlias) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShortNameAlias.
+func (in *ShortNameAlias) DeepCopy() *ShortNameAlias {
+	if in == nil {
+		return nil
+	}
+	out := new(ShortNameAlias)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdateTaint) DeepCopyInto(out *UpdateTaint) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateTaint.
+func (in *UpdateTaint) DeepCopy() *UpdateTaint {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdateTaint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdateWave) DeepCopyInto(out *UpdateWave) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateWave.
+func (in *UpdateWave) DeepCopy() *UpdateWave {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdateWave)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *UpdateWave) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdateWaveCondition) DeepCopyInto(out *UpdateWaveCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateWaveCondition.
+func (in *UpdateWaveCondition) DeepCopy() *UpdateWaveCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdateWaveCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdateWaveList) DeepCopyInto(out *UpdateWaveList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]UpdateWave, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateWaveList.
+func (in *UpdateWaveList) DeepCopy() *UpdateWaveList {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdateWaveList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *UpdateWaveList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdateWaveSpec) DeepCopyInto(out *UpdateWaveSpec) {
+	*out = *in
+	if in.MachineConfigPools != nil {
+		in, out := &in.MachineConfigPools, &out.MachineConfigPools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateWaveSpec.
+func (in *UpdateWaveSpec) DeepCopy() *UpdateWaveSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdateWaveSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdateWaveStage) DeepCopyInto(out *UpdateWaveStage) {
+	*out = *in
+	if in.MachineConfigPools != nil {
+		in, out := &in.MachineConfigPools, &out.MachineConfigPools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateWaveStage.
+func (in *UpdateWaveStage) DeepCopy() *UpdateWaveStage {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdateWaveStage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdateWaveStatus) DeepCopyInto(out *UpdateWaveStatus) {
+	*out = *in
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]UpdateWaveStage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]UpdateWaveCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateWaveStatus.
+func (in *UpdateWaveStatus) DeepCopy() *UpdateWaveStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdateWaveStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValidationProbe) DeepCopyInto(out *ValidationProbe) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValidationProbe.
+func (in *ValidationProbe) DeepCopy() *ValidationProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(ValidationProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadPartitioningConfiguration) DeepCopyInto(out *WorkloadPartitioningConfiguration) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadPartitioningConfiguration.
+func (in *WorkloadPartitioningConfiguration) DeepCopy() *WorkloadPartitioningConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadPartitioningConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
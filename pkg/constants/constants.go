@@ -1,6 +1,12 @@
 package constants
 
 import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -29,8 +35,85 @@ var (
 		Key:    "UpdateInProgress",
 		Effect: corev1.TaintEffectPreferNoSchedule,
 	}
-	// ConstantsByName is a map of constants for ease of templating
+	// ConstantsByName is a map of constants for ease of templating.  These are
+	// the built-in values, TemplateConstants returns them with the overrides
+	// loaded at runtime.
 	ConstantsByName = map[string]string{
 		"APIServerURLFile": APIServerURLFile,
 	}
+
+	// constantSchema validates the values a constant may be overridden with.
+	// Constants without a validator can't be overridden.
+	constantSchema = map[string]func(string) error{
+		"APIServerURLFile": validateAbsolutePath,
+	}
+
+	overridesLock sync.RWMutex
+	overrides     map[string]string
 )
+
+func validateAbsolutePath(value string) error {
+	if !path.IsAbs(value) || path.Clean(value) != value {
+		return fmt.Errorf("%q is not a clean absolute path", value)
+	}
+	return nil
+}
+
+// ParseConstantOverrides parses overrides of the template constants from a
+// JSON object mapping the names of constants to their values, and validates
+// them against the schema of each constant.
+func ParseConstantOverrides(data []byte) (map[string]string, error) {
+	values := map[string]string{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("constants must be a JSON object of strings: %w", err)
+	}
+	var errs []string
+	for name, value := range values {
+		validate, ok := constantSchema[name]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("unknown constant %s", name))
+			continue
+		}
+		if err := validate(value); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid value of constant %s: %v", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	return values, nil
+}
+
+// SetConstantOverrides replaces the overrides of the template constants, nil
+// restores the built-in values.  It reports whether the overrides changed.
+func SetConstantOverrides(values map[string]string) bool {
+	overridesLock.Lock()
+	defer overridesLock.Unlock()
+	if len(values) == 0 && len(overrides) == 0 {
+		return false
+	}
+	changed := len(values) != len(overrides)
+	for name, value := range values {
+		if current, ok := overrides[name]; !ok || current != value {
+			changed = true
+		}
+	}
+	overrides = values
+	return changed
+}
+
+// TemplateConstants returns the constants templates are rendered with:
+// ConstantsByName with the overrides applied.
+func TemplateConstants() map[string]string {
+	overridesLock.RLock()
+	defer overridesLock.RUnlock()
+	values := make(map[string]string, len(ConstantsByName))
+	for name, value := range ConstantsByName {
+		values[name] = value
+	}
+	for name, value := range overrides {
+		values[name] = value
+	}
+	return values
+}
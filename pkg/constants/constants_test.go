@@ -0,0 +1,38 @@
+package constants
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConstantOverrides(t *testing.T) {
+	values, err := ParseConstantOverrides([]byte(`{"APIServerURLFile": "/etc/kubernetes/apiserver.env"}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"APIServerURLFile": "/etc/kubernetes/apiserver.env"}, values)
+
+	for _, invalid := range []string{
+		`["APIServerURLFile"]`,
+		`{"APIServerURLFile": 1}`,
+		`{"Unknown": "/etc/unknown"}`,
+		`{"APIServerURLFile": "apiserver-url.env"}`,
+		`{"APIServerURLFile": "/etc/kubernetes/../apiserver-url.env"}`,
+	} {
+		_, err := ParseConstantOverrides([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestTemplateConstants(t *testing.T) {
+	defer SetConstantOverrides(nil)
+	assert.Equal(t, ConstantsByName, TemplateConstants())
+
+	assert.True(t, SetConstantOverrides(map[string]string{"APIServerURLFile": "/etc/kubernetes/apiserver.env"}))
+	assert.False(t, SetConstantOverrides(map[string]string{"APIServerURLFile": "/etc/kubernetes/apiserver.env"}))
+	assert.Equal(t, "/etc/kubernetes/apiserver.env", TemplateConstants()["APIServerURLFile"])
+	assert.Equal(t, APIServerURLFile, ConstantsByName["APIServerURLFile"])
+
+	assert.True(t, SetConstantOverrides(nil))
+	assert.Equal(t, ConstantsByName, TemplateConstants())
+}
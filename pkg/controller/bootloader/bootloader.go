@@ -0,0 +1,85 @@
+package bootloader
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/vincent-petithory/dataurl"
+	corev1 "k8s.io/api/core/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	// PasswordHashKey is the key of the password hash in the Secret of a pool's bootloader.
+	PasswordHashKey = "passwordHash"
+
+	// userCfgPath is where the GRUB user config is staged for the MCD, which
+	// installs it as /boot/grub2/user.cfg.  The grub2 config of RHCOS reads
+	// the password of the root superuser from it.
+	userCfgPath = "/etc/machine-config-daemon/bootloader/user.cfg"
+)
+
+// passwordHashRegexp matches the hashes printed by grub2-mkpasswd-pbkdf2.
+var passwordHashRegexp = regexp.MustCompile(`^grub\.pbkdf2\.sha512\.[0-9]+\.[0-9A-Fa-f]+\.[0-9A-Fa-f]+$`)
+
+// generatedConfigKind describes the MachineConfigs generated from the bootloader configuration of the pools.
+var generatedConfigKind = ctrlcommon.GeneratedConfigKind{
+	Suffix:        "bootloader",
+	Description:   "bootloader configuration",
+	ConditionType: mcfgv1.MachineConfigPoolBootloaderDegraded,
+	InvalidReason: "BootloaderConfigInvalid",
+}
+
+// passwordHash returns the validated password hash of a bootloader Secret.
+func passwordHash(secret *corev1.Secret) (string, error) {
+	hash := strings.TrimSpace(string(secret.Data[PasswordHashKey]))
+	if hash == "" {
+		return "", fmt.Errorf("Secret %s has no %s", secret.Name, PasswordHashKey)
+	}
+	if !passwordHashRegexp.MatchString(hash) {
+		return "", fmt.Errorf("%s of Secret %s is not a grub.pbkdf2.sha512 hash, generate it with grub2-mkpasswd-pbkdf2", PasswordHashKey, secret.Name)
+	}
+	return hash, nil
+}
+
+// generateMachineConfig renders the bootloader password of the pool into a
+// MachineConfig for the pool's role.  Unlike the Secret, the MachineConfig
+// can be read by anyone allowed to read MachineConfigs, and the MCS serves
+// the rendered config without authentication, so the hash is exposed to
+// offline guessing.
+func generateMachineConfig(pool *mcfgv1.MachineConfigPool, secret *corev1.Secret) (*mcfgv1.MachineConfig, error) {
+	hash, err := passwordHash(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	// only root may read the hash
+	mode := 0600
+	overwrite := true
+	du := dataurl.New([]byte(fmt.Sprintf("GRUB2_PASSWORD=%s\n", hash)), "text/plain")
+	du.Encoding = dataurl.EncodingASCII
+	source := du.String()
+	ignConfig := ctrlcommon.NewIgnConfig()
+	ignConfig.Storage.Files = append(ignConfig.Storage.Files, ign3types.File{
+		Node: ign3types.Node{
+			Path:      userCfgPath,
+			Overwrite: &overwrite,
+		},
+		FileEmbedded1: ign3types.FileEmbedded1{
+			Mode: &mode,
+			Contents: ign3types.Resource{
+				Source: &source,
+			},
+		},
+	})
+
+	mc, err := generatedConfigKind.NewMachineConfig(pool, ignConfig)
+	if err != nil {
+		return nil, err
+	}
+	return mc, nil
+}
@@ -0,0 +1,293 @@
+package bootloader
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformersv1 "k8s.io/client-go/informers/core/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	corev1clientset "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+)
+
+const (
+	// maxRetries is the number of times a pool will be retried before it is dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// a pool is going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+)
+
+// Controller defines the bootloader controller, which renders the bootloader
+// password of the pools into MachineConfigs.
+type Controller struct {
+	generated *ctrlcommon.GeneratedConfigs
+
+	syncHandler func(key string) error
+
+	mcpLister    mcfglistersv1.MachineConfigPoolLister
+	secretLister corelisterv1.SecretLister
+
+	mcpListerSynced    cache.InformerSynced
+	mcListerSynced     cache.InformerSynced
+	secretListerSynced cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+}
+
+// New returns a new bootloader controller.  secretInformer watches the
+// Secrets of the MCO namespace.
+func New(
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	mcInformer mcfginformersv1.MachineConfigInformer,
+	secretInformer coreinformersv1.SecretInformer,
+	kubeClient clientset.Interface,
+	mcfgClient mcfgclientset.Interface,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(&corev1clientset.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	ctrl := &Controller{
+		generated: &ctrlcommon.GeneratedConfigs{
+			GeneratedConfigKind: generatedConfigKind,
+			Client:              mcfgClient,
+			EventRecorder:       eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-bootloadercontroller"}),
+		},
+		queue: workqueue.NewNamedRateLimitingQueue(ctrlcommon.ControllerRateLimiter(), "machineconfigcontroller-bootloadercontroller"),
+	}
+
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addPool,
+		UpdateFunc: ctrl.updatePool,
+	})
+	mcInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: ctrl.updateMachineConfig,
+		DeleteFunc: ctrl.deleteMachineConfig,
+	})
+	secretInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addSecret,
+		UpdateFunc: ctrl.updateSecret,
+		DeleteFunc: ctrl.deleteSecret,
+	})
+
+	ctrl.syncHandler = ctrl.syncPool
+
+	ctrl.mcpLister = mcpInformer.Lister()
+	ctrl.generated.MCLister = mcInformer.Lister()
+	ctrl.secretLister = secretInformer.Lister()
+	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
+	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
+	ctrl.secretListerSynced = secretInformer.Informer().HasSynced
+
+	return ctrl
+}
+
+// Run executes the bootloader controller
+func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer ctrl.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.mcListerSynced, ctrl.secretListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-BootloaderController")
+	defer glog.Info("Shutting down MachineConfigController-BootloaderController")
+
+	for i := 0; i < workers; i++ {
+		go wait.Until(ctrl.worker, time.Second, stopCh)
+	}
+
+	<-stopCh
+}
+
+func (ctrl *Controller) addPool(obj interface{}) {
+	pool := obj.(*mcfgv1.MachineConfigPool)
+	glog.V(4).Infof("Adding MachineConfigPool %s", pool.Name)
+	ctrl.queue.Add(pool.Name)
+}
+
+func (ctrl *Controller) updatePool(old, cur interface{}) {
+	oldPool := old.(*mcfgv1.MachineConfigPool)
+	curPool := cur.(*mcfgv1.MachineConfigPool)
+	// Pools are updated all the time, only the bootloader matters.
+	if reflect.DeepEqual(oldPool.Spec.Bootloader, curPool.Spec.Bootloader) {
+		return
+	}
+	glog.V(4).Infof("Updating bootloader of MachineConfigPool %s", curPool.Name)
+	ctrl.queue.Add(curPool.Name)
+}
+
+func (ctrl *Controller) addSecret(obj interface{}) {
+	ctrl.enqueuePoolsForSecret(obj.(*corev1.Secret).Name)
+}
+
+func (ctrl *Controller) updateSecret(old, cur interface{}) {
+	oldSecret := old.(*corev1.Secret)
+	curSecret := cur.(*corev1.Secret)
+	if reflect.DeepEqual(oldSecret.Data, curSecret.Data) {
+		return
+	}
+	ctrl.enqueuePoolsForSecret(curSecret.Name)
+}
+
+func (ctrl *Controller) deleteSecret(obj interface{}) {
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		secret, ok = tombstone.Obj.(*corev1.Secret)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a Secret %#v", obj))
+			return
+		}
+	}
+	ctrl.enqueuePoolsForSecret(secret.Name)
+}
+
+// enqueuePoolsForSecret requeues the pools whose bootloader password is in
+// the Secret with the given name.
+func (ctrl *Controller) enqueuePoolsForSecret(name string) {
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't list MachineConfigPools: %w", err))
+		return
+	}
+	for _, pool := range pools {
+		if pool.Spec.Bootloader != nil && pool.Spec.Bootloader.PasswordSecret == name {
+			glog.V(4).Infof("Secret %s changed, requeueing MachineConfigPool %s", name, pool.Name)
+			ctrl.queue.Add(pool.Name)
+		}
+	}
+}
+
+func (ctrl *Controller) updateMachineConfig(old, cur interface{}) {
+	mc := cur.(*mcfgv1.MachineConfig)
+	if pool := generatedConfigKind.OwningPool(mc); pool != "" {
+		glog.V(4).Infof("MachineConfig %s generated for MachineConfigPool %s updated", mc.Name, pool)
+		ctrl.queue.Add(pool)
+	}
+}
+
+func (ctrl *Controller) deleteMachineConfig(obj interface{}) {
+	mc, ok := obj.(*mcfgv1.MachineConfig)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		mc, ok = tombstone.Obj.(*mcfgv1.MachineConfig)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a MachineConfig %#v", obj))
+			return
+		}
+	}
+	if pool := generatedConfigKind.OwningPool(mc); pool != "" {
+		glog.V(4).Infof("MachineConfig %s generated for MachineConfigPool %s deleted", mc.Name, pool)
+		ctrl.queue.Add(pool)
+	}
+}
+
+// worker runs a worker thread that just dequeues items, processes them, and marks them done.
+// It enforces that the syncHandler is never invoked concurrently with the same key.
+func (ctrl *Controller) worker() {
+	for ctrl.processNextWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextWorkItem() bool {
+	key, quit := ctrl.queue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	err := ctrl.syncHandler(key.(string))
+	ctrl.handleErr(err, key)
+
+	return true
+}
+
+func (ctrl *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.queue.Forget(key)
+		return
+	}
+
+	if ctrl.queue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing bootloader of MachineConfigPool %v: %v", key, err)
+		ctrl.queue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping MachineConfigPool %q out of the queue: %v", key, err)
+	ctrl.queue.Forget(key)
+	ctrl.queue.AddAfter(key, 1*time.Minute)
+}
+
+// syncPool renders the bootloader password of the pool with the given name
+// into its MachineConfig, or deletes the MachineConfig if there is none.
+// A deleted pool's MachineConfig is garbage collected through its owner reference.
+// This function is not meant to be invoked concurrently with the same key.
+func (ctrl *Controller) syncPool(name string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing bootloader of MachineConfigPool %q (%v)", name, startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing bootloader of MachineConfigPool %q (%v)", name, time.Since(startTime))
+	}()
+
+	pool, err := ctrl.mcpLister.Get(name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if pool.Spec.Bootloader == nil {
+		return ctrl.generated.Delete(pool)
+	}
+
+	secret, err := ctrl.secretLister.Secrets(ctrlcommon.MCONamespace).Get(pool.Spec.Bootloader.PasswordSecret)
+	if errors.IsNotFound(err) {
+		return ctrl.generated.SyncFailingStatus(pool, fmt.Errorf("Secret %s not found in namespace %s", pool.Spec.Bootloader.PasswordSecret, ctrlcommon.MCONamespace))
+	}
+	if err != nil {
+		return err
+	}
+
+	mc, err := generateMachineConfig(pool, secret)
+	if err != nil {
+		return ctrl.generated.SyncFailingStatus(pool, err)
+	}
+	if updated, err := ctrl.generated.Apply(pool, mc); err != nil {
+		return err
+	} else if updated {
+		ctrl.generated.EventRecorder.Eventf(pool, corev1.EventTypeNormal, "BootloaderConfigApplied", "Generated MachineConfig %s from the bootloader password", mc.Name)
+	}
+	return nil
+}
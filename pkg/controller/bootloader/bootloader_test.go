@@ -0,0 +1,52 @@
+package bootloader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+const testHash = "grub.pbkdf2.sha512.10000.4F0C1B2A.9D8E7F6A5B4C"
+
+func newSecret(name, hash string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ctrlcommon.MCONamespace},
+		Data:       map[string][]byte{PasswordHashKey: []byte(hash)},
+	}
+}
+
+func TestPasswordHash(t *testing.T) {
+	hash, err := passwordHash(newSecret("grub", testHash+"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, testHash, hash)
+
+	for _, invalid := range []string{"", "hunter2", "grub.pbkdf2.sha512.10000.salt", "grub.pbkdf2.sha512.10000.AB.CD\nGRUB2_PASSWORD=x"} {
+		_, err := passwordHash(newSecret("grub", invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestGenerateMachineConfig(t *testing.T) {
+	pool := helpers.NewPool("infra")
+	pool.Spec.Bootloader = &mcfgv1.BootloaderConfiguration{PasswordSecret: "grub"}
+	mc, err := generateMachineConfig(pool, newSecret("grub", testHash))
+	require.NoError(t, err)
+	assert.Equal(t, "99-infra-generated-bootloader", mc.Name)
+	assert.Equal(t, "infra", mc.Labels[mcfgv1.MachineConfigRoleLabelKey])
+	assert.Equal(t, "infra", generatedConfigKind.OwningPool(mc))
+
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	require.NoError(t, err)
+	require.Len(t, ignCfg.Storage.Files, 1)
+	assert.Equal(t, 0600, *ignCfg.Storage.Files[0].Mode)
+	conf, err := ctrlcommon.GetIgnitionFileDataByPath(&ignCfg, "/etc/machine-config-daemon/bootloader/user.cfg")
+	require.NoError(t, err)
+	assert.Equal(t, "GRUB2_PASSWORD="+testHash+"\n", string(conf))
+}
@@ -2,6 +2,7 @@ package bootstrap
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/ghodss/yaml"
 	"github.com/golang/glog"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -69,8 +71,9 @@ func (b *Bootstrap) Run(destDir string) error {
 	mcfgv1.Install(scheme)
 	apioperatorsv1alpha1.Install(scheme)
 	apicfgv1.Install(scheme)
+	corev1.AddToScheme(scheme)
 	codecFactory := serializer.NewCodecFactory(scheme)
-	decoder := codecFactory.UniversalDecoder(mcfgv1.GroupVersion, apioperatorsv1alpha1.GroupVersion, apicfgv1.GroupVersion)
+	decoder := codecFactory.UniversalDecoder(mcfgv1.GroupVersion, apioperatorsv1alpha1.GroupVersion, apicfgv1.GroupVersion, corev1.SchemeGroupVersion)
 
 	var cconfig *mcfgv1.ControllerConfig
 	var featureGate *apicfgv1.FeatureGate
@@ -80,6 +83,7 @@ func (b *Bootstrap) Run(destDir string) error {
 	var crconfigs []*mcfgv1.ContainerRuntimeConfig
 	var icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy
 	var imgCfg *apicfgv1.Image
+	var templatesImage string
 	for _, info := range infos {
 		if info.IsDir() {
 			continue
@@ -126,6 +130,12 @@ func (b *Bootstrap) Run(destDir string) error {
 				if obj.GetName() == ctrlcommon.ClusterFeatureInstanceName {
 					featureGate = obj
 				}
+			case *corev1.ConfigMap:
+				if obj.GetNamespace() == ctrlcommon.MCONamespace && obj.GetName() == ctrlcommon.CustomizationsConfigMapName {
+					if templatesImage, err = parseTemplatesImage(obj); err != nil {
+						return err
+					}
+				}
 			default:
 				glog.Infof("skipping %q [%d] manifest because of unhandled %T", file.Name(), idx+1, obji)
 			}
@@ -135,13 +145,24 @@ func (b *Bootstrap) Run(destDir string) error {
 	if cconfig == nil {
 		return fmt.Errorf("error: no controllerconfig found in dir: %q", destDir)
 	}
-	iconfigs, err := template.RunBootstrap(b.templatesDir, cconfig, psraw, featureGate)
+
+	// render from the same templates as the in-cluster controller will, so
+	// the first rendered configs don't change once it takes over
+	templatesDir := b.templatesDir
+	if templatesImage != "" {
+		templatesDir, err = template.FetchTemplatesImageWithPullSecret(context.TODO(), templatesImage, psraw, filepath.Join(os.TempDir(), "mcc-templates"), b.templatesDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	iconfigs, err := template.RunBootstrap(templatesDir, cconfig, psraw, featureGate, pools)
 	if err != nil {
 		return err
 	}
 	configs = append(configs, iconfigs...)
 
-	rconfigs, err := containerruntimeconfig.RunImageBootstrap(b.templatesDir, cconfig, pools, icspRules, imgCfg)
+	rconfigs, err := containerruntimeconfig.RunImageBootstrap(templatesDir, cconfig, pools, icspRules, imgCfg)
 	if err != nil {
 		return err
 	}
@@ -149,21 +170,21 @@ func (b *Bootstrap) Run(destDir string) error {
 	configs = append(configs, rconfigs...)
 
 	if len(crconfigs) > 0 {
-		containerRuntimeConfigs, err := containerruntimeconfig.RunContainerRuntimeBootstrap(b.templatesDir, crconfigs, cconfig, pools)
+		containerRuntimeConfigs, err := containerruntimeconfig.RunContainerRuntimeBootstrap(templatesDir, crconfigs, cconfig, pools)
 		if err != nil {
 			return err
 		}
 		configs = append(configs, containerRuntimeConfigs...)
 	}
 	if featureGate != nil {
-		featureConfigs, err := kubeletconfig.RunFeatureGateBootstrap(b.templatesDir, featureGate, cconfig, pools)
+		featureConfigs, err := kubeletconfig.RunFeatureGateBootstrap(templatesDir, featureGate, cconfig, pools)
 		if err != nil {
 			return err
 		}
 		configs = append(configs, featureConfigs...)
 	}
 	if len(kconfigs) > 0 {
-		kconfigs, err := kubeletconfig.RunKubeletBootstrap(b.templatesDir, kconfigs, cconfig, featureGate, pools)
+		kconfigs, err := kubeletconfig.RunKubeletBootstrap(templatesDir, kconfigs, cconfig, featureGate, pools)
 		if err != nil {
 			return err
 		}
@@ -218,6 +239,18 @@ func (b *Bootstrap) Run(destDir string) error {
 	return nil
 }
 
+// parseTemplatesImage returns the templatesImage of the customizations of
+// the operator.  The operator validates the rest of them.
+func parseTemplatesImage(cm *corev1.ConfigMap) (string, error) {
+	var c struct {
+		TemplatesImage string `json:"templatesImage,omitempty"`
+	}
+	if err := yaml.Unmarshal([]byte(cm.Data[ctrlcommon.CustomizationsConfigMapKey]), &c); err != nil {
+		return "", fmt.Errorf("invalid configmap %s/%s: %w", cm.Namespace, cm.Name, err)
+	}
+	return c.TemplatesImage, nil
+}
+
 func getPullSecretFromSecret(sData []byte) ([]byte, error) {
 	obji, err := runtime.Decode(kscheme.Codecs.UniversalDecoder(corev1.SchemeGroupVersion), sData)
 	if err != nil {
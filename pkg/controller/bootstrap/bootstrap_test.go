@@ -12,6 +12,8 @@ import (
 	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/diff"
 
 	mcoResourceRead "github.com/openshift/machine-config-operator/lib/resourceread"
@@ -201,3 +203,19 @@ func TestBootstrapRun(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTemplatesImage(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ctrlcommon.MCONamespace, Name: ctrlcommon.CustomizationsConfigMapName},
+		Data: map[string]string{
+			ctrlcommon.CustomizationsConfigMapKey: "templatesImage: quay.io/example/mco-templates@sha256:0123\ncontrollerTuning:\n  queueQPS: 5\n",
+		},
+	}
+	image, err := parseTemplatesImage(cm)
+	require.NoError(t, err)
+	assert.Equal(t, "quay.io/example/mco-templates@sha256:0123", image)
+
+	cm.Data[ctrlcommon.CustomizationsConfigMapKey] = "templatesImage: ["
+	_, err = parseTemplatesImage(cm)
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+package bootstrapcredentials
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	// SecretName is the Secret holding the credentials the MCS puts into the
+	// kubeconfig of the configs it serves.
+	SecretName = "node-bootstrapper-credentials"
+
+	// LegacySecretName is the Secret of the long-lived token of the
+	// node-bootstrapper service account, which the MCS serves until the
+	// rotated credentials exist.
+	LegacySecretName = "node-bootstrapper-token"
+
+	// RotatedAnnotationKey is set on the Secret to the time its token was issued.
+	RotatedAnnotationKey = "machineconfiguration.openshift.io/bootstrap-credentials-rotated"
+
+	// ExpiryAnnotationKey is set on the Secret to the time its token expires.
+	ExpiryAnnotationKey = "machineconfiguration.openshift.io/bootstrap-credentials-expiry"
+
+	// serviceAccountName is the service account new nodes request their
+	// client certificate as, whose CSRs the cluster-machine-approver approves.
+	serviceAccountName = "node-bootstrapper"
+
+	// rootCAConfigMapName is the ConfigMap holding the CA of the API server in
+	// every namespace.
+	rootCAConfigMapName = "kube-root-ca.crt"
+
+	// rotationInterval is how often the token is rotated.
+	rotationInterval = time.Hour
+
+	// approvalWindow is how long after the creation of its Machine the
+	// cluster-machine-approver approves the CSRs of a new node.  A token is
+	// rotated while it's still valid for that long, so a node which got its
+	// config right before the rotation can still join.
+	approvalWindow = 2 * time.Hour
+
+	// tokenLifetime is the lifetime requested for the tokens.
+	tokenLifetime = rotationInterval + approvalWindow
+)
+
+// removeLegacyToken returns whether the customizations of the operator, in
+// the data of their ConfigMap, opt into removing the legacy token once the
+// rotated credentials exist.  The operator validates the rest of them.
+func removeLegacyToken(data string) (bool, error) {
+	var c struct {
+		RemoveLegacyBootstrapToken bool `json:"removeLegacyBootstrapToken,omitempty"`
+	}
+	if err := yaml.Unmarshal([]byte(data), &c); err != nil {
+		return false, err
+	}
+	return c.RemoveLegacyBootstrapToken, nil
+}
+
+// nextRotation returns when a token issued at issued and expiring at expiry
+// is rotated: after the rotation interval, while it's still valid for the
+// approval window, or halfway through its lifetime if the API server issued
+// a shorter lived token than requested.
+func nextRotation(issued, expiry time.Time) time.Time {
+	if lifetime := expiry.Sub(issued); lifetime < tokenLifetime {
+		return issued.Add(lifetime / 2)
+	}
+	return issued.Add(rotationInterval)
+}
+
+// rotationTimes returns the times the token of the Secret was issued and expires.
+func rotationTimes(secret *corev1.Secret) (time.Time, time.Time, error) {
+	issued, err := time.Parse(time.RFC3339, secret.Annotations[RotatedAnnotationKey])
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid %s annotation: %w", RotatedAnnotationKey, err)
+	}
+	expiry, err := time.Parse(time.RFC3339, secret.Annotations[ExpiryAnnotationKey])
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid %s annotation: %w", ExpiryAnnotationKey, err)
+	}
+	return issued, expiry, nil
+}
+
+// newSecret returns the Secret holding a token issued at issued and expiring
+// at expiry, in the layout of a service account token Secret.
+func newSecret(caData, token string, issued, expiry time.Time) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SecretName,
+			Namespace: ctrlcommon.MCONamespace,
+			Annotations: map[string]string{
+				RotatedAnnotationKey: issued.UTC().Format(time.RFC3339),
+				ExpiryAnnotationKey:  expiry.UTC().Format(time.RFC3339),
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			corev1.ServiceAccountRootCAKey:    []byte(caData),
+			corev1.ServiceAccountTokenKey:     []byte(token),
+			corev1.ServiceAccountNamespaceKey: []byte(ctrlcommon.MCONamespace),
+		},
+	}
+}
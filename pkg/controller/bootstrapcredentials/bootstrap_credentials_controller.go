@@ -0,0 +1,307 @@
+package bootstrapcredentials
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformersv1 "k8s.io/client-go/informers/core/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	kscheme "k8s.io/client-go/kubernetes/scheme"
+	corev1clientset "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	// maxRetries is the number of times the credentials will be retried before they're dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// they're going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+
+	// queueKey is the only key of the queue, there's a single Secret to sync.
+	queueKey = "bootstrap-credentials"
+)
+
+// Controller defines the bootstrap credentials controller, which rotates the
+// short-lived node-bootstrapper token the MCS bakes into the configs it serves.
+type Controller struct {
+	kubeClient    clientset.Interface
+	eventRecorder record.EventRecorder
+
+	syncHandler func(key string) error
+	now         func() time.Time
+
+	secretLister    corelisterv1.SecretLister
+	configMapLister corelisterv1.ConfigMapLister
+
+	secretListerSynced    cache.InformerSynced
+	configMapListerSynced cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+}
+
+// New returns a new bootstrap credentials controller.  secretInformer and
+// configMapInformer watch the MCO namespace.
+func New(
+	secretInformer coreinformersv1.SecretInformer,
+	configMapInformer coreinformersv1.ConfigMapInformer,
+	kubeClient clientset.Interface,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(&corev1clientset.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	ctrl := &Controller{
+		kubeClient:    kubeClient,
+		eventRecorder: eventBroadcaster.NewRecorder(kscheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-bootstrapcredentialscontroller"}),
+		now:           time.Now,
+		queue:         workqueue.NewNamedRateLimitingQueue(ctrlcommon.ControllerRateLimiter(), "machineconfigcontroller-bootstrapcredentialscontroller"),
+	}
+
+	secretInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isNamed(SecretName, LegacySecretName),
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    ctrl.enqueue,
+			UpdateFunc: func(old, cur interface{}) { ctrl.enqueue(cur) },
+			DeleteFunc: ctrl.enqueue,
+		},
+	})
+	configMapInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isNamed(rootCAConfigMapName, ctrlcommon.CustomizationsConfigMapName),
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    ctrl.enqueue,
+			UpdateFunc: func(old, cur interface{}) { ctrl.enqueue(cur) },
+		},
+	})
+
+	ctrl.syncHandler = ctrl.syncCredentials
+
+	ctrl.secretLister = secretInformer.Lister()
+	ctrl.configMapLister = configMapInformer.Lister()
+	ctrl.secretListerSynced = secretInformer.Informer().HasSynced
+	ctrl.configMapListerSynced = configMapInformer.Informer().HasSynced
+
+	return ctrl
+}
+
+// Run executes the bootstrap credentials controller
+func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer ctrl.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, ctrl.secretListerSynced, ctrl.configMapListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-BootstrapCredentialsController")
+	defer glog.Info("Shutting down MachineConfigController-BootstrapCredentialsController")
+
+	// There's a single key, more workers wouldn't sync anything concurrently.
+	go wait.Until(ctrl.worker, time.Second, stopCh)
+
+	<-stopCh
+}
+
+// isNamed returns a filter for the objects of the MCO namespace with one of the given names.
+func isNamed(names ...string) func(obj interface{}) bool {
+	return func(obj interface{}) bool {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		o, err := meta.Accessor(obj)
+		if err != nil || o.GetNamespace() != ctrlcommon.MCONamespace {
+			return false
+		}
+		for _, name := range names {
+			if o.GetName() == name {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (ctrl *Controller) enqueue(obj interface{}) {
+	ctrl.queue.Add(queueKey)
+}
+
+// worker runs a worker thread that just dequeues items, processes them, and marks them done.
+// It enforces that the syncHandler is never invoked concurrently with the same key.
+func (ctrl *Controller) worker() {
+	for ctrl.processNextWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextWorkItem() bool {
+	key, quit := ctrl.queue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	err := ctrl.syncHandler(key.(string))
+	ctrl.handleErr(err, key)
+
+	return true
+}
+
+func (ctrl *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.queue.Forget(key)
+		return
+	}
+
+	if ctrl.queue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing bootstrap credentials: %v", err)
+		ctrl.queue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping bootstrap credentials out of the queue: %v", err)
+	ctrl.queue.Forget(key)
+	ctrl.queue.AddAfter(key, 1*time.Minute)
+}
+
+// syncCredentials rotates the token of the credentials Secret when it's due,
+// or when the Secret is missing or doesn't hold the current CA, and requeues
+// itself for the next rotation.  Once the credentials exist, it removes the
+// legacy token if the customizations of the operator opt into it.
+// This function is not meant to be invoked concurrently with the same key.
+func (ctrl *Controller) syncCredentials(key string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing bootstrap credentials (%v)", startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing bootstrap credentials (%v)", time.Since(startTime))
+	}()
+
+	cm, err := ctrl.configMapLister.ConfigMaps(ctrlcommon.MCONamespace).Get(rootCAConfigMapName)
+	if err != nil {
+		return fmt.Errorf("could not get the API server CA: %w", err)
+	}
+	caData := cm.Data[corev1.ServiceAccountRootCAKey]
+	if caData == "" {
+		return fmt.Errorf("ConfigMap %s has no %s", rootCAConfigMapName, corev1.ServiceAccountRootCAKey)
+	}
+
+	now := ctrl.now()
+	secret, err := ctrl.secretLister.Secrets(ctrlcommon.MCONamespace).Get(SecretName)
+	switch {
+	case errors.IsNotFound(err):
+		secret = nil
+	case err != nil:
+		return err
+	default:
+		issued, expiry, err := rotationTimes(secret)
+		if err != nil {
+			glog.Warningf("Rotating bootstrap credentials: %v", err)
+			break
+		}
+		ctrlcommon.MachineConfigControllerBootstrapCredentialsExpiry.Set(float64(expiry.Unix()))
+		next := nextRotation(issued, expiry)
+		if string(secret.Data[corev1.ServiceAccountRootCAKey]) != caData {
+			glog.Infof("Rotating bootstrap credentials as the API server CA changed")
+		} else if now.Before(next) {
+			ctrl.queue.AddAfter(key, next.Sub(now))
+			return ctrl.syncLegacyToken(secret)
+		}
+	}
+
+	token, expiry, err := ctrl.requestToken()
+	if err != nil {
+		if secret != nil {
+			ctrl.eventRecorder.Eventf(secret, corev1.EventTypeWarning, "BootstrapCredentialsRotationFailed", "Failed to request a token for service account %s: %v", serviceAccountName, err)
+		}
+		return fmt.Errorf("could not request a token for service account %s: %w", serviceAccountName, err)
+	}
+	newSecret := newSecret(caData, token, now, expiry)
+	if secret == nil {
+		secret, err = ctrl.kubeClient.CoreV1().Secrets(ctrlcommon.MCONamespace).Create(context.TODO(), newSecret, metav1.CreateOptions{})
+	} else {
+		updated := secret.DeepCopy()
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		for k, v := range newSecret.Annotations {
+			updated.Annotations[k] = v
+		}
+		updated.Data = newSecret.Data
+		secret, err = ctrl.kubeClient.CoreV1().Secrets(ctrlcommon.MCONamespace).Update(context.TODO(), updated, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return err
+	}
+
+	glog.Infof("Rotated bootstrap credentials, the new token expires at %s", expiry.UTC().Format(time.RFC3339))
+	ctrl.eventRecorder.Eventf(secret, corev1.EventTypeNormal, "BootstrapCredentialsRotated", "Rotated the token of service account %s, it expires at %s", serviceAccountName, expiry.UTC().Format(time.RFC3339))
+	ctrlcommon.MachineConfigControllerBootstrapCredentialsExpiry.Set(float64(expiry.Unix()))
+	ctrl.queue.AddAfter(key, nextRotation(now, expiry).Sub(now))
+	return ctrl.syncLegacyToken(secret)
+}
+
+// syncLegacyToken deletes the Secret of the legacy long-lived token, which
+// revokes the token, if the customizations of the operator opt into it.  The
+// operator doesn't recreate it then, as the rotated credentials exist.
+func (ctrl *Controller) syncLegacyToken(credentials *corev1.Secret) error {
+	cm, err := ctrl.configMapLister.ConfigMaps(ctrlcommon.MCONamespace).Get(ctrlcommon.CustomizationsConfigMapName)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	remove, err := removeLegacyToken(cm.Data[ctrlcommon.CustomizationsConfigMapKey])
+	if err != nil {
+		// the operator reports the invalid ConfigMap
+		glog.Warningf("Not removing the legacy bootstrap token: %v", err)
+		return nil
+	}
+	if !remove {
+		return nil
+	}
+	if _, err := ctrl.secretLister.Secrets(ctrlcommon.MCONamespace).Get(LegacySecretName); errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	err = ctrl.kubeClient.CoreV1().Secrets(ctrlcommon.MCONamespace).Delete(context.TODO(), LegacySecretName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	glog.Infof("Deleted the legacy bootstrap token Secret %s", LegacySecretName)
+	ctrl.eventRecorder.Eventf(credentials, corev1.EventTypeNormal, "LegacyBootstrapTokenRemoved", "Deleted Secret %s, revoking the long-lived token of service account %s", LegacySecretName, serviceAccountName)
+	return nil
+}
+
+// requestToken requests a token of the node-bootstrapper service account and
+// returns it with its expiry.
+func (ctrl *Controller) requestToken() (string, time.Time, error) {
+	expirationSeconds := int64(tokenLifetime / time.Second)
+	tr, err := ctrl.kubeClient.CoreV1().ServiceAccounts(ctrlcommon.MCONamespace).CreateToken(context.TODO(), serviceAccountName, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: &expirationSeconds,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if tr.Status.Token == "" {
+		return "", time.Time{}, fmt.Errorf("empty token")
+	}
+	return tr.Status.Token, tr.Status.ExpirationTimestamp.Time, nil
+}
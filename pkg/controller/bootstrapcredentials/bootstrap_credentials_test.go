@@ -0,0 +1,173 @@
+package bootstrapcredentials
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+var testNow = time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+
+func newRootCA(ca string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: rootCAConfigMapName, Namespace: ctrlcommon.MCONamespace},
+		Data:       map[string]string{corev1.ServiceAccountRootCAKey: ca},
+	}
+}
+
+func TestNextRotation(t *testing.T) {
+	// rotated after the interval, while still valid for the approval window
+	assert.Equal(t, testNow.Add(rotationInterval), nextRotation(testNow, testNow.Add(tokenLifetime)))
+	// halfway through the lifetime of tokens shorter lived than requested
+	assert.Equal(t, testNow.Add(30*time.Minute), nextRotation(testNow, testNow.Add(time.Hour)))
+}
+
+// newController returns a controller whose token requests return the tokens
+// "token-1", "token-2" and so on.
+func newController(t *testing.T, objects ...runtime.Object) (*Controller, *k8sfake.Clientset) {
+	f := helpers.NewFakeInformers(objects...)
+	tokens := 0
+	f.KubeClient.PrependReactor("create", "serviceaccounts", func(action core.Action) (bool, runtime.Object, error) {
+		create := action.(core.CreateAction)
+		if create.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		tr := create.GetObject().(*authenticationv1.TokenRequest).DeepCopy()
+		tokens++
+		tr.Status = authenticationv1.TokenRequestStatus{
+			Token:               fmt.Sprintf("token-%d", tokens),
+			ExpirationTimestamp: metav1.NewTime(testNow.Add(time.Duration(*tr.Spec.ExpirationSeconds) * time.Second)),
+		}
+		return true, tr, nil
+	})
+	ctrl := New(f.KubeInformers.Core().V1().Secrets(), f.KubeInformers.Core().V1().ConfigMaps(), f.KubeClient)
+	ctrl.eventRecorder = &record.FakeRecorder{}
+	ctrl.now = func() time.Time { return testNow }
+	f.FillListers(t)
+	return ctrl, f.KubeClient
+}
+
+func getSecret(t *testing.T, kubeClient *k8sfake.Clientset) *corev1.Secret {
+	secret, err := kubeClient.CoreV1().Secrets(ctrlcommon.MCONamespace).Get(context.TODO(), SecretName, metav1.GetOptions{})
+	require.NoError(t, err)
+	return secret
+}
+
+func TestSyncCredentials(t *testing.T) {
+	ctrl, kubeClient := newController(t, newRootCA("ca-1"))
+	require.NoError(t, ctrl.syncCredentials(queueKey))
+	secret := getSecret(t, kubeClient)
+	assert.Equal(t, map[string][]byte{
+		corev1.ServiceAccountRootCAKey:    []byte("ca-1"),
+		corev1.ServiceAccountTokenKey:     []byte("token-1"),
+		corev1.ServiceAccountNamespaceKey: []byte(ctrlcommon.MCONamespace),
+	}, secret.Data)
+	assert.Equal(t, "2022-05-01T10:00:00Z", secret.Annotations[RotatedAnnotationKey])
+	assert.Equal(t, "2022-05-01T13:00:00Z", secret.Annotations[ExpiryAnnotationKey])
+
+	tests := []struct {
+		name    string
+		issued  time.Time
+		ca      string
+		rotated bool
+	}{{
+		name:   "not due",
+		issued: testNow.Add(-50 * time.Minute),
+		ca:     "ca-1",
+	}, {
+		name:    "due",
+		issued:  testNow.Add(-rotationInterval),
+		ca:      "ca-1",
+		rotated: true,
+	}, {
+		name:    "CA changed",
+		issued:  testNow.Add(-time.Minute),
+		ca:      "ca-2",
+		rotated: true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			existing := newSecret("ca-1", "token-0", test.issued, test.issued.Add(tokenLifetime))
+			ctrl, kubeClient := newController(t, newRootCA(test.ca), existing)
+			require.NoError(t, ctrl.syncCredentials(queueKey))
+			secret := getSecret(t, kubeClient)
+			if test.rotated {
+				assert.Equal(t, "token-1", string(secret.Data[corev1.ServiceAccountTokenKey]))
+				assert.Equal(t, test.ca, string(secret.Data[corev1.ServiceAccountRootCAKey]))
+				assert.Equal(t, "2022-05-01T10:00:00Z", secret.Annotations[RotatedAnnotationKey])
+			} else {
+				assert.Equal(t, existing, secret)
+			}
+		})
+	}
+}
+
+func TestSyncCredentialsInvalidAnnotations(t *testing.T) {
+	existing := newSecret("ca-1", "token-0", testNow, testNow.Add(tokenLifetime))
+	existing.Annotations[ExpiryAnnotationKey] = "tomorrow"
+	ctrl, kubeClient := newController(t, newRootCA("ca-1"), existing)
+	require.NoError(t, ctrl.syncCredentials(queueKey))
+	assert.Equal(t, "token-1", string(getSecret(t, kubeClient).Data[corev1.ServiceAccountTokenKey]))
+}
+
+func TestSyncCredentialsMissingCA(t *testing.T) {
+	ctrl, kubeClient := newController(t)
+	assert.Error(t, ctrl.syncCredentials(queueKey))
+	_, err := kubeClient.CoreV1().Secrets(ctrlcommon.MCONamespace).Get(context.TODO(), SecretName, metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func TestSyncLegacyToken(t *testing.T) {
+	newCustomizations := func(data string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: ctrlcommon.CustomizationsConfigMapName, Namespace: ctrlcommon.MCONamespace},
+			Data:       map[string]string{ctrlcommon.CustomizationsConfigMapKey: data},
+		}
+	}
+	tests := []struct {
+		name           string
+		customizations *corev1.ConfigMap
+		removed        bool
+	}{{
+		name: "no customizations",
+	}, {
+		name:           "not opted in",
+		customizations: newCustomizations("templatesImage: quay.io/example/mco-templates@sha256:0123\n"),
+	}, {
+		name:           "invalid customizations",
+		customizations: newCustomizations("removeLegacyBootstrapToken: ["),
+	}, {
+		name:           "opted in",
+		customizations: newCustomizations("removeLegacyBootstrapToken: true\n"),
+		removed:        true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			legacy := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: LegacySecretName, Namespace: ctrlcommon.MCONamespace}}
+			objects := []runtime.Object{newRootCA("ca-1"), legacy}
+			if test.customizations != nil {
+				objects = append(objects, test.customizations)
+			}
+			ctrl, kubeClient := newController(t, objects...)
+			require.NoError(t, ctrl.syncCredentials(queueKey))
+			getSecret(t, kubeClient)
+			_, err := kubeClient.CoreV1().Secrets(ctrlcommon.MCONamespace).Get(context.TODO(), LegacySecretName, metav1.GetOptions{})
+			assert.Equal(t, test.removed, errors.IsNotFound(err))
+		})
+	}
+}
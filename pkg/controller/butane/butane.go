@@ -0,0 +1,111 @@
+package butane
+
+import (
+	"bytes"
+	"fmt"
+
+	fcctbase "github.com/coreos/fcct/base/v0_1"
+	translate3_1 "github.com/coreos/ignition/v2/config/v3_1/translate"
+	translate3 "github.com/coreos/ignition/v2/config/v3_2/translate"
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	// ConfigMapLabelKey selects the configmaps in the MCO namespace holding a Butane config.
+	ConfigMapLabelKey = "machineconfiguration.openshift.io/butane"
+	// ConfigMapDataKey is the key of the configmap data holding the Butane config.
+	ConfigMapDataKey = "config.bu"
+	// ErrorAnnotationKey is set on the configmap to the error translating it, if any.
+	ErrorAnnotationKey = "machineconfiguration.openshift.io/butane-error"
+	// SourceAnnotationKey is set on the generated MachineConfig to the namespace/name of the configmap.
+	SourceAnnotationKey = "machineconfiguration.openshift.io/butane-source"
+
+	// supportedVariant and supportedVersion are the Butane variant and spec
+	// version the vendored translator understands.
+	supportedVariant = "fcos"
+	supportedVersion = "1.0.0"
+)
+
+// butaneConfig is a Butane document: the variant and version header
+// followed by the config itself.
+type butaneConfig struct {
+	Variant         string `yaml:"variant"`
+	Version         string `yaml:"version"`
+	fcctbase.Config `yaml:",inline"`
+}
+
+// Translate strictly parses a Butane config and translates it to Ignition.
+// Unknown fields, unsupported variants and versions, and sections the
+// machine-config-daemon can't apply are errors.
+func Translate(data []byte) (*ign3types.Config, error) {
+	var cfg butaneConfig
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse Butane config: %w", err)
+	}
+	if cfg.Variant != supportedVariant || cfg.Version != supportedVersion {
+		return nil, fmt.Errorf("unsupported Butane variant %q version %q, only variant %q version %q is supported", cfg.Variant, cfg.Version, supportedVariant, supportedVersion)
+	}
+	switch {
+	case len(cfg.Storage.Disks) > 0:
+		return nil, fmt.Errorf("storage.disks is not supported in MachineConfigs")
+	case len(cfg.Storage.Raid) > 0:
+		return nil, fmt.Errorf("storage.raid is not supported in MachineConfigs")
+	case len(cfg.Storage.Filesystems) > 0:
+		return nil, fmt.Errorf("storage.filesystems is not supported in MachineConfigs")
+	case len(cfg.Ignition.Config.Merge) > 0 || cfg.Ignition.Config.Replace.Source != nil:
+		return nil, fmt.Errorf("ignition.config is not supported in MachineConfigs")
+	}
+
+	ign3_0config, tSet, err := cfg.Config.ToIgn3_0()
+	if err != nil {
+		return nil, fmt.Errorf("failed to translate Butane config to Ignition: %w\nTranslation set: %v", err, tSet)
+	}
+	ignCfg := translate3.Translate(translate3_1.Translate(ign3_0config))
+	if err := ctrlcommon.ValidateIgnition(ignCfg); err != nil {
+		return nil, err
+	}
+	return &ignCfg, nil
+}
+
+// isButaneConfigMap returns true if cm holds a Butane config for this controller.
+func isButaneConfigMap(cm *corev1.ConfigMap) bool {
+	_, ok := cm.Labels[ConfigMapLabelKey]
+	return ok && cm.Namespace == ctrlcommon.MCONamespace
+}
+
+// generateMachineConfig translates the Butane config of cm into a
+// MachineConfig of the same name for the role set by the role label of cm.
+func generateMachineConfig(cm *corev1.ConfigMap) (*mcfgv1.MachineConfig, error) {
+	role := cm.Labels[mcfgv1.MachineConfigRoleLabelKey]
+	if role == "" {
+		return nil, fmt.Errorf("configmap %s is missing the %s label", cm.Name, mcfgv1.MachineConfigRoleLabelKey)
+	}
+	data, ok := cm.Data[ConfigMapDataKey]
+	if !ok {
+		return nil, fmt.Errorf("configmap %s is missing the %s key", cm.Name, ConfigMapDataKey)
+	}
+	ignCfg, err := Translate([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+	mc, err := ctrlcommon.MachineConfigFromIgnConfig(role, cm.Name, ignCfg)
+	if err != nil {
+		return nil, err
+	}
+	mc.Annotations = map[string]string{
+		SourceAnnotationKey: sourceKey(cm),
+	}
+	return mc, nil
+}
+
+func sourceKey(cm metav1.Object) string {
+	return cm.GetNamespace() + "/" + cm.GetName()
+}
@@ -0,0 +1,322 @@
+package butane
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformersv1 "k8s.io/client-go/informers/core/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	corev1clientset "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	mcoResourceApply "github.com/openshift/machine-config-operator/lib/resourceapply"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+)
+
+const (
+	// maxRetries is the number of times a configmap will be retried before it is dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// a configmap is going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+)
+
+// Controller defines the butane controller, which translates Butane configs
+// stored in configmaps of the MCO namespace into MachineConfigs.
+type Controller struct {
+	client        mcfgclientset.Interface
+	kubeClient    clientset.Interface
+	eventRecorder record.EventRecorder
+
+	syncHandler func(key string) error
+
+	cmLister corelisterv1.ConfigMapLister
+	mcLister mcfglistersv1.MachineConfigLister
+
+	cmListerSynced cache.InformerSynced
+	mcListerSynced cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+}
+
+// New returns a new butane controller.
+func New(
+	cmInformer coreinformersv1.ConfigMapInformer,
+	mcInformer mcfginformersv1.MachineConfigInformer,
+	kubeClient clientset.Interface,
+	mcfgClient mcfgclientset.Interface,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(&corev1clientset.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	ctrl := &Controller{
+		client:        mcfgClient,
+		kubeClient:    kubeClient,
+		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-butanecontroller"}),
+		queue:         workqueue.NewNamedRateLimitingQueue(ctrlcommon.ControllerRateLimiter(), "machineconfigcontroller-butanecontroller"),
+	}
+
+	cmInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addConfigMap,
+		UpdateFunc: ctrl.updateConfigMap,
+		DeleteFunc: ctrl.deleteConfigMap,
+	})
+	mcInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: ctrl.updateMachineConfig,
+		DeleteFunc: ctrl.deleteMachineConfig,
+	})
+
+	ctrl.syncHandler = ctrl.syncConfigMap
+
+	ctrl.cmLister = cmInformer.Lister()
+	ctrl.mcLister = mcInformer.Lister()
+	ctrl.cmListerSynced = cmInformer.Informer().HasSynced
+	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
+
+	return ctrl
+}
+
+// Run executes the butane controller
+func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer ctrl.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, ctrl.cmListerSynced, ctrl.mcListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-ButaneController")
+	defer glog.Info("Shutting down MachineConfigController-ButaneController")
+
+	for i := 0; i < workers; i++ {
+		go wait.Until(ctrl.worker, time.Second, stopCh)
+	}
+
+	<-stopCh
+}
+
+func (ctrl *Controller) addConfigMap(obj interface{}) {
+	cm := obj.(*corev1.ConfigMap)
+	if !isButaneConfigMap(cm) {
+		return
+	}
+	glog.V(4).Infof("Adding Butane ConfigMap %s", cm.Name)
+	ctrl.enqueue(cm)
+}
+
+func (ctrl *Controller) updateConfigMap(old, cur interface{}) {
+	oldCM := old.(*corev1.ConfigMap)
+	curCM := cur.(*corev1.ConfigMap)
+	// Only the labels and the data matter, in particular ignore our own error annotation.
+	if !isButaneConfigMap(oldCM) && !isButaneConfigMap(curCM) {
+		return
+	}
+	if reflect.DeepEqual(oldCM.Labels, curCM.Labels) && reflect.DeepEqual(oldCM.Data, curCM.Data) {
+		return
+	}
+	glog.V(4).Infof("Updating Butane ConfigMap %s", curCM.Name)
+	ctrl.enqueue(curCM)
+}
+
+func (ctrl *Controller) deleteConfigMap(obj interface{}) {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		cm, ok = tombstone.Obj.(*corev1.ConfigMap)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a ConfigMap %#v", obj))
+			return
+		}
+	}
+	if !isButaneConfigMap(cm) {
+		return
+	}
+	glog.V(4).Infof("Deleting Butane ConfigMap %s", cm.Name)
+	ctrl.enqueue(cm)
+}
+
+func (ctrl *Controller) updateMachineConfig(old, cur interface{}) {
+	mc := cur.(*mcfgv1.MachineConfig)
+	if source, ok := mc.Annotations[SourceAnnotationKey]; ok {
+		glog.V(4).Infof("MachineConfig %s generated from Butane ConfigMap %s updated", mc.Name, source)
+		ctrl.queue.Add(source)
+	}
+}
+
+func (ctrl *Controller) deleteMachineConfig(obj interface{}) {
+	mc, ok := obj.(*mcfgv1.MachineConfig)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		mc, ok = tombstone.Obj.(*mcfgv1.MachineConfig)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a MachineConfig %#v", obj))
+			return
+		}
+	}
+	if source, ok := mc.Annotations[SourceAnnotationKey]; ok {
+		glog.V(4).Infof("MachineConfig %s generated from Butane ConfigMap %s deleted", mc.Name, source)
+		ctrl.queue.Add(source)
+	}
+}
+
+func (ctrl *Controller) enqueue(cm *corev1.ConfigMap) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(cm)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("Couldn't get key for object %#v: %v", cm, err))
+		return
+	}
+
+	ctrl.queue.Add(key)
+}
+
+// worker runs a worker thread that just dequeues items, processes them, and marks them done.
+// It enforces that the syncHandler is never invoked concurrently with the same key.
+func (ctrl *Controller) worker() {
+	for ctrl.processNextWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextWorkItem() bool {
+	key, quit := ctrl.queue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	err := ctrl.syncHandler(key.(string))
+	ctrl.handleErr(err, key)
+
+	return true
+}
+
+func (ctrl *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.queue.Forget(key)
+		return
+	}
+
+	if ctrl.queue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing Butane ConfigMap %v: %v", key, err)
+		ctrl.queue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping Butane ConfigMap %q out of the queue: %v", key, err)
+	ctrl.queue.Forget(key)
+	ctrl.queue.AddAfter(key, 1*time.Minute)
+}
+
+// syncConfigMap translates the Butane configmap with the given key into a
+// MachineConfig, or deletes the MachineConfig generated from it if it's gone.
+// This function is not meant to be invoked concurrently with the same key.
+func (ctrl *Controller) syncConfigMap(key string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing Butane ConfigMap %q (%v)", key, startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing Butane ConfigMap %q (%v)", key, time.Since(startTime))
+	}()
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	cm, err := ctrl.cmLister.ConfigMaps(namespace).Get(name)
+	if errors.IsNotFound(err) || (err == nil && !isButaneConfigMap(cm)) {
+		return ctrl.deleteGeneratedMachineConfig(key, name)
+	}
+	if err != nil {
+		return err
+	}
+
+	mc, err := generateMachineConfig(cm)
+	if err != nil {
+		return ctrl.syncFailingStatus(cm, err)
+	}
+	existing, err := ctrl.mcLister.Get(mc.Name)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && existing.Annotations[SourceAnnotationKey] != key {
+		return ctrl.syncFailingStatus(cm, fmt.Errorf("MachineConfig %s already exists and was not generated from this configmap", mc.Name))
+	}
+	if _, updated, err := mcoResourceApply.ApplyMachineConfig(ctrl.client.MachineconfigurationV1(), mc); err != nil {
+		return err
+	} else if updated {
+		ctrl.eventRecorder.Eventf(cm, corev1.EventTypeNormal, "MachineConfigGenerated", "Generated MachineConfig %s from Butane config", mc.Name)
+	}
+	return ctrl.syncStatus(cm, "")
+}
+
+// deleteGeneratedMachineConfig deletes the MachineConfig generated from the
+// configmap with the given key, leaving any other MachineConfig alone.
+func (ctrl *Controller) deleteGeneratedMachineConfig(key, name string) error {
+	mc, err := ctrl.mcLister.Get(name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if mc.Annotations[SourceAnnotationKey] != key {
+		return nil
+	}
+	glog.Infof("Deleting MachineConfig %s as Butane ConfigMap %s is gone", mc.Name, key)
+	err = ctrl.client.MachineconfigurationV1().MachineConfigs().Delete(context.TODO(), mc.Name, metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// syncFailingStatus reports a translation error on the configmap.  It's not
+// returned as retrying won't help until the configmap is changed.
+func (ctrl *Controller) syncFailingStatus(cm *corev1.ConfigMap, err error) error {
+	glog.Errorf("Failed to translate Butane ConfigMap %s: %v", cm.Name, err)
+	ctrl.eventRecorder.Eventf(cm, corev1.EventTypeWarning, "ButaneTranslationFailed", "Failed to translate Butane config: %v", err)
+	return ctrl.syncStatus(cm, err.Error())
+}
+
+// syncStatus records the translation error, if any, in an annotation of the configmap.
+func (ctrl *Controller) syncStatus(cm *corev1.ConfigMap, errMsg string) error {
+	if cm.Annotations[ErrorAnnotationKey] == errMsg {
+		return nil
+	}
+	newCM := cm.DeepCopy()
+	if errMsg == "" {
+		delete(newCM.Annotations, ErrorAnnotationKey)
+	} else {
+		if newCM.Annotations == nil {
+			newCM.Annotations = map[string]string{}
+		}
+		newCM.Annotations[ErrorAnnotationKey] = errMsg
+	}
+	_, err := ctrl.kubeClient.CoreV1().ConfigMaps(cm.Namespace).Update(context.TODO(), newCM, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,108 @@
+package butane
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const testButaneConfig = `variant: fcos
+version: 1.0.0
+storage:
+  files:
+    - path: /etc/example
+      mode: 0644
+      contents:
+        inline: hello
+systemd:
+  units:
+    - name: example.service
+      enabled: true
+      contents: |
+        [Service]
+        ExecStart=/bin/true
+        [Install]
+        WantedBy=multi-user.target
+`
+
+func TestTranslate(t *testing.T) {
+	ignCfg, err := Translate([]byte(testButaneConfig))
+	require.Nil(t, err)
+	require.Len(t, ignCfg.Storage.Files, 1)
+	assert.Equal(t, "/etc/example", ignCfg.Storage.Files[0].Path)
+	require.Len(t, ignCfg.Systemd.Units, 1)
+	assert.Equal(t, "example.service", ignCfg.Systemd.Units[0].Name)
+
+	tests := []struct {
+		name   string
+		config string
+	}{{
+		name:   "unknown field",
+		config: "variant: fcos\nversion: 1.0.0\nstorage:\n  flies: []\n",
+	}, {
+		name:   "unsupported variant",
+		config: "variant: rhcos\nversion: 1.0.0\n",
+	}, {
+		name:   "unsupported version",
+		config: "variant: fcos\nversion: 1.4.0\n",
+	}, {
+		name:   "disks",
+		config: "variant: fcos\nversion: 1.0.0\nstorage:\n  disks:\n    - device: /dev/vdb\n",
+	}, {
+		name:   "config merge",
+		config: "variant: fcos\nversion: 1.0.0\nignition:\n  config:\n    merge:\n      - source: https://example.com/config.ign\n",
+	}, {
+		name:   "invalid yaml",
+		config: "variant: [",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Translate([]byte(test.config))
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestGenerateMachineConfig(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "99-worker-example",
+			Namespace: ctrlcommon.MCONamespace,
+			Labels: map[string]string{
+				ConfigMapLabelKey:                "",
+				mcfgv1.MachineConfigRoleLabelKey: "worker",
+			},
+		},
+		Data: map[string]string{ConfigMapDataKey: testButaneConfig},
+	}
+	assert.True(t, isButaneConfigMap(cm))
+
+	mc, err := generateMachineConfig(cm)
+	require.Nil(t, err)
+	assert.Equal(t, "99-worker-example", mc.Name)
+	assert.Equal(t, "worker", mc.Labels[mcfgv1.MachineConfigRoleLabelKey])
+	assert.Equal(t, ctrlcommon.MCONamespace+"/99-worker-example", mc.Annotations[SourceAnnotationKey])
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	require.Nil(t, err)
+	assert.Len(t, ignCfg.Storage.Files, 1)
+
+	noRole := cm.DeepCopy()
+	delete(noRole.Labels, mcfgv1.MachineConfigRoleLabelKey)
+	_, err = generateMachineConfig(noRole)
+	assert.NotNil(t, err)
+
+	noData := cm.DeepCopy()
+	noData.Data = nil
+	_, err = generateMachineConfig(noData)
+	assert.NotNil(t, err)
+
+	otherNamespace := cm.DeepCopy()
+	otherNamespace.Namespace = "default"
+	assert.False(t, isButaneConfigMap(otherNamespace))
+}
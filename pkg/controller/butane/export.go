@@ -0,0 +1,382 @@
+package butane
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"gopkg.in/yaml.v3"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	// exportFCOSVersion and exportOpenShiftVersion are the Butane spec
+	// versions of the variants translating to Ignition spec 3.2.
+	exportFCOSVersion      = "1.3.0"
+	exportOpenShiftVersion = "4.10.0"
+
+	dataURLPrefix = "data:"
+)
+
+// The export types are the parts of the Butane spec which MachineConfigs
+// use, with their fields in the order of the spec so that the exported
+// configs read like hand-written ones.
+
+type exportConfig struct {
+	Variant   string           `yaml:"variant"`
+	Version   string           `yaml:"version"`
+	Metadata  *exportMetadata  `yaml:"metadata,omitempty"`
+	Passwd    *exportPasswd    `yaml:"passwd,omitempty"`
+	Storage   *exportStorage   `yaml:"storage,omitempty"`
+	Systemd   *exportSystemd   `yaml:"systemd,omitempty"`
+	OpenShift *exportOpenShift `yaml:"openshift,omitempty"`
+}
+
+type exportMetadata struct {
+	Name   string            `yaml:"name"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+type exportOpenShift struct {
+	KernelArguments []string          `yaml:"kernel_arguments,omitempty"`
+	Extensions      []exportExtension `yaml:"extensions,omitempty"`
+	FIPS            *bool             `yaml:"fips,omitempty"`
+	KernelType      string            `yaml:"kernel_type,omitempty"`
+}
+
+type exportExtension struct {
+	Name string `yaml:"name"`
+}
+
+type exportPasswd struct {
+	Users  []exportUser  `yaml:"users,omitempty"`
+	Groups []exportGroup `yaml:"groups,omitempty"`
+}
+
+type exportUser struct {
+	Name              string   `yaml:"name"`
+	PasswordHash      *string  `yaml:"password_hash,omitempty"`
+	SSHAuthorizedKeys []string `yaml:"ssh_authorized_keys,omitempty"`
+	UID               *int     `yaml:"uid,omitempty"`
+	Gecos             *string  `yaml:"gecos,omitempty"`
+	HomeDir           *string  `yaml:"home_dir,omitempty"`
+	NoCreateHome      *bool    `yaml:"no_create_home,omitempty"`
+	PrimaryGroup      *string  `yaml:"primary_group,omitempty"`
+	Groups            []string `yaml:"groups,omitempty"`
+	NoUserGroup       *bool    `yaml:"no_user_group,omitempty"`
+	NoLogInit         *bool    `yaml:"no_log_init,omitempty"`
+	Shell             *string  `yaml:"shell,omitempty"`
+	System            *bool    `yaml:"system,omitempty"`
+	ShouldExist       *bool    `yaml:"should_exist,omitempty"`
+}
+
+type exportGroup struct {
+	Name         string  `yaml:"name"`
+	Gid          *int    `yaml:"gid,omitempty"`
+	PasswordHash *string `yaml:"password_hash,omitempty"`
+	System       *bool   `yaml:"system,omitempty"`
+	ShouldExist  *bool   `yaml:"should_exist,omitempty"`
+}
+
+type exportStorage struct {
+	Directories []exportDirectory `yaml:"directories,omitempty"`
+	Files       []exportFile      `yaml:"files,omitempty"`
+	Links       []exportLink      `yaml:"links,omitempty"`
+}
+
+type exportNodeUser struct {
+	ID   *int    `yaml:"id,omitempty"`
+	Name *string `yaml:"name,omitempty"`
+}
+
+type exportDirectory struct {
+	Path      string          `yaml:"path"`
+	Overwrite *bool           `yaml:"overwrite,omitempty"`
+	Mode      *exportMode     `yaml:"mode,omitempty"`
+	User      *exportNodeUser `yaml:"user,omitempty"`
+	Group     *exportNodeUser `yaml:"group,omitempty"`
+}
+
+type exportFile struct {
+	Path      string           `yaml:"path"`
+	Overwrite *bool            `yaml:"overwrite,omitempty"`
+	Contents  *exportResource  `yaml:"contents,omitempty"`
+	Append    []exportResource `yaml:"append,omitempty"`
+	Mode      *exportMode      `yaml:"mode,omitempty"`
+	User      *exportNodeUser  `yaml:"user,omitempty"`
+	Group     *exportNodeUser  `yaml:"group,omitempty"`
+}
+
+type exportLink struct {
+	Path      string          `yaml:"path"`
+	Overwrite *bool           `yaml:"overwrite,omitempty"`
+	User      *exportNodeUser `yaml:"user,omitempty"`
+	Group     *exportNodeUser `yaml:"group,omitempty"`
+	Target    string          `yaml:"target"`
+	Hard      *bool           `yaml:"hard,omitempty"`
+}
+
+type exportResource struct {
+	Source       *string             `yaml:"source,omitempty"`
+	Inline       *string             `yaml:"inline,omitempty"`
+	Compression  *string             `yaml:"compression,omitempty"`
+	Verification *exportVerification `yaml:"verification,omitempty"`
+}
+
+type exportVerification struct {
+	Hash *string `yaml:"hash,omitempty"`
+}
+
+type exportSystemd struct {
+	Units []exportUnit `yaml:"units,omitempty"`
+}
+
+type exportUnit struct {
+	Name     string         `yaml:"name"`
+	Enabled  *bool          `yaml:"enabled,omitempty"`
+	Mask     *bool          `yaml:"mask,omitempty"`
+	Contents *string        `yaml:"contents,omitempty"`
+	Dropins  []exportDropin `yaml:"dropins,omitempty"`
+}
+
+type exportDropin struct {
+	Name     string  `yaml:"name"`
+	Contents *string `yaml:"contents,omitempty"`
+}
+
+// exportMode is a file mode, written in octal like in hand-written configs.
+type exportMode int
+
+func (m exportMode) MarshalYAML() (interface{}, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: fmt.Sprintf("0%o", int(m))}, nil
+}
+
+// ExportIgnition returns a Butane config of the fcos variant translating to
+// ignCfg, with the contents of its files decoded, for humans to review.  It
+// fails on the sections MachineConfigs can't hold, rather than leaving them
+// out of the review.
+func ExportIgnition(ignCfg *ign3types.Config) ([]byte, error) {
+	cfg, err := exportIgnition(ignCfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Variant, cfg.Version = "fcos", exportFCOSVersion
+	return marshalExport(cfg)
+}
+
+// ExportMachineConfig returns a Butane config of the openshift variant
+// translating to mc, with the contents of its files decoded, for humans to
+// review.  The name and role of mc become its metadata, and its kernel
+// arguments, extensions, FIPS mode and kernel type its openshift section.
+func ExportMachineConfig(mc *mcfgv1.MachineConfig) ([]byte, error) {
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("MachineConfig %s: %w", mc.Name, err)
+	}
+	cfg, err := exportIgnition(&ignCfg)
+	if err != nil {
+		return nil, fmt.Errorf("MachineConfig %s: %w", mc.Name, err)
+	}
+	cfg.Variant, cfg.Version = "openshift", exportOpenShiftVersion
+	cfg.Metadata = &exportMetadata{Name: mc.Name}
+	if role, ok := mc.Labels[mcfgv1.MachineConfigRoleLabelKey]; ok {
+		cfg.Metadata.Labels = map[string]string{mcfgv1.MachineConfigRoleLabelKey: role}
+	}
+	openshift := exportOpenShift{KernelArguments: mc.Spec.KernelArguments, KernelType: mc.Spec.KernelType}
+	for _, ext := range mc.Spec.Extensions {
+		openshift.Extensions = append(openshift.Extensions, exportExtension{Name: ext})
+	}
+	if mc.Spec.FIPS {
+		openshift.FIPS = &mc.Spec.FIPS
+	}
+	if len(openshift.KernelArguments) > 0 || len(openshift.Extensions) > 0 || openshift.FIPS != nil || openshift.KernelType != "" {
+		cfg.OpenShift = &openshift
+	}
+	return marshalExport(cfg)
+}
+
+func marshalExport(cfg *exportConfig) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// exportIgnition converts the sections of ignCfg, leaving the variant and
+// version to the caller.
+func exportIgnition(ignCfg *ign3types.Config) (*exportConfig, error) {
+	var unsupported []string
+	if len(ignCfg.Storage.Disks) > 0 {
+		unsupported = append(unsupported, "storage.disks")
+	}
+	if len(ignCfg.Storage.Raid) > 0 {
+		unsupported = append(unsupported, "storage.raid")
+	}
+	if len(ignCfg.Storage.Filesystems) > 0 {
+		unsupported = append(unsupported, "storage.filesystems")
+	}
+	if len(ignCfg.Storage.Luks) > 0 {
+		unsupported = append(unsupported, "storage.luks")
+	}
+	if len(ignCfg.Ignition.Config.Merge) > 0 || ignCfg.Ignition.Config.Replace.Source != nil {
+		unsupported = append(unsupported, "ignition.config")
+	}
+	if len(unsupported) > 0 {
+		return nil, fmt.Errorf("can't export %s, which MachineConfigs don't support", strings.Join(unsupported, ", "))
+	}
+
+	cfg := &exportConfig{Passwd: exportPasswdSection(ignCfg.Passwd)}
+	storage := exportStorage{}
+	for _, d := range ignCfg.Storage.Directories {
+		storage.Directories = append(storage.Directories, exportDirectory{
+			Path:      d.Path,
+			Overwrite: d.Overwrite,
+			Mode:      exportFileMode(d.Mode),
+			User:      exportOwner(d.User.ID, d.User.Name),
+			Group:     exportOwner(d.Group.ID, d.Group.Name),
+		})
+	}
+	for _, f := range ignCfg.Storage.Files {
+		file := exportFile{
+			Path:      f.Path,
+			Overwrite: f.Overwrite,
+			Mode:      exportFileMode(f.Mode),
+			User:      exportOwner(f.User.ID, f.User.Name),
+			Group:     exportOwner(f.Group.ID, f.Group.Name),
+		}
+		if f.Contents.Source != nil {
+			contents, err := exportContents(f.Contents)
+			if err != nil {
+				return nil, fmt.Errorf("file %s: %w", f.Path, err)
+			}
+			file.Contents = contents
+		}
+		for _, r := range f.Append {
+			contents, err := exportContents(r)
+			if err != nil {
+				return nil, fmt.Errorf("file %s: %w", f.Path, err)
+			}
+			file.Append = append(file.Append, *contents)
+		}
+		storage.Files = append(storage.Files, file)
+	}
+	for _, l := range ignCfg.Storage.Links {
+		storage.Links = append(storage.Links, exportLink{
+			Path:      l.Path,
+			Overwrite: l.Overwrite,
+			User:      exportOwner(l.User.ID, l.User.Name),
+			Group:     exportOwner(l.Group.ID, l.Group.Name),
+			Target:    l.Target,
+			Hard:      l.Hard,
+		})
+	}
+	if len(storage.Directories) > 0 || len(storage.Files) > 0 || len(storage.Links) > 0 {
+		cfg.Storage = &storage
+	}
+
+	systemd := exportSystemd{}
+	for _, u := range ignCfg.Systemd.Units {
+		unit := exportUnit{Name: u.Name, Enabled: u.Enabled, Mask: u.Mask, Contents: u.Contents}
+		for _, d := range u.Dropins {
+			unit.Dropins = append(unit.Dropins, exportDropin{Name: d.Name, Contents: d.Contents})
+		}
+		systemd.Units = append(systemd.Units, unit)
+	}
+	if len(systemd.Units) > 0 {
+		cfg.Systemd = &systemd
+	}
+	return cfg, nil
+}
+
+func exportPasswdSection(passwd ign3types.Passwd) *exportPasswd {
+	if len(passwd.Users) == 0 && len(passwd.Groups) == 0 {
+		return nil
+	}
+	exported := &exportPasswd{}
+	for _, u := range passwd.Users {
+		user := exportUser{
+			Name:         u.Name,
+			PasswordHash: u.PasswordHash,
+			UID:          u.UID,
+			Gecos:        u.Gecos,
+			HomeDir:      u.HomeDir,
+			NoCreateHome: u.NoCreateHome,
+			PrimaryGroup: u.PrimaryGroup,
+			NoUserGroup:  u.NoUserGroup,
+			NoLogInit:    u.NoLogInit,
+			Shell:        u.Shell,
+			System:       u.System,
+			ShouldExist:  u.ShouldExist,
+		}
+		for _, key := range u.SSHAuthorizedKeys {
+			user.SSHAuthorizedKeys = append(user.SSHAuthorizedKeys, string(key))
+		}
+		for _, group := range u.Groups {
+			user.Groups = append(user.Groups, string(group))
+		}
+		exported.Users = append(exported.Users, user)
+	}
+	for _, g := range passwd.Groups {
+		exported.Groups = append(exported.Groups, exportGroup{
+			Name:         g.Name,
+			Gid:          g.Gid,
+			PasswordHash: g.PasswordHash,
+			System:       g.System,
+			ShouldExist:  g.ShouldExist,
+		})
+	}
+	return exported
+}
+
+// exportContents returns the contents of a file as inline text if they're a
+// data URL decoding to text, and as their source otherwise, e.g. for remote or
+// binary contents.
+func exportContents(r ign3types.Resource) (*exportResource, error) {
+	exported := &exportResource{Source: r.Source, Compression: r.Compression}
+	if r.Verification.Hash != nil {
+		exported.Verification = &exportVerification{Hash: r.Verification.Hash}
+	}
+	if r.Source == nil || !strings.HasPrefix(*r.Source, dataURLPrefix) {
+		return exported, nil
+	}
+	data, err := ctrlcommon.DecodeIgnitionFileContents(r.Source, r.Compression)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return exported, nil
+	}
+	inline := string(data)
+	exported.Source, exported.Inline = nil, &inline
+	if r.Compression != nil && *r.Compression != "" {
+		// the hash is of the compressed contents, which Butane compresses
+		// again its own way
+		exported.Compression, exported.Verification = nil, nil
+	}
+	return exported, nil
+}
+
+func exportFileMode(mode *int) *exportMode {
+	if mode == nil {
+		return nil
+	}
+	m := exportMode(*mode)
+	return &m
+}
+
+func exportOwner(id *int, name *string) *exportNodeUser {
+	if id == nil && name == nil {
+		return nil
+	}
+	return &exportNodeUser{ID: id, Name: name}
+}
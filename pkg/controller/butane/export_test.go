@@ -0,0 +1,136 @@
+package butane
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func gzipDataURL(t *testing.T, contents string) string {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(contents))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return dataurl.EncodeBytes(buf.Bytes())
+}
+
+func TestExportMachineConfig(t *testing.T) {
+	enabled := true
+	dropin := "[Service]\nEnvironment=DEBUG=1\n"
+	ignCfg := ctrlcommon.NewIgnConfig()
+	ignCfg.Storage.Files = []ign3types.File{
+		helpers.CreateIgn3File("/etc/example.conf", "data:,key%3Dvalue%0Aother%3D1%0A", 0644),
+	}
+	ignCfg.Systemd.Units = []ign3types.Unit{{
+		Name:    "example.service",
+		Enabled: &enabled,
+		Dropins: []ign3types.Dropin{{Name: "10-debug.conf", Contents: &dropin}},
+	}}
+	mc, err := ctrlcommon.MachineConfigFromIgnConfig("worker", "99-worker-example", ignCfg)
+	require.NoError(t, err)
+	mc.Spec.KernelArguments = []string{"nosmt"}
+	mc.Spec.Extensions = []string{"usbguard"}
+
+	data, err := ExportMachineConfig(mc)
+	require.NoError(t, err)
+	assert.Equal(t, `variant: openshift
+version: 4.10.0
+metadata:
+  name: 99-worker-example
+  labels:
+    machineconfiguration.openshift.io/role: worker
+storage:
+  files:
+    - path: /etc/example.conf
+      contents:
+        inline: |
+          key=value
+          other=1
+      mode: 0644
+      user:
+        name: root
+systemd:
+  units:
+    - name: example.service
+      enabled: true
+      dropins:
+        - name: 10-debug.conf
+          contents: |
+            [Service]
+            Environment=DEBUG=1
+openshift:
+  kernel_arguments:
+    - nosmt
+  extensions:
+    - name: usbguard
+`, string(data))
+}
+
+func TestExportIgnition(t *testing.T) {
+	gzipped := gzipDataURL(t, "compressed\n")
+	gzipCompression := "gzip"
+	binary := dataurl.EncodeBytes([]byte{0xff, 0x00, 0x01})
+	remote := "https://example.com/file"
+	unit := "[Service]\nExecStart=/bin/true\n"
+	ignCfg := ctrlcommon.NewIgnConfig()
+	ignCfg.Storage.Files = []ign3types.File{
+		helpers.CreateEncodedIgn3File("/etc/text", "hello\n", 0600),
+		{Node: ign3types.Node{Path: "/etc/gzipped"}, FileEmbedded1: ign3types.FileEmbedded1{Contents: ign3types.Resource{Source: &gzipped, Compression: &gzipCompression}}},
+		{Node: ign3types.Node{Path: "/etc/binary"}, FileEmbedded1: ign3types.FileEmbedded1{Contents: ign3types.Resource{Source: &binary}}},
+		{Node: ign3types.Node{Path: "/etc/remote"}, FileEmbedded1: ign3types.FileEmbedded1{Contents: ign3types.Resource{Source: &remote}}},
+	}
+	ignCfg.Systemd.Units = []ign3types.Unit{{Name: "example.service", Contents: &unit}}
+	ignCfg.Passwd.Users = []ign3types.PasswdUser{{Name: "core", SSHAuthorizedKeys: []ign3types.SSHAuthorizedKey{"ssh-ed25519 AAAA"}}}
+
+	data, err := ExportIgnition(&ignCfg)
+	require.NoError(t, err)
+	exported := string(data)
+	assert.True(t, strings.HasPrefix(exported, "variant: fcos\nversion: 1.3.0\n"), exported)
+	assert.Contains(t, exported, "inline: |\n          compressed\n")
+	assert.NotContains(t, exported, "compression: gzip")
+	assert.Contains(t, exported, "source: "+binary)
+	assert.Contains(t, exported, "source: "+remote)
+	assert.Contains(t, exported, "ssh_authorized_keys:\n        - ssh-ed25519 AAAA\n")
+
+	// the export translates back to the same files and units, except for
+	// the ones which aren't inline
+	ignCfg.Storage.Files = ignCfg.Storage.Files[:2]
+	data, err = ExportIgnition(&ignCfg)
+	require.NoError(t, err)
+	translated, err := Translate(bytes.Replace(data, []byte("version: 1.3.0"), []byte("version: 1.0.0"), 1))
+	require.NoError(t, err)
+	require.Len(t, translated.Storage.Files, 2)
+	for i, f := range translated.Storage.Files {
+		want, err := ctrlcommon.DecodeIgnitionFileContents(ignCfg.Storage.Files[i].Contents.Source, ignCfg.Storage.Files[i].Contents.Compression)
+		require.NoError(t, err)
+		got, err := ctrlcommon.DecodeIgnitionFileContents(f.Contents.Source, f.Contents.Compression)
+		require.NoError(t, err)
+		assert.Equal(t, ignCfg.Storage.Files[i].Path, f.Path)
+		assert.Equal(t, string(want), string(got))
+	}
+	assert.Equal(t, 0600, *translated.Storage.Files[0].Mode)
+	assert.Equal(t, ignCfg.Systemd.Units, translated.Systemd.Units)
+
+	ignCfg.Storage.Disks = []ign3types.Disk{{Device: "/dev/vdb"}}
+	_, err = ExportIgnition(&ignCfg)
+	assert.EqualError(t, err, "can't export storage.disks, which MachineConfigs don't support")
+}
+
+func TestExportInvalidMachineConfig(t *testing.T) {
+	mc := &mcfgv1.MachineConfig{ObjectMeta: metav1.ObjectMeta{Name: "invalid"}}
+	mc.Spec.Config.Raw = []byte("{")
+	_, err := ExportMachineConfig(mc)
+	assert.Error(t, err)
+}
@@ -0,0 +1,151 @@
+package cgroupmode
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/controller/node"
+)
+
+const (
+	// WaitingAnnotationKey is set on a pool whose cgroup mode transition
+	// waits for the one of another pool, to the name of that pool.
+	WaitingAnnotationKey = "machineconfiguration.openshift.io/cgroup-mode-waiting-for"
+
+	// RequiredCgroupModeAnnotationKey is the annotation of a node whose
+	// workloads only work with a cgroup mode, e.g. an agent not supporting
+	// cgroup v2, set to v1 or v2.  The pool of the node can't switch to the
+	// other mode while it's set.
+	RequiredCgroupModeAnnotationKey = "machineconfiguration.openshift.io/required-cgroup-mode"
+
+	// modeAnnotationKey is set on the generated MachineConfig to the mode it renders.
+	modeAnnotationKey = "machineconfiguration.openshift.io/cgroup-mode"
+
+	crioConfPath = "/etc/crio/crio.conf.d/01-cgroup-mode"
+
+	workerPool = "worker"
+	masterPool = "master"
+)
+
+// kernelArgs are the kernel arguments booting the nodes into each cgroup mode.
+var kernelArgs = map[mcfgv1.CgroupMode][]string{
+	mcfgv1.CgroupModeV1: {"systemd.unified_cgroup_hierarchy=0", "systemd.legacy_systemd_cgroup_controller=1"},
+	mcfgv1.CgroupModeV2: {"systemd.unified_cgroup_hierarchy=1", "cgroup_no_v1=all", "psi=1"},
+}
+
+// crioConf makes CRI-O manage the cgroups of the containers through systemd,
+// like the kubelet does with its systemd cgroup driver.  Both drivers have to
+// agree, and only the systemd one delegates the cgroup v2 controllers
+// properly, so the transition pins them whatever the other configs of the
+// pool set.
+const crioConf = `[crio.runtime]
+cgroup_manager = "systemd"
+conmon_cgroup = "pod"
+`
+
+// generatedConfigKind describes the MachineConfigs generated from the cgroup mode of the pools.
+var generatedConfigKind = ctrlcommon.GeneratedConfigKind{
+	Suffix:        "cgroup-mode",
+	Description:   "cgroup mode",
+	ConditionType: mcfgv1.MachineConfigPoolCgroupModeDegraded,
+	InvalidReason: "CgroupModeInvalid",
+}
+
+// validateCgroupMode checks the cgroup mode of a pool.
+func validateCgroupMode(mode mcfgv1.CgroupMode) error {
+	if _, ok := kernelArgs[mode]; !ok {
+		return fmt.Errorf("invalid cgroupMode %q, must be %s or %s", mode, mcfgv1.CgroupModeV1, mcfgv1.CgroupModeV2)
+	}
+	return nil
+}
+
+// validateCompatibility checks the compatibility hints of the nodes of the
+// pool against its cgroup mode: none of them may require the other mode.
+func validateCompatibility(pool *mcfgv1.MachineConfigPool, pools []*mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	var conflicting []string
+	for _, n := range nodes {
+		required, ok := n.Annotations[RequiredCgroupModeAnnotationKey]
+		if !ok || required == string(pool.Spec.CgroupMode) {
+			continue
+		}
+		primary, err := node.GetPrimaryPoolForNode(pools, n)
+		if err != nil || primary == nil || primary.Name != pool.Name {
+			continue
+		}
+		conflicting = append(conflicting, fmt.Sprintf("%s (%s)", n.Name, required))
+	}
+	if len(conflicting) > 0 {
+		sort.Strings(conflicting)
+		return fmt.Errorf("nodes %s require another cgroup mode than %s through their %s annotation", strings.Join(conflicting, ", "), pool.Spec.CgroupMode, RequiredCgroupModeAnnotationKey)
+	}
+	return nil
+}
+
+// transitionOrder sorts the pools in the order they switch their cgroup mode,
+// like the stages of an UpdateWave: the custom pools first, by name, then
+// worker, and master last.
+func transitionOrder(pools []*mcfgv1.MachineConfigPool) []*mcfgv1.MachineConfigPool {
+	rank := func(pool *mcfgv1.MachineConfigPool) int {
+		switch pool.Name {
+		case workerPool:
+			return 1
+		case masterPool:
+			return 2
+		default:
+			return 0
+		}
+	}
+	sorted := append([]*mcfgv1.MachineConfigPool{}, pools...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if rank(sorted[i]) != rank(sorted[j]) {
+			return rank(sorted[i]) < rank(sorted[j])
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// transitionDone returns true if all the nodes of the pool run a rendered
+// config booting them into its cgroup mode.
+func transitionDone(pool *mcfgv1.MachineConfigPool, rendered *mcfgv1.MachineConfig) bool {
+	if rendered == nil || rendered.Name != pool.Status.Configuration.Name ||
+		pool.Spec.Configuration.Name != pool.Status.Configuration.Name ||
+		!mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolUpdated) {
+		return false
+	}
+	return sets.NewString(rendered.Spec.KernelArguments...).HasAll(kernelArgs[pool.Spec.CgroupMode]...)
+}
+
+// generatedMode returns the cgroup mode mc was generated for, or "" if there is none.
+func generatedMode(mc *mcfgv1.MachineConfig) mcfgv1.CgroupMode {
+	if mc == nil {
+		return ""
+	}
+	return mcfgv1.CgroupMode(mc.Annotations[modeAnnotationKey])
+}
+
+// generateMachineConfig renders the cgroup mode of the pool into a
+// MachineConfig for the pool's role: the kernel arguments of the mode, and
+// the CRI-O cgroup manager matching the systemd cgroup driver of the kubelet.
+func generateMachineConfig(pool *mcfgv1.MachineConfigPool) (*mcfgv1.MachineConfig, error) {
+	if err := validateCgroupMode(pool.Spec.CgroupMode); err != nil {
+		return nil, err
+	}
+
+	ignConfig := ctrlcommon.NewIgnConfig()
+	ignConfig.Storage.Files = append(ignConfig.Storage.Files, ctrlcommon.NewGeneratedFile(crioConfPath, crioConf))
+
+	mc, err := generatedConfigKind.NewMachineConfig(pool, ignConfig)
+	if err != nil {
+		return nil, err
+	}
+	mc.Spec.KernelArguments = append([]string{}, kernelArgs[pool.Spec.CgroupMode]...)
+	mc.Annotations[modeAnnotationKey] = string(pool.Spec.CgroupMode)
+	return mc, nil
+}
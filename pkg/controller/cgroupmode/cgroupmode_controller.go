@@ -0,0 +1,391 @@
+package cgroupmode
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformersv1 "k8s.io/client-go/informers/core/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	corev1clientset "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+)
+
+const (
+	// maxRetries is the number of times the pools will be retried before they're dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// they're going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+
+	// queueKey is the only key of the queue: the pools are synced together,
+	// as only one of them may switch its cgroup mode at a time.
+	queueKey = "cgroup-mode"
+)
+
+// Controller defines the cgroup mode controller, which renders the cgroup
+// mode of the pools into MachineConfigs, one pool at a time.
+type Controller struct {
+	client    mcfgclientset.Interface
+	generated *ctrlcommon.GeneratedConfigs
+
+	syncHandler func(key string) error
+
+	mcpLister  mcfglistersv1.MachineConfigPoolLister
+	mcLister   mcfglistersv1.MachineConfigLister
+	nodeLister corelisterv1.NodeLister
+
+	mcpListerSynced  cache.InformerSynced
+	mcListerSynced   cache.InformerSynced
+	nodeListerSynced cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+}
+
+// New returns a new cgroup mode controller.
+func New(
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	mcInformer mcfginformersv1.MachineConfigInformer,
+	nodeInformer coreinformersv1.NodeInformer,
+	kubeClient clientset.Interface,
+	mcfgClient mcfgclientset.Interface,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(&corev1clientset.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	ctrl := &Controller{
+		client: mcfgClient,
+		generated: &ctrlcommon.GeneratedConfigs{
+			GeneratedConfigKind: generatedConfigKind,
+			Client:              mcfgClient,
+			EventRecorder:       eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-cgroupmodecontroller"}),
+		},
+		queue: workqueue.NewNamedRateLimitingQueue(ctrlcommon.ControllerRateLimiter(), "machineconfigcontroller-cgroupmodecontroller"),
+	}
+
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addPool,
+		UpdateFunc: ctrl.updatePool,
+		DeleteFunc: ctrl.deletePool,
+	})
+	mcInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: ctrl.updateMachineConfig,
+		DeleteFunc: ctrl.deleteMachineConfig,
+	})
+	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addNode,
+		UpdateFunc: ctrl.updateNode,
+	})
+
+	ctrl.syncHandler = ctrl.syncPools
+
+	ctrl.mcpLister = mcpInformer.Lister()
+	ctrl.mcLister = mcInformer.Lister()
+	ctrl.generated.MCLister = ctrl.mcLister
+	ctrl.nodeLister = nodeInformer.Lister()
+	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
+	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
+	ctrl.nodeListerSynced = nodeInformer.Informer().HasSynced
+
+	return ctrl
+}
+
+// Run executes the cgroup mode controller
+func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer ctrl.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.mcListerSynced, ctrl.nodeListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-CgroupModeController")
+	defer glog.Info("Shutting down MachineConfigController-CgroupModeController")
+
+	// There's a single key, more workers wouldn't sync anything concurrently.
+	go wait.Until(ctrl.worker, time.Second, stopCh)
+
+	<-stopCh
+}
+
+func (ctrl *Controller) addPool(obj interface{}) {
+	pool := obj.(*mcfgv1.MachineConfigPool)
+	glog.V(4).Infof("Adding MachineConfigPool %s", pool.Name)
+	ctrl.queue.Add(queueKey)
+}
+
+func (ctrl *Controller) updatePool(old, cur interface{}) {
+	oldPool := old.(*mcfgv1.MachineConfigPool)
+	curPool := cur.(*mcfgv1.MachineConfigPool)
+	// The progress of the pools switching their cgroup mode gates the others,
+	// the other pools only matter when their cgroup mode changes.
+	if oldPool.Spec.CgroupMode == "" && curPool.Spec.CgroupMode == "" {
+		return
+	}
+	if oldPool.Spec.CgroupMode == curPool.Spec.CgroupMode && reflect.DeepEqual(oldPool.Spec.NodeSelector, curPool.Spec.NodeSelector) &&
+		reflect.DeepEqual(oldPool.Spec.Configuration, curPool.Spec.Configuration) && reflect.DeepEqual(oldPool.Status, curPool.Status) {
+		return
+	}
+	glog.V(4).Infof("Updating cgroup mode of MachineConfigPool %s", curPool.Name)
+	ctrl.queue.Add(queueKey)
+}
+
+func (ctrl *Controller) deletePool(obj interface{}) {
+	ctrl.queue.Add(queueKey)
+}
+
+func (ctrl *Controller) updateMachineConfig(old, cur interface{}) {
+	mc := cur.(*mcfgv1.MachineConfig)
+	if pool := generatedConfigKind.OwningPool(mc); pool != "" {
+		glog.V(4).Infof("MachineConfig %s generated for MachineConfigPool %s updated", mc.Name, pool)
+		ctrl.queue.Add(queueKey)
+	}
+}
+
+func (ctrl *Controller) deleteMachineConfig(obj interface{}) {
+	mc, ok := obj.(*mcfgv1.MachineConfig)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		mc, ok = tombstone.Obj.(*mcfgv1.MachineConfig)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a MachineConfig %#v", obj))
+			return
+		}
+	}
+	if pool := generatedConfigKind.OwningPool(mc); pool != "" {
+		glog.V(4).Infof("MachineConfig %s generated for MachineConfigPool %s deleted", mc.Name, pool)
+		ctrl.queue.Add(queueKey)
+	}
+}
+
+func (ctrl *Controller) addNode(obj interface{}) {
+	node := obj.(*corev1.Node)
+	if _, ok := node.Annotations[RequiredCgroupModeAnnotationKey]; ok {
+		ctrl.queue.Add(queueKey)
+	}
+}
+
+func (ctrl *Controller) updateNode(old, cur interface{}) {
+	oldNode := old.(*corev1.Node)
+	curNode := cur.(*corev1.Node)
+	if oldNode.Annotations[RequiredCgroupModeAnnotationKey] == curNode.Annotations[RequiredCgroupModeAnnotationKey] &&
+		(curNode.Annotations[RequiredCgroupModeAnnotationKey] == "" || reflect.DeepEqual(oldNode.Labels, curNode.Labels)) {
+		return
+	}
+	glog.V(4).Infof("Cgroup mode required by Node %s changed", curNode.Name)
+	ctrl.queue.Add(queueKey)
+}
+
+// worker runs a worker thread that just dequeues items, processes them, and marks them done.
+// It enforces that the syncHandler is never invoked concurrently with the same key.
+func (ctrl *Controller) worker() {
+	for ctrl.processNextWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextWorkItem() bool {
+	key, quit := ctrl.queue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	err := ctrl.syncHandler(key.(string))
+	ctrl.handleErr(err, key)
+
+	return true
+}
+
+func (ctrl *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.queue.Forget(key)
+		return
+	}
+
+	if ctrl.queue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing cgroup mode of MachineConfigPools: %v", err)
+		ctrl.queue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping cgroup mode of MachineConfigPools out of the queue: %v", err)
+	ctrl.queue.Forget(key)
+	ctrl.queue.AddAfter(key, 1*time.Minute)
+}
+
+// syncPools renders the cgroup mode of each pool into its MachineConfig, or
+// deletes the MachineConfig of the pools without one.  A pool only starts
+// switching to a new mode once no other pool is still rolling out its own
+// switch, in transitionOrder.  A deleted pool's MachineConfig is garbage
+// collected through its owner reference.
+// This function is not meant to be invoked concurrently with the same key.
+func (ctrl *Controller) syncPools(key string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing cgroup mode of MachineConfigPools (%v)", startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing cgroup mode of MachineConfigPools (%v)", time.Since(startTime))
+	}()
+
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	nodes, err := ctrl.nodeLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	pools = transitionOrder(pools)
+
+	// The pool whose nodes are switching, whose MachineConfig already renders
+	// its mode but which isn't updated yet.
+	switching := ""
+	for _, pool := range pools {
+		if pool.Spec.CgroupMode == "" {
+			continue
+		}
+		existing, err := ctrl.generated.Get(pool)
+		if err != nil {
+			return err
+		}
+		if generatedMode(existing) != pool.Spec.CgroupMode {
+			continue
+		}
+		done, err := ctrl.transitionDone(pool)
+		if err != nil {
+			return err
+		}
+		if !done {
+			switching = pool.Name
+			break
+		}
+	}
+
+	var errs []error
+	for _, pool := range pools {
+		next, err := ctrl.syncPool(pool, pools, nodes, switching)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("MachineConfigPool %s: %w", pool.Name, err))
+		}
+		if switching == "" {
+			switching = next
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// syncPool syncs the MachineConfig of a pool given the pool that is
+// switching its cgroup mode, if any.  It returns the name of the pool if it
+// started switching.
+func (ctrl *Controller) syncPool(pool *mcfgv1.MachineConfigPool, pools []*mcfgv1.MachineConfigPool, nodes []*corev1.Node, switching string) (string, error) {
+	if pool.Spec.CgroupMode == "" {
+		pool, err := ctrl.syncWaitingFor(pool, "")
+		if err != nil {
+			return "", err
+		}
+		return "", ctrl.generated.Delete(pool)
+	}
+
+	mc, err := generateMachineConfig(pool)
+	if err == nil {
+		err = validateCompatibility(pool, pools, nodes)
+	}
+	if err != nil {
+		pool, syncErr := ctrl.syncWaitingFor(pool, "")
+		if syncErr != nil {
+			return "", syncErr
+		}
+		return "", ctrl.generated.SyncFailingStatus(pool, err)
+	}
+	existing, err := ctrl.generated.Get(pool)
+	if err != nil {
+		return "", err
+	}
+
+	waitingFor, started := "", ""
+	if generatedMode(existing) != pool.Spec.CgroupMode {
+		if switching != "" && switching != pool.Name {
+			waitingFor = switching
+		} else {
+			started = pool.Name
+		}
+	}
+	pool, err = ctrl.syncWaitingFor(pool, waitingFor)
+	if err != nil {
+		return "", err
+	}
+	if waitingFor != "" {
+		glog.Infof("MachineConfigPool %s waits for MachineConfigPool %s to switch its cgroup mode", pool.Name, waitingFor)
+		return "", ctrl.generated.SyncStatus(pool)
+	}
+	updated, err := ctrl.generated.Apply(pool, mc)
+	if err != nil {
+		return started, err
+	}
+	if !updated && existing == nil {
+		// A MachineConfig of the same name which wasn't generated for the pool.
+		return "", nil
+	}
+	if updated && started != "" {
+		ctrl.generated.EventRecorder.Eventf(pool, corev1.EventTypeNormal, "CgroupModeApplied", "Generated MachineConfig %s switching the nodes to cgroup %s", mc.Name, pool.Spec.CgroupMode)
+	}
+	return started, nil
+}
+
+// transitionDone returns true if the nodes of the pool run its cgroup mode.
+func (ctrl *Controller) transitionDone(pool *mcfgv1.MachineConfigPool) (bool, error) {
+	if pool.Status.Configuration.Name == "" {
+		return false, nil
+	}
+	rendered, err := ctrl.mcLister.Get(pool.Status.Configuration.Name)
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return transitionDone(pool, rendered), nil
+}
+
+// syncWaitingFor records the pool the transition of the pool waits for, if
+// any, in an annotation of the pool, and returns the updated pool.
+func (ctrl *Controller) syncWaitingFor(pool *mcfgv1.MachineConfigPool, waitingFor string) (*mcfgv1.MachineConfigPool, error) {
+	if pool.Annotations[WaitingAnnotationKey] == waitingFor {
+		return pool, nil
+	}
+	newPool := pool.DeepCopy()
+	if waitingFor == "" {
+		delete(newPool.Annotations, WaitingAnnotationKey)
+	} else {
+		if newPool.Annotations == nil {
+			newPool.Annotations = map[string]string{}
+		}
+		newPool.Annotations[WaitingAnnotationKey] = waitingFor
+	}
+	return ctrl.client.MachineconfigurationV1().MachineConfigPools().Update(context.TODO(), newPool, metav1.UpdateOptions{})
+}
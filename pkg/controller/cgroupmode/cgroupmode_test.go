@@ -0,0 +1,112 @@
+package cgroupmode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+// updatedPool returns a pool whose nodes all run rendered, booting them with kargs.
+func updatedPool(name string, mode mcfgv1.CgroupMode, kargs ...string) (*mcfgv1.MachineConfigPool, *mcfgv1.MachineConfig) {
+	pool := helpers.NewPool(name)
+	pool.Spec.CgroupMode = mode
+	pool.Status.Conditions = append(pool.Status.Conditions, mcfgv1.MachineConfigPoolCondition{
+		Type:   mcfgv1.MachineConfigPoolUpdated,
+		Status: corev1.ConditionTrue,
+	})
+	rendered := helpers.NewMachineConfig(pool.Spec.Configuration.Name, nil, "", nil)
+	rendered.Spec.KernelArguments = kargs
+	return pool, rendered
+}
+
+func newNode(name, role, requiredMode string) *corev1.Node {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Labels:      map[string]string{"node-role/" + role: ""},
+		Annotations: map[string]string{},
+	}}
+	if requiredMode != "" {
+		node.Annotations[RequiredCgroupModeAnnotationKey] = requiredMode
+	}
+	return node
+}
+
+func TestValidateCgroupMode(t *testing.T) {
+	assert.NoError(t, validateCgroupMode(mcfgv1.CgroupModeV1))
+	assert.NoError(t, validateCgroupMode(mcfgv1.CgroupModeV2))
+	assert.Error(t, validateCgroupMode("v3"))
+}
+
+func TestValidateCompatibility(t *testing.T) {
+	worker := helpers.NewPool("worker")
+	worker.Spec.CgroupMode = mcfgv1.CgroupModeV2
+	infra := helpers.NewPool("infra")
+	infra.Spec.CgroupMode = mcfgv1.CgroupModeV1
+	pools := []*mcfgv1.MachineConfigPool{worker, infra}
+
+	// an infra node is also a worker, but only its primary pool counts
+	infraNode := newNode("infra-0", "infra", "v1")
+	infraNode.Labels["node-role/worker"] = ""
+	nodes := []*corev1.Node{newNode("worker-0", "worker", ""), newNode("worker-1", "worker", "v2"), infraNode}
+	assert.NoError(t, validateCompatibility(worker, pools, nodes))
+	assert.NoError(t, validateCompatibility(infra, pools, nodes))
+
+	nodes = append(nodes, newNode("worker-2", "worker", "v1"))
+	err := validateCompatibility(worker, pools, nodes)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "worker-2 (v1)")
+}
+
+func TestTransitionOrder(t *testing.T) {
+	var names []string
+	for _, pool := range transitionOrder([]*mcfgv1.MachineConfigPool{
+		helpers.NewPool("master"), helpers.NewPool("worker"), helpers.NewPool("infra"), helpers.NewPool("edge"),
+	}) {
+		names = append(names, pool.Name)
+	}
+	assert.Equal(t, []string{"edge", "infra", "worker", "master"}, names)
+}
+
+func TestTransitionDone(t *testing.T) {
+	pool, rendered := updatedPool("worker", mcfgv1.CgroupModeV2, "cgroup_no_v1=all", "psi=1", "systemd.unified_cgroup_hierarchy=1", "nosmt")
+	assert.True(t, transitionDone(pool, rendered))
+
+	// still on the config of the previous mode
+	pool, rendered = updatedPool("worker", mcfgv1.CgroupModeV2, "systemd.unified_cgroup_hierarchy=0")
+	assert.False(t, transitionDone(pool, rendered))
+
+	// rolling out the new config
+	pool, rendered = updatedPool("worker", mcfgv1.CgroupModeV2, kernelArgs[mcfgv1.CgroupModeV2]...)
+	pool.Spec.Configuration.Name = "rendered-worker-2"
+	assert.False(t, transitionDone(pool, rendered))
+}
+
+func TestGenerateMachineConfig(t *testing.T) {
+	pool := helpers.NewPool("infra")
+	pool.Spec.CgroupMode = mcfgv1.CgroupModeV2
+	mc, err := generateMachineConfig(pool)
+	require.NoError(t, err)
+	assert.Equal(t, "99-infra-generated-cgroup-mode", mc.Name)
+	assert.Equal(t, "infra", mc.Labels[mcfgv1.MachineConfigRoleLabelKey])
+	assert.Equal(t, "infra", generatedConfigKind.OwningPool(mc))
+	assert.Equal(t, mcfgv1.CgroupModeV2, generatedMode(mc))
+	assert.Equal(t, []string{"systemd.unified_cgroup_hierarchy=1", "cgroup_no_v1=all", "psi=1"}, mc.Spec.KernelArguments)
+
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	require.NoError(t, err)
+	conf, err := ctrlcommon.GetIgnitionFileDataByPath(&ignCfg, "/etc/crio/crio.conf.d/01-cgroup-mode")
+	require.NoError(t, err)
+	assert.Contains(t, string(conf), `cgroup_manager = "systemd"`)
+
+	pool.Spec.CgroupMode = mcfgv1.CgroupModeV1
+	mc, err = generateMachineConfig(pool)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"systemd.unified_cgroup_hierarchy=0", "systemd.legacy_systemd_cgroup_controller=1"}, mc.Spec.KernelArguments)
+}
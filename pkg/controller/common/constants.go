@@ -10,6 +10,13 @@ const (
 	// ReleaseImageVersionAnnotationKey is used to tag the rendered machineconfigs & controller config with the release image version.
 	ReleaseImageVersionAnnotationKey = "machineconfiguration.openshift.io/release-image-version"
 
+	// CustomizationsConfigMapName is the name of the optional ConfigMap in the MCO namespace holding the
+	// customizations of the operator, which bootstrap reads from the manifests too.
+	CustomizationsConfigMapName = "machine-config-operator-customizations"
+
+	// CustomizationsConfigMapKey is the key of the customizations in their ConfigMap.
+	CustomizationsConfigMapKey = "config.yaml"
+
 	// ControllerConfigName is the name of the ControllerConfig object that controllers use
 	ControllerConfigName = "machine-config-controller"
 
@@ -30,4 +37,45 @@ const (
 
 	// ClusterFeatureInstanceName is a singleton name for featureGate configuration
 	ClusterFeatureInstanceName = "cluster"
+
+	// SecretScanPolicyAnnotationKey is set on the ControllerConfig to choose what happens when a rendered template
+	// writes secret looking contents to a world-readable file: "Fail" (the default) or "Warn".
+	SecretScanPolicyAnnotationKey = "machineconfiguration.openshift.io/secret-scan-policy"
+
+	// ScriptLintSeverityAnnotationKey is set on the ControllerConfig to choose the lowest severity of the problems
+	// found in rendered shell scripts which fails rendering: "Error" (the default), "Warning" or "None".
+	ScriptLintSeverityAnnotationKey = "machineconfiguration.openshift.io/script-lint-severity"
+
+	// StrictTemplatesAnnotationKey is set on the ControllerConfig to "true" to fail rendering when a template
+	// references a missing map key, instead of rendering "<no value>".  Bootstrap rendering is strict unless it's "false".
+	StrictTemplatesAnnotationKey = "machineconfiguration.openshift.io/strict-templates"
+
+	// TimeSyncSourceAnnotationKey is set on the ControllerConfig, or on a MachineConfigPool to override it for the
+	// template MachineConfigs of the pool, to what the nodes synchronize their clock with: "NTP" (the default) or "PTP".
+	TimeSyncSourceAnnotationKey = "machineconfiguration.openshift.io/time-sync-source"
+
+	// TemplateVarsAnnotationKey is set on a MachineConfigPool to a JSON object of the string variables its
+	// template MachineConfigs are rendered with, as .PoolVars.
+	TemplateVarsAnnotationKey = "machineconfiguration.openshift.io/template-vars"
+
+	// InfraTemplatesAnnotationKey is set on the infra MachineConfigPool to "true" to render the MachineConfigs of the
+	// infra templates, which change the kernel settings of the nodes and so reboot them.
+	InfraTemplatesAnnotationKey = "machineconfiguration.openshift.io/infra-templates"
+
+	// RoleBaseLabelKey is set on a custom MachineConfigPool to "master" to render its default kubelet and
+	// container runtime configs from the master templates instead of the worker ones.
+	RoleBaseLabelKey = "machineconfiguration.openshift.io/role-base"
+
+	// RerenderGenerationAnnotationKey is set on the ControllerConfig to force new rendered machineconfigs, and so a
+	// rollout re-asserting the config on the nodes, even if their contents are unchanged. Any change of its value
+	// renders again; the value is copied to the rendered machineconfigs.
+	RerenderGenerationAnnotationKey = "machineconfiguration.openshift.io/rerender-generation"
+
+	// RenderedConfigCompressionAnnotationKey is set on the ControllerConfig to choose whether file contents are
+	// compressed when a rendered machineconfig would otherwise be too large to be stored: "None" (the default) or "Gzip".
+	RenderedConfigCompressionAnnotationKey = "machineconfiguration.openshift.io/rendered-config-compression"
+
+	// RenderProvenanceAnnotationKey is set on the rendered machineconfigs to the RenderProvenance of the inputs
+	// they were first rendered from, as JSON.
+	RenderProvenanceAnnotationKey = "machineconfiguration.openshift.io/render-provenance"
 )
@@ -18,17 +18,13 @@ import (
 	"k8s.io/client-go/informers"
 )
 
-const (
-	minResyncPeriod = 20 * time.Minute
-)
-
 func resyncPeriod() func() time.Duration {
 	return func() time.Duration {
 		// Disable gosec here to avoid throwing
 		// G404: Use of weak random number generator (math/rand instead of crypto/rand)
 		// #nosec
 		factor := rand.Float64() + 1
-		return time.Duration(float64(minResyncPeriod.Nanoseconds()) * factor)
+		return time.Duration(float64(controllerTuning.ResyncPeriod.Nanoseconds()) * factor)
 	}
 }
 
@@ -40,6 +36,7 @@ type ControllerContext struct {
 	InformerFactory                                     mcfginformers.SharedInformerFactory
 	KubeInformerFactory                                 informers.SharedInformerFactory
 	KubeNamespacedInformerFactory                       informers.SharedInformerFactory
+	KubeMCONamespacedInformerFactory                    informers.SharedInformerFactory
 	OpenShiftConfigKubeNamespacedInformerFactory        informers.SharedInformerFactory
 	OpenShiftKubeAPIServerKubeNamespacedInformerFactory informers.SharedInformerFactory
 	APIExtInformerFactory                               apiextinformers.SharedInformerFactory
@@ -67,6 +64,7 @@ func CreateControllerContext(cb *clients.Builder, stop <-chan struct{}, targetNa
 	sharedNamespacedInformers := mcfginformers.NewFilteredSharedInformerFactory(client, resyncPeriod()(), targetNamespace, nil)
 	kubeSharedInformer := informers.NewSharedInformerFactory(kubeClient, resyncPeriod()())
 	kubeNamespacedSharedInformer := informers.NewFilteredSharedInformerFactory(kubeClient, resyncPeriod()(), targetNamespace, nil)
+	kubeMCONamespacedSharedInformer := informers.NewFilteredSharedInformerFactory(kubeClient, resyncPeriod()(), MCONamespace, nil)
 	openShiftConfigKubeNamespacedSharedInformer := informers.NewFilteredSharedInformerFactory(kubeClient, resyncPeriod()(), "openshift-config", nil)
 	openShiftKubeAPIServerKubeNamespacedSharedInformer := informers.NewFilteredSharedInformerFactory(kubeClient,
 		resyncPeriod()(),
@@ -98,6 +96,7 @@ func CreateControllerContext(cb *clients.Builder, stop <-chan struct{}, targetNa
 		InformerFactory:                                     sharedInformers,
 		KubeInformerFactory:                                 kubeSharedInformer,
 		KubeNamespacedInformerFactory:                       kubeNamespacedSharedInformer,
+		KubeMCONamespacedInformerFactory:                    kubeMCONamespacedSharedInformer,
 		OpenShiftConfigKubeNamespacedInformerFactory:        openShiftConfigKubeNamespacedSharedInformer,
 		OpenShiftKubeAPIServerKubeNamespacedInformerFactory: openShiftKubeAPIServerKubeNamespacedSharedInformer,
 		APIExtInformerFactory:                               apiExtSharedInformer,
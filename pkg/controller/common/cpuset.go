@@ -0,0 +1,55 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	kubeletconfigv1beta1 "k8s.io/kubelet/config/v1beta1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+// maxCPU bounds the CPU ids of a cpuset, well above the CPU count of any
+// node, so that a typo can't expand to a huge set.
+const maxCPU = 8191
+
+// ParseCPUSet parses a cpuset in the list format of cpuset(7), e.g. 0-1,52-53.
+func ParseCPUSet(s string) (sets.Int, error) {
+	cpus := sets.NewInt()
+	if s == "" {
+		return nil, fmt.Errorf("empty cpuset")
+	}
+	for _, r := range strings.Split(s, ",") {
+		bounds := strings.SplitN(r, "-", 2)
+		low, err := strconv.Atoi(bounds[0])
+		if err != nil || low < 0 || low > maxCPU {
+			return nil, fmt.Errorf("invalid CPU %q in %q", bounds[0], s)
+		}
+		high := low
+		if len(bounds) == 2 {
+			high, err = strconv.Atoi(bounds[1])
+			if err != nil || high < low || high > maxCPU {
+				return nil, fmt.Errorf("invalid CPU range %q in %q", r, s)
+			}
+		}
+		for cpu := low; cpu <= high; cpu++ {
+			cpus.Insert(cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// ReservedSystemCPUs returns the reservedSystemCPUs a KubeletConfig sets, if any.
+func ReservedSystemCPUs(kc *mcfgv1.KubeletConfig) (string, error) {
+	if kc.Spec.KubeletConfig == nil || kc.Spec.KubeletConfig.Raw == nil {
+		return "", nil
+	}
+	cfg := &kubeletconfigv1beta1.KubeletConfiguration{}
+	if err := yaml.Unmarshal(kc.Spec.KubeletConfig.Raw, cfg); err != nil {
+		return "", fmt.Errorf("could not decode KubeletConfig %s: %v", kc.Name, err)
+	}
+	return cfg.ReservedSystemCPUs, nil
+}
@@ -0,0 +1,20 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestParseCPUSet(t *testing.T) {
+	cpus, err := ParseCPUSet("0-1,52-53,7")
+	require.NoError(t, err)
+	assert.Equal(t, sets.NewInt(0, 1, 7, 52, 53), cpus)
+
+	for _, invalid := range []string{"", "a", "-1", "3-1", "0,", "0-", "0-1-2", "0-100000"} {
+		_, err := ParseCPUSet(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,99 @@
+package common
+
+import (
+	"errors"
+)
+
+// The failure classes surfaced in conditions and node annotations, so that
+// automation can tell them apart without matching on error messages.
+const (
+	// ErrorReasonRender is reported when a rendered MachineConfig can't be generated for a pool
+	ErrorReasonRender = "RenderError"
+	// ErrorReasonMergeConflict is reported when the MachineConfigs of a pool can't be merged together
+	ErrorReasonMergeConflict = "MergeConflict"
+	// ErrorReasonDrainTimeout is reported when a node couldn't be drained in time
+	ErrorReasonDrainTimeout = "DrainTimeout"
+	// ErrorReasonOSUpdate is reported when updating the OS of a node failed
+	ErrorReasonOSUpdate = "OSUpdateError"
+	// ErrorReasonConfigTooLarge is reported when a rendered MachineConfig is too large to be stored
+	ErrorReasonConfigTooLarge = "ConfigTooLarge"
+	// ErrorReasonPostUpdateValidation is reported when a node failed the post-update validation of its pool
+	ErrorReasonPostUpdateValidation = "PostUpdateValidationFailed"
+	// ErrorReasonKubeletIncompatible is reported when a config doesn't work with the kubelet of a node
+	ErrorReasonKubeletIncompatible = "KubeletIncompatible"
+	// ErrorReasonBootFallback is reported when a node fell back to its previous OS deployment after failing to boot a config
+	ErrorReasonBootFallback = "BootFallback"
+)
+
+// reasoner is implemented by the typed errors below.
+type reasoner interface {
+	Reason() string
+}
+
+// RenderError is returned when a rendered MachineConfig can't be generated for a pool.
+type RenderError struct{ Err error }
+
+func (e *RenderError) Error() string  { return e.Err.Error() }
+func (e *RenderError) Unwrap() error  { return e.Err }
+func (e *RenderError) Reason() string { return ErrorReasonRender }
+
+// MergeConflictError is returned when the MachineConfigs of a pool conflict with each other.
+type MergeConflictError struct{ Err error }
+
+func (e *MergeConflictError) Error() string  { return e.Err.Error() }
+func (e *MergeConflictError) Unwrap() error  { return e.Err }
+func (e *MergeConflictError) Reason() string { return ErrorReasonMergeConflict }
+
+// DrainTimeoutError is returned when a node couldn't be drained in time.
+type DrainTimeoutError struct{ Err error }
+
+func (e *DrainTimeoutError) Error() string  { return e.Err.Error() }
+func (e *DrainTimeoutError) Unwrap() error  { return e.Err }
+func (e *DrainTimeoutError) Reason() string { return ErrorReasonDrainTimeout }
+
+// OSUpdateError is returned when updating the OS of a node failed.
+type OSUpdateError struct{ Err error }
+
+func (e *OSUpdateError) Error() string  { return e.Err.Error() }
+func (e *OSUpdateError) Unwrap() error  { return e.Err }
+func (e *OSUpdateError) Reason() string { return ErrorReasonOSUpdate }
+
+// ConfigTooLargeError is returned when a rendered MachineConfig is too large to be stored.
+type ConfigTooLargeError struct{ Err error }
+
+func (e *ConfigTooLargeError) Error() string  { return e.Err.Error() }
+func (e *ConfigTooLargeError) Unwrap() error  { return e.Err }
+func (e *ConfigTooLargeError) Reason() string { return ErrorReasonConfigTooLarge }
+
+// PostUpdateValidationError is returned when a node failed the post-update validation of its pool.
+type PostUpdateValidationError struct{ Err error }
+
+func (e *PostUpdateValidationError) Error() string  { return e.Err.Error() }
+func (e *PostUpdateValidationError) Unwrap() error  { return e.Err }
+func (e *PostUpdateValidationError) Reason() string { return ErrorReasonPostUpdateValidation }
+
+// KubeletIncompatibleError is returned when a config uses kubelet flags or
+// feature gates the kubelet of a node doesn't support.
+type KubeletIncompatibleError struct{ Err error }
+
+func (e *KubeletIncompatibleError) Error() string  { return e.Err.Error() }
+func (e *KubeletIncompatibleError) Unwrap() error  { return e.Err }
+func (e *KubeletIncompatibleError) Reason() string { return ErrorReasonKubeletIncompatible }
+
+// BootFallbackError is returned when the bootloader of a node fell back to its
+// previous OS deployment after the node failed its boot health checks.
+type BootFallbackError struct{ Err error }
+
+func (e *BootFallbackError) Error() string  { return e.Err.Error() }
+func (e *BootFallbackError) Unwrap() error  { return e.Err }
+func (e *BootFallbackError) Reason() string { return ErrorReasonBootFallback }
+
+// ErrorReason returns the reason of the outermost typed error wrapped by err,
+// or an empty string if err doesn't wrap any.
+func ErrorReason(err error) string {
+	var r reasoner
+	if errors.As(err, &r) {
+		return r.Reason()
+	}
+	return ""
+}
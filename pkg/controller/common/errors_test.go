@@ -0,0 +1,33 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorReason(t *testing.T) {
+	base := fmt.Errorf("boom")
+	tests := []struct {
+		err    error
+		reason string
+	}{
+		{err: nil, reason: ""},
+		{err: base, reason: ""},
+		{err: &RenderError{Err: base}, reason: ErrorReasonRender},
+		{err: &MergeConflictError{Err: base}, reason: ErrorReasonMergeConflict},
+		{err: errors.Wrapf(&DrainTimeoutError{Err: base}, "failed to update"), reason: ErrorReasonDrainTimeout},
+		{err: fmt.Errorf("sync failed: %w", &OSUpdateError{Err: base}), reason: ErrorReasonOSUpdate},
+		// the outermost typed error wins
+		{err: &RenderError{Err: &MergeConflictError{Err: base}}, reason: ErrorReasonRender},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.reason, ErrorReason(test.err), "%v", test.err)
+	}
+
+	var drainErr *DrainTimeoutError
+	assert.True(t, errors.As(errors.Wrap(&DrainTimeoutError{Err: base}, "wrapped"), &drainErr))
+	assert.Equal(t, "boom", drainErr.Error())
+}
@@ -0,0 +1,102 @@
+package common
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+// FeatureSchemaVersion is the version of the FeatureSchema document, bumped on
+// incompatible changes of its fields.
+const FeatureSchemaVersion = "v1"
+
+var (
+	// SupportedIgnitionVersions are the Ignition spec versions a MachineConfig may use,
+	// see IgnParseWrapper.
+	SupportedIgnitionVersions = []string{"2.2", "3.0", "3.1", "3.2"}
+
+	// SupportedKernelTypes are the values of the kernelType of a MachineConfig
+	// besides the empty one.
+	SupportedKernelTypes = []string{KernelTypeDefault, KernelTypeRealtime}
+
+	// UnreconcilableIgnitionSections are the Ignition sections the daemon refuses
+	// to change after the installation, see reconcilable in the daemon.
+	UnreconcilableIgnitionSections = []string{
+		"passwd.groups",
+		"storage.directories",
+		"storage.disks",
+		"storage.filesystems",
+		"storage.links",
+		"storage.raid",
+	}
+)
+
+// SupportedExtensions returns the RHCOS extensions a MachineConfig may
+// enable, with the packages each one installs.
+func SupportedExtensions() map[string][]string {
+	// In future when list of extensions grow, it will make
+	// more sense to populate it in a dynamic way.
+	return map[string][]string{
+		"usbguard":             {"usbguard"},
+		"kerberos":             {"krb5-workstation", "libkadm5"},
+		"kernel-devel":         {"kernel-devel", "kernel-headers"},
+		"sandboxed-containers": {"kata-containers"},
+	}
+}
+
+// FeatureSchema describes the MachineConfig features supported by a version
+// of the operator, for tools validating MachineConfigs before applying them.
+type FeatureSchema struct {
+	// SchemaVersion is FeatureSchemaVersion.
+	SchemaVersion string `json:"schemaVersion"`
+	// ReleaseVersion is the release of the operator, empty if unknown.
+	ReleaseVersion string `json:"releaseVersion,omitempty"`
+	// OperatorVersion is the git hash the operator is built from.
+	OperatorVersion string `json:"operatorVersion"`
+	// MachineConfigFields are the fields of the MachineConfig spec.
+	MachineConfigFields []string `json:"machineConfigFields"`
+	// IgnitionVersions are the Ignition spec versions of spec.config.
+	IgnitionVersions []string `json:"ignitionVersions"`
+	// UnreconcilableIgnitionSections are the Ignition sections which can't
+	// change after the installation.
+	UnreconcilableIgnitionSections []string `json:"unreconcilableIgnitionSections"`
+	// Extensions are the names of spec.extensions.
+	Extensions []string `json:"extensions"`
+	// KernelTypes are the values of spec.kernelType.
+	KernelTypes []string `json:"kernelTypes"`
+}
+
+// NewFeatureSchema returns the FeatureSchema of this build of the operator.
+func NewFeatureSchema(releaseVersion, operatorVersion string) FeatureSchema {
+	var extensions []string
+	for ext := range SupportedExtensions() {
+		extensions = append(extensions, ext)
+	}
+	sort.Strings(extensions)
+	return FeatureSchema{
+		SchemaVersion:                  FeatureSchemaVersion,
+		ReleaseVersion:                 releaseVersion,
+		OperatorVersion:                operatorVersion,
+		MachineConfigFields:            jsonFields(reflect.TypeOf(mcfgv1.MachineConfigSpec{})),
+		IgnitionVersions:               append([]string(nil), SupportedIgnitionVersions...),
+		UnreconcilableIgnitionSections: append([]string(nil), UnreconcilableIgnitionSections...),
+		Extensions:                     extensions,
+		KernelTypes:                    append([]string(nil), SupportedKernelTypes...),
+	}
+}
+
+// jsonFields returns the sorted JSON names of the fields of the struct t.
+func jsonFields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
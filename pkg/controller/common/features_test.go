@@ -0,0 +1,30 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+func TestNewFeatureSchema(t *testing.T) {
+	schema := NewFeatureSchema("4.10.0", "abc123")
+	assert.Equal(t, FeatureSchemaVersion, schema.SchemaVersion)
+	assert.Equal(t, "4.10.0", schema.ReleaseVersion)
+	assert.Equal(t, []string{"config", "extensions", "fips", "kernelArguments", "kernelType", "osImageURL"}, schema.MachineConfigFields)
+	assert.Equal(t, []string{"kerberos", "kernel-devel", "sandboxed-containers", "usbguard"}, schema.Extensions)
+	assert.Equal(t, []string{"default", "realtime"}, schema.KernelTypes)
+
+	// the schema must agree with the validation of the MachineConfigs
+	for _, kernelType := range schema.KernelTypes {
+		assert.NoError(t, ValidateMachineConfig(mcfgv1.MachineConfigSpec{KernelType: kernelType}))
+	}
+	assert.Error(t, ValidateMachineConfig(mcfgv1.MachineConfigSpec{KernelType: "64k-pages"}))
+	for _, v := range schema.IgnitionVersions {
+		_, err := IgnParseWrapper([]byte(fmt.Sprintf(`{"ignition":{"version":"%s.0"}}`, v)))
+		require.NoError(t, err, "Ignition version %s", v)
+	}
+}
@@ -0,0 +1,62 @@
+package common
+
+import (
+	"fmt"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterFreeze describes an active cluster-wide freeze of node updates.
+type ClusterFreeze struct {
+	// Reason is the reason given when freezing
+	Reason string
+	// FrozenBy is the user who froze node updates, recorded on admission
+	FrozenBy string
+	// Since is when node updates were frozen, recorded on admission
+	Since metav1.Time
+}
+
+// GetClusterFreeze returns the active cluster-wide freeze, or nil if node updates aren't frozen.
+func GetClusterFreeze(lister mcfglistersv1.MachineConfigFreezeLister) (*ClusterFreeze, error) {
+	mcf, err := lister.Get(mcfgv1.MachineConfigFreezeName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return clusterFreezeFromSpec(&mcf.Spec), nil
+}
+
+func clusterFreezeFromSpec(spec *mcfgv1.MachineConfigFreezeSpec) *ClusterFreeze {
+	if !spec.Frozen {
+		return nil
+	}
+	freeze := &ClusterFreeze{
+		Reason:   spec.Reason,
+		FrozenBy: spec.FrozenBy,
+	}
+	if spec.FrozenAt != nil {
+		freeze.Since = *spec.FrozenAt
+	}
+	return freeze
+}
+
+// String returns a human readable description of the freeze.
+func (f *ClusterFreeze) String() string {
+	msg := "Node updates are frozen cluster-wide"
+	if f.FrozenBy != "" {
+		msg += fmt.Sprintf(" by %s", f.FrozenBy)
+	}
+	if !f.Since.IsZero() {
+		msg += fmt.Sprintf(" since %s", f.Since.UTC().Format(time.RFC3339))
+	}
+	if f.Reason != "" {
+		msg += fmt.Sprintf(": %s", f.Reason)
+	}
+	return msg
+}
@@ -0,0 +1,27 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterFreezeFromSpec(t *testing.T) {
+	frozenAt := metav1.NewTime(time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	assert.Nil(t, clusterFreezeFromSpec(&mcfgv1.MachineConfigFreezeSpec{Frozen: false, Reason: "incident", FrozenBy: "alice"}))
+
+	freeze := clusterFreezeFromSpec(&mcfgv1.MachineConfigFreezeSpec{Frozen: true, Reason: "incident", FrozenBy: "alice", FrozenAt: &frozenAt})
+	require.NotNil(t, freeze)
+	assert.Equal(t, frozenAt, freeze.Since)
+	assert.Equal(t, "Node updates are frozen cluster-wide by alice since 2022-03-01T12:00:00Z: incident", freeze.String())
+
+	// without the fields set on admission
+	freeze = clusterFreezeFromSpec(&mcfgv1.MachineConfigFreezeSpec{Frozen: true})
+	require.NotNil(t, freeze)
+	assert.Equal(t, "Node updates are frozen cluster-wide", freeze.String())
+}
@@ -0,0 +1,186 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/golang/glog"
+	"github.com/vincent-petithory/dataurl"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	mcoResourceApply "github.com/openshift/machine-config-operator/lib/resourceapply"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/version"
+)
+
+// GeneratedConfigKind describes the MachineConfigs a controller generates
+// for the pools out of their spec.  Each pool gets at most one, named
+// 99-<pool>-generated-<Suffix> and controlled by the pool, so it's garbage
+// collected with it.
+type GeneratedConfigKind struct {
+	// Suffix is the suffix of the names of the MachineConfigs.
+	Suffix string
+	// Description names the configuration in messages, e.g. "storage configuration".
+	Description string
+	// ConditionType is the condition of the pool reporting an invalid configuration.
+	ConditionType mcfgv1.MachineConfigPoolConditionType
+	// InvalidReason is the reason of the condition and of the warning event
+	// reporting an invalid configuration.
+	InvalidReason string
+}
+
+// Name returns the name of the MachineConfig generated for the pool.
+func (k GeneratedConfigKind) Name(pool string) string {
+	return fmt.Sprintf("99-%s-generated-%s", pool, k.Suffix)
+}
+
+// OwningPool returns the name of the pool mc was generated for, or "" if it
+// isn't of this kind.
+func (k GeneratedConfigKind) OwningPool(mc *mcfgv1.MachineConfig) string {
+	ref := metav1.GetControllerOf(mc)
+	if ref == nil || ref.Kind != "MachineConfigPool" || mc.Name != k.Name(ref.Name) {
+		return ""
+	}
+	return ref.Name
+}
+
+// NewMachineConfig returns the MachineConfig generated for the pool's role
+// out of ignConfig.
+func (k GeneratedConfigKind) NewMachineConfig(pool *mcfgv1.MachineConfigPool, ignConfig ign3types.Config) (*mcfgv1.MachineConfig, error) {
+	mc, err := MachineConfigFromIgnConfig(pool.Name, k.Name(pool.Name), ignConfig)
+	if err != nil {
+		return nil, err
+	}
+	mc.SetAnnotations(map[string]string{
+		GeneratedByControllerVersionAnnotationKey: version.Hash,
+	})
+	mc.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(pool, mcfgv1.SchemeGroupVersion.WithKind("MachineConfigPool"))})
+	return mc, nil
+}
+
+// GeneratedConfigs manages the MachineConfigs of a GeneratedConfigKind, and
+// reports why a pool's MachineConfig can't be generated in the
+// ConditionType condition of the pool.
+type GeneratedConfigs struct {
+	GeneratedConfigKind
+
+	Client        mcfgclientset.Interface
+	MCLister      mcfglistersv1.MachineConfigLister
+	EventRecorder record.EventRecorder
+}
+
+// Get returns the MachineConfig generated for the pool, or nil if there is
+// none.
+func (g *GeneratedConfigs) Get(pool *mcfgv1.MachineConfigPool) (*mcfgv1.MachineConfig, error) {
+	mc, err := g.MCLister.Get(g.Name(pool.Name))
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if g.OwningPool(mc) != pool.Name {
+		return nil, nil
+	}
+	return mc, nil
+}
+
+// Apply creates or updates the MachineConfig generated for the pool, and
+// clears the condition of the pool.  It returns true if the MachineConfig
+// changed.  Another MachineConfig of the same name is left alone and
+// reported on the pool.
+func (g *GeneratedConfigs) Apply(pool *mcfgv1.MachineConfigPool, mc *mcfgv1.MachineConfig) (bool, error) {
+	existing, err := g.MCLister.Get(mc.Name)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	if err == nil && g.OwningPool(existing) != pool.Name {
+		return false, g.SyncFailingStatus(pool, fmt.Errorf("MachineConfig %s already exists and was not generated for this pool", mc.Name))
+	}
+	_, updated, err := mcoResourceApply.ApplyMachineConfig(g.Client.MachineconfigurationV1(), mc)
+	if err != nil {
+		return false, err
+	}
+	return updated, g.SyncStatus(pool)
+}
+
+// Delete deletes the MachineConfig generated for the pool, if any, and
+// clears the condition of the pool.
+func (g *GeneratedConfigs) Delete(pool *mcfgv1.MachineConfigPool) error {
+	mc, err := g.Get(pool)
+	if err != nil {
+		return err
+	}
+	if mc != nil {
+		glog.Infof("Deleting MachineConfig %s as MachineConfigPool %s has no %s", mc.Name, pool.Name, g.Description)
+		err := g.Client.MachineconfigurationV1().MachineConfigs().Delete(context.TODO(), mc.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return g.SyncStatus(pool)
+}
+
+// SyncFailingStatus reports an invalid configuration in the condition of
+// the pool, leaving the previously generated MachineConfig in place.  The
+// error isn't returned as retrying won't help until the configuration is
+// changed.
+func (g *GeneratedConfigs) SyncFailingStatus(pool *mcfgv1.MachineConfigPool, err error) error {
+	glog.Errorf("Invalid %s of MachineConfigPool %s: %v", g.Description, pool.Name, err)
+	g.EventRecorder.Eventf(pool, corev1.EventTypeWarning, g.InvalidReason, "Invalid %s: %v", g.Description, err)
+	return g.syncCondition(pool, mcfgv1.NewMachineConfigPoolCondition(g.ConditionType, corev1.ConditionTrue, g.InvalidReason, err.Error()))
+}
+
+// SyncStatus clears the condition of the pool.  Pools which never reported
+// an error aren't updated.
+func (g *GeneratedConfigs) SyncStatus(pool *mcfgv1.MachineConfigPool) error {
+	if mcfgv1.GetMachineConfigPoolCondition(pool.Status, g.ConditionType) == nil {
+		return nil
+	}
+	return g.syncCondition(pool, mcfgv1.NewMachineConfigPoolCondition(g.ConditionType, corev1.ConditionFalse, "", ""))
+}
+
+// syncCondition sets the condition of the pool, also when only its message
+// changed, which SetMachineConfigPoolCondition ignores.
+func (g *GeneratedConfigs) syncCondition(pool *mcfgv1.MachineConfigPool, cond *mcfgv1.MachineConfigPoolCondition) error {
+	existing := mcfgv1.GetMachineConfigPoolCondition(pool.Status, cond.Type)
+	if existing != nil && existing.Status == cond.Status {
+		if existing.Reason == cond.Reason && existing.Message == cond.Message {
+			return nil
+		}
+		cond.LastTransitionTime = existing.LastTransitionTime
+	}
+	newPool := pool.DeepCopy()
+	mcfgv1.RemoveMachineConfigPoolCondition(&newPool.Status, cond.Type)
+	mcfgv1.SetMachineConfigPoolCondition(&newPool.Status, *cond)
+	_, err := g.Client.MachineconfigurationV1().MachineConfigPools().UpdateStatus(context.TODO(), newPool, metav1.UpdateOptions{})
+	return err
+}
+
+// NewGeneratedFile returns a file with the given contents for a generated
+// MachineConfig.
+func NewGeneratedFile(path, contents string) ign3types.File {
+	mode := 0644
+	overwrite := true
+	du := dataurl.New([]byte(contents), "text/plain")
+	du.Encoding = dataurl.EncodingASCII
+	source := du.String()
+	return ign3types.File{
+		Node: ign3types.Node{
+			Path:      path,
+			Overwrite: &overwrite,
+		},
+		FileEmbedded1: ign3types.FileEmbedded1{
+			Mode: &mode,
+			Contents: ign3types.Resource{
+				Source: &source,
+			},
+		},
+	}
+}
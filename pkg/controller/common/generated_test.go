@@ -0,0 +1,109 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestGeneratedConfigs(t *testing.T) {
+	kind := GeneratedConfigKind{
+		Suffix:        "storage",
+		Description:   "storage configuration",
+		ConditionType: mcfgv1.MachineConfigPoolStorageDegraded,
+		InvalidReason: "StorageConfigInvalid",
+	}
+	pool := helpers.NewPool("infra")
+	mc, err := kind.NewMachineConfig(pool, NewIgnConfig())
+	require.NoError(t, err)
+	assert.Equal(t, "99-infra-generated-storage", mc.Name)
+	assert.Equal(t, "infra", kind.OwningPool(mc))
+
+	f := helpers.NewFakeInformers(pool)
+	g := &GeneratedConfigs{
+		GeneratedConfigKind: kind,
+		Client:              f.Client,
+		MCLister:            f.Informers.Machineconfiguration().V1().MachineConfigs().Lister(),
+		EventRecorder:       &record.FakeRecorder{},
+	}
+	f.FillListers(t)
+	getPool := func() *mcfgv1.MachineConfigPool {
+		pool, err := f.Client.MachineconfigurationV1().MachineConfigPools().Get(context.TODO(), "infra", metav1.GetOptions{})
+		require.NoError(t, err)
+		return pool
+	}
+
+	// pools which never failed aren't updated
+	require.NoError(t, g.Delete(pool))
+	assert.Nil(t, mcfgv1.GetMachineConfigPoolCondition(getPool().Status, kind.ConditionType))
+
+	require.NoError(t, g.SyncFailingStatus(getPool(), fmt.Errorf("first")))
+	cond := mcfgv1.GetMachineConfigPoolCondition(getPool().Status, kind.ConditionType)
+	require.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "StorageConfigInvalid", cond.Reason)
+
+	// a new error of the same reason replaces the message
+	require.NoError(t, g.SyncFailingStatus(getPool(), fmt.Errorf("second")))
+	assert.Equal(t, "second", mcfgv1.GetMachineConfigPoolCondition(getPool().Status, kind.ConditionType).Message)
+
+	updated, err := g.Apply(getPool(), mc)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.True(t, mcfgv1.IsMachineConfigPoolConditionFalse(getPool().Status.Conditions, kind.ConditionType))
+}
+
+func TestGeneratedConfigsOwnership(t *testing.T) {
+	kind := GeneratedConfigKind{
+		Suffix:        "storage",
+		Description:   "storage configuration",
+		ConditionType: mcfgv1.MachineConfigPoolStorageDegraded,
+		InvalidReason: "StorageConfigInvalid",
+	}
+	pool := helpers.NewPool("infra")
+	mc, err := kind.NewMachineConfig(pool, NewIgnConfig())
+	require.NoError(t, err)
+	other := helpers.NewMachineConfig(mc.Name, map[string]string{mcfgv1.MachineConfigRoleLabelKey: "infra"}, "", nil)
+
+	newGeneratedConfigs := func(objects ...runtime.Object) (*GeneratedConfigs, *fake.Clientset) {
+		f := helpers.NewFakeInformers(objects...)
+		g := &GeneratedConfigs{
+			GeneratedConfigKind: kind,
+			Client:              f.Client,
+			MCLister:            f.Informers.Machineconfiguration().V1().MachineConfigs().Lister(),
+			EventRecorder:       &record.FakeRecorder{},
+		}
+		f.FillListers(t)
+		return g, f.Client
+	}
+
+	// a MachineConfig of the same name not generated for the pool is left alone
+	g, client := newGeneratedConfigs(pool, other)
+	updated, err := g.Apply(pool, mc)
+	require.NoError(t, err)
+	assert.False(t, updated)
+	got, err := client.MachineconfigurationV1().MachineConfigPools().Get(context.TODO(), "infra", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, mcfgv1.GetMachineConfigPoolCondition(got.Status, kind.ConditionType).Message, "already exists")
+	require.NoError(t, g.Delete(pool))
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), mc.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	// the MachineConfig generated for the pool is deleted
+	g, client = newGeneratedConfigs(pool, mc)
+	require.NoError(t, g.Delete(pool))
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), mc.Name, metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+}
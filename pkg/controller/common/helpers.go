@@ -92,12 +92,14 @@ func MergeMachineConfigs(configs []*mcfgv1.MachineConfig, osImageURL string) (*m
 	// sets the KernelType if specified in any of the MachineConfig
 	// Setting kerneType to realtime in any of MachineConfig takes priority
 	// also if any of the config has FIPS enabled, it'll be set
+	var kernelTypeFrom string
 	for _, cfg := range configs {
 		if cfg.Spec.FIPS {
 			fips = true
 		}
 		if cfg.Spec.KernelType == KernelTypeRealtime {
 			kernelType = cfg.Spec.KernelType
+			kernelTypeFrom = cfg.Name
 			break
 		} else if kernelType == KernelTypeDefault {
 			kernelType = cfg.Spec.KernelType
@@ -115,15 +117,17 @@ func MergeMachineConfigs(configs []*mcfgv1.MachineConfig, osImageURL string) (*m
 	}
 
 	extensions := []string{}
+	var kernelDevelFrom string
 	for _, cfg := range configs {
 		extensions = append(extensions, cfg.Spec.Extensions...)
+		if kernelDevelFrom == "" && InSlice("kernel-devel", cfg.Spec.Extensions) {
+			kernelDevelFrom = cfg.Name
+		}
 	}
 
 	// Ensure that kernel-devel extension is applied only with default kernel.
-	if kernelType != KernelTypeDefault {
-		if InSlice("kernel-devel", extensions) {
-			return nil, fmt.Errorf("installing kernel-devel extension is not supported with kernelType: %s", kernelType)
-		}
+	if kernelType != KernelTypeDefault && kernelDevelFrom != "" {
+		return nil, &MergeConflictError{Err: fmt.Errorf("installing kernel-devel extension is not supported with kernelType: %s: MachineConfig %s installs kernel-devel, MachineConfig %s sets kernelType %s", kernelType, kernelDevelFrom, kernelTypeFrom, kernelType)}
 	}
 
 	return &mcfgv1.MachineConfig{
@@ -424,7 +428,7 @@ func InSlice(elem string, slice []string) bool {
 
 // ValidateMachineConfig validates that given MachineConfig Spec is valid.
 func ValidateMachineConfig(cfg mcfgv1.MachineConfigSpec) error {
-	if !(cfg.KernelType == "" || cfg.KernelType == KernelTypeDefault || cfg.KernelType == KernelTypeRealtime) {
+	if cfg.KernelType != "" && !InSlice(cfg.KernelType, SupportedKernelTypes) {
 		return errors.Errorf("kernelType=%s is invalid", cfg.KernelType)
 	}
 
@@ -467,7 +471,7 @@ func IgnParseWrapper(rawIgn []byte) (interface{}, error) {
 
 				// If the error is still UnknownVersion it's not a 3.2/3.1/3.0 or 2.x config, thus unsupported
 				if errV2.Error() == ign2error.ErrUnknownVersion.Error() {
-					return ign3types.Config{}, errors.Errorf("parsing Ignition config failed: unknown version. Supported spec versions: 2.2, 3.0, 3.1, 3.2")
+					return ign3types.Config{}, errors.Errorf("parsing Ignition config failed: unknown version. Supported spec versions: %s", strings.Join(SupportedIgnitionVersions, ", "))
 				}
 				return ign3types.Config{}, errors.Errorf("parsing Ignition spec v2 failed with error: %v\nReport: %v", errV2, rptV2)
 			}
@@ -766,17 +770,14 @@ func CalculateConfigFileDiffs(oldIgnConfig, newIgnConfig *ign3types.Config) []st
 	return diffFileSet
 }
 
-// GetIgnitionFileDataByPath retrieves the file data for a specified path from a given ignition config
+// GetIgnitionFileDataByPath retrieves the file data for a specified path from a given ignition config,
+// decompressed if the rendered config compressed it
 func GetIgnitionFileDataByPath(config *ign3types.Config, path string) ([]byte, error) {
 	for _, f := range config.Storage.Files {
 		if path == f.Path {
 			// Convert whatever we have to the actual bytes so we can inspect them
 			if f.Contents.Source != nil {
-				contents, err := dataurl.DecodeString(*f.Contents.Source)
-				if err != nil {
-					return nil, err
-				}
-				return contents.Data, err
+				return DecodeIgnitionFileContents(f.Contents.Source, f.Contents.Compression)
 			}
 		}
 	}
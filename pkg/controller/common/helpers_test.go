@@ -11,6 +11,7 @@ import (
 	validate3 "github.com/coreos/ignition/v2/config/validate"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
@@ -339,6 +340,16 @@ func TestMergeMachineConfigs(t *testing.T) {
 	}
 	assert.Equal(t, *mergedMachineConfig, *expectedMachineConfig)
 
+	// kernel-devel can't be installed with the realtime kernel
+	machineConfigKernelType.Name = "99-realtime"
+	inMachineConfigs = []*mcfgv1.MachineConfig{
+		machineConfigKernelType,
+		{ObjectMeta: metav1.ObjectMeta{Name: "99-kernel-devel"}, Spec: mcfgv1.MachineConfigSpec{Extensions: []string{"kernel-devel"}}},
+	}
+	_, err = MergeMachineConfigs(inMachineConfigs, osImageURL)
+	require.Error(t, err)
+	assert.Equal(t, ErrorReasonMergeConflict, ErrorReason(err))
+	assert.Contains(t, err.Error(), "MachineConfig 99-kernel-devel installs kernel-devel, MachineConfig 99-realtime sets kernelType realtime")
 }
 
 func TestRemoveIgnDuplicateFilesAndUnits(t *testing.T) {
@@ -22,8 +22,65 @@ var (
 			Help: "Set to the unix timestamp in utc of the current certificate expiry date if a certificate rotation is pending in specified paused pool",
 		}, []string{"pool"})
 
+	// MachineConfigControllerNodeRebootDeferred reports the nodes whose update, and so reboot, into the target config of their pool is deferred
+	MachineConfigControllerNodeRebootDeferred = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "machine_config_controller_node_reboot_deferred",
+			Help: "Set to the unix timestamp in utc of the earliest time the deferred update of the node is expected to start, or 0 if it's deferred until the pool is unpaused or the cluster unfrozen",
+		}, []string{"pool", "node"})
+
+	// MachineConfigControllerPoolRebootsDeferred counts the nodes of a pool whose update is deferred, by reason
+	MachineConfigControllerPoolRebootsDeferred = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "machine_config_controller_pool_reboots_deferred",
+			Help: "Number of nodes in the pool whose update and reboot is deferred, by reason (Paused, ClusterFrozen or MaxUnavailable)",
+		}, []string{"pool", "reason"})
+
+	// MachineConfigControllerNodeRebootTimedOut reports the nodes which didn't come back within the reboot timeout of their pool
+	MachineConfigControllerNodeRebootTimedOut = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "machine_config_controller_node_reboot_timed_out",
+			Help: "Set to the unix timestamp in utc of the time the node went NotReady while updating, if it didn't come back within the reboot timeout of its pool",
+		}, []string{"pool", "node"})
+
+	// MachineConfigControllerBootstrapCredentialsExpiry reports when the token served to new nodes expires
+	MachineConfigControllerBootstrapCredentialsExpiry = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "machine_config_controller_bootstrap_credentials_expiry",
+			Help: "Set to the unix timestamp in utc of the expiry of the node-bootstrapper token the machine config server puts into the configs of new nodes",
+		})
+
+	// MachineConfigControllerTemplateRenderDuration reports how long rendering the template MachineConfigs of a role takes
+	MachineConfigControllerTemplateRenderDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "machine_config_controller_template_render_duration_seconds",
+			Help:    "Time from the start of a render of the templates until the last template MachineConfig of the role is rendered",
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 12),
+		}, []string{"role"})
+
+	// MachineConfigControllerTemplateMachineConfigs reports how many template MachineConfigs of a role the last render produced
+	MachineConfigControllerTemplateMachineConfigs = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "machine_config_controller_template_machineconfigs",
+			Help: "Number of MachineConfigs of the role rendered by the last successful render of the templates",
+		}, []string{"role"})
+
+	// MachineConfigControllerTemplateFailures counts the templates which failed to parse or execute
+	MachineConfigControllerTemplateFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "machine_config_controller_template_failures_total",
+			Help: "Number of times the template at the path failed to render, by stage (parse or execute)",
+		}, []string{"path", "stage"})
+
 	metricsList = []prometheus.Collector{
 		MachineConfigControllerPausedPoolKubeletCA,
+		MachineConfigControllerNodeRebootDeferred,
+		MachineConfigControllerPoolRebootsDeferred,
+		MachineConfigControllerNodeRebootTimedOut,
+		MachineConfigControllerBootstrapCredentialsExpiry,
+		MachineConfigControllerTemplateRenderDuration,
+		MachineConfigControllerTemplateMachineConfigs,
+		MachineConfigControllerTemplateFailures,
 	}
 )
 
@@ -0,0 +1,62 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+// RenderProvenance records the exact inputs a rendered machineconfig was
+// rendered from, so that it can be reproduced from them.
+type RenderProvenance struct {
+	// MachineConfigs are the machineconfigs of the pool merged into the
+	// rendered machineconfig, in merge order.
+	MachineConfigs []ObjectVersion `json:"machineConfigs"`
+	// ControllerConfig is the controllerconfig supplying the OS image and
+	// the rendering options.
+	ControllerConfig ObjectVersion `json:"controllerConfig"`
+}
+
+// ObjectVersion identifies a version of an object.
+type ObjectVersion struct {
+	Name            string `json:"name"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	Generation      int64  `json:"generation,omitempty"`
+}
+
+// NewRenderProvenance returns the provenance of a machineconfig rendered from
+// the configs and the controllerconfig.
+func NewRenderProvenance(configs []*mcfgv1.MachineConfig, cconfig *mcfgv1.ControllerConfig) *RenderProvenance {
+	p := &RenderProvenance{
+		MachineConfigs: make([]ObjectVersion, 0, len(configs)),
+		ControllerConfig: ObjectVersion{
+			Name:            cconfig.Name,
+			ResourceVersion: cconfig.ResourceVersion,
+			Generation:      cconfig.Generation,
+		},
+	}
+	for _, config := range configs {
+		p.MachineConfigs = append(p.MachineConfigs, ObjectVersion{
+			Name:            config.Name,
+			ResourceVersion: config.ResourceVersion,
+			Generation:      config.Generation,
+		})
+	}
+	return p
+}
+
+// RenderProvenanceFromAnnotations returns the provenance recorded in the
+// annotations of a rendered machineconfig, or nil if there's none, as for
+// the configs rendered by older controllers.
+func RenderProvenanceFromAnnotations(annotations map[string]string) (*RenderProvenance, error) {
+	raw, ok := annotations[RenderProvenanceAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+	p := &RenderProvenance{}
+	if err := json.Unmarshal([]byte(raw), p); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %v", RenderProvenanceAnnotationKey, err)
+	}
+	return p, nil
+}
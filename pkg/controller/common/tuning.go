@@ -0,0 +1,97 @@
+package common
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// Bounds of the ControllerTuning knobs.  They keep a mistyped value from
+// either hammering the API server or stalling the controllers.
+const (
+	MinResyncPeriod   = 5 * time.Minute
+	MaxResyncPeriod   = 12 * time.Hour
+	MinQueueBaseDelay = time.Millisecond
+	MaxQueueBaseDelay = 10 * time.Second
+	MinQueueMaxDelay  = time.Second
+	MaxQueueMaxDelay  = time.Hour
+	MinQueueQPS       = 1
+	MaxQueueQPS       = 100
+	MinQueueBurst     = 1
+	MaxQueueBurst     = 1000
+)
+
+// ControllerTuning tunes the informer resyncs and the workqueue rate
+// limiting of the controllers.
+type ControllerTuning struct {
+	// ResyncPeriod is the minimum period of the informer resyncs, each
+	// informer resyncs at a random period between it and twice it.
+	ResyncPeriod time.Duration
+	// QueueBaseDelay and QueueMaxDelay bound the exponential backoff of the
+	// retries of an object.
+	QueueBaseDelay time.Duration
+	QueueMaxDelay  time.Duration
+	// QueueQPS and QueueBurst limit the rate at which a controller processes
+	// objects, across all objects.
+	QueueQPS   int
+	QueueBurst int
+}
+
+// DefaultControllerTuning is the tuning of the controllers unless
+// SetControllerTuning is called, matching workqueue.DefaultControllerRateLimiter.
+var DefaultControllerTuning = ControllerTuning{
+	ResyncPeriod:   20 * time.Minute,
+	QueueBaseDelay: 5 * time.Millisecond,
+	QueueMaxDelay:  1000 * time.Second,
+	QueueQPS:       10,
+	QueueBurst:     100,
+}
+
+var controllerTuning = DefaultControllerTuning
+
+// Validate checks the knobs are within their bounds.
+func (t ControllerTuning) Validate() error {
+	if t.ResyncPeriod < MinResyncPeriod || t.ResyncPeriod > MaxResyncPeriod {
+		return fmt.Errorf("resync period %v must be between %v and %v", t.ResyncPeriod, MinResyncPeriod, MaxResyncPeriod)
+	}
+	if t.QueueBaseDelay < MinQueueBaseDelay || t.QueueBaseDelay > MaxQueueBaseDelay {
+		return fmt.Errorf("queue base delay %v must be between %v and %v", t.QueueBaseDelay, MinQueueBaseDelay, MaxQueueBaseDelay)
+	}
+	if t.QueueMaxDelay < MinQueueMaxDelay || t.QueueMaxDelay > MaxQueueMaxDelay {
+		return fmt.Errorf("queue max delay %v must be between %v and %v", t.QueueMaxDelay, MinQueueMaxDelay, MaxQueueMaxDelay)
+	}
+	if t.QueueMaxDelay < t.QueueBaseDelay {
+		return fmt.Errorf("queue max delay %v must not be less than the base delay %v", t.QueueMaxDelay, t.QueueBaseDelay)
+	}
+	if t.QueueQPS < MinQueueQPS || t.QueueQPS > MaxQueueQPS {
+		return fmt.Errorf("queue QPS %d must be between %d and %d", t.QueueQPS, MinQueueQPS, MaxQueueQPS)
+	}
+	if t.QueueBurst < MinQueueBurst || t.QueueBurst > MaxQueueBurst {
+		return fmt.Errorf("queue burst %d must be between %d and %d", t.QueueBurst, MinQueueBurst, MaxQueueBurst)
+	}
+	if t.QueueBurst < t.QueueQPS {
+		return fmt.Errorf("queue burst %d must not be less than the QPS %d", t.QueueBurst, t.QueueQPS)
+	}
+	return nil
+}
+
+// SetControllerTuning sets the tuning of the controllers and informers
+// created afterwards.
+func SetControllerTuning(t ControllerTuning) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	controllerTuning = t
+	return nil
+}
+
+// ControllerRateLimiter returns the rate limiter of the workqueue of a
+// controller: workqueue.DefaultControllerRateLimiter with the tuned values.
+func ControllerRateLimiter() workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(controllerTuning.QueueBaseDelay, controllerTuning.QueueMaxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(controllerTuning.QueueQPS), controllerTuning.QueueBurst)},
+	)
+}
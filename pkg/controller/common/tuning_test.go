@@ -0,0 +1,50 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControllerTuningValidate(t *testing.T) {
+	assert.NoError(t, DefaultControllerTuning.Validate())
+
+	for name, mutate := range map[string]func(*ControllerTuning){
+		"resync too short":     func(t *ControllerTuning) { t.ResyncPeriod = time.Minute },
+		"resync too long":      func(t *ControllerTuning) { t.ResyncPeriod = 24 * time.Hour },
+		"base delay too short": func(t *ControllerTuning) { t.QueueBaseDelay = 0 },
+		"max delay too long":   func(t *ControllerTuning) { t.QueueMaxDelay = 2 * time.Hour },
+		"max below base":       func(t *ControllerTuning) { t.QueueBaseDelay, t.QueueMaxDelay = 10*time.Second, 5*time.Second },
+		"no QPS":               func(t *ControllerTuning) { t.QueueQPS = 0 },
+		"burst too large":      func(t *ControllerTuning) { t.QueueBurst = 5000 },
+		"burst below QPS":      func(t *ControllerTuning) { t.QueueQPS, t.QueueBurst = 50, 20 },
+	} {
+		tuning := DefaultControllerTuning
+		mutate(&tuning)
+		assert.Error(t, tuning.Validate(), name)
+		assert.Error(t, SetControllerTuning(tuning), name)
+	}
+	assert.Equal(t, DefaultControllerTuning, controllerTuning)
+}
+
+func TestControllerRateLimiter(t *testing.T) {
+	defer func() { controllerTuning = DefaultControllerTuning }()
+
+	tuning := DefaultControllerTuning
+	tuning.QueueBaseDelay = time.Second
+	tuning.QueueMaxDelay = 3 * time.Second
+	tuning.ResyncPeriod = time.Hour
+	require.NoError(t, SetControllerTuning(tuning))
+
+	limiter := ControllerRateLimiter()
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		assert.Equal(t, want, limiter.When("item"))
+	}
+	limiter.Forget("item")
+	assert.Equal(t, time.Second, limiter.When("item"))
+
+	period := resyncPeriod()()
+	assert.True(t, period >= time.Hour && period <= 2*time.Hour, period)
+}
@@ -53,6 +53,11 @@ const (
 	maxRetries = 15
 
 	builtInLabelKey = "machineconfiguration.openshift.io/mco-built-in"
+
+	// imageConfigQueueKey re-renders all the registries config for a change of the image config
+	imageConfigQueueKey = "openshift-config"
+	// icspQueueKey only re-renders the registries.conf for a change of the ICSPs
+	icspQueueKey = "openshift-config/icsp"
 )
 
 var (
@@ -71,12 +76,14 @@ type Controller struct {
 	templatesDir string
 
 	client        mcfgclientset.Interface
+	kubeClient    clientset.Interface
 	configClient  configclientset.Interface
 	eventRecorder record.EventRecorder
 
 	syncHandler                   func(mcp string) error
 	syncImgHandler                func(mcp string) error
 	enqueueContainerRuntimeConfig func(*mcfgv1.ContainerRuntimeConfig)
+	checkMirror                   mirrorCheckFunc
 
 	ccLister       mcfglistersv1.ControllerConfigLister
 	ccListerSynced cache.InformerSynced
@@ -120,10 +127,11 @@ func New(
 	ctrl := &Controller{
 		templatesDir:  templatesDir,
 		client:        mcfgClient,
+		kubeClient:    kubeClient,
 		configClient:  configClient,
 		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-containerruntimeconfigcontroller"}),
-		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-containerruntimeconfigcontroller"),
-		imgQueue:      workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		queue:         workqueue.NewNamedRateLimitingQueue(ctrlcommon.ControllerRateLimiter(), "machineconfigcontroller-containerruntimeconfigcontroller"),
+		imgQueue:      workqueue.NewRateLimitingQueue(ctrlcommon.ControllerRateLimiter()),
 	}
 
 	mcrInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -147,6 +155,7 @@ func New(
 	ctrl.syncHandler = ctrl.syncContainerRuntimeConfig
 	ctrl.syncImgHandler = ctrl.syncImageConfig
 	ctrl.enqueueContainerRuntimeConfig = ctrl.enqueue
+	ctrl.checkMirror = checkMirror
 
 	ctrl.mcpLister = mcpInformer.Lister()
 	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
@@ -204,27 +213,33 @@ func ctrConfigTriggerObjectChange(old, new *mcfgv1.ContainerRuntimeConfig) bool
 }
 
 func (ctrl *Controller) imageConfAdded(obj interface{}) {
-	ctrl.imgQueue.Add("openshift-config")
+	ctrl.imgQueue.Add(imageConfigQueueKey)
 }
 
 func (ctrl *Controller) imageConfUpdated(oldObj, newObj interface{}) {
-	ctrl.imgQueue.Add("openshift-config")
+	ctrl.imgQueue.Add(imageConfigQueueKey)
 }
 
 func (ctrl *Controller) imageConfDeleted(obj interface{}) {
-	ctrl.imgQueue.Add("openshift-config")
+	ctrl.imgQueue.Add(imageConfigQueueKey)
 }
 
 func (ctrl *Controller) icspConfAdded(obj interface{}) {
-	ctrl.imgQueue.Add("openshift-config")
+	ctrl.imgQueue.Add(icspQueueKey)
 }
 
 func (ctrl *Controller) icspConfUpdated(oldObj, newObj interface{}) {
-	ctrl.imgQueue.Add("openshift-config")
+	oldICSP := oldObj.(*apioperatorsv1alpha1.ImageContentSourcePolicy)
+	newICSP := newObj.(*apioperatorsv1alpha1.ImageContentSourcePolicy)
+	// resyncs and metadata updates don't change the mirrors
+	if reflect.DeepEqual(oldICSP.Spec, newICSP.Spec) {
+		return
+	}
+	ctrl.imgQueue.Add(icspQueueKey)
 }
 
 func (ctrl *Controller) icspConfDeleted(obj interface{}) {
-	ctrl.imgQueue.Add("openshift-config")
+	ctrl.imgQueue.Add(icspQueueKey)
 }
 
 func (ctrl *Controller) updateContainerRuntimeConfig(oldObj, newObj interface{}) {
@@ -545,9 +560,9 @@ func (ctrl *Controller) syncContainerRuntimeConfig(key string) error {
 			}
 		}
 		// Generate the original ContainerRuntimeConfig
-		originalStorageIgn, _, _, err := generateOriginalContainerRuntimeConfigs(ctrl.templatesDir, controllerConfig, role)
+		originalStorageIgn, _, _, err := generateOriginalContainerRuntimeConfigs(ctrl.templatesDir, controllerConfig, mtmpl.TemplateRole(pool))
 		if err != nil {
-			return ctrl.syncStatusOnly(cfg, err, "could not generate origin ContainerRuntime Configs: %v", err)
+			return ctrl.syncStatusOnly(cfg, &ctrlcommon.RenderError{Err: err}, "could not generate origin ContainerRuntime Configs: %v", err)
 		}
 
 		var configFileList []generatedConfigFile
@@ -556,7 +571,7 @@ func (ctrl *Controller) syncContainerRuntimeConfig(key string) error {
 			storageTOML, err := mergeConfigChanges(originalStorageIgn, cfg, updateStorageConfig)
 			if err != nil {
 				glog.V(2).Infoln(cfg, err, "error merging user changes to storage.conf: %v", err)
-				ctrl.syncStatusOnly(cfg, err)
+				ctrl.syncStatusOnly(cfg, &ctrlcommon.RenderError{Err: err})
 			} else {
 				configFileList = append(configFileList, generatedConfigFile{filePath: storageConfigPath, data: storageTOML})
 				ctrl.syncStatusOnly(cfg, nil)
@@ -564,10 +579,14 @@ func (ctrl *Controller) syncContainerRuntimeConfig(key string) error {
 		}
 
 		// Create the cri-o drop-in files
-		if ctrcfg.LogLevel != "" || ctrcfg.PidsLimit != nil || !ctrcfg.LogSizeMax.IsZero() {
+		if ctrcfg.LogLevel != "" || ctrcfg.PidsLimit != nil || !ctrcfg.LogSizeMax.IsZero() || ctrcfg.ConmonCgroup != "" || len(ctrcfg.RuntimeHandlers) > 0 {
 			crioFileConfigs := createCRIODropinFiles(cfg)
 			configFileList = append(configFileList, crioFileConfigs...)
 		}
+		// Create the registries.conf.d drop-in file
+		if len(ctrcfg.ShortNameAliases) > 0 || len(ctrcfg.UnqualifiedSearchRegistries) > 0 {
+			configFileList = append(configFileList, createRegistriesDropinFile(cfg)...)
+		}
 
 		if isNotFound {
 			tempIgnCfg := ctrlcommon.NewIgnConfig()
@@ -688,6 +707,9 @@ func (ctrl *Controller) syncImageConfig(key string) error {
 	// Deep-copy otherwise we are mutating our cache.
 	imgcfg = imgcfg.DeepCopy()
 
+	// ICSPs only feed into registries.conf, leave the rest of the config alone
+	registriesOnly := key == icspQueueKey
+
 	// Fetch the ClusterVersionConfig needed to get the registry being used by the payload
 	// so that we can avoid adding that registry to blocked registries in /etc/containers/registries.conf
 	clusterVersionCfg, err := ctrl.clusterVersionLister.Get("version")
@@ -733,9 +755,13 @@ func (ctrl *Controller) syncImageConfig(key string) error {
 	if err != nil {
 		return err
 	}
+	// New mirrors are checked once per sync, for all the pools
+	verifiedMirrors := map[mirrorCheck]error{}
+	var heldBackMirrors error
 	for _, pool := range mcpPools {
 		// To keep track of whether we "actually" got an updated image config
 		applied := true
+		var mirrorsErr error
 		role := pool.Name
 		// Get MachineConfig
 		managedKey, err := getManagedKeyReg(pool, ctrl.client)
@@ -743,9 +769,39 @@ func (ctrl *Controller) syncImageConfig(key string) error {
 			return err
 		}
 		if err := retry.RetryOnConflict(updateBackoff, func() error {
-			registriesIgn, err := registriesConfigIgnition(ctrl.templatesDir, controllerConfig, role,
-				imgcfg.Spec.RegistrySources.InsecureRegistries, blockedRegs, imgcfg.Spec.RegistrySources.AllowedRegistries,
-				imgcfg.Spec.RegistrySources.ContainerRuntimeSearchRegistries, icspRules)
+			mc, err := ctrl.client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), managedKey, metav1.GetOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("could not find MachineConfig: %v", err)
+			}
+			isNotFound := errors.IsNotFound(err)
+			// Hold back the new mirrors which aren't healthy, rather than
+			// failing image pulls on every node of the pool, and roll out the
+			// rest of the registries config
+			var currentRegistriesConf *ign3types.File
+			if !isNotFound {
+				// a MachineConfig without registries.conf has no mirrors yet
+				currentRegistriesConf, _ = findRegistriesConfig(mc)
+			}
+			checks, err := newMirrorChecks(currentRegistriesConf, icspRules, clusterVersionCfg.Status.Desired.Image)
+			if err != nil {
+				return err
+			}
+			unhealthy, err := ctrl.verifyMirrors(controllerConfig, checks, imgcfg.Spec.RegistrySources.InsecureRegistries, verifiedMirrors)
+			if err != nil {
+				return err
+			}
+			mirrorsErr = unhealthyMirrorsError(unhealthy, verifiedMirrors)
+			healthyICSPRules := withoutMirrors(icspRules, unhealthy)
+
+			var registriesIgn *ign3types.Config
+			if registriesOnly && !isNotFound {
+				registriesIgn, err = registriesConfOnlyIgnition(ctrl.templatesDir, controllerConfig, mtmpl.TemplateRole(pool), mc.Spec.Config.Raw,
+					imgcfg.Spec.RegistrySources.InsecureRegistries, blockedRegs, healthyICSPRules)
+			} else {
+				registriesIgn, err = registriesConfigIgnition(ctrl.templatesDir, controllerConfig, mtmpl.TemplateRole(pool),
+					imgcfg.Spec.RegistrySources.InsecureRegistries, blockedRegs, imgcfg.Spec.RegistrySources.AllowedRegistries,
+					imgcfg.Spec.RegistrySources.ContainerRuntimeSearchRegistries, healthyICSPRules)
+			}
 			if err != nil {
 				return err
 			}
@@ -753,11 +809,6 @@ func (ctrl *Controller) syncImageConfig(key string) error {
 			if err != nil {
 				return fmt.Errorf("could not encode registries Ignition config: %v", err)
 			}
-			mc, err := ctrl.client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), managedKey, metav1.GetOptions{})
-			if err != nil && !errors.IsNotFound(err) {
-				return fmt.Errorf("could not find MachineConfig: %v", err)
-			}
-			isNotFound := errors.IsNotFound(err)
 			if !isNotFound && equality.Semantic.DeepEqual(rawRegistriesIgn, mc.Spec.Config.Raw) {
 				// if the configuration for the registries is equal, we still need to compare
 				// the generated controller version because during an upgrade we need a new one
@@ -797,12 +848,17 @@ func (ctrl *Controller) syncImageConfig(key string) error {
 		}); err != nil {
 			return fmt.Errorf("could not Create/Update MachineConfig: %v", err)
 		}
-		if applied {
+		if mirrorsErr != nil {
+			heldBackMirrors = mirrorsErr
+		}
+		if applied && registriesOnly {
+			glog.Infof("Applied ImageContentSourcePolicies on MachineConfigPool %v", pool.Name)
+		} else if applied {
 			glog.Infof("Applied ImageConfig cluster on MachineConfigPool %v", pool.Name)
 		}
 	}
 
-	return nil
+	return ctrl.syncImageMirrorsStatus(mcpPools, heldBackMirrors)
 }
 
 func registriesConfigIgnition(templateDir string, controllerConfig *mcfgv1.ControllerConfig, role string,
@@ -819,18 +875,9 @@ func registriesConfigIgnition(templateDir string, controllerConfig *mcfgv1.Contr
 		return nil, fmt.Errorf("could not generate origin ContainerRuntime Configs: %v", err)
 	}
 
-	if insecureRegs != nil || blockedRegs != nil || len(icspRules) != 0 {
-		if originalRegistriesIgn.Contents.Source == nil {
-			return nil, fmt.Errorf("original registries config is empty")
-		}
-		contents, err := ctrlcommon.DecodeIgnitionFileContents(originalRegistriesIgn.Contents.Source, originalRegistriesIgn.Contents.Compression)
-		if err != nil {
-			return nil, fmt.Errorf("could not decode original registries config: %v", err)
-		}
-		registriesTOML, err = updateRegistriesConfig(contents, insecureRegs, blockedRegs, icspRules)
-		if err != nil {
-			return nil, fmt.Errorf("could not update registries config with new changes: %v", err)
-		}
+	registriesTOML, err = renderRegistriesConf(originalRegistriesIgn, insecureRegs, blockedRegs, icspRules)
+	if err != nil {
+		return nil, err
 	}
 	if blockedRegs != nil || allowedRegs != nil {
 		if originalPolicyIgn.Contents.Source == nil {
@@ -857,6 +904,61 @@ func registriesConfigIgnition(templateDir string, controllerConfig *mcfgv1.Contr
 	return &registriesIgn, nil
 }
 
+// renderRegistriesConf returns the registries.conf for the registries of the
+// image config and the ICSPs, or nil to keep the original one.
+func renderRegistriesConf(originalRegistriesIgn *ign3types.File, insecureRegs, blockedRegs []string, icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy) ([]byte, error) {
+	if insecureRegs == nil && blockedRegs == nil && len(icspRules) == 0 {
+		return nil, nil
+	}
+	if originalRegistriesIgn.Contents.Source == nil {
+		return nil, fmt.Errorf("original registries config is empty")
+	}
+	contents, err := ctrlcommon.DecodeIgnitionFileContents(originalRegistriesIgn.Contents.Source, originalRegistriesIgn.Contents.Compression)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode original registries config: %v", err)
+	}
+	registriesTOML, err := updateRegistriesConfig(contents, insecureRegs, blockedRegs, icspRules)
+	if err != nil {
+		return nil, fmt.Errorf("could not update registries config with new changes: %v", err)
+	}
+	return registriesTOML, nil
+}
+
+// registriesConfOnlyIgnition re-renders only the registries.conf of the
+// current Ignition config of a registries MachineConfig, the only file the
+// ICSPs feed into.  policy.json and the search registries drop-in are left as
+// they are, so an ICSP change only ever touches registries.conf, which the
+// daemon applies by reloading crio.
+func registriesConfOnlyIgnition(templateDir string, controllerConfig *mcfgv1.ControllerConfig, role string, current []byte,
+	insecureRegs, blockedRegs []string, icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy) (*ign3types.Config, error) {
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(current)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the current registries Ignition config: %v", err)
+	}
+	_, originalRegistriesIgn, _, err := generateOriginalContainerRuntimeConfigs(templateDir, controllerConfig, role)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate origin ContainerRuntime Configs: %v", err)
+	}
+	registriesTOML, err := renderRegistriesConf(originalRegistriesIgn, insecureRegs, blockedRegs, icspRules)
+	if err != nil {
+		return nil, err
+	}
+
+	var registriesFile []ign3types.File
+	if registriesTOML != nil {
+		registriesFile = createNewIgnition([]generatedConfigFile{{filePath: registriesConfigPath, data: registriesTOML}}).Storage.Files
+	}
+	// keep the order of registriesConfigIgnition, where registries.conf comes first
+	files := registriesFile
+	for _, f := range ignCfg.Storage.Files {
+		if f.Path != registriesConfigPath {
+			files = append(files, f)
+		}
+	}
+	ignCfg.Storage.Files = files
+	return &ignCfg, nil
+}
+
 // RunImageBootstrap generates MachineConfig objects for mcpPools that would have been generated by syncImageConfig,
 // except that mcfgv1.Image is not available.
 func RunImageBootstrap(templateDir string, controllerConfig *mcfgv1.ControllerConfig, mcpPools []*mcfgv1.MachineConfigPool, icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy, imgCfg *apicfgv1.Image) ([]*mcfgv1.MachineConfig, error) {
@@ -888,7 +990,7 @@ func RunImageBootstrap(templateDir string, controllerConfig *mcfgv1.ControllerCo
 		if err != nil {
 			return nil, err
 		}
-		registriesIgn, err := registriesConfigIgnition(templateDir, controllerConfig, role,
+		registriesIgn, err := registriesConfigIgnition(templateDir, controllerConfig, mtmpl.TemplateRole(pool),
 			insecureRegs, blockedRegs, allowedRegs, searchRegs, icspRules)
 		if err != nil {
 			return nil, err
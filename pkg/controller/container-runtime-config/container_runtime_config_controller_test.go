@@ -9,6 +9,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/resource"
 
+	"github.com/clarketm/json"
 	"github.com/golang/glog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -69,6 +70,7 @@ type fixture struct {
 
 	actions               []core.Action
 	skipActionsValidation bool
+	checkMirror           mirrorCheckFunc
 
 	objects         []runtime.Object
 	imgObjects      []runtime.Object
@@ -79,6 +81,7 @@ func newFixture(t *testing.T) *fixture {
 	f := &fixture{}
 	f.t = t
 	f.objects = []runtime.Object{}
+	f.checkMirror = healthyMirror
 	return f
 }
 
@@ -199,6 +202,7 @@ func (f *fixture) newController() *Controller {
 	c.icspListerSynced = alwaysReady
 	c.clusterVersionListerSynced = alwaysReady
 	c.eventRecorder = &record.FakeRecorder{}
+	c.checkMirror = f.checkMirror
 
 	stopCh := make(chan struct{})
 	defer close(stopCh)
@@ -786,6 +790,77 @@ func TestICSPUpdate(t *testing.T) {
 	}
 }
 
+// TestRegistriesConfOnlyIgnition ensures an ICSP change only re-renders registries.conf.
+func TestRegistriesConfOnlyIgnition(t *testing.T) {
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName, apicfgv1.AWSPlatformType)
+	icsp := newICSP("built-in", []apioperatorsv1alpha1.RepositoryDigestMirrors{
+		{Source: "built-in-source.example.com", Mirrors: []string{"built-in-mirror.example.com"}},
+	})
+	icspUpdate := icsp.DeepCopy()
+	icspUpdate.Spec.RepositoryDigestMirrors[0].Mirrors = append(icspUpdate.Spec.RepositoryDigestMirrors[0].Mirrors, "local-mirror.local")
+	insecureRegs := []string{"blah.io"}
+	searchRegs := []string{"search-reg.io"}
+
+	current, err := registriesConfigIgnition(templateDir, cc, "worker", insecureRegs, nil, []string{"allow.io"}, searchRegs, []*apioperatorsv1alpha1.ImageContentSourcePolicy{icsp})
+	require.NoError(t, err)
+	rawCurrent, err := json.Marshal(current)
+	require.NoError(t, err)
+
+	// same result as rendering everything again
+	got, err := registriesConfOnlyIgnition(templateDir, cc, "worker", rawCurrent, insecureRegs, nil, []*apioperatorsv1alpha1.ImageContentSourcePolicy{icspUpdate})
+	require.NoError(t, err)
+	want, err := registriesConfigIgnition(templateDir, cc, "worker", insecureRegs, nil, []string{"allow.io"}, searchRegs, []*apioperatorsv1alpha1.ImageContentSourcePolicy{icspUpdate})
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	// policy.json and the search registries drop-in are left alone even if their inputs changed
+	got, err = registriesConfOnlyIgnition(templateDir, cc, "worker", rawCurrent, insecureRegs, nil, []*apioperatorsv1alpha1.ImageContentSourcePolicy{icspUpdate})
+	require.NoError(t, err)
+	full, err := registriesConfigIgnition(templateDir, cc, "worker", insecureRegs, nil, nil, nil, []*apioperatorsv1alpha1.ImageContentSourcePolicy{icspUpdate})
+	require.NoError(t, err)
+	assert.Equal(t, len(current.Storage.Files), len(got.Storage.Files))
+	assert.Less(t, len(full.Storage.Files), len(got.Storage.Files))
+	for i, f := range got.Storage.Files {
+		if f.Path == registriesConfigPath {
+			assert.Equal(t, want.Storage.Files[i], f)
+		} else {
+			assert.Equal(t, current.Storage.Files[i], f)
+		}
+	}
+
+	// without insecure registries or ICSPs the original registries.conf is kept
+	got, err = registriesConfOnlyIgnition(templateDir, cc, "worker", rawCurrent, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, len(current.Storage.Files)-1, len(got.Storage.Files))
+	for _, f := range got.Storage.Files {
+		assert.NotEqual(t, registriesConfigPath, f.Path)
+	}
+}
+
+// TestICSPEvents ensures only changes of the mirrors of an ICSP queue a re-render, and only of registries.conf.
+func TestICSPEvents(t *testing.T) {
+	f := newFixture(t)
+	c := f.newController()
+
+	icsp := newICSP("built-in", []apioperatorsv1alpha1.RepositoryDigestMirrors{
+		{Source: "built-in-source.example.com", Mirrors: []string{"built-in-mirror.example.com"}},
+	})
+	resynced := icsp.DeepCopy()
+	resynced.ResourceVersion = "2"
+	resynced.Annotations = map[string]string{"foo": "bar"}
+	c.icspConfUpdated(icsp, resynced)
+	assert.Equal(t, 0, c.imgQueue.Len())
+
+	updated := icsp.DeepCopy()
+	updated.Spec.RepositoryDigestMirrors[0].Mirrors = append(updated.Spec.RepositoryDigestMirrors[0].Mirrors, "local-mirror.local")
+	c.icspConfUpdated(icsp, updated)
+	c.icspConfAdded(icsp)
+	c.icspConfDeleted(icsp)
+	require.Equal(t, 1, c.imgQueue.Len())
+	key, _ := c.imgQueue.Get()
+	assert.Equal(t, icspQueueKey, key)
+}
+
 func TestRunImageBootstrap(t *testing.T) {
 	for _, platform := range []apicfgv1.PlatformType{apicfgv1.AWSPlatformType, apicfgv1.NonePlatformType, "unrecognized"} {
 		t.Run(string(platform), func(t *testing.T) {
@@ -913,6 +988,90 @@ func TestContainerRuntimeConfigOptions(t *testing.T) {
 				LogLevel: "invalid",
 			},
 		},
+		{
+			name: "invalid conmon cgroup path",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				ConmonCgroup: "/sys/fs/cgroup/conmon",
+			},
+		},
+		{
+			name: "invalid conmon cgroup without slice name",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				ConmonCgroup: ".slice",
+			},
+		},
+		{
+			name: "invalid short name with registry",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				ShortNameAliases: []mcfgv1.ShortNameAlias{{Name: "quay.io/ubi8", Image: "registry.example.com/ubi8/ubi"}},
+			},
+		},
+		{
+			name: "invalid short name alias to unqualified image",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				ShortNameAliases: []mcfgv1.ShortNameAlias{{Name: "ubi8", Image: "ubi8/ubi"}},
+			},
+		},
+		{
+			name: "invalid short name alias to tagged image",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				ShortNameAliases: []mcfgv1.ShortNameAlias{{Name: "ubi8", Image: "registry.example.com/ubi8/ubi:latest"}},
+			},
+		},
+		{
+			name: "invalid duplicate short name",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				ShortNameAliases: []mcfgv1.ShortNameAlias{
+					{Name: "ubi8", Image: "registry.example.com/ubi8/ubi"},
+					{Name: "ubi8", Image: "mirror.example.com:5000/ubi8/ubi"},
+				},
+			},
+		},
+		{
+			name: "invalid search registry with repository",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				UnqualifiedSearchRegistries: []string{"registry.example.com/ubi8"},
+			},
+		},
+		{
+			name: "invalid runtime handler name",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				RuntimeHandlers: []mcfgv1.ContainerRuntimeHandler{{Name: "crun_wasm", RuntimePath: "/usr/bin/crun"}},
+			},
+		},
+		{
+			name: "invalid runtime handler overriding runc",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				RuntimeHandlers: []mcfgv1.ContainerRuntimeHandler{{Name: "runc", RuntimePath: "/usr/local/bin/runc"}},
+			},
+		},
+		{
+			name: "invalid relative runtime path",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				RuntimeHandlers: []mcfgv1.ContainerRuntimeHandler{{Name: "crun-wasm", RuntimePath: "crun"}},
+			},
+		},
+		{
+			name: "invalid runtime type",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				RuntimeHandlers: []mcfgv1.ContainerRuntimeHandler{{Name: "crun-wasm", RuntimePath: "/usr/bin/crun", RuntimeType: "wasm"}},
+			},
+		},
+		{
+			name: "invalid allowed annotation",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				RuntimeHandlers: []mcfgv1.ContainerRuntimeHandler{{Name: "crun-wasm", RuntimePath: "/usr/bin/crun", AllowedAnnotations: []string{"module.wasm.image/variant/"}}},
+			},
+		},
+		{
+			name: "invalid duplicate runtime handler",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				RuntimeHandlers: []mcfgv1.ContainerRuntimeHandler{
+					{Name: "crun-wasm", RuntimePath: "/usr/bin/crun"},
+					{Name: "crun-wasm", RuntimePath: "/usr/local/bin/crun"},
+				},
+			},
+		},
 	}
 
 	successTests := []struct {
@@ -943,6 +1102,37 @@ func TestContainerRuntimeConfigOptions(t *testing.T) {
 				LogLevel: "debug",
 			},
 		},
+		{
+			name: "valid conmon cgroup pod",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				ConmonCgroup: "pod",
+			},
+		},
+		{
+			name: "valid conmon cgroup slice",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				ConmonCgroup: "system.slice",
+			},
+		},
+		{
+			name: "valid short name aliases and search registries",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				ShortNameAliases: []mcfgv1.ShortNameAlias{
+					{Name: "ubi8", Image: "registry.example.com/ubi8/ubi"},
+					{Name: "tools/busybox", Image: "mirror.example.com:5000/library/busybox"},
+				},
+				UnqualifiedSearchRegistries: []string{"registry.example.com", "mirror.example.com:5000"},
+			},
+		},
+		{
+			name: "valid runtime handlers",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				RuntimeHandlers: []mcfgv1.ContainerRuntimeHandler{
+					{Name: "crun-wasm", RuntimePath: "/usr/bin/crun", AllowedAnnotations: []string{"module.wasm.image/variant"}},
+					{Name: "kata", RuntimePath: "/usr/bin/containerd-shim-kata-v2", RuntimeType: "vm", RuntimeRoot: "/run/vc"},
+				},
+			},
+		},
 	}
 
 	// Failure Tests
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
@@ -24,6 +25,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
@@ -39,11 +41,29 @@ const (
 	policyConfigPath        = "/etc/containers/policy.json"
 	// CRIODropInFilePathLogLevel is the path at which changes to the crio config for log-level
 	// will be dropped in this is exported so that we can use it in the e2e-tests
-	CRIODropInFilePathLogLevel   = "/etc/crio/crio.conf.d/01-ctrcfg-logLevel"
-	crioDropInFilePathPidsLimit  = "/etc/crio/crio.conf.d/01-ctrcfg-pidsLimit"
-	crioDropInFilePathLogSizeMax = "/etc/crio/crio.conf.d/01-ctrcfg-logSizeMax"
+	CRIODropInFilePathLogLevel     = "/etc/crio/crio.conf.d/01-ctrcfg-logLevel"
+	crioDropInFilePathPidsLimit    = "/etc/crio/crio.conf.d/01-ctrcfg-pidsLimit"
+	crioDropInFilePathLogSizeMax   = "/etc/crio/crio.conf.d/01-ctrcfg-logSizeMax"
+	crioDropInFilePathConmonCgroup = "/etc/crio/crio.conf.d/01-ctrcfg-conmonCgroup"
+	conmonCgroupPod                = "pod"
+	systemdSliceSuffix             = ".slice"
+	// registriesDropInFilePath sorts after searchRegDropInFilePath so that the search
+	// registries of a ctrcfg take precedence over the cluster-wide ones
+	registriesDropInFilePath = "/etc/containers/registries.conf.d/02-ctrcfg-registries.conf"
+	// crioDropInFilePathRuntimeHandlers holds the runtime handlers of the ctrcfg, in addition
+	// to the default runc one of CRI-O
+	crioDropInFilePathRuntimeHandlers = "/etc/crio/crio.conf.d/01-ctrcfg-runtimeHandlers"
+	runtimeTypeOCI                    = "oci"
+	runtimeTypeVM                     = "vm"
 )
 
+// reservedRuntimeHandlers are the runtime handlers shipped with CRI-O, which
+// a ctrcfg must not redefine.
+var reservedRuntimeHandlers = map[string]bool{
+	"runc": true,
+	"crun": true,
+}
+
 var errParsingReference = errors.New("error parsing reference of release image")
 
 // TOML-friendly explicit tables used for conversions.
@@ -93,6 +113,44 @@ type tomlConfigCRIOLogSizeMax struct {
 	} `toml:"crio"`
 }
 
+// tomlConfigCRIOConmonCgroup is used for conversions when conmon-cgroup is changed
+// TOML-friendly (it has all of the explicit tables). It's just used for
+// conversions.
+type tomlConfigCRIOConmonCgroup struct {
+	Crio struct {
+		Runtime struct {
+			ConmonCgroup string `toml:"conmon_cgroup,omitempty"`
+		} `toml:"runtime"`
+	} `toml:"crio"`
+}
+
+// tomlConfigCRIORuntimeHandlers is used for conversions when runtime handlers are added
+// TOML-friendly (it has all of the explicit tables). It's just used for
+// conversions.
+type tomlConfigCRIORuntimeHandlers struct {
+	Crio struct {
+		Runtime struct {
+			Runtimes map[string]tomlCRIORuntimeHandler `toml:"runtimes"`
+		} `toml:"runtime"`
+	} `toml:"crio"`
+}
+
+// tomlCRIORuntimeHandler is a [crio.runtime.runtimes.<name>] table of crio.conf
+type tomlCRIORuntimeHandler struct {
+	RuntimePath        string   `toml:"runtime_path"`
+	RuntimeType        string   `toml:"runtime_type,omitempty"`
+	RuntimeRoot        string   `toml:"runtime_root,omitempty"`
+	AllowedAnnotations []string `toml:"allowed_annotations,omitempty"`
+}
+
+// tomlConfigRegistriesDropIn is used for the registries.conf.d drop-in of a ctrcfg.
+// Only the keys that are set are written, as any key in a drop-in overrides the
+// value of registries.conf.
+type tomlConfigRegistriesDropIn struct {
+	UnqualifiedSearchRegistries []string          `toml:"unqualified-search-registries,omitempty"`
+	Aliases                     map[string]string `toml:"aliases,omitempty"`
+}
+
 // generatedConfigFile is a struct that holds the filepath and data of the various configs
 // Using a struct array ensures that the order of the ignition files always stay the same
 // ensuring that double MCs are not created due to a change in the order
@@ -267,6 +325,7 @@ func wrapErrorWithCondition(err error, args ...interface{}) mcfgv1.ContainerRunt
 			corev1.ConditionFalse,
 			fmt.Sprintf("Error: %v", err),
 		)
+		condition.Reason = ctrlcommon.ErrorReason(err)
 	} else {
 		condition = mcfgv1.NewContainerRuntimeConfigCondition(
 			mcfgv1.ContainerRuntimeConfigSuccess,
@@ -352,6 +411,52 @@ func createCRIODropinFiles(cfg *mcfgv1.ContainerRuntimeConfig) []generatedConfig
 			glog.V(2).Infoln(cfg, err, "error updating user changes for log-size-max to crio.conf.d: %v", err)
 		}
 	}
+	if ctrcfg.ConmonCgroup != "" {
+		tomlConf := tomlConfigCRIOConmonCgroup{}
+		tomlConf.Crio.Runtime.ConmonCgroup = ctrcfg.ConmonCgroup
+		generatedConfigFileList, err = addTOMLgeneratedConfigFile(generatedConfigFileList, crioDropInFilePathConmonCgroup, tomlConf)
+		if err != nil {
+			glog.V(2).Infoln(cfg, err, "error updating user changes for conmon-cgroup to crio.conf.d: %v", err)
+		}
+	}
+	if len(ctrcfg.RuntimeHandlers) > 0 {
+		tomlConf := tomlConfigCRIORuntimeHandlers{}
+		tomlConf.Crio.Runtime.Runtimes = map[string]tomlCRIORuntimeHandler{}
+		for _, handler := range ctrcfg.RuntimeHandlers {
+			tomlConf.Crio.Runtime.Runtimes[handler.Name] = tomlCRIORuntimeHandler{
+				RuntimePath:        handler.RuntimePath,
+				RuntimeType:        handler.RuntimeType,
+				RuntimeRoot:        handler.RuntimeRoot,
+				AllowedAnnotations: handler.AllowedAnnotations,
+			}
+		}
+		generatedConfigFileList, err = addTOMLgeneratedConfigFile(generatedConfigFileList, crioDropInFilePathRuntimeHandlers, tomlConf)
+		if err != nil {
+			glog.V(2).Infoln(cfg, err, "error updating user changes for runtime handlers to crio.conf.d: %v", err)
+		}
+	}
+	return generatedConfigFileList
+}
+
+// createRegistriesDropinFile creates the registries.conf.d drop-in file holding the
+// short-name aliases and unqualified search registries of the ctrcfg CR
+func createRegistriesDropinFile(cfg *mcfgv1.ContainerRuntimeConfig) []generatedConfigFile {
+	var (
+		generatedConfigFileList []generatedConfigFile
+		err                     error
+	)
+	ctrcfg := cfg.Spec.ContainerRuntimeConfig
+	tomlConf := tomlConfigRegistriesDropIn{UnqualifiedSearchRegistries: ctrcfg.UnqualifiedSearchRegistries}
+	if len(ctrcfg.ShortNameAliases) > 0 {
+		tomlConf.Aliases = map[string]string{}
+		for _, alias := range ctrcfg.ShortNameAliases {
+			tomlConf.Aliases[alias.Name] = alias.Image
+		}
+	}
+	generatedConfigFileList, err = addTOMLgeneratedConfigFile(generatedConfigFileList, registriesDropInFilePath, tomlConf)
+	if err != nil {
+		glog.V(2).Infoln(cfg, err, "error updating user changes for short-name aliases and search registries to registries.conf.d: %v", err)
+	}
 	return generatedConfigFileList
 }
 
@@ -489,6 +594,104 @@ func validateUserContainerRuntimeConfig(cfg *mcfgv1.ContainerRuntimeConfig) erro
 		}
 	}
 
+	// crio runs with the systemd cgroup manager, which only accepts "pod" or a slice here
+	if ctrcfg.ConmonCgroup != "" && ctrcfg.ConmonCgroup != conmonCgroupPod {
+		if !strings.HasSuffix(ctrcfg.ConmonCgroup, systemdSliceSuffix) || len(ctrcfg.ConmonCgroup) == len(systemdSliceSuffix) || strings.Contains(ctrcfg.ConmonCgroup, "/") {
+			return fmt.Errorf("invalid ConmonCgroup %q, must be %q or the name of a systemd slice ending in %q", ctrcfg.ConmonCgroup, conmonCgroupPod, systemdSliceSuffix)
+		}
+	}
+
+	aliases := map[string]bool{}
+	for _, alias := range ctrcfg.ShortNameAliases {
+		if err := validateShortNameAlias(alias); err != nil {
+			return err
+		}
+		if aliases[alias.Name] {
+			return fmt.Errorf("invalid ShortNameAliases, %q is aliased more than once", alias.Name)
+		}
+		aliases[alias.Name] = true
+	}
+
+	searchRegs := map[string]bool{}
+	for _, reg := range ctrcfg.UnqualifiedSearchRegistries {
+		if reg == "" || strings.ContainsAny(reg, "/@") {
+			return fmt.Errorf("invalid UnqualifiedSearchRegistries entry %q, must be a registry host[:port]", reg)
+		}
+		if searchRegs[reg] {
+			return fmt.Errorf("invalid UnqualifiedSearchRegistries, %q is listed more than once", reg)
+		}
+		searchRegs[reg] = true
+	}
+
+	handlers := map[string]bool{}
+	for _, handler := range ctrcfg.RuntimeHandlers {
+		if err := validateRuntimeHandler(handler); err != nil {
+			return err
+		}
+		if handlers[handler.Name] {
+			return fmt.Errorf("invalid RuntimeHandlers, %q is defined more than once", handler.Name)
+		}
+		handlers[handler.Name] = true
+	}
+
+	return nil
+}
+
+// validateRuntimeHandler checks a runtime handler the way CRI-O does when
+// loading crio.conf, as CRI-O fails to start with an invalid one.
+func validateRuntimeHandler(handler mcfgv1.ContainerRuntimeHandler) error {
+	if errs := validation.IsDNS1123Label(handler.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid runtime handler name %q: %s", handler.Name, strings.Join(errs, ", "))
+	}
+	if reservedRuntimeHandlers[handler.Name] {
+		return fmt.Errorf("invalid runtime handler name %q, it is reserved for the runtimes shipped with CRI-O", handler.Name)
+	}
+	if !filepath.IsAbs(handler.RuntimePath) {
+		return fmt.Errorf("invalid runtimePath %q for runtime handler %q, must be an absolute path", handler.RuntimePath, handler.Name)
+	}
+	if handler.RuntimeRoot != "" && !filepath.IsAbs(handler.RuntimeRoot) {
+		return fmt.Errorf("invalid runtimeRoot %q for runtime handler %q, must be an absolute path", handler.RuntimeRoot, handler.Name)
+	}
+	if handler.RuntimeType != "" && handler.RuntimeType != runtimeTypeOCI && handler.RuntimeType != runtimeTypeVM {
+		return fmt.Errorf("invalid runtimeType %q for runtime handler %q, must be %q or %q", handler.RuntimeType, handler.Name, runtimeTypeOCI, runtimeTypeVM)
+	}
+	for _, annotation := range handler.AllowedAnnotations {
+		if errs := validation.IsQualifiedName(annotation); len(errs) > 0 {
+			return fmt.Errorf("invalid allowed annotation %q for runtime handler %q: %s", annotation, handler.Name, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// validateShortNameAlias checks the alias the way containers/image does when
+// loading registries.conf, as an invalid alias fails every image pull on the node.
+func validateShortNameAlias(alias mcfgv1.ShortNameAlias) error {
+	isRegistry := func(domain string) bool {
+		return strings.ContainsAny(domain, ".:") || domain == "localhost"
+	}
+	hasTagOrDigest := func(ref reference.Reference) bool {
+		_, tagged := ref.(reference.Tagged)
+		_, digested := ref.(reference.Digested)
+		return tagged || digested
+	}
+
+	name, err := reference.Parse(alias.Name)
+	if err != nil {
+		return fmt.Errorf("invalid short name %q: %v", alias.Name, err)
+	}
+	named, ok := name.(reference.Named)
+	if !ok || hasTagOrDigest(name) || isRegistry(reference.Domain(named)) {
+		return fmt.Errorf("invalid short name %q, must not contain a registry, tag or digest", alias.Name)
+	}
+
+	image, err := reference.Parse(alias.Image)
+	if err != nil {
+		return fmt.Errorf("invalid image %q for short name %q: %v", alias.Image, alias.Name, err)
+	}
+	named, ok = image.(reference.Named)
+	if !ok || hasTagOrDigest(image) || !isRegistry(reference.Domain(named)) {
+		return fmt.Errorf("invalid image %q for short name %q, must be a fully qualified image name without a tag or digest", alias.Image, alias.Name)
+	}
 	return nil
 }
 
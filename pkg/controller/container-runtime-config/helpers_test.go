@@ -16,7 +16,11 @@ import (
 	apioperatorsv1alpha1 "github.com/openshift/api/operator/v1alpha1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/diff"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 )
 
 func TestUpdateRegistriesConfig(t *testing.T) {
@@ -406,3 +410,71 @@ func TestValidateRegistriesConfScopes(t *testing.T) {
 		require.Equal(t, tc.expectedErr, res)
 	}
 }
+
+func TestCreateCRIODropinFilesConmonCgroup(t *testing.T) {
+	ctrcfg := newContainerRuntimeConfig("conmon", &mcfgv1.ContainerRuntimeConfiguration{ConmonCgroup: "system.slice"}, &metav1.LabelSelector{})
+	files := createCRIODropinFiles(ctrcfg)
+	require.Len(t, files, 1)
+	assert.Equal(t, crioDropInFilePathConmonCgroup, files[0].filePath)
+
+	tomlConf := tomlConfigCRIOConmonCgroup{}
+	_, err := toml.Decode(string(files[0].data), &tomlConf)
+	require.NoError(t, err)
+	assert.Equal(t, "system.slice", tomlConf.Crio.Runtime.ConmonCgroup)
+}
+
+func TestCreateCRIODropinFilesRuntimeHandlers(t *testing.T) {
+	ctrcfg := newContainerRuntimeConfig("wasm", &mcfgv1.ContainerRuntimeConfiguration{
+		RuntimeHandlers: []mcfgv1.ContainerRuntimeHandler{
+			{Name: "crun-wasm", RuntimePath: "/usr/bin/crun", AllowedAnnotations: []string{"module.wasm.image/variant"}},
+			{Name: "kata", RuntimePath: "/usr/bin/containerd-shim-kata-v2", RuntimeType: "vm", RuntimeRoot: "/run/vc"},
+		},
+	}, &metav1.LabelSelector{})
+	files := createCRIODropinFiles(ctrcfg)
+	require.Len(t, files, 1)
+	assert.Equal(t, crioDropInFilePathRuntimeHandlers, files[0].filePath)
+
+	tomlConf := tomlConfigCRIORuntimeHandlers{}
+	meta, err := toml.Decode(string(files[0].data), &tomlConf)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]tomlCRIORuntimeHandler{
+		"crun-wasm": {RuntimePath: "/usr/bin/crun", AllowedAnnotations: []string{"module.wasm.image/variant"}},
+		"kata":      {RuntimePath: "/usr/bin/containerd-shim-kata-v2", RuntimeType: "vm", RuntimeRoot: "/run/vc"},
+	}, tomlConf.Crio.Runtime.Runtimes)
+	// unset options are left to CRI-O's defaults
+	assert.False(t, meta.IsDefined("crio", "runtime", "runtimes", "crun-wasm", "runtime_type"))
+}
+
+func TestCreateRegistriesDropinFile(t *testing.T) {
+	ctrcfg := newContainerRuntimeConfig("registries", &mcfgv1.ContainerRuntimeConfiguration{
+		ShortNameAliases: []mcfgv1.ShortNameAlias{{Name: "ubi8", Image: "registry.example.com/ubi8/ubi"}},
+	}, &metav1.LabelSelector{})
+	files := createRegistriesDropinFile(ctrcfg)
+	require.Len(t, files, 1)
+	assert.Equal(t, registriesDropInFilePath, files[0].filePath)
+
+	// the search registries of registries.conf must be kept if they aren't set
+	tomlConf := sysregistriesv2.V2RegistriesConf{}
+	meta, err := toml.Decode(string(files[0].data), &tomlConf)
+	require.NoError(t, err)
+	assert.False(t, meta.IsDefined("unqualified-search-registries"))
+	assert.Equal(t, map[string]string{"ubi8": "registry.example.com/ubi8/ubi"}, tomlConf.Aliases)
+
+	ctrcfg.Spec.ContainerRuntimeConfig.UnqualifiedSearchRegistries = []string{"registry.example.com"}
+	files = createRegistriesDropinFile(ctrcfg)
+	require.Len(t, files, 1)
+	tomlConf = sysregistriesv2.V2RegistriesConf{}
+	_, err = toml.Decode(string(files[0].data), &tomlConf)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"registry.example.com"}, tomlConf.UnqualifiedSearchRegistries)
+}
+
+func TestWrapErrorWithConditionReason(t *testing.T) {
+	cond := wrapErrorWithCondition(&ctrlcommon.RenderError{Err: errors.New("template: missing")})
+	assert.Equal(t, mcfgv1.ContainerRuntimeConfigFailure, cond.Type)
+	assert.Equal(t, ctrlcommon.ErrorReasonRender, cond.Reason)
+	assert.Equal(t, "Error: template: missing", cond.Message)
+
+	cond = wrapErrorWithCondition(errors.New("could not find MachineConfigPool"))
+	assert.Empty(t, cond.Reason)
+}
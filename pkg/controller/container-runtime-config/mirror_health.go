@@ -0,0 +1,302 @@
+package containerruntimeconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/image"
+	"github.com/containers/image/v5/pkg/sysregistriesv2"
+	"github.com/containers/image/v5/types"
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/golang/glog"
+	apioperatorsv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	// mirrorCheckTimeout bounds the health check of a single mirror.
+	mirrorCheckTimeout = 30 * time.Second
+
+	// skipMirrorCheckAnnotationKey opts the mirrors of an ICSP out of the
+	// health checks, e.g. for mirrors only reachable from the nodes.
+	skipMirrorCheckAnnotationKey = "machineconfiguration.openshift.io/skip-mirror-check"
+)
+
+// mirrorCheck is a mirror of an ICSP source to check before rolling it out.
+type mirrorCheck struct {
+	source string
+	mirror string
+	// sample is an image of the source pinned by digest, as found on the
+	// mirror, if there is one.
+	sample string
+}
+
+// mirrorCheckFunc checks the health of a mirror.
+type mirrorCheckFunc func(ctx context.Context, sys *types.SystemContext, check mirrorCheck) error
+
+// newMirrorChecks returns the checks of the mirrors of the ICSPs which aren't
+// in the current registries.conf yet, if any.  sampleImage is an image
+// pinned by digest, usually the release image, which is pulled from the
+// mirrors of its repository to check they serve the same digest.
+func newMirrorChecks(current *ign3types.File, icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy, sampleImage string) ([]mirrorCheck, error) {
+	existing := map[mirrorCheck]bool{}
+	if current != nil && current.Contents.Source != nil {
+		contents, err := ctrlcommon.DecodeIgnitionFileContents(current.Contents.Source, current.Contents.Compression)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode current registries config: %v", err)
+		}
+		tomlConf := sysregistriesv2.V2RegistriesConf{}
+		if _, err := toml.Decode(string(contents), &tomlConf); err != nil {
+			return nil, fmt.Errorf("error unmarshalling current registries config: %v", err)
+		}
+		for _, reg := range tomlConf.Registries {
+			source := reg.Prefix
+			if source == "" {
+				source = reg.Location
+			}
+			for _, mirror := range reg.Mirrors {
+				existing[mirrorCheck{source: source, mirror: mirror.Location}] = true
+			}
+		}
+	}
+
+	var sample reference.Canonical
+	if named, err := reference.ParseNormalizedNamed(sampleImage); err == nil {
+		sample, _ = named.(reference.Canonical)
+	}
+
+	checks := []mirrorCheck{}
+	for _, icsp := range icspRules {
+		if icsp.Annotations[skipMirrorCheckAnnotationKey] == "true" {
+			continue
+		}
+		for _, rdm := range icsp.Spec.RepositoryDigestMirrors {
+			for _, mirror := range rdm.Mirrors {
+				check := mirrorCheck{source: rdm.Source, mirror: mirror}
+				if existing[check] {
+					continue
+				}
+				existing[check] = true
+				if sample != nil && (sample.Name() == rdm.Source || strings.HasPrefix(sample.Name(), rdm.Source+"/")) {
+					check.sample = mirror + strings.TrimPrefix(sample.Name(), rdm.Source) + "@" + sample.Digest().String()
+				}
+				checks = append(checks, check)
+			}
+		}
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		if checks[i].source != checks[j].source {
+			return checks[i].source < checks[j].source
+		}
+		return checks[i].mirror < checks[j].mirror
+	})
+	return checks, nil
+}
+
+// checkMirror pulls the manifest of the sample image of a mirror, which is
+// verified against the digest the image is pinned by.  Mirrors without a
+// sample image are only checked to be reachable registries.
+func checkMirror(ctx context.Context, sys *types.SystemContext, check mirrorCheck) error {
+	ctx, cancel := context.WithTimeout(ctx, mirrorCheckTimeout)
+	defer cancel()
+
+	if check.sample == "" {
+		return pingRegistry(ctx, sys, check.mirror)
+	}
+	ref, err := docker.ParseReference("//" + check.sample)
+	if err != nil {
+		return err
+	}
+	src, err := ref.NewImageSource(ctx, sys)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if _, _, err := image.UnparsedInstance(src, nil).Manifest(ctx); err != nil {
+		return fmt.Errorf("pulling %s: %w", check.sample, err)
+	}
+	return nil
+}
+
+// pingRegistry checks the registry of a mirror answers on its API endpoint.
+// Registries requiring authentication answer with 401 Unauthorized.  Like the
+// sample pulls, it goes through the cluster proxy the operator sets in the
+// environment of the controller.
+func pingRegistry(ctx context.Context, sys *types.SystemContext, mirror string) error {
+	host := strings.SplitN(mirror, "/", 2)[0]
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if sys.DockerInsecureSkipTLSVerify == types.OptionalBoolTrue {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+host+"/v2/", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized {
+		return fmt.Errorf("registry %s answered %s", host, resp.Status)
+	}
+	return nil
+}
+
+// isInsecureRegistry returns whether the registry of a mirror is one of the
+// insecure registries of the image config, which may have a wildcard prefix.
+func isInsecureRegistry(mirror string, insecureRegs []string) bool {
+	host := strings.SplitN(mirror, "/", 2)[0]
+	for _, reg := range insecureRegs {
+		switch {
+		case reg == host, reg == mirror, strings.HasPrefix(mirror, reg+"/"):
+			return true
+		case strings.HasPrefix(reg, "*.") && strings.HasSuffix(host, reg[1:]):
+			return true
+		}
+	}
+	return false
+}
+
+// verifyMirrors runs the checks not run yet in this sync, using the cluster
+// pull secret to pull sample images, and returns the unhealthy mirrors.
+func (ctrl *Controller) verifyMirrors(cc *mcfgv1.ControllerConfig, checks []mirrorCheck, insecureRegs []string, verified map[mirrorCheck]error) ([]mirrorCheck, error) {
+	sys := &types.SystemContext{}
+	needsAuth := false
+	for _, check := range checks {
+		if _, ok := verified[check]; !ok && check.sample != "" {
+			needsAuth = true
+		}
+	}
+	if needsAuth && cc.Spec.PullSecret != nil {
+		authFile, err := ctrl.writePullSecret(cc.Spec.PullSecret)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(authFile)
+		sys.AuthFilePath = authFile
+	}
+
+	var unhealthy []mirrorCheck
+	for _, check := range checks {
+		err, ok := verified[check]
+		if !ok {
+			checkSys := *sys
+			if isInsecureRegistry(check.mirror, insecureRegs) {
+				checkSys.DockerInsecureSkipTLSVerify = types.OptionalBoolTrue
+			}
+			err = ctrl.checkMirror(context.TODO(), &checkSys, check)
+			verified[check] = err
+			if err == nil {
+				glog.Infof("Image mirror %s of %s is healthy", check.mirror, check.source)
+			}
+		}
+		if err != nil {
+			unhealthy = append(unhealthy, check)
+		}
+	}
+	return unhealthy, nil
+}
+
+// unhealthyMirrorsError returns an error listing the unhealthy mirrors and
+// why, or nil if there are none.
+func unhealthyMirrorsError(unhealthy []mirrorCheck, verified map[mirrorCheck]error) error {
+	if len(unhealthy) == 0 {
+		return nil
+	}
+	msgs := make([]string, 0, len(unhealthy))
+	for _, check := range unhealthy {
+		msgs = append(msgs, fmt.Sprintf("mirror %s of %s: %v", check.mirror, check.source, verified[check]))
+	}
+	return fmt.Errorf("unhealthy image mirrors: %s", strings.Join(msgs, "; "))
+}
+
+// withoutMirrors returns the ICSPs without the unhealthy mirrors, and without
+// the sources left with no mirrors.  The ICSPs aren't modified.
+func withoutMirrors(icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy, unhealthy []mirrorCheck) []*apioperatorsv1alpha1.ImageContentSourcePolicy {
+	if len(unhealthy) == 0 {
+		return icspRules
+	}
+	skip := map[[2]string]bool{}
+	for _, check := range unhealthy {
+		skip[[2]string{check.source, check.mirror}] = true
+	}
+	rules := make([]*apioperatorsv1alpha1.ImageContentSourcePolicy, 0, len(icspRules))
+	for _, icsp := range icspRules {
+		icsp = icsp.DeepCopy()
+		rdms := []apioperatorsv1alpha1.RepositoryDigestMirrors{}
+		for _, rdm := range icsp.Spec.RepositoryDigestMirrors {
+			mirrors := []string{}
+			for _, mirror := range rdm.Mirrors {
+				if !skip[[2]string{rdm.Source, mirror}] {
+					mirrors = append(mirrors, mirror)
+				}
+			}
+			if len(mirrors) > 0 {
+				rdm.Mirrors = mirrors
+				rdms = append(rdms, rdm)
+			}
+		}
+		icsp.Spec.RepositoryDigestMirrors = rdms
+		rules = append(rules, icsp)
+	}
+	return rules
+}
+
+// writePullSecret writes the pull secret to a temporary auth file, which the
+// caller must remove.
+func (ctrl *Controller) writePullSecret(ref *corev1.ObjectReference) (string, error) {
+	secret, err := ctrl.kubeClient.CoreV1().Secrets(ref.Namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("could not get pull secret: %v", err)
+	}
+	authFile, err := ioutil.TempFile("", "mirror-check-auth-")
+	if err != nil {
+		return "", err
+	}
+	if _, err := authFile.Write(secret.Data[corev1.DockerConfigJsonKey]); err != nil {
+		authFile.Close()
+		os.Remove(authFile.Name())
+		return "", err
+	}
+	if err := authFile.Close(); err != nil {
+		os.Remove(authFile.Name())
+		return "", err
+	}
+	return authFile.Name(), nil
+}
+
+// syncImageMirrorsStatus reports the result of the mirror checks in the
+// ImageMirrorsDegraded condition of the pools.  A healthy result only
+// updates pools which were degraded.
+func (ctrl *Controller) syncImageMirrorsStatus(pools []*mcfgv1.MachineConfigPool, mirrorsErr error) error {
+	for _, pool := range pools {
+		cond := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolImageMirrorsDegraded, corev1.ConditionFalse, "", "")
+		if mirrorsErr != nil {
+			cond = mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolImageMirrorsDegraded, corev1.ConditionTrue, "UnhealthyMirrors",
+				fmt.Sprintf("Not rolling out these mirrors: %v", mirrorsErr))
+		} else if !mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolImageMirrorsDegraded) {
+			continue
+		}
+		pool = pool.DeepCopy()
+		mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *cond)
+		if _, err := ctrl.client.MachineconfigurationV1().MachineConfigPools().UpdateStatus(context.TODO(), pool, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+	return mirrorsErr
+}
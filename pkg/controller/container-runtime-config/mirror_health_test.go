@@ -0,0 +1,185 @@
+package containerruntimeconfig
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/containers/image/v5/types"
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	apicfgv1 "github.com/openshift/api/config/v1"
+	apioperatorsv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+const sampleDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func healthyMirror(context.Context, *types.SystemContext, mirrorCheck) error {
+	return nil
+}
+
+func TestNewMirrorChecks(t *testing.T) {
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName, apicfgv1.AWSPlatformType)
+	current := newICSP("current", []apioperatorsv1alpha1.RepositoryDigestMirrors{
+		{Source: "quay.io/openshift-release-dev/ocp-release", Mirrors: []string{"mirror.example.com/ocp/release"}},
+	})
+	currentIgn, err := registriesConfigIgnition(templateDir, cc, "worker", nil, nil, nil, nil, []*apioperatorsv1alpha1.ImageContentSourcePolicy{current})
+	require.NoError(t, err)
+	var currentRegistriesConf *ign3types.File
+	for i := range currentIgn.Storage.Files {
+		if currentIgn.Storage.Files[i].Path == registriesConfigPath {
+			currentRegistriesConf = &currentIgn.Storage.Files[i]
+		}
+	}
+	require.NotNil(t, currentRegistriesConf)
+
+	updated := newICSP("current", []apioperatorsv1alpha1.RepositoryDigestMirrors{
+		{Source: "quay.io/openshift-release-dev/ocp-release", Mirrors: []string{"mirror.example.com/ocp/release", "backup.example.com/release"}},
+		{Source: "quay.io/openshift-release-dev/ocp-v4.0-art-dev", Mirrors: []string{"mirror.example.com/ocp/art-dev"}},
+	})
+	skipped := newICSP("skipped", []apioperatorsv1alpha1.RepositoryDigestMirrors{
+		{Source: "registry.example.com", Mirrors: []string{"unreachable.example.com"}},
+	})
+	skipped.Annotations = map[string]string{skipMirrorCheckAnnotationKey: "true"}
+	icsps := []*apioperatorsv1alpha1.ImageContentSourcePolicy{updated, skipped}
+
+	checks, err := newMirrorChecks(currentRegistriesConf, icsps, "quay.io/openshift-release-dev/ocp-release@"+sampleDigest)
+	require.NoError(t, err)
+	assert.Equal(t, []mirrorCheck{
+		{source: "quay.io/openshift-release-dev/ocp-release", mirror: "backup.example.com/release", sample: "backup.example.com/release@" + sampleDigest},
+		{source: "quay.io/openshift-release-dev/ocp-v4.0-art-dev", mirror: "mirror.example.com/ocp/art-dev"},
+	}, checks)
+
+	// without a current registries.conf all the mirrors are new, and a
+	// sample image not pinned by digest isn't pulled
+	checks, err = newMirrorChecks(nil, icsps, "quay.io/openshift-release-dev/ocp-release:4.10.0")
+	require.NoError(t, err)
+	assert.Len(t, checks, 3)
+	for _, check := range checks {
+		assert.Empty(t, check.sample)
+	}
+}
+
+func TestIsInsecureRegistry(t *testing.T) {
+	insecureRegs := []string{"insecure.example.com", "*.lab.example.com", "mirror.example.com/insecure"}
+	for mirror, insecure := range map[string]bool{
+		"insecure.example.com/ocp":        true,
+		"registry.lab.example.com/ocp":    true,
+		"mirror.example.com/insecure/ocp": true,
+		"mirror.example.com/ocp":          false,
+		"lab.example.com/ocp":             false,
+	} {
+		assert.Equal(t, insecure, isInsecureRegistry(mirror, insecureRegs), mirror)
+	}
+}
+
+func TestICSPUnhealthyMirror(t *testing.T) {
+	f := newFixture(t)
+
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName, apicfgv1.AWSPlatformType)
+	mcp := helpers.NewMachineConfigPool("master", nil, helpers.MasterSelector, "v0")
+	mcp2 := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v0")
+	imgcfg := newImageConfig("cluster", &apicfgv1.RegistrySources{})
+	cvcfg := newClusterVersionConfig("version", "release.example.com/ocp/release@"+sampleDigest)
+	icsp := newICSP("release", []apioperatorsv1alpha1.RepositoryDigestMirrors{
+		{Source: "release.example.com/ocp", Mirrors: []string{"mirror.example.com/ocp"}},
+		{Source: "quay.example.com/app", Mirrors: []string{"mirror.example.com/app"}},
+	})
+
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, mcp, mcp2)
+	f.imgLister = append(f.imgLister, imgcfg)
+	f.icspLister = append(f.icspLister, icsp)
+	f.cvLister = append(f.cvLister, cvcfg)
+	f.objects = append(f.objects, mcp, mcp2)
+	f.imgObjects = append(f.imgObjects, imgcfg)
+	f.operatorObjects = append(f.operatorObjects, icsp)
+
+	var checked []mirrorCheck
+	f.checkMirror = func(_ context.Context, _ *types.SystemContext, check mirrorCheck) error {
+		checked = append(checked, check)
+		if check.mirror == "mirror.example.com/ocp" {
+			return fmt.Errorf("manifest unknown")
+		}
+		return nil
+	}
+	c := f.newController()
+	err := c.syncImgHandler(icspQueueKey)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mirror mirror.example.com/ocp of release.example.com/ocp: manifest unknown")
+	// the mirrors are checked once for both pools
+	assert.Equal(t, []mirrorCheck{
+		{source: "quay.example.com/app", mirror: "mirror.example.com/app"},
+		{source: "release.example.com/ocp", mirror: "mirror.example.com/ocp", sample: "mirror.example.com/ocp/release@" + sampleDigest},
+	}, checked)
+
+	// only the healthy mirror is rolled out
+	degraded := []*mcfgv1.MachineConfigPool{}
+	for _, name := range []string{"master", "worker"} {
+		pool, err := f.client.MachineconfigurationV1().MachineConfigPools().Get(context.TODO(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.True(t, mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolImageMirrorsDegraded), name)
+		degraded = append(degraded, pool)
+
+		registriesConf := getRegistriesConf(t, f, pool)
+		assert.Contains(t, registriesConf, "mirror.example.com/app", name)
+		assert.NotContains(t, registriesConf, "mirror.example.com/ocp", name)
+	}
+
+	// once the mirror is healthy it's rolled out too and the condition cleared
+	f = newFixture(t)
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, degraded...)
+	f.imgLister = append(f.imgLister, imgcfg)
+	f.icspLister = append(f.icspLister, icsp)
+	f.cvLister = append(f.cvLister, cvcfg)
+	f.objects = append(f.objects, degraded[0], degraded[1])
+	f.imgObjects = append(f.imgObjects, imgcfg)
+	f.operatorObjects = append(f.operatorObjects, icsp)
+
+	c = f.newController()
+	require.NoError(t, c.syncImgHandler(icspQueueKey))
+	for _, pool := range degraded {
+		pool, err := f.client.MachineconfigurationV1().MachineConfigPools().Get(context.TODO(), pool.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.True(t, mcfgv1.IsMachineConfigPoolConditionFalse(pool.Status.Conditions, mcfgv1.MachineConfigPoolImageMirrorsDegraded), pool.Name)
+		assert.Contains(t, getRegistriesConf(t, f, pool), "mirror.example.com/ocp", pool.Name)
+	}
+}
+
+func TestWithoutMirrors(t *testing.T) {
+	icsp := newICSP("release", []apioperatorsv1alpha1.RepositoryDigestMirrors{
+		{Source: "release.example.com/ocp", Mirrors: []string{"mirror.example.com/ocp"}},
+		{Source: "quay.example.com/app", Mirrors: []string{"mirror.example.com/app", "backup.example.com/app"}},
+	})
+	rules := []*apioperatorsv1alpha1.ImageContentSourcePolicy{icsp}
+	assert.Equal(t, rules, withoutMirrors(rules, nil))
+
+	filtered := withoutMirrors(rules, []mirrorCheck{
+		{source: "release.example.com/ocp", mirror: "mirror.example.com/ocp"},
+		{source: "quay.example.com/app", mirror: "backup.example.com/app"},
+	})
+	assert.Equal(t, []apioperatorsv1alpha1.RepositoryDigestMirrors{
+		{Source: "quay.example.com/app", Mirrors: []string{"mirror.example.com/app"}},
+	}, filtered[0].Spec.RepositoryDigestMirrors)
+	// the ICSP of the lister isn't modified
+	assert.Len(t, icsp.Spec.RepositoryDigestMirrors, 2)
+}
+
+// getRegistriesConf returns the registries.conf generated for the pool.
+func getRegistriesConf(t *testing.T, f *fixture, pool *mcfgv1.MachineConfigPool) string {
+	keyReg, _ := getManagedKeyReg(pool, nil)
+	mc, err := f.client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), keyReg, metav1.GetOptions{})
+	require.NoError(t, err)
+	file, err := findRegistriesConfig(mc)
+	require.NoError(t, err)
+	contents, err := ctrlcommon.DecodeIgnitionFileContents(file.Contents.Source, file.Contents.Compression)
+	require.NoError(t, err)
+	return string(contents)
+}
@@ -0,0 +1,158 @@
+package freeze
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+// WebhookPath is the path the MachineConfigFreeze admission webhook is served on.
+const WebhookPath = "/mutate-machineconfigfreeze"
+
+// patchOp is a JSON patch operation.
+type patchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// webhookHandler records who froze node updates and when in the spec of the
+// MachineConfigFreeze, so that the freeze can be attributed without going
+// through the apiserver audit log.
+type webhookHandler struct {
+	now func() time.Time
+}
+
+// NewWebhookHandler returns the handler of the MachineConfigFreeze mutating
+// admission webhook.
+func NewWebhookHandler() http.Handler {
+	return &webhookHandler{now: time.Now}
+}
+
+func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	review := &admissionv1.AdmissionReview{}
+	if err := json.NewDecoder(r.Body).Decode(review); err != nil {
+		http.Error(w, fmt.Sprintf("decoding admission review: %v", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "admission review without request", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := admit(review.Request, metav1.NewTime(h.now().UTC().Truncate(time.Second)))
+	if err != nil {
+		resp = &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result:  &metav1.Status{Status: metav1.StatusFailure, Message: err.Error(), Code: http.StatusBadRequest},
+		}
+	}
+	resp.UID = review.Request.UID
+	review.Response = resp
+	review.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		glog.Errorf("Writing MachineConfigFreeze admission response: %v", err)
+	}
+}
+
+// admit patches frozenBy and frozenAt of the admitted MachineConfigFreeze.
+// They're set to the requesting user and now when node updates get frozen,
+// kept from the old object while they stay frozen, so that they can't be
+// forged, and cleared when they're unfrozen.
+func admit(req *admissionv1.AdmissionRequest, now metav1.Time) (*admissionv1.AdmissionResponse, error) {
+	mcf := &mcfgv1.MachineConfigFreeze{}
+	if err := json.Unmarshal(req.Object.Raw, mcf); err != nil {
+		return nil, fmt.Errorf("decoding MachineConfigFreeze: %w", err)
+	}
+	old := &mcfgv1.MachineConfigFreeze{}
+	if req.Operation == admissionv1.Update {
+		if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+			return nil, fmt.Errorf("decoding old MachineConfigFreeze: %w", err)
+		}
+	}
+
+	var patch []patchOp
+	switch {
+	case !mcf.Spec.Frozen:
+		if mcf.Spec.FrozenBy != "" {
+			patch = append(patch, patchOp{Op: "remove", Path: "/spec/frozenBy"})
+		}
+		if mcf.Spec.FrozenAt != nil {
+			patch = append(patch, patchOp{Op: "remove", Path: "/spec/frozenAt"})
+		}
+	case old.Spec.Frozen:
+		patch = restore(patch, "/spec/frozenBy", old.Spec.FrozenBy, old.Spec.FrozenBy != "", mcf.Spec.FrozenBy != "")
+		patch = restore(patch, "/spec/frozenAt", old.Spec.FrozenAt, old.Spec.FrozenAt != nil, mcf.Spec.FrozenAt != nil)
+	default:
+		patch = append(patch,
+			patchOp{Op: "add", Path: "/spec/frozenBy", Value: req.UserInfo.Username},
+			patchOp{Op: "add", Path: "/spec/frozenAt", Value: now},
+		)
+	}
+
+	resp := &admissionv1.AdmissionResponse{Allowed: true}
+	if len(patch) == 0 {
+		return resp, nil
+	}
+	raw, err := json.Marshal(patch)
+	if err != nil {
+		return nil, err
+	}
+	patchType := admissionv1.PatchTypeJSONPatch
+	resp.Patch = raw
+	resp.PatchType = &patchType
+	return resp, nil
+}
+
+// restore sets path back to the old value if the old object had it, or
+// removes it from the admitted object otherwise.
+func restore(patch []patchOp, path string, old interface{}, hadOld, present bool) []patchOp {
+	switch {
+	case hadOld:
+		return append(patch, patchOp{Op: "add", Path: path, Value: old})
+	case present:
+		return append(patch, patchOp{Op: "remove", Path: path})
+	}
+	return patch
+}
+
+// StartWebhook serves the MachineConfigFreeze admission webhook on addr until
+// the process exits.  The serving certificate is reloaded on each handshake,
+// so that it picks up the rotations of the service CA.
+func StartWebhook(addr, certFile, keyFile string) {
+	mux := http.NewServeMux()
+	mux.Handle(WebhookPath, NewWebhookHandler())
+	s := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+		TLSConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+				cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+				if err != nil {
+					return nil, err
+				}
+				return &cert, nil
+			},
+		},
+	}
+
+	glog.Infof("Starting MachineConfigFreeze admission webhook on %s", addr)
+	if err := s.ListenAndServeTLS("", ""); err != http.ErrServerClosed {
+		glog.Exitf("MachineConfigFreeze admission webhook exited with error: %v", err)
+	}
+}
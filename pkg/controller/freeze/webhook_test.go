@@ -0,0 +1,138 @@
+package freeze
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+func newFreeze(spec mcfgv1.MachineConfigFreezeSpec) runtime.RawExtension {
+	mcf := &mcfgv1.MachineConfigFreeze{
+		TypeMeta:   metav1.TypeMeta{APIVersion: mcfgv1.SchemeGroupVersion.String(), Kind: "MachineConfigFreeze"},
+		ObjectMeta: metav1.ObjectMeta{Name: mcfgv1.MachineConfigFreezeName},
+		Spec:       spec,
+	}
+	raw, _ := json.Marshal(mcf)
+	return runtime.RawExtension{Raw: raw}
+}
+
+func TestWebhook(t *testing.T) {
+	now := time.Date(2022, 3, 2, 8, 30, 0, 0, time.UTC)
+	frozenAt := metav1.NewTime(time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name      string
+		operation admissionv1.Operation
+		object    mcfgv1.MachineConfigFreezeSpec
+		oldObject *mcfgv1.MachineConfigFreezeSpec
+		patch     string
+	}{{
+		name:      "create frozen",
+		operation: admissionv1.Create,
+		object:    mcfgv1.MachineConfigFreezeSpec{Frozen: true, Reason: "incident 1234"},
+		patch:     `[{"op":"add","path":"/spec/frozenBy","value":"alice"},{"op":"add","path":"/spec/frozenAt","value":"2022-03-02T08:30:00Z"}]`,
+	}, {
+		name:      "create frozen with forged fields",
+		operation: admissionv1.Create,
+		object:    mcfgv1.MachineConfigFreezeSpec{Frozen: true, FrozenBy: "bob", FrozenAt: &frozenAt},
+		patch:     `[{"op":"add","path":"/spec/frozenBy","value":"alice"},{"op":"add","path":"/spec/frozenAt","value":"2022-03-02T08:30:00Z"}]`,
+	}, {
+		name:      "create unfrozen",
+		operation: admissionv1.Create,
+		object:    mcfgv1.MachineConfigFreezeSpec{},
+	}, {
+		name:      "freeze",
+		operation: admissionv1.Update,
+		object:    mcfgv1.MachineConfigFreezeSpec{Frozen: true},
+		oldObject: &mcfgv1.MachineConfigFreezeSpec{},
+		patch:     `[{"op":"add","path":"/spec/frozenBy","value":"alice"},{"op":"add","path":"/spec/frozenAt","value":"2022-03-02T08:30:00Z"}]`,
+	}, {
+		name:      "stay frozen keeps who froze and when",
+		operation: admissionv1.Update,
+		object:    mcfgv1.MachineConfigFreezeSpec{Frozen: true, Reason: "incident 1235", FrozenBy: "alice"},
+		oldObject: &mcfgv1.MachineConfigFreezeSpec{Frozen: true, Reason: "incident 1234", FrozenBy: "bob", FrozenAt: &frozenAt},
+		patch:     `[{"op":"add","path":"/spec/frozenBy","value":"bob"},{"op":"add","path":"/spec/frozenAt","value":"2022-03-01T12:00:00Z"}]`,
+	}, {
+		name:      "stay frozen without recorded fields",
+		operation: admissionv1.Update,
+		object:    mcfgv1.MachineConfigFreezeSpec{Frozen: true, FrozenBy: "alice", FrozenAt: &frozenAt},
+		oldObject: &mcfgv1.MachineConfigFreezeSpec{Frozen: true},
+		patch:     `[{"op":"remove","path":"/spec/frozenBy"},{"op":"remove","path":"/spec/frozenAt"}]`,
+	}, {
+		name:      "unfreeze",
+		operation: admissionv1.Update,
+		object:    mcfgv1.MachineConfigFreezeSpec{Frozen: false, FrozenBy: "bob", FrozenAt: &frozenAt},
+		oldObject: &mcfgv1.MachineConfigFreezeSpec{Frozen: true, FrozenBy: "bob", FrozenAt: &frozenAt},
+		patch:     `[{"op":"remove","path":"/spec/frozenBy"},{"op":"remove","path":"/spec/frozenAt"}]`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := &admissionv1.AdmissionRequest{
+				UID:       types.UID("uid"),
+				Operation: test.operation,
+				UserInfo:  authenticationv1.UserInfo{Username: "alice"},
+				Object:    newFreeze(test.object),
+			}
+			if test.oldObject != nil {
+				req.OldObject = newFreeze(*test.oldObject)
+			}
+			body, err := json.Marshal(&admissionv1.AdmissionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+				Request:  req,
+			})
+			require.NoError(t, err)
+
+			h := &webhookHandler{now: func() time.Time { return now }}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, WebhookPath, bytes.NewReader(body)))
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			review := &admissionv1.AdmissionReview{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), review))
+			require.NotNil(t, review.Response)
+			assert.True(t, review.Response.Allowed)
+			assert.Equal(t, types.UID("uid"), review.Response.UID)
+			if test.patch == "" {
+				assert.Nil(t, review.Response.Patch)
+				assert.Nil(t, review.Response.PatchType)
+				return
+			}
+			require.NotNil(t, review.Response.PatchType)
+			assert.Equal(t, admissionv1.PatchTypeJSONPatch, *review.Response.PatchType)
+			assert.JSONEq(t, test.patch, string(review.Response.Patch))
+		})
+	}
+}
+
+func TestWebhookRejectsInvalidObject(t *testing.T) {
+	body, err := json.Marshal(&admissionv1.AdmissionReview{
+		Request: &admissionv1.AdmissionRequest{
+			UID:       types.UID("uid"),
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: []byte(`{"spec":{"frozen":"yes"}}`)},
+		},
+	})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	NewWebhookHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, WebhookPath, bytes.NewReader(body)))
+	review := &admissionv1.AdmissionReview{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), review))
+	require.NotNil(t, review.Response)
+	assert.False(t, review.Response.Allowed)
+	assert.Equal(t, types.UID("uid"), review.Response.UID)
+}
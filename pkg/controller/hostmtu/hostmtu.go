@@ -0,0 +1,99 @@
+package hostmtu
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	nmConfDir = "/etc/NetworkManager/conf.d"
+
+	// minMTU is the minimum MTU of IPv4 hosts and maxMTU the largest jumbo
+	// frames commonly supported by NICs.
+	minMTU = 576
+	maxMTU = 9216
+)
+
+// interfaceNameRegexp matches the names the kernel accepts for an interface,
+// leaving out the ones which would need escaping in a keyfile.
+var interfaceNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.-]{1,15}$`)
+
+// mtuConfTemplate is the NetworkManager keyfile of the MTU migration
+// procedure: the connection defaults apply to whichever profile activates
+// the interface, so the generated profiles of ovs-configuration pick it up too.
+const mtuConfTemplate = `[connection-%[1]s-mtu]
+match-device=interface-name:%[1]s
+ethernet.mtu=%[2]d
+`
+
+// generatedConfigKind describes the MachineConfigs generated from the host MTU of the pools.
+var generatedConfigKind = ctrlcommon.GeneratedConfigKind{
+	Suffix:        "host-mtu",
+	Description:   "host MTU",
+	ConditionType: mcfgv1.MachineConfigPoolHostMTUDegraded,
+	InvalidReason: "HostMTUConfigInvalid",
+}
+
+func mtuConfPath(iface string) string {
+	return filepath.Join(nmConfDir, fmt.Sprintf("99-%s-mtu.conf", iface))
+}
+
+// mtuMigration returns the MTU migration in progress, if any.
+func mtuMigration(cc *mcfgv1.ControllerConfig) *configv1.MTUMigration {
+	if cc.Spec.Network == nil {
+		return nil
+	}
+	return cc.Spec.Network.MTUMigration
+}
+
+// validateHostMTU checks the host MTU of a pool, and that it agrees with the
+// machine MTU an MTU migration in progress is moving to.
+func validateHostMTU(cfg *mcfgv1.HostMTUConfiguration, migration *configv1.MTUMigration) error {
+	if cfg.MTU < minMTU || cfg.MTU > maxMTU {
+		return fmt.Errorf("invalid mtu %d: must be between %d and %d", cfg.MTU, minMTU, maxMTU)
+	}
+	if len(cfg.Interfaces) == 0 {
+		return fmt.Errorf("interfaces must be set")
+	}
+	seen := sets.NewString()
+	for _, iface := range cfg.Interfaces {
+		if !interfaceNameRegexp.MatchString(iface) {
+			return fmt.Errorf("invalid interface name %q", iface)
+		}
+		if seen.Has(iface) {
+			return fmt.Errorf("duplicate interface %q", iface)
+		}
+		seen.Insert(iface)
+	}
+	if migration != nil && migration.Machine != nil && migration.Machine.To != nil && *migration.Machine.To != cfg.MTU {
+		return fmt.Errorf("mtu %d does not match the machine MTU %d of the MTU migration in progress", cfg.MTU, *migration.Machine.To)
+	}
+	return nil
+}
+
+// generateMachineConfig renders the host MTU of the pool into a MachineConfig
+// for the pool's role.
+func generateMachineConfig(pool *mcfgv1.MachineConfigPool, migration *configv1.MTUMigration) (*mcfgv1.MachineConfig, error) {
+	cfg := pool.Spec.HostMTU
+	if err := validateHostMTU(cfg, migration); err != nil {
+		return nil, err
+	}
+
+	ignConfig := ctrlcommon.NewIgnConfig()
+	for _, iface := range sets.NewString(cfg.Interfaces...).List() {
+		ignConfig.Storage.Files = append(ignConfig.Storage.Files, ctrlcommon.NewGeneratedFile(mtuConfPath(iface), fmt.Sprintf(mtuConfTemplate, iface, cfg.MTU)))
+	}
+
+	mc, err := generatedConfigKind.NewMachineConfig(pool, ignConfig)
+	if err != nil {
+		return nil, err
+	}
+	return mc, nil
+}
@@ -0,0 +1,265 @@
+package hostmtu
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	corev1clientset "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	configv1 "github.com/openshift/api/config/v1"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+)
+
+const (
+	// maxRetries is the number of times a pool will be retried before it is dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// a pool is going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+)
+
+// Controller defines the host MTU controller, which renders the host MTU
+// of the pools into MachineConfigs.
+type Controller struct {
+	generated *ctrlcommon.GeneratedConfigs
+
+	syncHandler func(key string) error
+
+	mcpLister mcfglistersv1.MachineConfigPoolLister
+	ccLister  mcfglistersv1.ControllerConfigLister
+
+	mcpListerSynced cache.InformerSynced
+	mcListerSynced  cache.InformerSynced
+	ccListerSynced  cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+}
+
+// New returns a new host MTU controller.
+func New(
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	mcInformer mcfginformersv1.MachineConfigInformer,
+	ccInformer mcfginformersv1.ControllerConfigInformer,
+	kubeClient clientset.Interface,
+	mcfgClient mcfgclientset.Interface,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(&corev1clientset.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	ctrl := &Controller{
+		generated: &ctrlcommon.GeneratedConfigs{
+			GeneratedConfigKind: generatedConfigKind,
+			Client:              mcfgClient,
+			EventRecorder:       eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-hostmtucontroller"}),
+		},
+		queue: workqueue.NewNamedRateLimitingQueue(ctrlcommon.ControllerRateLimiter(), "machineconfigcontroller-hostmtucontroller"),
+	}
+
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addPool,
+		UpdateFunc: ctrl.updatePool,
+	})
+	mcInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: ctrl.updateMachineConfig,
+		DeleteFunc: ctrl.deleteMachineConfig,
+	})
+	ccInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: ctrl.updateControllerConfig,
+	})
+
+	ctrl.syncHandler = ctrl.syncPool
+
+	ctrl.mcpLister = mcpInformer.Lister()
+	ctrl.generated.MCLister = mcInformer.Lister()
+	ctrl.ccLister = ccInformer.Lister()
+	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
+	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
+	ctrl.ccListerSynced = ccInformer.Informer().HasSynced
+
+	return ctrl
+}
+
+// Run executes the host MTU controller
+func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer ctrl.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.mcListerSynced, ctrl.ccListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-HostMTUController")
+	defer glog.Info("Shutting down MachineConfigController-HostMTUController")
+
+	for i := 0; i < workers; i++ {
+		go wait.Until(ctrl.worker, time.Second, stopCh)
+	}
+
+	<-stopCh
+}
+
+func (ctrl *Controller) addPool(obj interface{}) {
+	pool := obj.(*mcfgv1.MachineConfigPool)
+	glog.V(4).Infof("Adding MachineConfigPool %s", pool.Name)
+	ctrl.queue.Add(pool.Name)
+}
+
+func (ctrl *Controller) updatePool(old, cur interface{}) {
+	oldPool := old.(*mcfgv1.MachineConfigPool)
+	curPool := cur.(*mcfgv1.MachineConfigPool)
+	// Pools are updated all the time, only the host MTU matters.
+	if reflect.DeepEqual(oldPool.Spec.HostMTU, curPool.Spec.HostMTU) {
+		return
+	}
+	glog.V(4).Infof("Updating host MTU of MachineConfigPool %s", curPool.Name)
+	ctrl.queue.Add(curPool.Name)
+}
+
+// updateControllerConfig requeues the pools with a host MTU when an MTU
+// migration starts or ends, as their MTU is checked against it.
+func (ctrl *Controller) updateControllerConfig(old, cur interface{}) {
+	oldCC := old.(*mcfgv1.ControllerConfig)
+	curCC := cur.(*mcfgv1.ControllerConfig)
+	if reflect.DeepEqual(mtuMigration(oldCC), mtuMigration(curCC)) {
+		return
+	}
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't list MachineConfigPools: %w", err))
+		return
+	}
+	for _, pool := range pools {
+		if pool.Spec.HostMTU != nil {
+			glog.V(4).Infof("MTU migration changed, requeueing MachineConfigPool %s", pool.Name)
+			ctrl.queue.Add(pool.Name)
+		}
+	}
+}
+
+func (ctrl *Controller) updateMachineConfig(old, cur interface{}) {
+	mc := cur.(*mcfgv1.MachineConfig)
+	if pool := generatedConfigKind.OwningPool(mc); pool != "" {
+		glog.V(4).Infof("MachineConfig %s generated for MachineConfigPool %s updated", mc.Name, pool)
+		ctrl.queue.Add(pool)
+	}
+}
+
+func (ctrl *Controller) deleteMachineConfig(obj interface{}) {
+	mc, ok := obj.(*mcfgv1.MachineConfig)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		mc, ok = tombstone.Obj.(*mcfgv1.MachineConfig)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a MachineConfig %#v", obj))
+			return
+		}
+	}
+	if pool := generatedConfigKind.OwningPool(mc); pool != "" {
+		glog.V(4).Infof("MachineConfig %s generated for MachineConfigPool %s deleted", mc.Name, pool)
+		ctrl.queue.Add(pool)
+	}
+}
+
+// worker runs a worker thread that just dequeues items, processes them, and marks them done.
+// It enforces that the syncHandler is never invoked concurrently with the same key.
+func (ctrl *Controller) worker() {
+	for ctrl.processNextWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextWorkItem() bool {
+	key, quit := ctrl.queue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	err := ctrl.syncHandler(key.(string))
+	ctrl.handleErr(err, key)
+
+	return true
+}
+
+func (ctrl *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.queue.Forget(key)
+		return
+	}
+
+	if ctrl.queue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing host MTU of MachineConfigPool %v: %v", key, err)
+		ctrl.queue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping MachineConfigPool %q out of the queue: %v", key, err)
+	ctrl.queue.Forget(key)
+	ctrl.queue.AddAfter(key, 1*time.Minute)
+}
+
+// syncPool renders the host MTU of the pool with the given name
+// into its MachineConfig, or deletes the MachineConfig if there is none.
+// A deleted pool's MachineConfig is garbage collected through its owner reference.
+// This function is not meant to be invoked concurrently with the same key.
+func (ctrl *Controller) syncPool(name string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing host MTU of MachineConfigPool %q (%v)", name, startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing host MTU of MachineConfigPool %q (%v)", name, time.Since(startTime))
+	}()
+
+	pool, err := ctrl.mcpLister.Get(name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if pool.Spec.HostMTU == nil {
+		return ctrl.generated.Delete(pool)
+	}
+
+	var migration *configv1.MTUMigration
+	cc, err := ctrl.ccLister.Get(ctrlcommon.ControllerConfigName)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		migration = mtuMigration(cc)
+	}
+
+	mc, err := generateMachineConfig(pool, migration)
+	if err != nil {
+		return ctrl.generated.SyncFailingStatus(pool, err)
+	}
+	if updated, err := ctrl.generated.Apply(pool, mc); err != nil {
+		return err
+	} else if updated {
+		ctrl.generated.EventRecorder.Eventf(pool, corev1.EventTypeNormal, "HostMTUConfigApplied", "Generated MachineConfig %s from the host MTU", mc.Name)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package hostmtu
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func newMigration(to uint32) *configv1.MTUMigration {
+	return &configv1.MTUMigration{Machine: &configv1.MTUMigrationValues{To: &to}}
+}
+
+func TestValidateHostMTU(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       mcfgv1.HostMTUConfiguration
+		migration *configv1.MTUMigration
+		valid     bool
+	}{{
+		name:  "jumbo frames",
+		cfg:   mcfgv1.HostMTUConfiguration{MTU: 9000, Interfaces: []string{"ens3", "ens4.100"}},
+		valid: true,
+	}, {
+		name:      "matching migration",
+		cfg:       mcfgv1.HostMTUConfiguration{MTU: 9000, Interfaces: []string{"ens3"}},
+		migration: newMigration(9000),
+		valid:     true,
+	}, {
+		name:      "network only migration",
+		cfg:       mcfgv1.HostMTUConfiguration{MTU: 9000, Interfaces: []string{"ens3"}},
+		migration: &configv1.MTUMigration{},
+		valid:     true,
+	}, {
+		name:      "mismatching migration",
+		cfg:       mcfgv1.HostMTUConfiguration{MTU: 9000, Interfaces: []string{"ens3"}},
+		migration: newMigration(8000),
+	}, {
+		name: "too small",
+		cfg:  mcfgv1.HostMTUConfiguration{MTU: 500, Interfaces: []string{"ens3"}},
+	}, {
+		name: "too large",
+		cfg:  mcfgv1.HostMTUConfiguration{MTU: 65536, Interfaces: []string{"ens3"}},
+	}, {
+		name: "no interfaces",
+		cfg:  mcfgv1.HostMTUConfiguration{MTU: 9000},
+	}, {
+		name: "invalid interface",
+		cfg:  mcfgv1.HostMTUConfiguration{MTU: 9000, Interfaces: []string{"../ens3"}},
+	}, {
+		name: "interface name too long",
+		cfg:  mcfgv1.HostMTUConfiguration{MTU: 9000, Interfaces: []string{"enp0s20f0u1u6u3x"}},
+	}, {
+		name: "duplicate interface",
+		cfg:  mcfgv1.HostMTUConfiguration{MTU: 9000, Interfaces: []string{"ens3", "ens3"}},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateHostMTU(&test.cfg, test.migration)
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestGenerateMachineConfig(t *testing.T) {
+	pool := helpers.NewPool("infra")
+	pool.Spec.HostMTU = &mcfgv1.HostMTUConfiguration{MTU: 9000, Interfaces: []string{"ens4", "ens3"}}
+	mc, err := generateMachineConfig(pool, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "99-infra-generated-host-mtu", mc.Name)
+	assert.Equal(t, "infra", mc.Labels[mcfgv1.MachineConfigRoleLabelKey])
+	assert.Equal(t, "infra", generatedConfigKind.OwningPool(mc))
+
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	require.NoError(t, err)
+	require.Len(t, ignCfg.Storage.Files, 2)
+	assert.Equal(t, "/etc/NetworkManager/conf.d/99-ens3-mtu.conf", ignCfg.Storage.Files[0].Path)
+	conf, err := ctrlcommon.GetIgnitionFileDataByPath(&ignCfg, "/etc/NetworkManager/conf.d/99-ens4-mtu.conf")
+	require.NoError(t, err)
+	assert.Equal(t, "[connection-ens4-mtu]\nmatch-device=interface-name:ens4\nethernet.mtu=9000\n", string(conf))
+}
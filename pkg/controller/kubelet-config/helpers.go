@@ -6,12 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
 	"github.com/imdario/mergo"
 	osev1 "github.com/openshift/api/config/v1"
 	"github.com/vincent-petithory/dataurl"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -270,6 +272,106 @@ func validateUserKubeletConfig(cfg *mcfgv1.KubeletConfig) error {
 		cfg.Spec.AutoSizingReserved != nil && *cfg.Spec.AutoSizingReserved {
 		return fmt.Errorf("KubeletConfiguration: autoSizingReserved and systemdReserved cannot be set together")
 	}
+	return validateResourceManagers(kcDecoded)
+}
+
+// validateResourceManagers checks the CPU, memory and topology manager fields
+// of a user KubeletConfiguration, including the constraints between them the
+// kubelet would otherwise only report by failing to start.
+func validateResourceManagers(kc *kubeletconfigv1beta1.KubeletConfiguration) error {
+	switch kc.CPUManagerPolicy {
+	case "", "none", "static":
+	default:
+		return fmt.Errorf("KubeletConfiguration: cpuManagerPolicy must be none or static, but contains: %s", kc.CPUManagerPolicy)
+	}
+	if len(kc.CPUManagerPolicyOptions) > 0 && kc.CPUManagerPolicy != "static" {
+		return fmt.Errorf("KubeletConfiguration: cpuManagerPolicyOptions requires the static cpuManagerPolicy")
+	}
+	if kc.CPUManagerReconcilePeriod.Duration < 0 {
+		return fmt.Errorf("KubeletConfiguration: cpuManagerReconcilePeriod must not be negative, but contains: %v", kc.CPUManagerReconcilePeriod.Duration)
+	}
+	if kc.ReservedSystemCPUs != "" {
+		if err := validateCPUList(kc.ReservedSystemCPUs); err != nil {
+			return fmt.Errorf("KubeletConfiguration: reservedSystemCPUs is not valid: %v", err)
+		}
+	}
+	systemReservedCPU, err := reservedQuantity(kc.SystemReserved, "cpu")
+	if err != nil {
+		return fmt.Errorf("KubeletConfiguration: systemReserved is not valid: %v", err)
+	}
+	kubeReservedCPU, err := reservedQuantity(kc.KubeReserved, "cpu")
+	if err != nil {
+		return fmt.Errorf("KubeletConfiguration: kubeReserved is not valid: %v", err)
+	}
+	// Unless set by the user, systemReserved cpu is set to a non-zero value by the node sizing env file.
+	_, systemReservedCPUSet := kc.SystemReserved["cpu"]
+	if kc.CPUManagerPolicy == "static" && kc.ReservedSystemCPUs == "" && systemReservedCPUSet && systemReservedCPU.IsZero() && kubeReservedCPU.IsZero() {
+		return fmt.Errorf("KubeletConfiguration: the static cpuManagerPolicy requires reserved CPUs, set reservedSystemCPUs or a non-zero systemReserved or kubeReserved cpu")
+	}
+
+	switch kc.TopologyManagerPolicy {
+	case "", kubeletconfigv1beta1.NoneTopologyManagerPolicy, kubeletconfigv1beta1.BestEffortTopologyManagerPolicy,
+		kubeletconfigv1beta1.RestrictedTopologyManagerPolicy, kubeletconfigv1beta1.SingleNumaNodeTopologyManagerPolicy:
+	default:
+		return fmt.Errorf("KubeletConfiguration: topologyManagerPolicy must be none, best-effort, restricted or single-numa-node, but contains: %s", kc.TopologyManagerPolicy)
+	}
+	switch kc.TopologyManagerScope {
+	case "", kubeletconfigv1beta1.ContainerTopologyManagerScope, kubeletconfigv1beta1.PodTopologyManagerScope:
+	default:
+		return fmt.Errorf("KubeletConfiguration: topologyManagerScope must be container or pod, but contains: %s", kc.TopologyManagerScope)
+	}
+
+	switch kc.MemoryManagerPolicy {
+	case "", kubeletconfigv1beta1.NoneMemoryManagerPolicy, kubeletconfigv1beta1.StaticMemoryManagerPolicy:
+	default:
+		return fmt.Errorf("KubeletConfiguration: memoryManagerPolicy must be None or Static, but contains: %s", kc.MemoryManagerPolicy)
+	}
+	if kc.MemoryManagerPolicy == kubeletconfigv1beta1.StaticMemoryManagerPolicy && len(kc.ReservedMemory) == 0 {
+		return fmt.Errorf("KubeletConfiguration: the Static memoryManagerPolicy requires reservedMemory")
+	}
+	if len(kc.ReservedMemory) > 0 && kc.MemoryManagerPolicy != kubeletconfigv1beta1.StaticMemoryManagerPolicy {
+		return fmt.Errorf("KubeletConfiguration: reservedMemory requires the Static memoryManagerPolicy")
+	}
+	for _, r := range kc.ReservedMemory {
+		if r.NumaNode < 0 {
+			return fmt.Errorf("KubeletConfiguration: reservedMemory numaNode must not be negative, but contains: %d", r.NumaNode)
+		}
+		if len(r.Limits) == 0 {
+			return fmt.Errorf("KubeletConfiguration: reservedMemory for numaNode %d has no limits", r.NumaNode)
+		}
+	}
+	return nil
+}
+
+// reservedQuantity parses the quantity of resource in a systemReserved or
+// kubeReserved map, zero if it's not set.
+func reservedQuantity(reserved map[string]string, resourceName string) (resource.Quantity, error) {
+	value, ok := reserved[resourceName]
+	if !ok {
+		return resource.Quantity{}, nil
+	}
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("%s: %v", resourceName, err)
+	}
+	return q, nil
+}
+
+// validateCPUList checks that s is a list of CPUs in the Linux cpuset format, e.g. "0-3,8".
+func validateCPUList(s string) error {
+	for _, r := range strings.Split(s, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(r), "-", 2)
+		low, err := strconv.Atoi(bounds[0])
+		if err != nil || low < 0 {
+			return fmt.Errorf("invalid CPU %q in %q", bounds[0], s)
+		}
+		if len(bounds) == 2 {
+			high, err := strconv.Atoi(bounds[1])
+			if err != nil || high < low {
+				return fmt.Errorf("invalid CPU range %q in %q", r, s)
+			}
+		}
+	}
 	return nil
 }
 
@@ -281,6 +383,7 @@ func wrapErrorWithCondition(err error, args ...interface{}) mcfgv1.KubeletConfig
 			corev1.ConditionFalse,
 			fmt.Sprintf("Error: %v", err),
 		)
+		condition.Reason = ctrlcommon.ErrorReason(err)
 	} else {
 		condition = mcfgv1.NewKubeletConfigCondition(
 			mcfgv1.KubeletConfigSuccess,
@@ -22,8 +22,10 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformersv1 "k8s.io/client-go/informers/core/v1"
 	clientset "k8s.io/client-go/kubernetes"
 	coreclientsetv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
@@ -95,6 +97,9 @@ type Controller struct {
 	apiserverLister       oselistersv1.APIServerLister
 	apiserverListerSynced cache.InformerSynced
 
+	nodeLister       corelisterv1.NodeLister
+	nodeListerSynced cache.InformerSynced
+
 	queue        workqueue.RateLimitingInterface
 	featureQueue workqueue.RateLimitingInterface
 }
@@ -107,6 +112,7 @@ func New(
 	mkuInformer mcfginformersv1.KubeletConfigInformer,
 	featInformer oseinformersv1.FeatureGateInformer,
 	apiserverInformer oseinformersv1.APIServerInformer,
+	nodeInformer coreinformersv1.NodeInformer,
 	kubeClient clientset.Interface,
 	mcfgClient mcfgclientset.Interface,
 ) *Controller {
@@ -118,8 +124,8 @@ func New(
 		templatesDir:  templatesDir,
 		client:        mcfgClient,
 		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-kubeletconfigcontroller"}),
-		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-kubeletconfigcontroller"),
-		featureQueue:  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-featurecontroller"),
+		queue:         workqueue.NewNamedRateLimitingQueue(ctrlcommon.ControllerRateLimiter(), "machineconfigcontroller-kubeletconfigcontroller"),
+		featureQueue:  workqueue.NewNamedRateLimitingQueue(ctrlcommon.ControllerRateLimiter(), "machineconfigcontroller-featurecontroller"),
 	}
 
 	mkuInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -134,6 +140,11 @@ func New(
 		DeleteFunc: ctrl.deleteFeature,
 	})
 
+	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addNode,
+		UpdateFunc: ctrl.updateNode,
+	})
+
 	ctrl.syncHandler = ctrl.syncKubeletConfig
 	ctrl.enqueueKubeletConfig = ctrl.enqueue
 
@@ -152,6 +163,9 @@ func New(
 	ctrl.apiserverLister = apiserverInformer.Lister()
 	ctrl.apiserverListerSynced = apiserverInformer.Informer().HasSynced
 
+	ctrl.nodeLister = nodeInformer.Lister()
+	ctrl.nodeListerSynced = nodeInformer.Informer().HasSynced
+
 	return ctrl
 }
 
@@ -161,7 +175,7 @@ func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
 	defer ctrl.queue.ShutDown()
 	defer ctrl.featureQueue.ShutDown()
 
-	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.mckListerSynced, ctrl.ccListerSynced, ctrl.featListerSynced, ctrl.apiserverListerSynced) {
+	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.mckListerSynced, ctrl.ccListerSynced, ctrl.featListerSynced, ctrl.apiserverListerSynced, ctrl.nodeListerSynced) {
 		return
 	}
 
@@ -226,6 +240,51 @@ func (ctrl *Controller) deleteKubeletConfig(obj interface{}) {
 	}
 }
 
+// addNode re-validates the reservations of the KubeletConfigs applying to a
+// new node, which may be smaller than the nodes they were checked against.
+func (ctrl *Controller) addNode(obj interface{}) {
+	node := obj.(*corev1.Node)
+	glog.V(4).Infof("Adding Node %s", node.Name)
+	ctrl.enqueueKubeletConfigsForNode(node)
+}
+
+func (ctrl *Controller) updateNode(old, cur interface{}) {
+	oldNode := old.(*corev1.Node)
+	curNode := cur.(*corev1.Node)
+
+	// Only a resize or a move to another pool changes the outcome of the
+	// reservations check, so ignore the status heartbeats
+	if reflect.DeepEqual(oldNode.Status.Capacity, curNode.Status.Capacity) && reflect.DeepEqual(oldNode.Labels, curNode.Labels) {
+		return
+	}
+	glog.V(4).Infof("Update Node %s", curNode.Name)
+	ctrl.enqueueKubeletConfigsForNode(curNode)
+}
+
+// enqueueKubeletConfigsForNode enqueues the KubeletConfigs applying to a pool
+// of the node.
+func (ctrl *Controller) enqueueKubeletConfigsForNode(node *corev1.Node) {
+	cfgs, err := ctrl.mckLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't list KubeletConfigs: %v", err))
+		return
+	}
+	for _, cfg := range cfgs {
+		pools, err := ctrl.getPoolsForKubeletConfig(cfg)
+		if err != nil {
+			continue
+		}
+		for _, pool := range pools {
+			selector, err := metav1.LabelSelectorAsSelector(pool.Spec.NodeSelector)
+			if err != nil || selector.Empty() || !selector.Matches(labels.Set(node.Labels)) {
+				continue
+			}
+			ctrl.enqueueKubeletConfig(cfg)
+			break
+		}
+	}
+}
+
 func (ctrl *Controller) cascadeDelete(cfg *mcfgv1.KubeletConfig) error {
 	if len(cfg.GetFinalizers()) == 0 {
 		return nil
@@ -485,6 +544,12 @@ func (ctrl *Controller) syncKubeletConfig(key string) error {
 	if err := validateUserKubeletConfig(cfg); err != nil {
 		return ctrl.syncStatusOnly(cfg, newForgetError(err))
 	}
+	var userKubeConfig *kubeletconfigv1beta1.KubeletConfiguration
+	if cfg.Spec.KubeletConfig != nil && cfg.Spec.KubeletConfig.Raw != nil {
+		if userKubeConfig, err = decodeKubeletConfig(cfg.Spec.KubeletConfig.Raw); err != nil {
+			return ctrl.syncStatusOnly(cfg, newForgetError(err))
+		}
+	}
 
 	// Find all MachineConfigPools
 	mcpPools, err := ctrl.getPoolsForKubeletConfig(cfg)
@@ -498,6 +563,17 @@ func (ctrl *Controller) syncKubeletConfig(key string) error {
 		return ctrl.syncStatusOnly(cfg, err)
 	}
 
+	// Check the reservations against the nodes of every pool before applying
+	// the config to any of them
+	if userKubeConfig != nil {
+		for _, pool := range mcpPools {
+			if err := ctrl.validateReservationsForPool(userKubeConfig, pool); err != nil {
+				ctrl.eventRecorder.Eventf(cfg, corev1.EventTypeWarning, "InsufficientAllocatable", "Not applying to pool %s: %v", pool.Name, err)
+				return ctrl.syncStatusOnly(cfg, err, "could not apply to MachineConfigPool %s: %v", pool.Name, err)
+			}
+		}
+	}
+
 	features, err := ctrl.featLister.Get(ctrlcommon.ClusterFeatureInstanceName)
 	if macherrors.IsNotFound(err) {
 		features = createNewDefaultFeatureGate()
@@ -536,9 +612,9 @@ func (ctrl *Controller) syncKubeletConfig(key string) error {
 			return fmt.Errorf("could not get ControllerConfig %v", err)
 		}
 
-		originalKubeConfig, err := generateOriginalKubeletConfigWithFeatureGates(cc, ctrl.templatesDir, role, features)
+		originalKubeConfig, err := generateOriginalKubeletConfigWithFeatureGates(cc, ctrl.templatesDir, mtmpl.TemplateRole(pool), features)
 		if err != nil {
-			return ctrl.syncStatusOnly(cfg, err, "could not get original kubelet config: %v", err)
+			return ctrl.syncStatusOnly(cfg, &ctrlcommon.RenderError{Err: err}, "could not get original kubelet config: %v", err)
 		}
 
 		// Get the default API Server Security Profile
@@ -560,7 +636,7 @@ func (ctrl *Controller) syncKubeletConfig(key string) error {
 
 		kubeletIgnition, logLevelIgnition, autoSizingReservedIgnition, err := generateKubeletIgnFiles(cfg, originalKubeConfig, userDefinedSystemReserved)
 		if err != nil {
-			return ctrl.syncStatusOnly(cfg, err)
+			return ctrl.syncStatusOnly(cfg, &ctrlcommon.RenderError{Err: err})
 		}
 
 		if isNotFound {
@@ -639,6 +715,20 @@ func (ctrl *Controller) syncKubeletConfig(key string) error {
 	return ctrl.syncStatusOnly(cfg, nil)
 }
 
+// validateReservationsForPool checks the reservations of a user
+// KubeletConfiguration against the current nodes of a pool.
+func (ctrl *Controller) validateReservationsForPool(kc *kubeletconfigv1beta1.KubeletConfiguration, pool *mcfgv1.MachineConfigPool) error {
+	selector, err := metav1.LabelSelectorAsSelector(pool.Spec.NodeSelector)
+	if err != nil {
+		return fmt.Errorf("invalid label selector: %v", err)
+	}
+	nodes, err := ctrl.nodeLister.List(selector)
+	if err != nil {
+		return err
+	}
+	return validateReservationsForNodes(kc, nodes)
+}
+
 // cleanUpDuplicatedMC removes the MC of uncorrected version if format of its name contains 'generated-xxx'.
 // BZ 1955517: upgrade when there are more than one configs, these generated MC will be duplicated
 // by upgraded MC with number suffixed name (func getManagedKubeletConfigKey()) and fails the upgrade.
@@ -14,12 +14,14 @@ import (
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/diff"
 	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	kubeinformers "k8s.io/client-go/informers"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
@@ -54,6 +56,7 @@ type fixture struct {
 	mckLister       []*mcfgv1.KubeletConfig
 	featLister      []*osev1.FeatureGate
 	apiserverLister []*osev1.APIServer
+	nodeLister      []*corev1.Node
 
 	actions               []core.Action
 	skipActionsValidation bool
@@ -188,6 +191,7 @@ func (f *fixture) newController() *Controller {
 
 	i := informers.NewSharedInformerFactory(f.client, 0)
 	featinformer := oseinformersv1.NewSharedInformerFactory(f.oseclient, 0)
+	k8sI := kubeinformers.NewSharedInformerFactory(k8sfake.NewSimpleClientset(), 0)
 
 	c := New(templateDir,
 		i.Machineconfiguration().V1().MachineConfigPools(),
@@ -195,6 +199,7 @@ func (f *fixture) newController() *Controller {
 		i.Machineconfiguration().V1().KubeletConfigs(),
 		featinformer.Config().V1().FeatureGates(),
 		featinformer.Config().V1().APIServers(),
+		k8sI.Core().V1().Nodes(),
 		k8sfake.NewSimpleClientset(),
 		f.client,
 	)
@@ -203,6 +208,7 @@ func (f *fixture) newController() *Controller {
 	c.ccListerSynced = alwaysReady
 	c.featListerSynced = alwaysReady
 	c.apiserverListerSynced = alwaysReady
+	c.nodeListerSynced = alwaysReady
 	c.eventRecorder = &record.FakeRecorder{}
 
 	stopCh := make(chan struct{})
@@ -225,6 +231,9 @@ func (f *fixture) newController() *Controller {
 	for _, c := range f.apiserverLister {
 		featinformer.Config().V1().APIServers().Informer().GetIndexer().Add(c)
 	}
+	for _, c := range f.nodeLister {
+		k8sI.Core().V1().Nodes().Informer().GetIndexer().Add(c)
+	}
 
 	return c
 }
@@ -704,6 +713,105 @@ func TestKubeletConfigDenylistedOptions(t *testing.T) {
 	}
 }
 
+func TestKubeletConfigResourceManagers(t *testing.T) {
+	reservedMemory := []kubeletconfigv1beta1.MemoryReservation{{
+		NumaNode: 0,
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1100Mi")},
+	}}
+
+	failureTests := []struct {
+		name   string
+		config *kubeletconfigv1beta1.KubeletConfiguration
+	}{
+		{
+			name:   "unknown cpu manager policy",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{CPUManagerPolicy: "dynamic"},
+		},
+		{
+			name: "cpu manager policy options without static policy",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{
+				CPUManagerPolicyOptions: map[string]string{"full-pcpus-only": "true"},
+			},
+		},
+		{
+			name: "static cpu manager without reserved cpus",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{
+				CPUManagerPolicy: "static",
+				SystemReserved:   map[string]string{"cpu": "0"},
+			},
+		},
+		{
+			name: "invalid reserved system cpus",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{
+				CPUManagerPolicy:   "static",
+				ReservedSystemCPUs: "3-1",
+			},
+		},
+		{
+			name:   "unknown topology manager policy",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{TopologyManagerPolicy: "strict"},
+		},
+		{
+			name:   "unknown topology manager scope",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{TopologyManagerScope: "node"},
+		},
+		{
+			name:   "static memory manager without reserved memory",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{MemoryManagerPolicy: "Static"},
+		},
+		{
+			name:   "reserved memory without static memory manager",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{ReservedMemory: reservedMemory},
+		},
+	}
+
+	successTests := []struct {
+		name   string
+		config *kubeletconfigv1beta1.KubeletConfiguration
+	}{
+		{
+			name: "static cpu manager with default system reserved",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{
+				CPUManagerPolicy:        "static",
+				CPUManagerPolicyOptions: map[string]string{"full-pcpus-only": "true"},
+			},
+		},
+		{
+			name: "static cpu manager with reserved system cpus",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{
+				CPUManagerPolicy:   "static",
+				ReservedSystemCPUs: "0-1,4",
+				SystemReserved:     map[string]string{"cpu": "0"},
+			},
+		},
+		{
+			name: "single numa node topology with static memory manager",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{
+				TopologyManagerPolicy: "single-numa-node",
+				TopologyManagerScope:  "pod",
+				MemoryManagerPolicy:   "Static",
+				ReservedMemory:        reservedMemory,
+			},
+		},
+	}
+
+	for _, test := range failureTests {
+		kc := newKubeletConfig(test.name, test.config, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "", ""))
+		err := validateUserKubeletConfig(kc)
+		if err == nil {
+			t.Errorf("%s: failed", test.name)
+		}
+	}
+
+	for _, test := range successTests {
+		kc := newKubeletConfig(test.name, test.config, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "", ""))
+		err := validateUserKubeletConfig(kc)
+		if err != nil {
+			t.Errorf("%s: failed with %v. should have succeeded", test.name, err)
+		}
+	}
+}
+
 func TestKubeletFeatureExists(t *testing.T) {
 	for _, platform := range []osev1.PlatformType{osev1.AWSPlatformType, osev1.NonePlatformType, "Unrecognized"} {
 		t.Run(string(platform), func(t *testing.T) {
@@ -956,3 +1064,13 @@ func TestKubeletConfigResync(t *testing.T) {
 		})
 	}
 }
+
+func TestWrapErrorWithConditionReason(t *testing.T) {
+	cond := wrapErrorWithCondition(&ctrlcommon.RenderError{Err: fmt.Errorf("template: missing")}, "could not get original kubelet config: %v", "template: missing")
+	require.Equal(t, mcfgv1.KubeletConfigFailure, cond.Type)
+	require.Equal(t, ctrlcommon.ErrorReasonRender, cond.Reason)
+	require.Equal(t, "could not get original kubelet config: template: missing", cond.Message)
+
+	cond = wrapErrorWithCondition(nil)
+	require.Empty(t, cond.Reason)
+}
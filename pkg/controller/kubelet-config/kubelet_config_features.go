@@ -19,6 +19,7 @@ import (
 
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mtmpl "github.com/openshift/machine-config-operator/pkg/controller/template"
 	"github.com/openshift/machine-config-operator/pkg/version"
 )
 
@@ -100,7 +101,7 @@ func (ctrl *Controller) syncFeatureHandler(key string) error {
 			}
 		}
 
-		rawCfgIgn, err := generateKubeConfigIgnFromFeatures(cc, ctrl.templatesDir, role, features)
+		rawCfgIgn, err := generateKubeConfigIgnFromFeatures(cc, ctrl.templatesDir, mtmpl.TemplateRole(pool), features)
 		if err != nil {
 			return err
 		}
@@ -242,7 +243,7 @@ func RunFeatureGateBootstrap(templateDir string, features *osev1.FeatureGate, co
 
 	for _, pool := range mcpPools {
 		role := pool.Name
-		rawCfgIgn, err := generateKubeConfigIgnFromFeatures(controllerConfig, templateDir, role, features)
+		rawCfgIgn, err := generateKubeConfigIgnFromFeatures(controllerConfig, templateDir, mtmpl.TemplateRole(pool), features)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,83 @@
+package kubeletconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	kubeletconfigv1beta1 "k8s.io/kubelet/config/v1beta1"
+)
+
+// reservedResources maps the node resources the kubelet computes the
+// allocatable of to the hard eviction signal reserving more of them.
+var reservedResources = []struct {
+	name           corev1.ResourceName
+	evictionSignal string
+}{
+	{corev1.ResourceCPU, ""},
+	{corev1.ResourceMemory, "memory.available"},
+	{corev1.ResourceEphemeralStorage, "nodefs.available"},
+}
+
+// evictionThreshold returns the amount of a resource of the given capacity
+// reserved by a hard eviction threshold, which is either a quantity or a
+// percentage of the capacity.
+func evictionThreshold(value string, capacity resource.Quantity) (resource.Quantity, error) {
+	if strings.HasSuffix(value, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return resource.Quantity{}, fmt.Errorf("invalid percentage %q", value)
+		}
+		return *resource.NewQuantity(int64(float64(capacity.Value())*percent/100), capacity.Format), nil
+	}
+	return resource.ParseQuantity(value)
+}
+
+// validateReservationsForNodes checks that the systemReserved, kubeReserved
+// and evictionHard values of a user KubeletConfiguration leave some of each
+// resource allocatable on the smallest of the nodes it applies to.  A node
+// with no allocatable left can't schedule any pod, so this catches typos in
+// those values before they are rolled out.
+func validateReservationsForNodes(kc *kubeletconfigv1beta1.KubeletConfiguration, nodes []*corev1.Node) error {
+	for _, r := range reservedResources {
+		var smallest *corev1.Node
+		var capacity resource.Quantity
+		for _, node := range nodes {
+			c, ok := node.Status.Capacity[r.name]
+			if !ok || c.IsZero() {
+				continue
+			}
+			if smallest == nil || c.Cmp(capacity) < 0 {
+				smallest, capacity = node, c
+			}
+		}
+		if smallest == nil {
+			continue
+		}
+
+		reserved, err := reservedQuantity(kc.SystemReserved, string(r.name))
+		if err != nil {
+			return fmt.Errorf("KubeletConfiguration: systemReserved is not valid: %v", err)
+		}
+		kubeReserved, err := reservedQuantity(kc.KubeReserved, string(r.name))
+		if err != nil {
+			return fmt.Errorf("KubeletConfiguration: kubeReserved is not valid: %v", err)
+		}
+		reserved.Add(kubeReserved)
+		if value, ok := kc.EvictionHard[r.evictionSignal]; ok && r.evictionSignal != "" {
+			threshold, err := evictionThreshold(value, capacity)
+			if err != nil {
+				return fmt.Errorf("KubeletConfiguration: evictionHard is not valid: %s: %v", r.evictionSignal, err)
+			}
+			reserved.Add(threshold)
+		}
+
+		if reserved.Cmp(capacity) >= 0 {
+			return fmt.Errorf("KubeletConfiguration: systemReserved, kubeReserved and evictionHard reserve %s of %s, all of the capacity %s of node %s",
+				reserved.String(), r.name, capacity.String(), smallest.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package kubeletconfig
+
+import (
+	"testing"
+
+	osev1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeletconfigv1beta1 "k8s.io/kubelet/config/v1beta1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func newNodeWithCapacity(name, cpu, memory, storage string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"node-role/worker": ""}},
+		Status: corev1.NodeStatus{
+			Capacity: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse(cpu),
+				corev1.ResourceMemory:           resource.MustParse(memory),
+				corev1.ResourceEphemeralStorage: resource.MustParse(storage),
+			},
+		},
+	}
+}
+
+func TestValidateReservationsForNodes(t *testing.T) {
+	nodes := []*corev1.Node{
+		newNodeWithCapacity("big", "32", "128Gi", "1Ti"),
+		newNodeWithCapacity("small", "4", "16Gi", "120Gi"),
+	}
+
+	for _, c := range []struct {
+		name   string
+		config *kubeletconfigv1beta1.KubeletConfiguration
+		err    string
+	}{
+		{
+			name:   "no reservations",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{MaxPods: 100},
+		},
+		{
+			name: "reservations fit the smallest node",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{
+				SystemReserved: map[string]string{"cpu": "2", "memory": "8Gi"},
+				KubeReserved:   map[string]string{"cpu": "1", "memory": "4Gi"},
+				EvictionHard:   map[string]string{"memory.available": "2Gi", "nodefs.available": "10%"},
+			},
+		},
+		{
+			name: "memory in the wrong unit",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{
+				SystemReserved: map[string]string{"memory": "17G"},
+				EvictionHard:   map[string]string{"memory.available": "500Mi"},
+			},
+			err: "of memory, all of the capacity 16Gi of node small",
+		},
+		{
+			name: "cpu reserved by systemReserved and kubeReserved",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{
+				SystemReserved: map[string]string{"cpu": "3"},
+				KubeReserved:   map[string]string{"cpu": "1500m"},
+			},
+			err: "of cpu, all of the capacity 4 of node small",
+		},
+		{
+			name: "reservations equal to the capacity",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{
+				SystemReserved: map[string]string{"cpu": "3"},
+				KubeReserved:   map[string]string{"cpu": "1"},
+			},
+			err: "of cpu, all of the capacity 4 of node small",
+		},
+		{
+			name: "percentage eviction threshold",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{
+				SystemReserved: map[string]string{"ephemeral-storage": "100Gi"},
+				EvictionHard:   map[string]string{"nodefs.available": "20%"},
+			},
+			err: "of ephemeral-storage, all of the capacity 120Gi of node small",
+		},
+		{
+			name: "invalid eviction threshold",
+			config: &kubeletconfigv1beta1.KubeletConfiguration{
+				EvictionHard: map[string]string{"memory.available": "110%"},
+			},
+			err: "evictionHard is not valid: memory.available",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateReservationsForNodes(c.config, nodes)
+			if c.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), c.err)
+		})
+	}
+
+	// pools without nodes have nothing to check against
+	assert.NoError(t, validateReservationsForNodes(&kubeletconfigv1beta1.KubeletConfiguration{
+		SystemReserved: map[string]string{"memory": "1Ti"},
+	}, nil))
+}
+
+func TestKubeletConfigReservationsExceedNodeCapacity(t *testing.T) {
+	f := newFixture(t)
+
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName, osev1.AWSPlatformType)
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v0")
+	kc1 := newKubeletConfig("too-much-reserved", &kubeletconfigv1beta1.KubeletConfiguration{
+		SystemReserved: map[string]string{"memory": "32Gi"},
+	}, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "pools.operator.machineconfiguration.openshift.io/worker", ""))
+
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.mckLister = append(f.mckLister, kc1)
+	f.nodeLister = append(f.nodeLister, newNodeWithCapacity("small", "4", "16Gi", "120Gi"))
+	f.objects = append(f.objects, kc1)
+
+	// the status is updated with the error and no MachineConfig is created
+	f.expectUpdateKubeletConfig(kc1)
+
+	f.runController(getKey(kc1, t), true)
+}
+
+func TestKubeletConfigNodeChangesRevalidateReservations(t *testing.T) {
+	f := newFixture(t)
+
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v0")
+	kc1 := newKubeletConfig("reserved", &kubeletconfigv1beta1.KubeletConfiguration{
+		SystemReserved: map[string]string{"memory": "8Gi"},
+	}, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "pools.operator.machineconfiguration.openshift.io/worker", ""))
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.mckLister = append(f.mckLister, kc1)
+
+	c := f.newController()
+	var enqueued []string
+	c.enqueueKubeletConfig = func(cfg *mcfgv1.KubeletConfig) {
+		enqueued = append(enqueued, cfg.Name)
+	}
+
+	node := newNodeWithCapacity("small", "4", "16Gi", "120Gi")
+	c.addNode(node)
+	assert.Equal(t, []string{"reserved"}, enqueued)
+
+	// heartbeats don't change the outcome of the check
+	heartbeat := node.DeepCopy()
+	heartbeat.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+	c.updateNode(node, heartbeat)
+	assert.Len(t, enqueued, 1)
+
+	resized := node.DeepCopy()
+	resized.Status.Capacity[corev1.ResourceMemory] = resource.MustParse("8Gi")
+	c.updateNode(node, resized)
+	assert.Len(t, enqueued, 2)
+
+	// nodes of other pools are ignored
+	infra := newNodeWithCapacity("infra", "4", "16Gi", "120Gi")
+	infra.Labels = map[string]string{"node-role/infra": ""}
+	c.addNode(infra)
+	assert.Len(t, enqueued, 2)
+}
@@ -0,0 +1,222 @@
+package lowlatency
+
+import (
+	"fmt"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// hugePageSizesKB are the supported huge page sizes, in kB as in the sysfs
+// paths of their pools.
+var hugePageSizesKB = map[mcfgv1.HugePageSize]int{
+	mcfgv1.HugePageSize2M: 2048,
+	mcfgv1.HugePageSize1G: 1048576,
+}
+
+// hugePagesUnitTemplate allocates huge pages on a NUMA node before the
+// kubelet starts and reads the huge pages of the node.  The kernel allocates
+// as many pages as it can, so the unit fails if it fell short, e.g. of 1G
+// pages once the memory is fragmented.
+const hugePagesUnitTemplate = `[Unit]
+Description=Allocate %[1]d %[2]s huge pages on NUMA node %[3]d
+Before=kubelet.service
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/bin/sh -c 'echo %[1]d > %[4]s && test "$(cat %[4]s)" -eq %[1]d'
+
+[Install]
+WantedBy=multi-user.target
+`
+
+// generatedConfigKind describes the MachineConfigs generated from the low latency configuration of the pools.
+var generatedConfigKind = ctrlcommon.GeneratedConfigKind{
+	Suffix:        "low-latency",
+	Description:   "low latency configuration",
+	ConditionType: mcfgv1.MachineConfigPoolLowLatencyDegraded,
+	InvalidReason: "LowLatencyInvalid",
+}
+
+// validateLowLatency checks the low latency configuration of a pool on its
+// own: the tickless CPUs must be isolated, and the huge pages allocated once
+// per size and NUMA node.
+func validateLowLatency(cfg *mcfgv1.LowLatencyConfiguration) error {
+	isolated, err := ctrlcommon.ParseCPUSet(cfg.IsolatedCPUs)
+	if err != nil {
+		return fmt.Errorf("invalid isolatedCPUs: %v", err)
+	}
+	if cfg.TicklessCPUs != "" {
+		tickless, err := ctrlcommon.ParseCPUSet(cfg.TicklessCPUs)
+		if err != nil {
+			return fmt.Errorf("invalid ticklessCPUs: %v", err)
+		}
+		if !isolated.IsSuperset(tickless) {
+			return fmt.Errorf("ticklessCPUs %s aren't all isolatedCPUs %s", cfg.TicklessCPUs, cfg.IsolatedCPUs)
+		}
+	}
+	if cfg.HugePages == nil {
+		return nil
+	}
+	if cfg.HugePages.DefaultSize != "" {
+		if _, ok := hugePageSizesKB[cfg.HugePages.DefaultSize]; !ok {
+			return fmt.Errorf("invalid hugePages defaultSize %q, must be %s or %s", cfg.HugePages.DefaultSize, mcfgv1.HugePageSize2M, mcfgv1.HugePageSize1G)
+		}
+	}
+	allocated := sets.NewString()
+	for _, p := range cfg.HugePages.Pages {
+		if _, ok := hugePageSizesKB[p.Size]; !ok {
+			return fmt.Errorf("invalid huge page size %q, must be %s or %s", p.Size, mcfgv1.HugePageSize2M, mcfgv1.HugePageSize1G)
+		}
+		if p.Count < 1 {
+			return fmt.Errorf("invalid count %d of %s huge pages", p.Count, p.Size)
+		}
+		node := "all the NUMA nodes"
+		if p.NUMANode != nil {
+			if *p.NUMANode < 0 {
+				return fmt.Errorf("invalid NUMA node %d of %s huge pages", *p.NUMANode, p.Size)
+			}
+			node = fmt.Sprintf("NUMA node %d", *p.NUMANode)
+		}
+		key := fmt.Sprintf("%s huge pages on %s", p.Size, node)
+		if allocated.Has(key) {
+			return fmt.Errorf("%s are allocated twice", key)
+		}
+		allocated.Insert(key)
+	}
+	return nil
+}
+
+// kubeletReservedCPUs returns the reservedSystemCPUs of the kubelet of the
+// pool, after checking that they don't intersect the isolated CPUs: the
+// KubeletConfigs of the pool which set reservedSystemCPUs must all reserve
+// the same CPUs, and there must be at least one.
+func kubeletReservedCPUs(pool *mcfgv1.MachineConfigPool, kcs []*mcfgv1.KubeletConfig) (string, error) {
+	isolated, err := ctrlcommon.ParseCPUSet(pool.Spec.LowLatency.IsolatedCPUs)
+	if err != nil {
+		return "", err
+	}
+	var reserved string
+	var reservedCPUs sets.Int
+	for _, kc := range kcs {
+		selector, err := metav1.LabelSelectorAsSelector(kc.Spec.MachineConfigPoolSelector)
+		if err != nil || selector.Empty() || !selector.Matches(labels.Set(pool.Labels)) {
+			continue
+		}
+		value, err := ctrlcommon.ReservedSystemCPUs(kc)
+		if err != nil {
+			return "", err
+		}
+		if value == "" {
+			continue
+		}
+		cpus, err := ctrlcommon.ParseCPUSet(value)
+		if err != nil {
+			return "", fmt.Errorf("KubeletConfig %s: invalid reservedSystemCPUs: %v", kc.Name, err)
+		}
+		if reservedCPUs != nil && !cpus.Equal(reservedCPUs) {
+			return "", fmt.Errorf("KubeletConfig %s reserves CPUs %s, another KubeletConfig of the pool %s", kc.Name, value, reserved)
+		}
+		if both := cpus.Intersection(isolated); both.Len() > 0 {
+			return "", fmt.Errorf("KubeletConfig %s reserves isolated CPUs %v", kc.Name, both.List())
+		}
+		reserved, reservedCPUs = value, cpus
+	}
+	if reservedCPUs == nil {
+		return "", fmt.Errorf("no KubeletConfig of the pool sets reservedSystemCPUs, the CPUs which aren't isolated")
+	}
+	return reserved, nil
+}
+
+// kernelArguments returns the kernel arguments isolating the CPUs, moving
+// the interrupts to the reserved ones, and allocating the huge pages which
+// aren't pinned to a NUMA node.
+func kernelArguments(cfg *mcfgv1.LowLatencyConfiguration, reserved string) []string {
+	kargs := []string{
+		"isolcpus=managed_irq," + cfg.IsolatedCPUs,
+		"irqaffinity=" + reserved,
+	}
+	if cfg.TicklessCPUs != "" {
+		kargs = append(kargs, "nohz_full="+cfg.TicklessCPUs, "rcu_nocbs="+cfg.TicklessCPUs)
+	}
+	if cfg.HugePages == nil {
+		return kargs
+	}
+	if cfg.HugePages.DefaultSize != "" {
+		kargs = append(kargs, "default_hugepagesz="+string(cfg.HugePages.DefaultSize))
+	}
+	// hugepagesz registers a size, and hugepages allocates the pages of the
+	// size before it
+	var sizes []mcfgv1.HugePageSize
+	atBoot := map[mcfgv1.HugePageSize]int32{}
+	for _, p := range cfg.HugePages.Pages {
+		if _, ok := atBoot[p.Size]; !ok {
+			sizes = append(sizes, p.Size)
+			atBoot[p.Size] = 0
+		}
+		if p.NUMANode == nil {
+			atBoot[p.Size] += p.Count
+		}
+	}
+	for _, size := range sizes {
+		kargs = append(kargs, "hugepagesz="+string(size))
+		if atBoot[size] > 0 {
+			kargs = append(kargs, fmt.Sprintf("hugepages=%d", atBoot[size]))
+		}
+	}
+	return kargs
+}
+
+// hugePagesUnits returns the units allocating the huge pages pinned to a
+// NUMA node.
+func hugePagesUnits(cfg *mcfgv1.LowLatencyConfiguration) []ign3types.Unit {
+	if cfg.HugePages == nil {
+		return nil
+	}
+	var units []ign3types.Unit
+	enabled := true
+	for _, p := range cfg.HugePages.Pages {
+		if p.NUMANode == nil {
+			continue
+		}
+		path := fmt.Sprintf("/sys/devices/system/node/node%d/hugepages/hugepages-%dkB/nr_hugepages", *p.NUMANode, hugePageSizesKB[p.Size])
+		contents := fmt.Sprintf(hugePagesUnitTemplate, p.Count, p.Size, *p.NUMANode, path)
+		units = append(units, ign3types.Unit{
+			Name:     fmt.Sprintf("hugepages-allocation-%s-node%d.service", strings.ToLower(string(p.Size)), *p.NUMANode),
+			Enabled:  &enabled,
+			Contents: &contents,
+		})
+	}
+	return units
+}
+
+// generateMachineConfig renders the low latency configuration of the pool
+// into a MachineConfig for the pool's role, once the kubelet of the pool
+// reserves CPUs apart from the isolated ones.
+func generateMachineConfig(pool *mcfgv1.MachineConfigPool, kcs []*mcfgv1.KubeletConfig) (*mcfgv1.MachineConfig, error) {
+	cfg := pool.Spec.LowLatency
+	if err := validateLowLatency(cfg); err != nil {
+		return nil, err
+	}
+	reserved, err := kubeletReservedCPUs(pool, kcs)
+	if err != nil {
+		return nil, err
+	}
+
+	ignConfig := ctrlcommon.NewIgnConfig()
+	ignConfig.Systemd.Units = hugePagesUnits(cfg)
+
+	mc, err := generatedConfigKind.NewMachineConfig(pool, ignConfig)
+	if err != nil {
+		return nil, err
+	}
+	mc.Spec.KernelArguments = kernelArguments(cfg, reserved)
+	return mc, nil
+}
@@ -0,0 +1,176 @@
+package lowlatency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestValidateLowLatency(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   mcfgv1.LowLatencyConfiguration
+		valid bool
+	}{{
+		name:  "isolated CPUs",
+		cfg:   mcfgv1.LowLatencyConfiguration{IsolatedCPUs: "2-51,54-103"},
+		valid: true,
+	}, {
+		name:  "tickless CPUs",
+		cfg:   mcfgv1.LowLatencyConfiguration{IsolatedCPUs: "2-51", TicklessCPUs: "4-51"},
+		valid: true,
+	}, {
+		name: "huge pages",
+		cfg: mcfgv1.LowLatencyConfiguration{IsolatedCPUs: "2-51", HugePages: &mcfgv1.HugePagesConfiguration{
+			DefaultSize: mcfgv1.HugePageSize1G,
+			Pages: []mcfgv1.HugePagesAllocation{
+				{Size: mcfgv1.HugePageSize1G, Count: 16},
+				{Size: mcfgv1.HugePageSize1G, Count: 8, NUMANode: int32Ptr(0)},
+				{Size: mcfgv1.HugePageSize2M, Count: 1024, NUMANode: int32Ptr(0)},
+			},
+		}},
+		valid: true,
+	}, {
+		name: "invalid isolated CPUs",
+		cfg:  mcfgv1.LowLatencyConfiguration{IsolatedCPUs: "2-51;54"},
+	}, {
+		name: "tickless CPUs not isolated",
+		cfg:  mcfgv1.LowLatencyConfiguration{IsolatedCPUs: "2-51", TicklessCPUs: "0-51"},
+	}, {
+		name: "invalid default size",
+		cfg:  mcfgv1.LowLatencyConfiguration{IsolatedCPUs: "2-51", HugePages: &mcfgv1.HugePagesConfiguration{DefaultSize: "4K"}},
+	}, {
+		name: "invalid size",
+		cfg: mcfgv1.LowLatencyConfiguration{IsolatedCPUs: "2-51", HugePages: &mcfgv1.HugePagesConfiguration{
+			Pages: []mcfgv1.HugePagesAllocation{{Size: "16G", Count: 1}},
+		}},
+	}, {
+		name: "no pages",
+		cfg: mcfgv1.LowLatencyConfiguration{IsolatedCPUs: "2-51", HugePages: &mcfgv1.HugePagesConfiguration{
+			Pages: []mcfgv1.HugePagesAllocation{{Size: mcfgv1.HugePageSize2M}},
+		}},
+	}, {
+		name: "allocated twice",
+		cfg: mcfgv1.LowLatencyConfiguration{IsolatedCPUs: "2-51", HugePages: &mcfgv1.HugePagesConfiguration{
+			Pages: []mcfgv1.HugePagesAllocation{
+				{Size: mcfgv1.HugePageSize1G, Count: 8, NUMANode: int32Ptr(1)},
+				{Size: mcfgv1.HugePageSize1G, Count: 4, NUMANode: int32Ptr(1)},
+			},
+		}},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateLowLatency(&test.cfg)
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func newKubeletConfig(name, pool, raw string) *mcfgv1.KubeletConfig {
+	return &mcfgv1.KubeletConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: mcfgv1.KubeletConfigSpec{
+			MachineConfigPoolSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"pools.operator.machineconfiguration.openshift.io/" + pool: ""},
+			},
+			KubeletConfig: &runtime.RawExtension{Raw: []byte(raw)},
+		},
+	}
+}
+
+func TestKubeletReservedCPUs(t *testing.T) {
+	pool := helpers.NewPool("infra")
+	pool.Spec.LowLatency = &mcfgv1.LowLatencyConfiguration{IsolatedCPUs: "2-51,54-103"}
+	tests := []struct {
+		name     string
+		kcs      []*mcfgv1.KubeletConfig
+		reserved string
+	}{{
+		name:     "reserved CPUs",
+		kcs:      []*mcfgv1.KubeletConfig{newKubeletConfig("a", "infra", `{"reservedSystemCPUs":"0-1,52-53"}`)},
+		reserved: "0-1,52-53",
+	}, {
+		name: "other KubeletConfigs ignored",
+		kcs: []*mcfgv1.KubeletConfig{
+			newKubeletConfig("a", "infra", `{"reservedSystemCPUs":"0-1,52-53"}`),
+			newKubeletConfig("b", "infra", `{"maxPods":500}`),
+			newKubeletConfig("c", "worker", `{"reservedSystemCPUs":"2"}`),
+		},
+		reserved: "0-1,52-53",
+	}, {
+		name: "no KubeletConfig",
+	}, {
+		name: "reserved isolated CPUs",
+		kcs:  []*mcfgv1.KubeletConfig{newKubeletConfig("a", "infra", `{"reservedSystemCPUs":"0-2"}`)},
+	}, {
+		name: "conflicting KubeletConfigs",
+		kcs: []*mcfgv1.KubeletConfig{
+			newKubeletConfig("a", "infra", `{"reservedSystemCPUs":"0-1"}`),
+			newKubeletConfig("b", "infra", `{"reservedSystemCPUs":"0-1,52-53"}`),
+		},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reserved, err := kubeletReservedCPUs(pool, test.kcs)
+			if test.reserved != "" {
+				assert.NoError(t, err)
+				assert.Equal(t, test.reserved, reserved)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestGenerateMachineConfig(t *testing.T) {
+	pool := helpers.NewPool("infra")
+	pool.Spec.LowLatency = &mcfgv1.LowLatencyConfiguration{
+		IsolatedCPUs: "2-51,54-103",
+		TicklessCPUs: "4-51",
+		HugePages: &mcfgv1.HugePagesConfiguration{
+			DefaultSize: mcfgv1.HugePageSize1G,
+			Pages: []mcfgv1.HugePagesAllocation{
+				{Size: mcfgv1.HugePageSize1G, Count: 16},
+				{Size: mcfgv1.HugePageSize2M, Count: 1024, NUMANode: int32Ptr(1)},
+			},
+		},
+	}
+	kcs := []*mcfgv1.KubeletConfig{newKubeletConfig("a", "infra", `{"reservedSystemCPUs":"0-1,52-53"}`)}
+	mc, err := generateMachineConfig(pool, kcs)
+	require.NoError(t, err)
+	assert.Equal(t, "99-infra-generated-low-latency", mc.Name)
+	assert.Equal(t, "infra", mc.Labels[mcfgv1.MachineConfigRoleLabelKey])
+	assert.Equal(t, "infra", generatedConfigKind.OwningPool(mc))
+	assert.Equal(t, []string{
+		"isolcpus=managed_irq,2-51,54-103",
+		"irqaffinity=0-1,52-53",
+		"nohz_full=4-51",
+		"rcu_nocbs=4-51",
+		"default_hugepagesz=1G",
+		"hugepagesz=1G",
+		"hugepages=16",
+		"hugepagesz=2M",
+	}, mc.Spec.KernelArguments)
+
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	require.NoError(t, err)
+	require.Len(t, ignCfg.Systemd.Units, 1)
+	unit := ignCfg.Systemd.Units[0]
+	assert.Equal(t, "hugepages-allocation-2m-node1.service", unit.Name)
+	assert.Contains(t, *unit.Contents, "echo 1024 > /sys/devices/system/node/node1/hugepages/hugepages-2048kB/nr_hugepages")
+}
@@ -0,0 +1,170 @@
+package machineconfigprofile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/version"
+)
+
+const (
+	// ProfileLabelKey is set on the MachineConfigs generated for a profile to the name of the profile.
+	ProfileLabelKey = "machineconfiguration.openshift.io/machine-config-profile"
+)
+
+// bundle is the set of node settings of a profile.  The bundles are part of
+// the operator, and change with it: pools applying a profile update with the
+// operator when its bundle does.
+type bundle struct {
+	// kernelArgs are appended to the kernel arguments of the pool.
+	kernelArgs []string
+	// files are written on the nodes, by path.  The paths are distinct
+	// between the bundles so that profiles can be combined.
+	files map[string]string
+	// kubeletConfig is a KubeletConfiguration fragment, applied through a
+	// KubeletConfig as the kubelet-config controller owns kubelet.conf.
+	kubeletConfig string
+}
+
+var bundles = map[mcfgv1.ProfileType]bundle{
+	// low-latency keeps the housekeeping of the kernel off the CPUs the
+	// static CPU manager hands out exclusively to guaranteed pods.
+	mcfgv1.ProfileLowLatency: {
+		kernelArgs: []string{"skew_tick=1", "tsc=reliable", "nosoftlockup", "nmi_watchdog=0", "mce=off", "rcupdate.rcu_normal_after_boot=0"},
+		files: map[string]string{
+			"/etc/sysctl.d/90-profile-low-latency.conf": `kernel.hung_task_timeout_secs = 600
+kernel.nmi_watchdog = 0
+kernel.sched_rt_runtime_us = -1
+kernel.timer_migration = 0
+vm.stat_interval = 10
+`,
+		},
+		kubeletConfig: `{"cpuManagerPolicy":"static","cpuManagerReconcilePeriod":"5s","topologyManagerPolicy":"best-effort"}`,
+	},
+	// hardened sets the kernel defaults protectKernelDefaults makes the
+	// kubelet check, which is why they're only safe together.
+	mcfgv1.ProfileHardened: {
+		kernelArgs: []string{"slab_nomerge", "pti=on", "vsyscall=none", "page_poison=1"},
+		files: map[string]string{
+			"/etc/sysctl.d/90-profile-hardened.conf": `kernel.dmesg_restrict = 1
+kernel.kptr_restrict = 2
+kernel.unprivileged_bpf_disabled = 1
+kernel.yama.ptrace_scope = 1
+net.ipv4.conf.all.accept_redirects = 0
+net.ipv4.conf.all.send_redirects = 0
+net.ipv4.conf.default.accept_redirects = 0
+kernel.keys.root_maxbytes = 25000000
+kernel.keys.root_maxkeys = 1000000
+kernel.panic = 10
+kernel.panic_on_oops = 1
+vm.overcommit_memory = 1
+vm.panic_on_oom = 0
+`,
+			"/etc/crio/crio.conf.d/90-profile-hardened": `[crio.runtime]
+selinux = true
+add_inheritable_capabilities = false
+`,
+		},
+		kubeletConfig: `{"protectKernelDefaults":true,"streamingConnectionIdleTimeout":"5m0s"}`,
+	},
+	// edge-minimal caps the disk used by the journal, the container logs
+	// and the images on nodes with small disks.
+	mcfgv1.ProfileEdgeMinimal: {
+		files: map[string]string{
+			"/etc/systemd/journald.conf.d/90-profile-edge-minimal.conf": `[Journal]
+SystemMaxUse=500M
+RuntimeMaxUse=100M
+`,
+		},
+		kubeletConfig: `{"containerLogMaxFiles":3,"containerLogMaxSize":"10Mi","imageGCHighThresholdPercent":70,"imageGCLowThresholdPercent":50}`,
+	},
+}
+
+// generatedName returns the name of the MachineConfig generated for the profile and pool.
+func generatedName(profile, pool string) string {
+	return fmt.Sprintf("99-%s-generated-profile-%s", pool, profile)
+}
+
+// kubeletConfigName returns the name of the KubeletConfig generated for the profile.
+func kubeletConfigName(profile string) string {
+	return fmt.Sprintf("%s-profile", profile)
+}
+
+// validateProfile checks the spec of a profile.
+func validateProfile(profile *mcfgv1.MachineConfigProfile) error {
+	spec := profile.Spec
+	if spec.MachineConfigPoolSelector == nil {
+		return fmt.Errorf("machineConfigPoolSelector must be set")
+	}
+	if _, err := metav1.LabelSelectorAsSelector(spec.MachineConfigPoolSelector); err != nil {
+		return fmt.Errorf("invalid machineConfigPoolSelector: %w", err)
+	}
+	if _, ok := bundles[spec.Profile]; !ok {
+		return fmt.Errorf("invalid profile %q, must be one of %s", spec.Profile, strings.Join(profileTypes(), ", "))
+	}
+	return nil
+}
+
+func profileTypes() []string {
+	var types []string
+	for t := range bundles {
+		types = append(types, string(t))
+	}
+	sort.Strings(types)
+	return types
+}
+
+// generateMachineConfig renders the kernel arguments and files of the
+// profile's bundle into a MachineConfig for the pool's role.
+func generateMachineConfig(profile *mcfgv1.MachineConfigProfile, pool string) (*mcfgv1.MachineConfig, error) {
+	b := bundles[profile.Spec.Profile]
+
+	var paths []string
+	for path := range b.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	ignConfig := ctrlcommon.NewIgnConfig()
+	for _, path := range paths {
+		ignConfig.Storage.Files = append(ignConfig.Storage.Files, ctrlcommon.NewGeneratedFile(path, b.files[path]))
+	}
+
+	mc, err := ctrlcommon.MachineConfigFromIgnConfig(pool, generatedName(profile.Name, pool), ignConfig)
+	if err != nil {
+		return nil, err
+	}
+	mc.Spec.KernelArguments = append([]string{}, b.kernelArgs...)
+	mc.Labels[ProfileLabelKey] = profile.Name
+	mc.SetAnnotations(map[string]string{
+		ctrlcommon.GeneratedByControllerVersionAnnotationKey: version.Hash,
+	})
+	mc.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(profile, controllerKind)})
+	return mc, nil
+}
+
+// generateKubeletConfig returns the KubeletConfig applying the kubelet
+// settings of the profile's bundle to its pools, or nil if it has none.
+func generateKubeletConfig(profile *mcfgv1.MachineConfigProfile) *mcfgv1.KubeletConfig {
+	b := bundles[profile.Spec.Profile]
+	if b.kubeletConfig == "" {
+		return nil
+	}
+	return &mcfgv1.KubeletConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            kubeletConfigName(profile.Name),
+			Labels:          map[string]string{ProfileLabelKey: profile.Name},
+			Annotations:     map[string]string{ctrlcommon.GeneratedByControllerVersionAnnotationKey: version.Hash},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(profile, controllerKind)},
+		},
+		Spec: mcfgv1.KubeletConfigSpec{
+			MachineConfigPoolSelector: profile.Spec.MachineConfigPoolSelector.DeepCopy(),
+			KubeletConfig:             &runtime.RawExtension{Raw: []byte(b.kubeletConfig)},
+		},
+	}
+}
@@ -0,0 +1,494 @@
+package machineconfigprofile
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	corev1clientset "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	mcoResourceApply "github.com/openshift/machine-config-operator/lib/resourceapply"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/version"
+)
+
+const (
+	// maxRetries is the number of times a profile will be retried before it is dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// a profile is going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+)
+
+// controllerKind contains the schema.GroupVersionKind for the owner of the generated objects.
+var controllerKind = mcfgv1.SchemeGroupVersion.WithKind("MachineConfigProfile")
+
+// Controller defines the machine config profile controller, which renders
+// the bundle of each MachineConfigProfile into a MachineConfig for each of
+// its pools, and a KubeletConfig for its kubelet settings.
+type Controller struct {
+	client        mcfgclientset.Interface
+	eventRecorder record.EventRecorder
+
+	syncHandler func(key string) error
+
+	profileLister mcfglistersv1.MachineConfigProfileLister
+	mcpLister     mcfglistersv1.MachineConfigPoolLister
+	mcLister      mcfglistersv1.MachineConfigLister
+	mckLister     mcfglistersv1.KubeletConfigLister
+
+	profileListerSynced cache.InformerSynced
+	mcpListerSynced     cache.InformerSynced
+	mcListerSynced      cache.InformerSynced
+	mckListerSynced     cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+}
+
+// New returns a new machine config profile controller.
+func New(
+	profileInformer mcfginformersv1.MachineConfigProfileInformer,
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	mcInformer mcfginformersv1.MachineConfigInformer,
+	mckInformer mcfginformersv1.KubeletConfigInformer,
+	kubeClient clientset.Interface,
+	mcfgClient mcfgclientset.Interface,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(&corev1clientset.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	ctrl := &Controller{
+		client:        mcfgClient,
+		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-machineconfigprofilecontroller"}),
+		queue:         workqueue.NewNamedRateLimitingQueue(ctrlcommon.ControllerRateLimiter(), "machineconfigcontroller-machineconfigprofilecontroller"),
+	}
+
+	// A deleted profile's MachineConfigs and KubeletConfig are garbage
+	// collected through their owner reference.
+	profileInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addProfile,
+		UpdateFunc: ctrl.updateProfile,
+	})
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addPool,
+		UpdateFunc: ctrl.updatePool,
+		DeleteFunc: ctrl.deletePool,
+	})
+	mcInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: ctrl.updateMachineConfig,
+		DeleteFunc: ctrl.deleteMachineConfig,
+	})
+	mckInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addKubeletConfig,
+		UpdateFunc: ctrl.updateKubeletConfig,
+		DeleteFunc: ctrl.deleteKubeletConfig,
+	})
+
+	ctrl.syncHandler = ctrl.syncProfile
+
+	ctrl.profileLister = profileInformer.Lister()
+	ctrl.mcpLister = mcpInformer.Lister()
+	ctrl.mcLister = mcInformer.Lister()
+	ctrl.mckLister = mckInformer.Lister()
+	ctrl.profileListerSynced = profileInformer.Informer().HasSynced
+	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
+	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
+	ctrl.mckListerSynced = mckInformer.Informer().HasSynced
+
+	return ctrl
+}
+
+// Run executes the machine config profile controller
+func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer ctrl.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, ctrl.profileListerSynced, ctrl.mcpListerSynced, ctrl.mcListerSynced, ctrl.mckListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-MachineConfigProfileController")
+	defer glog.Info("Shutting down MachineConfigController-MachineConfigProfileController")
+
+	for i := 0; i < workers; i++ {
+		go wait.Until(ctrl.worker, time.Second, stopCh)
+	}
+
+	<-stopCh
+}
+
+func (ctrl *Controller) addProfile(obj interface{}) {
+	profile := obj.(*mcfgv1.MachineConfigProfile)
+	glog.V(4).Infof("Adding MachineConfigProfile %s", profile.Name)
+	ctrl.queue.Add(profile.Name)
+}
+
+func (ctrl *Controller) updateProfile(old, cur interface{}) {
+	oldProfile := old.(*mcfgv1.MachineConfigProfile)
+	curProfile := cur.(*mcfgv1.MachineConfigProfile)
+	if reflect.DeepEqual(oldProfile.Spec, curProfile.Spec) {
+		return
+	}
+	glog.V(4).Infof("Updating MachineConfigProfile %s", curProfile.Name)
+	ctrl.queue.Add(curProfile.Name)
+}
+
+// enqueueAllProfiles queues all profiles, as any of them may select a changed
+// pool or conflict with a changed KubeletConfig.
+func (ctrl *Controller) enqueueAllProfiles() {
+	profiles, err := ctrl.profileLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, profile := range profiles {
+		ctrl.queue.Add(profile.Name)
+	}
+}
+
+func (ctrl *Controller) addPool(obj interface{}) {
+	ctrl.enqueueAllProfiles()
+}
+
+func (ctrl *Controller) updatePool(old, cur interface{}) {
+	oldPool := old.(*mcfgv1.MachineConfigPool)
+	curPool := cur.(*mcfgv1.MachineConfigPool)
+	// Only the labels selecting the pool matter.
+	if reflect.DeepEqual(oldPool.Labels, curPool.Labels) {
+		return
+	}
+	ctrl.enqueueAllProfiles()
+}
+
+func (ctrl *Controller) deletePool(obj interface{}) {
+	ctrl.enqueueAllProfiles()
+}
+
+// The MachineConfigs of a profile are only watched to restore them if they're
+// changed or deleted by someone else.
+func (ctrl *Controller) updateMachineConfig(old, cur interface{}) {
+	if profile := owningProfile(cur.(metav1.Object)); profile != "" {
+		ctrl.queue.Add(profile)
+	}
+}
+
+func (ctrl *Controller) deleteMachineConfig(obj interface{}) {
+	mc, ok := obj.(*mcfgv1.MachineConfig)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		mc, ok = tombstone.Obj.(*mcfgv1.MachineConfig)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a MachineConfig %#v", obj))
+			return
+		}
+	}
+	if profile := owningProfile(mc); profile != "" {
+		ctrl.queue.Add(profile)
+	}
+}
+
+func (ctrl *Controller) addKubeletConfig(obj interface{}) {
+	ctrl.enqueueForKubeletConfig(obj.(*mcfgv1.KubeletConfig))
+}
+
+func (ctrl *Controller) updateKubeletConfig(old, cur interface{}) {
+	oldCfg := old.(*mcfgv1.KubeletConfig)
+	curCfg := cur.(*mcfgv1.KubeletConfig)
+	// The kubelet-config controller updates the status and finalizers of
+	// every KubeletConfig, which don't matter here.
+	if reflect.DeepEqual(oldCfg.Spec, curCfg.Spec) {
+		return
+	}
+	ctrl.enqueueForKubeletConfig(curCfg)
+}
+
+func (ctrl *Controller) deleteKubeletConfig(obj interface{}) {
+	cfg, ok := obj.(*mcfgv1.KubeletConfig)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		cfg, ok = tombstone.Obj.(*mcfgv1.KubeletConfig)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a KubeletConfig %#v", obj))
+			return
+		}
+	}
+	ctrl.enqueueForKubeletConfig(cfg)
+}
+
+// enqueueForKubeletConfig queues the profile a KubeletConfig was generated
+// for, or all profiles if it's another KubeletConfig they may conflict with.
+func (ctrl *Controller) enqueueForKubeletConfig(cfg *mcfgv1.KubeletConfig) {
+	if profile := owningProfile(cfg); profile != "" {
+		ctrl.queue.Add(profile)
+		return
+	}
+	ctrl.enqueueAllProfiles()
+}
+
+// owningProfile returns the name of the profile obj was generated for by
+// this controller, or "" if it wasn't.
+func owningProfile(obj metav1.Object) string {
+	ref := metav1.GetControllerOf(obj)
+	if ref == nil || ref.Kind != controllerKind.Kind {
+		return ""
+	}
+	return ref.Name
+}
+
+// worker runs a worker thread that just dequeues items, processes them, and marks them done.
+// It enforces that the syncHandler is never invoked concurrently with the same key.
+func (ctrl *Controller) worker() {
+	for ctrl.processNextWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextWorkItem() bool {
+	key, quit := ctrl.queue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	err := ctrl.syncHandler(key.(string))
+	ctrl.handleErr(err, key)
+
+	return true
+}
+
+func (ctrl *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.queue.Forget(key)
+		return
+	}
+
+	if ctrl.queue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing MachineConfigProfile %v: %v", key, err)
+		ctrl.queue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping MachineConfigProfile %q out of the queue: %v", key, err)
+	ctrl.queue.Forget(key)
+	ctrl.queue.AddAfter(key, 1*time.Minute)
+}
+
+// syncProfile renders the bundle of the profile with the given name into a
+// MachineConfig for each pool it selects and a KubeletConfig, and deletes its
+// MachineConfigs of the other pools.  A bundle is applied as a whole or not
+// at all: if its kubelet settings conflict with another KubeletConfig of the
+// pools, nothing is changed.
+// This function is not meant to be invoked concurrently with the same key.
+func (ctrl *Controller) syncProfile(name string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing MachineConfigProfile %q (%v)", name, startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing MachineConfigProfile %q (%v)", name, time.Since(startTime))
+	}()
+
+	profile, err := ctrl.profileLister.Get(name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// An invalid change leaves the settings of the last valid spec in place.
+	if err := validateProfile(profile); err != nil {
+		glog.Errorf("Invalid MachineConfigProfile %s: %v", profile.Name, err)
+		ctrl.eventRecorder.Eventf(profile, corev1.EventTypeWarning, "MachineConfigProfileInvalid", "Invalid MachineConfigProfile: %v", err)
+		return ctrl.syncStatus(profile, profile.Status.MachineConfigPools, profile.Status.ProfileVersion, "Invalid", err.Error())
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(profile.Spec.MachineConfigPoolSelector)
+	if err != nil {
+		return err
+	}
+	pools, err := ctrl.mcpLister.List(selector)
+	if err != nil {
+		return err
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Name < pools[j].Name })
+
+	kc := generateKubeletConfig(profile)
+	if kc != nil && len(pools) > 0 {
+		conflicts, err := ctrl.getConflicts(profile, pools)
+		if err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			message := "kubelet settings conflict with " + strings.Join(conflicts, ", ")
+			ctrl.eventRecorder.Eventf(profile, corev1.EventTypeWarning, "MachineConfigProfileConflict", "MachineConfigProfile %s", message)
+			return ctrl.syncStatus(profile, profile.Status.MachineConfigPools, profile.Status.ProfileVersion, "Conflict", message)
+		}
+	}
+
+	renderedPools := sets.NewString()
+	for _, pool := range pools {
+		mc, err := generateMachineConfig(profile, pool.Name)
+		if err != nil {
+			return err
+		}
+		if _, updated, err := mcoResourceApply.ApplyMachineConfig(ctrl.client.MachineconfigurationV1(), mc); err != nil {
+			return err
+		} else if updated {
+			ctrl.eventRecorder.Eventf(profile, corev1.EventTypeNormal, "MachineConfigProfileRendered", "Generated MachineConfig %s for pool %s", mc.Name, pool.Name)
+		}
+		renderedPools.Insert(pool.Name)
+	}
+
+	// A KubeletConfig matching no pool is reported as failing by the
+	// kubelet-config controller, so it only exists while there are pools.
+	if len(pools) == 0 {
+		kc = nil
+	}
+	if err := ctrl.syncKubeletConfig(profile, kc); err != nil {
+		return err
+	}
+
+	if err := ctrl.deleteStaleMachineConfigs(profile, renderedPools); err != nil {
+		return err
+	}
+	return ctrl.syncStatus(profile, renderedPools.List(), version.Hash, "", "")
+}
+
+// getConflicts returns the KubeletConfigs not generated for the profile which
+// apply to some of its pools.  The kubelet-config controller renders each
+// KubeletConfig into a whole kubelet.conf, so only one of them takes effect.
+func (ctrl *Controller) getConflicts(profile *mcfgv1.MachineConfigProfile, pools []*mcfgv1.MachineConfigPool) ([]string, error) {
+	cfgs, err := ctrl.mckLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var conflicts []string
+	for _, cfg := range cfgs {
+		if owningProfile(cfg) == profile.Name {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(cfg.Spec.MachineConfigPoolSelector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		for _, pool := range pools {
+			if selector.Matches(labels.Set(pool.Labels)) {
+				conflicts = append(conflicts, fmt.Sprintf("KubeletConfig %s of pool %s", cfg.Name, pool.Name))
+			}
+		}
+	}
+	sort.Strings(conflicts)
+	return conflicts, nil
+}
+
+// syncKubeletConfig creates or updates the KubeletConfig of the profile, or
+// deletes it if required is nil.  The metadata and status of an existing
+// KubeletConfig are left to the kubelet-config controller.
+func (ctrl *Controller) syncKubeletConfig(profile *mcfgv1.MachineConfigProfile, required *mcfgv1.KubeletConfig) error {
+	name := kubeletConfigName(profile.Name)
+	existing, err := ctrl.mckLister.Get(name)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if errors.IsNotFound(err) {
+		if required == nil {
+			return nil
+		}
+		_, err := ctrl.client.MachineconfigurationV1().KubeletConfigs().Create(context.TODO(), required, metav1.CreateOptions{})
+		return err
+	}
+
+	if owningProfile(existing) != profile.Name {
+		return fmt.Errorf("KubeletConfig %s exists and wasn't generated for MachineConfigProfile %s", name, profile.Name)
+	}
+	if required == nil {
+		glog.Infof("Deleting KubeletConfig %s of MachineConfigProfile %s", name, profile.Name)
+		err := ctrl.client.MachineconfigurationV1().KubeletConfigs().Delete(context.TODO(), name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+	if equality.Semantic.DeepEqual(existing.Spec, required.Spec) {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.Spec = required.Spec
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[ctrlcommon.GeneratedByControllerVersionAnnotationKey] = version.Hash
+	_, err = ctrl.client.MachineconfigurationV1().KubeletConfigs().Update(context.TODO(), updated, metav1.UpdateOptions{})
+	return err
+}
+
+// deleteStaleMachineConfigs deletes the MachineConfigs generated for the
+// profile for pools it no longer applies to.
+func (ctrl *Controller) deleteStaleMachineConfigs(profile *mcfgv1.MachineConfigProfile, renderedPools sets.String) error {
+	mcs, err := ctrl.mcLister.List(labels.SelectorFromSet(labels.Set{ProfileLabelKey: profile.Name}))
+	if err != nil {
+		return err
+	}
+	for _, mc := range mcs {
+		if owningProfile(mc) != profile.Name || renderedPools.Has(mc.Labels[mcfgv1.MachineConfigRoleLabelKey]) {
+			continue
+		}
+		glog.Infof("Deleting MachineConfig %s of MachineConfigProfile %s", mc.Name, profile.Name)
+		err := ctrl.client.MachineconfigurationV1().MachineConfigs().Delete(context.TODO(), mc.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncStatus records the pools the profile is applied to and the version of
+// its bundle, and sets the Degraded condition with the given reason, if any.
+func (ctrl *Controller) syncStatus(profile *mcfgv1.MachineConfigProfile, pools []string, profileVersion, reason, message string) error {
+	newStatus := profile.Status.DeepCopy()
+	newStatus.ObservedGeneration = profile.Generation
+	newStatus.MachineConfigPools = pools
+	newStatus.ProfileVersion = profileVersion
+	if reason == "" {
+		mcfgv1.SetMachineConfigProfileCondition(newStatus, *mcfgv1.NewMachineConfigProfileCondition(mcfgv1.MachineConfigProfileDegraded, corev1.ConditionFalse, "", ""))
+	} else {
+		mcfgv1.SetMachineConfigProfileCondition(newStatus, *mcfgv1.NewMachineConfigProfileCondition(mcfgv1.MachineConfigProfileDegraded, corev1.ConditionTrue, reason, message))
+	}
+	if reflect.DeepEqual(&profile.Status, newStatus) {
+		return nil
+	}
+	newProfile := profile.DeepCopy()
+	newProfile.Status = *newStatus
+	_, err := ctrl.client.MachineconfigurationV1().MachineConfigProfiles().UpdateStatus(context.TODO(), newProfile, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,168 @@
+package machineconfigprofile
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	kubeletconfigv1beta1 "k8s.io/kubelet/config/v1beta1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	"github.com/openshift/machine-config-operator/pkg/version"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+const (
+	workerPoolLabel = "pools.operator.machineconfiguration.openshift.io/worker"
+	masterPoolLabel = "pools.operator.machineconfiguration.openshift.io/master"
+)
+
+func newProfile(name string, profileType mcfgv1.ProfileType, poolLabel string) *mcfgv1.MachineConfigProfile {
+	return &mcfgv1.MachineConfigProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Generation: 1},
+		Spec: mcfgv1.MachineConfigProfileSpec{
+			MachineConfigPoolSelector: &metav1.LabelSelector{MatchLabels: map[string]string{poolLabel: ""}},
+			Profile:                   profileType,
+		},
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	assert.NoError(t, validateProfile(newProfile("rt", mcfgv1.ProfileLowLatency, workerPoolLabel)))
+
+	profile := newProfile("rt", mcfgv1.ProfileLowLatency, workerPoolLabel)
+	profile.Spec.MachineConfigPoolSelector = nil
+	assert.Error(t, validateProfile(profile))
+
+	err := validateProfile(newProfile("rt", "blog-post", workerPoolLabel))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be one of edge-minimal, hardened, low-latency")
+}
+
+// TestBundles checks that the kubelet settings of the bundles are accepted
+// by the kubelet-config controller, which refuses a few fields.
+func TestBundles(t *testing.T) {
+	for profileType, b := range bundles {
+		t.Run(string(profileType), func(t *testing.T) {
+			if b.kubeletConfig == "" {
+				return
+			}
+			kc := &kubeletconfigv1beta1.KubeletConfiguration{}
+			require.NoError(t, json.Unmarshal([]byte(b.kubeletConfig), kc))
+			assert.Empty(t, kc.CgroupDriver)
+			assert.Empty(t, kc.ClusterDNS)
+			assert.Empty(t, kc.FeatureGates)
+			assert.Empty(t, kc.StaticPodPath)
+		})
+	}
+}
+
+func TestGenerateMachineConfig(t *testing.T) {
+	mc, err := generateMachineConfig(newProfile("secure", mcfgv1.ProfileHardened, workerPoolLabel), "worker")
+	require.NoError(t, err)
+	assert.Equal(t, "99-worker-generated-profile-secure", mc.Name)
+	assert.Equal(t, "worker", mc.Labels[mcfgv1.MachineConfigRoleLabelKey])
+	assert.Equal(t, "secure", mc.Labels[ProfileLabelKey])
+	assert.Equal(t, "secure", owningProfile(mc))
+	assert.Equal(t, version.Hash, mc.Annotations[ctrlcommon.GeneratedByControllerVersionAnnotationKey])
+	assert.Contains(t, mc.Spec.KernelArguments, "slab_nomerge")
+
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	require.NoError(t, err)
+	sysctls, err := ctrlcommon.GetIgnitionFileDataByPath(&ignCfg, "/etc/sysctl.d/90-profile-hardened.conf")
+	require.NoError(t, err)
+	// the kernel defaults protectKernelDefaults checks
+	assert.Contains(t, string(sysctls), "kernel.panic = 10\n")
+	assert.Contains(t, string(sysctls), "vm.overcommit_memory = 1\n")
+	_, err = ctrlcommon.GetIgnitionFileDataByPath(&ignCfg, "/etc/crio/crio.conf.d/90-profile-hardened")
+	assert.NoError(t, err)
+}
+
+func TestGenerateKubeletConfig(t *testing.T) {
+	profile := newProfile("rt", mcfgv1.ProfileLowLatency, workerPoolLabel)
+	kc := generateKubeletConfig(profile)
+	require.NotNil(t, kc)
+	assert.Equal(t, "rt-profile", kc.Name)
+	assert.Equal(t, "rt", owningProfile(kc))
+	assert.Equal(t, profile.Spec.MachineConfigPoolSelector, kc.Spec.MachineConfigPoolSelector)
+	assert.JSONEq(t, `{"cpuManagerPolicy":"static","cpuManagerReconcilePeriod":"5s","topologyManagerPolicy":"best-effort"}`, string(kc.Spec.KubeletConfig.Raw))
+}
+
+func newController(t *testing.T, objects ...runtime.Object) (*Controller, *fake.Clientset) {
+	f := helpers.NewFakeInformers(objects...)
+	ctrl := New(f.Informers.Machineconfiguration().V1().MachineConfigProfiles(), f.Informers.Machineconfiguration().V1().MachineConfigPools(),
+		f.Informers.Machineconfiguration().V1().MachineConfigs(), f.Informers.Machineconfiguration().V1().KubeletConfigs(), f.KubeClient, f.Client)
+	ctrl.eventRecorder = &record.FakeRecorder{}
+	f.FillListers(t)
+	return ctrl, f.Client
+}
+
+func TestSyncProfile(t *testing.T) {
+	profile := newProfile("rt", mcfgv1.ProfileLowLatency, workerPoolLabel)
+	ctrl, client := newController(t, profile, helpers.NewPool("worker"), helpers.NewPool("master"))
+	require.NoError(t, ctrl.syncProfile("rt"))
+	mc, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), "99-worker-generated-profile-rt", metav1.GetOptions{})
+	require.NoError(t, err)
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), "99-master-generated-profile-rt", metav1.GetOptions{})
+	assert.Error(t, err)
+	kc, err := client.MachineconfigurationV1().KubeletConfigs().Get(context.TODO(), "rt-profile", metav1.GetOptions{})
+	require.NoError(t, err)
+	profile, err = client.MachineconfigurationV1().MachineConfigProfiles().Get(context.TODO(), "rt", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"worker"}, profile.Status.MachineConfigPools)
+	assert.Equal(t, version.Hash, profile.Status.ProfileVersion)
+	assert.Equal(t, int64(1), profile.Status.ObservedGeneration)
+	assert.Equal(t, corev1.ConditionFalse, mcfgv1.GetMachineConfigProfileCondition(profile.Status, mcfgv1.MachineConfigProfileDegraded).Status)
+
+	// moving the profile to the master pool deletes its worker MachineConfig
+	// and moves its KubeletConfig along
+	profile.Spec.MachineConfigPoolSelector.MatchLabels = map[string]string{masterPoolLabel: ""}
+	ctrl, client = newController(t, profile, helpers.NewPool("worker"), helpers.NewPool("master"), mc, kc)
+	require.NoError(t, ctrl.syncProfile("rt"))
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), "99-master-generated-profile-rt", metav1.GetOptions{})
+	assert.NoError(t, err)
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), "99-worker-generated-profile-rt", metav1.GetOptions{})
+	assert.Error(t, err)
+	kc, err = client.MachineconfigurationV1().KubeletConfigs().Get(context.TODO(), "rt-profile", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{masterPoolLabel: ""}, kc.Spec.MachineConfigPoolSelector.MatchLabels)
+
+	// selecting no pools deletes the KubeletConfig
+	profile.Spec.MachineConfigPoolSelector.MatchLabels = map[string]string{"pools.operator.machineconfiguration.openshift.io/none": ""}
+	ctrl, client = newController(t, profile, helpers.NewPool("worker"), helpers.NewPool("master"), kc)
+	require.NoError(t, ctrl.syncProfile("rt"))
+	_, err = client.MachineconfigurationV1().KubeletConfigs().Get(context.TODO(), "rt-profile", metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func TestSyncProfileConflict(t *testing.T) {
+	profile := newProfile("rt", mcfgv1.ProfileLowLatency, workerPoolLabel)
+	user := &mcfgv1.KubeletConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "max-pods"},
+		Spec: mcfgv1.KubeletConfigSpec{
+			MachineConfigPoolSelector: &metav1.LabelSelector{MatchLabels: map[string]string{workerPoolLabel: ""}},
+		},
+	}
+	ctrl, client := newController(t, profile, helpers.NewPool("worker"), user)
+	require.NoError(t, ctrl.syncProfile("rt"))
+	_, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), "99-worker-generated-profile-rt", metav1.GetOptions{})
+	assert.Error(t, err)
+	_, err = client.MachineconfigurationV1().KubeletConfigs().Get(context.TODO(), "rt-profile", metav1.GetOptions{})
+	assert.Error(t, err)
+	profile, err = client.MachineconfigurationV1().MachineConfigProfiles().Get(context.TODO(), "rt", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, profile.Status.MachineConfigPools)
+	cond := mcfgv1.GetMachineConfigProfileCondition(profile.Status, mcfgv1.MachineConfigProfileDegraded)
+	require.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "Conflict", cond.Reason)
+	assert.Equal(t, "kubelet settings conflict with KubeletConfig max-pods of pool worker", cond.Message)
+}
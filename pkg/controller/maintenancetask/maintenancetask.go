@@ -0,0 +1,171 @@
+package maintenancetask
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/vincent-petithory/dataurl"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/version"
+)
+
+const (
+	// TaskLabelKey is set on the MachineConfigs generated for a task to the name of the task.
+	TaskLabelKey = "machineconfiguration.openshift.io/maintenance-task"
+
+	scriptDir = "/usr/local/bin"
+)
+
+const serviceTemplate = `[Unit]
+Description=MaintenanceTask %[1]s
+
+[Service]
+Type=oneshot
+ExecStart=/bin/bash %[2]s
+`
+
+const timerTemplate = `[Unit]
+Description=Run MaintenanceTask %[1]s on schedule
+
+[Timer]
+OnCalendar=%[2]s
+RandomizedDelaySec=%[3]d
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`
+
+// generatedName returns the name of the MachineConfig generated for the task and pool.
+func generatedName(task, pool string) string {
+	return fmt.Sprintf("99-%s-generated-maintenance-task-%s", pool, task)
+}
+
+func unitName(task string) string {
+	return "mco-task-" + task
+}
+
+func scriptPath(task string) string {
+	return fmt.Sprintf("%s/mco-task-%s.sh", scriptDir, task)
+}
+
+// validateTask checks the spec of a task.  The schedule is only checked for
+// obvious mistakes, systemd parses it on the node.
+func validateTask(task *mcfgv1.MaintenanceTask) error {
+	spec := task.Spec
+	if spec.MachineConfigPoolSelector == nil {
+		return fmt.Errorf("machineConfigPoolSelector must be set")
+	}
+	if _, err := metav1.LabelSelectorAsSelector(spec.MachineConfigPoolSelector); err != nil {
+		return fmt.Errorf("invalid machineConfigPoolSelector: %w", err)
+	}
+	if strings.TrimSpace(spec.Schedule) == "" {
+		return fmt.Errorf("schedule must be set")
+	}
+	if strings.ContainsAny(spec.Schedule, "\n\r") {
+		return fmt.Errorf("invalid schedule %q: must be a single line", spec.Schedule)
+	}
+	if spec.RandomizedDelay != nil && spec.RandomizedDelay.Duration < 0 {
+		return fmt.Errorf("invalid randomizedDelay %v: must not be negative", spec.RandomizedDelay.Duration)
+	}
+	if strings.TrimSpace(spec.Script) == "" {
+		return fmt.Errorf("script must be set")
+	}
+	return nil
+}
+
+// renderTask renders the task into the Ignition config of the script and its
+// service and timer units.
+func renderTask(task *mcfgv1.MaintenanceTask) ign3types.Config {
+	delay := 0
+	if task.Spec.RandomizedDelay != nil {
+		delay = int(task.Spec.RandomizedDelay.Duration.Seconds())
+	}
+	service := fmt.Sprintf(serviceTemplate, task.Name, scriptPath(task.Name))
+	timer := fmt.Sprintf(timerTemplate, task.Name, task.Spec.Schedule, delay)
+
+	mode := 0755
+	overwrite := true
+	du := dataurl.New([]byte(task.Spec.Script), "text/plain")
+	du.Encoding = dataurl.EncodingASCII
+	source := du.String()
+	enabled := true
+
+	ignConfig := ctrlcommon.NewIgnConfig()
+	ignConfig.Storage.Files = []ign3types.File{{
+		Node: ign3types.Node{
+			Path:      scriptPath(task.Name),
+			Overwrite: &overwrite,
+		},
+		FileEmbedded1: ign3types.FileEmbedded1{
+			Mode: &mode,
+			Contents: ign3types.Resource{
+				Source: &source,
+			},
+		},
+	}}
+	ignConfig.Systemd.Units = []ign3types.Unit{{
+		Name:     unitName(task.Name) + ".service",
+		Contents: &service,
+	}, {
+		Name:     unitName(task.Name) + ".timer",
+		Enabled:  &enabled,
+		Contents: &timer,
+	}}
+	return ignConfig
+}
+
+// findCollisions returns the files and units of the rendered task which are
+// also defined by one of the MachineConfigs.
+func findCollisions(rendered ign3types.Config, mcs []*mcfgv1.MachineConfig) ([]string, error) {
+	paths := map[string]bool{}
+	for _, f := range rendered.Storage.Files {
+		paths[f.Path] = true
+	}
+	units := map[string]bool{}
+	for _, u := range rendered.Systemd.Units {
+		units[u.Name] = true
+	}
+
+	var collisions []string
+	for _, mc := range mcs {
+		if len(mc.Spec.Config.Raw) == 0 {
+			continue
+		}
+		ignConfig, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("parsing MachineConfig %s: %w", mc.Name, err)
+		}
+		for _, f := range ignConfig.Storage.Files {
+			if paths[f.Path] {
+				collisions = append(collisions, fmt.Sprintf("file %s of MachineConfig %s", f.Path, mc.Name))
+			}
+		}
+		for _, u := range ignConfig.Systemd.Units {
+			if units[u.Name] {
+				collisions = append(collisions, fmt.Sprintf("unit %s of MachineConfig %s", u.Name, mc.Name))
+			}
+		}
+	}
+	sort.Strings(collisions)
+	return collisions, nil
+}
+
+// generateMachineConfig renders the task into a MachineConfig for the pool's role.
+func generateMachineConfig(task *mcfgv1.MaintenanceTask, pool string) (*mcfgv1.MachineConfig, error) {
+	mc, err := ctrlcommon.MachineConfigFromIgnConfig(pool, generatedName(task.Name, pool), renderTask(task))
+	if err != nil {
+		return nil, err
+	}
+	mc.Labels[TaskLabelKey] = task.Name
+	mc.SetAnnotations(map[string]string{
+		ctrlcommon.GeneratedByControllerVersionAnnotationKey: version.Hash,
+	})
+	mc.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(task, controllerKind)})
+	return mc, nil
+}
@@ -0,0 +1,405 @@
+package maintenancetask
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	corev1clientset "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	mcoResourceApply "github.com/openshift/machine-config-operator/lib/resourceapply"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+)
+
+const (
+	// maxRetries is the number of times a task will be retried before it is dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// a task is going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+)
+
+// controllerKind contains the schema.GroupVersionKind for the owner of the generated MachineConfigs.
+var controllerKind = mcfgv1.SchemeGroupVersion.WithKind("MaintenanceTask")
+
+// Controller defines the maintenance task controller, which renders each
+// MaintenanceTask into a MachineConfig for each of its pools.
+type Controller struct {
+	client        mcfgclientset.Interface
+	eventRecorder record.EventRecorder
+
+	syncHandler func(key string) error
+
+	taskLister mcfglistersv1.MaintenanceTaskLister
+	mcpLister  mcfglistersv1.MachineConfigPoolLister
+	mcLister   mcfglistersv1.MachineConfigLister
+
+	taskListerSynced cache.InformerSynced
+	mcpListerSynced  cache.InformerSynced
+	mcListerSynced   cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+}
+
+// New returns a new maintenance task controller.
+func New(
+	taskInformer mcfginformersv1.MaintenanceTaskInformer,
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	mcInformer mcfginformersv1.MachineConfigInformer,
+	kubeClient clientset.Interface,
+	mcfgClient mcfgclientset.Interface,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(&corev1clientset.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	ctrl := &Controller{
+		client:        mcfgClient,
+		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-maintenancetaskcontroller"}),
+		queue:         workqueue.NewNamedRateLimitingQueue(ctrlcommon.ControllerRateLimiter(), "machineconfigcontroller-maintenancetaskcontroller"),
+	}
+
+	// A deleted task's MachineConfigs are garbage collected through their owner reference.
+	taskInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addTask,
+		UpdateFunc: ctrl.updateTask,
+	})
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addPool,
+		UpdateFunc: ctrl.updatePool,
+		DeleteFunc: ctrl.deletePool,
+	})
+	mcInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addMachineConfig,
+		UpdateFunc: ctrl.updateMachineConfig,
+		DeleteFunc: ctrl.deleteMachineConfig,
+	})
+
+	ctrl.syncHandler = ctrl.syncTask
+
+	ctrl.taskLister = taskInformer.Lister()
+	ctrl.mcpLister = mcpInformer.Lister()
+	ctrl.mcLister = mcInformer.Lister()
+	ctrl.taskListerSynced = taskInformer.Informer().HasSynced
+	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
+	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
+
+	return ctrl
+}
+
+// Run executes the maintenance task controller
+func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer ctrl.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, ctrl.taskListerSynced, ctrl.mcpListerSynced, ctrl.mcListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-MaintenanceTaskController")
+	defer glog.Info("Shutting down MachineConfigController-MaintenanceTaskController")
+
+	for i := 0; i < workers; i++ {
+		go wait.Until(ctrl.worker, time.Second, stopCh)
+	}
+
+	<-stopCh
+}
+
+func (ctrl *Controller) addTask(obj interface{}) {
+	task := obj.(*mcfgv1.MaintenanceTask)
+	glog.V(4).Infof("Adding MaintenanceTask %s", task.Name)
+	ctrl.queue.Add(task.Name)
+}
+
+func (ctrl *Controller) updateTask(old, cur interface{}) {
+	oldTask := old.(*mcfgv1.MaintenanceTask)
+	curTask := cur.(*mcfgv1.MaintenanceTask)
+	if reflect.DeepEqual(oldTask.Spec, curTask.Spec) {
+		return
+	}
+	glog.V(4).Infof("Updating MaintenanceTask %s", curTask.Name)
+	ctrl.queue.Add(curTask.Name)
+}
+
+// enqueueAllTasks queues all tasks, as any of them may select a changed pool
+// or collide with a changed MachineConfig.
+func (ctrl *Controller) enqueueAllTasks() {
+	tasks, err := ctrl.taskLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, task := range tasks {
+		ctrl.queue.Add(task.Name)
+	}
+}
+
+func (ctrl *Controller) addPool(obj interface{}) {
+	ctrl.enqueueAllTasks()
+}
+
+func (ctrl *Controller) updatePool(old, cur interface{}) {
+	oldPool := old.(*mcfgv1.MachineConfigPool)
+	curPool := cur.(*mcfgv1.MachineConfigPool)
+	// Only the labels selecting the pool and its MachineConfigs matter.
+	if reflect.DeepEqual(oldPool.Labels, curPool.Labels) && reflect.DeepEqual(oldPool.Spec.MachineConfigSelector, curPool.Spec.MachineConfigSelector) {
+		return
+	}
+	ctrl.enqueueAllTasks()
+}
+
+func (ctrl *Controller) deletePool(obj interface{}) {
+	ctrl.enqueueAllTasks()
+}
+
+func (ctrl *Controller) addMachineConfig(obj interface{}) {
+	ctrl.enqueueForMachineConfig(obj.(*mcfgv1.MachineConfig))
+}
+
+func (ctrl *Controller) updateMachineConfig(old, cur interface{}) {
+	ctrl.enqueueForMachineConfig(cur.(*mcfgv1.MachineConfig))
+}
+
+func (ctrl *Controller) deleteMachineConfig(obj interface{}) {
+	mc, ok := obj.(*mcfgv1.MachineConfig)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		mc, ok = tombstone.Obj.(*mcfgv1.MachineConfig)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a MachineConfig %#v", obj))
+			return
+		}
+	}
+	ctrl.enqueueForMachineConfig(mc)
+}
+
+// enqueueForMachineConfig queues the task a MachineConfig was generated for,
+// or all tasks if it's another MachineConfig the tasks may collide with.
+func (ctrl *Controller) enqueueForMachineConfig(mc *mcfgv1.MachineConfig) {
+	if isRenderedMachineConfig(mc) {
+		return
+	}
+	if task := owningTask(mc); task != "" {
+		ctrl.queue.Add(task)
+		return
+	}
+	ctrl.enqueueAllTasks()
+}
+
+// owningTask returns the name of the task mc was generated for by this
+// controller, or "" if it wasn't.
+func owningTask(mc *mcfgv1.MachineConfig) string {
+	ref := metav1.GetControllerOf(mc)
+	if ref == nil || ref.Kind != controllerKind.Kind {
+		return ""
+	}
+	return ref.Name
+}
+
+// isRenderedMachineConfig returns true if mc is the rendered config of a pool,
+// which merges all the MachineConfigs of the pool.
+func isRenderedMachineConfig(mc *mcfgv1.MachineConfig) bool {
+	ref := metav1.GetControllerOf(mc)
+	return ref != nil && ref.Kind == "MachineConfigPool" && strings.HasPrefix(mc.Name, "rendered-")
+}
+
+// worker runs a worker thread that just dequeues items, processes them, and marks them done.
+// It enforces that the syncHandler is never invoked concurrently with the same key.
+func (ctrl *Controller) worker() {
+	for ctrl.processNextWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextWorkItem() bool {
+	key, quit := ctrl.queue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	err := ctrl.syncHandler(key.(string))
+	ctrl.handleErr(err, key)
+
+	return true
+}
+
+func (ctrl *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.queue.Forget(key)
+		return
+	}
+
+	if ctrl.queue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing MaintenanceTask %v: %v", key, err)
+		ctrl.queue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping MaintenanceTask %q out of the queue: %v", key, err)
+	ctrl.queue.Forget(key)
+	ctrl.queue.AddAfter(key, 1*time.Minute)
+}
+
+// syncTask renders the task with the given name into a MachineConfig for each
+// pool it selects, unless the task collides with another MachineConfig of the
+// pool, and deletes its MachineConfigs of the other pools.
+// This function is not meant to be invoked concurrently with the same key.
+func (ctrl *Controller) syncTask(name string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing MaintenanceTask %q (%v)", name, startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing MaintenanceTask %q (%v)", name, time.Since(startTime))
+	}()
+
+	task, err := ctrl.taskLister.Get(name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// An invalid change leaves the MachineConfigs of the last valid spec in place.
+	if err := validateTask(task); err != nil {
+		glog.Errorf("Invalid MaintenanceTask %s: %v", task.Name, err)
+		ctrl.eventRecorder.Eventf(task, corev1.EventTypeWarning, "MaintenanceTaskInvalid", "Invalid MaintenanceTask: %v", err)
+		return ctrl.syncStatus(task, task.Status.MachineConfigPools, "Invalid", err.Error())
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(task.Spec.MachineConfigPoolSelector)
+	if err != nil {
+		return err
+	}
+	pools, err := ctrl.mcpLister.List(selector)
+	if err != nil {
+		return err
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Name < pools[j].Name })
+
+	rendered := renderTask(task)
+	renderedPools := sets.NewString()
+	var collisions []string
+	for _, pool := range pools {
+		poolCollisions, err := ctrl.getCollisions(task, pool, rendered)
+		if err != nil {
+			return err
+		}
+		if len(poolCollisions) > 0 {
+			collisions = append(collisions, fmt.Sprintf("pool %s: %s", pool.Name, strings.Join(poolCollisions, ", ")))
+			continue
+		}
+		mc, err := generateMachineConfig(task, pool.Name)
+		if err != nil {
+			return err
+		}
+		if _, updated, err := mcoResourceApply.ApplyMachineConfig(ctrl.client.MachineconfigurationV1(), mc); err != nil {
+			return err
+		} else if updated {
+			ctrl.eventRecorder.Eventf(task, corev1.EventTypeNormal, "MaintenanceTaskRendered", "Generated MachineConfig %s for pool %s", mc.Name, pool.Name)
+		}
+		renderedPools.Insert(pool.Name)
+	}
+
+	if err := ctrl.deleteStaleMachineConfigs(task, renderedPools); err != nil {
+		return err
+	}
+
+	if len(collisions) > 0 {
+		message := "collides with " + strings.Join(collisions, "; ")
+		ctrl.eventRecorder.Eventf(task, corev1.EventTypeWarning, "MaintenanceTaskCollision", "MaintenanceTask %s", message)
+		return ctrl.syncStatus(task, renderedPools.List(), "Collision", message)
+	}
+	return ctrl.syncStatus(task, renderedPools.List(), "", "")
+}
+
+// getCollisions returns the files and units of the rendered task also defined
+// by the other MachineConfigs of the pool.
+func (ctrl *Controller) getCollisions(task *mcfgv1.MaintenanceTask, pool *mcfgv1.MachineConfigPool, rendered ign3types.Config) ([]string, error) {
+	if pool.Spec.MachineConfigSelector == nil {
+		return nil, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(pool.Spec.MachineConfigSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid machineConfigSelector of pool %s: %w", pool.Name, err)
+	}
+	mcs, err := ctrl.mcLister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	var others []*mcfgv1.MachineConfig
+	for _, mc := range mcs {
+		if owningTask(mc) == task.Name || isRenderedMachineConfig(mc) {
+			continue
+		}
+		others = append(others, mc)
+	}
+	return findCollisions(rendered, others)
+}
+
+// deleteStaleMachineConfigs deletes the MachineConfigs generated for the task
+// for pools it's no longer rendered for.
+func (ctrl *Controller) deleteStaleMachineConfigs(task *mcfgv1.MaintenanceTask, renderedPools sets.String) error {
+	mcs, err := ctrl.mcLister.List(labels.SelectorFromSet(labels.Set{TaskLabelKey: task.Name}))
+	if err != nil {
+		return err
+	}
+	for _, mc := range mcs {
+		if owningTask(mc) != task.Name || renderedPools.Has(mc.Labels[mcfgv1.MachineConfigRoleLabelKey]) {
+			continue
+		}
+		glog.Infof("Deleting MachineConfig %s of MaintenanceTask %s", mc.Name, task.Name)
+		err := ctrl.client.MachineconfigurationV1().MachineConfigs().Delete(context.TODO(), mc.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncStatus records the pools the task is rendered for and sets the
+// Degraded condition with the given reason, if any.
+func (ctrl *Controller) syncStatus(task *mcfgv1.MaintenanceTask, pools []string, reason, message string) error {
+	newStatus := task.Status.DeepCopy()
+	newStatus.ObservedGeneration = task.Generation
+	newStatus.MachineConfigPools = pools
+	if reason == "" {
+		mcfgv1.SetMaintenanceTaskCondition(newStatus, *mcfgv1.NewMaintenanceTaskCondition(mcfgv1.MaintenanceTaskDegraded, corev1.ConditionFalse, "", ""))
+	} else {
+		mcfgv1.SetMaintenanceTaskCondition(newStatus, *mcfgv1.NewMaintenanceTaskCondition(mcfgv1.MaintenanceTaskDegraded, corev1.ConditionTrue, reason, message))
+	}
+	if reflect.DeepEqual(&task.Status, newStatus) {
+		return nil
+	}
+	newTask := task.DeepCopy()
+	newTask.Status = *newStatus
+	_, err := ctrl.client.MachineconfigurationV1().MaintenanceTasks().UpdateStatus(context.TODO(), newTask, metav1.UpdateOptions{})
+	return err
+}
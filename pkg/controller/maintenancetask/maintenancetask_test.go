@@ -0,0 +1,184 @@
+package maintenancetask
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func newTask(name string, poolLabels map[string]string) *mcfgv1.MaintenanceTask {
+	return &mcfgv1.MaintenanceTask{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Generation: 1},
+		Spec: mcfgv1.MaintenanceTaskSpec{
+			MachineConfigPoolSelector: &metav1.LabelSelector{MatchLabels: poolLabels},
+			Schedule:                  "daily",
+			RandomizedDelay:           &metav1.Duration{Duration: 30 * time.Minute},
+			Script:                    "podman image prune -af\n",
+		},
+	}
+}
+
+func TestValidateTask(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*mcfgv1.MaintenanceTask)
+		valid  bool
+	}{{
+		name:   "valid",
+		mutate: func(*mcfgv1.MaintenanceTask) {},
+		valid:  true,
+	}, {
+		name:   "no selector",
+		mutate: func(task *mcfgv1.MaintenanceTask) { task.Spec.MachineConfigPoolSelector = nil },
+	}, {
+		name: "invalid selector",
+		mutate: func(task *mcfgv1.MaintenanceTask) {
+			task.Spec.MachineConfigPoolSelector = &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "a", Operator: "Maybe"}}}
+		},
+	}, {
+		name:   "no schedule",
+		mutate: func(task *mcfgv1.MaintenanceTask) { task.Spec.Schedule = " " },
+	}, {
+		name:   "multi-line schedule",
+		mutate: func(task *mcfgv1.MaintenanceTask) { task.Spec.Schedule = "daily\nExecStart=/bin/true" },
+	}, {
+		name:   "negative delay",
+		mutate: func(task *mcfgv1.MaintenanceTask) { task.Spec.RandomizedDelay.Duration = -time.Minute },
+	}, {
+		name:   "no script",
+		mutate: func(task *mcfgv1.MaintenanceTask) { task.Spec.Script = "" },
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			task := newTask("prune-images", map[string]string{"pools.operator.machineconfiguration.openshift.io/worker": ""})
+			test.mutate(task)
+			err := validateTask(task)
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestGenerateMachineConfig(t *testing.T) {
+	task := newTask("prune-images", nil)
+	mc, err := generateMachineConfig(task, "worker")
+	require.NoError(t, err)
+	assert.Equal(t, "99-worker-generated-maintenance-task-prune-images", mc.Name)
+	assert.Equal(t, "worker", mc.Labels[mcfgv1.MachineConfigRoleLabelKey])
+	assert.Equal(t, "prune-images", mc.Labels[TaskLabelKey])
+	assert.Equal(t, "prune-images", owningTask(mc))
+
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	require.NoError(t, err)
+	script, err := ctrlcommon.GetIgnitionFileDataByPath(&ignCfg, "/usr/local/bin/mco-task-prune-images.sh")
+	require.NoError(t, err)
+	assert.Equal(t, "podman image prune -af\n", string(script))
+
+	units := map[string]ign3types.Unit{}
+	for _, unit := range ignCfg.Systemd.Units {
+		units[unit.Name] = unit
+	}
+	require.Contains(t, units, "mco-task-prune-images.service")
+	assert.Nil(t, units["mco-task-prune-images.service"].Enabled)
+	assert.Contains(t, *units["mco-task-prune-images.service"].Contents, "ExecStart=/bin/bash /usr/local/bin/mco-task-prune-images.sh\n")
+	require.Contains(t, units, "mco-task-prune-images.timer")
+	assert.True(t, *units["mco-task-prune-images.timer"].Enabled)
+	assert.Contains(t, *units["mco-task-prune-images.timer"].Contents, "OnCalendar=daily\nRandomizedDelaySec=1800\n")
+}
+
+func TestFindCollisions(t *testing.T) {
+	rendered := renderTask(newTask("prune-images", nil))
+	enabled := true
+	unitMC, err := ctrlcommon.MachineConfigFromIgnConfig("worker", "99-worker-units", ign3types.Config{
+		Ignition: ign3types.Ignition{Version: ign3types.MaxVersion.String()},
+		Systemd: ign3types.Systemd{Units: []ign3types.Unit{
+			{Name: "mco-task-prune-images.timer", Enabled: &enabled},
+			{Name: "other.service", Enabled: &enabled},
+		}},
+	})
+	require.NoError(t, err)
+	fileMC := helpers.NewMachineConfig("99-worker-files", nil, "", []ign3types.File{
+		helpers.NewIgnFile("/usr/local/bin/mco-task-prune-images.sh", "#!/bin/sh\n"),
+		helpers.NewIgnFile("/etc/other", "other"),
+	})
+
+	collisions, err := findCollisions(rendered, []*mcfgv1.MachineConfig{unitMC, fileMC})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"file /usr/local/bin/mco-task-prune-images.sh of MachineConfig 99-worker-files",
+		"unit mco-task-prune-images.timer of MachineConfig 99-worker-units",
+	}, collisions)
+
+	collisions, err = findCollisions(rendered, []*mcfgv1.MachineConfig{helpers.NewMachineConfig("99-worker-empty", nil, "", nil)})
+	require.NoError(t, err)
+	assert.Empty(t, collisions)
+}
+
+func newController(t *testing.T, objects ...runtime.Object) (*Controller, *fake.Clientset) {
+	f := helpers.NewFakeInformers(objects...)
+	ctrl := New(f.Informers.Machineconfiguration().V1().MaintenanceTasks(), f.Informers.Machineconfiguration().V1().MachineConfigPools(), f.Informers.Machineconfiguration().V1().MachineConfigs(), f.KubeClient, f.Client)
+	ctrl.eventRecorder = &record.FakeRecorder{}
+	f.FillListers(t)
+	return ctrl, f.Client
+}
+
+func TestSyncTask(t *testing.T) {
+	task := newTask("prune-images", map[string]string{"pools.operator.machineconfiguration.openshift.io/worker": ""})
+	ctrl, client := newController(t, task, helpers.NewPool("worker"), helpers.NewPool("master"))
+	require.NoError(t, ctrl.syncTask("prune-images"))
+	mc, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), "99-worker-generated-maintenance-task-prune-images", metav1.GetOptions{})
+	require.NoError(t, err)
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), "99-master-generated-maintenance-task-prune-images", metav1.GetOptions{})
+	assert.Error(t, err)
+	task, err = client.MachineconfigurationV1().MaintenanceTasks().Get(context.TODO(), "prune-images", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"worker"}, task.Status.MachineConfigPools)
+	assert.Equal(t, int64(1), task.Status.ObservedGeneration)
+	assert.Equal(t, corev1.ConditionFalse, mcfgv1.GetMaintenanceTaskCondition(task.Status, mcfgv1.MaintenanceTaskDegraded).Status)
+
+	// moving the task to the master pool deletes its worker MachineConfig
+	task.Spec.MachineConfigPoolSelector.MatchLabels = map[string]string{"pools.operator.machineconfiguration.openshift.io/master": ""}
+	ctrl, client = newController(t, task, helpers.NewPool("worker"), helpers.NewPool("master"), mc)
+	require.NoError(t, ctrl.syncTask("prune-images"))
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), "99-master-generated-maintenance-task-prune-images", metav1.GetOptions{})
+	assert.NoError(t, err)
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), "99-worker-generated-maintenance-task-prune-images", metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func TestSyncTaskCollision(t *testing.T) {
+	task := newTask("prune-images", map[string]string{"pools.operator.machineconfiguration.openshift.io/worker": ""})
+	other := helpers.NewMachineConfig("99-worker-prune", map[string]string{mcfgv1.MachineConfigRoleLabelKey: "worker"}, "", []ign3types.File{
+		helpers.NewIgnFile("/usr/local/bin/mco-task-prune-images.sh", "#!/bin/sh\n"),
+	})
+	worker := helpers.NewPool("worker")
+	worker.Spec.MachineConfigSelector = metav1.AddLabelToSelector(&metav1.LabelSelector{}, mcfgv1.MachineConfigRoleLabelKey, "worker")
+	ctrl, client := newController(t, task, worker, other)
+	require.NoError(t, ctrl.syncTask("prune-images"))
+	_, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), "99-worker-generated-maintenance-task-prune-images", metav1.GetOptions{})
+	assert.Error(t, err)
+	task, err = client.MachineconfigurationV1().MaintenanceTasks().Get(context.TODO(), "prune-images", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, task.Status.MachineConfigPools)
+	cond := mcfgv1.GetMaintenanceTaskCondition(task.Status, mcfgv1.MaintenanceTaskDegraded)
+	require.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "Collision", cond.Reason)
+	assert.Equal(t, "collides with pool worker: file /usr/local/bin/mco-task-prune-images.sh of MachineConfig 99-worker-prune", cond.Message)
+}
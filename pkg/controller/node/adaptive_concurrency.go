@@ -0,0 +1,151 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// adaptiveConcurrency is the adaptive concurrency of the rollout of a pool
+// after taking the latest node updates into account.
+type adaptiveConcurrency struct {
+	status *mcfgv1.AdaptiveConcurrencyStatus
+	// changes describes how and why the limit changed, if it did
+	changes []string
+	// next is the time until the next updating node is overdue, or 0 if none is updating
+	next time.Duration
+}
+
+// initialAdaptiveConcurrency returns the state of the pool's adaptive
+// concurrency, starting over at a single node for a new config.
+func initialAdaptiveConcurrency(pool *mcfgv1.MachineConfigPool) *mcfgv1.AdaptiveConcurrencyStatus {
+	targetConfig := pool.Spec.Configuration.Name
+	if st := pool.Status.AdaptiveConcurrency; st != nil && st.TargetConfig == targetConfig {
+		return st.DeepCopy()
+	}
+	return &mcfgv1.AdaptiveConcurrencyStatus{TargetConfig: targetConfig, Limit: 1}
+}
+
+// recordUpdateOutcome doubles the limit once as many updates as it allows
+// succeeded, and halves it when one failed.
+func recordUpdateOutcome(st *mcfgv1.AdaptiveConcurrencyStatus, success bool, maxUnavailable int) {
+	if !success {
+		st.Successes = 0
+		if st.Limit > 1 {
+			st.Limit /= 2
+		}
+		return
+	}
+	st.Successes++
+	if st.Successes >= st.Limit && int(st.Limit) < maxUnavailable {
+		st.Successes = 0
+		st.Limit *= 2
+		if int(st.Limit) > maxUnavailable {
+			st.Limit = int32(maxUnavailable)
+		}
+	}
+}
+
+// getAdaptiveConcurrency tracks the nodes of the pool updating to its target
+// config and adapts the limit to the outcome of their updates: an update
+// succeeds if the node is done and Ready within expectedNodeUpdateDuration,
+// and fails if the node is degraded or overdue.
+func getAdaptiveConcurrency(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, maxUnavailable int, now time.Time) *adaptiveConcurrency {
+	expected := pool.Spec.AdaptiveConcurrency.ExpectedNodeUpdateDuration.Duration
+	targetConfig := pool.Spec.Configuration.Name
+	st := initialAdaptiveConcurrency(pool)
+	ac := &adaptiveConcurrency{status: st}
+
+	byName := map[string]*corev1.Node{}
+	for _, node := range nodes {
+		byName[node.Name] = node
+	}
+	oldLimit := st.Limit
+	tracked := map[string]bool{}
+	var updating []mcfgv1.AdaptiveConcurrencyNode
+	for _, u := range st.UpdatingNodes {
+		tracked[u.Name] = true
+		node, ok := byName[u.Name]
+		took := now.Sub(u.Since.Time)
+		switch {
+		case !ok || node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] != targetConfig:
+			// the node left the pool, forget about it
+		case isNodeMCDFailing(node):
+			recordUpdateOutcome(st, false, maxUnavailable)
+			ac.changes = append(ac.changes, fmt.Sprintf("node %s failed to update", u.Name))
+		case isNodeDoneAt(node, targetConfig) && isNodeReady(node):
+			if u.Overdue {
+				// already counted as a failure
+				continue
+			}
+			recordUpdateOutcome(st, took <= expected, maxUnavailable)
+			if took > expected {
+				ac.changes = append(ac.changes, fmt.Sprintf("node %s took %s to update", u.Name, took.Round(time.Second)))
+			}
+		case u.Overdue:
+			// The node keeps counting against the limit while it's unavailable,
+			// but it's only counted as a failure once.
+			updating = append(updating, u)
+		case took > expected:
+			recordUpdateOutcome(st, false, maxUnavailable)
+			ac.changes = append(ac.changes, fmt.Sprintf("node %s is still updating after %s", u.Name, took.Round(time.Second)))
+			u.Overdue = true
+			updating = append(updating, u)
+		default:
+			updating = append(updating, u)
+			if remaining := expected - took; ac.next == 0 || remaining < ac.next {
+				ac.next = remaining
+			}
+		}
+	}
+	if st.Limit == oldLimit {
+		ac.changes = nil
+	}
+
+	// Start tracking the nodes targeted since the last sync.
+	for _, node := range nodes {
+		if tracked[node.Name] || !isUpdatingTo(node, targetConfig) {
+			continue
+		}
+		updating = append(updating, mcfgv1.AdaptiveConcurrencyNode{Name: node.Name, Since: metav1.NewTime(now)})
+		if ac.next == 0 || expected < ac.next {
+			ac.next = expected
+		}
+	}
+	st.UpdatingNodes = updating
+	return ac
+}
+
+// syncAdaptiveConcurrency updates the adaptive concurrency of the pool,
+// requeues the pool for when the next updating node is overdue, and returns
+// the number of nodes of the pool that may be unavailable.
+func (ctrl *Controller) syncAdaptiveConcurrency(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, maxUnavailable int, now time.Time) int {
+	if pool.Spec.AdaptiveConcurrency == nil {
+		pool.Status.AdaptiveConcurrency = nil
+		return maxUnavailable
+	}
+	ac := getAdaptiveConcurrency(pool, nodes, maxUnavailable, now)
+	if old := pool.Status.AdaptiveConcurrency; old != nil && old.TargetConfig == ac.status.TargetConfig && old.Limit != ac.status.Limit {
+		reason, eventType := "AdaptiveConcurrencyIncreased", corev1.EventTypeNormal
+		msg := fmt.Sprintf("Updating up to %d nodes at once, after %d updates succeeded in time", ac.status.Limit, old.Limit)
+		if ac.status.Limit < old.Limit {
+			reason, eventType = "AdaptiveConcurrencyDecreased", corev1.EventTypeWarning
+			msg = fmt.Sprintf("Updating up to %d nodes at once: %s", ac.status.Limit, strings.Join(ac.changes, ", "))
+		}
+		ctrl.logPool(pool, "%s", msg)
+		ctrl.eventRecorder.Event(pool, eventType, reason, msg)
+	}
+	pool.Status.AdaptiveConcurrency = ac.status
+	if ac.next > 0 {
+		ctrl.enqueueAfter(pool, ac.next)
+	}
+	if int(ac.status.Limit) < maxUnavailable {
+		return int(ac.status.Limit)
+	}
+	return maxUnavailable
+}
@@ -0,0 +1,179 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestRecordUpdateOutcome(t *testing.T) {
+	st := &mcfgv1.AdaptiveConcurrencyStatus{Limit: 1}
+	var limits []int32
+	for _, success := range []bool{true, true, true, true, true, true, false, true, true, false, false, false} {
+		recordUpdateOutcome(st, success, 6)
+		limits = append(limits, st.Limit)
+	}
+	// doubles after a window of successes, up to maxUnavailable, and halves on failures
+	assert.Equal(t, []int32{2, 2, 4, 4, 4, 4, 2, 2, 4, 2, 1, 1}, limits)
+
+	st = &mcfgv1.AdaptiveConcurrencyStatus{Limit: 4, Successes: 3}
+	recordUpdateOutcome(st, true, 6)
+	assert.Equal(t, int32(6), st.Limit)
+}
+
+func TestGetAdaptiveConcurrency(t *testing.T) {
+	now := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	since := func(ago time.Duration) metav1.Time { return metav1.NewTime(now.Add(-ago)) }
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	pool.Spec.AdaptiveConcurrency = &mcfgv1.AdaptiveConcurrencyPolicy{ExpectedNodeUpdateDuration: metav1.Duration{Duration: 15 * time.Minute}}
+
+	// a new config starts over at a single node, and tracks the updating nodes
+	pool.Status.AdaptiveConcurrency = &mcfgv1.AdaptiveConcurrencyStatus{TargetConfig: "v0", Limit: 8}
+	nodes := []*corev1.Node{
+		newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateWorking),
+		newNodeWithReadyAndDaemonState("node-1", "v0", "v0", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDone),
+	}
+	ac := getAdaptiveConcurrency(pool, nodes, 10, now)
+	assert.Equal(t, &mcfgv1.AdaptiveConcurrencyStatus{
+		TargetConfig:  "v1",
+		Limit:         1,
+		UpdatingNodes: []mcfgv1.AdaptiveConcurrencyNode{{Name: "node-0", Since: metav1.NewTime(now)}},
+	}, ac.status)
+	assert.Equal(t, 15*time.Minute, ac.next)
+
+	tests := []struct {
+		name     string
+		node     *corev1.Node
+		since    time.Duration
+		limit    int32
+		updating bool
+		next     time.Duration
+		changed  bool
+	}{{
+		name:     "still updating",
+		node:     newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionFalse, daemonconsts.MachineConfigDaemonStateWorking),
+		since:    10 * time.Minute,
+		limit:    2,
+		updating: true,
+		next:     5 * time.Minute,
+	}, {
+		name:  "done in time",
+		node:  newNodeWithReadyAndDaemonState("node-0", "v1", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDone),
+		since: 10 * time.Minute,
+		limit: 4,
+	}, {
+		name:    "done late",
+		node:    newNodeWithReadyAndDaemonState("node-0", "v1", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDone),
+		since:   20 * time.Minute,
+		limit:   1,
+		changed: true,
+	}, {
+		name:     "overdue",
+		node:     newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionFalse, daemonconsts.MachineConfigDaemonStateWorking),
+		since:    20 * time.Minute,
+		limit:    1,
+		updating: true,
+		changed:  true,
+	}, {
+		name:    "degraded",
+		node:    newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDegraded),
+		since:   time.Minute,
+		limit:   1,
+		changed: true,
+	}, {
+		name:  "retargeted",
+		node:  newNodeWithReadyAndDaemonState("node-0", "v0", "v0", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDone),
+		since: 20 * time.Minute,
+		limit: 2,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool.Status.AdaptiveConcurrency = &mcfgv1.AdaptiveConcurrencyStatus{
+				TargetConfig:  "v1",
+				Limit:         2,
+				Successes:     1,
+				UpdatingNodes: []mcfgv1.AdaptiveConcurrencyNode{{Name: "node-0", Since: since(test.since)}},
+			}
+			ac := getAdaptiveConcurrency(pool, []*corev1.Node{test.node}, 10, now)
+			assert.Equal(t, test.limit, ac.status.Limit)
+			assert.Equal(t, test.updating, len(ac.status.UpdatingNodes) == 1)
+			assert.Equal(t, test.next, ac.next)
+			assert.Equal(t, test.changed, len(ac.changes) > 0, ac.changes)
+		})
+	}
+
+	// an overdue node stays tracked, but is counted as a failure only once
+	pool.Status.AdaptiveConcurrency = &mcfgv1.AdaptiveConcurrencyStatus{
+		TargetConfig:  "v1",
+		Limit:         4,
+		UpdatingNodes: []mcfgv1.AdaptiveConcurrencyNode{{Name: "node-0", Since: since(20 * time.Minute)}},
+	}
+	nodes = []*corev1.Node{newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionFalse, daemonconsts.MachineConfigDaemonStateWorking)}
+	ac = getAdaptiveConcurrency(pool, nodes, 10, now)
+	require.Equal(t, int32(2), ac.status.Limit)
+	pool.Status.AdaptiveConcurrency = ac.status
+	ac = getAdaptiveConcurrency(pool, nodes, 10, now.Add(time.Minute))
+	assert.Equal(t, int32(2), ac.status.Limit)
+	assert.Equal(t, []mcfgv1.AdaptiveConcurrencyNode{{Name: "node-0", Since: since(20 * time.Minute), Overdue: true}}, ac.status.UpdatingNodes)
+	assert.Zero(t, ac.next)
+	pool.Status.AdaptiveConcurrency = ac.status
+	nodes[0] = newNodeWithReadyAndDaemonState("node-0", "v1", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDone)
+	ac = getAdaptiveConcurrency(pool, nodes, 10, now.Add(time.Hour))
+	assert.Equal(t, int32(2), ac.status.Limit)
+	assert.Empty(t, ac.status.UpdatingNodes)
+}
+
+func TestSyncAdaptiveConcurrency(t *testing.T) {
+	ctrl := newFixture(t).newController()
+	now := time.Now()
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	pool.Spec.AdaptiveConcurrency = &mcfgv1.AdaptiveConcurrencyPolicy{ExpectedNodeUpdateDuration: metav1.Duration{Duration: 15 * time.Minute}}
+	nodes := []*corev1.Node{newNodeWithReadyAndDaemonState("node-0", "v1", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDone)}
+	pool.Status.AdaptiveConcurrency = &mcfgv1.AdaptiveConcurrencyStatus{
+		TargetConfig:  "v1",
+		Limit:         2,
+		Successes:     1,
+		UpdatingNodes: []mcfgv1.AdaptiveConcurrencyNode{{Name: "node-0", Since: metav1.NewTime(now.Add(-time.Minute))}},
+	}
+
+	assert.Equal(t, 3, ctrl.syncAdaptiveConcurrency(pool, nodes, 3, now))
+	assert.Equal(t, int32(3), pool.Status.AdaptiveConcurrency.Limit)
+	// the state is kept in the status of the pool
+	assert.Equal(t, pool.Status.AdaptiveConcurrency, calculateStatus(pool, nodes).AdaptiveConcurrency)
+
+	// and cleared once disabled
+	pool.Spec.AdaptiveConcurrency = nil
+	assert.Nil(t, calculateStatus(pool, nodes).AdaptiveConcurrency)
+	assert.Equal(t, 3, ctrl.syncAdaptiveConcurrency(pool, nodes, 3, now))
+	assert.Nil(t, pool.Status.AdaptiveConcurrency)
+}
+
+func TestSimulateRolloutAdaptiveConcurrency(t *testing.T) {
+	pools := []*mcfgv1.MachineConfigPool{helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")}
+	pools[0].Spec.AdaptiveConcurrency = &mcfgv1.AdaptiveConcurrencyPolicy{ExpectedNodeUpdateDuration: metav1.Duration{Duration: 15 * time.Minute}}
+	var nodes []*corev1.Node
+	for _, name := range []string{"worker-0", "worker-1", "worker-2", "worker-3", "worker-4", "worker-5", "worker-6"} {
+		node := newNodeWithReadyAndDaemonState(name, "v0", "v0", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDone)
+		node.Labels = map[string]string{"node-role/worker": ""}
+		nodes = append(nodes, node)
+	}
+	maxUnavailable := intstrutil.FromString("50%")
+
+	plan, err := SimulateRollout("worker", pools, nodes, &maxUnavailable, 10*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"worker-0"}, {"worker-1", "worker-2"}, {"worker-3", "worker-4", "worker-5"}, {"worker-6"}}, plan.Batches)
+
+	// updates taking longer than expected never ramp up
+	plan, err = SimulateRollout("worker", pools, nodes, &maxUnavailable, 20*time.Minute)
+	require.NoError(t, err)
+	assert.Len(t, plan.Batches, 7)
+}
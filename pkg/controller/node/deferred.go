@@ -0,0 +1,144 @@
+package node
+
+import (
+	"fmt"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The reasons the update of a node, and so its reboot, is deferred.
+const (
+	deferredReasonPaused         = "Paused"
+	deferredReasonClusterFrozen  = "ClusterFrozen"
+	deferredReasonMaxUnavailable = "MaxUnavailable"
+)
+
+var deferredReasons = []string{deferredReasonPaused, deferredReasonClusterFrozen, deferredReasonMaxUnavailable}
+
+// deferredIndefinitely is the start of a deferred update that has no estimate,
+// e.g. because the pool is paused.
+const deferredIndefinitely = time.Duration(-1)
+
+// deferredUpdates are the nodes of a pool pending an update to its target
+// config that the node controller holds back.
+type deferredUpdates struct {
+	reason  string
+	message string
+	// starts are the estimated times from now at which the deferred nodes start updating
+	starts map[string]time.Duration
+}
+
+// getDeferredUpdates returns the nodes of the pool whose update is deferred,
+// or nil if none is.  Nodes that are already targeted or failing aren't
+// deferred.  The starts are estimated from a simulated rollout assuming every
+// node takes DefaultNodeUpdateDuration.
+func getDeferredUpdates(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, freeze *ctrlcommon.ClusterFreeze) (*deferredUpdates, error) {
+	targetConfig := pool.Spec.Configuration.Name
+	if targetConfig == "" {
+		return nil, nil
+	}
+	var pending []*corev1.Node
+	for _, node := range nodes {
+		if !isNodeManaged(node) || isNodeMCDFailing(node) {
+			continue
+		}
+		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == targetConfig || node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] == targetConfig {
+			continue
+		}
+		pending = append(pending, node)
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	deferred := &deferredUpdates{starts: map[string]time.Duration{}}
+	switch {
+	case pool.Spec.Paused:
+		deferred.reason = deferredReasonPaused
+		deferred.message = fmt.Sprintf("%d nodes will not update to %s until the pool is unpaused", len(pending), targetConfig)
+		for _, node := range pending {
+			deferred.starts[node.Name] = deferredIndefinitely
+		}
+	case freeze != nil:
+		deferred.reason = deferredReasonClusterFrozen
+		deferred.message = fmt.Sprintf("%d nodes will not update to %s: %v", len(pending), targetConfig, freeze)
+		for _, node := range pending {
+			deferred.starts[node.Name] = deferredIndefinitely
+		}
+	default:
+		plan, err := SimulateRollout(pool.Name, []*mcfgv1.MachineConfigPool{pool}, nodes, nil, DefaultNodeUpdateDuration)
+		if err != nil {
+			return nil, err
+		}
+		starts := map[string]time.Duration{}
+		for i, batch := range plan.Batches {
+			for _, name := range batch {
+				starts[name] = plan.BatchStarts[i]
+			}
+		}
+		earliest := deferredIndefinitely
+		for _, node := range pending {
+			start, ok := starts[node.Name]
+			if ok && start == 0 {
+				// targeted right away
+				continue
+			}
+			if !ok {
+				start = deferredIndefinitely
+			} else if earliest == deferredIndefinitely || start < earliest {
+				earliest = start
+			}
+			deferred.starts[node.Name] = start
+		}
+		if len(deferred.starts) == 0 {
+			return nil, nil
+		}
+		deferred.reason = deferredReasonMaxUnavailable
+		if earliest == deferredIndefinitely {
+			deferred.message = fmt.Sprintf("%d nodes are waiting for maxUnavailable to update to %s, with no estimate as unavailable nodes use up the budget", len(deferred.starts), targetConfig)
+		} else {
+			deferred.message = fmt.Sprintf("%d nodes are waiting for maxUnavailable to update to %s, the earliest is expected to start in %s", len(deferred.starts), targetConfig, earliest)
+		}
+	}
+	return deferred, nil
+}
+
+// setRebootDeferredCondition reports the deferred updates, if any, in the RebootDeferred condition.
+func setRebootDeferredCondition(status *mcfgv1.MachineConfigPoolStatus, deferred *deferredUpdates) {
+	if deferred == nil {
+		cond := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRebootDeferred, corev1.ConditionFalse, "", "")
+		mcfgv1.SetMachineConfigPoolCondition(status, *cond)
+		return
+	}
+	cond := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRebootDeferred, corev1.ConditionTrue, deferred.reason, deferred.message)
+	mcfgv1.SetMachineConfigPoolCondition(status, *cond)
+}
+
+// setDeferredRebootMetrics reports the deferred updates of the pool, with the
+// estimated starts converted to timestamps relative to now.
+func setDeferredRebootMetrics(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, deferred *deferredUpdates, now time.Time) {
+	counts := map[string]int{}
+	for _, node := range nodes {
+		start, ok := time.Duration(0), false
+		if deferred != nil {
+			start, ok = deferred.starts[node.Name]
+		}
+		if !ok {
+			ctrlcommon.MachineConfigControllerNodeRebootDeferred.DeleteLabelValues(pool.Name, node.Name)
+			continue
+		}
+		counts[deferred.reason]++
+		var timestamp float64
+		if start != deferredIndefinitely {
+			timestamp = float64(now.Add(start).UTC().Unix())
+		}
+		ctrlcommon.MachineConfigControllerNodeRebootDeferred.WithLabelValues(pool.Name, node.Name).Set(timestamp)
+	}
+	for _, reason := range deferredReasons {
+		ctrlcommon.MachineConfigControllerPoolRebootsDeferred.WithLabelValues(pool.Name, reason).Set(float64(counts[reason]))
+	}
+}
@@ -0,0 +1,92 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestGetDeferredUpdates(t *testing.T) {
+	workerNode := func(name, current, desired, state string) *corev1.Node {
+		node := newNodeWithReadyAndDaemonState(name, current, desired, corev1.ConditionTrue, state)
+		node.Labels = map[string]string{"node-role/worker": ""}
+		return node
+	}
+	nodes := []*corev1.Node{
+		workerNode("worker-0", "v1", "v1", daemonconsts.MachineConfigDaemonStateDone),
+		workerNode("worker-1", "v0", "v1", daemonconsts.MachineConfigDaemonStateWorking),
+		workerNode("worker-2", "v0", "v0", daemonconsts.MachineConfigDaemonStateDone),
+		workerNode("worker-3", "v0", "v0", daemonconsts.MachineConfigDaemonStateDone),
+	}
+
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	deferred, err := getDeferredUpdates(pool, nodes, nil)
+	require.NoError(t, err)
+	assert.Equal(t, deferredReasonMaxUnavailable, deferred.reason)
+	assert.Equal(t, map[string]time.Duration{"worker-2": 10 * time.Minute, "worker-3": 20 * time.Minute}, deferred.starts)
+	assert.Contains(t, deferred.message, "expected to start in 10m0s")
+
+	// the budget allows updating worker-2 right away
+	pool.Spec.MaxUnavailable = intStrPtr(intstr.FromInt(2))
+	deferred, err = getDeferredUpdates(pool, nodes, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{"worker-3": 10 * time.Minute}, deferred.starts)
+
+	// a degraded node uses up the budget, there's no estimate
+	pool.Spec.MaxUnavailable = nil
+	degraded := append([]*corev1.Node{}, nodes...)
+	degraded[1] = workerNode("worker-1", "v0", "v1", daemonconsts.MachineConfigDaemonStateDegraded)
+	deferred, err = getDeferredUpdates(pool, degraded, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{"worker-2": deferredIndefinitely, "worker-3": deferredIndefinitely}, deferred.starts)
+	assert.Contains(t, deferred.message, "no estimate")
+
+	deferred, err = getDeferredUpdates(pool, nodes, &ctrlcommon.ClusterFreeze{Reason: "incident 1234"})
+	require.NoError(t, err)
+	assert.Equal(t, deferredReasonClusterFrozen, deferred.reason)
+	assert.Contains(t, deferred.message, "incident 1234")
+	assert.Equal(t, map[string]time.Duration{"worker-2": deferredIndefinitely, "worker-3": deferredIndefinitely}, deferred.starts)
+
+	pool.Spec.Paused = true
+	deferred, err = getDeferredUpdates(pool, nodes, nil)
+	require.NoError(t, err)
+	assert.Equal(t, deferredReasonPaused, deferred.reason)
+	assert.Len(t, deferred.starts, 2)
+
+	status := mcfgv1.MachineConfigPoolStatus{}
+	setRebootDeferredCondition(&status, deferred)
+	assert.True(t, mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolRebootDeferred))
+
+	now := time.Unix(1700000000, 0)
+	setDeferredRebootMetrics(pool, nodes, deferred, now)
+	assert.Equal(t, float64(2), testutil.ToFloat64(ctrlcommon.MachineConfigControllerPoolRebootsDeferred.WithLabelValues("worker", deferredReasonPaused)))
+	assert.Equal(t, float64(0), testutil.ToFloat64(ctrlcommon.MachineConfigControllerNodeRebootDeferred.WithLabelValues("worker", "worker-2")))
+
+	pool.Spec.Paused = false
+	deferred, err = getDeferredUpdates(pool, nodes, nil)
+	require.NoError(t, err)
+	setDeferredRebootMetrics(pool, nodes, deferred, now)
+	assert.Equal(t, float64(0), testutil.ToFloat64(ctrlcommon.MachineConfigControllerPoolRebootsDeferred.WithLabelValues("worker", deferredReasonPaused)))
+	assert.Equal(t, float64(2), testutil.ToFloat64(ctrlcommon.MachineConfigControllerPoolRebootsDeferred.WithLabelValues("worker", deferredReasonMaxUnavailable)))
+	assert.Equal(t, float64(now.Add(20*time.Minute).Unix()), testutil.ToFloat64(ctrlcommon.MachineConfigControllerNodeRebootDeferred.WithLabelValues("worker", "worker-3")))
+
+	// nothing is deferred once all the nodes are targeted
+	for _, node := range nodes {
+		node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] = "v1"
+	}
+	deferred, err = getDeferredUpdates(pool, nodes, nil)
+	require.NoError(t, err)
+	assert.Nil(t, deferred)
+	setRebootDeferredCondition(&status, deferred)
+	assert.True(t, mcfgv1.IsMachineConfigPoolConditionFalse(status.Conditions, mcfgv1.MachineConfigPoolRebootDeferred))
+}
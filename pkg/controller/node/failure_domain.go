@@ -0,0 +1,69 @@
+package node
+
+import (
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// maxUnavailablePerFailureDomain returns how many nodes of each failure domain
+// of the pool can be unavailable at once, computed from the size of the domain
+// like maxUnavailable is from the size of the pool.
+func maxUnavailablePerFailureDomain(policy *mcfgv1.FailureDomainPolicy, nodes []*corev1.Node) (map[string]int, error) {
+	domainSizes := map[string]int{}
+	for _, node := range nodes {
+		domainSizes[node.Labels[policy.LabelKey]]++
+	}
+	intOrPercent := intstrutil.FromInt(1)
+	if policy.MaxUnavailable != nil {
+		intOrPercent = *policy.MaxUnavailable
+	}
+	maxunavail := map[string]int{}
+	for domain, size := range domainSizes {
+		n, err := intstrutil.GetScaledValueFromIntOrPercent(&intOrPercent, size, false)
+		if err != nil {
+			return nil, err
+		}
+		if n < 1 {
+			n = 1
+		}
+		maxunavail[domain] = n
+	}
+	return maxunavail, nil
+}
+
+// filterFailureDomainCandidates drops the candidates whose failure domain
+// already has as many unavailable nodes as it allows, picking the remaining
+// ones in order.  The candidates are trimmed to the capacity of the pool
+// later, so every prefix of the result respects the limits too.
+func filterFailureDomainCandidates(pool *mcfgv1.MachineConfigPool, nodes, candidates []*corev1.Node) ([]*corev1.Node, error) {
+	policy := pool.Spec.FailureDomain
+	if policy == nil || len(candidates) == 0 {
+		return candidates, nil
+	}
+	maxunavail, err := maxUnavailablePerFailureDomain(policy, nodes)
+	if err != nil {
+		return nil, err
+	}
+	// as for the pool, nodes failing to update to the target config count
+	// against the availability of their domain
+	targetConfig := pool.Spec.Configuration.Name
+	unavail := map[string]int{}
+	for _, node := range nodes {
+		failing := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == targetConfig && isNodeMCDFailing(node)
+		if failing || isNodeUnavailable(node) {
+			unavail[node.Labels[policy.LabelKey]]++
+		}
+	}
+	var filtered []*corev1.Node
+	for _, node := range candidates {
+		domain := node.Labels[policy.LabelKey]
+		if unavail[domain] >= maxunavail[domain] {
+			continue
+		}
+		unavail[domain]++
+		filtered = append(filtered, node)
+	}
+	return filtered, nil
+}
@@ -0,0 +1,75 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+const rackLabel = "example.com/rack"
+
+func inRack(node *corev1.Node, rack string) *corev1.Node {
+	if rack != "" {
+		node.Labels = map[string]string{rackLabel: rack}
+	}
+	return node
+}
+
+func TestFilterFailureDomainCandidates(t *testing.T) {
+	nodes := []*corev1.Node{
+		// rack a has a node updating already
+		inRack(newNodeWithReady("node-a0", "v0", "v1", corev1.ConditionFalse), "a"),
+		inRack(newNodeWithReady("node-a1", "v0", "v0", corev1.ConditionTrue), "a"),
+		inRack(newNodeWithReady("node-b0", "v0", "v0", corev1.ConditionTrue), "b"),
+		inRack(newNodeWithReady("node-b1", "v0", "v0", corev1.ConditionTrue), "b"),
+		inRack(newNodeWithReady("node-c0", "v0", "v0", corev1.ConditionTrue), "c"),
+		inRack(newNodeWithReady("node-c1", "v0", "v0", corev1.ConditionTrue), "c"),
+		inRack(newNodeWithReady("node-c2", "v0", "v0", corev1.ConditionTrue), "c"),
+		inRack(newNodeWithReady("node-c3", "v0", "v0", corev1.ConditionTrue), "c"),
+		// nodes without the label share a domain
+		newNodeWithReady("node-x0", "v0", "v0", corev1.ConditionTrue),
+		newNodeWithReady("node-x1", "v0", "v0", corev1.ConditionTrue),
+	}
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	names := func(nodes []*corev1.Node) []string {
+		var names []string
+		for _, node := range nodes {
+			names = append(names, node.Name)
+		}
+		return names
+	}
+
+	candidates := getCandidateMachines(pool, nodes, 10)
+	require.Len(t, candidates, 9)
+
+	// without a policy the candidates are left alone
+	filtered, err := filterFailureDomainCandidates(pool, nodes, candidates)
+	require.NoError(t, err)
+	assert.Equal(t, candidates, filtered)
+
+	pool.Spec.FailureDomain = &mcfgv1.FailureDomainPolicy{LabelKey: rackLabel}
+	filtered, err = filterFailureDomainCandidates(pool, nodes, candidates)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node-b0", "node-c0", "node-x0"}, names(filtered))
+
+	// a percentage is scaled by the size of each domain, rounding down to at least 1
+	maxUnavail := intstr.FromString("50%")
+	pool.Spec.FailureDomain.MaxUnavailable = &maxUnavail
+	filtered, err = filterFailureDomainCandidates(pool, nodes, candidates)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node-b0", "node-c0", "node-c1", "node-x0"}, names(filtered))
+
+	// a node failing to update to the target config counts against its domain
+	nodes[4].Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] = "v1"
+	nodes[4].Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateDegraded
+	filtered, err = filterFailureDomainCandidates(pool, nodes, getCandidateMachines(pool, nodes, 10))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node-b0", "node-c1", "node-x0"}, names(filtered))
+}
@@ -89,11 +89,13 @@ type Controller struct {
 	mcLister   mcfglistersv1.MachineConfigLister
 	mcpLister  mcfglistersv1.MachineConfigPoolLister
 	nodeLister corelisterv1.NodeLister
+	mcfLister  mcfglistersv1.MachineConfigFreezeLister
 
 	ccListerSynced   cache.InformerSynced
 	mcListerSynced   cache.InformerSynced
 	mcpListerSynced  cache.InformerSynced
 	nodeListerSynced cache.InformerSynced
+	mcfListerSynced  cache.InformerSynced
 
 	schedulerList         cligolistersv1.SchedulerLister
 	schedulerListerSynced cache.InformerSynced
@@ -107,6 +109,7 @@ func New(
 	mcInformer mcfginformersv1.MachineConfigInformer,
 	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
 	nodeInformer coreinformersv1.NodeInformer,
+	mcfInformer mcfginformersv1.MachineConfigFreezeInformer,
 	schedulerInformer cligoinformersv1.SchedulerInformer,
 	kubeClient clientset.Interface,
 	mcfgClient mcfgclientset.Interface,
@@ -119,7 +122,7 @@ func New(
 		client:        mcfgClient,
 		kubeClient:    kubeClient,
 		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-nodecontroller"}),
-		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-nodecontroller"),
+		queue:         workqueue.NewNamedRateLimitingQueue(ctrlcommon.ControllerRateLimiter(), "machineconfigcontroller-nodecontroller"),
 	}
 
 	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -132,6 +135,11 @@ func New(
 		UpdateFunc: ctrl.updateNode,
 		DeleteFunc: ctrl.deleteNode,
 	})
+	mcfInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addMachineConfigFreeze,
+		UpdateFunc: ctrl.updateMachineConfigFreeze,
+		DeleteFunc: ctrl.deleteMachineConfigFreeze,
+	})
 	schedulerInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    ctrl.checkMasterNodesOnAdd,
 		UpdateFunc: ctrl.checkMasterNodesOnUpdate,
@@ -144,10 +152,12 @@ func New(
 	ctrl.mcLister = mcInformer.Lister()
 	ctrl.mcpLister = mcpInformer.Lister()
 	ctrl.nodeLister = nodeInformer.Lister()
+	ctrl.mcfLister = mcfInformer.Lister()
 	ctrl.ccListerSynced = ccInformer.Informer().HasSynced
 	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
 	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
 	ctrl.nodeListerSynced = nodeInformer.Informer().HasSynced
+	ctrl.mcfListerSynced = mcfInformer.Informer().HasSynced
 
 	ctrl.schedulerList = schedulerInformer.Lister()
 	ctrl.schedulerListerSynced = schedulerInformer.Informer().HasSynced
@@ -160,7 +170,7 @@ func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 	defer ctrl.queue.ShutDown()
 
-	if !cache.WaitForCacheSync(stopCh, ctrl.ccListerSynced, ctrl.mcListerSynced, ctrl.mcpListerSynced, ctrl.nodeListerSynced, ctrl.schedulerListerSynced) {
+	if !cache.WaitForCacheSync(stopCh, ctrl.ccListerSynced, ctrl.mcListerSynced, ctrl.mcpListerSynced, ctrl.nodeListerSynced, ctrl.mcfListerSynced, ctrl.schedulerListerSynced) {
 		return
 	}
 
@@ -309,9 +319,32 @@ func (ctrl *Controller) updateMachineConfigPool(old, cur interface{}) {
 	curPool := cur.(*mcfgv1.MachineConfigPool)
 
 	glog.V(4).Infof("Updating MachineConfigPool %s", oldPool.Name)
+	if !reflect.DeepEqual(oldPool.Spec.NodeSelector, curPool.Spec.NodeSelector) {
+		ctrl.deleteMetricsOfLeavingNodes(oldPool)
+	}
 	ctrl.enqueueMachineConfigPool(curPool)
 }
 
+// deleteMetricsOfLeavingNodes deletes the series of the nodes the node selector
+// of the previous version of pool selected, which are no longer in the pool.
+func (ctrl *Controller) deleteMetricsOfLeavingNodes(oldPool *mcfgv1.MachineConfigPool) {
+	selector, err := metav1.LabelSelectorAsSelector(oldPool.Spec.NodeSelector)
+	if err != nil {
+		return
+	}
+	nodes, err := ctrl.nodeLister.List(selector)
+	if err != nil {
+		glog.Errorf("error listing the nodes of pool %s: %v", oldPool.Name, err)
+		return
+	}
+	for _, node := range nodes {
+		pool, err := ctrl.getPrimaryPoolForNode(node)
+		if err != nil || pool == nil || pool.Name != oldPool.Name {
+			deleteNodeMetrics(oldPool.Name, node.Name)
+		}
+	}
+}
+
 func (ctrl *Controller) deleteMachineConfigPool(obj interface{}) {
 	pool, ok := obj.(*mcfgv1.MachineConfigPool)
 	if !ok {
@@ -328,6 +361,65 @@ func (ctrl *Controller) deleteMachineConfigPool(obj interface{}) {
 	}
 	glog.V(4).Infof("Deleting MachineConfigPool %s", pool.Name)
 	// TODO(abhinavdahiya): handle deletes.
+	if selector, err := metav1.LabelSelectorAsSelector(pool.Spec.NodeSelector); err == nil {
+		nodes, err := ctrl.nodeLister.List(selector)
+		if err != nil {
+			glog.Errorf("error listing the nodes of pool %s: %v", pool.Name, err)
+		}
+		for _, node := range nodes {
+			deleteNodeMetrics(pool.Name, node.Name)
+		}
+	}
+	ctrlcommon.MachineConfigControllerPausedPoolKubeletCA.DeleteLabelValues(pool.Name)
+	for _, reason := range deferredReasons {
+		ctrlcommon.MachineConfigControllerPoolRebootsDeferred.DeleteLabelValues(pool.Name, reason)
+	}
+}
+
+func (ctrl *Controller) addMachineConfigFreeze(obj interface{}) {
+	mcf := obj.(*mcfgv1.MachineConfigFreeze)
+	if mcf.Name == mcfgv1.MachineConfigFreezeName {
+		ctrl.enqueueAllPools()
+	}
+}
+
+func (ctrl *Controller) updateMachineConfigFreeze(old, cur interface{}) {
+	oldMCF := old.(*mcfgv1.MachineConfigFreeze)
+	curMCF := cur.(*mcfgv1.MachineConfigFreeze)
+	if curMCF.Name == mcfgv1.MachineConfigFreezeName && !reflect.DeepEqual(oldMCF.Spec, curMCF.Spec) {
+		ctrl.enqueueAllPools()
+	}
+}
+
+func (ctrl *Controller) deleteMachineConfigFreeze(obj interface{}) {
+	mcf, ok := obj.(*mcfgv1.MachineConfigFreeze)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		mcf, ok = tombstone.Obj.(*mcfgv1.MachineConfigFreeze)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a MachineConfigFreeze %#v", obj))
+			return
+		}
+	}
+	if mcf.Name == mcfgv1.MachineConfigFreezeName {
+		ctrl.enqueueAllPools()
+	}
+}
+
+// enqueueAllPools resyncs every pool, e.g. after node updates were frozen or unfrozen.
+func (ctrl *Controller) enqueueAllPools() {
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("error listing pools: %w", err))
+		return
+	}
+	for _, pool := range pools {
+		ctrl.enqueueMachineConfigPool(pool)
+	}
 }
 
 // Determine if masters are currently configured as schedulable
@@ -438,6 +530,10 @@ func (ctrl *Controller) updateNode(old, cur interface{}) {
 	oldNode := old.(*corev1.Node)
 	curNode := cur.(*corev1.Node)
 
+	if !reflect.DeepEqual(oldNode.Labels, curNode.Labels) {
+		ctrl.handleNodePoolChange(oldNode, curNode)
+	}
+
 	if !isNodeManaged(curNode) {
 		return
 	}
@@ -502,6 +598,10 @@ func (ctrl *Controller) updateNode(old, cur interface{}) {
 			ctrl.logPoolNode(pool, curNode, "changed taints")
 			changed = true
 		}
+		if oldNode.Annotations[daemonconsts.DrainBlockersAnnotationKey] != curNode.Annotations[daemonconsts.DrainBlockersAnnotationKey] {
+			ctrl.logPoolNode(pool, curNode, "changed drain blockers")
+			changed = true
+		}
 	}
 
 	if !changed {
@@ -547,10 +647,34 @@ func (ctrl *Controller) deleteNode(obj interface{}) {
 	}
 	glog.V(4).Infof("Node %s delete", node.Name)
 	for _, pool := range pools {
+		deleteNodeMetrics(pool.Name, node.Name)
 		ctrl.enqueueMachineConfigPool(pool)
 	}
 }
 
+// handleNodePoolChange deletes the series of a node which its labels moved
+// out of its pool, and syncs that pool so its status no longer counts it.
+func (ctrl *Controller) handleNodePoolChange(oldNode, curNode *corev1.Node) {
+	oldPool, err := ctrl.getPrimaryPoolForNode(oldNode)
+	if err != nil || oldPool == nil {
+		return
+	}
+	curPool, err := ctrl.getPrimaryPoolForNode(curNode)
+	if err == nil && curPool != nil && curPool.Name == oldPool.Name {
+		return
+	}
+	ctrl.logPoolNode(oldPool, curNode, "Node left the pool")
+	deleteNodeMetrics(oldPool.Name, curNode.Name)
+	ctrl.enqueueMachineConfigPool(oldPool)
+}
+
+// deleteNodeMetrics deletes the series the pool reports for the node, once the
+// node is deleted or no longer in the pool.
+func deleteNodeMetrics(pool, node string) {
+	ctrlcommon.MachineConfigControllerNodeRebootDeferred.DeleteLabelValues(pool, node)
+	ctrlcommon.MachineConfigControllerNodeRebootTimedOut.DeleteLabelValues(pool, node)
+}
+
 // getPoolsForNode chooses the MachineConfigPools that should be used for a given node.
 // It disambiguates in the case where e.g. a node has both master/worker roles applied,
 // and where a custom role may be used. It returns a slice of all the pools the node belongs to.
@@ -566,7 +690,11 @@ func (ctrl *Controller) getPoolsForNode(node *corev1.Node) ([]*mcfgv1.MachineCon
 	if err != nil {
 		return nil, err
 	}
+	return getPoolsForNodeFromList(pl, node)
+}
 
+// getPoolsForNodeFromList implements getPoolsForNode given all the pools of the cluster.
+func getPoolsForNodeFromList(pl []*mcfgv1.MachineConfigPool, node *corev1.Node) ([]*mcfgv1.MachineConfigPool, error) {
 	var pools []*mcfgv1.MachineConfigPool
 	for _, p := range pl {
 		selector, err := metav1.LabelSelectorAsSelector(p.Spec.NodeSelector)
@@ -635,6 +763,19 @@ func (ctrl *Controller) getPrimaryPoolForNode(node *corev1.Node) (*mcfgv1.Machin
 	return pools[0], nil
 }
 
+// GetPrimaryPoolForNode returns the pool a node targets among all the pools
+// of the cluster, or nil if it isn't managed by any.
+func GetPrimaryPoolForNode(pools []*mcfgv1.MachineConfigPool, node *corev1.Node) (*mcfgv1.MachineConfigPool, error) {
+	if isWindows(node) {
+		return nil, nil
+	}
+	pl, err := getPoolsForNodeFromList(pools, node)
+	if err != nil || pl == nil {
+		return nil, err
+	}
+	return pl[0], nil
+}
+
 func (ctrl *Controller) enqueue(pool *mcfgv1.MachineConfigPool) {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(pool)
 	if err != nil {
@@ -766,6 +907,18 @@ func (ctrl *Controller) syncMachineConfigPool(key string) error {
 		return ctrl.syncStatusOnly(pool)
 	}
 
+	freeze, err := ctrlcommon.GetClusterFreeze(ctrl.mcfLister)
+	if err != nil {
+		return goerrs.Wrapf(err, "error checking for a cluster-wide freeze of pool %q", pool.Name)
+	}
+	if freeze != nil {
+		if pool.Spec.Configuration.Name != pool.Status.Configuration.Name {
+			glog.Infof("Pool %s will not update: %v", pool.Name, freeze)
+			ctrl.setPendingFileMetrics(pool)
+		}
+		return ctrl.syncStatusOnly(pool)
+	}
+
 	// We aren't paused anymore, so reset the metrics
 	ctrl.resetPendingFileMetrics(pool)
 
@@ -788,6 +941,15 @@ func (ctrl *Controller) syncMachineConfigPool(key string) error {
 	if err := ctrl.setClusterConfigAnnotation(nodes); err != nil {
 		return goerrs.Wrapf(err, "error setting clusterConfig Annotation for node in pool %q, error: %v", pool.Name, err)
 	}
+	if err := ctrl.setPreRebootNotificationAnnotation(pool, nodes); err != nil {
+		return goerrs.Wrapf(err, "error setting preRebootNotification Annotation for node in pool %q", pool.Name)
+	}
+	if err := ctrl.setPostUpdateValidationAnnotation(pool, nodes); err != nil {
+		return goerrs.Wrapf(err, "error setting postUpdateValidation Annotation for node in pool %q", pool.Name)
+	}
+	if err := ctrl.setImagePruningAnnotation(pool, nodes); err != nil {
+		return goerrs.Wrapf(err, "error setting imagePruning Annotation for node in pool %q", pool.Name)
+	}
 	// Taint all the nodes in the node pool, irrespective of their upgrade status.
 	ctx := context.TODO()
 	for _, node := range nodes {
@@ -800,7 +962,29 @@ func (ctrl *Controller) syncMachineConfigPool(key string) error {
 			}
 		}
 	}
-	candidates, capacity := getAllCandidateMachines(pool, nodes, maxunavail)
+	if err := ctrl.syncUpdateTaints(pool, nodes); err != nil {
+		return goerrs.Wrapf(err, "error syncing update taints of pool %q", pool.Name)
+	}
+	rebootTimeouts, err := ctrl.syncRebootTimeouts(pool, nodes, time.Now())
+	if err != nil {
+		return goerrs.Wrapf(err, "error handling reboot timeouts of pool %q", pool.Name)
+	}
+	countedNodes := excludeTimedOutNodes(pool, nodes, rebootTimeouts, maxunavail)
+	limit := ctrl.syncAdaptiveConcurrency(pool, nodes, maxunavail, time.Now())
+	candidates, capacity := getAllCandidateMachines(pool, countedNodes, limit)
+	if len(candidates) > 0 && pool.Spec.FailureDomain != nil {
+		allCandidates := len(candidates)
+		candidates, err = filterFailureDomainCandidates(pool, countedNodes, candidates)
+		if err != nil {
+			if syncErr := ctrl.syncStatusOnly(pool); syncErr != nil {
+				return goerrs.Wrapf(err, "error getting failure domain limits for pool %q, sync error: %v", pool.Name, syncErr)
+			}
+			return err
+		}
+		if held := allCandidates - len(candidates); held > 0 {
+			ctrl.logPool(pool, "%d candidate nodes held back by the limits of their %s failure domain", held, pool.Spec.FailureDomain.LabelKey)
+		}
+	}
 	if len(candidates) > 0 {
 		ctrl.logPool(pool, "%d candidate nodes for update, capacity: %d", len(candidates), capacity)
 		if err := ctrl.updateCandidateMachines(pool, candidates, capacity); err != nil {
@@ -866,6 +1050,70 @@ func (ctrl *Controller) setClusterConfigAnnotation(nodes []*corev1.Node) error {
 	return nil
 }
 
+// setPreRebootNotificationAnnotation copies the preRebootNotification of the
+// pool to its nodes, for the daemon to read before draining them.
+func (ctrl *Controller) setPreRebootNotificationAnnotation(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	var policy interface{}
+	if pool.Spec.PreRebootNotification != nil {
+		policy = pool.Spec.PreRebootNotification
+	}
+	return ctrl.setPolicyAnnotation(nodes, daemonconsts.PreRebootNotificationAnnotationKey, policy)
+}
+
+// setPostUpdateValidationAnnotation copies the postUpdateValidation of the
+// pool to its nodes, for the daemon to read after applying a config.
+func (ctrl *Controller) setPostUpdateValidationAnnotation(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	var policy interface{}
+	if pool.Spec.PostUpdateValidation != nil {
+		policy = pool.Spec.PostUpdateValidation
+	}
+	return ctrl.setPolicyAnnotation(nodes, daemonconsts.PostUpdateValidationAnnotationKey, policy)
+}
+
+// setImagePruningAnnotation copies the imagePruning of the pool to its nodes,
+// for the daemon to read once it completed an update.
+func (ctrl *Controller) setImagePruningAnnotation(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	var policy interface{}
+	if pool.Spec.ImagePruning != nil {
+		policy = pool.Spec.ImagePruning
+	}
+	return ctrl.setPolicyAnnotation(nodes, daemonconsts.ImagePruningAnnotationKey, policy)
+}
+
+// setPolicyAnnotation sets the annotation key of the nodes to the JSON policy,
+// or removes it if policy is nil.
+func (ctrl *Controller) setPolicyAnnotation(nodes []*corev1.Node, key string, policy interface{}) error {
+	value := ""
+	if policy != nil {
+		data, err := json.Marshal(policy)
+		if err != nil {
+			return err
+		}
+		value = string(data)
+	}
+
+	for _, node := range nodes {
+		if node.Annotations[key] == value {
+			continue
+		}
+		_, err := internal.UpdateNodeRetry(ctrl.kubeClient.CoreV1().Nodes(), ctrl.nodeLister, node.Name, func(node *corev1.Node) {
+			if value == "" {
+				delete(node.Annotations, key)
+				return
+			}
+			if node.Annotations == nil {
+				node.Annotations = map[string]string{}
+			}
+			node.Annotations[key] = value
+		})
+		if err != nil {
+			return err
+		}
+		glog.V(4).Infof("Updated %s annotation of node %s to %q", key, node.Name, value)
+	}
+	return nil
+}
+
 func (ctrl *Controller) setDesiredMachineConfigAnnotation(nodeName, currentConfig string) error {
 	return clientretry.RetryOnConflict(constants.NodeUpdateBackoff, func() error {
 		oldNode, err := ctrl.kubeClient.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
@@ -988,6 +1236,12 @@ func (ctrl *Controller) updateCandidateMachines(pool *mcfgv1.MachineConfigPool,
 	}
 	targetConfig := pool.Spec.Configuration.Name
 	for _, node := range candidates {
+		// taint the node before the daemon starts draining it
+		if pool.Spec.UpdateTaint != nil {
+			if err := ctrl.setUpdateTaint(node, pool.Spec.UpdateTaint); err != nil {
+				return goerrs.Wrapf(err, "setting update taint on node %s", node.Name)
+			}
+		}
 		ctrl.logPool(pool, "Setting node %s target to %s", node.Name, targetConfig)
 		if err := ctrl.setDesiredMachineConfigAnnotation(node.Name, targetConfig); err != nil {
 			return goerrs.Wrapf(err, "setting desired config for node %s", node.Name)
@@ -36,6 +36,7 @@ import (
 	"github.com/openshift/machine-config-operator/test/helpers"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	utilrand "k8s.io/apimachinery/pkg/util/rand"
 )
 
@@ -63,6 +64,7 @@ type fixture struct {
 	objects          []runtime.Object
 	schedulerObjects []runtime.Object
 	schedulerLister  []*apicfgv1.Scheduler
+	mcfLister        []*mcfgv1.MachineConfigFreeze
 }
 
 func newFixture(t *testing.T) *fixture {
@@ -82,11 +84,12 @@ func (f *fixture) newController() *Controller {
 	k8sI := kubeinformers.NewSharedInformerFactory(f.kubeclient, noResyncPeriodFunc())
 	ci := configv1informer.NewSharedInformerFactory(f.schedulerClient, noResyncPeriodFunc())
 	c := New(i.Machineconfiguration().V1().ControllerConfigs(), i.Machineconfiguration().V1().MachineConfigs(), i.Machineconfiguration().V1().MachineConfigPools(), k8sI.Core().V1().Nodes(),
-		ci.Config().V1().Schedulers(), f.kubeclient, f.client)
+		i.Machineconfiguration().V1().MachineConfigFreezes(), ci.Config().V1().Schedulers(), f.kubeclient, f.client)
 
 	c.ccListerSynced = alwaysReady
 	c.mcpListerSynced = alwaysReady
 	c.nodeListerSynced = alwaysReady
+	c.mcfListerSynced = alwaysReady
 	c.schedulerListerSynced = alwaysReady
 	c.eventRecorder = &record.FakeRecorder{}
 
@@ -110,6 +113,9 @@ func (f *fixture) newController() *Controller {
 	for _, c := range f.schedulerLister {
 		ci.Config().V1().Schedulers().Informer().GetIndexer().Add(c)
 	}
+	for _, c := range f.mcfLister {
+		i.Machineconfiguration().V1().MachineConfigFreezes().Informer().GetIndexer().Add(c)
+	}
 
 	return c
 }
@@ -221,7 +227,9 @@ func filterInformerActions(actions []core.Action) []core.Action {
 				action.Matches("list", "controllerconfigs") ||
 				action.Matches("watch", "controllerconfigs") ||
 				action.Matches("list", "nodes") ||
-				action.Matches("watch", "nodes")) {
+				action.Matches("watch", "nodes") ||
+				action.Matches("list", "machineconfigfreezes") ||
+				action.Matches("watch", "machineconfigfreezes")) {
 			continue
 		}
 		ret = append(ret, action)
@@ -892,7 +900,7 @@ func TestShouldMakeProgress(t *testing.T) {
 				}
 				f.expectPatchNodeAction(expNode, exppatch)
 			}
-			expStatus := calculateStatus(mcp, nodes)
+			expStatus := calculateExpectedStatus(t, mcp, nodes, nil)
 			expMcp := mcp.DeepCopy()
 			expMcp.Status = expStatus
 			f.expectUpdateMachineConfigPoolStatus(expMcp)
@@ -931,7 +939,38 @@ func TestPaused(t *testing.T) {
 		f.kubeobjects = append(f.kubeobjects, nodes[idx])
 	}
 
-	expStatus := calculateStatus(mcp, nodes)
+	expStatus := calculateExpectedStatus(t, mcp, nodes, nil)
+	expMcp := mcp.DeepCopy()
+	expMcp.Status = expStatus
+	f.expectUpdateMachineConfigPoolStatus(expMcp)
+
+	f.run(getKey(mcp, t))
+}
+
+func TestClusterFrozen(t *testing.T) {
+	f := newFixture(t)
+	mcp := helpers.NewMachineConfigPool("test-cluster-infra", nil, helpers.InfraSelector, "v1")
+	mcpWorker := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	mcp.Spec.MaxUnavailable = intStrPtr(intstr.FromInt(1))
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role/worker": "", "node-role/infra": ""}),
+		newNodeWithLabel("node-1", "v0", "v0", map[string]string{"node-role/worker": "", "node-role/infra": ""}),
+	}
+	freeze := &mcfgv1.MachineConfigFreeze{
+		ObjectMeta: metav1.ObjectMeta{Name: mcfgv1.MachineConfigFreezeName},
+		Spec:       mcfgv1.MachineConfigFreezeSpec{Frozen: true, Reason: "incident 1234"},
+	}
+
+	f.mcpLister = append(f.mcpLister, mcp, mcpWorker)
+	f.objects = append(f.objects, mcp, mcpWorker)
+	f.nodeLister = append(f.nodeLister, nodes...)
+	f.mcfLister = append(f.mcfLister, freeze)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+
+	// only the status is updated, node-1 doesn't get the new desired config
+	expStatus := calculateExpectedStatus(t, mcp, nodes, &ctrlcommon.ClusterFreeze{Reason: "incident 1234"})
 	expMcp := mcp.DeepCopy()
 	expMcp.Status = expStatus
 	f.expectUpdateMachineConfigPoolStatus(expMcp)
@@ -973,7 +1012,7 @@ func TestAlertOnPausedKubeletCA(t *testing.T) {
 		f.objects = append(f.objects, mcs[idx])
 	}
 
-	expStatus := calculateStatus(mcp, nodes)
+	expStatus := calculateExpectedStatus(t, mcp, nodes, nil)
 	expMcp := mcp.DeepCopy()
 	expMcp.Status = expStatus
 	f.expectUpdateMachineConfigPoolStatus(expMcp)
@@ -1007,7 +1046,7 @@ func TestShouldUpdateStatusOnlyUpdated(t *testing.T) {
 		f.kubeobjects = append(f.kubeobjects, nodes[idx])
 	}
 
-	expStatus := calculateStatus(mcp, nodes)
+	expStatus := calculateExpectedStatus(t, mcp, nodes, nil)
 	expMcp := mcp.DeepCopy()
 	expMcp.Status = expStatus
 	f.expectUpdateMachineConfigPoolStatus(expMcp)
@@ -1034,7 +1073,7 @@ func TestShouldUpdateStatusOnlyNoProgress(t *testing.T) {
 		f.kubeobjects = append(f.kubeobjects, nodes[idx])
 	}
 
-	expStatus := calculateStatus(mcp, nodes)
+	expStatus := calculateExpectedStatus(t, mcp, nodes, nil)
 	expMcp := mcp.DeepCopy()
 	expMcp.Status = expStatus
 	f.expectUpdateMachineConfigPoolStatus(expMcp)
@@ -1052,7 +1091,7 @@ func TestShouldDoNothing(t *testing.T) {
 		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role/worker": "", "node-role/infra": ""}),
 		newNodeWithLabel("node-1", "v1", "v1", map[string]string{"node-role/worker": "", "node-role/infra": ""}),
 	}
-	status := calculateStatus(mcp, nodes)
+	status := calculateExpectedStatus(t, mcp, nodes, nil)
 	mcp.Status = status
 
 	f.ccLister = append(f.ccLister, cc)
@@ -1082,7 +1121,7 @@ func TestControlPlaneTopology(t *testing.T) {
 	for _, node := range nodes {
 		addNodeAnnotations(node, annotations)
 	}
-	status := calculateStatus(mcp, nodes)
+	status := calculateExpectedStatus(t, mcp, nodes, nil)
 	mcp.Status = status
 
 	f.ccLister = append(f.ccLister, cc)
@@ -1096,6 +1135,187 @@ func TestControlPlaneTopology(t *testing.T) {
 	f.run(getKey(mcp, t))
 }
 
+func TestPreRebootNotificationAnnotation(t *testing.T) {
+	f := newFixture(t)
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName, configv1.TopologyMode(""))
+	mcp := helpers.NewMachineConfigPool("test-cluster-infra", nil, helpers.InfraSelector, "v1")
+	mcpWorker := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	mcp.Spec.PreRebootNotification = &mcfgv1.PreRebootNotification{
+		LeadTime:      metav1.Duration{Duration: time.Minute},
+		PodAnnotation: "example.com/reboot",
+	}
+
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role/worker": "", "node-role/infra": ""}),
+		// already annotated
+		newNodeWithLabel("node-1", "v1", "v1", map[string]string{"node-role/worker": "", "node-role/infra": ""}),
+	}
+	annotation := `{"leadTime":"1m0s","podAnnotation":"example.com/reboot"}`
+	addNodeAnnotations(nodes[1], map[string]string{daemonconsts.PreRebootNotificationAnnotationKey: annotation})
+	mcp.Status = calculateExpectedStatus(t, mcp, nodes, nil)
+
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, mcp, mcpWorker)
+	f.objects = append(f.objects, mcp, mcpWorker)
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+
+	expNode := nodes[0].DeepCopy()
+	expNode.Annotations[daemonconsts.PreRebootNotificationAnnotationKey] = annotation
+	oldData, err := json.Marshal(nodes[0])
+	require.NoError(t, err)
+	newData, err := json.Marshal(expNode)
+	require.NoError(t, err)
+	exppatch, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, corev1.Node{})
+	require.NoError(t, err)
+	f.expectPatchNodeAction(expNode, exppatch)
+
+	f.run(getKey(mcp, t))
+}
+
+func TestPostUpdateValidationAnnotation(t *testing.T) {
+	f := newFixture(t)
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName, configv1.TopologyMode(""))
+	mcp := helpers.NewMachineConfigPool("test-cluster-infra", nil, helpers.InfraSelector, "v1")
+	mcpWorker := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	mcp.Spec.PostUpdateValidation = &mcfgv1.PostUpdateValidation{
+		Probes:        []mcfgv1.ValidationProbe{{Name: "kubelet", SystemdUnit: "kubelet.service"}},
+		FailurePolicy: mcfgv1.PostUpdateValidationFailurePolicyRollback,
+	}
+
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role/worker": "", "node-role/infra": ""}),
+		// already annotated
+		newNodeWithLabel("node-1", "v1", "v1", map[string]string{"node-role/worker": "", "node-role/infra": ""}),
+	}
+	annotation := `{"probes":[{"name":"kubelet","systemdUnit":"kubelet.service"}],"failurePolicy":"Rollback"}`
+	addNodeAnnotations(nodes[1], map[string]string{daemonconsts.PostUpdateValidationAnnotationKey: annotation})
+	mcp.Status = calculateExpectedStatus(t, mcp, nodes, nil)
+
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, mcp, mcpWorker)
+	f.objects = append(f.objects, mcp, mcpWorker)
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+
+	expNode := nodes[0].DeepCopy()
+	expNode.Annotations[daemonconsts.PostUpdateValidationAnnotationKey] = annotation
+	oldData, err := json.Marshal(nodes[0])
+	require.NoError(t, err)
+	newData, err := json.Marshal(expNode)
+	require.NoError(t, err)
+	exppatch, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, corev1.Node{})
+	require.NoError(t, err)
+	f.expectPatchNodeAction(expNode, exppatch)
+
+	f.run(getKey(mcp, t))
+}
+
+func TestImagePruningAnnotation(t *testing.T) {
+	f := newFixture(t)
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName, configv1.TopologyMode(""))
+	mcp := helpers.NewMachineConfigPool("test-cluster-infra", nil, helpers.InfraSelector, "v1")
+	mcpWorker := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	mcp.Spec.ImagePruning = &mcfgv1.ImagePruning{PinnedImages: []string{"quay.io/example/agent"}}
+
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role/worker": "", "node-role/infra": ""}),
+		// already annotated
+		newNodeWithLabel("node-1", "v1", "v1", map[string]string{"node-role/worker": "", "node-role/infra": ""}),
+	}
+	annotation := `{"pinnedImages":["quay.io/example/agent"]}`
+	addNodeAnnotations(nodes[1], map[string]string{daemonconsts.ImagePruningAnnotationKey: annotation})
+	mcp.Status = calculateExpectedStatus(t, mcp, nodes, nil)
+
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, mcp, mcpWorker)
+	f.objects = append(f.objects, mcp, mcpWorker)
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+
+	expNode := nodes[0].DeepCopy()
+	expNode.Annotations[daemonconsts.ImagePruningAnnotationKey] = annotation
+	oldData, err := json.Marshal(nodes[0])
+	require.NoError(t, err)
+	newData, err := json.Marshal(expNode)
+	require.NoError(t, err)
+	exppatch, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, corev1.Node{})
+	require.NoError(t, err)
+	f.expectPatchNodeAction(expNode, exppatch)
+
+	f.run(getKey(mcp, t))
+}
+
+func TestUpdateTaint(t *testing.T) {
+	annotation := `{"key":"example.com/updating","effect":"NoSchedule"}`
+	taint := corev1.Taint{Key: "example.com/updating", Effect: corev1.TaintEffectNoSchedule}
+
+	// run syncs a pool with the node and a node that was never tainted,
+	// expecting the node to be patched to expNode.  A single node is patched
+	// per run, as the lister returns the nodes in any order.
+	run := func(t *testing.T, node, expNode *corev1.Node) {
+		f := newFixture(t)
+		cc := newControllerConfig(ctrlcommon.ControllerConfigName, configv1.TopologyMode(""))
+		mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+		mcp.Spec.UpdateTaint = &mcfgv1.UpdateTaint{Key: "example.com/updating", Effect: corev1.TaintEffectNoSchedule}
+		nodes := []*corev1.Node{node, newNodeWithLabel("node-2", "v1", "v1", map[string]string{"node-role/worker": ""})}
+		mcp.Status = calculateExpectedStatus(t, mcp, nodes, nil)
+
+		f.ccLister = append(f.ccLister, cc)
+		f.mcpLister = append(f.mcpLister, mcp)
+		f.objects = append(f.objects, mcp)
+		f.nodeLister = append(f.nodeLister, nodes...)
+		for idx := range nodes {
+			f.kubeobjects = append(f.kubeobjects, nodes[idx])
+		}
+
+		oldData, err := json.Marshal(node)
+		require.NoError(t, err)
+		newData, err := json.Marshal(expNode)
+		require.NoError(t, err)
+		exppatch, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, corev1.Node{})
+		require.NoError(t, err)
+		f.expectPatchNodeAction(expNode, exppatch)
+
+		f.run(getKey(mcp, t))
+	}
+
+	t.Run("updating", func(t *testing.T) {
+		node := newNodeWithLabel("node-0", "v0", "v1", map[string]string{"node-role/worker": ""})
+		tainted := node.DeepCopy()
+		addNodeAnnotations(tainted, map[string]string{daemonconsts.UpdateTaintAnnotationKey: annotation})
+		tainted.Spec.Taints = []corev1.Taint{taint}
+		run(t, node, tainted)
+	})
+
+	t.Run("done updating", func(t *testing.T) {
+		node := newNodeWithLabel("node-1", "v1", "v1", map[string]string{"node-role/worker": ""})
+		addNodeAnnotations(node, map[string]string{daemonconsts.UpdateTaintAnnotationKey: annotation})
+		node.Spec.Taints = []corev1.Taint{taint}
+		untainted := node.DeepCopy()
+		delete(untainted.Annotations, daemonconsts.UpdateTaintAnnotationKey)
+		untainted.Spec.Taints = []corev1.Taint{}
+		run(t, node, untainted)
+	})
+}
+
+// calculateExpectedStatus returns the status syncStatusOnly is expected to set
+func calculateExpectedStatus(t *testing.T, pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, freeze *ctrlcommon.ClusterFreeze) mcfgv1.MachineConfigPoolStatus {
+	deferred, err := getDeferredUpdates(pool, nodes, freeze)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := calculateStatus(pool, nodes)
+	setRebootDeferredCondition(&status, deferred)
+	return status
+}
+
 // adds annotation to the node
 func addNodeAnnotations(node *corev1.Node, annotations map[string]string) {
 	if node.Annotations == nil {
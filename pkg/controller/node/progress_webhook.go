@@ -0,0 +1,145 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// progressWebhookSignatureHeader is the header carrying the signature of
+	// the body of a progress webhook request.
+	progressWebhookSignatureHeader = "X-Machine-Config-Signature"
+
+	// progressWebhookSigningKey is the key of the signing secret of a
+	// progress webhook holding the signing key.
+	progressWebhookSigningKey = "signingKey"
+
+	// progressWebhookTimeout bounds each request to a progress webhook.
+	progressWebhookTimeout = 10 * time.Second
+)
+
+// progressWebhookBackoff is how often, and how far apart, a failed request
+// to a progress webhook is retried.
+var progressWebhookBackoff = wait.Backoff{Steps: 4, Duration: 5 * time.Second, Factor: 2}
+
+// becameTrue returns whether the condition of the pool is true in newStatus
+// but wasn't in oldStatus.
+func becameTrue(oldStatus, newStatus mcfgv1.MachineConfigPoolStatus, condType mcfgv1.MachineConfigPoolConditionType) bool {
+	return !mcfgv1.IsMachineConfigPoolConditionTrue(oldStatus.Conditions, condType) &&
+		mcfgv1.IsMachineConfigPoolConditionTrue(newStatus.Conditions, condType)
+}
+
+// getProgressEvents returns the events of the rollout of the pool between
+// the oldStatus and the newStatus of the pool.
+func getProgressEvents(pool *mcfgv1.MachineConfigPool, oldStatus, newStatus mcfgv1.MachineConfigPoolStatus, now time.Time) []mcfgv1.PoolProgressEvent {
+	var events []mcfgv1.PoolProgressEvent
+	newEvent := func(eventType mcfgv1.PoolProgressEventType, message string) {
+		events = append(events, mcfgv1.PoolProgressEvent{
+			Type:          eventType,
+			Pool:          pool.Name,
+			MachineConfig: pool.Spec.Configuration.Name,
+			Message:       message,
+			Time:          metav1.NewTime(now),
+		})
+	}
+	if becameTrue(oldStatus, newStatus, mcfgv1.MachineConfigPoolUpdating) {
+		newEvent(mcfgv1.PoolProgressStarted, mcfgv1.GetMachineConfigPoolCondition(newStatus, mcfgv1.MachineConfigPoolUpdating).Message)
+	}
+	if becameTrue(oldStatus, newStatus, mcfgv1.MachineConfigPoolUpdated) {
+		newEvent(mcfgv1.PoolProgressCompleted, mcfgv1.GetMachineConfigPoolCondition(newStatus, mcfgv1.MachineConfigPoolUpdated).Message)
+	}
+	if becameTrue(oldStatus, newStatus, mcfgv1.MachineConfigPoolDegraded) {
+		var reasons []string
+		for _, condType := range []mcfgv1.MachineConfigPoolConditionType{mcfgv1.MachineConfigPoolNodeDegraded, mcfgv1.MachineConfigPoolRenderDegraded} {
+			if cond := mcfgv1.GetMachineConfigPoolCondition(newStatus, condType); cond != nil && cond.Status == corev1.ConditionTrue && cond.Message != "" {
+				reasons = append(reasons, cond.Message)
+			}
+		}
+		newEvent(mcfgv1.PoolProgressDegraded, strings.Join(reasons, "; "))
+	}
+	return events
+}
+
+// signProgressEvent returns the value of the signature header of a request
+// with the body.
+func signProgressEvent(body, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// sendProgressEvent POSTs the signed event to the webhook.
+func (ctrl *Controller) sendProgressEvent(webhook mcfgv1.ProgressWebhook, event mcfgv1.PoolProgressEvent) error {
+	if !strings.HasPrefix(webhook.URL, "http://") && !strings.HasPrefix(webhook.URL, "https://") {
+		return fmt.Errorf("unsupported url %q, must be http or https", webhook.URL)
+	}
+	secret, err := ctrl.kubeClient.CoreV1().Secrets(ctrlcommon.MCONamespace).Get(context.TODO(), webhook.SigningSecret, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("getting signing secret: %w", err)
+	}
+	key := secret.Data[progressWebhookSigningKey]
+	if len(key) == 0 {
+		return fmt.Errorf("Secret %s has no %s", webhook.SigningSecret, progressWebhookSigningKey)
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(progressWebhookSignatureHeader, signProgressEvent(body, key))
+	client := &http.Client{Timeout: progressWebhookTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// notifyProgressWebhooks sends the events to the progress webhooks of the
+// pool in the background, retrying failed requests.  Notifications are best
+// effort: an event which can't be delivered is reported in a
+// ProgressWebhookFailed event on the pool.
+func (ctrl *Controller) notifyProgressWebhooks(pool *mcfgv1.MachineConfigPool, events []mcfgv1.PoolProgressEvent) {
+	if len(events) == 0 {
+		return
+	}
+	for _, webhook := range pool.Spec.ProgressWebhooks {
+		webhook := webhook
+		go func() {
+			for _, event := range events {
+				var lastErr error
+				err := wait.ExponentialBackoff(progressWebhookBackoff, func() (bool, error) {
+					lastErr = ctrl.sendProgressEvent(webhook, event)
+					return lastErr == nil, nil
+				})
+				if err != nil {
+					glog.Warningf("Failed to send %s event of pool %s to %s: %v", event.Type, pool.Name, webhook.URL, lastErr)
+					ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "ProgressWebhookFailed", "Failed to send %s event to %s: %v", event.Type, webhook.URL, lastErr)
+				}
+			}
+		}()
+	}
+}
@@ -0,0 +1,107 @@
+package node
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func statusWithConditions(conds ...*mcfgv1.MachineConfigPoolCondition) mcfgv1.MachineConfigPoolStatus {
+	status := mcfgv1.MachineConfigPoolStatus{}
+	for _, cond := range conds {
+		mcfgv1.SetMachineConfigPoolCondition(&status, *cond)
+	}
+	return status
+}
+
+func TestGetProgressEvents(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	now := time.Now()
+	updated := statusWithConditions(
+		mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolUpdated, corev1.ConditionTrue, "", "All nodes are updated with v0"),
+		mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolUpdating, corev1.ConditionFalse, "", ""),
+		mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolDegraded, corev1.ConditionFalse, "", ""),
+	)
+	updating := statusWithConditions(
+		mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolUpdated, corev1.ConditionFalse, "", ""),
+		mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolUpdating, corev1.ConditionTrue, "", "All nodes are updating to v1"),
+		mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolDegraded, corev1.ConditionFalse, "", ""),
+	)
+	degraded := statusWithConditions(
+		mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolUpdated, corev1.ConditionFalse, "", ""),
+		mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolUpdating, corev1.ConditionTrue, "", "All nodes are updating to v1"),
+		mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolNodeDegraded, corev1.ConditionTrue, "1 nodes are reporting degraded status on sync", `Node node-0 is reporting: "failed"`),
+		mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolDegraded, corev1.ConditionTrue, "", ""),
+	)
+
+	events := getProgressEvents(pool, updated, updating, now)
+	require.Len(t, events, 1)
+	assert.Equal(t, mcfgv1.PoolProgressEvent{
+		Type:          mcfgv1.PoolProgressStarted,
+		Pool:          "worker",
+		MachineConfig: "v1",
+		Message:       "All nodes are updating to v1",
+		Time:          metav1.NewTime(now),
+	}, events[0])
+
+	events = getProgressEvents(pool, updating, degraded, now)
+	require.Len(t, events, 1)
+	assert.Equal(t, mcfgv1.PoolProgressDegraded, events[0].Type)
+	assert.Equal(t, `Node node-0 is reporting: "failed"`, events[0].Message)
+
+	events = getProgressEvents(pool, degraded, updated, now)
+	require.Len(t, events, 1)
+	assert.Equal(t, mcfgv1.PoolProgressCompleted, events[0].Type)
+
+	assert.Empty(t, getProgressEvents(pool, updating, updating, now))
+}
+
+func TestSendProgressEvent(t *testing.T) {
+	var body []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		signature = r.Header.Get(progressWebhookSignatureHeader)
+	}))
+	defer server.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ci-webhook", Namespace: ctrlcommon.MCONamespace},
+		Data:       map[string][]byte{progressWebhookSigningKey: []byte("s3cr3t")},
+	}
+	ctrl := &Controller{kubeClient: k8sfake.NewSimpleClientset(secret)}
+	event := mcfgv1.PoolProgressEvent{Type: mcfgv1.PoolProgressCompleted, Pool: "worker", MachineConfig: "v1"}
+
+	require.NoError(t, ctrl.sendProgressEvent(mcfgv1.ProgressWebhook{URL: server.URL, SigningSecret: "ci-webhook"}, event))
+	var got mcfgv1.PoolProgressEvent
+	require.NoError(t, json.Unmarshal(body, &got))
+	assert.Equal(t, event.Type, got.Type)
+	assert.Equal(t, event.MachineConfig, got.MachineConfig)
+	assert.Equal(t, signProgressEvent(body, []byte("s3cr3t")), signature)
+	assert.Regexp(t, "^sha256=[0-9a-f]{64}$", signature)
+
+	err := ctrl.sendProgressEvent(mcfgv1.ProgressWebhook{URL: server.URL, SigningSecret: "missing"}, event)
+	assert.Error(t, err)
+	err = ctrl.sendProgressEvent(mcfgv1.ProgressWebhook{URL: "ftp://ci.example.com", SigningSecret: "ci-webhook"}, event)
+	assert.Contains(t, err.Error(), "unsupported url")
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	err = ctrl.sendProgressEvent(mcfgv1.ProgressWebhook{URL: failing.URL, SigningSecret: "ci-webhook"}, event)
+	assert.Contains(t, err.Error(), "unexpected status 503")
+}
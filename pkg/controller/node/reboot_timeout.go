@@ -0,0 +1,169 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NodeRebootTimedOutCondition is set on the nodes which didn't come back
+// within the reboot timeout of their pool.  A MachineHealthCheck can list it
+// in its unhealthyConditions to remediate such nodes.
+const NodeRebootTimedOutCondition corev1.NodeConditionType = "MachineConfigRebootTimedOut"
+
+const (
+	rebootTimedOutReason  = "RebootTimedOut"
+	rebootRecoveredReason = "NodeRecovered"
+)
+
+// rebootTimeouts are the nodes of a pool which didn't come back within its reboot timeout.
+type rebootTimeouts struct {
+	// timedOut maps the timed out nodes to the time they went NotReady
+	timedOut map[string]time.Time
+	// next is the time until the next updating node times out, or 0 if none is waiting
+	next time.Duration
+}
+
+// updatingNotReadySince returns when a node updating to a new config went
+// NotReady, i.e. presumably rebooted, or false if it isn't such a node.
+func updatingNotReadySince(node *corev1.Node) (time.Time, bool) {
+	if node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] == node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] {
+		return time.Time{}, false
+	}
+	if !isNodeMCDState(node, daemonconsts.MachineConfigDaemonStateWorking) {
+		return time.Time{}, false
+	}
+	for _, cond := range node.Status.Conditions {
+		if cond.Type != corev1.NodeReady {
+			continue
+		}
+		if cond.Status == corev1.ConditionTrue {
+			return time.Time{}, false
+		}
+		return cond.LastTransitionTime.Time, true
+	}
+	return time.Time{}, false
+}
+
+// getRebootTimeouts returns the nodes of the pool which have been updating
+// and NotReady for longer than its reboot timeout, if any is set.
+func getRebootTimeouts(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, now time.Time) *rebootTimeouts {
+	rt := &rebootTimeouts{timedOut: map[string]time.Time{}}
+	if pool.Spec.RebootTimeout == nil {
+		return rt
+	}
+	timeout := pool.Spec.RebootTimeout.Timeout.Duration
+	for _, node := range nodes {
+		since, ok := updatingNotReadySince(node)
+		if !ok {
+			continue
+		}
+		remaining := since.Add(timeout).Sub(now)
+		if remaining <= 0 {
+			rt.timedOut[node.Name] = since
+			continue
+		}
+		if rt.next == 0 || remaining < rt.next {
+			rt.next = remaining
+		}
+	}
+	return rt
+}
+
+// excludeTimedOutNodes returns the nodes to count against maxUnavailable.
+// With the Continue action the timed out nodes are left out, so the rollout
+// proceeds with the remaining nodes.  Once more than maxUnavailable nodes
+// timed out, the config itself likely keeps nodes from coming back, so they
+// all count again and the rollout stalls.
+func excludeTimedOutNodes(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, rt *rebootTimeouts, maxUnavailable int) []*corev1.Node {
+	if pool.Spec.RebootTimeout == nil || pool.Spec.RebootTimeout.Action != mcfgv1.RebootTimeoutActionContinue {
+		return nodes
+	}
+	if len(rt.timedOut) == 0 || len(rt.timedOut) > maxUnavailable {
+		return nodes
+	}
+	var remaining []*corev1.Node
+	for _, node := range nodes {
+		if _, ok := rt.timedOut[node.Name]; !ok {
+			remaining = append(remaining, node)
+		}
+	}
+	return remaining
+}
+
+func getNodeCondition(node *corev1.Node, condType corev1.NodeConditionType) *corev1.NodeCondition {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == condType {
+			return &node.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// syncRebootTimeouts marks the nodes of the pool which timed out with the
+// MachineConfigRebootTimedOut condition, clears it once they're back, and
+// requeues the pool for when the next node times out.
+func (ctrl *Controller) syncRebootTimeouts(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, now time.Time) (*rebootTimeouts, error) {
+	rt := getRebootTimeouts(pool, nodes, now)
+	for _, node := range nodes {
+		cond := getNodeCondition(node, NodeRebootTimedOutCondition)
+		marked := cond != nil && cond.Status == corev1.ConditionTrue
+		since, timedOut := rt.timedOut[node.Name]
+		if !timedOut {
+			ctrlcommon.MachineConfigControllerNodeRebootTimedOut.DeleteLabelValues(pool.Name, node.Name)
+			if marked {
+				ctrl.logPoolNode(pool, node, "No longer waiting for the node to come back from its update")
+				if err := ctrl.setNodeRebootTimedOutCondition(node.Name, corev1.ConditionFalse, rebootRecoveredReason, ""); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		ctrlcommon.MachineConfigControllerNodeRebootTimedOut.WithLabelValues(pool.Name, node.Name).Set(float64(since.UTC().Unix()))
+		if marked {
+			continue
+		}
+		timeout := pool.Spec.RebootTimeout.Timeout.Duration
+		desired := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]
+		msg := fmt.Sprintf("Node did not come back within %s of going NotReady while updating to %s", timeout, desired)
+		ctrl.logPoolNode(pool, node, "%s", msg)
+		if err := ctrl.setNodeRebootTimedOutCondition(node.Name, corev1.ConditionTrue, rebootTimedOutReason, msg); err != nil {
+			return nil, err
+		}
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "NodeRebootTimedOut", "Node %s did not come back within %s of going NotReady while updating to %s", node.Name, timeout, desired)
+	}
+	if rt.next > 0 {
+		ctrl.enqueueAfter(pool, rt.next)
+	}
+	return rt, nil
+}
+
+// setNodeRebootTimedOutCondition patches the MachineConfigRebootTimedOut condition of the node.
+func (ctrl *Controller) setNodeRebootTimedOutCondition(nodeName string, status corev1.ConditionStatus, reason, message string) error {
+	now := metav1.Now()
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []corev1.NodeCondition{{
+				Type:               NodeRebootTimedOutCondition,
+				Status:             status,
+				Reason:             reason,
+				Message:            message,
+				LastHeartbeatTime:  now,
+				LastTransitionTime: now,
+			}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = ctrl.kubeClient.CoreV1().Nodes().PatchStatus(context.TODO(), nodeName, patch)
+	return err
+}
@@ -0,0 +1,148 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func newRebootingNode(name string, ready corev1.ConditionStatus, state string, since time.Time) *corev1.Node {
+	node := newNodeWithReadyAndDaemonState(name, "v0", "v1", ready, state)
+	node.Status.Conditions[0].LastTransitionTime = metav1.NewTime(since)
+	return node
+}
+
+func TestGetRebootTimeouts(t *testing.T) {
+	now := time.Now()
+	nodes := []*corev1.Node{
+		newRebootingNode("node-0", corev1.ConditionFalse, daemonconsts.MachineConfigDaemonStateWorking, now.Add(-20*time.Minute)),
+		newRebootingNode("node-1", corev1.ConditionUnknown, daemonconsts.MachineConfigDaemonStateWorking, now.Add(-5*time.Minute)),
+		newRebootingNode("node-2", corev1.ConditionFalse, daemonconsts.MachineConfigDaemonStateWorking, now.Add(-8*time.Minute)),
+		// back up, or failing rather than rebooting
+		newRebootingNode("node-3", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateWorking, now.Add(-20*time.Minute)),
+		newRebootingNode("node-4", corev1.ConditionFalse, daemonconsts.MachineConfigDaemonStateDegraded, now.Add(-20*time.Minute)),
+		newNodeWithReadyAndDaemonState("node-5", "v1", "v1", corev1.ConditionFalse, daemonconsts.MachineConfigDaemonStateDone),
+	}
+
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	rt := getRebootTimeouts(pool, nodes, now)
+	assert.Empty(t, rt.timedOut)
+	assert.Zero(t, rt.next)
+
+	pool.Spec.RebootTimeout = &mcfgv1.RebootTimeoutPolicy{Timeout: metav1.Duration{Duration: 10 * time.Minute}}
+	rt = getRebootTimeouts(pool, nodes, now)
+	assert.Equal(t, map[string]time.Time{"node-0": nodes[0].Status.Conditions[0].LastTransitionTime.Time}, rt.timedOut)
+	assert.Equal(t, 2*time.Minute, rt.next)
+}
+
+func TestExcludeTimedOutNodes(t *testing.T) {
+	now := time.Now()
+	nodes := []*corev1.Node{
+		newRebootingNode("node-0", corev1.ConditionFalse, daemonconsts.MachineConfigDaemonStateWorking, now.Add(-20*time.Minute)),
+		newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+		newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+	}
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	pool.Spec.RebootTimeout = &mcfgv1.RebootTimeoutPolicy{Timeout: metav1.Duration{Duration: 10 * time.Minute}}
+
+	// the rollout stalls by default
+	rt := getRebootTimeouts(pool, nodes, now)
+	assert.Equal(t, nodes, excludeTimedOutNodes(pool, nodes, rt, 1))
+	assert.Empty(t, getCandidateMachines(pool, excludeTimedOutNodes(pool, nodes, rt, 1), 1))
+
+	pool.Spec.RebootTimeout.Action = mcfgv1.RebootTimeoutActionContinue
+	remaining := excludeTimedOutNodes(pool, nodes, rt, 1)
+	assert.Equal(t, nodes[1:], remaining)
+	assert.Equal(t, []*corev1.Node{nodes[1]}, getCandidateMachines(pool, remaining, 1))
+
+	// more timed out nodes than maxUnavailable stall the rollout again
+	nodes[1] = newRebootingNode("node-1", corev1.ConditionFalse, daemonconsts.MachineConfigDaemonStateWorking, now.Add(-20*time.Minute))
+	rt = getRebootTimeouts(pool, nodes, now)
+	assert.Equal(t, nodes, excludeTimedOutNodes(pool, nodes, rt, 1))
+}
+
+func TestSyncRebootTimeouts(t *testing.T) {
+	now := time.Now()
+	timedOut := newRebootingNode("node-0", corev1.ConditionFalse, daemonconsts.MachineConfigDaemonStateWorking, now.Add(-20*time.Minute))
+	recovered := newNodeWithReady("node-1", "v1", "v1", corev1.ConditionTrue)
+	recovered.Status.Conditions = append(recovered.Status.Conditions, corev1.NodeCondition{Type: NodeRebootTimedOutCondition, Status: corev1.ConditionTrue})
+
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	pool.Spec.RebootTimeout = &mcfgv1.RebootTimeoutPolicy{Timeout: metav1.Duration{Duration: 10 * time.Minute}}
+
+	f := newFixture(t)
+	f.kubeobjects = []runtime.Object{timedOut, recovered}
+	c := f.newController()
+
+	rt, err := c.syncRebootTimeouts(pool, []*corev1.Node{timedOut, recovered}, now)
+	require.NoError(t, err)
+	assert.Contains(t, rt.timedOut, "node-0")
+
+	node, err := f.kubeclient.CoreV1().Nodes().Get(context.TODO(), "node-0", metav1.GetOptions{})
+	require.NoError(t, err)
+	cond := getNodeCondition(node, NodeRebootTimedOutCondition)
+	require.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, rebootTimedOutReason, cond.Reason)
+	assert.Equal(t, float64(timedOut.Status.Conditions[0].LastTransitionTime.UTC().Unix()), testutil.ToFloat64(ctrlcommon.MachineConfigControllerNodeRebootTimedOut.WithLabelValues("worker", "node-0")))
+
+	node, err = f.kubeclient.CoreV1().Nodes().Get(context.TODO(), "node-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	cond = getNodeCondition(node, NodeRebootTimedOutCondition)
+	require.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	// the ready condition is left alone
+	assert.Equal(t, corev1.ConditionTrue, getNodeCondition(node, corev1.NodeReady).Status)
+}
+
+func TestDeleteNodeMetrics(t *testing.T) {
+	worker := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	infra := helpers.NewMachineConfigPool("infra", nil, helpers.InfraSelector, "v1")
+	node := newNodeWithLabel("metrics-node", "v1", "v1", map[string]string{"node-role/worker": ""})
+	infraNode := newNodeWithLabel("metrics-node", "v1", "v1", map[string]string{"node-role/worker": "", "node-role/infra": ""})
+
+	f := newFixture(t)
+	f.mcpLister = []*mcfgv1.MachineConfigPool{worker, infra}
+	c := f.newController()
+	var enqueued []string
+	c.enqueueMachineConfigPool = func(pool *mcfgv1.MachineConfigPool) {
+		enqueued = append(enqueued, pool.Name)
+	}
+
+	// reported returns whether either series of the node in pool exists
+	reported := func(pool string) bool {
+		deferred := testutil.CollectAndCount(ctrlcommon.MachineConfigControllerNodeRebootDeferred)
+		timedOut := testutil.CollectAndCount(ctrlcommon.MachineConfigControllerNodeRebootTimedOut)
+		ctrlcommon.MachineConfigControllerNodeRebootDeferred.WithLabelValues(pool, node.Name)
+		ctrlcommon.MachineConfigControllerNodeRebootTimedOut.WithLabelValues(pool, node.Name)
+		return deferred == testutil.CollectAndCount(ctrlcommon.MachineConfigControllerNodeRebootDeferred) ||
+			timedOut == testutil.CollectAndCount(ctrlcommon.MachineConfigControllerNodeRebootTimedOut)
+	}
+
+	// labeled into the infra pool
+	ctrlcommon.MachineConfigControllerNodeRebootDeferred.WithLabelValues("worker", node.Name).Set(0)
+	ctrlcommon.MachineConfigControllerNodeRebootTimedOut.WithLabelValues("worker", node.Name).Set(1)
+	c.updateNode(node, infraNode)
+	assert.False(t, reported("worker"))
+	assert.Contains(t, enqueued, "worker")
+
+	// deleted
+	ctrlcommon.MachineConfigControllerNodeRebootDeferred.WithLabelValues("infra", node.Name).Set(0)
+	ctrlcommon.MachineConfigControllerNodeRebootTimedOut.WithLabelValues("infra", node.Name).Set(1)
+	c.deleteNode(infraNode)
+	assert.False(t, reported("infra"))
+	deleteNodeMetrics("worker", node.Name)
+	deleteNodeMetrics("infra", node.Name)
+}
@@ -0,0 +1,166 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// DefaultNodeUpdateDuration is the time a single node is assumed to take to
+// drain, update and reboot when estimating rollouts.
+const DefaultNodeUpdateDuration = 10 * time.Minute
+
+// RolloutPlan is the predicted rollout of the target config of a pool.
+type RolloutPlan struct {
+	// Pool is the name of the pool
+	Pool string
+	// TargetConfig is the rendered config the nodes are updated to
+	TargetConfig string
+	// Paused is set if the pool is paused, the plan only starts once it's unpaused
+	Paused bool
+	// MaxUnavailable is the number of nodes that may be unavailable at the same time
+	MaxUnavailable int
+	// InProgress are the nodes already updating to the target config
+	InProgress []string
+	// Batches are the nodes the node controller targets at the same time, in order
+	Batches [][]string
+	// BatchStarts are the estimated times from now at which the batches start
+	BatchStarts []time.Duration
+	// Blocked are the nodes that won't complete the update, e.g. because they're
+	// degraded or because unavailable nodes use up the disruption budget
+	Blocked []string
+	// EstimatedDuration is the estimated time until all the nodes that can be updated are
+	EstimatedDuration time.Duration
+}
+
+// SimulateRollout predicts how the node controller rolls out the target config
+// of the named pool, given the current pools and nodes, without changing
+// anything.  Every node is assumed to take nodeUpdateDuration to update and
+// every batch waits for the previous one to complete.  If maxUnavailableOverride is
+// set it overrides the disruption budget of the pool, to compare settings.
+// The batches of a pool with adaptive concurrency grow as its updates succeed.
+//
+// The controller doesn't order candidates within a pool, the simulation
+// picks them in name order.
+func SimulateRollout(poolName string, pools []*mcfgv1.MachineConfigPool, nodes []*corev1.Node, maxUnavailableOverride *intstrutil.IntOrString, nodeUpdateDuration time.Duration) (*RolloutPlan, error) {
+	var pool *mcfgv1.MachineConfigPool
+	for _, p := range pools {
+		if p.Name == poolName {
+			pool = p.DeepCopy()
+			break
+		}
+	}
+	if pool == nil {
+		return nil, fmt.Errorf("pool %s not found", poolName)
+	}
+	if maxUnavailableOverride != nil {
+		pool.Spec.MaxUnavailable = maxUnavailableOverride
+	}
+	targetConfig := pool.Spec.Configuration.Name
+	if targetConfig == "" {
+		return nil, fmt.Errorf("pool %s has no target config yet", poolName)
+	}
+
+	// Work on copies of the nodes of the pool, which are updated as the simulation goes.
+	var simNodes []*corev1.Node
+	for _, node := range nodes {
+		if isWindows(node) {
+			continue
+		}
+		pl, err := getPoolsForNodeFromList(pools, node)
+		if err != nil {
+			return nil, err
+		}
+		if len(pl) == 0 || pl[0].Name != poolName {
+			continue
+		}
+		simNode := node.DeepCopy()
+		if simNode.Annotations == nil {
+			simNode.Annotations = map[string]string{}
+		}
+		simNodes = append(simNodes, simNode)
+	}
+	sort.Slice(simNodes, func(i, j int) bool { return simNodes[i].Name < simNodes[j].Name })
+
+	maxunavail, err := maxUnavailable(pool, simNodes)
+	if err != nil {
+		return nil, err
+	}
+	plan := &RolloutPlan{
+		Pool:           poolName,
+		TargetConfig:   targetConfig,
+		Paused:         pool.Spec.Paused,
+		MaxUnavailable: maxunavail,
+	}
+	for _, node := range simNodes {
+		if isUpdatingTo(node, targetConfig) {
+			plan.InProgress = append(plan.InProgress, node.Name)
+		}
+	}
+
+	// With adaptive concurrency, every update is assumed to succeed if it
+	// takes no longer than expected.
+	var adaptive *mcfgv1.AdaptiveConcurrencyStatus
+	if pool.Spec.AdaptiveConcurrency != nil {
+		adaptive = initialAdaptiveConcurrency(pool)
+	}
+
+	for {
+		limit := maxunavail
+		if adaptive != nil && int(adaptive.Limit) < limit {
+			limit = int(adaptive.Limit)
+		}
+		candidates, capacity := getAllCandidateMachines(pool, simNodes, limit)
+		if uint(len(candidates)) > capacity {
+			candidates = candidates[:capacity]
+		}
+		var batch []string
+		for _, node := range candidates {
+			node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] = targetConfig
+			node.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateWorking
+			batch = append(batch, node.Name)
+		}
+
+		var updating []*corev1.Node
+		for _, node := range simNodes {
+			if isUpdatingTo(node, targetConfig) {
+				updating = append(updating, node)
+			}
+		}
+		if len(updating) == 0 {
+			break
+		}
+		if len(batch) > 0 {
+			plan.Batches = append(plan.Batches, batch)
+			plan.BatchStarts = append(plan.BatchStarts, plan.EstimatedDuration)
+		}
+		plan.EstimatedDuration += nodeUpdateDuration
+		for _, node := range updating {
+			if adaptive != nil {
+				recordUpdateOutcome(adaptive, nodeUpdateDuration <= pool.Spec.AdaptiveConcurrency.ExpectedNodeUpdateDuration.Duration, maxunavail)
+			}
+			node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] = targetConfig
+			node.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateDone
+			node.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+			node.Spec.Unschedulable = false
+		}
+	}
+
+	for _, node := range simNodes {
+		if !isNodeDoneAt(node, targetConfig) {
+			plan.Blocked = append(plan.Blocked, node.Name)
+		}
+	}
+	return plan, nil
+}
+
+// isUpdatingTo returns true if the node is targeted to config and is expected
+// to get there, i.e. isn't done yet and isn't failing.
+func isUpdatingTo(node *corev1.Node, config string) bool {
+	return node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == config && !isNodeDone(node) && !isNodeMCDFailing(node)
+}
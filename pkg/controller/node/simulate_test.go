@@ -0,0 +1,67 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestSimulateRollout(t *testing.T) {
+	workerNode := func(name, current, desired, state string) *corev1.Node {
+		node := newNodeWithReadyAndDaemonState(name, current, desired, corev1.ConditionTrue, state)
+		node.Labels = map[string]string{"node-role/worker": ""}
+		return node
+	}
+	pools := []*mcfgv1.MachineConfigPool{
+		helpers.NewMachineConfigPool("master", nil, helpers.MasterSelector, "v1"),
+		helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1"),
+	}
+	master := newNodeWithReady("master-0", "v0", "v0", corev1.ConditionTrue)
+	master.Labels = map[string]string{"node-role/master": ""}
+	nodes := []*corev1.Node{
+		master,
+		workerNode("worker-4", "v0", "v0", daemonconsts.MachineConfigDaemonStateDone),
+		workerNode("worker-3", "v0", "v0", daemonconsts.MachineConfigDaemonStateDone),
+		workerNode("worker-2", "v0", "v0", daemonconsts.MachineConfigDaemonStateDone),
+		workerNode("worker-1", "v0", "v1", daemonconsts.MachineConfigDaemonStateWorking),
+		workerNode("worker-0", "v1", "v1", daemonconsts.MachineConfigDaemonStateDone),
+	}
+
+	plan, err := SimulateRollout("worker", pools, nodes, nil, 10*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, plan.MaxUnavailable)
+	assert.Equal(t, []string{"worker-1"}, plan.InProgress)
+	assert.Equal(t, [][]string{{"worker-2"}, {"worker-3"}, {"worker-4"}}, plan.Batches)
+	assert.Equal(t, []time.Duration{10 * time.Minute, 20 * time.Minute, 30 * time.Minute}, plan.BatchStarts)
+	assert.Empty(t, plan.Blocked)
+	assert.Equal(t, 40*time.Minute, plan.EstimatedDuration)
+	// nothing was changed
+	assert.Equal(t, "v0", nodes[1].Annotations[daemonconsts.DesiredMachineConfigAnnotationKey])
+
+	maxUnavailable := intstrutil.FromString("50%")
+	plan, err = SimulateRollout("worker", pools, nodes, &maxUnavailable, 10*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 2, plan.MaxUnavailable)
+	assert.Equal(t, [][]string{{"worker-2"}, {"worker-3", "worker-4"}}, plan.Batches)
+	assert.Equal(t, []time.Duration{0, 10 * time.Minute}, plan.BatchStarts)
+	assert.Equal(t, 20*time.Minute, plan.EstimatedDuration)
+
+	// a degraded node uses up the budget
+	nodes[4] = workerNode("worker-1", "v0", "v1", daemonconsts.MachineConfigDaemonStateDegraded)
+	plan, err = SimulateRollout("worker", pools, nodes, nil, 10*time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Batches)
+	assert.Equal(t, []string{"worker-1", "worker-2", "worker-3", "worker-4"}, plan.Blocked)
+	assert.Zero(t, plan.EstimatedDuration)
+
+	_, err = SimulateRollout("infra", pools, nodes, nil, 10*time.Minute)
+	assert.Error(t, err)
+}
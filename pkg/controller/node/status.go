@@ -2,11 +2,15 @@ package node
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/golang/glog"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -19,14 +23,29 @@ func (ctrl *Controller) syncStatusOnly(pool *mcfgv1.MachineConfigPool) error {
 		return err
 	}
 
+	freeze, err := ctrlcommon.GetClusterFreeze(ctrl.mcfLister)
+	if err != nil {
+		return err
+	}
+	deferred, err := getDeferredUpdates(pool, nodes, freeze)
+	if err != nil {
+		return err
+	}
+	setDeferredRebootMetrics(pool, nodes, deferred, time.Now())
+
 	newStatus := calculateStatus(pool, nodes)
+	setRebootDeferredCondition(&newStatus, deferred)
 	if equality.Semantic.DeepEqual(pool.Status, newStatus) {
 		return nil
 	}
 
+	oldStatus := pool.Status
 	newPool := pool
 	newPool.Status = newStatus
 	_, err = ctrl.client.MachineconfigurationV1().MachineConfigPools().UpdateStatus(context.TODO(), newPool, metav1.UpdateOptions{})
+	if err == nil && len(pool.Spec.ProgressWebhooks) > 0 {
+		ctrl.notifyProgressWebhooks(pool, getProgressEvents(pool, oldStatus, newStatus, time.Now()))
+	}
 	if pool.Spec.Configuration.Name != newPool.Spec.Configuration.Name {
 		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "Updating", "Pool %s now targeting %s", pool.Name, newPool.Spec.Configuration.Name)
 	}
@@ -52,7 +71,12 @@ func calculateStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) mcfgv
 	degradedReasons := []string{}
 	for _, n := range degradedMachines {
 		reason, ok := n.Annotations[daemonconsts.MachineConfigDaemonReasonAnnotationKey]
-		if ok && reason != "" {
+		if !ok || reason == "" {
+			continue
+		}
+		if errorReason := n.Annotations[daemonconsts.MachineConfigDaemonErrorReasonAnnotationKey]; errorReason != "" {
+			degradedReasons = append(degradedReasons, fmt.Sprintf("Node %s is reporting %s: %q", n.Name, errorReason, reason))
+		} else {
 			degradedReasons = append(degradedReasons, fmt.Sprintf("Node %s is reporting: %q", n.Name, reason))
 		}
 	}
@@ -65,9 +89,14 @@ func calculateStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) mcfgv
 		ReadyMachineCount:       readyMachineCount,
 		UnavailableMachineCount: unavailableMachineCount,
 		DegradedMachineCount:    degradedMachineCount,
+		DegradedReasons:         getDegradedReasons(degradedMachines),
+		DrainBlockers:           getDrainBlockers(nodes),
 	}
 
 	status.Configuration = pool.Status.Configuration
+	if pool.Spec.AdaptiveConcurrency != nil {
+		status.AdaptiveConcurrency = pool.Status.AdaptiveConcurrency
+	}
 
 	conditions := pool.Status.Conditions
 	for i := range conditions {
@@ -127,6 +156,71 @@ func calculateStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) mcfgv
 	return status
 }
 
+// getDegradedReasons counts the degraded machines by the class of the error
+// they report, falling back to their state, most frequent first.
+func getDegradedReasons(degraded []*corev1.Node) []mcfgv1.PoolDegradedReason {
+	counts := map[string]int32{}
+	for _, node := range degraded {
+		reason := node.Annotations[daemonconsts.MachineConfigDaemonErrorReasonAnnotationKey]
+		if reason == "" {
+			reason = node.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey]
+		}
+		counts[reason]++
+	}
+
+	var reasons []mcfgv1.PoolDegradedReason
+	for reason, count := range counts {
+		reasons = append(reasons, mcfgv1.PoolDegradedReason{Reason: reason, MachineCount: count})
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if reasons[i].MachineCount != reasons[j].MachineCount {
+			return reasons[i].MachineCount > reasons[j].MachineCount
+		}
+		return reasons[i].Reason < reasons[j].Reason
+	})
+	return reasons
+}
+
+// getDrainBlockers merges the PodDisruptionBudgets blocking the drain of the
+// nodes, as reported by their daemons.
+func getDrainBlockers(nodes []*corev1.Node) []mcfgv1.PoolDrainBlocker {
+	var blockers []mcfgv1.PoolDrainBlocker
+	index := map[string]int{}
+	for _, node := range nodes {
+		annotation := node.Annotations[daemonconsts.DrainBlockersAnnotationKey]
+		if annotation == "" {
+			continue
+		}
+		var nodeBlockers []mcfgv1.PoolDrainBlocker
+		if err := json.Unmarshal([]byte(annotation), &nodeBlockers); err != nil {
+			glog.Warningf("Ignoring invalid drain blockers annotation on node %s: %v", node.Name, err)
+			continue
+		}
+		for _, b := range nodeBlockers {
+			key := b.Namespace + "/" + b.Name
+			i, ok := index[key]
+			if !ok {
+				i = len(blockers)
+				index[key] = i
+				b.Nodes = nil
+				blockers = append(blockers, b)
+			}
+			blockers[i].Nodes = append(blockers[i].Nodes, node.Name)
+		}
+	}
+
+	for i := range blockers {
+		sort.Strings(blockers[i].Nodes)
+	}
+	sort.Slice(blockers, func(i, j int) bool {
+		if blockers[i].Namespace != blockers[j].Namespace {
+			return blockers[i].Namespace < blockers[j].Namespace
+		}
+		return blockers[i].Name < blockers[j].Name
+	})
+	return blockers
+}
+
 // isNodeManaged checks whether the MCD has ever run on a node
 func isNodeManaged(node *corev1.Node) bool {
 	if isWindows(node) {
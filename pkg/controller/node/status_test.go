@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -646,3 +647,93 @@ func TestCalculateStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateStatusDegradedReasons(t *testing.T) {
+	drainTimeout := newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionFalse, daemonconsts.MachineConfigDaemonStateDegraded)
+	drainTimeout.Annotations[daemonconsts.MachineConfigDaemonReasonAnnotationKey] = "failed to drain node : node-0 after 1 hour"
+	drainTimeout.Annotations[daemonconsts.MachineConfigDaemonErrorReasonAnnotationKey] = ctrlcommon.ErrorReasonDrainTimeout
+	uncategorized := newNodeWithReadyAndDaemonState("node-1", "v0", "v1", corev1.ConditionFalse, daemonconsts.MachineConfigDaemonStateDegraded)
+	uncategorized.Annotations[daemonconsts.MachineConfigDaemonReasonAnnotationKey] = "boom"
+
+	pool := &mcfgv1.MachineConfigPool{
+		Spec: mcfgv1.MachineConfigPoolSpec{
+			Configuration: mcfgv1.MachineConfigPoolStatusConfiguration{ObjectReference: corev1.ObjectReference{Name: "v1"}},
+		},
+	}
+	status := calculateStatus(pool, []*corev1.Node{drainTimeout, uncategorized})
+	cond := mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolNodeDegraded)
+	if cond == nil {
+		t.Fatal("node degraded condition not found")
+	}
+	if got, want := cond.Message, `Node node-0 is reporting DrainTimeout: "failed to drain node : node-0 after 1 hour", Node node-1 is reporting: "boom"`; got != want {
+		t.Fatalf("mismatch cond.Message: got %s want: %s", got, want)
+	}
+}
+
+func TestCalculateStatusDrainBlockers(t *testing.T) {
+	node0 := newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateWorking)
+	node0.Annotations[daemonconsts.DrainBlockersAnnotationKey] = `[{"namespace":"shop","name":"frontend","owner":"Deployment/frontend","disruptionsAllowed":0},{"namespace":"db","name":"postgres","owner":"StatefulSet/postgres","disruptionsAllowed":0}]`
+	node1 := newNodeWithReadyAndDaemonState("node-1", "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateWorking)
+	node1.Annotations[daemonconsts.DrainBlockersAnnotationKey] = `[{"namespace":"shop","name":"frontend","owner":"Deployment/frontend","disruptionsAllowed":0}]`
+	invalid := newNodeWithReadyAndDaemonState("node-2", "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateWorking)
+	invalid.Annotations[daemonconsts.DrainBlockersAnnotationKey] = `{`
+	unblocked := newNodeWithReadyAndDaemonState("node-3", "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateWorking)
+
+	pool := &mcfgv1.MachineConfigPool{
+		Spec: mcfgv1.MachineConfigPoolSpec{
+			Configuration: mcfgv1.MachineConfigPoolStatusConfiguration{ObjectReference: corev1.ObjectReference{Name: "v1"}},
+		},
+	}
+	status := calculateStatus(pool, []*corev1.Node{node1, node0, invalid, unblocked})
+	expected := []mcfgv1.PoolDrainBlocker{{
+		Namespace: "db",
+		Name:      "postgres",
+		Owner:     "StatefulSet/postgres",
+		Nodes:     []string{"node-0"},
+	}, {
+		Namespace: "shop",
+		Name:      "frontend",
+		Owner:     "Deployment/frontend",
+		Nodes:     []string{"node-0", "node-1"},
+	}}
+	if !reflect.DeepEqual(status.DrainBlockers, expected) {
+		t.Fatalf("mismatch drainBlockers: got %v want: %v", status.DrainBlockers, expected)
+	}
+
+	status = calculateStatus(pool, []*corev1.Node{unblocked})
+	if status.DrainBlockers != nil {
+		t.Fatalf("expected no drainBlockers, got %v", status.DrainBlockers)
+	}
+}
+
+func TestCalculateStatusDegradedReasonsHistogram(t *testing.T) {
+	var nodes []*corev1.Node
+	for i, errorReason := range []string{ctrlcommon.ErrorReasonDrainTimeout, ctrlcommon.ErrorReasonOSUpdate, ctrlcommon.ErrorReasonDrainTimeout, "", ctrlcommon.ErrorReasonDrainTimeout} {
+		node := newNodeWithReadyAndDaemonState(fmt.Sprintf("node-%d", i), "v0", "v1", corev1.ConditionFalse, daemonconsts.MachineConfigDaemonStateDegraded)
+		node.Annotations[daemonconsts.MachineConfigDaemonErrorReasonAnnotationKey] = errorReason
+		nodes = append(nodes, node)
+	}
+	nodes = append(nodes,
+		newNodeWithReadyAndDaemonState("node-5", "v0", "v1", corev1.ConditionFalse, daemonconsts.MachineConfigDaemonStateUnreconcilable),
+		newNodeWithReadyAndDaemonState("node-6", "v1", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDone),
+	)
+
+	pool := &mcfgv1.MachineConfigPool{
+		Spec: mcfgv1.MachineConfigPoolSpec{
+			Configuration: mcfgv1.MachineConfigPoolStatusConfiguration{ObjectReference: corev1.ObjectReference{Name: "v1"}},
+		},
+	}
+	status := calculateStatus(pool, nodes)
+	expected := []mcfgv1.PoolDegradedReason{
+		{Reason: ctrlcommon.ErrorReasonDrainTimeout, MachineCount: 3},
+		{Reason: daemonconsts.MachineConfigDaemonStateDegraded, MachineCount: 1},
+		{Reason: ctrlcommon.ErrorReasonOSUpdate, MachineCount: 1},
+		{Reason: daemonconsts.MachineConfigDaemonStateUnreconcilable, MachineCount: 1},
+	}
+	if !reflect.DeepEqual(status.DegradedReasons, expected) {
+		t.Fatalf("mismatch degradedReasons: got %v want: %v", status.DegradedReasons, expected)
+	}
+	if status.DegradedMachineCount != 6 {
+		t.Fatalf("mismatch degradedMachineCount: got %d want: 6", status.DegradedMachineCount)
+	}
+}
@@ -0,0 +1,101 @@
+package node
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// nodeUpdateTaint returns the update taint the node controller set on a
+// node, or nil if there is none or it can't be parsed.
+func nodeUpdateTaint(node *corev1.Node) *mcfgv1.UpdateTaint {
+	value := node.Annotations[daemonconsts.UpdateTaintAnnotationKey]
+	if value == "" {
+		return nil
+	}
+	taint := &mcfgv1.UpdateTaint{}
+	if err := json.Unmarshal([]byte(value), taint); err != nil {
+		glog.Warningf("Ignoring invalid %s annotation of node %s: %v", daemonconsts.UpdateTaintAnnotationKey, node.Name, err)
+		return nil
+	}
+	return taint
+}
+
+// isNodeUpdating returns whether a node was targeted to a config it isn't
+// done updating to yet.
+func isNodeUpdating(node *corev1.Node) bool {
+	return node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] != "" && !isNodeDone(node)
+}
+
+// syncUpdateTaints sets the update taint of the pool on its updating nodes
+// and removes it from the nodes which are done updating and Ready.  Other
+// nodes keep the taint they have, if any.
+func (ctrl *Controller) syncUpdateTaints(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	for _, node := range nodes {
+		switch {
+		case isNodeUpdating(node):
+			if err := ctrl.setUpdateTaint(node, pool.Spec.UpdateTaint); err != nil {
+				return err
+			}
+		case isNodeDone(node) && isNodeReady(node):
+			if err := ctrl.setUpdateTaint(node, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setUpdateTaint replaces the update taint of a node, recorded in its
+// annotation, with taint, or removes it if taint is nil.
+func (ctrl *Controller) setUpdateTaint(node *corev1.Node, taint *mcfgv1.UpdateTaint) error {
+	current := nodeUpdateTaint(node)
+	if reflect.DeepEqual(current, taint) {
+		return nil
+	}
+	value := ""
+	if taint != nil {
+		data, err := json.Marshal(taint)
+		if err != nil {
+			return err
+		}
+		value = string(data)
+	}
+
+	_, err := internal.UpdateNodeRetry(ctrl.kubeClient.CoreV1().Nodes(), ctrl.nodeLister, node.Name, func(node *corev1.Node) {
+		taints := []corev1.Taint{}
+		for _, t := range node.Spec.Taints {
+			if current != nil && t.Key == current.Key && t.Effect == current.Effect {
+				continue
+			}
+			if taint != nil && t.Key == taint.Key && t.Effect == taint.Effect {
+				continue
+			}
+			taints = append(taints, t)
+		}
+		if taint == nil {
+			delete(node.Annotations, daemonconsts.UpdateTaintAnnotationKey)
+		} else {
+			taints = append(taints, corev1.Taint{Key: taint.Key, Value: taint.Value, Effect: taint.Effect})
+			if node.Annotations == nil {
+				node.Annotations = map[string]string{}
+			}
+			node.Annotations[daemonconsts.UpdateTaintAnnotationKey] = value
+		}
+		node.Spec.Taints = taints
+	})
+	if err != nil {
+		return err
+	}
+	if taint == nil {
+		glog.Infof("Removed update taint %s:%s from node %s", current.Key, current.Effect, node.Name)
+	} else {
+		glog.Infof("Set update taint %s:%s on node %s", taint.Key, taint.Effect, node.Name)
+	}
+	return nil
+}
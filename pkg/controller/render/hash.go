@@ -7,6 +7,7 @@ import (
 
 	"github.com/ghodss/yaml"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 )
 
 var (
@@ -32,6 +33,11 @@ func getMachineConfigHashedName(pool *mcfgv1.MachineConfigPool, config *mcfgv1.M
 	if err != nil {
 		return "", err
 	}
+	// The rerender generation only takes part in the hash when set, so that the
+	// names of the configs rendered without it don't change.
+	if gen := config.Annotations[ctrlcommon.RerenderGenerationAnnotationKey]; gen != "" {
+		data = append(data, []byte("\n"+ctrlcommon.RerenderGenerationAnnotationKey+": "+gen)...)
+	}
 
 	h, err := hashData(data)
 	if err != nil {
@@ -0,0 +1,95 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/vincent-petithory/dataurl"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// pullSecretPath is where the cluster pull secret is written on the nodes.
+const pullSecretPath = "/var/lib/kubelet/config.json"
+
+// filterPullSecret leaves only the credentials of the registries of scope in
+// the pull secret of the merged MachineConfig.
+func filterPullSecret(merged *mcfgv1.MachineConfig, scope *mcfgv1.PoolPullSecret) error {
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(merged.Spec.Config.Raw)
+	if err != nil {
+		return err
+	}
+	var file *ign3types.File
+	for i := range ignCfg.Storage.Files {
+		if ignCfg.Storage.Files[i].Path == pullSecretPath {
+			file = &ignCfg.Storage.Files[i]
+		}
+	}
+	if file == nil || file.Contents.Source == nil {
+		return nil
+	}
+	data, err := ctrlcommon.DecodeIgnitionFileContents(file.Contents.Source, file.Contents.Compression)
+	if err != nil {
+		return fmt.Errorf("decoding %s: %w", pullSecretPath, err)
+	}
+	filtered, err := filterPullSecretAuths(data, scope.Registries)
+	if err != nil {
+		return fmt.Errorf("filtering %s: %w", pullSecretPath, err)
+	}
+	source := dataurl.EncodeBytes(filtered)
+	file.Contents.Source = &source
+	file.Contents.Compression = nil
+	file.Contents.Verification = ign3types.Verification{}
+
+	rawIgn, err := json.Marshal(ignCfg)
+	if err != nil {
+		return err
+	}
+	merged.Spec.Config.Raw = rawIgn
+	return nil
+}
+
+// filterPullSecretAuths returns the pull secret data with only the auths of
+// the registries, or of their repositories, left.
+func filterPullSecretAuths(data []byte, registries []string) ([]byte, error) {
+	var secret map[string]json.RawMessage
+	if err := json.Unmarshal(data, &secret); err != nil {
+		return nil, err
+	}
+	var auths map[string]json.RawMessage
+	if raw, ok := secret["auths"]; ok {
+		if err := json.Unmarshal(raw, &auths); err != nil {
+			return nil, fmt.Errorf("invalid auths: %w", err)
+		}
+	}
+	kept := map[string]json.RawMessage{}
+	for key, auth := range auths {
+		if registryMatches(key, registries) {
+			kept[key] = auth
+		}
+	}
+	rawAuths, err := json.Marshal(kept)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		secret = map[string]json.RawMessage{}
+	}
+	secret["auths"] = rawAuths
+	return json.Marshal(secret)
+}
+
+// registryMatches returns whether the pull secret key is one of the
+// registries or a repository of one of them.
+func registryMatches(key string, registries []string) bool {
+	for _, registry := range registries {
+		registry = strings.TrimSuffix(registry, "/")
+		if key == registry || strings.HasPrefix(key, registry+"/") {
+			return true
+		}
+	}
+	return false
+}
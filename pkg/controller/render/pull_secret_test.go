@@ -0,0 +1,74 @@
+package render
+
+import (
+	"encoding/json"
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+const testPullSecret = `{"auths": {
+  "cloud.openshift.com": {"auth": "b3Blbg==", "email": "me@example.com"},
+  "quay.io": {"auth": "cXVheQ=="},
+  "mirror.example.com:5000": {"auth": "bWlycm9y"},
+  "mirror.example.com:5000/ocp/release": {"auth": "cmVsZWFzZQ=="},
+  "mirror.example.com:50001": {"auth": "b3RoZXI="}
+}}`
+
+func TestGenerateRenderedMachineConfigPullSecret(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("edge", nil, helpers.WorkerSelector, "")
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+	configs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("00-worker", map[string]string{"node-role/worker": ""}, "", []ign3types.File{
+			helpers.CreateIgn3File(pullSecretPath, dataurl.EncodeBytes([]byte(testPullSecret)), 0600),
+		}),
+	}
+	pullSecretAuths := func(mc *mcfgv1.MachineConfig) []string {
+		ignCfg, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+		require.NoError(t, err)
+		data, err := ctrlcommon.GetIgnitionFileDataByPath(&ignCfg, pullSecretPath)
+		require.NoError(t, err)
+		var secret struct {
+			Auths map[string]json.RawMessage `json:"auths"`
+		}
+		require.NoError(t, json.Unmarshal(data, &secret))
+		var keys []string
+		for key := range secret.Auths {
+			keys = append(keys, key)
+		}
+		return keys
+	}
+
+	unfiltered, err := generateRenderedMachineConfig(mcp, configs, cc)
+	require.NoError(t, err)
+	assert.Len(t, pullSecretAuths(unfiltered), 5)
+
+	mcp.Spec.PullSecret = &mcfgv1.PoolPullSecret{Registries: []string{"mirror.example.com:5000"}}
+	filtered, err := generateRenderedMachineConfig(mcp, configs, cc)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"mirror.example.com:5000", "mirror.example.com:5000/ocp/release"}, pullSecretAuths(filtered))
+	assert.NotEqual(t, unfiltered.Name, filtered.Name)
+
+	mcp.Spec.PullSecret = &mcfgv1.PoolPullSecret{}
+	empty, err := generateRenderedMachineConfig(mcp, configs, cc)
+	require.NoError(t, err)
+	assert.Empty(t, pullSecretAuths(empty))
+}
+
+func TestFilterPullSecretAuths(t *testing.T) {
+	filtered, err := filterPullSecretAuths([]byte(`{"auths": {"quay.io": {"auth": "a"}, "registry.redhat.io": {"auth": "b"}}, "credHelpers": {"gcr.io": "gcloud"}}`), []string{"quay.io/"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"auths": {"quay.io": {"auth": "a"}}, "credHelpers": {"gcr.io": "gcloud"}}`, string(filtered))
+
+	_, err = filterPullSecretAuths([]byte(`not json`), nil)
+	assert.Error(t, err)
+	_, err = filterPullSecretAuths([]byte(`{"auths": []}`), nil)
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package render
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"time"
@@ -85,7 +86,7 @@ func New(
 	ctrl := &Controller{
 		client:        mcfgClient,
 		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-rendercontroller"}),
-		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-rendercontroller"),
+		queue:         workqueue.NewNamedRateLimitingQueue(ctrlcommon.ControllerRateLimiter(), "machineconfigcontroller-rendercontroller"),
 	}
 
 	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -98,6 +99,9 @@ func New(
 		UpdateFunc: ctrl.updateMachineConfig,
 		DeleteFunc: ctrl.deleteMachineConfig,
 	})
+	ccInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: ctrl.updateControllerConfig,
+	})
 
 	ctrl.syncHandler = ctrl.syncMachineConfigPool
 	ctrl.enqueueMachineConfigPool = ctrl.enqueueDefault
@@ -224,6 +228,29 @@ func (ctrl *Controller) updateMachineConfig(old, cur interface{}) {
 	}
 }
 
+func (ctrl *Controller) updateControllerConfig(old, cur interface{}) {
+	oldCC := old.(*mcfgv1.ControllerConfig)
+	curCC := cur.(*mcfgv1.ControllerConfig)
+
+	oldGen := oldCC.Annotations[ctrlcommon.RerenderGenerationAnnotationKey]
+	curGen := curCC.Annotations[ctrlcommon.RerenderGenerationAnnotationKey]
+	if oldGen == curGen {
+		// other changes reach the pools through the machineconfigs of the template controller
+		return
+	}
+
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("error listing machineconfigpools: %v", err)
+		return
+	}
+
+	glog.V(4).Infof("ControllerConfig %s rerender generation changed from %q to %q", curCC.Name, oldGen, curGen)
+	for _, p := range pools {
+		ctrl.enqueueMachineConfigPool(p)
+	}
+}
+
 func (ctrl *Controller) deleteMachineConfig(obj interface{}) {
 	mc, ok := obj.(*mcfgv1.MachineConfig)
 
@@ -431,7 +458,7 @@ func (ctrl *Controller) syncMachineConfigPool(key string) error {
 		return err
 	}
 	if len(mcs) == 0 {
-		return ctrl.syncFailingStatus(pool, fmt.Errorf("no MachineConfigs found matching selector %v", selector))
+		return ctrl.syncFailingStatus(pool, &ctrlcommon.RenderError{Err: fmt.Errorf("no MachineConfigs found matching selector %v", selector)})
 	}
 
 	if err := ctrl.syncGeneratedMachineConfig(pool, mcs); err != nil {
@@ -454,7 +481,7 @@ func (ctrl *Controller) syncAvailableStatus(pool *mcfgv1.MachineConfigPool) erro
 }
 
 func (ctrl *Controller) syncFailingStatus(pool *mcfgv1.MachineConfigPool, err error) error {
-	sdegraded := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRenderDegraded, corev1.ConditionTrue, "", fmt.Sprintf("Failed to render configuration for pool %s: %v", pool.Name, err))
+	sdegraded := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRenderDegraded, corev1.ConditionTrue, ctrlcommon.ErrorReason(err), fmt.Sprintf("Failed to render configuration for pool %s: %v", pool.Name, err))
 	mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *sdegraded)
 	if _, updateErr := ctrl.client.MachineconfigurationV1().MachineConfigPools().UpdateStatus(context.TODO(), pool, metav1.UpdateOptions{}); updateErr != nil {
 		glog.Errorf("Error updating MachineConfigPool %s: %v", pool.Name, updateErr)
@@ -484,7 +511,7 @@ func (ctrl *Controller) syncGeneratedMachineConfig(pool *mcfgv1.MachineConfigPoo
 
 	generated, err := generateRenderedMachineConfig(pool, configs, cc)
 	if err != nil {
-		return err
+		return renderError(err)
 	}
 
 	source := []corev1.ObjectReference{}
@@ -492,7 +519,7 @@ func (ctrl *Controller) syncGeneratedMachineConfig(pool *mcfgv1.MachineConfigPoo
 		source = append(source, corev1.ObjectReference{Kind: machineconfigKind.Kind, Name: cfg.GetName(), APIVersion: machineconfigKind.GroupVersion().String()})
 	}
 
-	_, err = ctrl.mcLister.Get(generated.Name)
+	existing, err := ctrl.mcLister.Get(generated.Name)
 	if apierrors.IsNotFound(err) {
 		_, err = ctrl.client.MachineconfigurationV1().MachineConfigs().Create(context.TODO(), generated, metav1.CreateOptions{})
 		if err != nil {
@@ -505,6 +532,13 @@ func (ctrl *Controller) syncGeneratedMachineConfig(pool *mcfgv1.MachineConfigPoo
 	if err != nil {
 		return err
 	}
+	if existing != nil {
+		// The inputs may change without changing the rendered contents, keep
+		// the provenance of the inputs the config was first rendered from.
+		if provenance, ok := existing.Annotations[ctrlcommon.RenderProvenanceAnnotationKey]; ok {
+			generated.Annotations[ctrlcommon.RenderProvenanceAnnotationKey] = provenance
+		}
+	}
 
 	newPool := pool.DeepCopy()
 	newPool.Spec.Configuration.Source = source
@@ -533,6 +567,15 @@ func (ctrl *Controller) syncGeneratedMachineConfig(pool *mcfgv1.MachineConfigPoo
 	return nil
 }
 
+// renderError wraps err in a RenderError, unless it's already classified,
+// e.g. as a merge conflict.
+func renderError(err error) error {
+	if ctrlcommon.ErrorReason(err) != "" {
+		return err
+	}
+	return &ctrlcommon.RenderError{Err: err}
+}
+
 // generateRenderedMachineConfig takes all MCs for a given pool and returns a single rendered MC. For ex master-XXXX or worker-XXXX
 func generateRenderedMachineConfig(pool *mcfgv1.MachineConfigPool, configs []*mcfgv1.MachineConfig, cconfig *mcfgv1.ControllerConfig) (*mcfgv1.MachineConfig, error) {
 	// Suppress rendered config generation until a corresponding new controller can roll out too.
@@ -552,10 +595,31 @@ func generateRenderedMachineConfig(pool *mcfgv1.MachineConfigPool, configs []*mc
 		}
 	}
 
+	compression, err := configCompressionFromAnnotations(cconfig.Annotations)
+	if err != nil {
+		return nil, err
+	}
+
 	merged, err := ctrlcommon.MergeMachineConfigs(configs, cconfig.Spec.OSImageURL)
 	if err != nil {
 		return nil, err
 	}
+	if pool.Spec.PullSecret != nil {
+		if err := filterPullSecret(merged, pool.Spec.PullSecret); err != nil {
+			return nil, err
+		}
+	}
+	if compression == ConfigCompressionGzip {
+		if err := compressLargeFiles(merged); err != nil {
+			return nil, err
+		}
+	}
+	if merged.Annotations == nil {
+		merged.Annotations = map[string]string{}
+	}
+	if gen := cconfig.Annotations[ctrlcommon.RerenderGenerationAnnotationKey]; gen != "" {
+		merged.Annotations[ctrlcommon.RerenderGenerationAnnotationKey] = gen
+	}
 	hashedName, err := getMachineConfigHashedName(pool, merged)
 	if err != nil {
 		return nil, err
@@ -564,12 +628,18 @@ func generateRenderedMachineConfig(pool *mcfgv1.MachineConfigPool, configs []*mc
 
 	merged.SetName(hashedName)
 	merged.SetOwnerReferences([]metav1.OwnerReference{*oref})
-	if merged.Annotations == nil {
-		merged.Annotations = map[string]string{}
-	}
 	merged.Annotations[ctrlcommon.GeneratedByControllerVersionAnnotationKey] = version.Hash
 	merged.Annotations[ctrlcommon.ReleaseImageVersionAnnotationKey] = cconfig.Annotations[ctrlcommon.ReleaseImageVersionAnnotationKey]
+	// MergeMachineConfigs sorted the configs in merge order
+	provenance, err := json.Marshal(ctrlcommon.NewRenderProvenance(configs, cconfig))
+	if err != nil {
+		return nil, err
+	}
+	merged.Annotations[ctrlcommon.RenderProvenanceAnnotationKey] = string(provenance)
 
+	if err := checkRenderedConfigSize(merged, configs, compression); err != nil {
+		return nil, err
+	}
 	return merged, nil
 }
 
@@ -1,6 +1,7 @@
 package render
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"testing"
@@ -316,6 +317,44 @@ func TestIgnValidationGenerateRenderedMachineConfig(t *testing.T) {
 
 }
 
+func TestRenderDegradedReason(t *testing.T) {
+	invalid := helpers.NewMachineConfig("05-invalid", map[string]string{"node-role/master": ""}, "dummy://1", nil)
+	invalid.Spec.Config.Raw = []byte(`{"ignition":{"version":""}}`)
+	realtime := helpers.NewMachineConfig("05-realtime", map[string]string{"node-role/master": ""}, "dummy://1", nil)
+	realtime.Spec.KernelType = ctrlcommon.KernelTypeRealtime
+	kernelDevel := helpers.NewMachineConfig("06-kernel-devel", map[string]string{"node-role/master": ""}, "dummy://1", nil)
+	kernelDevel.Spec.Extensions = []string{"kernel-devel"}
+
+	tests := []struct {
+		name   string
+		mcs    []*mcfgv1.MachineConfig
+		reason string
+	}{
+		{name: "invalid config", mcs: []*mcfgv1.MachineConfig{invalid}, reason: ctrlcommon.ErrorReasonRender},
+		{name: "merge conflict", mcs: []*mcfgv1.MachineConfig{realtime, kernelDevel}, reason: ctrlcommon.ErrorReasonMergeConflict},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			mcp := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "")
+			mcs := append([]*mcfgv1.MachineConfig{helpers.NewMachineConfig("00-test-cluster-master", map[string]string{"node-role/master": ""}, "dummy://", nil)}, test.mcs...)
+			f.ccLister = append(f.ccLister, newControllerConfig(ctrlcommon.ControllerConfigName))
+			f.mcpLister = append(f.mcpLister, mcp)
+			f.objects = append(f.objects, mcp)
+			f.mcLister = append(f.mcLister, mcs...)
+
+			c := f.newController()
+			require.Error(t, c.syncHandler(mcp.Name))
+			pool, err := f.client.MachineconfigurationV1().MachineConfigPools().Get(context.TODO(), mcp.Name, metav1.GetOptions{})
+			require.NoError(t, err)
+			cond := mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolRenderDegraded)
+			require.NotNil(t, cond)
+			assert.Equal(t, corev1.ConditionTrue, cond.Status)
+			assert.Equal(t, test.reason, cond.Reason)
+		})
+	}
+}
+
 func TestUpdatesGeneratedMachineConfig(t *testing.T) {
 	f := newFixture(t)
 	mcp := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "")
@@ -386,6 +425,106 @@ func TestGenerateMachineConfigNoOverrideOSImageURL(t *testing.T) {
 	assert.Equal(t, "dummy", gmc.Spec.OSImageURL)
 }
 
+func TestGenerateMachineConfigRerenderGeneration(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "")
+	mcs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("00-test-cluster-master", map[string]string{"node-role/master": ""}, "dummy-test-1", []ign3types.File{}),
+	}
+
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+	gmc, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	require.Nil(t, err)
+	_, ok := gmc.Annotations[ctrlcommon.RerenderGenerationAnnotationKey]
+	assert.False(t, ok)
+
+	// bumping the generation renders the same contents under a new name
+	cc.Annotations[ctrlcommon.RerenderGenerationAnnotationKey] = "1"
+	gmc1, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	require.Nil(t, err)
+	assert.NotEqual(t, gmc.Name, gmc1.Name)
+	assert.Equal(t, gmc.Spec, gmc1.Spec)
+	assert.Equal(t, "1", gmc1.Annotations[ctrlcommon.RerenderGenerationAnnotationKey])
+
+	cc.Annotations[ctrlcommon.RerenderGenerationAnnotationKey] = "2"
+	gmc2, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	require.Nil(t, err)
+	assert.NotEqual(t, gmc1.Name, gmc2.Name)
+
+	// an empty generation is the same as none
+	cc.Annotations[ctrlcommon.RerenderGenerationAnnotationKey] = ""
+	gmc3, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	require.Nil(t, err)
+	assert.Equal(t, gmc.Name, gmc3.Name)
+}
+
+func TestGenerateMachineConfigProvenance(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "")
+	mcs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("05-extra-master", map[string]string{"node-role/master": ""}, "dummy://1", []ign3types.File{}),
+		helpers.NewMachineConfig("00-test-cluster-master", map[string]string{"node-role/master": ""}, "dummy://", []ign3types.File{}),
+	}
+	mcs[0].ResourceVersion = "12"
+	mcs[1].ResourceVersion = "34"
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+	cc.ResourceVersion = "56"
+	cc.Generation = 3
+
+	gmc, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	require.Nil(t, err)
+	provenance, err := ctrlcommon.RenderProvenanceFromAnnotations(gmc.Annotations)
+	require.Nil(t, err)
+	assert.Equal(t, &ctrlcommon.RenderProvenance{
+		MachineConfigs: []ctrlcommon.ObjectVersion{
+			{Name: "00-test-cluster-master", ResourceVersion: "34"},
+			{Name: "05-extra-master", ResourceVersion: "12"},
+		},
+		ControllerConfig: ctrlcommon.ObjectVersion{Name: ctrlcommon.ControllerConfigName, ResourceVersion: "56", Generation: 3},
+	}, provenance)
+
+	// the provenance doesn't take part in the name
+	mcs[0].ResourceVersion = "78"
+	gmc1, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	require.Nil(t, err)
+	assert.Equal(t, gmc.Name, gmc1.Name)
+	assert.NotEqual(t, gmc.Annotations[ctrlcommon.RenderProvenanceAnnotationKey], gmc1.Annotations[ctrlcommon.RenderProvenanceAnnotationKey])
+}
+
+// TestKeepsRenderProvenance checks that a change of the inputs not changing
+// the rendered contents doesn't overwrite the provenance of the rendered config.
+func TestKeepsRenderProvenance(t *testing.T) {
+	f := newFixture(t)
+	mcp := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "")
+	mcs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("00-test-cluster-master", map[string]string{"node-role/master": ""}, "dummy://", []ign3types.File{}),
+	}
+	mcs[0].ResourceVersion = "1"
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+
+	gmc, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	require.Nil(t, err)
+	mcp.Spec.Configuration.Name = gmc.Name
+	mcp.Status.Configuration.Name = gmc.Name
+
+	// relabeling the input bumps its resourceVersion
+	mcs[0] = mcs[0].DeepCopy()
+	mcs[0].ResourceVersion = "2"
+
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, mcs[0], gmc)
+	f.objects = append(f.objects, mcs[0], gmc)
+
+	mcpNew := mcp.DeepCopy()
+	mcpNew.Spec.Configuration.Source = []corev1.ObjectReference{{Kind: machineconfigKind.Kind, Name: mcs[0].GetName(), APIVersion: machineconfigKind.GroupVersion().String()}}
+
+	// no update of the rendered config
+	f.expectGetMachineConfigAction(gmc)
+	f.expectUpdateMachineConfigPool(mcpNew)
+
+	f.run(getKey(mcp, t))
+}
+
 func TestVersionSkew(t *testing.T) {
 	mcp := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "")
 	mcs := []*mcfgv1.MachineConfig{
@@ -0,0 +1,254 @@
+package render
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/vincent-petithory/dataurl"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// ConfigCompression decides whether file contents are compressed when a
+// rendered MachineConfig would otherwise be too large to be stored.
+type ConfigCompression string
+
+const (
+	// ConfigCompressionNone leaves file contents as they are.
+	ConfigCompressionNone ConfigCompression = "None"
+	// ConfigCompressionGzip gzips the contents of the largest files.
+	ConfigCompressionGzip ConfigCompression = "Gzip"
+)
+
+const (
+	// maxRenderedConfigSize is the largest a serialized rendered MachineConfig
+	// may be.  etcd rejects requests over 1.5MiB by default, the rest is left
+	// for the metadata the apiserver adds.
+	maxRenderedConfigSize = 1280 * 1024
+
+	// largestContributorsReported is how many files and units the error of a
+	// too large rendered MachineConfig names.
+	largestContributorsReported = 5
+
+	// minCompressedFileSize is the size of the contents of a file below which
+	// compressing it isn't worth it.
+	minCompressedFileSize = 1024
+)
+
+// configCompressionFromAnnotations returns the compression configured on the ControllerConfig, defaulting to None.
+func configCompressionFromAnnotations(annotations map[string]string) (ConfigCompression, error) {
+	switch compression := ConfigCompression(annotations[ctrlcommon.RenderedConfigCompressionAnnotationKey]); compression {
+	case "":
+		return ConfigCompressionNone, nil
+	case ConfigCompressionNone, ConfigCompressionGzip:
+		return compression, nil
+	default:
+		return "", fmt.Errorf("invalid %s annotation %q, must be %q or %q", ctrlcommon.RenderedConfigCompressionAnnotationKey, compression, ConfigCompressionNone, ConfigCompressionGzip)
+	}
+}
+
+// renderedConfigSize returns the size of the MachineConfig as stored.
+func renderedConfigSize(mc *mcfgv1.MachineConfig) (int, error) {
+	raw, err := json.Marshal(mc)
+	if err != nil {
+		return 0, err
+	}
+	return len(raw), nil
+}
+
+// compressLargeFiles gzips the contents of the largest uncompressed files of
+// the merged MachineConfig until it fits within maxRenderedConfigSize, if it
+// doesn't already.  Ignition and the daemon decompress them when writing the files.
+func compressLargeFiles(merged *mcfgv1.MachineConfig) error {
+	size, err := renderedConfigSize(merged)
+	if err != nil {
+		return err
+	}
+	if size <= maxRenderedConfigSize {
+		return nil
+	}
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(merged.Spec.Config.Raw)
+	if err != nil {
+		return err
+	}
+
+	files := ignCfg.Storage.Files
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return fileContentsSize(files[order[i]]) > fileContentsSize(files[order[j]]) })
+
+	excess := size - maxRenderedConfigSize
+	for _, i := range order {
+		if excess <= 0 {
+			break
+		}
+		saved, err := compressFileContents(&files[i].Contents)
+		if err != nil {
+			return fmt.Errorf("compressing contents of file %q: %w", files[i].Path, err)
+		}
+		excess -= saved
+	}
+
+	rawIgn, err := json.Marshal(ignCfg)
+	if err != nil {
+		return err
+	}
+	merged.Spec.Config.Raw = rawIgn
+	return nil
+}
+
+// compressFileContents replaces uncompressed contents with their gzipped
+// form, unless they're too small or compress poorly, and returns the number
+// of bytes saved.
+func compressFileContents(contents *ign3types.Resource) (int, error) {
+	if contents.Source == nil || len(*contents.Source) < minCompressedFileSize {
+		return 0, nil
+	}
+	if contents.Compression != nil && *contents.Compression != "" {
+		return 0, nil
+	}
+	data, err := ctrlcommon.DecodeIgnitionFileContents(contents.Source, nil)
+	if err != nil {
+		return 0, err
+	}
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return 0, err
+	}
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	source := dataurl.EncodeBytes(buf.Bytes())
+	saved := len(*contents.Source) - len(source)
+	if saved <= 0 {
+		return 0, nil
+	}
+	compression := "gzip"
+	contents.Source = &source
+	contents.Compression = &compression
+	// the contents changed, so a verification hash of the uncompressed ones no longer applies
+	contents.Verification = ign3types.Verification{}
+	return saved, nil
+}
+
+func fileContentsSize(f ign3types.File) int {
+	if f.Contents.Source == nil {
+		return 0
+	}
+	return len(*f.Contents.Source)
+}
+
+// contributor is a file or unit of a rendered MachineConfig and the
+// MachineConfig it comes from.
+type contributor struct {
+	kind   string
+	name   string
+	source string
+	size   int
+}
+
+// checkRenderedConfigSize returns a ConfigTooLargeError naming the largest
+// files and units, and the MachineConfigs setting them, if the rendered
+// MachineConfig is too large to be stored.  The configs are the ones merged
+// into it.
+func checkRenderedConfigSize(rendered *mcfgv1.MachineConfig, configs []*mcfgv1.MachineConfig, compression ConfigCompression) error {
+	size, err := renderedConfigSize(rendered)
+	if err != nil {
+		return err
+	}
+	if size <= maxRenderedConfigSize {
+		return nil
+	}
+
+	contributors, err := getLargestContributors(rendered, configs)
+	if err != nil {
+		return err
+	}
+	var largest []string
+	for _, c := range contributors {
+		largest = append(largest, fmt.Sprintf("%s %s (%s) from %s", c.kind, c.name, formatSize(c.size), c.source))
+	}
+	msg := fmt.Sprintf("rendered MachineConfig is %s, over the limit of %s; largest contributors: %s", formatSize(size), formatSize(maxRenderedConfigSize), strings.Join(largest, ", "))
+	if compression != ConfigCompressionGzip {
+		msg += fmt.Sprintf("; setting the %s annotation of the controllerconfig to %s compresses file contents", ctrlcommon.RenderedConfigCompressionAnnotationKey, ConfigCompressionGzip)
+	}
+	return &ctrlcommon.ConfigTooLargeError{Err: fmt.Errorf("%s", msg)}
+}
+
+// getLargestContributors returns the largest files and units of the rendered
+// MachineConfig, each attributed to the last of the configs, in merge order,
+// to set it.
+func getLargestContributors(rendered *mcfgv1.MachineConfig, configs []*mcfgv1.MachineConfig) ([]contributor, error) {
+	fileSources := map[string]string{}
+	unitSources := map[string]string{}
+	for _, config := range configs {
+		if config.Spec.Config.Raw == nil {
+			continue
+		}
+		ignCfg, err := ctrlcommon.ParseAndConvertConfig(config.Spec.Config.Raw)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range ignCfg.Storage.Files {
+			fileSources[f.Path] = config.Name
+		}
+		for _, u := range ignCfg.Systemd.Units {
+			unitSources[u.Name] = config.Name
+		}
+	}
+
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(rendered.Spec.Config.Raw)
+	if err != nil {
+		return nil, err
+	}
+	var contributors []contributor
+	for _, f := range ignCfg.Storage.Files {
+		size := fileContentsSize(f)
+		for _, a := range f.Append {
+			if a.Source != nil {
+				size += len(*a.Source)
+			}
+		}
+		contributors = append(contributors, contributor{kind: "file", name: f.Path, source: fileSources[f.Path], size: size})
+	}
+	for _, u := range ignCfg.Systemd.Units {
+		size := 0
+		if u.Contents != nil {
+			size += len(*u.Contents)
+		}
+		for _, d := range u.Dropins {
+			if d.Contents != nil {
+				size += len(*d.Contents)
+			}
+		}
+		contributors = append(contributors, contributor{kind: "unit", name: u.Name, source: unitSources[u.Name], size: size})
+	}
+	sort.SliceStable(contributors, func(i, j int) bool { return contributors[i].size > contributors[j].size })
+	if len(contributors) > largestContributorsReported {
+		contributors = contributors[:largestContributorsReported]
+	}
+	return contributors, nil
+}
+
+func formatSize(size int) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.2fMiB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1fKiB", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%dB", size)
+	}
+}
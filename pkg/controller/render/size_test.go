@@ -0,0 +1,94 @@
+package render
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func newSizedMachineConfigs(contents []byte) []*mcfgv1.MachineConfig {
+	return []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("00-worker", map[string]string{"node-role/worker": ""}, "", []ign3types.File{
+			helpers.CreateIgn3File("/etc/small.conf", "data:,small", 0644),
+		}),
+		helpers.NewMachineConfig("99-worker-big", map[string]string{"node-role/worker": ""}, "", []ign3types.File{
+			helpers.CreateIgn3File("/usr/local/bin/big", dataurl.EncodeBytes(contents), 0755),
+		}),
+	}
+}
+
+func TestGenerateRenderedMachineConfigTooLarge(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "")
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+
+	random := make([]byte, maxRenderedConfigSize)
+	rand.New(rand.NewSource(1)).Read(random)
+	_, err := generateRenderedMachineConfig(mcp, newSizedMachineConfigs(random), cc)
+	require.Error(t, err)
+	assert.Equal(t, ctrlcommon.ErrorReasonConfigTooLarge, ctrlcommon.ErrorReason(err))
+	assert.Contains(t, err.Error(), "largest contributors: file /usr/local/bin/big (1.67MiB) from 99-worker-big, file /etc/small.conf (11B) from 00-worker")
+	assert.Contains(t, err.Error(), ctrlcommon.RenderedConfigCompressionAnnotationKey)
+
+	// random contents don't compress
+	cc.Annotations[ctrlcommon.RenderedConfigCompressionAnnotationKey] = string(ConfigCompressionGzip)
+	_, err = generateRenderedMachineConfig(mcp, newSizedMachineConfigs(random), cc)
+	require.Error(t, err)
+	assert.Equal(t, ctrlcommon.ErrorReasonConfigTooLarge, ctrlcommon.ErrorReason(err))
+	assert.NotContains(t, err.Error(), ctrlcommon.RenderedConfigCompressionAnnotationKey)
+
+	cc.Annotations[ctrlcommon.RenderedConfigCompressionAnnotationKey] = "zstd"
+	_, err = generateRenderedMachineConfig(mcp, newSizedMachineConfigs(random), cc)
+	require.Error(t, err)
+	assert.Empty(t, ctrlcommon.ErrorReason(err))
+}
+
+func TestGenerateRenderedMachineConfigCompression(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "")
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+	cc.Annotations[ctrlcommon.RenderedConfigCompressionAnnotationKey] = string(ConfigCompressionGzip)
+
+	// configs which fit are left alone
+	small := bytes.Repeat([]byte("a"), 64*1024)
+	gmc, err := generateRenderedMachineConfig(mcp, newSizedMachineConfigs(small), cc)
+	require.NoError(t, err)
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(gmc.Spec.Config.Raw)
+	require.NoError(t, err)
+	for _, f := range ignCfg.Storage.Files {
+		assert.Nil(t, f.Contents.Compression, f.Path)
+	}
+
+	big := bytes.Repeat([]byte("a"), maxRenderedConfigSize)
+	gmc, err = generateRenderedMachineConfig(mcp, newSizedMachineConfigs(big), cc)
+	require.NoError(t, err)
+	size, err := renderedConfigSize(gmc)
+	require.NoError(t, err)
+	assert.Less(t, size, 64*1024)
+
+	ignCfg, err = ctrlcommon.ParseAndConvertConfig(gmc.Spec.Config.Raw)
+	require.NoError(t, err)
+	files := map[string]ign3types.File{}
+	for _, f := range ignCfg.Storage.Files {
+		files[f.Path] = f
+	}
+	require.NotNil(t, files["/usr/local/bin/big"].Contents.Compression)
+	assert.Equal(t, "gzip", *files["/usr/local/bin/big"].Contents.Compression)
+	contents, err := ctrlcommon.DecodeIgnitionFileContents(files["/usr/local/bin/big"].Contents.Source, files["/usr/local/bin/big"].Contents.Compression)
+	require.NoError(t, err)
+	assert.Equal(t, big, contents)
+	// too small to bother
+	assert.Nil(t, files["/etc/small.conf"].Contents.Compression)
+
+	// compressing is deterministic, so the name is stable
+	gmc1, err := generateRenderedMachineConfig(mcp, newSizedMachineConfigs(big), cc)
+	require.NoError(t, err)
+	assert.Equal(t, gmc.Name, gmc1.Name)
+}
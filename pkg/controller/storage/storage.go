@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	multipathConfPath = "/etc/multipath.conf"
+	iscsidConfPath    = "/etc/iscsi/iscsid.conf"
+
+	// initiatorNameUnit writes the initiator name of the node before iscsid starts,
+	// the name depends on the hostname so it can't be a plain file.
+	initiatorNameUnit = "iscsi-initiatorname.service"
+
+	// maxInitiatorNamePrefixLength leaves room for the hostname in the 223
+	// bytes an iSCSI name is limited to.
+	maxInitiatorNamePrefixLength = 160
+)
+
+// defaultMultipathConf is what mpathconf --enable writes.
+const defaultMultipathConf = `defaults {
+	user_friendly_names yes
+	find_multipaths yes
+}
+
+blacklist {
+}
+`
+
+// multipathRootKernelArguments boot from a multipathed root device.
+var multipathRootKernelArguments = []string{"rd.multipath=default", "root=/dev/disk/by-label/dm-mpath-root"}
+
+// iqnPrefixRegexp matches an iqn.yyyy-mm.reversed.domain name, optionally
+// followed by a colon and a unique string.
+var iqnPrefixRegexp = regexp.MustCompile(`^iqn\.[0-9]{4}-[0-9]{2}\.[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*(:[a-zA-Z0-9.:-]+)?$`)
+
+const initiatorNameUnitTemplate = `[Unit]
+Description=Set the iSCSI initiator name of the node
+Wants=network-online.target
+After=network-online.target
+Before=iscsid.socket iscsid.service iscsi.service
+
+[Service]
+Type=oneshot
+ExecStart=/bin/sh -c 'echo "InitiatorName=%s:$$(hostname -s)" > /etc/iscsi/initiatorname.iscsi'
+RemainAfterExit=yes
+
+[Install]
+WantedBy=multi-user.target
+`
+
+// generatedConfigKind describes the MachineConfigs generated from the storage configuration of the pools.
+var generatedConfigKind = ctrlcommon.GeneratedConfigKind{
+	Suffix:        "storage",
+	Description:   "storage configuration",
+	ConditionType: mcfgv1.MachineConfigPoolStorageDegraded,
+	InvalidReason: "StorageConfigInvalid",
+}
+
+// validateStorage checks the storage configuration of a pool.
+func validateStorage(cfg *mcfgv1.PoolStorageConfiguration) error {
+	if cfg.Multipath == nil && cfg.ISCSI == nil {
+		return fmt.Errorf("storage must configure multipath or iscsi")
+	}
+	if cfg.ISCSI != nil {
+		prefix := cfg.ISCSI.InitiatorNamePrefix
+		if prefix == "" && cfg.ISCSI.Config == "" {
+			return fmt.Errorf("iscsi must set initiatorNamePrefix or config")
+		}
+		if prefix != "" && !iqnPrefixRegexp.MatchString(prefix) {
+			return fmt.Errorf("invalid iscsi initiatorNamePrefix %q: must be an iqn name such as iqn.2022-01.com.example", prefix)
+		}
+		if len(prefix) > maxInitiatorNamePrefixLength {
+			return fmt.Errorf("invalid iscsi initiatorNamePrefix %q: must be at most %d characters", prefix, maxInitiatorNamePrefixLength)
+		}
+	}
+	return nil
+}
+
+func newEnabledUnit(name string, contents *string) ign3types.Unit {
+	enabled := true
+	return ign3types.Unit{
+		Name:     name,
+		Enabled:  &enabled,
+		Contents: contents,
+	}
+}
+
+// generateMachineConfig renders the storage configuration of the pool into a
+// MachineConfig for the pool's role.
+func generateMachineConfig(pool *mcfgv1.MachineConfigPool) (*mcfgv1.MachineConfig, error) {
+	cfg := pool.Spec.Storage
+	if err := validateStorage(cfg); err != nil {
+		return nil, err
+	}
+
+	ignConfig := ctrlcommon.NewIgnConfig()
+	var kargs []string
+	if mp := cfg.Multipath; mp != nil {
+		conf := mp.Config
+		if conf == "" {
+			conf = defaultMultipathConf
+		}
+		ignConfig.Storage.Files = append(ignConfig.Storage.Files, ctrlcommon.NewGeneratedFile(multipathConfPath, conf))
+		ignConfig.Systemd.Units = append(ignConfig.Systemd.Units, newEnabledUnit("multipathd.service", nil))
+		if mp.RootDevice {
+			kargs = append(kargs, multipathRootKernelArguments...)
+		}
+	}
+	if iscsi := cfg.ISCSI; iscsi != nil {
+		if iscsi.Config != "" {
+			ignConfig.Storage.Files = append(ignConfig.Storage.Files, ctrlcommon.NewGeneratedFile(iscsidConfPath, iscsi.Config))
+		}
+		if iscsi.InitiatorNamePrefix != "" {
+			unit := fmt.Sprintf(initiatorNameUnitTemplate, iscsi.InitiatorNamePrefix)
+			ignConfig.Systemd.Units = append(ignConfig.Systemd.Units, newEnabledUnit(initiatorNameUnit, &unit))
+		}
+		ignConfig.Systemd.Units = append(ignConfig.Systemd.Units, newEnabledUnit("iscsid.service", nil))
+	}
+
+	mc, err := generatedConfigKind.NewMachineConfig(pool, ignConfig)
+	if err != nil {
+		return nil, err
+	}
+	mc.Spec.KernelArguments = kargs
+	return mc, nil
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestValidateStorage(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   mcfgv1.PoolStorageConfiguration
+		valid bool
+	}{{
+		name:  "multipath",
+		cfg:   mcfgv1.PoolStorageConfiguration{Multipath: &mcfgv1.MultipathConfiguration{}},
+		valid: true,
+	}, {
+		name:  "initiator name prefix",
+		cfg:   mcfgv1.PoolStorageConfiguration{ISCSI: &mcfgv1.ISCSIConfiguration{InitiatorNamePrefix: "iqn.2022-01.com.example"}},
+		valid: true,
+	}, {
+		name:  "initiator name prefix with a unique string",
+		cfg:   mcfgv1.PoolStorageConfiguration{ISCSI: &mcfgv1.ISCSIConfiguration{InitiatorNamePrefix: "iqn.2022-01.com.example:ocp-worker"}},
+		valid: true,
+	}, {
+		name:  "iscsid.conf only",
+		cfg:   mcfgv1.PoolStorageConfiguration{ISCSI: &mcfgv1.ISCSIConfiguration{Config: "node.startup = automatic\n"}},
+		valid: true,
+	}, {
+		name: "empty",
+	}, {
+		name: "empty iscsi",
+		cfg:  mcfgv1.PoolStorageConfiguration{ISCSI: &mcfgv1.ISCSIConfiguration{}},
+	}, {
+		name: "not an iqn",
+		cfg:  mcfgv1.PoolStorageConfiguration{ISCSI: &mcfgv1.ISCSIConfiguration{InitiatorNamePrefix: "eui.02004567A425678D"}},
+	}, {
+		name: "uppercase domain",
+		cfg:  mcfgv1.PoolStorageConfiguration{ISCSI: &mcfgv1.ISCSIConfiguration{InitiatorNamePrefix: "iqn.2022-01.com.Example"}},
+	}, {
+		name: "trailing colon",
+		cfg:  mcfgv1.PoolStorageConfiguration{ISCSI: &mcfgv1.ISCSIConfiguration{InitiatorNamePrefix: "iqn.2022-01.com.example:"}},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateStorage(&test.cfg)
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestGenerateMachineConfig(t *testing.T) {
+	pool := helpers.NewPool("infra")
+	pool.Spec.Storage = &mcfgv1.PoolStorageConfiguration{
+		Multipath: &mcfgv1.MultipathConfiguration{RootDevice: true},
+		ISCSI: &mcfgv1.ISCSIConfiguration{
+			InitiatorNamePrefix: "iqn.2022-01.com.example",
+			Config:              "node.startup = automatic\n",
+		},
+	}
+	mc, err := generateMachineConfig(pool)
+	require.NoError(t, err)
+	assert.Equal(t, "99-infra-generated-storage", mc.Name)
+	assert.Equal(t, "infra", mc.Labels[mcfgv1.MachineConfigRoleLabelKey])
+	assert.Equal(t, "infra", generatedConfigKind.OwningPool(mc))
+	assert.Equal(t, []string{"rd.multipath=default", "root=/dev/disk/by-label/dm-mpath-root"}, mc.Spec.KernelArguments)
+
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	require.NoError(t, err)
+	multipathConf, err := ctrlcommon.GetIgnitionFileDataByPath(&ignCfg, multipathConfPath)
+	require.NoError(t, err)
+	assert.Equal(t, defaultMultipathConf, string(multipathConf))
+	iscsidConf, err := ctrlcommon.GetIgnitionFileDataByPath(&ignCfg, iscsidConfPath)
+	require.NoError(t, err)
+	assert.Equal(t, "node.startup = automatic\n", string(iscsidConf))
+
+	units := map[string]string{}
+	for _, unit := range ignCfg.Systemd.Units {
+		require.NotNil(t, unit.Enabled)
+		assert.True(t, *unit.Enabled)
+		units[unit.Name] = ""
+		if unit.Contents != nil {
+			units[unit.Name] = *unit.Contents
+		}
+	}
+	assert.Contains(t, units, "multipathd.service")
+	assert.Contains(t, units, "iscsid.service")
+	assert.Contains(t, units[initiatorNameUnit], `echo "InitiatorName=iqn.2022-01.com.example:$$(hostname -s)"`)
+
+	// multipath without a root device doesn't change the kernel arguments
+	pool.Spec.Storage = &mcfgv1.PoolStorageConfiguration{Multipath: &mcfgv1.MultipathConfiguration{Config: "defaults {\n}\n"}}
+	mc, err = generateMachineConfig(pool)
+	require.NoError(t, err)
+	assert.Empty(t, mc.Spec.KernelArguments)
+}
@@ -0,0 +1,103 @@
+package template
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+
+	"github.com/openshift/machine-config-operator/pkg/controller/butane"
+)
+
+// butaneSuffix marks a files or units template holding a whole Butane config,
+// translated like the Butane configmaps instead of being transpiled as a
+// CoreOS config fragment.
+const butaneSuffix = ".bu"
+
+// moveButaneTemplates moves the rendered Butane templates of the templates of
+// type dir to butaneTemplates, keyed by <dir>/<name>.
+func moveButaneTemplates(butaneTemplates, templates map[string]string, dir string) {
+	for name, data := range templates {
+		if strings.HasSuffix(name, butaneSuffix) {
+			butaneTemplates[filepath.Join(dir, name)] = data
+			delete(templates, name)
+		}
+	}
+}
+
+// mergeButaneTemplates translates the rendered Butane templates and adds their
+// files, directories, links and units to ignCfg.  A Butane template may not
+// write a path or define a unit another template does, and can't hold the
+// sections the machine-config-daemon doesn't apply, see butane.Translate.
+func mergeButaneTemplates(ignCfg *ign3types.Config, butaneTemplates map[string]string) error {
+	names := []string{}
+	for name := range butaneTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	paths := map[string]bool{}
+	for _, f := range ignCfg.Storage.Files {
+		paths[f.Path] = true
+	}
+	for _, d := range ignCfg.Storage.Directories {
+		paths[d.Path] = true
+	}
+	for _, l := range ignCfg.Storage.Links {
+		paths[l.Path] = true
+	}
+	units := map[string]bool{}
+	for _, u := range ignCfg.Systemd.Units {
+		units[u.Name] = true
+	}
+	addPath := func(name, path string) error {
+		if paths[path] {
+			return fmt.Errorf("butane template %q writes %s, which another template writes", name, path)
+		}
+		paths[path] = true
+		return nil
+	}
+
+	for _, name := range names {
+		butaneCfg, err := butane.Translate([]byte(butaneTemplates[name]))
+		if err != nil {
+			return fmt.Errorf("invalid butane template %q: %w", name, err)
+		}
+		if len(butaneCfg.Passwd.Users) > 0 || len(butaneCfg.Passwd.Groups) > 0 {
+			return fmt.Errorf("invalid butane template %q: passwd is not supported in templates", name)
+		}
+		for _, f := range butaneCfg.Storage.Files {
+			if err := addPath(name, f.Path); err != nil {
+				return err
+			}
+		}
+		for _, d := range butaneCfg.Storage.Directories {
+			if err := addPath(name, d.Path); err != nil {
+				return err
+			}
+		}
+		for _, l := range butaneCfg.Storage.Links {
+			if err := addPath(name, l.Path); err != nil {
+				return err
+			}
+		}
+		for _, u := range butaneCfg.Systemd.Units {
+			if units[u.Name] {
+				return fmt.Errorf("butane template %q defines the unit %s, which another template defines", name, u.Name)
+			}
+			units[u.Name] = true
+		}
+
+		if ignCfg.Ignition.Version == "" {
+			// the config is empty if the template has no files and units
+			ignCfg.Ignition.Version = ign3types.MaxVersion.String()
+		}
+		ignCfg.Storage.Files = append(ignCfg.Storage.Files, butaneCfg.Storage.Files...)
+		ignCfg.Storage.Directories = append(ignCfg.Storage.Directories, butaneCfg.Storage.Directories...)
+		ignCfg.Storage.Links = append(ignCfg.Storage.Links, butaneCfg.Storage.Links...)
+		ignCfg.Systemd.Units = append(ignCfg.Systemd.Units, butaneCfg.Systemd.Units...)
+	}
+	return nil
+}
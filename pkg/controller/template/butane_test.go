@@ -0,0 +1,139 @@
+package template
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const testButaneTemplate = `variant: fcos
+version: 1.0.0
+storage:
+  directories:
+    - path: /etc/my-agent
+      mode: 0755
+  files:
+    - path: /etc/my-agent/agent.conf
+      mode: 0644
+      contents:
+        inline: |
+          image={{ .Images.infraImageKey }}
+  links:
+    - path: /etc/my-agent/current
+      target: /etc/my-agent/agent.conf
+systemd:
+  units:
+    - name: my-agent.service
+      enabled: true
+      contents: |
+        [Service]
+        ExecStart=/usr/bin/true
+        [Install]
+        WantedBy=multi-user.target
+`
+
+func generateWithButane(t *testing.T, templates map[string]string) (*mcfgv1.MachineConfig, error) {
+	t.Helper()
+	dir := t.TempDir()
+	namePath := filepath.Join(dir, "worker", "00-worker")
+	for path, data := range templates {
+		path = filepath.Join(namePath, platformBase, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := &mcfgv1.ControllerConfig{
+		Spec: mcfgv1.ControllerConfigSpec{
+			Images: map[string]string{"infraImageKey": "quay.io/openshift/pod"},
+			Infra: &configv1.Infrastructure{
+				Status: configv1.InfrastructureStatus{
+					PlatformStatus: &configv1.PlatformStatus{Type: configv1.AWSPlatformType},
+				},
+			},
+		},
+	}
+	renderConfig := &RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}
+	commonAdded := true
+	return generateMachineConfigForName(renderConfig, "worker", "00-worker", dir, namePath, &commonAdded)
+}
+
+func TestButaneTemplates(t *testing.T) {
+	mc, err := generateWithButane(t, map[string]string{
+		"files/my-agent.bu": testButaneTemplate,
+		"units/other.service.yaml": `name: other.service
+enabled: true
+contents: |
+  [Service]
+  ExecStart=/usr/bin/true
+`,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate machine config: %v", err)
+	}
+	ign, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	if err != nil {
+		t.Fatalf("failed to parse Ignition config: %v", err)
+	}
+	contents, err := ctrlcommon.GetIgnitionFileDataByPath(&ign, "/etc/my-agent/agent.conf")
+	if err != nil {
+		t.Fatalf("expected the file of the butane template: %v", err)
+	}
+	if string(contents) != "image=quay.io/openshift/pod\n" {
+		t.Fatalf("mismatch got: %q want: %q", contents, "image=quay.io/openshift/pod\n")
+	}
+	if len(ign.Storage.Directories) != 1 || len(ign.Storage.Links) != 1 {
+		t.Fatalf("expected the directory and link of the butane template, got %v and %v", ign.Storage.Directories, ign.Storage.Links)
+	}
+	if !findIgnUnit(ign.Systemd.Units, "my-agent.service", t) || !findIgnUnit(ign.Systemd.Units, "other.service", t) {
+		t.Fatal("expected the units of both templates")
+	}
+}
+
+func TestButaneTemplatesInvalid(t *testing.T) {
+	for name, c := range map[string]struct {
+		templates map[string]string
+		want      string
+	}{
+		"conflicting file": {
+			templates: map[string]string{
+				"files/my-agent.bu":   testButaneTemplate,
+				"files/my-agent.yaml": "path: /etc/my-agent/agent.conf\ncontents:\n  inline: \"\"\n",
+			},
+			want: "writes /etc/my-agent/agent.conf, which another template writes",
+		},
+		"conflicting unit": {
+			templates: map[string]string{
+				"units/my-agent.bu":           testButaneTemplate,
+				"units/my-agent.service.yaml": "name: my-agent.service\ncontents: |\n  [Service]\n  ExecStart=/usr/bin/true\n",
+			},
+			want: "defines the unit my-agent.service, which another template defines",
+		},
+		"filesystems": {
+			templates: map[string]string{
+				"files/var.bu": "variant: fcos\nversion: 1.0.0\nstorage:\n  filesystems:\n    - device: /dev/vdb\n      format: xfs\n",
+			},
+			want: "storage.filesystems is not supported",
+		},
+		"passwd": {
+			templates: map[string]string{
+				"files/users.bu": "variant: fcos\nversion: 1.0.0\npasswd:\n  users:\n    - name: admin\n",
+			},
+			want: "passwd is not supported in templates",
+		},
+	} {
+		_, err := generateWithButane(t, c.templates)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, c.want, err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package template
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// azureResourceManagerService is the name the Azure ARM endpoint override is
+// exposed as by cloudServiceEndpoint.
+const azureResourceManagerService = "resourceManager"
+
+// serviceEndpoint is a custom endpoint of a cloud service, e.g. ec2 in
+// GovCloud or the resource manager of Azure Stack.
+type serviceEndpoint struct {
+	Name string
+	URL  string
+}
+
+// cloudServiceEndpoints is a template function returning the custom service
+// endpoints set in the platform status of the cluster, sorted by service name:
+// the serviceEndpoints of AWS and PowerVS, and the armEndpoint of Azure as
+// resourceManager.
+//
+//	{{range cloudServiceEndpoints .}}{{.Name}}={{.URL}}{{end}}
+func cloudServiceEndpoints(cfg RenderConfig) []serviceEndpoint {
+	if cfg.Infra == nil || cfg.Infra.Status.PlatformStatus == nil {
+		return nil
+	}
+	status := cfg.Infra.Status.PlatformStatus
+	var endpoints []serviceEndpoint
+	switch status.Type {
+	case configv1.AWSPlatformType:
+		if status.AWS != nil {
+			for _, e := range status.AWS.ServiceEndpoints {
+				endpoints = append(endpoints, serviceEndpoint{Name: e.Name, URL: e.URL})
+			}
+		}
+	case configv1.AzurePlatformType:
+		if status.Azure != nil && status.Azure.ARMEndpoint != "" {
+			endpoints = append(endpoints, serviceEndpoint{Name: azureResourceManagerService, URL: status.Azure.ARMEndpoint})
+		}
+	case configv1.PowerVSPlatformType:
+		if status.PowerVS != nil {
+			for _, e := range status.PowerVS.ServiceEndpoints {
+				endpoints = append(endpoints, serviceEndpoint{Name: e.Name, URL: e.URL})
+			}
+		}
+	}
+	sort.SliceStable(endpoints, func(i, j int) bool { return endpoints[i].Name < endpoints[j].Name })
+	return endpoints
+}
+
+// cloudServiceEndpoint is a template function returning the custom endpoint
+// of a cloud service, or an empty string if the default one is used.
+//
+//	{{with cloudServiceEndpoint . "ec2"}}--endpoint={{.}}{{end}}
+func cloudServiceEndpoint(cfg RenderConfig, name string) string {
+	for _, e := range cloudServiceEndpoints(cfg) {
+		if e.Name == name {
+			return e.URL
+		}
+	}
+	return ""
+}
+
+// cloudProviderConfig returns the contents of /etc/kubernetes/cloud.conf.
+// This is the CloudProviderConfig of the cluster when there is one.  Without
+// one, the in-tree AWS provider of the kubelet still needs the custom service
+// endpoints, so they are rendered as its ServiceOverride sections.
+func cloudProviderConfig(cfg RenderConfig) (string, error) {
+	if cfg.CloudProviderConfig != "" {
+		return cfg.CloudProviderConfig, nil
+	}
+	endpoints := cloudServiceEndpoints(cfg)
+	if len(endpoints) == 0 || cfg.Infra.Status.PlatformStatus.Type != configv1.AWSPlatformType {
+		return "", nil
+	}
+	provider, err := cloudProvider(cfg)
+	if err != nil || provider != "aws" {
+		return "", err
+	}
+	region := cfg.Infra.Status.PlatformStatus.AWS.Region
+	var b strings.Builder
+	b.WriteString("[Global]\n")
+	for i, e := range endpoints {
+		fmt.Fprintf(&b, "\n[ServiceOverride %q]\nService = %s\nRegion = %s\nURL = %s\nSigningRegion = %s\n", fmt.Sprint(i), e.Name, region, e.URL, region)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,107 @@
+package template
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/cloudprovider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+func newCloudRenderConfig(status *configv1.PlatformStatus) RenderConfig {
+	return RenderConfig{
+		ControllerConfigSpec: &mcfgv1.ControllerConfigSpec{
+			Infra: &configv1.Infrastructure{
+				Status: configv1.InfrastructureStatus{PlatformStatus: status},
+			},
+		},
+	}
+}
+
+func TestCloudServiceEndpoints(t *testing.T) {
+	aws := newCloudRenderConfig(&configv1.PlatformStatus{
+		Type: configv1.AWSPlatformType,
+		AWS: &configv1.AWSPlatformStatus{
+			Region: "us-gov-west-1",
+			ServiceEndpoints: []configv1.AWSServiceEndpoint{
+				{Name: "elasticloadbalancing", URL: "https://elasticloadbalancing.us-gov-west-1.amazonaws.com"},
+				{Name: "ec2", URL: "https://ec2.us-gov-west-1.amazonaws.com"},
+			},
+		},
+	})
+	azure := newCloudRenderConfig(&configv1.PlatformStatus{
+		Type: configv1.AzurePlatformType,
+		Azure: &configv1.AzurePlatformStatus{
+			CloudName:   configv1.AzureStackCloud,
+			ARMEndpoint: "https://management.local.azurestack.external",
+		},
+	})
+
+	got, err := renderTemplate(aws, "endpoints", []byte(`{{range cloudServiceEndpoints .}}{{.Name}}={{.URL}} {{end}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "ec2=https://ec2.us-gov-west-1.amazonaws.com elasticloadbalancing=https://elasticloadbalancing.us-gov-west-1.amazonaws.com ", string(got))
+
+	for _, c := range []struct {
+		cfg     RenderConfig
+		service string
+		url     string
+	}{
+		{aws, "ec2", "https://ec2.us-gov-west-1.amazonaws.com"},
+		{aws, "s3", ""},
+		{azure, "resourceManager", "https://management.local.azurestack.external"},
+		{newCloudRenderConfig(&configv1.PlatformStatus{Type: configv1.AzurePlatformType}), "resourceManager", ""},
+		{newCloudRenderConfig(nil), "ec2", ""},
+	} {
+		got, err := renderTemplate(c.cfg, "endpoint", []byte(`{{cloudServiceEndpoint . "`+c.service+`"}}`))
+		require.NoError(t, err)
+		assert.Equal(t, c.url, string(got), c.service)
+	}
+}
+
+func TestCloudProviderConfigServiceOverrides(t *testing.T) {
+	cfg := newCloudRenderConfig(&configv1.PlatformStatus{
+		Type: configv1.AWSPlatformType,
+		AWS: &configv1.AWSPlatformStatus{
+			Region: "us-gov-west-1",
+			ServiceEndpoints: []configv1.AWSServiceEndpoint{
+				{Name: "ec2", URL: "https://ec2.example.com"},
+				{Name: "sts", URL: "https://sts.example.com"},
+			},
+		},
+	})
+
+	conf, err := cloudProviderConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, `[Global]
+
+[ServiceOverride "0"]
+Service = ec2
+Region = us-gov-west-1
+URL = https://ec2.example.com
+SigningRegion = us-gov-west-1
+
+[ServiceOverride "1"]
+Service = sts
+Region = us-gov-west-1
+URL = https://sts.example.com
+SigningRegion = us-gov-west-1
+`, conf)
+	assert.Equal(t, "--cloud-config=/etc/kubernetes/cloud.conf", cloudConfigFlag(cfg))
+
+	// a cloud config from the cluster is used as is
+	cfg.CloudProviderConfig = "[Global]\nZone = a\n"
+	conf, err = cloudProviderConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.CloudProviderConfig, conf)
+
+	// the external cloud provider doesn't read the kubelet's cloud config
+	cfg.CloudProviderConfig = ""
+	cfg.FeatureGate = newFeatures("cluster", "CustomNoUpgrade", []string{cloudprovider.ExternalCloudProviderFeature}, nil)
+	conf, err = cloudProviderConfig(cfg)
+	require.NoError(t, err)
+	assert.Empty(t, conf)
+	assert.Equal(t, "", cloudConfigFlag(cfg))
+}
@@ -1,6 +1,21 @@
 package template
 
 const (
+	// ImagesConfigMapName is the ConfigMap of the MCO namespace the payload images are read from
+	ImagesConfigMapName string = "machine-config-operator-images"
+
+	// TemplateOverlaysConfigMapName is the ConfigMap of the MCO namespace holding the templates added by the cluster admin
+	TemplateOverlaysConfigMapName string = "machine-config-template-overlays"
+
+	// ZoneVIPsConfigMapName is the ConfigMap of the MCO namespace holding the VIPs of each availability zone
+	ZoneVIPsConfigMapName string = "machine-config-zone-vips"
+
+	// ZoneVIPsConfigMapKey is the key of the zone VIPs ConfigMap holding the YAML list of the zones and their VIPs
+	ZoneVIPsConfigMapKey string = "zones.yaml"
+
+	// ConstantsConfigMapKey is the key of the images ConfigMap overriding the template constants, as a JSON object
+	ConstantsConfigMapKey string = "constants.json"
+
 	// MachineConfigOperatorKey is our own image used by e.g. machine-config-daemon-pull.service
 	MachineConfigOperatorKey string = "machineConfigOperator"
 
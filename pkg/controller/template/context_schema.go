@@ -0,0 +1,122 @@
+package template
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ContextSchema describes everything available to the templates: the fields
+// of the RenderConfig they're executed with and the functions they can call.
+type ContextSchema struct {
+	// Fields are the fields of the RenderConfig, including the promoted
+	// fields of the ControllerConfigSpec, e.g. Proxy for {{.Proxy}}.
+	Fields []ContextField `json:"fields"`
+	// Functions are the functions registered on top of the sprig ones.
+	Functions []ContextFunction `json:"functions"`
+	// SprigFunctions are the names of the allowed sprig functions.
+	SprigFunctions []string `json:"sprigFunctions"`
+}
+
+// ContextField is a field of the template context, as it's referenced in the
+// templates, e.g. HTTPProxy in {{.Proxy.HTTPProxy}}.
+type ContextField struct {
+	Name string `json:"name"`
+	// Type is the Go type of the field, e.g. *v1.ProxyStatus.
+	Type string `json:"type"`
+	// Fields are the fields of a struct, or of the elements of a slice or a
+	// map of structs.
+	Fields []ContextField `json:"fields,omitempty"`
+	// Recursive is set when the type of the field is the one of an enclosing
+	// field, whose fields aren't listed again.
+	Recursive bool `json:"recursive,omitempty"`
+}
+
+// ContextFunction is a function the templates can call.
+type ContextFunction struct {
+	Name string `json:"name"`
+	// Params are the Go types of the parameters, e.g. template.RenderConfig
+	// for the functions called as {{fn .}}.
+	Params []string `json:"params"`
+	// Variadic is set when the last parameter takes any number of values.
+	Variadic bool `json:"variadic,omitempty"`
+	// Result is the Go type of the value the function returns.
+	Result string `json:"result"`
+	// Fails is set when the function can fail the rendering.
+	Fails bool `json:"fails,omitempty"`
+}
+
+// NewContextSchema returns the schema of the context of the templates, from
+// the RenderConfig and the functions currently registered.
+func NewContextSchema() *ContextSchema {
+	schema := &ContextSchema{
+		Fields:         contextFields(reflect.TypeOf(RenderConfig{}), map[reflect.Type]bool{}),
+		Functions:      []ContextFunction{},
+		SprigFunctions: []string{},
+	}
+
+	funcsLock.RLock()
+	defer funcsLock.RUnlock()
+	for name, fn := range funcs {
+		if !registeredFuncs[name] {
+			schema.SprigFunctions = append(schema.SprigFunctions, name)
+			continue
+		}
+		t := reflect.TypeOf(fn)
+		f := ContextFunction{Name: name, Params: []string{}, Variadic: t.IsVariadic(), Result: t.Out(0).String(), Fails: t.NumOut() == 2}
+		for i := 0; i < t.NumIn(); i++ {
+			f.Params = append(f.Params, t.In(i).String())
+		}
+		schema.Functions = append(schema.Functions, f)
+	}
+	sort.Slice(schema.Functions, func(i, j int) bool { return schema.Functions[i].Name < schema.Functions[j].Name })
+	sort.Strings(schema.SprigFunctions)
+	return schema
+}
+
+// contextFields returns the exported fields of the struct t, with the ones of
+// its embedded structs promoted like text/template does.  enclosing are the
+// struct types of the enclosing fields, so that recursive types end.
+func contextFields(t reflect.Type, enclosing map[reflect.Type]bool) []ContextField {
+	enclosing[t] = true
+	defer delete(enclosing, t)
+
+	fields := []ContextField{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.Anonymous {
+			if embedded := structType(sf.Type); embedded != nil {
+				fields = append(fields, contextFields(embedded, enclosing)...)
+				continue
+			}
+		}
+		if sf.PkgPath != "" {
+			// unexported
+			continue
+		}
+		field := ContextField{Name: sf.Name, Type: sf.Type.String()}
+		if st := structType(sf.Type); st != nil {
+			if enclosing[st] {
+				field.Recursive = true
+			} else {
+				field.Fields = contextFields(st, enclosing)
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// structType returns the struct type t is, points to, or holds the elements
+// of, or nil if it's none of them.
+func structType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			return t
+		default:
+			return nil
+		}
+	}
+}
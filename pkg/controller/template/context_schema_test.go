@@ -0,0 +1,47 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findContextField(fields []ContextField, name string) *ContextField {
+	for i := range fields {
+		if fields[i].Name == name {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+func TestNewContextSchema(t *testing.T) {
+	schema := NewContextSchema()
+
+	// promoted from the ControllerConfigSpec
+	proxy := findContextField(schema.Fields, "Proxy")
+	require.NotNil(t, proxy)
+	assert.Equal(t, "*v1.ProxyStatus", proxy.Type)
+	httpProxy := findContextField(proxy.Fields, "HTTPProxy")
+	require.NotNil(t, httpProxy)
+	assert.Equal(t, "string", httpProxy.Type)
+	assert.Nil(t, findContextField(schema.Fields, "ControllerConfigSpec"))
+
+	assert.NotNil(t, findContextField(schema.Fields, "PoolVars"))
+
+	var noProxy *ContextFunction
+	for i, f := range schema.Functions {
+		if f.Name == "clusterNoProxy" {
+			noProxy = &schema.Functions[i]
+		}
+		assert.NotEqual(t, "default", f.Name, "sprig functions are listed apart")
+	}
+	require.NotNil(t, noProxy)
+	assert.Equal(t, []string{"template.RenderConfig"}, noProxy.Params)
+	assert.Equal(t, "string", noProxy.Result)
+	assert.True(t, noProxy.Fails)
+
+	assert.Contains(t, schema.SprigFunctions, "default")
+	assert.NotContains(t, schema.SprigFunctions, "clusterNoProxy")
+}
@@ -0,0 +1,60 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// systemdEscape is a template function that escapes a value for use inside a
+// double quoted word of a systemd unit, e.g. Environment="FOO={{systemdEscape .Foo}}".
+// Quotes, backslashes and control characters are escaped and % is doubled so
+// it isn't expanded as a specifier.  $ is left alone: in Exec lines pass such
+// values through Environment= and reference them as ${FOO}.
+func systemdEscape(s string) (string, error) {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == 0:
+			return "", fmt.Errorf("systemd values can't contain NUL bytes: %q", s)
+		case r == '\\' || r == '"':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '%':
+			b.WriteString("%%")
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), nil
+}
+
+// shellQuote is a template function that single quotes a value as a single
+// word of a POSIX shell script, e.g. export HTTP_PROXY={{shellQuote .Proxy.HTTPProxy}}.
+func shellQuote(s string) (string, error) {
+	if strings.ContainsRune(s, 0) {
+		return "", fmt.Errorf("shell words can't contain NUL bytes: %q", s)
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'", nil
+}
+
+// jsonEscape is a template function that escapes a value for use inside a
+// JSON string, e.g. "server": "{{jsonEscape .URL}}".
+func jsonEscape(s string) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return "", err
+	}
+	// strip the quotes and the newline added by Encode
+	escaped := strings.TrimSuffix(buf.String(), "\n")
+	return escaped[1 : len(escaped)-1], nil
+}
@@ -0,0 +1,316 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/Masterminds/sprig"
+)
+
+var (
+	funcsLock sync.RWMutex
+	// funcs are the functions available to the templates: the allowed sprig
+	// ones, and the ones registered on top of them.
+	funcs = allowedSprigFuncs()
+	// disallowedSprigFuncs are the names of the sprig functions which aren't
+	// in sprigAllowlist.
+	disallowedSprigFuncs = map[string]bool{}
+	// registeredFuncs are the names of the registered functions.
+	registeredFuncs = map[string]bool{}
+
+	funcNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	errorType      = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// sprigAllowlist are the sprig functions available to the templates, the ones
+// computing their result from their arguments only.  The others read the
+// environment of the controller (env, expandenv), resolve host names
+// (getHostByName), generate keys, certificates and passwords, or depend on the
+// time or on randomness, which would render other MachineConfigs on each sync.
+var sprigAllowlist = map[string]bool{
+	// strings
+	"abbrev": true, "abbrevboth": true, "camelcase": true, "cat": true, "contains": true,
+	"hasPrefix": true, "hasSuffix": true, "indent": true, "initials": true, "join": true,
+	"kebabcase": true, "lower": true, "nindent": true, "nospace": true, "plural": true,
+	"quote": true, "repeat": true, "replace": true, "snakecase": true, "split": true,
+	"splitList": true, "splitn": true, "squote": true, "substr": true, "swapcase": true,
+	"title": true, "toString": true, "toStrings": true, "trim": true, "trimAll": true,
+	"trimPrefix": true, "trimSuffix": true, "trimall": true, "trunc": true, "untitle": true,
+	"upper": true, "wrap": true, "wrapWith": true,
+	// regular expressions
+	"regexFind": true, "regexFindAll": true, "regexMatch": true, "regexReplaceAll": true,
+	"regexReplaceAllLiteral": true, "regexSplit": true,
+	// numbers
+	"add": true, "add1": true, "atoi": true, "biggest": true, "ceil": true, "div": true,
+	"float64": true, "floor": true, "int": true, "int64": true, "max": true, "min": true,
+	"mod": true, "mul": true, "round": true, "sub": true, "toDecimal": true, "until": true,
+	"untilStep": true,
+	// lists and dicts
+	"append": true, "compact": true, "concat": true, "deepCopy": true, "dict": true,
+	"first": true, "has": true, "hasKey": true, "initial": true, "keys": true, "last": true,
+	"list": true, "merge": true, "mergeOverwrite": true, "omit": true, "pick": true,
+	"pluck": true, "prepend": true, "push": true, "rest": true, "reverse": true, "set": true,
+	"slice": true, "sortAlpha": true, "tuple": true, "uniq": true, "unset": true,
+	"values": true, "without": true,
+	// encodings and checksums
+	"adler32sum": true, "b32dec": true, "b32enc": true, "b64dec": true, "b64enc": true,
+	"sha1sum": true, "sha256sum": true, "toJson": true, "toPrettyJson": true,
+	// paths and URLs
+	"base": true, "clean": true, "dir": true, "ext": true, "isAbs": true, "urlJoin": true,
+	"urlParse": true,
+	// flow control, defaults and types
+	"coalesce": true, "deepEqual": true, "default": true, "empty": true, "fail": true,
+	"kindIs": true, "kindOf": true, "ternary": true, "typeIs": true, "typeIsLike": true,
+	"typeOf": true,
+	// versions
+	"semver": true, "semverCompare": true,
+}
+
+// allowedSprigFuncs returns the sprig functions of sprigAllowlist, and records
+// the names of the others in disallowedSprigFuncs.
+func allowedSprigFuncs() template.FuncMap {
+	allowed := template.FuncMap{}
+	for name, fn := range sprig.TxtFuncMap() {
+		if sprigAllowlist[name] {
+			allowed[name] = fn
+		} else {
+			disallowedSprigFuncs[name] = true
+		}
+	}
+	return allowed
+}
+
+// RegisterFunc makes fn available to the templates rendered by the
+// controller and at bootstrap as name.  It fails if name is already taken by
+// a sprig or registered function, use OverrideFunc to replace one.  Like the
+// functions of a template.FuncMap, fn must return a value, and optionally an
+// error failing the rendering.
+func RegisterFunc(name string, fn interface{}) error {
+	if err := validateFunc(name, fn); err != nil {
+		return err
+	}
+	if name == includeFunc {
+		return fmt.Errorf("template function %s is reserved for the partials", name)
+	}
+	funcsLock.Lock()
+	defer funcsLock.Unlock()
+	if _, ok := funcs[name]; ok {
+		if registeredFuncs[name] {
+			return fmt.Errorf("template function %s is already registered", name)
+		}
+		return fmt.Errorf("template function %s conflicts with the sprig function", name)
+	}
+	funcs[name] = fn
+	registeredFuncs[name] = true
+	return nil
+}
+
+// MustRegisterFunc is like RegisterFunc but panics on error, for
+// registrations in init functions.
+func MustRegisterFunc(name string, fn interface{}) {
+	if err := RegisterFunc(name, fn); err != nil {
+		panic(err)
+	}
+}
+
+// OverrideFunc replaces the sprig or registered function name with fn, e.g.
+// to stub a function reading the cluster in tests.  It fails if there is no
+// such function, so a misspelled name doesn't go unnoticed.  The returned
+// function restores the replaced one.
+func OverrideFunc(name string, fn interface{}) (func(), error) {
+	if err := validateFunc(name, fn); err != nil {
+		return nil, err
+	}
+	funcsLock.Lock()
+	defer funcsLock.Unlock()
+	previous, ok := funcs[name]
+	if !ok {
+		return nil, fmt.Errorf("template function %s to override doesn't exist", name)
+	}
+	funcs[name] = fn
+	return func() {
+		funcsLock.Lock()
+		defer funcsLock.Unlock()
+		funcs[name] = previous
+	}, nil
+}
+
+// RegisteredFuncs returns the sorted names of the registered functions,
+// without the sprig ones.
+func RegisteredFuncs() []string {
+	funcsLock.RLock()
+	defer funcsLock.RUnlock()
+	names := make([]string, 0, len(registeredFuncs))
+	for name := range registeredFuncs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// templateFuncs returns a copy of the functions available to the templates.
+func templateFuncs() template.FuncMap {
+	funcsLock.RLock()
+	defer funcsLock.RUnlock()
+	copied := make(template.FuncMap, len(funcs))
+	for name, fn := range funcs {
+		copied[name] = fn
+	}
+	return copied
+}
+
+// parseTemplate parses the template text named name with the functions
+// available to the templates, along with the partials it may include.  It
+// fails if the template uses sprig functions which aren't allowed, naming
+// them, rather than with text/template's error for undefined functions.
+func parseTemplate(name, text string, strict bool, partials map[string]string) (*template.Template, error) {
+	available := templateFuncs()
+	// the disallowed functions are defined for parsing only, and fail if
+	// they're called
+	stubs := template.FuncMap{}
+	for fn := range disallowedSprigFuncs {
+		if _, ok := available[fn]; !ok {
+			fn := fn
+			stubs[fn] = func(...interface{}) (string, error) {
+				return "", fmt.Errorf("template function %s isn't allowed", fn)
+			}
+		}
+	}
+	tmpl := template.New(name).Funcs(stubs).Funcs(available)
+	if strict {
+		tmpl = tmpl.Option("missingkey=error")
+	}
+	tmpl, err := addPartials(tmpl, partials)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err = tmpl.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	used := map[string]bool{}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			usedFuncs(t.Tree.Root, used)
+		}
+	}
+	var disallowed []string
+	for fn := range used {
+		if _, ok := stubs[fn]; ok {
+			disallowed = append(disallowed, fn)
+		}
+	}
+	if len(disallowed) > 0 {
+		sort.Strings(disallowed)
+		return nil, fmt.Errorf("template uses sprig functions which aren't allowed: %s", strings.Join(disallowed, ", "))
+	}
+	return tmpl, nil
+}
+
+// usedFuncs adds the names of the functions called in node to used.
+func usedFuncs(node parse.Node, used map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			usedFuncs(c, used)
+		}
+	case *parse.ActionNode:
+		usedFuncs(n.Pipe, used)
+	case *parse.TemplateNode:
+		usedFuncs(n.Pipe, used)
+	case *parse.IfNode:
+		usedBranchFuncs(&n.BranchNode, used)
+	case *parse.RangeNode:
+		usedBranchFuncs(&n.BranchNode, used)
+	case *parse.WithNode:
+		usedBranchFuncs(&n.BranchNode, used)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			usedFuncs(c, used)
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			usedFuncs(a, used)
+		}
+	case *parse.ChainNode:
+		usedFuncs(n.Node, used)
+	case *parse.IdentifierNode:
+		used[n.Ident] = true
+	}
+}
+
+func usedBranchFuncs(n *parse.BranchNode, used map[string]bool) {
+	usedFuncs(n.Pipe, used)
+	usedFuncs(n.List, used)
+	usedFuncs(n.ElseList, used)
+}
+
+// validateFunc checks what text/template would otherwise panic on when
+// parsing the templates.
+func validateFunc(name string, fn interface{}) error {
+	if !funcNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid template function name %q", name)
+	}
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Errorf("template function %s is not a function", name)
+	}
+	t := v.Type()
+	switch {
+	case t.NumOut() == 1:
+	case t.NumOut() == 2 && t.Out(1) == errorType:
+	default:
+		return fmt.Errorf("template function %s must return a value, and optionally an error", name)
+	}
+	return nil
+}
+
+func init() {
+	MustRegisterFunc("skip", skipMissing)
+	MustRegisterFunc("cloudProvider", cloudProvider)
+	MustRegisterFunc("cloudConfigFlag", cloudConfigFlag)
+	MustRegisterFunc("cloudProviderConfig", cloudProviderConfig)
+	MustRegisterFunc("cloudServiceEndpoints", cloudServiceEndpoints)
+	MustRegisterFunc("cloudServiceEndpoint", cloudServiceEndpoint)
+	MustRegisterFunc("onPremPlatformAPIServerInternalIP", onPremPlatformAPIServerInternalIP)
+	MustRegisterFunc("onPremPlatformAPIServerInternalIPs", onPremPlatformAPIServerInternalIPs)
+	MustRegisterFunc("apiServerInternalEndpoints", apiServerInternalEndpoints)
+	MustRegisterFunc("internalRegistryHostname", internalRegistryHostname)
+	MustRegisterFunc("imageRegistryHostnames", imageRegistryHostnames)
+	MustRegisterFunc("onPremPlatformIngressIP", onPremPlatformIngressIP)
+	MustRegisterFunc("onPremPlatformIngressIPs", onPremPlatformIngressIPs)
+	MustRegisterFunc("onPremPlatformShortName", onPremPlatformShortName)
+	MustRegisterFunc("onPremPlatformKeepalivedEnableUnicast", onPremPlatformKeepalivedEnableUnicast)
+	MustRegisterFunc("onPremZoneVIPs", onPremZoneVIPs)
+	MustRegisterFunc("isSNO", isSNO)
+	MustRegisterFunc("controlPlaneReplicas", controlPlaneReplicas)
+	MustRegisterFunc("cgroupMode", cgroupMode)
+	MustRegisterFunc("clusterDNSIP", clusterDNSIP)
+	MustRegisterFunc("isFIPS", isFIPS)
+	MustRegisterFunc("isSingleStackIPv6", isSingleStackIPv6)
+	MustRegisterFunc("isDualStack", isDualStack)
+	MustRegisterFunc("primaryIPFamily", primaryIPFamily)
+	MustRegisterFunc("isPTPSynced", isPTPSynced)
+	MustRegisterFunc("urlHost", urlHost)
+	MustRegisterFunc("urlPort", urlPort)
+	MustRegisterFunc("systemdEscape", systemdEscape)
+	MustRegisterFunc("shellQuote", shellQuote)
+	MustRegisterFunc("jsonEscape", jsonEscape)
+	MustRegisterFunc("clusterHTTPProxy", clusterHTTPProxy)
+	MustRegisterFunc("clusterHTTPSProxy", clusterHTTPSProxy)
+	MustRegisterFunc("clusterNoProxy", clusterNoProxy)
+	MustRegisterFunc("proxyEnvFile", proxyEnvFile)
+}
@@ -0,0 +1,144 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegisterFunc(t *testing.T) {
+	defer func() {
+		funcsLock.Lock()
+		delete(funcs, "testGreeting")
+		delete(registeredFuncs, "testGreeting")
+		funcsLock.Unlock()
+	}()
+
+	if err := RegisterFunc("testGreeting", func(cfg RenderConfig) string { return "hello " + cfg.PullSecret }); err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	got, err := renderTemplate(RenderConfig{PullSecret: "world"}, "greeting", []byte(`{{testGreeting .}}`))
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	if string(got) != "hello world" {
+		t.Fatalf("mismatch got: %s want: hello world", got)
+	}
+
+	for name, want := range map[string]string{
+		"testGreeting": "already registered",
+		"isSNO":        "already registered",
+		"trim":         "conflicts with the sprig function",
+		"include":      "reserved for the partials",
+	} {
+		err := RegisterFunc(name, func() string { return "" })
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, want, err)
+		}
+	}
+}
+
+func TestValidateFunc(t *testing.T) {
+	for name, c := range map[string]struct {
+		name string
+		fn   interface{}
+		want string
+	}{
+		"invalid name":       {name: "a-b", fn: func() string { return "" }, want: "invalid template function name"},
+		"not a function":     {name: "notAFunc", fn: "", want: "is not a function"},
+		"nil function":       {name: "nilFunc", fn: (func() string)(nil), want: "is not a function"},
+		"no result":          {name: "noResult", fn: func() {}, want: "must return a value"},
+		"second not error":   {name: "twoValues", fn: func() (string, string) { return "", "" }, want: "must return a value"},
+		"value and an error": {name: "valid", fn: func() (string, error) { return "", nil }},
+	} {
+		err := validateFunc(c.name, c.fn)
+		if c.want == "" && err != nil {
+			t.Errorf("%s: expected nil error %v", name, err)
+		}
+		if c.want != "" && (err == nil || !strings.Contains(err.Error(), c.want)) {
+			t.Errorf("%s: expected error containing %q, got %v", name, c.want, err)
+		}
+	}
+}
+
+func TestOverrideFunc(t *testing.T) {
+	config := RenderConfig{PullSecret: "dummy"}
+	restore, err := OverrideFunc("isSNO", func(RenderConfig) bool { return true })
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	got, err := renderTemplate(config, "sno", []byte(`{{isSNO .}}`))
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	if string(got) != "true" {
+		t.Fatalf("mismatch got: %s want: true", got)
+	}
+
+	restore()
+	funcsLock.RLock()
+	_, ok := funcs["isSNO"].(func(RenderConfig) interface{})
+	funcsLock.RUnlock()
+	if !ok {
+		t.Fatal("expected the built-in isSNO to be restored")
+	}
+
+	if _, err := OverrideFunc("isSingleNode", func() bool { return true }); err == nil {
+		t.Fatal("expected an error overriding a function that doesn't exist")
+	}
+}
+
+func TestRegisteredFuncs(t *testing.T) {
+	names := RegisteredFuncs()
+	for i := 1; i < len(names); i++ {
+		if names[i-1] >= names[i] {
+			t.Fatalf("expected sorted names, got %v", names)
+		}
+	}
+	for _, name := range []string{"isSNO", "onPremPlatformIngressIPs", "systemdEscape"} {
+		found := false
+		for _, n := range names {
+			found = found || n == name
+		}
+		if !found {
+			t.Errorf("expected %s to be registered", name)
+		}
+	}
+	for _, n := range names {
+		if n == "trim" {
+			t.Fatal("expected the sprig functions not to be listed")
+		}
+	}
+}
+
+func TestSprigAllowlist(t *testing.T) {
+	got, err := renderTemplate(RenderConfig{}, "allowed", []byte(`{{"a" | indent 2}}{{list "b" "c" | join ","}}`))
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	if string(got) != "  ab,c" {
+		t.Fatalf("mismatch got: %s want:   ab,c", got)
+	}
+
+	for name, text := range map[string]string{
+		"env":       `{{env "HOME"}}`,
+		"piped":     `{{"$HOME" | expandenv}}`,
+		"branch":    `{{if true}}{{else}}{{getHostByName "example.com"}}{{end}}`,
+		"range":     `{{range until 2}}{{now}}{{end}}`,
+		"defined":   `{{define "t"}}{{uuidv4}}{{end}}`,
+		"argument":  `{{print (randAlphaNum 8)}}`,
+		"undefined": `{{notAFunction}}`,
+	} {
+		_, err := renderTemplate(RenderConfig{}, name, []byte(text))
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+			continue
+		}
+		if allowed := !strings.Contains(err.Error(), "aren't allowed"); allowed != (name == "undefined") {
+			t.Errorf("%s: unexpected error %v", name, err)
+		}
+	}
+
+	if _, ok := templateFuncs()["env"]; ok {
+		t.Fatal("expected env not to be available")
+	}
+}
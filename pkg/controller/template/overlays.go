@@ -0,0 +1,152 @@
+package template
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// TemplateOverlay is a template added by the cluster admin through the
+// overlays ConfigMap to the templates of a role, e.g. a unit of an agent
+// only needed on some platform.
+type TemplateOverlay struct {
+	// Role is the role directory of the templates, e.g. worker.  The
+	// overlays of the worker role apply to the custom pools too.
+	Role string
+	// Name is the template MachineConfig, e.g. 00-worker.
+	Name string
+	// Type is files, units or tmpfiles, like the template directories.
+	Type string
+	// File is the name of the template, e.g. my-agent.service.yaml.
+	File string
+	// Contents is the template, rendered like the built-in ones.
+	Contents string
+}
+
+// key returns the key of the overlay in the overlays ConfigMap.
+func (o TemplateOverlay) key() string {
+	return strings.Join([]string{o.Role, o.Name, o.Type, o.File}, ".")
+}
+
+// ParseTemplateOverlays parses the data of the overlays ConfigMap, whose keys
+// are <role>.<name>.<type>.<file>, e.g. worker.00-worker.units.my-agent.service.yaml,
+// and checks each overlay targets a template MachineConfig of templatesDir.
+func ParseTemplateOverlays(data map[string]string, templatesDir string) ([]TemplateOverlay, error) {
+	var keys []string
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var overlays []TemplateOverlay
+	for _, key := range keys {
+		parts := strings.SplitN(key, ".", 4)
+		if len(parts) != 4 || parts[0] == "" || parts[1] == "" || parts[3] == "" {
+			return nil, fmt.Errorf("invalid overlay %q: the key must be <role>.<name>.<type>.<file>", key)
+		}
+		overlay := TemplateOverlay{Role: parts[0], Name: parts[1], Type: parts[2], File: parts[3], Contents: data[key]}
+		switch overlay.Type {
+		case filesDir, unitsDir, tmpfilesDir:
+		default:
+			return nil, fmt.Errorf("invalid overlay %q: type must be %s, %s or %s", key, filesDir, unitsDir, tmpfilesDir)
+		}
+		// Overlays only add templates, they can't remove the built-in ones.
+		if strings.HasSuffix(overlay.File, deleteMarkerSuffix) || strings.HasSuffix(overlay.File, keepMarkerSuffix) || strings.TrimSpace(overlay.Contents) == "" {
+			return nil, fmt.Errorf("invalid overlay %q: overlays can only add templates, not markers or empty ones", key)
+		}
+		if overlay.Role == "common" {
+			return nil, fmt.Errorf("invalid overlay %q: the common templates can't be overlaid, overlay those of each role instead", key)
+		}
+		exists, err := existsDir(filepath.Join(templatesDir, overlay.Role, overlay.Name))
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("invalid overlay %q: there is no template MachineConfig %s for role %s", key, overlay.Name, overlay.Role)
+		}
+		overlays = append(overlays, overlay)
+	}
+	return overlays, nil
+}
+
+// renderOverlays renders the overlays of the template MachineConfig name in
+// the role directory, by type and file like filterTemplates does.  An overlay
+// may not replace a built-in template of the same type and file.
+func renderOverlays(config *RenderConfig, roleDir, name string, builtin map[string]map[string]string) (map[string]map[string]string, error) {
+	rendered := map[string]map[string]string{filesDir: {}, unitsDir: {}, tmpfilesDir: {}}
+	for _, overlay := range config.Overlays {
+		if overlay.Role != roleDir || overlay.Name != name {
+			continue
+		}
+		if _, ok := builtin[overlay.Type][overlay.File]; ok {
+			return nil, fmt.Errorf("overlay %q replaces the built-in %s template %s of %s, overlays can only add templates", overlay.key(), overlay.Type, overlay.File, name)
+		}
+		data, err := renderTemplate(*config, "overlay "+overlay.key(), []byte(overlay.Contents))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > 0 {
+			rendered[overlay.Type][overlay.File] = string(data)
+		}
+	}
+	return rendered, nil
+}
+
+// mergeOverlays adds the files and units of the rendered overlays to the
+// config of the built-in templates.  An overlay may not write a file a
+// built-in template writes, nor redefine a built-in unit, but it may add
+// dropins to it.
+func mergeOverlays(ignCfg *ign3types.Config, files, units []string) error {
+	if len(files) == 0 && len(units) == 0 {
+		return nil
+	}
+	overlayCfg, err := ctrlcommon.TranspileCoreOSConfigToIgn(files, units)
+	if err != nil {
+		return fmt.Errorf("error transpiling overlays to Ignition config: %v", err)
+	}
+	paths := map[string]bool{}
+	for _, f := range ignCfg.Storage.Files {
+		paths[f.Path] = true
+	}
+	for _, f := range overlayCfg.Storage.Files {
+		if paths[f.Path] {
+			return fmt.Errorf("an overlay writes the file %s of a built-in template, overlays can only add files", f.Path)
+		}
+	}
+	builtinUnits := map[string]*ign3types.Unit{}
+	for i := range ignCfg.Systemd.Units {
+		builtinUnits[ignCfg.Systemd.Units[i].Name] = &ignCfg.Systemd.Units[i]
+	}
+	var newUnits []ign3types.Unit
+	for _, u := range overlayCfg.Systemd.Units {
+		builtinUnit, ok := builtinUnits[u.Name]
+		if !ok {
+			newUnits = append(newUnits, u)
+			continue
+		}
+		// A built-in unit can only be extended by new dropins.
+		if u.Contents != nil || u.Enabled != nil || u.Mask != nil {
+			return fmt.Errorf("an overlay redefines the unit %s of a built-in template, overlays can only add dropins to it", u.Name)
+		}
+		for _, d := range u.Dropins {
+			for _, existing := range builtinUnit.Dropins {
+				if existing.Name == d.Name {
+					return fmt.Errorf("an overlay redefines the dropin %s of the unit %s of a built-in template", d.Name, u.Name)
+				}
+			}
+			builtinUnit.Dropins = append(builtinUnit.Dropins, d)
+		}
+	}
+	if ignCfg.Ignition.Version == "" {
+		// the config is empty if the template has no files and units
+		ignCfg.Ignition.Version = ign3types.MaxVersion.String()
+	}
+	ignCfg.Storage.Files = append(ignCfg.Storage.Files, overlayCfg.Storage.Files...)
+	ignCfg.Systemd.Units = append(ignCfg.Systemd.Units, newUnits...)
+	return nil
+}
@@ -0,0 +1,140 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+func TestParseTemplateOverlays(t *testing.T) {
+	overlays, err := ParseTemplateOverlays(map[string]string{
+		"worker.01-worker-kubelet.units.my-agent.service.yaml": "name: my-agent.service\n",
+		"master.00-master.files.my-agent.yaml":                 "path: /etc/my-agent.conf\n",
+	}, templateDir)
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	if len(overlays) != 2 {
+		t.Fatalf("expected 2 overlays, got %v", overlays)
+	}
+	want := TemplateOverlay{Role: "worker", Name: "01-worker-kubelet", Type: "units", File: "my-agent.service.yaml", Contents: "name: my-agent.service\n"}
+	if overlays[1] != want {
+		t.Fatalf("mismatch got: %v want: %v", overlays[1], want)
+	}
+
+	for key, want := range map[string]string{
+		"worker.00-worker.units":                     "the key must be",
+		"worker.00-worker.scripts.a.sh":              "type must be",
+		"worker.00-worker.files.kubelet.yaml.delete": "overlays can only add templates",
+		"common._base.files.a.yaml":                  "common templates can't be overlaid",
+		"worker.02-worker-extra.files.a.yaml":        "there is no template MachineConfig 02-worker-extra",
+	} {
+		_, err := ParseTemplateOverlays(map[string]string{key: "path: /etc/a\n"}, templateDir)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", key, want, err)
+		}
+	}
+}
+
+func generateWithOverlays(t *testing.T, role string, data map[string]string) ([]*mcfgv1.MachineConfig, error) {
+	t.Helper()
+	controllerConfig, err := controllerConfigFromFile(configs["aws"])
+	if err != nil {
+		t.Fatalf("failed to get controllerconfig config: %v", err)
+	}
+	overlays, err := ParseTemplateOverlays(data, templateDir)
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	return GenerateMachineConfigsForRole(&RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true, Overlays: overlays}, role, templateDir)
+}
+
+func TestGenerateMachineConfigsWithOverlays(t *testing.T) {
+	// the overlays of the worker role apply to custom pools
+	cfgs, err := generateWithOverlays(t, "custom", map[string]string{
+		"worker.00-worker.files.my-agent.yaml": `mode: 0644
+path: "/etc/my-agent.conf"
+contents:
+  inline: |
+    image={{ .Images.infraImageKey }}
+`,
+		"worker.01-worker-kubelet.units.my-agent.service.yaml": `name: my-agent.service
+enabled: true
+contents: |
+  [Service]
+  ExecStart=/usr/bin/true
+`,
+		"worker.01-worker-kubelet.units.kubelet-dropin.yaml": `name: kubelet.service
+dropins:
+- name: 90-my-agent.conf
+  contents: |
+    [Service]
+    Environment="MY_AGENT=1"
+`,
+	})
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+
+	var files []ign3types.File
+	var units []ign3types.Unit
+	var kubeletUnit *ign3types.Unit
+	for _, cfg := range cfgs {
+		ign, err := ctrlcommon.ParseAndConvertConfig(cfg.Spec.Config.Raw)
+		if err != nil {
+			t.Fatalf("failed to parse Ignition config: %v", err)
+		}
+		files = append(files, ign.Storage.Files...)
+		units = append(units, ign.Systemd.Units...)
+		for i, u := range ign.Systemd.Units {
+			if cfg.Name == "01-worker-kubelet" && u.Name == "kubelet.service" {
+				kubeletUnit = &ign.Systemd.Units[i]
+			}
+		}
+	}
+	if !findIgnFile(files, "/etc/my-agent.conf", t) {
+		t.Fatal("expected the file of the overlay")
+	}
+	if !findIgnUnit(units, "my-agent.service", t) {
+		t.Fatal("expected the unit of the overlay")
+	}
+	if kubeletUnit == nil || kubeletUnit.Contents == nil || !strings.Contains(*kubeletUnit.Contents, "ExecStart=/usr/bin/hyperkube") {
+		t.Fatal("expected the built-in kubelet.service to be kept")
+	}
+	foundDropin := false
+	for _, d := range kubeletUnit.Dropins {
+		foundDropin = foundDropin || d.Name == "90-my-agent.conf"
+	}
+	if !foundDropin {
+		t.Fatal("expected the dropin of the overlay")
+	}
+}
+
+func TestGenerateMachineConfigsWithConflictingOverlays(t *testing.T) {
+	for key, c := range map[string]struct {
+		contents string
+		want     string
+	}{
+		"worker.01-worker-kubelet.units.kubelet.service.yaml": {
+			contents: "name: my-kubelet.service\n",
+			want:     "replaces the built-in units template kubelet.service.yaml",
+		},
+		"worker.01-worker-kubelet.files.my-kubelet.yaml": {
+			contents: "path: /etc/kubernetes/kubelet.conf\ncontents:\n  inline: \"\"\n",
+			want:     "writes the file /etc/kubernetes/kubelet.conf of a built-in template",
+		},
+		"worker.01-worker-kubelet.units.my-kubelet.service.yaml": {
+			contents: "name: kubelet.service\ncontents: |\n  [Service]\n  ExecStart=/usr/bin/true\n",
+			want:     "redefines the unit kubelet.service",
+		},
+	} {
+		_, err := generateWithOverlays(t, "worker", map[string]string{key: c.contents})
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: expected error containing %q, got %v", key, c.want, err)
+		}
+	}
+}
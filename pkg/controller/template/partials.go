@@ -0,0 +1,75 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const (
+	// partialsDir is the directory of the templates dir holding the
+	// partials, the templates shared by the templates of all roles.
+	partialsDir = "_partials"
+	// includeFunc is the function rendering a partial to a string, so that
+	// it can be piped, e.g. to indent.
+	includeFunc = "include"
+)
+
+// loadPartials returns the partials of templateDir, by name: the name of
+// their file without its extension, e.g. proxy-env for
+// templates/_partials/proxy-env.tmpl.  A templates dir without partials has
+// none.
+func loadPartials(templateDir string) (map[string]string, error) {
+	dir := filepath.Join(templateDir, partialsDir)
+	infos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dir %q: %v", dir, err)
+	}
+
+	partials := map[string]string{}
+	paths := map[string]string{}
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, info.Name())
+		name := strings.TrimSuffix(info.Name(), filepath.Ext(info.Name()))
+		if other, ok := paths[name]; ok {
+			return nil, fmt.Errorf("partials %q and %q have the same name %s", other, path, name)
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %q: %v", path, err)
+		}
+		partials[name] = string(data)
+		paths[name] = path
+	}
+	return partials, nil
+}
+
+// addPartials parses partials as templates associated with tmpl, and
+// defines the include function rendering them.
+func addPartials(tmpl *template.Template, partials map[string]string) (*template.Template, error) {
+	tmpl = tmpl.Funcs(template.FuncMap{
+		includeFunc: func(name string, data interface{}) (string, error) {
+			buf := new(bytes.Buffer)
+			if err := tmpl.ExecuteTemplate(buf, name, data); err != nil {
+				return "", err
+			}
+			return buf.String(), nil
+		},
+	})
+	for name, text := range partials {
+		if _, err := tmpl.New(name).Parse(text); err != nil {
+			return nil, fmt.Errorf("failed to parse partial %s: %v", name, err)
+		}
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,132 @@
+package template
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+func writePartial(t *testing.T, dir, name, text string) {
+	path := filepath.Join(dir, partialsDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadPartials(t *testing.T) {
+	dir := t.TempDir()
+	partials, err := loadPartials(dir)
+	if err != nil || partials != nil {
+		t.Fatalf("expected no partials without a partials dir, got %v %v", partials, err)
+	}
+
+	writePartial(t, dir, "proxy-env.tmpl", "EnvironmentFile=/etc/mco/proxy.env")
+	writePartial(t, dir, "greeting.yaml", "hello {{.}}")
+	partials, err = loadPartials(dir)
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	expected := map[string]string{
+		"proxy-env": "EnvironmentFile=/etc/mco/proxy.env",
+		"greeting":  "hello {{.}}",
+	}
+	if !reflect.DeepEqual(partials, expected) {
+		t.Fatalf("mismatch got: %v want: %v", partials, expected)
+	}
+
+	writePartial(t, dir, "greeting.tmpl", "hi {{.}}")
+	_, err = loadPartials(dir)
+	if err == nil || !strings.Contains(err.Error(), "have the same name greeting") {
+		t.Fatalf("expected a duplicate partial error, got %v", err)
+	}
+}
+
+func TestRenderTemplateWithPartials(t *testing.T) {
+	partials := map[string]string{
+		"dropin": "[Service]\nEnvironment=A={{.PullSecret}}\n",
+	}
+	for text, want := range map[string]string{
+		`{{template "dropin" .}}`:                          "[Service]\nEnvironment=A=secret\n",
+		"contents: |\n{{include \"dropin\" . | indent 2}}": "contents: |\n  [Service]\n  Environment=A=secret\n  ",
+	} {
+		got, err := renderTemplateWithPartials(RenderConfig{PullSecret: "secret"}, "unit", []byte(text), partials)
+		if err != nil {
+			t.Fatalf("%s: expected nil error %v", text, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: mismatch got: %q want: %q", text, got, want)
+		}
+	}
+
+	for name, c := range map[string]struct {
+		partials map[string]string
+		want     string
+	}{
+		"missing":    {partials: map[string]string{"other": ""}, want: `no template "dropin"`},
+		"invalid":    {partials: map[string]string{"dropin": "{{if}}"}, want: "failed to parse partial dropin"},
+		"disallowed": {partials: map[string]string{"dropin": `{{env "HOME"}}`}, want: "aren't allowed"},
+	} {
+		_, err := renderTemplateWithPartials(RenderConfig{}, name, []byte(`{{include "dropin" .}}`), c.partials)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, c.want, err)
+		}
+	}
+}
+
+func TestGenerateMachineConfigsWithPartials(t *testing.T) {
+	dir := t.TempDir()
+	writePartial(t, dir, "motd.tmpl", "{{ index .PoolVars \"greeting\" | default \"hello\" }} from {{.Infra.Status.PlatformStatus.Type}}")
+	for _, role := range []string{"master", "worker"} {
+		path := filepath.Join(dir, role, "00-"+role, platformBase, filesDir, "motd.yaml")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		template := "mode: 0644\npath: /etc/motd\ncontents:\n  inline: {{include \"motd\" .}}\n"
+		if err := ioutil.WriteFile(path, []byte(template), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := &mcfgv1.ControllerConfig{
+		Spec: mcfgv1.ControllerConfigSpec{
+			Infra: &configv1.Infrastructure{
+				Status: configv1.InfrastructureStatus{
+					PlatformStatus: &configv1.PlatformStatus{Type: configv1.AWSPlatformType},
+				},
+			},
+		},
+	}
+	renderConfig := &RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}
+
+	cfgs, err := generateTemplateMachineConfigs(context.TODO(), renderConfig, dir, map[string]poolTemplateConfig{"worker": {Vars: map[string]string{"greeting": "hi"}}})
+	if err != nil {
+		t.Fatalf("failed to generate machine configs: %v", err)
+	}
+	motds := map[string]string{}
+	for _, cfg := range cfgs {
+		ign, err := ctrlcommon.ParseAndConvertConfig(cfg.Spec.Config.Raw)
+		if err != nil {
+			t.Fatalf("failed to parse Ignition config: %v", err)
+		}
+		contents, err := ctrlcommon.GetIgnitionFileDataByPath(&ign, "/etc/motd")
+		if err != nil {
+			t.Fatalf("expected /etc/motd in %s: %v", cfg.Name, err)
+		}
+		motds[cfg.Name] = string(contents)
+	}
+	expected := map[string]string{"00-master": "hello from AWS", "00-worker": "hi from AWS"}
+	if !reflect.DeepEqual(motds, expected) {
+		t.Fatalf("mismatch got: %v want: %v", motds, expected)
+	}
+}
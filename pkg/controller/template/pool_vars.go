@@ -0,0 +1,98 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// poolVarNameRegexp matches the names of pool variables, which the templates
+// reference as fields of .PoolVars.
+var poolVarNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parsePoolVars returns the template variables of the template-vars
+// annotation of pool, or nil if it has none.
+func parsePoolVars(pool *mcfgv1.MachineConfigPool) (map[string]string, error) {
+	data, ok := pool.Annotations[ctrlcommon.TemplateVarsAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+	vars := map[string]string{}
+	if err := json.Unmarshal([]byte(data), &vars); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation of pool %s: %w", ctrlcommon.TemplateVarsAnnotationKey, pool.Name, err)
+	}
+	for name := range vars {
+		if !poolVarNameRegexp.MatchString(name) {
+			return nil, fmt.Errorf("invalid %s annotation of pool %s: invalid variable name %q", ctrlcommon.TemplateVarsAnnotationKey, pool.Name, name)
+		}
+	}
+	return vars, nil
+}
+
+// poolTemplateConfig is what the annotations of a pool change in the
+// RenderConfig of the template MachineConfigs of its role.
+type poolTemplateConfig struct {
+	// Vars are the variables of the template-vars annotation, nil without it
+	Vars map[string]string
+	// TimeSyncSource is the time-sync-source annotation, empty without it
+	TimeSyncSource TimeSyncSource
+	// InfraTemplates is whether the infra-templates annotation opts the pool in
+	// to the MachineConfigs of the infra templates
+	InfraTemplates bool
+}
+
+// apply returns config with the changes of the pool, config itself if there
+// are none.
+func (c poolTemplateConfig) apply(config *RenderConfig) *RenderConfig {
+	if c.Vars == nil && c.TimeSyncSource == "" {
+		return config
+	}
+	poolConfig := &RenderConfig{}
+	*poolConfig = *config
+	if c.Vars != nil {
+		poolConfig.PoolVars = c.Vars
+	}
+	if c.TimeSyncSource != "" {
+		poolConfig.TimeSyncSource = c.TimeSyncSource
+	}
+	return poolConfig
+}
+
+// hasPoolTemplateAnnotations returns whether the annotations of pool change
+// the rendering of the template MachineConfigs.
+func hasPoolTemplateAnnotations(pool *mcfgv1.MachineConfigPool) bool {
+	_, vars := pool.Annotations[ctrlcommon.TemplateVarsAnnotationKey]
+	_, timeSync := pool.Annotations[ctrlcommon.TimeSyncSourceAnnotationKey]
+	_, infra := pool.Annotations[ctrlcommon.InfraTemplatesAnnotationKey]
+	return vars || timeSync || infra
+}
+
+// poolTemplateConfigs returns the template variables, time sync sources and
+// infra templates opt-ins of pools, keyed by pool name.  The template MachineConfigs of a role are
+// rendered with those of the pool of the same name, custom pools use the
+// worker MachineConfigs and so the ones of the worker pool.
+func poolTemplateConfigs(pools []*mcfgv1.MachineConfigPool) (map[string]poolTemplateConfig, error) {
+	var configs map[string]poolTemplateConfig
+	for _, pool := range pools {
+		vars, err := parsePoolVars(pool)
+		if err != nil {
+			return nil, err
+		}
+		timeSyncSource, err := timeSyncSourceFromAnnotations(pool.Annotations, "")
+		if err != nil {
+			return nil, fmt.Errorf("pool %s: %w", pool.Name, err)
+		}
+		infraTemplates := pool.Annotations[ctrlcommon.InfraTemplatesAnnotationKey] == "true"
+		if vars == nil && timeSyncSource == "" && !infraTemplates {
+			continue
+		}
+		if configs == nil {
+			configs = map[string]poolTemplateConfig{}
+		}
+		configs[pool.Name] = poolTemplateConfig{Vars: vars, TimeSyncSource: timeSyncSource, InfraTemplates: infraTemplates}
+	}
+	return configs, nil
+}
@@ -0,0 +1,114 @@
+package template
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+func newPoolWithVars(name, vars string) *mcfgv1.MachineConfigPool {
+	pool := &mcfgv1.MachineConfigPool{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if vars != "" {
+		pool.Annotations = map[string]string{ctrlcommon.TemplateVarsAnnotationKey: vars}
+	}
+	return pool
+}
+
+func TestPoolTemplateConfigs(t *testing.T) {
+	ptp := newPoolWithVars("ptp", "")
+	ptp.Annotations = map[string]string{ctrlcommon.TimeSyncSourceAnnotationKey: "PTP"}
+	infra := newPoolWithVars("infra", `{}`)
+	infra.Annotations[ctrlcommon.InfraTemplatesAnnotationKey] = "true"
+	poolConfigs, err := poolTemplateConfigs([]*mcfgv1.MachineConfigPool{
+		newPoolWithVars("master", ""),
+		newPoolWithVars("worker", `{"registry": "mirror.example.com", "reservedCPU": "500m"}`),
+		infra,
+		ptp,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]poolTemplateConfig{
+		"worker": {Vars: map[string]string{"registry": "mirror.example.com", "reservedCPU": "500m"}},
+		"infra":  {Vars: map[string]string{}, InfraTemplates: true},
+		"ptp":    {TimeSyncSource: TimeSyncSourcePTP},
+	}
+	if !reflect.DeepEqual(poolConfigs, expected) {
+		t.Fatalf("mismatch got: %v want: %v", poolConfigs, expected)
+	}
+
+	ptp.Annotations[ctrlcommon.TimeSyncSourceAnnotationKey] = "GPS"
+	_, err = poolTemplateConfigs([]*mcfgv1.MachineConfigPool{ptp})
+	if err == nil || !strings.Contains(err.Error(), `pool ptp: invalid machineconfiguration.openshift.io/time-sync-source annotation "GPS"`) {
+		t.Errorf("expected an invalid time sync source error, got %v", err)
+	}
+
+	for vars, want := range map[string]string{
+		`not json`:             "invalid machineconfiguration.openshift.io/template-vars annotation of pool worker",
+		`{"cpu": 1}`:           "cannot unmarshal number",
+		`{"reserved-cpu": ""}`: `invalid variable name "reserved-cpu"`,
+	} {
+		_, err := poolTemplateConfigs([]*mcfgv1.MachineConfigPool{newPoolWithVars("worker", vars)})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", vars, want, err)
+		}
+	}
+}
+
+func TestGenerateMachineConfigsWithPoolVars(t *testing.T) {
+	dir := t.TempDir()
+	for _, role := range []string{"master", "worker"} {
+		path := filepath.Join(dir, role, "00-"+role, platformBase, filesDir, "registry.yaml")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		template := "mode: 0644\npath: /etc/registry\ncontents:\n  inline: {{ index .PoolVars \"registry\" | default \"quay.io\" }}\n"
+		if err := ioutil.WriteFile(path, []byte(template), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := &mcfgv1.ControllerConfig{
+		Spec: mcfgv1.ControllerConfigSpec{
+			Infra: &configv1.Infrastructure{
+				Status: configv1.InfrastructureStatus{
+					PlatformStatus: &configv1.PlatformStatus{Type: configv1.AWSPlatformType},
+				},
+			},
+		},
+	}
+	renderConfig := &RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}
+
+	cfgs, err := generateTemplateMachineConfigs(context.TODO(), renderConfig, dir, map[string]poolTemplateConfig{"worker": {Vars: map[string]string{"registry": "mirror.example.com"}}})
+	if err != nil {
+		t.Fatalf("failed to generate machine configs: %v", err)
+	}
+	registries := map[string]string{}
+	for _, cfg := range cfgs {
+		ign, err := ctrlcommon.ParseAndConvertConfig(cfg.Spec.Config.Raw)
+		if err != nil {
+			t.Fatalf("failed to parse Ignition config: %v", err)
+		}
+		contents, err := ctrlcommon.GetIgnitionFileDataByPath(&ign, "/etc/registry")
+		if err != nil {
+			t.Fatalf("expected /etc/registry in %s: %v", cfg.Name, err)
+		}
+		registries[cfg.Name] = string(contents)
+	}
+	expected := map[string]string{"00-master": "quay.io", "00-worker": "mirror.example.com"}
+	if !reflect.DeepEqual(registries, expected) {
+		t.Fatalf("mismatch got: %v want: %v", registries, expected)
+	}
+	if renderConfig.PoolVars != nil {
+		t.Fatalf("expected the RenderConfig to be left unchanged, got %v", renderConfig.PoolVars)
+	}
+}
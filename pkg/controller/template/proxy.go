@@ -0,0 +1,110 @@
+package template
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// defaultNoProxy are the hosts the nodes always reach directly, whatever the
+// noProxy of the cluster proxy says.
+var defaultNoProxy = []string{"localhost", "127.0.0.1", "::1", ".svc", ".cluster.local"}
+
+// clusterHTTPProxy is a template function that returns the http proxy of the
+// cluster, or "" if there is none.
+func clusterHTTPProxy(cfg RenderConfig) string {
+	if cfg.Proxy == nil {
+		return ""
+	}
+	return cfg.Proxy.HTTPProxy
+}
+
+// clusterHTTPSProxy is a template function that returns the https proxy of
+// the cluster, or "" if there is none.
+func clusterHTTPSProxy(cfg RenderConfig) string {
+	if cfg.Proxy == nil {
+		return ""
+	}
+	return cfg.Proxy.HTTPSProxy
+}
+
+// clusterNoProxy is a template function that returns the comma separated
+// hosts which bypass the cluster proxy: its noProxy, followed by the
+// defaultNoProxy and the internal API server host it doesn't list already.
+// It returns "" if the cluster has no proxy.
+func clusterNoProxy(cfg RenderConfig) (string, error) {
+	if clusterHTTPProxy(cfg) == "" && clusterHTTPSProxy(cfg) == "" {
+		return "", nil
+	}
+	hosts := []string{}
+	seen := map[string]bool{}
+	add := func(host string) {
+		host = strings.TrimSpace(host)
+		if host != "" && !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	for _, host := range strings.Split(cfg.Proxy.NoProxy, ",") {
+		add(host)
+	}
+	for _, host := range defaultNoProxy {
+		add(host)
+	}
+	if cfg.Infra != nil && cfg.Infra.Status.APIServerInternalURL != "" {
+		u, err := url.Parse(cfg.Infra.Status.APIServerInternalURL)
+		if err != nil {
+			return "", fmt.Errorf("invalid internal API server url: %w", err)
+		}
+		add(u.Hostname())
+	}
+	return strings.Join(hosts, ","), nil
+}
+
+// envFileQuote double quotes a value of a systemd EnvironmentFile, escaping
+// the characters systemd unescapes in such values.  Unlike in unit files, %
+// isn't a specifier there and is left alone, so url encoded proxy passwords
+// are written as they are.
+func envFileQuote(s string) (string, error) {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '\n' || r == '\r' || r == 0:
+			return "", fmt.Errorf("environment values can't contain newlines or NUL bytes: %q", s)
+		case r == '"' || r == '\\' || r == '`' || r == '$':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String(), nil
+}
+
+// proxyEnvFile is a template function that returns the contents of a systemd
+// EnvironmentFile setting HTTP_PROXY, HTTPS_PROXY and NO_PROXY to the proxy
+// of the cluster, one variable per line, or "" if the cluster has no proxy.
+func proxyEnvFile(cfg RenderConfig) (string, error) {
+	noProxy, err := clusterNoProxy(cfg)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, v := range []struct{ name, value string }{
+		{"HTTP_PROXY", clusterHTTPProxy(cfg)},
+		{"HTTPS_PROXY", clusterHTTPSProxy(cfg)},
+		{"NO_PROXY", noProxy},
+	} {
+		if v.value == "" {
+			continue
+		}
+		quoted, err := envFileQuote(v.value)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", v.name, err)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", v.name, quoted)
+	}
+	return b.String(), nil
+}
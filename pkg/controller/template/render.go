@@ -2,17 +2,21 @@ package template
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
-	"text/template"
+	"sync"
+	"time"
 
-	"github.com/Masterminds/sprig"
+	"github.com/apparentlymart/go-cidr/cidr"
 	"github.com/golang/glog"
 	configv1 "github.com/openshift/api/config/v1"
 	"github.com/openshift/library-go/pkg/cloudprovider"
@@ -28,6 +32,20 @@ type RenderConfig struct {
 	*mcfgv1.ControllerConfigSpec
 	PullSecret  string
 	FeatureGate *configv1.FeatureGate
+	// SecretScanPolicy decides whether secrets in world-readable files fail rendering, defaults to Fail
+	SecretScanPolicy SecretScanPolicy
+	// ScriptLintSeverity is the lowest severity of the problems found in rendered shell scripts which fails rendering, defaults to Error
+	ScriptLintSeverity ScriptLintSeverity
+	// StrictTemplates fails rendering when a template references a missing map key, instead of rendering "<no value>"
+	StrictTemplates bool
+	// Overlays are the templates added by the cluster admin, rendered after the built-in ones
+	Overlays []TemplateOverlay
+	// ZoneVIPs are the VIPs of the availability zones whose nodes don't use the VIPs of the cluster
+	ZoneVIPs []ZoneVIPs
+	// PoolVars are the variables of the template-vars annotation of the pool the templates are rendered for
+	PoolVars map[string]string
+	// TimeSyncSource is what the nodes synchronize their clock with, defaults to NTP
+	TimeSyncSource TimeSyncSource
 
 	// no need to set this, will be automatically configured
 	Constants map[string]string
@@ -38,46 +56,87 @@ const (
 	unitsDir       = "units"
 	platformBase   = "_base"
 	platformOnPrem = "on-prem"
+	// topologySingleNode is the overlay directory applied after the platform ones
+	// on single node clusters, e.g. to drop units that only matter with several nodes.
+	topologySingleNode = "single-node"
+
+	// deleteMarkerSuffix marks an empty file removing the template of the same
+	// name (without the suffix) inherited from a more generic directory.
+	deleteMarkerSuffix = ".delete"
+	// keepMarkerSuffix marks an empty file that is intentionally empty and
+	// renders to nothing, e.g. to keep an otherwise empty platform directory.
+	keepMarkerSuffix = ".keep"
+
+	// infraRole is the role of the optional infra pool.  An infra pool selects
+	// the worker MachineConfigs too, so its templates only hold what differs
+	// and don't include the common ones again.  They are only rendered once
+	// the pool opts in with the infra-templates annotation.
+	infraRole = "infra"
+
+	// maxRenderWorkers is the number of MachineConfigs rendered at once.
+	maxRenderWorkers = 8
+
+	// externalPlatformType is the platform of clusters whose infrastructure is
+	// managed by an external cloud controller manager, which the vendored API
+	// doesn't define yet.
+	externalPlatformType configv1.PlatformType = "External"
 )
 
 // generateTemplateMachineConfigs returns MachineConfig objects from the templateDir and a config object
 // expected directory structure for correctly templating machine configs: <templatedir>/<role>/<name>/<platform>/<type>/<tmpl_file>
 //
 // All files from platform _base are always included, and may be overridden or
-// supplemented by platform-specific templates.
+// supplemented by platform-specific templates, and then by the single-node ones
+// on single node clusters. Each platform directory may hold an overlay for the
+// architecture of the nodes, e.g. <platform>/s390x/<type>/<tmpl_file>.
 //
 //  ex:
 //       templates/worker/00-worker/_base/units/kubelet.conf.tmpl
 //                                    /files/hostname.tmpl
+//                                    /tmpfiles/runtime-dirs.conf
 //                              /aws/units/kubelet-dropin.conf.tmpl
 //                       /01-worker-kubelet/_base/files/random.conf.tmpl
 //                /master/00-master/_base/units/kubelet.tmpl
 //                                    /files/hostname.tmpl
 //
-func generateTemplateMachineConfigs(config *RenderConfig, templateDir string) ([]*mcfgv1.MachineConfig, error) {
+// Rendering stops early once ctx is cancelled, returning an error.
+func generateTemplateMachineConfigs(ctx context.Context, config *RenderConfig, templateDir string, poolConfigs map[string]poolTemplateConfig) ([]*mcfgv1.MachineConfig, error) {
 	infos, err := ioutil.ReadDir(templateDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read dir %q: %v", templateDir, err)
 	}
 
-	cfgs := []*mcfgv1.MachineConfig{}
-
+	dirs := []machineConfigDir{}
 	for _, info := range infos {
 		if !info.IsDir() {
 			glog.Infof("ignoring non-directory path %q", info.Name())
 			continue
 		}
 		role := info.Name()
-		if role == "common" {
+		if role == "common" || role == partialsDir {
+			continue
+		}
+		// The infra templates change kernel settings, rolling them out to
+		// the existing infra pools on upgrade would reboot their nodes.
+		if role == infraRole && !poolConfigs[role].InfraTemplates {
 			continue
 		}
 
-		roleConfigs, err := GenerateMachineConfigsForRole(config, role, templateDir)
+		roleConfig := poolConfigs[role].apply(config)
+		roleDirs, err := machineConfigDirsForRole(roleConfig, role, filepath.Join(templateDir, role), role == infraRole)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create MachineConfig for role %s: %v", role, err)
 		}
-		cfgs = append(cfgs, roleConfigs...)
+		dirs = append(dirs, roleDirs...)
+	}
+
+	cfgs, errs, elapsed := renderTemplateDirs(ctx, templateDir, dirs)
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to create MachineConfig for role %s: %v", dirs[i].role, err)
+		}
 	}
+	observeRender(dirs, elapsed)
 
 	// tag all machineconfigs with the controller version
 	for _, cfg := range cfgs {
@@ -90,42 +149,142 @@ func generateTemplateMachineConfigs(config *RenderConfig, templateDir string) ([
 	return cfgs, nil
 }
 
+// TemplateRole returns the role whose templates the default configs of the
+// pool are rendered from.  Custom pools reuse the worker templates, unless
+// their role-base label is master, e.g. for control plane like pools such as
+// an arbiter.
+func TemplateRole(pool *mcfgv1.MachineConfigPool) string {
+	//nolint:goconst
+	if pool.Labels[ctrlcommon.RoleBaseLabelKey] == "master" {
+		return "master"
+	}
+	return pool.Name
+}
+
 // GenerateMachineConfigsForRole creates MachineConfigs for the role provided
 func GenerateMachineConfigsForRole(config *RenderConfig, role, templateDir string) ([]*mcfgv1.MachineConfig, error) {
 	rolePath := role
 	//nolint:goconst
 	if role != "worker" && role != "master" {
-		// custom pools are only allowed to be worker's children
-		// and can reuse the worker templates
+		// custom pools are worker's children unless TemplateRole
+		// made them master's, and reuse the worker templates, infra
+		// included as its own templates only hold additions
 		rolePath = "worker"
 	}
 
-	path := filepath.Join(templateDir, rolePath)
+	return generateMachineConfigsForRoleDir(config, role, templateDir, filepath.Join(templateDir, rolePath), false)
+}
+
+// generateMachineConfigsForRoleDir creates MachineConfigs for the role from the
+// templates in path, adding the common ones unless skipCommon is set.
+func generateMachineConfigsForRoleDir(config *RenderConfig, role, templateDir, path string, skipCommon bool) ([]*mcfgv1.MachineConfig, error) {
+	dirs, err := machineConfigDirsForRole(config, role, path, skipCommon)
+	if err != nil {
+		return nil, err
+	}
+	cfgs, errs, _ := renderTemplateDirs(context.TODO(), templateDir, dirs)
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cfgs, nil
+}
+
+// machineConfigDir is a <templatedir>/<role>/<name> directory rendered into the
+// MachineConfig name of role with config.
+type machineConfigDir struct {
+	config *RenderConfig
+	role   string
+	name   string
+	path   string
+	// commonAdded is false for the directory the common templates are added to
+	commonAdded bool
+}
+
+// machineConfigDirsForRole returns the directories of the MachineConfigs of the
+// role in path, rendered with config, adding the common templates to the
+// first one unless skipCommon is set.
+func machineConfigDirsForRole(config *RenderConfig, role, path string, skipCommon bool) ([]machineConfigDir, error) {
 	infos, err := ioutil.ReadDir(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read dir %q: %v", path, err)
 	}
 
-	cfgs := []*mcfgv1.MachineConfig{}
+	dirs := []machineConfigDir{}
 	// This func doesn't process "common"
 	// common templates are only added to 00-<role>
 	// templates/<role>/{00-<role>,01-<role>-container-runtime,01-<role>-kubelet}
-	var commonAdded bool
+	commonAdded := skipCommon
 	for _, info := range infos {
 		if !info.IsDir() {
 			glog.Infof("ignoring non-directory path %q", info.Name())
 			continue
 		}
-		name := info.Name()
-		namePath := filepath.Join(path, name)
-		nameConfig, err := generateMachineConfigForName(config, role, name, templateDir, namePath, &commonAdded)
-		if err != nil {
-			return nil, err
-		}
-		cfgs = append(cfgs, nameConfig)
+		dirs = append(dirs, machineConfigDir{config: config, role: role, name: info.Name(), path: filepath.Join(path, info.Name()), commonAdded: commonAdded})
+		commonAdded = true
 	}
+	return dirs, nil
+}
 
-	return cfgs, nil
+// renderTemplateDirs renders the MachineConfigs of dirs concurrently, with at
+// most maxRenderWorkers at once.  The MachineConfigs and errors are returned
+// in the order of dirs, so that the output doesn't depend on which directory
+// was rendered first, with the time from the start of the render until each
+// one was done.  Once ctx is cancelled, the directories not rendered yet fail
+// with its error.
+func renderTemplateDirs(ctx context.Context, templatesDir string, dirs []machineConfigDir) ([]*mcfgv1.MachineConfig, []error, []time.Duration) {
+	cfgs := make([]*mcfgv1.MachineConfig, len(dirs))
+	errs := make([]error, len(dirs))
+	elapsed := make([]time.Duration, len(dirs))
+	start := time.Now()
+
+	workers := maxRenderWorkers
+	if len(dirs) < workers {
+		workers = len(dirs)
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				dir := dirs[i]
+				cfgs[i], errs[i] = generateMachineConfigForName(dir.config, dir.role, dir.name, templatesDir, dir.path, &dir.commonAdded)
+				elapsed[i] = time.Since(start)
+			}
+		}()
+	}
+	for i := range dirs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return cfgs, errs, elapsed
+}
+
+// observeRender records the metrics of a successful render of dirs, which
+// took elapsed from the start of the render.  The duration of a role is the
+// time its last MachineConfig took, as the roles are rendered together.
+func observeRender(dirs []machineConfigDir, elapsed []time.Duration) {
+	durations := map[string]time.Duration{}
+	counts := map[string]int{}
+	for i, dir := range dirs {
+		if elapsed[i] > durations[dir.role] {
+			durations[dir.role] = elapsed[i]
+		}
+		counts[dir.role]++
+	}
+	for role, d := range durations {
+		ctrlcommon.MachineConfigControllerTemplateRenderDuration.WithLabelValues(role).Observe(d.Seconds())
+		ctrlcommon.MachineConfigControllerTemplateMachineConfigs.WithLabelValues(role).Set(float64(counts[role]))
+	}
 }
 
 func platformStringFromControllerConfigSpec(ic *mcfgv1.ControllerConfigSpec) (string, error) {
@@ -147,7 +306,7 @@ func platformStringFromControllerConfigSpec(ic *mcfgv1.ControllerConfigSpec) (st
 		return "", fmt.Errorf("cannot generate MachineConfigs when no platformStatus.type is set")
 	case platformBase:
 		return "", fmt.Errorf("platform _base unsupported")
-	case configv1.AWSPlatformType, configv1.AlibabaCloudPlatformType, configv1.AzurePlatformType, configv1.BareMetalPlatformType, configv1.GCPPlatformType, configv1.OpenStackPlatformType, configv1.LibvirtPlatformType, configv1.OvirtPlatformType, configv1.VSpherePlatformType, configv1.KubevirtPlatformType, configv1.PowerVSPlatformType, configv1.NonePlatformType, configv1.NutanixPlatformType:
+	case configv1.AWSPlatformType, configv1.AlibabaCloudPlatformType, configv1.AzurePlatformType, configv1.BareMetalPlatformType, configv1.GCPPlatformType, configv1.OpenStackPlatformType, configv1.LibvirtPlatformType, configv1.OvirtPlatformType, configv1.VSpherePlatformType, configv1.KubevirtPlatformType, configv1.PowerVSPlatformType, configv1.NonePlatformType, configv1.NutanixPlatformType, externalPlatformType:
 		return strings.ToLower(string(ic.Infra.Status.PlatformStatus.Type)), nil
 	default:
 		// platformNone is used for a non-empty, but currently unsupported platform.
@@ -159,7 +318,7 @@ func platformStringFromControllerConfigSpec(ic *mcfgv1.ControllerConfigSpec) (st
 	}
 }
 
-func filterTemplates(toFilter map[string]string, path string, config *RenderConfig) error {
+func filterTemplates(toFilter map[string]string, path string, config *RenderConfig, partials map[string]string) error {
 	walkFn := func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -168,8 +327,20 @@ func filterTemplates(toFilter map[string]string, path string, config *RenderConf
 			return nil
 		}
 
-		// empty templates signify don't create
-		if info.Size() == 0 {
+		switch {
+		case strings.HasSuffix(info.Name(), deleteMarkerSuffix):
+			if info.Size() != 0 {
+				return fmt.Errorf("%s marker %q must be empty", deleteMarkerSuffix, path)
+			}
+			delete(toFilter, strings.TrimSuffix(info.Name(), deleteMarkerSuffix))
+			return nil
+		case strings.HasSuffix(info.Name(), keepMarkerSuffix):
+			if info.Size() != 0 {
+				return fmt.Errorf("%s marker %q must be empty", keepMarkerSuffix, path)
+			}
+			return nil
+		case info.Size() == 0:
+			// empty templates signify don't create, prefer an explicit delete marker
 			delete(toFilter, info.Name())
 			return nil
 		}
@@ -180,7 +351,7 @@ func filterTemplates(toFilter map[string]string, path string, config *RenderConf
 		}
 
 		// Render the template file
-		renderedData, err := renderTemplate(*config, path, filedata)
+		renderedData, err := renderTemplateWithPartials(*config, path, filedata, partials)
 		if err != nil {
 			return err
 		}
@@ -199,6 +370,29 @@ func filterTemplates(toFilter map[string]string, path string, config *RenderConf
 	return filepath.Walk(path, walkFn)
 }
 
+// overlayDirs returns the directories of a template applying to the config, with later ones taking precedence.
+// Each platform directory is followed by its subdirectory for the architecture of the nodes, if known,
+// e.g. _base, _base/s390x, aws, aws/s390x.
+func overlayDirs(config *RenderConfig, platformString string) []string {
+	platformDirs := []string{platformBase}
+	if onPremPlatform(config.Infra.Status.PlatformStatus.Type) {
+		platformDirs = append(platformDirs, platformOnPrem)
+	}
+	platformDirs = append(platformDirs, platformString)
+
+	var dirs []string
+	for _, dir := range platformDirs {
+		dirs = append(dirs, dir)
+		if arch := config.Architecture; arch != "" {
+			dirs = append(dirs, filepath.Join(dir, arch))
+		}
+	}
+	if singleNodeTopology(config.ControllerConfigSpec) {
+		dirs = append(dirs, topologySingleNode)
+	}
+	return dirs
+}
+
 func generateMachineConfigForName(config *RenderConfig, role, name, templateDir, path string, commonAdded *bool) (*mcfgv1.MachineConfig, error) {
 	platformString, err := platformStringFromControllerConfigSpec(config.ControllerConfigSpec)
 	if err != nil {
@@ -208,10 +402,7 @@ func generateMachineConfigForName(config *RenderConfig, role, name, templateDir,
 	platformDirs := []string{}
 	if !*commonAdded {
 		// Loop over templates/common which applies everywhere
-		for _, dir := range []string{platformBase, platformOnPrem, platformString} {
-			if dir == platformOnPrem && !onPremPlatform(config.Infra.Status.PlatformStatus.Type) {
-				continue
-			}
+		for _, dir := range overlayDirs(config, platformString) {
 			basePath := filepath.Join(templateDir, "common", dir)
 			exists, err := existsDir(basePath)
 			if err != nil {
@@ -226,10 +417,7 @@ func generateMachineConfigForName(config *RenderConfig, role, name, templateDir,
 	}
 
 	// And now over the target e.g. templates/master/00-master,01-master-container-runtime,01-master-kubelet
-	for _, dir := range []string{platformBase, platformOnPrem, platformString} {
-		if dir == platformOnPrem && !onPremPlatform(config.Infra.Status.PlatformStatus.Type) {
-			continue
-		}
+	for _, dir := range overlayDirs(config, platformString) {
 		platformPath := filepath.Join(path, dir)
 		exists, err := existsDir(platformPath)
 		if err != nil {
@@ -241,8 +429,14 @@ func generateMachineConfigForName(config *RenderConfig, role, name, templateDir,
 		platformDirs = append(platformDirs, platformPath)
 	}
 
+	partials, err := loadPartials(templateDir)
+	if err != nil {
+		return nil, err
+	}
+
 	files := map[string]string{}
 	units := map[string]string{}
+	tmpfiles := map[string]string{}
 	// walk all role dirs, with later ones taking precedence
 	for _, platformDir := range platformDirs {
 		p := filepath.Join(platformDir, filesDir)
@@ -251,7 +445,7 @@ func generateMachineConfigForName(config *RenderConfig, role, name, templateDir,
 			return nil, err
 		}
 		if exists {
-			if err := filterTemplates(files, p, config); err != nil {
+			if err := filterTemplates(files, p, config, partials); err != nil {
 				return nil, err
 			}
 		}
@@ -262,10 +456,35 @@ func generateMachineConfigForName(config *RenderConfig, role, name, templateDir,
 			return nil, err
 		}
 		if exists {
-			if err := filterTemplates(units, p, config); err != nil {
+			if err := filterTemplates(units, p, config, partials); err != nil {
 				return nil, err
 			}
 		}
+
+		p = filepath.Join(platformDir, tmpfilesDir)
+		exists, err = existsDir(p)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			if err := filterTemplates(tmpfiles, p, config, partials); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// The overlays of the cluster admin come last, and may only add
+	// templates to the built-in ones.
+	overlays, err := renderOverlays(config, filepath.Base(filepath.Dir(path)), name, map[string]map[string]string{
+		filesDir:    files,
+		unitsDir:    units,
+		tmpfilesDir: tmpfiles,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for file, data := range overlays[tmpfilesDir] {
+		tmpfiles[file] = data
 	}
 
 	// keySortVals returns a list of values, sorted by key
@@ -285,10 +504,37 @@ func generateMachineConfigForName(config *RenderConfig, role, name, templateDir,
 		return vs
 	}
 
+	// Butane templates are translated on their own and merged after the
+	// CoreOS config fragments, the built-in ones before the overlays.
+	butaneTemplates := map[string]string{}
+	moveButaneTemplates(butaneTemplates, files, filesDir)
+	moveButaneTemplates(butaneTemplates, units, unitsDir)
+	butaneOverlays := map[string]string{}
+	moveButaneTemplates(butaneOverlays, overlays[filesDir], filesDir)
+	moveButaneTemplates(butaneOverlays, overlays[unitsDir], unitsDir)
+
 	ignCfg, err := ctrlcommon.TranspileCoreOSConfigToIgn(keySortVals(files), keySortVals(units))
 	if err != nil {
 		return nil, fmt.Errorf("error transpiling CoreOS config to Ignition config: %v", err)
 	}
+	if err := mergeButaneTemplates(ignCfg, butaneTemplates); err != nil {
+		return nil, err
+	}
+	if err := mergeOverlays(ignCfg, keySortVals(overlays[filesDir]), keySortVals(overlays[unitsDir])); err != nil {
+		return nil, err
+	}
+	if err := mergeButaneTemplates(ignCfg, butaneOverlays); err != nil {
+		return nil, err
+	}
+	if err := appendTmpfiles(ignCfg, tmpfiles); err != nil {
+		return nil, err
+	}
+	if err := lintScripts(config, name, ignCfg); err != nil {
+		return nil, err
+	}
+	if err := scanForSecrets(config, name, ignCfg); err != nil {
+		return nil, err
+	}
 	mcfg, err := ctrlcommon.MachineConfigFromIgnConfig(role, name, ignCfg)
 	if err != nil {
 		return nil, fmt.Errorf("error creating MachineConfig from Ignition config: %v", err)
@@ -302,33 +548,45 @@ func generateMachineConfigForName(config *RenderConfig, role, name, templateDir,
 // renderTemplate renders a template file with values from a RenderConfig
 // returns the rendered file data
 func renderTemplate(config RenderConfig, path string, b []byte) ([]byte, error) {
-	funcs := sprig.TxtFuncMap()
-	funcs["skip"] = skipMissing
-	funcs["cloudProvider"] = cloudProvider
-	funcs["cloudConfigFlag"] = cloudConfigFlag
-	funcs["onPremPlatformAPIServerInternalIP"] = onPremPlatformAPIServerInternalIP
-	funcs["onPremPlatformIngressIP"] = onPremPlatformIngressIP
-	funcs["onPremPlatformShortName"] = onPremPlatformShortName
-	funcs["onPremPlatformKeepalivedEnableUnicast"] = onPremPlatformKeepalivedEnableUnicast
-	funcs["urlHost"] = urlHost
-	funcs["urlPort"] = urlPort
-	tmpl, err := template.New(path).Funcs(funcs).Parse(string(b))
+	return renderTemplateWithPartials(config, path, b, nil)
+}
+
+// renderTemplateWithPartials is renderTemplate for a template which may
+// include partials.
+func renderTemplateWithPartials(config RenderConfig, path string, b []byte, partials map[string]string) ([]byte, error) {
+	tmpl, err := parseTemplate(path, string(b), config.StrictTemplates, partials)
 	if err != nil {
+		ctrlcommon.MachineConfigControllerTemplateFailures.WithLabelValues(path, "parse").Inc()
 		return nil, fmt.Errorf("failed to parse template %s: %v", path, err)
 	}
 
 	if config.Constants == nil {
-		config.Constants = constants.ConstantsByName
+		config.Constants = constants.TemplateConstants()
 	}
 
 	buf := new(bytes.Buffer)
 	if err := tmpl.Execute(buf, config); err != nil {
+		ctrlcommon.MachineConfigControllerTemplateFailures.WithLabelValues(path, "execute").Inc()
 		return nil, fmt.Errorf("failed to execute template: %v", err)
 	}
 
 	return buf.Bytes(), nil
 }
 
+// strictTemplatesFromAnnotations returns whether the ControllerConfig asks
+// for strict template rendering, defaulting to def.
+func strictTemplatesFromAnnotations(annotations map[string]string, def bool) (bool, error) {
+	value, ok := annotations[ctrlcommon.StrictTemplatesAnnotationKey]
+	if !ok {
+		return def, nil
+	}
+	strict, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s annotation %q, must be \"true\" or \"false\"", ctrlcommon.StrictTemplatesAnnotationKey, value)
+	}
+	return strict, nil
+}
+
 var skipKeyValidate = regexp.MustCompile(`^[_a-z]\w*$`)
 
 // Keys labelled with skip ie. {{skip "key"}}, don't need to be templated in now because at Ignition request they will be templated in with query params
@@ -354,6 +612,9 @@ func cloudProvider(cfg RenderConfig) (interface{}, error) {
 			return strings.ToLower(string(cfg.Infra.Status.PlatformStatus.Type)), nil
 		case configv1.GCPPlatformType:
 			return "gce", nil
+		case externalPlatformType:
+			// the cloud controller manager of the platform, if any, initializes the nodes
+			return "external", nil
 		default:
 			return "", nil
 		}
@@ -363,7 +624,8 @@ func cloudProvider(cfg RenderConfig) (interface{}, error) {
 }
 
 // Process the {{cloudConfigFlag .}}
-// If the CloudProviderConfig field is set and not empty, this
+// If the CloudProviderConfig field is set and not empty, or service endpoint
+// overrides are rendered in its place (see cloudProviderConfig), this
 // returns the cloud conf flag for kubelet [1] pointing the kubelet to use
 // /etc/kubernetes/cloud.conf for configuring the cloud provider for select platforms.
 // By default, even if CloudProviderConfig fields is set, the kubelet will be configured to be
@@ -371,7 +633,10 @@ func cloudProvider(cfg RenderConfig) (interface{}, error) {
 //
 // [1]: https://kubernetes.io/docs/reference/command-line-tools-reference/kubelet/#options
 func cloudConfigFlag(cfg RenderConfig) interface{} {
-	if cfg.CloudProviderConfig == "" {
+	if conf, err := cloudProviderConfig(cfg); err != nil {
+		glog.Error(err)
+		return ""
+	} else if conf == "" {
 		return ""
 	}
 
@@ -497,6 +762,245 @@ func onPremPlatformAPIServerInternalIP(cfg RenderConfig) (interface{}, error) {
 	}
 }
 
+// onPremPlatformAPIServerInternalIPs is a template function that returns the
+// API server internal VIPs of on-prem platforms as a list, primary IP family
+// first, so templates can range over them and configure both families of
+// dual-stack clusters.  The list is empty if the platform doesn't have any,
+// e.g. on VSphere UPI.
+func onPremPlatformAPIServerInternalIPs(cfg RenderConfig) (interface{}, error) {
+	ip, err := onPremPlatformAPIServerInternalIP(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ipList(ip), nil
+}
+
+// onPremPlatformIngressIPs is a template function that returns the Ingress
+// VIPs of on-prem platforms as a list, like onPremPlatformAPIServerInternalIPs.
+func onPremPlatformIngressIPs(cfg RenderConfig) (interface{}, error) {
+	ip, err := onPremPlatformIngressIP(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ipList(ip), nil
+}
+
+// ipList returns the VIP of the platform status as the ordered list of VIPs.
+// The infrastructure API vendored here reports a single VIP per platform, the
+// one of the primary IP family, so the list has at most one entry until it
+// reports the apiServerInternalIPs and ingressIPs lists.
+func ipList(ip interface{}) []string {
+	if s, ok := ip.(string); ok && s != "" {
+		return []string{s}
+	}
+	return []string{}
+}
+
+// apiServerInternalEndpoints is a template function that returns the
+// host:port endpoints of the internal API server from the infrastructure
+// status: one per API server internal VIP on on-prem platforms, or else the
+// host of the internal API server URL.
+func apiServerInternalEndpoints(cfg RenderConfig) (interface{}, error) {
+	if cfg.Infra == nil {
+		return nil, fmt.Errorf("infrastructure is not set")
+	}
+	var host string
+	port := "6443"
+	if internalURL := cfg.Infra.Status.APIServerInternalURL; internalURL != "" {
+		parsed, err := url.Parse(internalURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API server internal URL: %w", err)
+		}
+		host = parsed.Hostname()
+		if p := parsed.Port(); p != "" {
+			port = p
+		}
+	}
+
+	var hosts []string
+	if cfg.Infra.Status.PlatformStatus != nil && onPremPlatform(cfg.Infra.Status.PlatformStatus.Type) {
+		ips, err := onPremPlatformAPIServerInternalIPs(cfg)
+		if err != nil {
+			return nil, err
+		}
+		hosts = ips.([]string)
+	}
+	if len(hosts) == 0 && host != "" {
+		hosts = []string{host}
+	}
+
+	endpoints := []string{}
+	for _, h := range hosts {
+		endpoints = append(endpoints, net.JoinHostPort(h, port))
+	}
+	return endpoints, nil
+}
+
+// internalRegistryHostname is a template function that returns the host[:port]
+// of the integrated image registry from the status of the cluster image config,
+// or an empty string if the registry isn't installed.
+func internalRegistryHostname(cfg RenderConfig) interface{} {
+	if cfg.Image == nil {
+		return ""
+	}
+	return cfg.Image.InternalRegistryHostname
+}
+
+// imageRegistryHostnames is a template function that returns the host[:port]
+// names the integrated image registry is reachable at: the internal hostname
+// first, followed by the external ones if the registry is exposed.
+func imageRegistryHostnames(cfg RenderConfig) interface{} {
+	hostnames := []string{}
+	if cfg.Image == nil {
+		return hostnames
+	}
+	seen := map[string]bool{"": true}
+	for _, h := range append([]string{cfg.Image.InternalRegistryHostname}, cfg.Image.ExternalRegistryHostnames...) {
+		if !seen[h] {
+			seen[h] = true
+			hostnames = append(hostnames, h)
+		}
+	}
+	return hostnames
+}
+
+// singleNodeTopology returns true if the control plane runs on a single node
+func singleNodeTopology(ic *mcfgv1.ControllerConfigSpec) bool {
+	return ic.Infra != nil && ic.Infra.Status.ControlPlaneTopology == configv1.SingleReplicaTopologyMode
+}
+
+// isSNO is a template function that returns true on single node clusters
+func isSNO(cfg RenderConfig) interface{} {
+	return singleNodeTopology(cfg.ControllerConfigSpec)
+}
+
+// controlPlaneReplicas is a template function that returns the number of control plane
+// nodes of the control plane topology: 1 for SingleReplica, 0 for External and 3 otherwise,
+// including clusters that predate the topology.
+func controlPlaneReplicas(cfg RenderConfig) interface{} {
+	if cfg.Infra == nil {
+		return 3
+	}
+	switch cfg.Infra.Status.ControlPlaneTopology {
+	case configv1.SingleReplicaTopologyMode:
+		return 1
+	case configv1.ExternalTopologyMode:
+		return 0
+	default:
+		return 3
+	}
+}
+
+// cgroupMode is a template function that returns the cgroup mode of the
+// cluster node config, v1 or v2.  It returns the default mode, v1, if the node
+// config doesn't set one.
+func cgroupMode(cfg RenderConfig) (string, error) {
+	switch cfg.CgroupMode {
+	case configv1.CgroupModeEmpty:
+		return string(configv1.CgroupModeDefault), nil
+	case configv1.CgroupModeV1, configv1.CgroupModeV2:
+		return string(cfg.CgroupMode), nil
+	default:
+		return "", fmt.Errorf("invalid cgroupMode %q, must be %s or %s", cfg.CgroupMode, configv1.CgroupModeV1, configv1.CgroupModeV2)
+	}
+}
+
+// clusterDNSIP is a template function that returns the IP of the cluster DNS
+// service, the 10th address of the service network of the primary IP family,
+// e.g. 172.30.0.10 or fd02::a.  It falls back to .ClusterDNSIP for the
+// ControllerConfigs predating .ServiceNetwork.
+func clusterDNSIP(cfg RenderConfig) (string, error) {
+	if len(cfg.ServiceNetwork) == 0 {
+		return cfg.ClusterDNSIP, nil
+	}
+	_, network, err := net.ParseCIDR(cfg.ServiceNetwork[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid service network: %w", err)
+	}
+	ip, err := cidr.Host(network, 10)
+	if err != nil {
+		return "", fmt.Errorf("service network %s: %w", network, err)
+	}
+	return ip.String(), nil
+}
+
+// isFIPS is a template function that returns true if the cluster was
+// installed with FIPS mode enabled.
+func isFIPS(cfg RenderConfig) bool {
+	return cfg.FIPS
+}
+
+// ipFamiliesOf returns the IP families of the service network of the
+// cluster, the first one being the primary.  ControllerConfigs predating
+// serviceNetwork fall back to their ipFamilies, with IPv4 primary in
+// dual-stack clusters.
+func ipFamiliesOf(cfg RenderConfig) ([]mcfgv1.IPFamiliesType, error) {
+	if len(cfg.ServiceNetwork) == 0 {
+		switch cfg.IPFamilies {
+		case mcfgv1.IPFamiliesIPv6:
+			return []mcfgv1.IPFamiliesType{mcfgv1.IPFamiliesIPv6}, nil
+		case mcfgv1.IPFamiliesDualStack:
+			return []mcfgv1.IPFamiliesType{mcfgv1.IPFamiliesIPv4, mcfgv1.IPFamiliesIPv6}, nil
+		default:
+			return []mcfgv1.IPFamiliesType{mcfgv1.IPFamiliesIPv4}, nil
+		}
+	}
+	var families []mcfgv1.IPFamiliesType
+	for _, cidr := range cfg.ServiceNetwork {
+		ip, _, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid service network: %w", err)
+		}
+		family := mcfgv1.IPFamiliesIPv4
+		if ip.To4() == nil {
+			family = mcfgv1.IPFamiliesIPv6
+		}
+		if !ipFamiliesContain(families, family) {
+			families = append(families, family)
+		}
+	}
+	return families, nil
+}
+
+func ipFamiliesContain(families []mcfgv1.IPFamiliesType, family mcfgv1.IPFamiliesType) bool {
+	for _, f := range families {
+		if f == family {
+			return true
+		}
+	}
+	return false
+}
+
+// isSingleStackIPv6 is a template function that returns true if the cluster
+// network is IPv6 only.
+func isSingleStackIPv6(cfg RenderConfig) (bool, error) {
+	families, err := ipFamiliesOf(cfg)
+	if err != nil {
+		return false, err
+	}
+	return len(families) == 1 && families[0] == mcfgv1.IPFamiliesIPv6, nil
+}
+
+// isDualStack is a template function that returns true if the cluster network
+// is both IPv4 and IPv6.
+func isDualStack(cfg RenderConfig) (bool, error) {
+	families, err := ipFamiliesOf(cfg)
+	if err != nil {
+		return false, err
+	}
+	return len(families) > 1, nil
+}
+
+// primaryIPFamily is a template function that returns the primary IP family
+// of the cluster network, IPv4 or IPv6: the one of its first service network.
+func primaryIPFamily(cfg RenderConfig) (string, error) {
+	families, err := ipFamiliesOf(cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(families[0]), nil
+}
+
 // existsDir returns true if path exists and is a directory, false if the path
 // does not exist, and error if there is a runtime error or the path is not a directory
 func existsDir(path string) (bool, error) {
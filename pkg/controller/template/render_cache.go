@@ -0,0 +1,110 @@
+package template
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/golang/glog"
+	configv1 "github.com/openshift/api/config/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/constants"
+)
+
+// renderCache holds the MachineConfigs last rendered from the templates, keyed
+// by the hash of the templates tree and of the RenderConfig.  Every sync of
+// the ControllerConfig renders them otherwise, even when nothing they depend
+// on changed.
+type renderCache struct {
+	lock sync.Mutex
+	key  string
+	mcs  []*mcfgv1.MachineConfig
+}
+
+// getMachineConfigs returns the template MachineConfigs of config, like
+// getMachineConfigsForControllerConfig, rendering them only if the templates
+// or the RenderConfig changed since the last call.  A render cancelled through
+// ctx isn't cached.
+func (c *renderCache) getMachineConfigs(ctx context.Context, templatesDir string, config *mcfgv1.ControllerConfig, pullSecretRaw []byte, featureGate *configv1.FeatureGate, overlays []TemplateOverlay, zoneVIPs []ZoneVIPs, poolConfigs map[string]poolTemplateConfig) ([]*mcfgv1.MachineConfig, error) {
+	rc, err := newRenderConfig(config, pullSecretRaw, featureGate, overlays, zoneVIPs)
+	if err != nil {
+		return nil, err
+	}
+	key, err := renderCacheKey(templatesDir, config, rc, poolConfigs)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.mcs != nil && c.key == key {
+		glog.V(4).Infof("Templates and render config unchanged, reusing the rendered MachineConfigs")
+		return copyMachineConfigs(c.mcs), nil
+	}
+	mcs, err := renderMachineConfigs(ctx, templatesDir, config, rc, poolConfigs)
+	if err != nil {
+		return nil, err
+	}
+	c.key = key
+	c.mcs = copyMachineConfigs(mcs)
+	return mcs, nil
+}
+
+// renderCacheKey hashes everything the rendered MachineConfigs of config
+// depend on: the templates tree, the RenderConfig with the template constants
+// it's rendered with, the template variables of the pools, and the
+// ControllerConfig owning them.
+func renderCacheKey(templatesDir string, config *mcfgv1.ControllerConfig, rc *RenderConfig, poolConfigs map[string]poolTemplateConfig) (string, error) {
+	h := sha256.New()
+	err := filepath.Walk(templatesDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(templatesDir, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", rel, len(data))
+		h.Write(data)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash templates %q: %w", templatesDir, err)
+	}
+
+	data, err := json.Marshal(struct {
+		Owner     types.UID
+		Name      string
+		Render    *RenderConfig
+		Constants map[string]string
+		Pools     map[string]poolTemplateConfig
+	}{config.UID, config.Name, rc, constants.TemplateConstants(), poolConfigs})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash render config: %w", err)
+	}
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func copyMachineConfigs(mcs []*mcfgv1.MachineConfig) []*mcfgv1.MachineConfig {
+	copies := make([]*mcfgv1.MachineConfig, 0, len(mcs))
+	for _, mc := range mcs {
+		copies = append(copies, mc.DeepCopy())
+	}
+	return copies
+}
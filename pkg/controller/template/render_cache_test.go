@@ -0,0 +1,75 @@
+package template
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+func TestRenderCache(t *testing.T) {
+	dir := t.TempDir()
+	filesPath := filepath.Join(dir, "worker", "00-worker", platformBase, filesDir)
+	if err := os.MkdirAll(filesPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTemplate := func(contents string) {
+		data := "mode: 0644\npath: /etc/my-agent.conf\ncontents:\n  inline: " + contents + "\n"
+		if err := ioutil.WriteFile(filepath.Join(filesPath, "my-agent.yaml"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTemplate("hello")
+	cc := newControllerConfig("test-cluster")
+	cache := &renderCache{}
+
+	mcs, err := cache.getMachineConfigs(context.TODO(), dir, cc, []byte(`{"dummy": "dummy"}`), nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	if len(mcs) != 1 || mcs[0].Name != "00-worker" {
+		t.Fatalf("expected the 00-worker MachineConfig, got %v", mcs)
+	}
+	cached := cache.mcs[0]
+	// the returned MachineConfigs are copies, which the caller may modify
+	mcs[0].Annotations["modified"] = "true"
+
+	mcs, err = cache.getMachineConfigs(context.TODO(), dir, cc, []byte(`{"dummy": "dummy"}`), nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	if cache.mcs[0] != cached {
+		t.Fatal("expected the MachineConfigs to be reused")
+	}
+	if _, ok := mcs[0].Annotations["modified"]; ok {
+		t.Fatal("expected the cached MachineConfigs to be unmodified")
+	}
+
+	_, err = cache.getMachineConfigs(context.TODO(), dir, cc, []byte(`{"other": "dummy"}`), nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	if cache.mcs[0] == cached {
+		t.Fatal("expected the MachineConfigs to be rendered again for another pull secret")
+	}
+
+	writeTemplate("world")
+	mcs, err = cache.getMachineConfigs(context.TODO(), dir, cc, []byte(`{"other": "dummy"}`), nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	ign, err := ctrlcommon.ParseAndConvertConfig(mcs[0].Spec.Config.Raw)
+	if err != nil {
+		t.Fatalf("failed to parse Ignition config: %v", err)
+	}
+	contents, err := ctrlcommon.GetIgnitionFileDataByPath(&ign, "/etc/my-agent.conf")
+	if err != nil {
+		t.Fatalf("expected the file of the template: %v", err)
+	}
+	if string(contents) != "world" {
+		t.Fatalf("mismatch got: %q want: %q", contents, "world")
+	}
+}
@@ -2,16 +2,21 @@ package template
 
 import (
 	"bytes"
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
 	configv1 "github.com/openshift/api/config/v1"
 	"github.com/openshift/library-go/pkg/cloudprovider"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
@@ -96,6 +101,9 @@ func TestCloudProvider(t *testing.T) {
 	}, {
 		platform: configv1.NutanixPlatformType,
 		res:      "",
+	}, {
+		platform: externalPlatformType,
+		res:      "external",
 	}}
 	for idx, c := range cases {
 		name := fmt.Sprintf("case #%d", idx)
@@ -112,7 +120,165 @@ func TestCloudProvider(t *testing.T) {
 					},
 				},
 			}
-			got, err := renderTemplate(RenderConfig{&config.Spec, `{"dummy":"dummy"}`, c.featureGate, nil}, name, dummyTemplate)
+			got, err := renderTemplate(RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, FeatureGate: c.featureGate, StrictTemplates: true}, name, dummyTemplate)
+			if err != nil {
+				t.Fatalf("expected nil error %v", err)
+			}
+
+			if string(got) != c.res {
+				t.Fatalf("mismatch got: %s want: %s", got, c.res)
+			}
+		})
+	}
+}
+
+func TestPlatformStringFromControllerConfigSpec(t *testing.T) {
+	cases := []struct {
+		platform configv1.PlatformType
+		res      string
+	}{{
+		platform: configv1.AWSPlatformType,
+		res:      "aws",
+	}, {
+		platform: externalPlatformType,
+		res:      "external",
+	}, {
+		// unsupported platforms are rendered as none
+		platform: "Unknown",
+		res:      "none",
+	}}
+	for _, c := range cases {
+		t.Run(string(c.platform), func(t *testing.T) {
+			res, err := platformStringFromControllerConfigSpec(&mcfgv1.ControllerConfigSpec{
+				Infra: &configv1.Infrastructure{
+					Status: configv1.InfrastructureStatus{
+						PlatformStatus: &configv1.PlatformStatus{Type: c.platform},
+					},
+				},
+			})
+			if err != nil {
+				t.Fatalf("expected nil error %v", err)
+			}
+			if res != c.res {
+				t.Fatalf("mismatch got: %s want: %s", res, c.res)
+			}
+		})
+	}
+}
+
+func TestAPIServerInternalEndpoints(t *testing.T) {
+	dummyTemplate := []byte(`{{range onPremPlatformAPIServerInternalIPs .}}{{.}};{{end}}|{{range apiServerInternalEndpoints .}}{{.}};{{end}}|{{range onPremPlatformIngressIPs .}}{{.}};{{end}}`)
+
+	cases := []struct {
+		name           string
+		platformStatus *configv1.PlatformStatus
+		internalURL    string
+		res            string
+	}{{
+		name: "baremetal",
+		platformStatus: &configv1.PlatformStatus{
+			Type:      configv1.BareMetalPlatformType,
+			BareMetal: &configv1.BareMetalPlatformStatus{APIServerInternalIP: "192.168.111.5", IngressIP: "192.168.111.4"},
+		},
+		internalURL: "https://api-int.example.com:6443",
+		res:         "192.168.111.5;|192.168.111.5:6443;|192.168.111.4;",
+	}, {
+		name: "openstack ipv6",
+		platformStatus: &configv1.PlatformStatus{
+			Type:      configv1.OpenStackPlatformType,
+			OpenStack: &configv1.OpenStackPlatformStatus{APIServerInternalIP: "fd2e:6f44:5dd8::5", IngressIP: "fd2e:6f44:5dd8::4"},
+		},
+		internalURL: "https://api-int.example.com:6443",
+		res:         "fd2e:6f44:5dd8::5;|[fd2e:6f44:5dd8::5]:6443;|fd2e:6f44:5dd8::4;",
+	}, {
+		name: "nutanix",
+		platformStatus: &configv1.PlatformStatus{
+			Type:    configv1.NutanixPlatformType,
+			Nutanix: &configv1.NutanixPlatformStatus{APIServerInternalIP: "10.0.0.1"},
+		},
+		internalURL: "https://api-int.example.com:6443",
+		res:         "10.0.0.1;|10.0.0.1:6443;|",
+	}, {
+		name:           "vsphere upi",
+		platformStatus: &configv1.PlatformStatus{Type: configv1.VSpherePlatformType},
+		internalURL:    "https://api-int.example.com:6443",
+		res:            "|api-int.example.com:6443;|",
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			config := &mcfgv1.ControllerConfig{
+				Spec: mcfgv1.ControllerConfigSpec{
+					Infra: &configv1.Infrastructure{
+						Status: configv1.InfrastructureStatus{
+							Platform:             c.platformStatus.Type,
+							PlatformStatus:       c.platformStatus,
+							APIServerInternalURL: c.internalURL,
+						},
+					},
+				},
+			}
+			got, err := renderTemplate(RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}, c.name, dummyTemplate)
+			if err != nil {
+				t.Fatalf("expected nil error %v", err)
+			}
+
+			if string(got) != c.res {
+				t.Fatalf("mismatch got: %s want: %s", got, c.res)
+			}
+		})
+	}
+
+	// off-prem platforms only have the internal API server URL
+	config := &mcfgv1.ControllerConfig{
+		Spec: mcfgv1.ControllerConfigSpec{
+			Infra: &configv1.Infrastructure{
+				Status: configv1.InfrastructureStatus{
+					Platform:             configv1.AWSPlatformType,
+					PlatformStatus:       &configv1.PlatformStatus{Type: configv1.AWSPlatformType},
+					APIServerInternalURL: "https://api-int.example.com:6443",
+				},
+			},
+		},
+	}
+	got, err := renderTemplate(RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}, "aws", []byte(`{{range apiServerInternalEndpoints .}}{{.}};{{end}}`))
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	if string(got) != "api-int.example.com:6443;" {
+		t.Fatalf("mismatch got: %s want: api-int.example.com:6443;", got)
+	}
+}
+
+func TestImageRegistryHostnames(t *testing.T) {
+	dummyTemplate := []byte(`{{internalRegistryHostname .}}|{{range imageRegistryHostnames .}}{{.}};{{end}}`)
+
+	cases := []struct {
+		name  string
+		image *configv1.ImageStatus
+		res   string
+	}{{
+		name: "no image config",
+		res:  "|",
+	}, {
+		name:  "internal",
+		image: &configv1.ImageStatus{InternalRegistryHostname: "image-registry.openshift-image-registry.svc:5000"},
+		res:   "image-registry.openshift-image-registry.svc:5000|image-registry.openshift-image-registry.svc:5000;",
+	}, {
+		name: "exposed",
+		image: &configv1.ImageStatus{
+			InternalRegistryHostname:  "image-registry.openshift-image-registry.svc:5000",
+			ExternalRegistryHostnames: []string{"registry.apps.example.com", "image-registry.openshift-image-registry.svc:5000"},
+		},
+		res: "image-registry.openshift-image-registry.svc:5000|image-registry.openshift-image-registry.svc:5000;registry.apps.example.com;",
+	}, {
+		name:  "external only",
+		image: &configv1.ImageStatus{ExternalRegistryHostnames: []string{"registry.apps.example.com"}},
+		res:   "|registry.apps.example.com;",
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			config := &mcfgv1.ControllerConfig{Spec: mcfgv1.ControllerConfigSpec{Image: c.image}}
+			got, err := renderTemplate(RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}, c.name, dummyTemplate)
 			if err != nil {
 				t.Fatalf("expected nil error %v", err)
 			}
@@ -254,7 +420,7 @@ func TestCloudConfigFlag(t *testing.T) {
 					CloudProviderConfig: c.content,
 				},
 			}
-			got, err := renderTemplate(RenderConfig{&config.Spec, `{"dummy":"dummy"}`, c.featureGate, nil}, name, dummyTemplate)
+			got, err := renderTemplate(RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, FeatureGate: c.featureGate, StrictTemplates: true}, name, dummyTemplate)
 			if err != nil {
 				t.Fatalf("expected nil error %v", err)
 			}
@@ -308,6 +474,244 @@ func TestSkipMissing(t *testing.T) {
 	}
 }
 
+func TestStrictTemplates(t *testing.T) {
+	tmpl := []byte(`image: {{.Images.missing}}`)
+	config := RenderConfig{ControllerConfigSpec: &mcfgv1.ControllerConfigSpec{Images: map[string]string{}}}
+	got, err := renderTemplate(config, "lenient", tmpl)
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	if string(got) != "image: <no value>" {
+		t.Fatalf("mismatch got: %s want: image: <no value>", got)
+	}
+
+	config.StrictTemplates = true
+	if _, err := renderTemplate(config, "strict", tmpl); err == nil || !strings.Contains(err.Error(), `map has no entry for key "missing"`) {
+		t.Fatalf("expected a missing key error, got: %v", err)
+	}
+
+	for _, c := range []struct {
+		annotations map[string]string
+		def         bool
+		strict      bool
+		err         bool
+	}{
+		{annotations: nil, def: false, strict: false},
+		{annotations: nil, def: true, strict: true},
+		{annotations: map[string]string{ctrlcommon.StrictTemplatesAnnotationKey: "true"}, def: false, strict: true},
+		{annotations: map[string]string{ctrlcommon.StrictTemplatesAnnotationKey: "false"}, def: true, strict: false},
+		{annotations: map[string]string{ctrlcommon.StrictTemplatesAnnotationKey: "strict"}, err: true},
+	} {
+		strict, err := strictTemplatesFromAnnotations(c.annotations, c.def)
+		if (err != nil) != c.err || strict != c.strict {
+			t.Fatalf("annotations %v, default %v: got %v, %v", c.annotations, c.def, strict, err)
+		}
+	}
+}
+
+func TestFilterTemplatesMarkers(t *testing.T) {
+	writeTemplates := func(t *testing.T, files map[string]string) string {
+		dir := t.TempDir()
+		for name, data := range files {
+			if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dir
+	}
+
+	base := writeTemplates(t, map[string]string{
+		"a.yaml": "a",
+		"b.yaml": "b",
+		"c.yaml": "c",
+	})
+	platform := writeTemplates(t, map[string]string{
+		"a.yaml.delete": "",
+		"b.yaml":        "",
+		"d.yaml.keep":   "",
+	})
+
+	files := map[string]string{}
+	for _, dir := range []string{base, platform} {
+		if err := filterTemplates(files, dir, &RenderConfig{}, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if want := map[string]string{"c.yaml": "c"}; !reflect.DeepEqual(files, want) {
+		t.Fatalf("mismatch got: %v want: %v", files, want)
+	}
+
+	for _, marker := range []string{"a.yaml.delete", "a.yaml.keep"} {
+		invalid := writeTemplates(t, map[string]string{marker: "a"})
+		if err := filterTemplates(map[string]string{}, invalid, &RenderConfig{}, nil); err == nil {
+			t.Fatalf("expected error for non-empty marker %s", marker)
+		}
+	}
+}
+
+func TestArchitectureOverlays(t *testing.T) {
+	writeTemplate := func(t *testing.T, path, data string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unit := func(name, exec string) string {
+		return fmt.Sprintf("name: %s\nenabled: true\ncontents: |\n  [Service]\n  ExecStart=%s\n", name, exec)
+	}
+
+	dir := t.TempDir()
+	namePath := filepath.Join(dir, "worker", "01-worker-kubelet")
+	writeTemplate(t, filepath.Join(namePath, platformBase, unitsDir, "a.service.yaml"), unit("a.service", "/bin/true"))
+	writeTemplate(t, filepath.Join(namePath, platformBase, "s390x", unitsDir, "a.service.yaml"), unit("a.service", "/bin/s390x"))
+	writeTemplate(t, filepath.Join(namePath, platformBase, "s390x", unitsDir, "b.service.yaml"), unit("b.service", "/bin/true"))
+	writeTemplate(t, filepath.Join(namePath, "aws", unitsDir, "a.service.yaml"), unit("a.service", "/bin/aws"))
+	writeTemplate(t, filepath.Join(namePath, "aws", "aarch64", unitsDir, "a.service.yaml"), unit("a.service", "/bin/aws-aarch64"))
+
+	cases := []struct {
+		platform configv1.PlatformType
+		arch     string
+		units    map[string]string
+	}{{
+		platform: configv1.AWSPlatformType,
+		arch:     "",
+		units:    map[string]string{"a.service": "/bin/aws"},
+	}, {
+		platform: configv1.AWSPlatformType,
+		arch:     "x86_64",
+		units:    map[string]string{"a.service": "/bin/aws"},
+	}, {
+		platform: configv1.AWSPlatformType,
+		arch:     "aarch64",
+		units:    map[string]string{"a.service": "/bin/aws-aarch64"},
+	}, {
+		// the platform directory takes precedence over the architecture overlay of _base
+		platform: configv1.AWSPlatformType,
+		arch:     "s390x",
+		units:    map[string]string{"a.service": "/bin/aws", "b.service": "/bin/true"},
+	}, {
+		platform: configv1.NonePlatformType,
+		arch:     "s390x",
+		units:    map[string]string{"a.service": "/bin/s390x", "b.service": "/bin/true"},
+	}}
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s/%s", c.platform, c.arch), func(t *testing.T) {
+			config := &mcfgv1.ControllerConfig{
+				Spec: mcfgv1.ControllerConfigSpec{
+					Architecture: c.arch,
+					Infra: &configv1.Infrastructure{
+						Status: configv1.InfrastructureStatus{
+							PlatformStatus: &configv1.PlatformStatus{Type: c.platform},
+						},
+					},
+				},
+			}
+			renderConfig := &RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}
+
+			commonAdded := true
+			mc, err := generateMachineConfigForName(renderConfig, "worker", "01-worker-kubelet", dir, namePath, &commonAdded)
+			if err != nil {
+				t.Fatalf("failed to generate machine config: %v", err)
+			}
+			ign, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+			if err != nil {
+				t.Fatalf("failed to parse Ignition config: %v", err)
+			}
+			units := map[string]string{}
+			for _, u := range ign.Systemd.Units {
+				units[u.Name] = strings.TrimSpace(strings.TrimPrefix(*u.Contents, "[Service]\nExecStart="))
+			}
+			if !reflect.DeepEqual(units, c.units) {
+				t.Fatalf("mismatch got: %v want: %v", units, c.units)
+			}
+		})
+	}
+}
+
+func TestSingleNodeTopology(t *testing.T) {
+	writeTemplate := func(t *testing.T, path, data string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unit := func(name string) string {
+		return fmt.Sprintf("name: %s\nenabled: true\ncontents: |\n  [Service]\n  ExecStart=/bin/true\n", name)
+	}
+
+	dir := t.TempDir()
+	namePath := filepath.Join(dir, "master", "00-master")
+	writeTemplate(t, filepath.Join(namePath, platformBase, unitsDir, "a.service.yaml"), unit("a.service"))
+	writeTemplate(t, filepath.Join(namePath, platformBase, unitsDir, "b.service.yaml"), unit("b.service"))
+	writeTemplate(t, filepath.Join(namePath, topologySingleNode, unitsDir, "b.service.yaml.delete"), "")
+
+	cases := []struct {
+		topology configv1.TopologyMode
+		replicas string
+		units    []string
+	}{{
+		topology: configv1.HighlyAvailableTopologyMode,
+		replicas: "false 3",
+		units:    []string{"a.service", "b.service"},
+	}, {
+		topology: "",
+		replicas: "false 3",
+		units:    []string{"a.service", "b.service"},
+	}, {
+		topology: configv1.SingleReplicaTopologyMode,
+		replicas: "true 1",
+		units:    []string{"a.service"},
+	}, {
+		topology: configv1.ExternalTopologyMode,
+		replicas: "false 0",
+		units:    []string{"a.service", "b.service"},
+	}}
+	for _, c := range cases {
+		t.Run(string(c.topology), func(t *testing.T) {
+			config := &mcfgv1.ControllerConfig{
+				Spec: mcfgv1.ControllerConfigSpec{
+					Infra: &configv1.Infrastructure{
+						Status: configv1.InfrastructureStatus{
+							PlatformStatus:       &configv1.PlatformStatus{Type: configv1.AWSPlatformType},
+							ControlPlaneTopology: c.topology,
+						},
+					},
+				},
+			}
+			renderConfig := &RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}
+
+			got, err := renderTemplate(*renderConfig, "dummy", []byte(`{{isSNO .}} {{controlPlaneReplicas .}}`))
+			if err != nil {
+				t.Fatalf("expected nil error %v", err)
+			}
+			if string(got) != c.replicas {
+				t.Fatalf("mismatch got: %s want: %s", got, c.replicas)
+			}
+
+			commonAdded := true
+			mc, err := generateMachineConfigForName(renderConfig, "master", "00-master", dir, namePath, &commonAdded)
+			if err != nil {
+				t.Fatalf("failed to generate machine config: %v", err)
+			}
+			ign, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+			if err != nil {
+				t.Fatalf("failed to parse Ignition config: %v", err)
+			}
+			units := []string{}
+			for _, u := range ign.Systemd.Units {
+				units = append(units, u.Name)
+			}
+			if !reflect.DeepEqual(units, c.units) {
+				t.Fatalf("mismatch got: %v want: %v", units, c.units)
+			}
+		})
+	}
+}
+
 const templateDir = "../../../templates"
 
 var (
@@ -345,17 +749,41 @@ func TestInvalidPlatform(t *testing.T) {
 
 	// we must treat unrecognized constants as "none"
 	controllerConfig.Spec.Infra.Status.PlatformStatus.Type = "_bad_"
-	_, err = generateTemplateMachineConfigs(&RenderConfig{&controllerConfig.Spec, `{"dummy":"dummy"}`, nil, nil}, templateDir)
+	_, err = generateTemplateMachineConfigs(context.TODO(), &RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}, templateDir, nil)
 	if err != nil {
 		t.Errorf("expect nil error, got: %v", err)
 	}
 
-	// explicitly blocked
+	// explicitly blocked, in a tree with a single role so the error doesn't
+	// depend on which role is rendered first
+	singleRoleDir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(singleRoleDir)
+	for _, dir := range []string{"common", "master/00-master"} {
+		if err := os.MkdirAll(filepath.Join(singleRoleDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
 	controllerConfig.Spec.Infra.Status.PlatformStatus.Type = "_base"
-	_, err = generateTemplateMachineConfigs(&RenderConfig{&controllerConfig.Spec, `{"dummy":"dummy"}`, nil, nil}, templateDir)
+	_, err = generateTemplateMachineConfigs(context.TODO(), &RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}, singleRoleDir, nil)
 	expectErr(err, "failed to create MachineConfig for role master: platform _base unsupported")
 }
 
+func TestGenerateMachineConfigsCancelled(t *testing.T) {
+	controllerConfig, err := controllerConfigFromFile(configs["aws"])
+	if err != nil {
+		t.Fatalf("failed to get controllerconfig config: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = generateTemplateMachineConfigs(ctx, &RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}, templateDir, nil)
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Fatalf("expected a cancelled render, got: %v", err)
+	}
+}
+
 func TestGenerateMachineConfigs(t *testing.T) {
 	for test, config := range configs {
 		controllerConfig, err := controllerConfigFromFile(config)
@@ -363,7 +791,18 @@ func TestGenerateMachineConfigs(t *testing.T) {
 			t.Fatalf("failed to get controllerconfig config: %v", err)
 		}
 
-		cfgs, err := generateTemplateMachineConfigs(&RenderConfig{&controllerConfig.Spec, `{"dummy":"dummy"}`, nil, nil}, templateDir)
+		rc := &RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}
+		cfgs, err := generateTemplateMachineConfigs(context.TODO(), rc, templateDir, nil)
+		if err != nil {
+			t.Fatalf("failed to generate machine configs: %v", err)
+		}
+		for _, cfg := range cfgs {
+			if cfg.Labels[mcfgv1.MachineConfigRoleLabelKey] == "infra" {
+				t.Errorf("Rendered infra MachineConfig %s without the infra pool opting in", cfg.Name)
+			}
+		}
+
+		cfgs, err = generateTemplateMachineConfigs(context.TODO(), rc, templateDir, map[string]poolTemplateConfig{"infra": {InfraTemplates: true}})
 		if err != nil {
 			t.Fatalf("failed to generate machine configs: %v", err)
 		}
@@ -374,6 +813,7 @@ func TestGenerateMachineConfigs(t *testing.T) {
 		foundKubeletUnitWorker := false
 		foundMTUMigrationMaster := false
 		foundMTUMigrationWorker := false
+		foundInfraSysctl := false
 
 		for _, cfg := range cfgs {
 			if cfg.Labels == nil {
@@ -411,6 +851,12 @@ func TestGenerateMachineConfigs(t *testing.T) {
 					foundMTUMigrationWorker = findIgnFile(ign.Storage.Files, "/usr/local/bin/mtu-migration.sh", t)
 					foundMTUMigrationWorker = foundMTUMigrationWorker || findIgnFile(ign.Storage.Files, "/etc/systemd/system/mtu-migration.service", t)
 				}
+			} else if role == "infra" {
+				// infra pools select the worker configs too, only the additions are rendered
+				foundInfraSysctl = foundInfraSysctl || findIgnFile(ign.Storage.Files, "/etc/sysctl.d/70-infra.conf", t)
+				if findIgnFile(ign.Storage.Files, "/var/lib/kubelet/config.json", t) {
+					t.Errorf("Found common templates for infra")
+				}
 			} else {
 				t.Fatalf("Unknown role %s", role)
 			}
@@ -428,6 +874,9 @@ func TestGenerateMachineConfigs(t *testing.T) {
 		if !foundKubeletUnitWorker {
 			t.Errorf("Failed to find kubelet unit for worker")
 		}
+		if !foundInfraSysctl {
+			t.Errorf("Failed to find sysctls for infra")
+		}
 		if test == "mtu-migration" {
 			if !foundMTUMigrationMaster {
 				t.Errorf("Failed to find mtu-migration files for master")
@@ -446,6 +895,171 @@ func TestGenerateMachineConfigs(t *testing.T) {
 	}
 }
 
+func TestGenerateMachineConfigsOrdering(t *testing.T) {
+	writeTemplate := func(t *testing.T, path, data string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := func(path string) string {
+		return fmt.Sprintf("mode: 0644\npath: %s\ncontents:\n  inline: data\n", path)
+	}
+
+	// more MachineConfigs than workers, so they're rendered concurrently
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "common", platformBase, filesDir, "common.yaml"), file("/etc/common"))
+	names := []string{}
+	for i := 0; i < 3*maxRenderWorkers; i++ {
+		name := fmt.Sprintf("%02d-worker", i)
+		names = append(names, name)
+		writeTemplate(t, filepath.Join(dir, "worker", name, platformBase, filesDir, "file.yaml"), file("/etc/"+name))
+	}
+	config := &mcfgv1.ControllerConfig{
+		Spec: mcfgv1.ControllerConfigSpec{
+			Infra: &configv1.Infrastructure{
+				Status: configv1.InfrastructureStatus{
+					PlatformStatus: &configv1.PlatformStatus{Type: configv1.AWSPlatformType},
+				},
+			},
+		},
+	}
+	renderConfig := &RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}
+
+	var first []*mcfgv1.MachineConfig
+	for run := 0; run < 5; run++ {
+		cfgs, err := generateTemplateMachineConfigs(context.TODO(), renderConfig, dir, nil)
+		if err != nil {
+			t.Fatalf("failed to generate machine configs: %v", err)
+		}
+		if len(cfgs) != len(names) {
+			t.Fatalf("expected %d machine configs, got %d", len(names), len(cfgs))
+		}
+		for i, cfg := range cfgs {
+			if cfg.Name != names[i] {
+				t.Fatalf("mismatch got: %s want: %s at %d", cfg.Name, names[i], i)
+			}
+			ign, err := ctrlcommon.ParseAndConvertConfig(cfg.Spec.Config.Raw)
+			if err != nil {
+				t.Fatalf("failed to parse Ignition config: %v", err)
+			}
+			if findIgnFile(ign.Storage.Files, "/etc/common", t) != (i == 0) {
+				t.Fatalf("expected the common templates only in %s, found them in %s", names[0], cfg.Name)
+			}
+		}
+		if first == nil {
+			first = cfgs
+		} else if !reflect.DeepEqual(first, cfgs) {
+			t.Fatalf("rendering is not deterministic")
+		}
+	}
+
+	// the error of the first failing MachineConfig is reported
+	writeTemplate(t, filepath.Join(dir, "worker", names[5], platformBase, filesDir, "bad.yaml"), "{{ .Bad5 }}")
+	writeTemplate(t, filepath.Join(dir, "worker", names[2], platformBase, filesDir, "bad.yaml"), "{{ .Bad2 }}")
+	_, err := generateTemplateMachineConfigs(context.TODO(), renderConfig, dir, nil)
+	if err == nil || !strings.Contains(err.Error(), "Bad2") {
+		t.Fatalf("expected the error of %s, got %v", names[2], err)
+	}
+}
+
+func TestTemplateRole(t *testing.T) {
+	dir := t.TempDir()
+	for _, role := range []string{"master", "worker"} {
+		path := filepath.Join(dir, role, "00-"+role, platformBase, filesDir, "role.yaml")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("mode: 0644\npath: /etc/role\ncontents:\n  inline: "+role+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := &mcfgv1.ControllerConfig{
+		Spec: mcfgv1.ControllerConfigSpec{
+			Infra: &configv1.Infrastructure{
+				Status: configv1.InfrastructureStatus{
+					PlatformStatus: &configv1.PlatformStatus{Type: configv1.AWSPlatformType},
+				},
+			},
+		},
+	}
+	renderConfig := &RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}
+
+	for _, tc := range []struct {
+		pool     string
+		roleBase string
+		want     string
+	}{
+		{pool: "master", want: "master"},
+		{pool: "worker", want: "worker"},
+		{pool: "infra", want: "worker"},
+		{pool: "arbiter", roleBase: "master", want: "master"},
+		{pool: "infra-control", roleBase: "master", want: "master"},
+		{pool: "edge", roleBase: "worker", want: "worker"},
+	} {
+		pool := &mcfgv1.MachineConfigPool{ObjectMeta: metav1.ObjectMeta{Name: tc.pool}}
+		if tc.roleBase != "" {
+			pool.Labels = map[string]string{ctrlcommon.RoleBaseLabelKey: tc.roleBase}
+		}
+		cfgs, err := GenerateMachineConfigsForRole(renderConfig, TemplateRole(pool), dir)
+		if err != nil {
+			t.Fatalf("%s: failed to generate machine configs: %v", tc.pool, err)
+		}
+		if len(cfgs) != 1 {
+			t.Fatalf("%s: expected 1 machine config, got %d", tc.pool, len(cfgs))
+		}
+		ign, err := ctrlcommon.ParseAndConvertConfig(cfgs[0].Spec.Config.Raw)
+		if err != nil {
+			t.Fatalf("%s: failed to parse Ignition config: %v", tc.pool, err)
+		}
+		contents, err := ctrlcommon.GetIgnitionFileDataByPath(&ign, "/etc/role")
+		if err != nil {
+			t.Fatalf("%s: %v", tc.pool, err)
+		}
+		if string(contents) != tc.want {
+			t.Errorf("%s: expected the %s templates, got the %s ones", tc.pool, tc.want, contents)
+		}
+	}
+}
+
+func TestOnPremStaticPods(t *testing.T) {
+	for _, test := range []struct {
+		config  string
+		enabled bool
+	}{
+		{config: "baremetal", enabled: true},
+		{config: "nutanix", enabled: true},
+		{config: "aws", enabled: false},
+	} {
+		t.Run(test.config, func(t *testing.T) {
+			controllerConfig, err := controllerConfigFromFile(configs[test.config])
+			if err != nil {
+				t.Fatalf("failed to get controllerconfig config: %v", err)
+			}
+			cfgs, err := generateTemplateMachineConfigs(context.TODO(), &RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}, templateDir, nil)
+			if err != nil {
+				t.Fatalf("failed to generate machine configs: %v", err)
+			}
+
+			for _, path := range []string{"/etc/kubernetes/manifests/keepalived.yaml", "/etc/kubernetes/manifests/coredns.yaml"} {
+				found := false
+				for _, cfg := range cfgs {
+					ign, err := ctrlcommon.ParseAndConvertConfig(cfg.Spec.Config.Raw)
+					if err != nil {
+						t.Fatalf("Failed to parse Ignition config")
+					}
+					found = found || findIgnFile(ign.Storage.Files, path, t)
+				}
+				if found != test.enabled {
+					t.Errorf("expected %s to be rendered: %v, got %v", path, test.enabled, found)
+				}
+			}
+		})
+	}
+}
+
 func controllerConfigFromFile(path string) (*mcfgv1.ControllerConfig, error) {
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -522,3 +1136,173 @@ func verifyIgn(actual [][]byte, dir string, t *testing.T) {
 		t.Errorf("can't find expected file:\n%v", key)
 	}
 }
+
+func TestCgroupMode(t *testing.T) {
+	cases := []struct {
+		mode    configv1.CgroupMode
+		want    string
+		wantErr bool
+	}{
+		{mode: "", want: "v1"},
+		{mode: configv1.CgroupModeV1, want: "v1"},
+		{mode: configv1.CgroupModeV2, want: "v2"},
+		{mode: "v3", wantErr: true},
+	}
+	for _, c := range cases {
+		config := &mcfgv1.ControllerConfig{Spec: mcfgv1.ControllerConfigSpec{CgroupMode: c.mode}}
+		renderConfig := &RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}
+
+		got, err := renderTemplate(*renderConfig, "dummy", []byte(`{{if eq (cgroupMode .) "v2"}}unified{{else}}legacy{{end}} {{cgroupMode .}}`))
+		if c.wantErr {
+			if err == nil {
+				t.Fatalf("%q: expected an error", c.mode)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: expected nil error %v", c.mode, err)
+		}
+		want := "legacy " + c.want
+		if c.want == "v2" {
+			want = "unified v2"
+		}
+		if string(got) != want {
+			t.Fatalf("%q: mismatch got: %s want: %s", c.mode, got, want)
+		}
+	}
+}
+
+func TestClusterDNSIP(t *testing.T) {
+	cases := []struct {
+		serviceNetwork []string
+		clusterDNSIP   string
+		want           string
+		wantErr        bool
+	}{
+		{serviceNetwork: []string{"172.30.0.0/16"}, clusterDNSIP: "172.30.0.10", want: "172.30.0.10"},
+		// the service network isn't always the default one
+		{serviceNetwork: []string{"10.96.0.0/12"}, clusterDNSIP: "10.96.0.10", want: "10.96.0.10"},
+		{serviceNetwork: []string{"fd02::/112"}, want: "fd02::a"},
+		// dual-stack: the service network of the primary IP family
+		{serviceNetwork: []string{"fd02::/112", "172.30.0.0/16"}, want: "fd02::a"},
+		{serviceNetwork: []string{"172.30.0.0/16", "fd02::/112"}, want: "172.30.0.10"},
+		// ControllerConfigs predating serviceNetwork
+		{clusterDNSIP: "172.30.0.10", want: "172.30.0.10"},
+		{serviceNetwork: []string{"172.30.0.0/29"}, wantErr: true},
+		{serviceNetwork: []string{"172.30.0.0"}, wantErr: true},
+	}
+	for _, c := range cases {
+		config := &mcfgv1.ControllerConfig{Spec: mcfgv1.ControllerConfigSpec{ServiceNetwork: c.serviceNetwork, ClusterDNSIP: c.clusterDNSIP}}
+		renderConfig := &RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}
+
+		got, err := renderTemplate(*renderConfig, "dummy", []byte(`{{clusterDNSIP .}}`))
+		if c.wantErr {
+			if err == nil {
+				t.Fatalf("%v: expected an error", c.serviceNetwork)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: expected nil error %v", c.serviceNetwork, err)
+		}
+		if string(got) != c.want {
+			t.Fatalf("%v: mismatch got: %s want: %s", c.serviceNetwork, got, c.want)
+		}
+	}
+}
+
+func TestIsFIPS(t *testing.T) {
+	for _, fips := range []bool{false, true} {
+		config := &mcfgv1.ControllerConfig{Spec: mcfgv1.ControllerConfigSpec{FIPS: fips}}
+		renderConfig := &RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}
+
+		got, err := renderTemplate(*renderConfig, "dummy", []byte(`{{if isFIPS .}}FIPS{{else}}DEFAULT{{end}}`))
+		if err != nil {
+			t.Fatalf("expected nil error %v", err)
+		}
+		want := "DEFAULT"
+		if fips {
+			want = "FIPS"
+		}
+		if string(got) != want {
+			t.Fatalf("mismatch got: %s want: %s", got, want)
+		}
+	}
+}
+
+func TestIPFamilies(t *testing.T) {
+	cases := []struct {
+		serviceNetwork []string
+		ipFamilies     mcfgv1.IPFamiliesType
+		want           string
+		wantErr        bool
+	}{
+		{serviceNetwork: []string{"172.30.0.0/16"}, ipFamilies: mcfgv1.IPFamiliesIPv4, want: "false false IPv4"},
+		{serviceNetwork: []string{"fd02::/112"}, ipFamilies: mcfgv1.IPFamiliesIPv6, want: "true false IPv6"},
+		{serviceNetwork: []string{"172.30.0.0/16", "fd02::/112"}, ipFamilies: mcfgv1.IPFamiliesDualStack, want: "false true IPv4"},
+		{serviceNetwork: []string{"fd02::/112", "172.30.0.0/16", "fd03::/112"}, ipFamilies: mcfgv1.IPFamiliesDualStack, want: "false true IPv6"},
+		// ControllerConfigs predating serviceNetwork
+		{ipFamilies: mcfgv1.IPFamiliesIPv4, want: "false false IPv4"},
+		{ipFamilies: mcfgv1.IPFamiliesIPv6, want: "true false IPv6"},
+		{ipFamilies: mcfgv1.IPFamiliesDualStack, want: "false true IPv4"},
+		{want: "false false IPv4"},
+		{serviceNetwork: []string{"172.30.0.0"}, wantErr: true},
+	}
+	for _, c := range cases {
+		config := &mcfgv1.ControllerConfig{Spec: mcfgv1.ControllerConfigSpec{ServiceNetwork: c.serviceNetwork, IPFamilies: c.ipFamilies}}
+		renderConfig := &RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}
+
+		got, err := renderTemplate(*renderConfig, "dummy", []byte(`{{isSingleStackIPv6 .}} {{isDualStack .}} {{primaryIPFamily .}}`))
+		if c.wantErr {
+			if err == nil {
+				t.Fatalf("%v: expected an error", c.serviceNetwork)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v %s: expected nil error %v", c.serviceNetwork, c.ipFamilies, err)
+		}
+		if string(got) != c.want {
+			t.Fatalf("%v %s: mismatch got: %s want: %s", c.serviceNetwork, c.ipFamilies, got, c.want)
+		}
+	}
+}
+
+func TestRenderMetrics(t *testing.T) {
+	controllerConfig, err := controllerConfigFromFile(configs["aws"])
+	if err != nil {
+		t.Fatalf("failed to get controllerconfig config: %v", err)
+	}
+	renderConfig := &RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}
+	cfgs, err := generateTemplateMachineConfigs(context.TODO(), renderConfig, templateDir, nil)
+	if err != nil {
+		t.Fatalf("failed to generate machine configs: %v", err)
+	}
+	counts := map[string]int{}
+	for _, cfg := range cfgs {
+		counts[cfg.Labels[mcfgv1.MachineConfigRoleLabelKey]]++
+	}
+	for role, count := range counts {
+		if got := testutil.ToFloat64(ctrlcommon.MachineConfigControllerTemplateMachineConfigs.WithLabelValues(role)); got != float64(count) {
+			t.Errorf("%s: mismatch got: %v MachineConfigs want: %d", role, got, count)
+		}
+	}
+	// other tests render other roles too
+	if n := testutil.CollectAndCount(ctrlcommon.MachineConfigControllerTemplateRenderDuration); n < len(counts) {
+		t.Errorf("expected a render duration for each of the %d roles, got %d", len(counts), n)
+	}
+
+	for stage, text := range map[string]string{
+		"parse":   `{{.Missing`,
+		"execute": `{{index .PoolVars 1}}`,
+	} {
+		path := "failing-" + stage
+		before := testutil.ToFloat64(ctrlcommon.MachineConfigControllerTemplateFailures.WithLabelValues(path, stage))
+		if _, err := renderTemplate(*renderConfig, path, []byte(text)); err == nil {
+			t.Fatalf("%s: expected an error", stage)
+		}
+		if got := testutil.ToFloat64(ctrlcommon.MachineConfigControllerTemplateFailures.WithLabelValues(path, stage)); got != before+1 {
+			t.Errorf("%s: mismatch got: %v failures want: %v", stage, got, before+1)
+		}
+	}
+}
@@ -0,0 +1,554 @@
+package template
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/golang/glog"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// ScriptLintSeverity is the severity of a problem found in a rendered shell
+// script, and as a policy the lowest severity which fails rendering.
+type ScriptLintSeverity string
+
+const (
+	// ScriptLintSeverityError is a syntax error the shell would reject.
+	ScriptLintSeverityError ScriptLintSeverity = "Error"
+	// ScriptLintSeverityWarning is a likely templating mistake which the
+	// shell accepts.
+	ScriptLintSeverityWarning ScriptLintSeverity = "Warning"
+	// ScriptLintSeverityNone as a policy only logs the problems.
+	ScriptLintSeverityNone ScriptLintSeverity = "None"
+)
+
+// shells are the interpreters whose scripts are linted, the ones sharing the
+// POSIX shell grammar.
+var shells = map[string]bool{"sh": true, "bash": true, "dash": true}
+
+// scriptLintSeverityFromAnnotations returns the policy configured on the ControllerConfig, defaulting to Error.
+func scriptLintSeverityFromAnnotations(annotations map[string]string) (ScriptLintSeverity, error) {
+	switch severity := ScriptLintSeverity(annotations[ctrlcommon.ScriptLintSeverityAnnotationKey]); severity {
+	case "":
+		return ScriptLintSeverityError, nil
+	case ScriptLintSeverityError, ScriptLintSeverityWarning, ScriptLintSeverityNone:
+		return severity, nil
+	default:
+		return "", fmt.Errorf("invalid %s annotation %q, must be %q, %q or %q", ctrlcommon.ScriptLintSeverityAnnotationKey, severity,
+			ScriptLintSeverityError, ScriptLintSeverityWarning, ScriptLintSeverityNone)
+	}
+}
+
+// fails returns whether a problem of the given severity fails rendering under the policy.
+func (policy ScriptLintSeverity) fails(severity ScriptLintSeverity) bool {
+	switch policy {
+	case ScriptLintSeverityNone:
+		return false
+	case ScriptLintSeverityWarning:
+		return true
+	default:
+		return severity == ScriptLintSeverityError
+	}
+}
+
+// scriptFinding is a problem found in a script.
+type scriptFinding struct {
+	severity ScriptLintSeverity
+	line     int
+	message  string
+}
+
+// isShellScript returns whether a file is a shell script: either it starts
+// with the shebang of a shell, or it has no shebang and is installed in a bin
+// directory.  Scripts of other interpreters are left alone.
+func isShellScript(filePath string, contents []byte) (script, shebang bool) {
+	if !strings.HasPrefix(string(contents), "#!") {
+		dir := path.Dir(filePath)
+		return path.Base(dir) == "bin" || path.Base(dir) == "sbin", false
+	}
+	firstLine := strings.SplitN(string(contents), "\n", 2)[0]
+	fields := strings.Fields(strings.TrimPrefix(firstLine, "#!"))
+	if len(fields) == 0 {
+		return false, true
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, arg := range fields[1:] {
+			if !strings.HasPrefix(arg, "-") {
+				interpreter = path.Base(arg)
+				break
+			}
+		}
+	}
+	return shells[interpreter], true
+}
+
+// lintScript checks a shell script for the mistakes templating tends to
+// introduce: syntax errors such as an unclosed quote, and values the
+// template didn't have.
+func lintScript(contents []byte, shebang bool) []scriptFinding {
+	var findings []scriptFinding
+	if !shebang {
+		findings = append(findings, scriptFinding{ScriptLintSeverityWarning, 1, "missing shebang"})
+	}
+	for i, line := range strings.Split(string(contents), "\n") {
+		if strings.Contains(line, "<no value>") {
+			findings = append(findings, scriptFinding{ScriptLintSeverityWarning, i + 1, "contains <no value>, the rendering of a missing template value"})
+		}
+		if strings.HasSuffix(line, "\r") {
+			findings = append(findings, scriptFinding{ScriptLintSeverityWarning, i + 1, "ends with a carriage return"})
+		}
+	}
+	l := &shellLexer{src: string(contents), line: 1}
+	l.lexCommands(0, 1)
+	if l.err != nil {
+		findings = append(findings, *l.err)
+	}
+	return findings
+}
+
+// lintScripts lints the shell scripts of the rendered config and fails if
+// any has a problem at or above the configured severity.
+func lintScripts(config *RenderConfig, name string, ignCfg *ign3types.Config) error {
+	policy := config.ScriptLintSeverity
+	if policy == "" {
+		policy = ScriptLintSeverityError
+	}
+	var failures []string
+	for _, file := range ignCfg.Storage.Files {
+		contents, err := ctrlcommon.DecodeIgnitionFileContents(file.Contents.Source, file.Contents.Compression)
+		if err != nil {
+			return fmt.Errorf("could not decode file %q: %w", file.Path, err)
+		}
+		script, shebang := isShellScript(file.Path, contents)
+		if !script {
+			continue
+		}
+		for _, f := range lintScript(contents, shebang) {
+			msg := fmt.Sprintf("%s: line %d: %s", file.Path, f.line, f.message)
+			if policy.fails(f.severity) {
+				failures = append(failures, msg)
+			} else {
+				glog.Warningf("Template %s: %s: %s", name, strings.ToLower(string(f.severity)), msg)
+			}
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("template %s has invalid shell scripts: %s", name, strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// closers maps the shell keywords opening a compound command to the one
+// closing it.
+var closers = map[string]string{"if": "fi", "case": "esac", "do": "done", "{": "}"}
+
+// heredoc is a here-document whose body starts on the next line.
+type heredoc struct {
+	delim     string
+	stripTabs bool
+	line      int
+}
+
+// shellLexer scans a shell script for unbalanced quotes, substitutions,
+// here-documents and compound commands.  It's not a full parser: it stops at
+// the first error and only recognizes keywords at the start of a command.
+type shellLexer struct {
+	src      string
+	pos      int
+	line     int
+	heredocs []heredoc
+	err      *scriptFinding
+}
+
+func (l *shellLexer) fail(line int, format string, args ...interface{}) {
+	if l.err == nil {
+		l.err = &scriptFinding{ScriptLintSeverityError, line, fmt.Sprintf(format, args...)}
+	}
+}
+
+func (l *shellLexer) done() bool {
+	return l.pos >= len(l.src) || l.err != nil
+}
+
+// skip consumes n bytes, counting lines.
+func (l *shellLexer) skip(n int) {
+	for ; n > 0 && l.pos < len(l.src); n-- {
+		if l.src[l.pos] == '\n' {
+			l.line++
+		}
+		l.pos++
+	}
+}
+
+func isMetachar(c byte) bool {
+	return strings.IndexByte(" \t\n;&|()<>", c) >= 0
+}
+
+func isAssignment(word string) bool {
+	i := strings.IndexByte(word, '=')
+	if i <= 0 {
+		return false
+	}
+	for j, c := range word[:i] {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || j > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// lexCommands scans a list of commands, up to the ')' closing a command
+// substitution opened on startLine if end is ')', or to the end of the script.
+func (l *shellLexer) lexCommands(end byte, startLine int) {
+	type opener struct {
+		keyword string
+		line    int
+	}
+	var stack []opener
+	cmdPos := true
+	parenDepth := 0
+	expectIn, expectFuncName, redirectTarget := false, false, false
+
+	checkClosed := func() {
+		if len(stack) > 0 {
+			top := stack[len(stack)-1]
+			l.fail(top.line, "%q is never closed by %q", top.keyword, closers[top.keyword])
+		}
+	}
+
+	for !l.done() {
+		c := l.src[l.pos]
+		switch {
+		case c == ' ' || c == '\t':
+			l.skip(1)
+		case c == '\n':
+			l.skip(1)
+			l.readHeredocs()
+			cmdPos = true
+		case c == ';' || c == '&' || c == '|':
+			l.skip(1)
+			cmdPos = true
+		case c == '(':
+			l.skip(1)
+			parenDepth++
+			cmdPos = true
+		case c == ')':
+			line := l.line
+			l.skip(1)
+			switch {
+			case parenDepth > 0:
+				parenDepth--
+			case len(stack) > 0 && stack[len(stack)-1].keyword == "case":
+				// the end of a pattern of a case
+			case end == ')':
+				checkClosed()
+				return
+			default:
+				l.fail(line, "unexpected ')'")
+			}
+			cmdPos = true
+		case c == '<' || c == '>':
+			if strings.HasPrefix(l.src[l.pos:], "<<") && !strings.HasPrefix(l.src[l.pos:], "<<<") {
+				l.lexHeredocOperator()
+				continue
+			}
+			for l.pos < len(l.src) && strings.IndexByte("<>&|", l.src[l.pos]) >= 0 {
+				l.skip(1)
+			}
+			redirectTarget = true
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			line := l.line
+			word, literal := l.lexWord()
+			switch {
+			case redirectTarget:
+				redirectTarget = false
+			case expectIn:
+				if literal && word == "in" {
+					expectIn = false
+					cmdPos = true
+				}
+			case expectFuncName:
+				expectFuncName = false
+				cmdPos = true
+			case cmdPos && literal:
+				switch word {
+				case "if", "do", "{":
+					stack = append(stack, opener{word, line})
+				case "case":
+					stack = append(stack, opener{word, line})
+					expectIn = true
+					cmdPos = false
+				case "fi", "esac", "done", "}":
+					if len(stack) == 0 || closers[stack[len(stack)-1].keyword] != word {
+						l.fail(line, "unexpected %q", word)
+						return
+					}
+					stack = stack[:len(stack)-1]
+					cmdPos = false
+				case "then", "else", "elif", "while", "until", "!", "time":
+				case "for", "select":
+					cmdPos = false
+				case "function":
+					expectFuncName = true
+					cmdPos = false
+				default:
+					cmdPos = isAssignment(word)
+				}
+			default:
+				cmdPos = cmdPos && isAssignment(word)
+			}
+		}
+	}
+	if l.err != nil {
+		return
+	}
+	if len(l.heredocs) > 0 {
+		h := l.heredocs[0]
+		l.fail(h.line, "here-document delimited by %q is never closed", h.delim)
+		return
+	}
+	if end == ')' {
+		l.fail(startLine, "unterminated $(")
+		return
+	}
+	checkClosed()
+}
+
+// lexWord scans a word, returning its unquoted text and whether it has no
+// quotes, escapes or expansions, i.e. could be a keyword.
+func (l *shellLexer) lexWord() (string, bool) {
+	var word strings.Builder
+	literal := true
+	for !l.done() {
+		c := l.src[l.pos]
+		if isMetachar(c) {
+			break
+		}
+		switch c {
+		case '\\':
+			literal = false
+			l.skip(2)
+		case '\'':
+			literal = false
+			l.lexSingleQuote()
+		case '"':
+			literal = false
+			l.lexDoubleQuote()
+		case '`':
+			literal = false
+			l.lexBackquote()
+		case '$':
+			literal = false
+			l.lexDollar()
+		default:
+			word.WriteByte(c)
+			l.skip(1)
+		}
+	}
+	return word.String(), literal
+}
+
+func (l *shellLexer) lexSingleQuote() {
+	line := l.line
+	end := strings.IndexByte(l.src[l.pos+1:], '\'')
+	if end < 0 {
+		l.fail(line, "unterminated single quote")
+		return
+	}
+	l.skip(end + 2)
+}
+
+func (l *shellLexer) lexDoubleQuote() {
+	line := l.line
+	l.skip(1)
+	for !l.done() {
+		switch l.src[l.pos] {
+		case '"':
+			l.skip(1)
+			return
+		case '\\':
+			l.skip(2)
+		case '$':
+			l.lexDollar()
+		case '`':
+			l.lexBackquote()
+		default:
+			l.skip(1)
+		}
+	}
+	l.fail(line, "unterminated double quote")
+}
+
+func (l *shellLexer) lexBackquote() {
+	line := l.line
+	l.skip(1)
+	for !l.done() {
+		switch l.src[l.pos] {
+		case '`':
+			l.skip(1)
+			return
+		case '\\':
+			l.skip(2)
+		default:
+			l.skip(1)
+		}
+	}
+	l.fail(line, "unterminated backquote")
+}
+
+// lexDollar scans an expansion starting with $.
+func (l *shellLexer) lexDollar() {
+	line := l.line
+	l.skip(1)
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "(("):
+		l.lexArithmetic(line)
+	case strings.HasPrefix(l.src[l.pos:], "("):
+		l.skip(1)
+		l.lexCommands(')', line)
+	case strings.HasPrefix(l.src[l.pos:], "{"):
+		l.lexParameter(line)
+	case strings.HasPrefix(l.src[l.pos:], "'"):
+		// ANSI-C quoting, where \' doesn't end the string
+		l.skip(1)
+		for !l.done() {
+			switch l.src[l.pos] {
+			case '\'':
+				l.skip(1)
+				return
+			case '\\':
+				l.skip(2)
+			default:
+				l.skip(1)
+			}
+		}
+		l.fail(line, "unterminated $' quote")
+	}
+}
+
+func (l *shellLexer) lexArithmetic(line int) {
+	l.skip(2)
+	depth := 0
+	for !l.done() {
+		switch l.src[l.pos] {
+		case '(':
+			depth++
+			l.skip(1)
+		case ')':
+			l.skip(1)
+			if depth == 0 {
+				if strings.HasPrefix(l.src[l.pos:], ")") {
+					l.skip(1)
+					return
+				}
+				// not arithmetic but a command substitution starting with
+				// a subshell, as in $( (a) b), go on with its commands
+				l.lexCommands(')', line)
+				return
+			}
+			depth--
+		case '$':
+			l.lexDollar()
+		default:
+			l.skip(1)
+		}
+	}
+	l.fail(line, "unterminated $((")
+}
+
+func (l *shellLexer) lexParameter(line int) {
+	l.skip(1)
+	for !l.done() {
+		switch l.src[l.pos] {
+		case '}':
+			l.skip(1)
+			return
+		case '\\':
+			l.skip(2)
+		case '\'':
+			l.lexSingleQuote()
+		case '"':
+			l.lexDoubleQuote()
+		case '`':
+			l.lexBackquote()
+		case '$':
+			l.lexDollar()
+		default:
+			l.skip(1)
+		}
+	}
+	l.fail(line, "unterminated ${")
+}
+
+// lexHeredocOperator scans a << or <<- operator and its delimiter, whose
+// body is read at the end of the line.
+func (l *shellLexer) lexHeredocOperator() {
+	h := heredoc{line: l.line}
+	l.skip(2)
+	if strings.HasPrefix(l.src[l.pos:], "-") {
+		h.stripTabs = true
+		l.skip(1)
+	}
+	for l.pos < len(l.src) && (l.src[l.pos] == ' ' || l.src[l.pos] == '\t') {
+		l.skip(1)
+	}
+	var delim strings.Builder
+	for l.pos < len(l.src) && !isMetachar(l.src[l.pos]) {
+		switch c := l.src[l.pos]; c {
+		case '\'', '"':
+			end := strings.IndexByte(l.src[l.pos+1:], c)
+			if end < 0 {
+				l.fail(h.line, "unterminated quote in here-document delimiter")
+				return
+			}
+			delim.WriteString(l.src[l.pos+1 : l.pos+1+end])
+			l.skip(end + 2)
+		case '\\':
+			l.skip(1)
+		default:
+			delim.WriteByte(c)
+			l.skip(1)
+		}
+	}
+	h.delim = delim.String()
+	if h.delim == "" {
+		l.fail(h.line, "missing here-document delimiter")
+		return
+	}
+	l.heredocs = append(l.heredocs, h)
+}
+
+// readHeredocs skips the bodies of the here-documents of the previous line.
+func (l *shellLexer) readHeredocs() {
+	for len(l.heredocs) > 0 {
+		h := l.heredocs[0]
+		for {
+			if l.pos >= len(l.src) {
+				l.fail(h.line, "here-document delimited by %q is never closed", h.delim)
+				return
+			}
+			end := strings.IndexByte(l.src[l.pos:], '\n')
+			if end < 0 {
+				end = len(l.src) - l.pos
+			}
+			text := l.src[l.pos : l.pos+end]
+			l.skip(end + 1)
+			if h.stripTabs {
+				text = strings.TrimLeft(text, "\t")
+			}
+			if text == h.delim {
+				break
+			}
+		}
+		l.heredocs = l.heredocs[1:]
+	}
+}
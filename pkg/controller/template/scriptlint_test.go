@@ -0,0 +1,174 @@
+package template
+
+import (
+	"context"
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+func TestIsShellScript(t *testing.T) {
+	cases := []struct {
+		path     string
+		contents string
+		script   bool
+		shebang  bool
+	}{
+		{"/usr/local/bin/foo.sh", "#!/bin/bash\necho", true, true},
+		{"/etc/foo", "#!/usr/bin/env bash\necho", true, true},
+		{"/etc/foo", "#!/usr/bin/env -S sh -e\necho", true, true},
+		{"/usr/local/bin/foo.py", "#!/usr/bin/python3\nprint()", false, true},
+		{"/usr/local/bin/foo", "echo", true, false},
+		{"/usr/local/sbin/foo", "echo", true, false},
+		{"/etc/foo.conf", "foo=bar", false, false},
+	}
+	for _, tc := range cases {
+		script, shebang := isShellScript(tc.path, []byte(tc.contents))
+		assert.Equal(t, tc.script, script, tc.path)
+		assert.Equal(t, tc.shebang, shebang, tc.path)
+	}
+}
+
+func TestLintScript(t *testing.T) {
+	valid := []string{
+		"echo 'it''s' \"a \\\"b\\\" $(date +%s) ${HOME:-/} `id -u`\" $'\\'' $((1 + (2 * 3)))",
+		"if [ -f /etc/foo ]; then\n  echo yes # it's there\nelif true; then :; else\n  echo no\nfi",
+		"case \"$1\" in\n  start|stop) echo \"$1\" ;;\n  (restart) echo; ;;\n  *) exit 1 ;;\nesac",
+		"x=$(case $1 in a) echo a ;; esac)",
+		"for i in 1 2 done fi; do echo $i; done < /dev/null",
+		"while read -r line; do\n  { echo \"$line\"; } >&2\ndone",
+		"f() {\n  local a=(1 2)\n  (cd / && ls) | wc -l\n}\nfunction g {\n  f 2>&1\n}",
+		"cat <<EOF > /tmp/foo\nunbalanced ' quote \"\nif\nEOF\ncat <<-'END'\n\t$(not expanded\n\tEND\necho done",
+		"echo ${#} $# ${#array[@]} \"${x//\\}/y}\"",
+		"[[ $x =~ ^(a|b)$ ]] && echo }",
+	}
+	for _, script := range valid {
+		for _, f := range lintScript([]byte(script), true) {
+			t.Errorf("unexpected finding in %q: line %d: %s", script, f.line, f.message)
+		}
+	}
+
+	invalid := []struct {
+		script string
+		line   int
+	}{
+		{"echo 'unclosed\necho", 1},
+		{"echo ok\necho \"unclosed", 2},
+		{"echo `id", 1},
+		{"echo $(date", 1},
+		{"echo ${HOME", 1},
+		{"echo $((1 + 2)", 1},
+		{"echo $(echo \"a)", 1},
+		{"if true; then\n  echo\n", 1},
+		{"if true; then echo; done", 1},
+		{"case $1 in\n  a) echo ;;\n", 1},
+		{"for i in 1; do echo; fi", 1},
+		{"echo }\n}", 2},
+		{"echo )", 1},
+		{"cat <<EOF\nfoo\nEOFX", 1},
+		{"cat <<", 1},
+	}
+	for _, tc := range invalid {
+		findings := lintScript([]byte(tc.script), true)
+		if assert.Len(t, findings, 1, tc.script) {
+			assert.Equal(t, ScriptLintSeverityError, findings[0].severity, tc.script)
+			assert.Equal(t, tc.line, findings[0].line, "%s: %s", tc.script, findings[0].message)
+		}
+	}
+
+	findings := lintScript([]byte("echo <no value>\r\necho"), false)
+	require.Len(t, findings, 3)
+	for _, f := range findings {
+		assert.Equal(t, ScriptLintSeverityWarning, f.severity)
+	}
+}
+
+func TestLintScripts(t *testing.T) {
+	cases := []struct {
+		name        string
+		file        ign3types.File
+		severity    ScriptLintSeverity
+		expectError bool
+	}{
+		{
+			name: "valid script",
+			file: newFile("/usr/local/bin/foo.sh", 0755, "#!/bin/bash\necho foo\n"),
+		},
+		{
+			name:        "syntax error",
+			file:        newFile("/usr/local/bin/foo.sh", 0755, "#!/bin/bash\necho 'foo\n"),
+			expectError: true,
+		},
+		{
+			name:     "syntax error with policy None",
+			file:     newFile("/usr/local/bin/foo.sh", 0755, "#!/bin/bash\necho 'foo\n"),
+			severity: ScriptLintSeverityNone,
+		},
+		{
+			name: "warning",
+			file: newFile("/usr/local/bin/foo", 0755, "echo foo\n"),
+		},
+		{
+			name:        "warning with policy Warning",
+			file:        newFile("/usr/local/bin/foo", 0755, "echo foo\n"),
+			severity:    ScriptLintSeverityWarning,
+			expectError: true,
+		},
+		{
+			name: "not a shell script",
+			file: newFile("/usr/local/bin/foo.py", 0755, "#!/usr/bin/python3\nprint('foo\n"),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &RenderConfig{ScriptLintSeverity: tc.severity}
+			ignCfg := ctrlcommon.NewIgnConfig()
+			ignCfg.Storage.Files = []ign3types.File{tc.file}
+			err := lintScripts(config, "00-worker", &ignCfg)
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestScriptLintSeverityFromAnnotations(t *testing.T) {
+	severity, err := scriptLintSeverityFromAnnotations(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, ScriptLintSeverityError, severity)
+
+	severity, err = scriptLintSeverityFromAnnotations(map[string]string{ctrlcommon.ScriptLintSeverityAnnotationKey: "None"})
+	assert.NoError(t, err)
+	assert.Equal(t, ScriptLintSeverityNone, severity)
+
+	_, err = scriptLintSeverityFromAnnotations(map[string]string{ctrlcommon.ScriptLintSeverityAnnotationKey: "Info"})
+	assert.Error(t, err)
+}
+
+// TestShippedScripts checks the scripts of the templates don't even have
+// warnings, on every platform.
+func TestShippedScripts(t *testing.T) {
+	// the images referenced by the templates, which the test configs lack
+	images := []string{"apiServerWatcherKey", "baremetalRuntimeCfgImage", "corednsImage", "haproxyImage", "infraImageKey", "keepalivedImage", "machineConfigOperator"}
+	for test, config := range configs {
+		controllerConfig, err := controllerConfigFromFile(config)
+		require.NoError(t, err, test)
+		if controllerConfig.Spec.Images == nil {
+			controllerConfig.Spec.Images = map[string]string{}
+		}
+		for _, image := range images {
+			if controllerConfig.Spec.Images[image] == "" {
+				controllerConfig.Spec.Images[image] = "image/" + image + ":1"
+			}
+		}
+		rc := &RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`, ScriptLintSeverity: ScriptLintSeverityWarning}
+		_, err = generateTemplateMachineConfigs(context.TODO(), rc, templateDir, nil)
+		assert.NoError(t, err, test)
+	}
+}
@@ -0,0 +1,92 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/golang/glog"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// SecretScanPolicy decides what happens when a rendered template writes secret
+// looking contents to a world-readable file.
+type SecretScanPolicy string
+
+const (
+	// SecretScanPolicyFail makes rendering fail.
+	SecretScanPolicyFail SecretScanPolicy = "Fail"
+	// SecretScanPolicyWarn only logs a warning.
+	SecretScanPolicyWarn SecretScanPolicy = "Warn"
+
+	// worldReadable is the permission bit for other users to read a file
+	worldReadable = 0004
+)
+
+var (
+	// privateKeyPattern matches PEM encoded private keys of any type, e.g. "RSA PRIVATE KEY" or "ENCRYPTED PRIVATE KEY"
+	privateKeyPattern = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----`)
+	// pullSecretPattern matches docker config JSON with inline credentials
+	pullSecretPattern = regexp.MustCompile(`"auths"\s*:\s*\{[^}]*"auth"\s*:\s*"[^"]+"`)
+)
+
+// secretScanPolicyFromAnnotations returns the policy configured on the ControllerConfig, defaulting to Fail.
+func secretScanPolicyFromAnnotations(annotations map[string]string) (SecretScanPolicy, error) {
+	switch policy := SecretScanPolicy(annotations[ctrlcommon.SecretScanPolicyAnnotationKey]); policy {
+	case "":
+		return SecretScanPolicyFail, nil
+	case SecretScanPolicyFail, SecretScanPolicyWarn:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid %s annotation %q, must be %q or %q", ctrlcommon.SecretScanPolicyAnnotationKey, policy, SecretScanPolicyFail, SecretScanPolicyWarn)
+	}
+}
+
+// scanForSecrets flags files of the rendered config that are readable by
+// everyone yet contain a private key, a pull secret, or the cluster's own pull
+// secret.  This catches template mistakes before they end up on every node.
+func scanForSecrets(config *RenderConfig, name string, ignCfg *ign3types.Config) error {
+	var findings []string
+	for _, file := range ignCfg.Storage.Files {
+		mode := 0644
+		if file.Mode != nil {
+			mode = *file.Mode
+		}
+		if mode&worldReadable == 0 {
+			continue
+		}
+		contents, err := ctrlcommon.DecodeIgnitionFileContents(file.Contents.Source, file.Contents.Compression)
+		if err != nil {
+			return fmt.Errorf("could not decode file %q: %w", file.Path, err)
+		}
+		if kind := secretKind(config, contents); kind != "" {
+			findings = append(findings, fmt.Sprintf("%s (mode %#o) contains a %s", file.Path, mode, kind))
+		}
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	err := fmt.Errorf("template %s writes secrets to world-readable files: %s", name, strings.Join(findings, "; "))
+	if config.SecretScanPolicy == SecretScanPolicyWarn {
+		glog.Warningf("%v", err)
+		return nil
+	}
+	return err
+}
+
+// secretKind returns a description of the secret found in contents, or "" if there's none.
+func secretKind(config *RenderConfig, contents []byte) string {
+	switch {
+	case privateKeyPattern.Match(contents):
+		return "private key"
+	case config.PullSecret != "" && config.PullSecret != "{}" && bytes.Contains(contents, []byte(config.PullSecret)):
+		return "the cluster pull secret"
+	case pullSecretPattern.Match(contents):
+		return "pull secret"
+	}
+	return ""
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
@@ -16,6 +17,7 @@ import (
 	oselistersv1 "github.com/openshift/client-go/config/listers/config/v1"
 	mcoResourceApply "github.com/openshift/machine-config-operator/lib/resourceapply"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/constants"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
 	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
@@ -25,11 +27,14 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	coreinformersv1 "k8s.io/client-go/informers/core/v1"
 	clientset "k8s.io/client-go/kubernetes"
 	corev1clientset "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
@@ -61,13 +66,32 @@ type Controller struct {
 	ccLister   mcfglistersv1.ControllerConfigLister
 	mcLister   mcfglistersv1.MachineConfigLister
 	featLister oselistersv1.FeatureGateLister
+	cmLister   corelisterv1.ConfigMapLister
+	mcpLister  mcfglistersv1.MachineConfigPoolLister
 
 	ccListerSynced        cache.InformerSynced
 	mcListerSynced        cache.InformerSynced
 	secretsInformerSynced cache.InformerSynced
 	featListerSynced      cache.InformerSynced
+	cmListerSynced        cache.InformerSynced
+	mcpListerSynced       cache.InformerSynced
 
 	queue workqueue.RateLimitingInterface
+
+	// renderCache holds the last rendered MachineConfigs, reused while
+	// neither the templates nor the RenderConfig change.
+	renderCache renderCache
+
+	// renders are the renders of ControllerConfigs in progress, by key, which
+	// a newer generation of their ControllerConfig cancels.
+	rendersLock sync.Mutex
+	renders     map[string]*controllerConfigRender
+}
+
+// controllerConfigRender is the render of a generation of a ControllerConfig.
+type controllerConfigRender struct {
+	generation int64
+	cancel     context.CancelFunc
 }
 
 // New returns a new template controller.
@@ -77,6 +101,8 @@ func New(
 	mcInformer mcfginformersv1.MachineConfigInformer,
 	secretsInformer coreinformersv1.SecretInformer,
 	featureInformer oseinformersv1.FeatureGateInformer,
+	configMapInformer coreinformersv1.ConfigMapInformer,
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
 	kubeClient clientset.Interface,
 	mcfgClient mcfgclientset.Interface,
 ) *Controller {
@@ -89,7 +115,7 @@ func New(
 		client:        mcfgClient,
 		kubeClient:    kubeClient,
 		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-templatecontroller"}),
-		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-templatecontroller"),
+		queue:         workqueue.NewNamedRateLimitingQueue(ctrlcommon.ControllerRateLimiter(), "machineconfigcontroller-templatecontroller"),
 	}
 
 	ccInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -117,16 +143,35 @@ func New(
 		DeleteFunc: ctrl.deleteFeature,
 	})
 
+	configMapInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isTemplateConfigMap,
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    ctrl.addConfigMap,
+			UpdateFunc: ctrl.updateConfigMap,
+			DeleteFunc: ctrl.deleteConfigMap,
+		},
+	})
+
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addMachineConfigPool,
+		UpdateFunc: ctrl.updateMachineConfigPool,
+		DeleteFunc: ctrl.deleteMachineConfigPool,
+	})
+
 	ctrl.syncHandler = ctrl.syncControllerConfig
 	ctrl.enqueueControllerConfig = ctrl.enqueue
 
 	ctrl.ccLister = ccInformer.Lister()
 	ctrl.mcLister = mcInformer.Lister()
 	ctrl.featLister = featureInformer.Lister()
+	ctrl.cmLister = configMapInformer.Lister()
+	ctrl.mcpLister = mcpInformer.Lister()
 	ctrl.ccListerSynced = ccInformer.Informer().HasSynced
 	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
 	ctrl.secretsInformerSynced = secretsInformer.Informer().HasSynced
 	ctrl.featListerSynced = featureInformer.Informer().HasSynced
+	ctrl.cmListerSynced = configMapInformer.Informer().HasSynced
+	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
 
 	return ctrl
 }
@@ -226,12 +271,109 @@ func (ctrl *Controller) deleteFeature(obj interface{}) {
 	ctrl.enqueueController()
 }
 
+// isTemplateConfigMap filters the ConfigMaps changing the templates: the
+// images ConfigMap, which may override the template constants, the overlays
+// ConfigMap and the zone VIPs ConfigMap.
+func isTemplateConfigMap(obj interface{}) bool {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	cm, ok := obj.(*corev1.ConfigMap)
+	return ok && cm.Namespace == ctrlcommon.MCONamespace && (cm.Name == ImagesConfigMapName || cm.Name == TemplateOverlaysConfigMapName || cm.Name == ZoneVIPsConfigMapName)
+}
+
+func (ctrl *Controller) addConfigMap(obj interface{}) {
+	cm := obj.(*corev1.ConfigMap)
+	glog.V(4).Infof("Adding ConfigMap %s", cm.Name)
+	ctrl.enqueueController()
+}
+
+func (ctrl *Controller) updateConfigMap(old, cur interface{}) {
+	oldCM := old.(*corev1.ConfigMap)
+	curCM := cur.(*corev1.ConfigMap)
+	if curCM.Name == TemplateOverlaysConfigMapName || curCM.Name == ZoneVIPsConfigMapName {
+		if !reflect.DeepEqual(oldCM.Data, curCM.Data) {
+			glog.V(4).Infof("ConfigMap %s changed", curCM.Name)
+			ctrl.enqueueController()
+		}
+		return
+	}
+	if oldCM.Data[ConstantsConfigMapKey] != curCM.Data[ConstantsConfigMapKey] {
+		glog.V(4).Infof("Template constants of ConfigMap %s changed", ImagesConfigMapName)
+		ctrl.enqueueController()
+	}
+}
+
+func (ctrl *Controller) deleteConfigMap(obj interface{}) {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		cm, ok = tombstone.Obj.(*corev1.ConfigMap)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a ConfigMap %#v", obj))
+			return
+		}
+	}
+	glog.V(4).Infof("Deleting ConfigMap %s", cm.Name)
+	ctrl.enqueueController()
+}
+
+// The template MachineConfigs are rendered with the template variables, time
+// sync sources and infra templates opt-ins of the pools, so a pool is only of
+// interest if it has some.
+
+func (ctrl *Controller) addMachineConfigPool(obj interface{}) {
+	pool := obj.(*mcfgv1.MachineConfigPool)
+	if hasPoolTemplateAnnotations(pool) {
+		glog.V(4).Infof("Adding MachineConfigPool %s with template annotations", pool.Name)
+		ctrl.enqueueController()
+	}
+}
+
+func (ctrl *Controller) updateMachineConfigPool(old, cur interface{}) {
+	oldPool := old.(*mcfgv1.MachineConfigPool)
+	curPool := cur.(*mcfgv1.MachineConfigPool)
+	for _, key := range []string{ctrlcommon.TemplateVarsAnnotationKey, ctrlcommon.TimeSyncSourceAnnotationKey, ctrlcommon.InfraTemplatesAnnotationKey} {
+		oldValue, oldOK := oldPool.Annotations[key]
+		curValue, curOK := curPool.Annotations[key]
+		if oldOK != curOK || oldValue != curValue {
+			glog.V(4).Infof("Annotation %s of MachineConfigPool %s changed", key, curPool.Name)
+			ctrl.enqueueController()
+			return
+		}
+	}
+}
+
+func (ctrl *Controller) deleteMachineConfigPool(obj interface{}) {
+	pool, ok := obj.(*mcfgv1.MachineConfigPool)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		pool, ok = tombstone.Obj.(*mcfgv1.MachineConfigPool)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a MachineConfigPool %#v", obj))
+			return
+		}
+	}
+	if hasPoolTemplateAnnotations(pool) {
+		glog.V(4).Infof("Deleting MachineConfigPool %s with template annotations", pool.Name)
+		ctrl.enqueueController()
+	}
+}
+
 // Run executes the template controller
 func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 	defer ctrl.queue.ShutDown()
 
-	if !cache.WaitForCacheSync(stopCh, ctrl.ccListerSynced, ctrl.mcListerSynced, ctrl.secretsInformerSynced, ctrl.featListerSynced) {
+	if !cache.WaitForCacheSync(stopCh, ctrl.ccListerSynced, ctrl.mcListerSynced, ctrl.secretsInformerSynced, ctrl.featListerSynced, ctrl.cmListerSynced, ctrl.mcpListerSynced) {
 		return
 	}
 
@@ -255,9 +397,50 @@ func (ctrl *Controller) updateControllerConfig(old, cur interface{}) {
 	oldCfg := old.(*mcfgv1.ControllerConfig)
 	curCfg := cur.(*mcfgv1.ControllerConfig)
 	glog.V(4).Infof("Updating ControllerConfig %s", oldCfg.Name)
+	ctrl.cancelStaleRender(curCfg)
 	ctrl.enqueueControllerConfig(curCfg)
 }
 
+// startRender registers the render of the generation of the ControllerConfig
+// with the given key, returning a context which a newer generation cancels,
+// and a function to call once the render is done.
+func (ctrl *Controller) startRender(key string, generation int64) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	render := &controllerConfigRender{generation: generation, cancel: cancel}
+
+	ctrl.rendersLock.Lock()
+	defer ctrl.rendersLock.Unlock()
+	if ctrl.renders == nil {
+		ctrl.renders = map[string]*controllerConfigRender{}
+	}
+	ctrl.renders[key] = render
+	return ctx, func() {
+		cancel()
+		ctrl.rendersLock.Lock()
+		defer ctrl.rendersLock.Unlock()
+		if ctrl.renders[key] == render {
+			delete(ctrl.renders, key)
+		}
+	}
+}
+
+// cancelStaleRender cancels the render in progress of an older generation of
+// cfg.  The sync rendering it stops without applying its MachineConfigs, and
+// the workqueue syncs cfg again with the latest inputs, instead of applying
+// MachineConfigs about to be replaced.
+func (ctrl *Controller) cancelStaleRender(cfg *mcfgv1.ControllerConfig) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(cfg)
+	if err != nil {
+		return
+	}
+	ctrl.rendersLock.Lock()
+	defer ctrl.rendersLock.Unlock()
+	if render, ok := ctrl.renders[key]; ok && render.generation < cfg.GetGeneration() {
+		glog.Infof("ControllerConfig %s changed to generation %d, cancelling the render of generation %d", cfg.Name, cfg.GetGeneration(), render.generation)
+		render.cancel()
+	}
+}
+
 func (ctrl *Controller) deleteControllerConfig(obj interface{}) {
 	cfg, ok := obj.(*mcfgv1.ControllerConfig)
 	if !ok {
@@ -480,11 +663,44 @@ func (ctrl *Controller) syncControllerConfig(key string) error {
 		glog.V(2).Infof("%v", err)
 		return ctrl.syncFailingStatus(cfg, err)
 	}
-	mcs, err := getMachineConfigsForControllerConfig(ctrl.templatesDir, cfg, pullSecretRaw, fg)
+	if err := ctrl.syncTemplateConstants(); err != nil {
+		return ctrl.syncFailingStatus(cfg, err)
+	}
+	overlays, err := ctrl.getTemplateOverlays()
+	if err != nil {
+		return ctrl.syncFailingStatus(cfg, err)
+	}
+	zoneVIPs, err := ctrl.getZoneVIPs()
+	if err != nil {
+		return ctrl.syncFailingStatus(cfg, err)
+	}
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return ctrl.syncFailingStatus(cfg, err)
+	}
+	poolConfigs, err := poolTemplateConfigs(pools)
 	if err != nil {
 		return ctrl.syncFailingStatus(cfg, err)
 	}
+	ctx, done := ctrl.startRender(key, cfg.GetGeneration())
+	defer done()
+	mcs, err := ctrl.renderCache.getMachineConfigs(ctx, ctrl.templatesDir, cfg, pullSecretRaw, fg, overlays, zoneVIPs, poolConfigs)
+	if ctx.Err() != nil {
+		// requeued by the newer generation
+		glog.V(2).Infof("Render of generation %d of ControllerConfig %s cancelled", cfg.GetGeneration(), key)
+		return nil
+	}
+	if err != nil {
+		return ctrl.syncFailingStatus(cfg, &ctrlcommon.RenderError{Err: err})
+	}
 
+	// Once the first MachineConfig is applied the whole set is, a cancellation
+	// past this point would leave the render controller with MachineConfigs
+	// of two generations to merge.
+	if ctx.Err() != nil {
+		glog.V(2).Infof("Render of generation %d of ControllerConfig %s cancelled before applying its MachineConfigs", cfg.GetGeneration(), key)
+		return nil
+	}
 	for _, mc := range mcs {
 		_, updated, err := mcoResourceApply.ApplyMachineConfig(ctrl.client.MachineconfigurationV1(), mc)
 		if err != nil {
@@ -494,21 +710,86 @@ func (ctrl *Controller) syncControllerConfig(key string) error {
 			glog.V(4).Infof("Machineconfig %s was updated", mc.Name)
 		}
 	}
+	if err := ctrl.deleteStaleInfraMachineConfigs(cfg, mcs); err != nil {
+		return ctrl.syncFailingStatus(cfg, err)
+	}
 
 	return ctrl.syncCompletedStatus(cfg)
 }
 
-func getMachineConfigsForControllerConfig(templatesDir string, config *mcfgv1.ControllerConfig, pullSecretRaw []byte, featureGate *configv1.FeatureGate) ([]*mcfgv1.MachineConfig, error) {
+// deleteStaleInfraMachineConfigs deletes the infra MachineConfigs of cfg which
+// weren't rendered, left over from an infra pool that opted out of the infra
+// templates.  The other roles are always rendered.
+func (ctrl *Controller) deleteStaleInfraMachineConfigs(cfg *mcfgv1.ControllerConfig, rendered []*mcfgv1.MachineConfig) error {
+	selector := labels.SelectorFromSet(labels.Set{mcfgv1.MachineConfigRoleLabelKey: infraRole})
+	existing, err := ctrl.mcLister.List(selector)
+	if err != nil {
+		return err
+	}
+	names := sets.NewString()
+	for _, mc := range rendered {
+		names.Insert(mc.Name)
+	}
+	for _, mc := range existing {
+		if names.Has(mc.Name) || !metav1.IsControlledBy(mc, cfg) {
+			continue
+		}
+		if err := ctrl.client.MachineconfigurationV1().MachineConfigs().Delete(context.TODO(), mc.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		glog.Infof("Deleted MachineConfig %s, its pool doesn't opt in to the infra templates", mc.Name)
+	}
+	return nil
+}
+
+func getMachineConfigsForControllerConfig(templatesDir string, config *mcfgv1.ControllerConfig, pullSecretRaw []byte, featureGate *configv1.FeatureGate, overlays []TemplateOverlay, zoneVIPs []ZoneVIPs, poolConfigs map[string]poolTemplateConfig) ([]*mcfgv1.MachineConfig, error) {
+	rc, err := newRenderConfig(config, pullSecretRaw, featureGate, overlays, zoneVIPs)
+	if err != nil {
+		return nil, err
+	}
+	return renderMachineConfigs(context.TODO(), templatesDir, config, rc, poolConfigs)
+}
+
+// newRenderConfig returns the RenderConfig the templates of config are
+// rendered with.
+func newRenderConfig(config *mcfgv1.ControllerConfig, pullSecretRaw []byte, featureGate *configv1.FeatureGate, overlays []TemplateOverlay, zoneVIPs []ZoneVIPs) (*RenderConfig, error) {
 	buf := &bytes.Buffer{}
 	if err := json.Compact(buf, pullSecretRaw); err != nil {
 		return nil, fmt.Errorf("couldn't compact pullsecret %q: %v", string(pullSecretRaw), err)
 	}
-	rc := &RenderConfig{
+	secretScanPolicy, err := secretScanPolicyFromAnnotations(config.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	scriptLintSeverity, err := scriptLintSeverityFromAnnotations(config.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	strictTemplates, err := strictTemplatesFromAnnotations(config.Annotations, false)
+	if err != nil {
+		return nil, err
+	}
+	timeSyncSource, err := timeSyncSourceFromAnnotations(config.Annotations, TimeSyncSourceNTP)
+	if err != nil {
+		return nil, err
+	}
+	return &RenderConfig{
 		ControllerConfigSpec: &config.Spec,
 		PullSecret:           string(buf.Bytes()),
 		FeatureGate:          featureGate,
-	}
-	mcs, err := generateTemplateMachineConfigs(rc, templatesDir)
+		SecretScanPolicy:     secretScanPolicy,
+		ScriptLintSeverity:   scriptLintSeverity,
+		StrictTemplates:      strictTemplates,
+		Overlays:             overlays,
+		ZoneVIPs:             zoneVIPs,
+		TimeSyncSource:       timeSyncSource,
+	}, nil
+}
+
+// renderMachineConfigs renders the template MachineConfigs of config with rc
+// and the template variables of the pools, owned by config and sorted by name.
+func renderMachineConfigs(ctx context.Context, templatesDir string, config *mcfgv1.ControllerConfig, rc *RenderConfig, poolConfigs map[string]poolTemplateConfig) ([]*mcfgv1.MachineConfig, error) {
+	mcs, err := generateTemplateMachineConfigs(ctx, rc, templatesDir, poolConfigs)
 	if err != nil {
 		return nil, err
 	}
@@ -522,7 +803,89 @@ func getMachineConfigsForControllerConfig(templatesDir string, config *mcfgv1.Co
 	return mcs, nil
 }
 
+// syncTemplateConstants loads the overrides of the template constants from
+// the images ConfigMap.  Invalid overrides fail rendering rather than being
+// ignored, so a mistake can't silently render the built-in values instead.
+func (ctrl *Controller) syncTemplateConstants() error {
+	var overrides map[string]string
+	cm, err := ctrl.cmLister.ConfigMaps(ctrlcommon.MCONamespace).Get(ImagesConfigMapName)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && cm.Data[ConstantsConfigMapKey] != "" {
+		overrides, err = constants.ParseConstantOverrides([]byte(cm.Data[ConstantsConfigMapKey]))
+		if err != nil {
+			return fmt.Errorf("invalid %s of ConfigMap %s: %w", ConstantsConfigMapKey, ImagesConfigMapName, err)
+		}
+	}
+	if constants.SetConstantOverrides(overrides) {
+		glog.Infof("Template constants overridden by ConfigMap %s: %v", ImagesConfigMapName, overrides)
+	}
+	return nil
+}
+
+// getTemplateOverlays loads the templates the cluster admin adds through the
+// overlays ConfigMap.  Invalid overlays fail rendering, like invalid built-in
+// templates do.
+func (ctrl *Controller) getTemplateOverlays() ([]TemplateOverlay, error) {
+	cm, err := ctrl.cmLister.ConfigMaps(ctrlcommon.MCONamespace).Get(TemplateOverlaysConfigMapName)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	overlays, err := ParseTemplateOverlays(cm.Data, ctrl.templatesDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ConfigMap %s: %w", TemplateOverlaysConfigMapName, err)
+	}
+	return overlays, nil
+}
+
+// getZoneVIPs loads the VIPs of the availability zones from the zone VIPs
+// ConfigMap.  Invalid zones fail rendering, so the nodes keep their VIPs.
+func (ctrl *Controller) getZoneVIPs() ([]ZoneVIPs, error) {
+	cm, err := ctrl.cmLister.ConfigMaps(ctrlcommon.MCONamespace).Get(ZoneVIPsConfigMapName)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	zoneVIPs, err := ParseZoneVIPs([]byte(cm.Data[ZoneVIPsConfigMapKey]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s of ConfigMap %s: %w", ZoneVIPsConfigMapKey, ZoneVIPsConfigMapName, err)
+	}
+	return zoneVIPs, nil
+}
+
+// RunRender renders the template MachineConfigs of config with the overlays,
+// zone VIPs and template variables of the pools like the controller does, for
+// the offline render command.  Unlike RunBootstrap, templates are only strict
+// if the ControllerConfig asks for it.
+func RunRender(templatesDir string, config *mcfgv1.ControllerConfig, pullSecretRaw []byte, featureGate *configv1.FeatureGate, overlays []TemplateOverlay, zoneVIPs []ZoneVIPs, pools []*mcfgv1.MachineConfigPool) ([]*mcfgv1.MachineConfig, error) {
+	poolConfigs, err := poolTemplateConfigs(pools)
+	if err != nil {
+		return nil, err
+	}
+	return getMachineConfigsForControllerConfig(templatesDir, config, pullSecretRaw, featureGate, overlays, zoneVIPs, poolConfigs)
+}
+
 // RunBootstrap runs the tempate controller in boostrap mode.
-func RunBootstrap(templatesDir string, config *mcfgv1.ControllerConfig, pullSecretRaw []byte, featureGate *configv1.FeatureGate) ([]*mcfgv1.MachineConfig, error) {
-	return getMachineConfigsForControllerConfig(templatesDir, config, pullSecretRaw, featureGate)
+func RunBootstrap(templatesDir string, config *mcfgv1.ControllerConfig, pullSecretRaw []byte, featureGate *configv1.FeatureGate, pools []*mcfgv1.MachineConfigPool) ([]*mcfgv1.MachineConfig, error) {
+	// Bootstrap rendering is strict unless the ControllerConfig opts out, so
+	// that a template referencing a missing key fails the installation
+	// instead of landing on the nodes.
+	if _, ok := config.Annotations[ctrlcommon.StrictTemplatesAnnotationKey]; !ok {
+		config = config.DeepCopy()
+		if config.Annotations == nil {
+			config.Annotations = map[string]string{}
+		}
+		config.Annotations[ctrlcommon.StrictTemplatesAnnotationKey] = "true"
+	}
+	poolConfigs, err := poolTemplateConfigs(pools)
+	if err != nil {
+		return nil, err
+	}
+	return getMachineConfigsForControllerConfig(templatesDir, config, pullSecretRaw, featureGate, nil, nil, poolConfigs)
 }
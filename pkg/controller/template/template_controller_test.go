@@ -23,11 +23,13 @@ import (
 	"k8s.io/apimachinery/pkg/util/rand"
 	coreinformersv1 "k8s.io/client-go/informers"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
 	core "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/constants"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
 	informers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
@@ -48,6 +50,7 @@ type fixture struct {
 	ccLister   []*mcfgv1.ControllerConfig
 	mcLister   []*mcfgv1.MachineConfig
 	featLister []*osev1.FeatureGate
+	mcpLister  []*mcfgv1.MachineConfigPool
 
 	kubeactions []core.Action
 	actions     []core.Action
@@ -109,11 +112,13 @@ func (f *fixture) newController() *Controller {
 	i := informers.NewSharedInformerFactory(f.client, noResyncPeriodFunc())
 	c := New(templateDir,
 		i.Machineconfiguration().V1().ControllerConfigs(), i.Machineconfiguration().V1().MachineConfigs(), cinformer.Core().V1().Secrets(), featinformer.Config().V1().FeatureGates(),
-		f.kubeclient, f.client)
+		cinformer.Core().V1().ConfigMaps(), i.Machineconfiguration().V1().MachineConfigPools(), f.kubeclient, f.client)
 
 	c.ccListerSynced = alwaysReady
 	c.mcListerSynced = alwaysReady
 	c.featListerSynced = alwaysReady
+	c.cmListerSynced = alwaysReady
+	c.mcpListerSynced = alwaysReady
 	c.eventRecorder = &record.FakeRecorder{}
 
 	stopCh := make(chan struct{})
@@ -133,6 +138,10 @@ func (f *fixture) newController() *Controller {
 		featinformer.Config().V1().FeatureGates().Informer().GetIndexer().Add(c)
 	}
 
+	for _, p := range f.mcpLister {
+		i.Machineconfiguration().V1().MachineConfigPools().Informer().GetIndexer().Add(p)
+	}
+
 	return c
 }
 
@@ -251,7 +260,9 @@ func filterInformerActions(actions []core.Action) []core.Action {
 			(action.Matches("list", "controllerconfigs") ||
 				action.Matches("watch", "controllerconfigs") ||
 				action.Matches("list", "machineconfigs") ||
-				action.Matches("watch", "machineconfigs")) {
+				action.Matches("watch", "machineconfigs") ||
+				action.Matches("list", "machineconfigpools") ||
+				action.Matches("watch", "machineconfigpools")) {
 			continue
 		}
 		ret = append(ret, action)
@@ -304,7 +315,50 @@ func TestCreatesMachineConfigs(t *testing.T) {
 	f.objects = append(f.objects, cc)
 	f.kubeobjects = append(f.kubeobjects, ps)
 
-	expMCs, err := getMachineConfigsForControllerConfig(templateDir, cc, []byte(`{"dummy": "dummy"}`), nil)
+	expMCs, err := getMachineConfigsForControllerConfig(templateDir, cc, []byte(`{"dummy": "dummy"}`), nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rcc := cc.DeepCopy()
+	rcc.Status.ObservedGeneration = 1
+	rcc.Status.Conditions = []mcfgv1.ControllerConfigStatusCondition{{Type: mcfgv1.TemplateControllerRunning, Status: corev1.ConditionTrue, Message: "syncing towards (1) generation using controller version v0.0.0-was-not-built-properly"}}
+	f.expectUpdateControllerConfigStatus(rcc)
+	f.expectGetSecretAction(ps)
+
+	for idx := range expMCs {
+		f.expectGetMachineConfigAction(expMCs[idx])
+		f.expectCreateMachineConfigAction(expMCs[idx])
+	}
+	ccc := cc.DeepCopy()
+	ccc.Status.ObservedGeneration = 1
+	ccc.Status.Conditions = []mcfgv1.ControllerConfigStatusCondition{
+		{Type: mcfgv1.TemplateControllerCompleted, Status: corev1.ConditionTrue, Message: "sync completed towards (1) generation using controller version v0.0.0-was-not-built-properly"},
+		{Type: mcfgv1.TemplateControllerRunning, Status: corev1.ConditionFalse},
+		{Type: mcfgv1.TemplateControllerFailing, Status: corev1.ConditionFalse},
+	}
+	f.expectUpdateControllerConfigStatus(ccc)
+
+	f.run(getKey(cc, t))
+}
+
+func TestCreatesMachineConfigsWithPoolVars(t *testing.T) {
+	f := newFixture(t)
+	cc := newControllerConfig("test-cluster")
+	ps := newPullSecret("coreos-pull-secret", []byte(`{"dummy": "dummy"}`))
+	pool := &mcfgv1.MachineConfigPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "worker",
+			Annotations: map[string]string{ctrlcommon.TemplateVarsAnnotationKey: `{"systemReservedCPU": "1000m"}`},
+		},
+	}
+
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, pool)
+	f.objects = append(f.objects, cc)
+	f.kubeobjects = append(f.kubeobjects, ps)
+
+	poolConfigs := map[string]poolTemplateConfig{"worker": {Vars: map[string]string{"systemReservedCPU": "1000m"}}}
+	expMCs, err := getMachineConfigsForControllerConfig(templateDir, cc, []byte(`{"dummy": "dummy"}`), nil, nil, nil, poolConfigs)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -341,7 +395,7 @@ func TestCreatesMachineConfigsWithFeatureGate(t *testing.T) {
 	f.objects = append(f.objects, cc)
 	f.kubeobjects = append(f.kubeobjects, ps)
 
-	expMCs, err := getMachineConfigsForControllerConfig(templateDir, cc, []byte(`{"dummy": "dummy"}`), feat)
+	expMCs, err := getMachineConfigsForControllerConfig(templateDir, cc, []byte(`{"dummy": "dummy"}`), feat, nil, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -367,6 +421,10 @@ func TestCreatesMachineConfigsWithFeatureGate(t *testing.T) {
 	f.run(getKey(cc, t))
 }
 
+func (f *fixture) expectDeleteMachineConfigAction(config *mcfgv1.MachineConfig) {
+	f.actions = append(f.actions, core.NewRootDeleteAction(schema.GroupVersionResource{Resource: "machineconfigs"}, config.Name))
+}
+
 func TestDoNothing(t *testing.T) {
 	f := newFixture(t)
 	cc := newControllerConfig("test-cluster")
@@ -374,7 +432,7 @@ func TestDoNothing(t *testing.T) {
 	feat := newFeatures("cluster", "CustomNoUpgrade", []string{cloudprovider.ExternalCloudProviderFeature}, nil)
 	f.featLister = append(f.featLister, feat)
 
-	mcs, err := getMachineConfigsForControllerConfig(templateDir, cc, []byte(`{"dummy": "dummy"}`), feat)
+	mcs, err := getMachineConfigsForControllerConfig(templateDir, cc, []byte(`{"dummy": "dummy"}`), feat, nil, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -407,6 +465,57 @@ func TestDoNothing(t *testing.T) {
 	f.run(getKey(cc, t))
 }
 
+func TestDeletesStaleInfraMachineConfigs(t *testing.T) {
+	f := newFixture(t)
+	cc := newControllerConfig("test-cluster")
+	ps := newPullSecret("coreos-pull-secret", []byte(`{"dummy": "dummy"}`))
+
+	// rendered while the infra pool opted in, which it no longer does
+	staleMCs, err := getMachineConfigsForControllerConfig(templateDir, cc, []byte(`{"dummy": "dummy"}`), nil, nil, nil, map[string]poolTemplateConfig{"infra": {InfraTemplates: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.ccLister = append(f.ccLister, cc)
+	f.objects = append(f.objects, cc)
+	f.kubeobjects = append(f.kubeobjects, ps)
+	f.mcLister = append(f.mcLister, staleMCs...)
+	for idx := range staleMCs {
+		f.objects = append(f.objects, staleMCs[idx])
+	}
+
+	rcc := cc.DeepCopy()
+	rcc.Status.ObservedGeneration = 1
+	rcc.Status.Conditions = []mcfgv1.ControllerConfigStatusCondition{{Type: mcfgv1.TemplateControllerRunning, Status: corev1.ConditionTrue, Message: "syncing towards (1) generation using controller version v0.0.0-was-not-built-properly"}}
+	f.expectUpdateControllerConfigStatus(rcc)
+	f.expectGetSecretAction(ps)
+	infraMCs := 0
+	for idx := range staleMCs {
+		if staleMCs[idx].Labels[mcfgv1.MachineConfigRoleLabelKey] != "infra" {
+			f.expectGetMachineConfigAction(staleMCs[idx])
+		}
+	}
+	for idx := range staleMCs {
+		if staleMCs[idx].Labels[mcfgv1.MachineConfigRoleLabelKey] == "infra" {
+			f.expectDeleteMachineConfigAction(staleMCs[idx])
+			infraMCs++
+		}
+	}
+	if infraMCs == 0 {
+		t.Fatal("no infra MachineConfigs rendered")
+	}
+	ccc := cc.DeepCopy()
+	ccc.Status.ObservedGeneration = 1
+	ccc.Status.Conditions = []mcfgv1.ControllerConfigStatusCondition{
+		{Type: mcfgv1.TemplateControllerCompleted, Status: corev1.ConditionTrue, Message: "sync completed towards (1) generation using controller version v0.0.0-was-not-built-properly"},
+		{Type: mcfgv1.TemplateControllerRunning, Status: corev1.ConditionFalse},
+		{Type: mcfgv1.TemplateControllerFailing, Status: corev1.ConditionFalse},
+	}
+	f.expectUpdateControllerConfigStatus(ccc)
+
+	f.run(getKey(cc, t))
+}
+
 func TestRecreateMachineConfig(t *testing.T) {
 	f := newFixture(t)
 	cc := newControllerConfig("test-cluster")
@@ -414,7 +523,7 @@ func TestRecreateMachineConfig(t *testing.T) {
 	feat := newFeatures("cluster", "CustomNoUpgrade", []string{cloudprovider.ExternalCloudProviderFeature}, nil)
 	f.featLister = append(f.featLister, feat)
 
-	mcs, err := getMachineConfigsForControllerConfig(templateDir, cc, []byte(`{"dummy": "dummy"}`), feat)
+	mcs, err := getMachineConfigsForControllerConfig(templateDir, cc, []byte(`{"dummy": "dummy"}`), feat, nil, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -455,7 +564,7 @@ func TestUpdateMachineConfig(t *testing.T) {
 	feat := newFeatures("cluster", "CustomNoUpgrade", []string{cloudprovider.ExternalCloudProviderFeature}, nil)
 	f.featLister = append(f.featLister, feat)
 
-	mcs, err := getMachineConfigsForControllerConfig(templateDir, cc, []byte(`{"dummy": "dummy"}`), feat)
+	mcs, err := getMachineConfigsForControllerConfig(templateDir, cc, []byte(`{"dummy": "dummy"}`), feat, nil, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -475,7 +584,7 @@ func TestUpdateMachineConfig(t *testing.T) {
 		f.objects = append(f.objects, mcs[idx])
 	}
 
-	expmcs, err := getMachineConfigsForControllerConfig(templateDir, cc, []byte(`{"dummy": "dummy"}`), feat)
+	expmcs, err := getMachineConfigsForControllerConfig(templateDir, cc, []byte(`{"dummy": "dummy"}`), feat, nil, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -507,3 +616,123 @@ func getKey(config *mcfgv1.ControllerConfig, t *testing.T) string {
 	}
 	return key
 }
+
+func TestSyncTemplateConstants(t *testing.T) {
+	defer constants.SetConstantOverrides(nil)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	ctrl := &Controller{cmLister: corelisterv1.NewConfigMapLister(indexer)}
+
+	if err := ctrl.syncTemplateConstants(); err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	if got := constants.TemplateConstants()["APIServerURLFile"]; got != constants.APIServerURLFile {
+		t.Fatalf("mismatch got: %s want: %s", got, constants.APIServerURLFile)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ImagesConfigMapName, Namespace: ctrlcommon.MCONamespace},
+		Data:       map[string]string{ConstantsConfigMapKey: `{"APIServerURLFile": "/etc/kubernetes/apiserver.env"}`},
+	}
+	if err := indexer.Add(cm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.syncTemplateConstants(); err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	if got := constants.TemplateConstants()["APIServerURLFile"]; got != "/etc/kubernetes/apiserver.env" {
+		t.Fatalf("mismatch got: %s want: /etc/kubernetes/apiserver.env", got)
+	}
+
+	// invalid overrides fail and keep the previous ones
+	cm = cm.DeepCopy()
+	cm.Data[ConstantsConfigMapKey] = `{"APIServerURLFile": "apiserver.env"}`
+	if err := indexer.Update(cm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.syncTemplateConstants(); err == nil {
+		t.Fatal("expected an error for a relative path")
+	}
+	if got := constants.TemplateConstants()["APIServerURLFile"]; got != "/etc/kubernetes/apiserver.env" {
+		t.Fatalf("mismatch got: %s want: /etc/kubernetes/apiserver.env", got)
+	}
+
+	if err := indexer.Delete(cm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.syncTemplateConstants(); err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	if got := constants.TemplateConstants()["APIServerURLFile"]; got != constants.APIServerURLFile {
+		t.Fatalf("mismatch got: %s want: %s", got, constants.APIServerURLFile)
+	}
+}
+
+func TestCancelStaleRender(t *testing.T) {
+	ctrl := &Controller{}
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+	key := getKey(cc, t)
+
+	ctx, done := ctrl.startRender(key, cc.Generation)
+	ctrl.cancelStaleRender(cc)
+	if ctx.Err() != nil {
+		t.Fatal("expected the render of the current generation to go on")
+	}
+
+	// a newer generation cancels it
+	cc.Generation++
+	ctrl.cancelStaleRender(cc)
+	if ctx.Err() == nil {
+		t.Fatal("expected the render of the previous generation to be cancelled")
+	}
+	done()
+	if len(ctrl.renders) != 0 {
+		t.Fatalf("expected no render in progress, got %v", ctrl.renders)
+	}
+
+	// the render of a newer generation outlives the older one it replaced
+	_, doneOld := ctrl.startRender(key, 1)
+	ctx, done = ctrl.startRender(key, 2)
+	doneOld()
+	ctrl.cancelStaleRender(cc)
+	if ctx.Err() != nil {
+		t.Fatal("expected the render of the current generation to go on")
+	}
+	done()
+}
+
+func TestRunRender(t *testing.T) {
+	cc, err := controllerConfigFromFile(configs["aws"])
+	if err != nil {
+		t.Fatalf("failed to get controllerconfig config: %v", err)
+	}
+	overlays, err := ParseTemplateOverlays(map[string]string{
+		"worker.00-worker.files.my-agent.yaml": "path: /etc/my-agent.conf\ncontents:\n  inline: hello\n",
+	}, templateDir)
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	mcs, err := RunRender(templateDir, cc, []byte(`{"dummy": "dummy"}`), nil, overlays, nil, nil)
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	// the same MachineConfigs as the controller, plus the overlay
+	expected, err := getMachineConfigsForControllerConfig(templateDir, cc, []byte(`{"dummy": "dummy"}`), nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	if len(mcs) != len(expected) {
+		t.Fatalf("expected %d MachineConfigs, got %d", len(expected), len(mcs))
+	}
+	for i, mc := range mcs {
+		if mc.Name != expected[i].Name {
+			t.Fatalf("mismatch got: %s want: %s", mc.Name, expected[i].Name)
+		}
+		ign, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+		if err != nil {
+			t.Fatalf("failed to parse Ignition config: %v", err)
+		}
+		if found := findIgnFile(ign.Storage.Files, "/etc/my-agent.conf", t); found != (mc.Name == "00-worker") {
+			t.Fatalf("%s: unexpected overlay file presence %v", mc.Name, found)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package template
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	"github.com/containers/image/v5/pkg/compression"
+	"github.com/containers/image/v5/types"
+	"github.com/golang/glog"
+	digest "github.com/opencontainers/go-digest"
+)
+
+// ParseTemplatesImage parses the reference of an image holding a template
+// tree.  The reference must be pinned by digest, so the templates only ever
+// change along with the configuration of the operator.
+func ParseTemplatesImage(image string) (reference.Canonical, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return nil, fmt.Errorf("invalid templates image %q: %w", image, err)
+	}
+	canonical, ok := named.(reference.Canonical)
+	if !ok {
+		return nil, fmt.Errorf("templates image %q must be referenced by digest", image)
+	}
+	return canonical, nil
+}
+
+// FetchTemplatesImageWithPullSecret is FetchTemplatesImage with the registry
+// credentials of pullSecret, the contents of a pull secret.
+func FetchTemplatesImageWithPullSecret(ctx context.Context, image string, pullSecret []byte, cacheDir, bakedDir string) (string, error) {
+	authFile, err := ioutil.TempFile("", "templates-auth-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(authFile.Name())
+	if _, err := authFile.Write(pullSecret); err != nil {
+		authFile.Close()
+		return "", err
+	}
+	if err := authFile.Close(); err != nil {
+		return "", err
+	}
+	return FetchTemplatesImage(ctx, image, authFile.Name(), cacheDir, bakedDir)
+}
+
+// FetchTemplatesImage extracts the template tree from the layers of a
+// digest-pinned image (or OCI artifact) into cacheDir and returns its path.
+// The layers are tar archives, optionally compressed, applied in order.  The
+// tree is extracted into a directory named after the digest, so it's only
+// fetched once.  It must have all the roles and MachineConfig directories of
+// the baked-in tree in bakedDir.  authFile is the registry credentials in the
+// format of a pull secret, if any.
+func FetchTemplatesImage(ctx context.Context, image, authFile, cacheDir, bakedDir string) (string, error) {
+	ref, err := ParseTemplatesImage(image)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(cacheDir, ref.Digest().Encoded())
+	if _, err := os.Stat(dir); err == nil {
+		glog.Infof("Using templates of image %s cached in %s", image, dir)
+		return dir, nil
+	}
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", err
+	}
+	tmpDir, err := ioutil.TempDir(cacheDir, ".tmp-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := pullTemplatesImage(ctx, ref, authFile, tmpDir); err != nil {
+		return "", fmt.Errorf("fetching templates image %s: %w", image, err)
+	}
+	if err := validateTemplatesTree(tmpDir, bakedDir); err != nil {
+		return "", fmt.Errorf("templates image %s: %w", image, err)
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return "", err
+	}
+	glog.Infof("Extracted templates of image %s into %s", image, dir)
+	return dir, nil
+}
+
+func pullTemplatesImage(ctx context.Context, ref reference.Canonical, authFile, dest string) error {
+	imgRef, err := docker.NewReference(ref)
+	if err != nil {
+		return err
+	}
+	sys := &types.SystemContext{AuthFilePath: authFile}
+	src, err := imgRef.NewImageSource(ctx, sys)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	raw, mimeType, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if matches, err := manifest.MatchesDigest(raw, ref.Digest()); err != nil || !matches {
+		return fmt.Errorf("manifest does not match digest %s", ref.Digest())
+	}
+	if manifest.MIMETypeIsMultiImage(mimeType) {
+		return fmt.Errorf("manifest lists are not supported, reference the digest of a single image")
+	}
+	m, err := manifest.FromBlob(raw, mimeType)
+	if err != nil {
+		return err
+	}
+
+	for _, layer := range m.LayerInfos() {
+		if err := extractLayer(ctx, src, layer.BlobInfo, dest); err != nil {
+			return fmt.Errorf("layer %s: %w", layer.Digest, err)
+		}
+	}
+	return nil
+}
+
+// extractLayer extracts a layer into dest, verifying its digest.
+func extractLayer(ctx context.Context, src types.ImageSource, info types.BlobInfo, dest string) error {
+	blob, _, err := src.GetBlob(ctx, info, none.NoCache)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+	return extractVerifiedTar(blob, info.Digest, dest)
+}
+
+// extractVerifiedTar extracts the, optionally compressed, tar archive r into
+// dest and checks r matches the expected digest.
+func extractVerifiedTar(r io.Reader, expected digest.Digest, dest string) error {
+	verifier := expected.Verifier()
+	tee := io.TeeReader(r, verifier)
+	decompressed, _, err := compression.AutoDecompress(tee)
+	if err != nil {
+		return err
+	}
+	defer decompressed.Close()
+	if err := extractTar(decompressed, dest); err != nil {
+		return err
+	}
+	// the tar reader stops at the end-of-archive marker, read the padding too
+	if _, err := io.Copy(ioutil.Discard, tee); err != nil {
+		return err
+	}
+	if !verifier.Verified() {
+		return fmt.Errorf("content does not match digest %s", expected)
+	}
+	return nil
+}
+
+// extractTar extracts the directories and regular files of a tar archive
+// into dest.  Templates are plain files, so links, devices and whiteouts are
+// rejected rather than interpreted.
+func extractTar(r io.Reader, dest string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.Clean(hdr.Name)
+		if name == "." || name == "/" {
+			continue
+		}
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("invalid path %q", hdr.Name)
+		}
+		if strings.HasPrefix(filepath.Base(name), ".wh.") {
+			return fmt.Errorf("unsupported whiteout %q", hdr.Name)
+		}
+		path := filepath.Join(dest, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			if err := writeTarFile(tr, path); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported type %q of %q", hdr.Typeflag, hdr.Name)
+		}
+	}
+}
+
+func writeTarFile(r io.Reader, path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// validateTemplatesTree checks dir has the directories of every role of the
+// baked-in tree, and of their MachineConfigs, so a wrong or outdated image
+// doesn't render MachineConfigs removing everything for some pools.
+func validateTemplatesTree(dir, bakedDir string) error {
+	roles, err := ioutil.ReadDir(bakedDir)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if !role.IsDir() {
+			continue
+		}
+		if err := requireDir(dir, role.Name()); err != nil {
+			return err
+		}
+		mcDirs, err := ioutil.ReadDir(filepath.Join(bakedDir, role.Name()))
+		if err != nil {
+			return err
+		}
+		for _, mcDir := range mcDirs {
+			if !mcDir.IsDir() {
+				continue
+			}
+			if err := requireDir(dir, filepath.Join(role.Name(), mcDir.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func requireDir(dir, name string) error {
+	info, err := os.Stat(filepath.Join(dir, name))
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("not a template tree: missing directory %q", name)
+	}
+	return nil
+}
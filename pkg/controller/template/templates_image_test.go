@@ -0,0 +1,152 @@
+package template
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTemplatesImage = "quay.io/example/mco-templates@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestParseTemplatesImage(t *testing.T) {
+	ref, err := ParseTemplatesImage(testTemplatesImage)
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", ref.Digest().String())
+
+	_, err = ParseTemplatesImage("quay.io/example/mco-templates:latest")
+	assert.Error(t, err)
+	_, err = ParseTemplatesImage("quay.io/example/mco-templates@sha256:abc")
+	assert.Error(t, err)
+}
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	contents string
+}
+
+func newTar(t *testing.T, entries []tarEntry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typeflag, Mode: 0644, Size: int64(len(e.contents))}
+		if e.typeflag == tar.TypeSymlink {
+			hdr.Linkname = e.contents
+			hdr.Size = 0
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		if e.typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte(e.contents))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err := gw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func TestExtractVerifiedTar(t *testing.T) {
+	tree := newTar(t, []tarEntry{
+		{name: "./common/", typeflag: tar.TypeDir},
+		{name: "./common/_base/files/foo.yaml", typeflag: tar.TypeReg, contents: "foo"},
+		{name: "master/00-master/_base/units/bar.service.yaml", typeflag: tar.TypeReg, contents: "bar"},
+	})
+
+	for name, layer := range map[string][]byte{"plain": tree, "gzip": gzipped(t, tree)} {
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "templates")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			require.NoError(t, extractVerifiedTar(bytes.NewReader(layer), digest.FromBytes(layer), dir))
+			contents, err := ioutil.ReadFile(filepath.Join(dir, "common/_base/files/foo.yaml"))
+			require.NoError(t, err)
+			assert.Equal(t, "foo", string(contents))
+			contents, err = ioutil.ReadFile(filepath.Join(dir, "master/00-master/_base/units/bar.service.yaml"))
+			require.NoError(t, err)
+			assert.Equal(t, "bar", string(contents))
+		})
+	}
+
+	t.Run("digest mismatch", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "templates")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		assert.Error(t, extractVerifiedTar(bytes.NewReader(tree), digest.FromString("other"), dir))
+	})
+}
+
+func TestExtractTarRejects(t *testing.T) {
+	cases := map[string]tarEntry{
+		"absolute path": {name: "/etc/passwd", typeflag: tar.TypeReg, contents: "x"},
+		"parent path":   {name: "../escape", typeflag: tar.TypeReg, contents: "x"},
+		"symlink":       {name: "common/link", typeflag: tar.TypeSymlink, contents: "/etc"},
+		"whiteout":      {name: "common/.wh.foo", typeflag: tar.TypeReg},
+	}
+	for name, entry := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "templates")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+			assert.Error(t, extractTar(bytes.NewReader(newTar(t, []tarEntry{entry})), dir))
+		})
+	}
+}
+
+func TestValidateTemplatesTree(t *testing.T) {
+	bakedDir, err := ioutil.TempDir("", "templates-baked")
+	require.NoError(t, err)
+	defer os.RemoveAll(bakedDir)
+	for _, d := range []string{"common/_base", "master/00-master", "worker/00-worker", "infra/01-infra-kubelet"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(bakedDir, d), 0755))
+	}
+
+	dir, err := ioutil.TempDir("", "templates")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, d := range []string{"common/_base", "master/00-master", "worker/00-worker"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, d), 0755))
+	}
+	assert.Error(t, validateTemplatesTree(dir, bakedDir))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "infra"), 0755))
+	assert.Error(t, validateTemplatesTree(dir, bakedDir))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "infra/01-infra-kubelet"), 0755))
+	assert.NoError(t, validateTemplatesTree(dir, bakedDir))
+}
+
+func TestFetchTemplatesImageCached(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "templates-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	cached := filepath.Join(cacheDir, "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	require.NoError(t, os.MkdirAll(cached, 0755))
+
+	// the image is not fetched again once extracted
+	dir, err := FetchTemplatesImage(context.TODO(), testTemplatesImage, "", cacheDir, "")
+	require.NoError(t, err)
+	assert.Equal(t, cached, dir)
+
+	_, err = FetchTemplatesImage(context.TODO(), "quay.io/example/mco-templates:latest", "", cacheDir, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,285 @@
+// Package testing renders the MachineConfig templates for a matrix of
+// ControllerConfigs and FeatureGates and compares the Ignition configs they
+// render to golden files, so that changes to the templates, in this
+// repository or in the ones shipping templates for it such as the installer
+// or OKD, show up as diffs of the rendered configs.
+//
+// A test of a templates directory looks like:
+//
+//	var update = flag.Bool("update", false, "update the golden files")
+//
+//	func TestTemplates(t *testing.T) {
+//		configs, err := templatetesting.LoadControllerConfigs("testdata/controllerconfigs")
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		golden := templatetesting.Golden{TemplatesDir: "templates", Dir: "testdata/golden", Update: *update}
+//		golden.Check(t, templatetesting.Matrix(configs, nil))
+//	}
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	configv1 "github.com/openshift/api/config/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/controller/template"
+)
+
+const (
+	// DefaultPullSecret is the pull secret the cases are rendered with when
+	// they don't set one.
+	DefaultPullSecret = `{"dummy":"dummy"}`
+
+	// controllerConfigPrefix and controllerConfigSuffix surround the name of
+	// the ControllerConfigs of LoadControllerConfigs, as in
+	// controller_config_aws.yaml.
+	controllerConfigPrefix = "controller_config_"
+	controllerConfigSuffix = ".yaml"
+
+	// goldenSuffix is the extension of the golden files.
+	goldenSuffix = ".json"
+)
+
+// Case is a ControllerConfig and a FeatureGate the templates are rendered
+// with.  The golden files of a case are in the directory named after it.
+type Case struct {
+	Name             string
+	ControllerConfig *mcfgv1.ControllerConfig
+	// FeatureGate is the cluster FeatureGate, none if nil.
+	FeatureGate *configv1.FeatureGate
+	// PullSecret defaults to DefaultPullSecret.
+	PullSecret string
+}
+
+// Matrix returns the cases rendering every ControllerConfig with every
+// FeatureGate, named <config> with no FeatureGates and <config>-<featuregate>
+// otherwise, sorted by name.
+func Matrix(configs map[string]*mcfgv1.ControllerConfig, featureGates map[string]*configv1.FeatureGate) []Case {
+	var cases []Case
+	for name, config := range configs {
+		if len(featureGates) == 0 {
+			cases = append(cases, Case{Name: name, ControllerConfig: config})
+			continue
+		}
+		for gate, featureGate := range featureGates {
+			cases = append(cases, Case{Name: name + "-" + gate, ControllerConfig: config, FeatureGate: featureGate})
+		}
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].Name < cases[j].Name })
+	return cases
+}
+
+// LoadControllerConfig reads a ControllerConfig manifest.
+func LoadControllerConfig(path string) (*mcfgv1.ControllerConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	obj, _, err := scheme.Codecs.UniversalDecoder().Decode(data, nil, &mcfgv1.ControllerConfig{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode ControllerConfig manifest %s: %v", path, err)
+	}
+	config, ok := obj.(*mcfgv1.ControllerConfig)
+	if !ok {
+		return nil, fmt.Errorf("expected manifest %s to decode into *mcfgv1.ControllerConfig, got %T", path, obj)
+	}
+	return config, nil
+}
+
+// LoadControllerConfigs reads the controller_config_<name>.yaml manifests of
+// dir, keyed by name, e.g. aws for controller_config_aws.yaml.  Underscores
+// in the name become dashes.
+func LoadControllerConfigs(dir string) (map[string]*mcfgv1.ControllerConfig, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	configs := map[string]*mcfgv1.ControllerConfig{}
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasPrefix(name, controllerConfigPrefix) || !strings.HasSuffix(name, controllerConfigSuffix) {
+			continue
+		}
+		config, err := LoadControllerConfig(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		name = strings.TrimSuffix(strings.TrimPrefix(name, controllerConfigPrefix), controllerConfigSuffix)
+		configs[strings.ReplaceAll(name, "_", "-")] = config
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no %s<name>%s ControllerConfig in %s", controllerConfigPrefix, controllerConfigSuffix, dir)
+	}
+	return configs, nil
+}
+
+// Render renders the templates of templatesDir for c like the controller
+// does, and returns the Ignition configs of the MachineConfigs, converted to
+// the spec version the controller writes and indented, keyed by the name of
+// the MachineConfig.
+func Render(templatesDir string, c Case) (map[string][]byte, error) {
+	pullSecret := c.PullSecret
+	if pullSecret == "" {
+		pullSecret = DefaultPullSecret
+	}
+	mcs, err := template.RunRender(templatesDir, c.ControllerConfig, []byte(pullSecret), c.FeatureGate, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	rendered := map[string][]byte{}
+	for _, mc := range mcs {
+		ignConfig, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("MachineConfig %s: %v", mc.Name, err)
+		}
+		data, err := json.MarshalIndent(ignConfig, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("MachineConfig %s: %v", mc.Name, err)
+		}
+		rendered[mc.Name] = append(data, '\n')
+	}
+	return rendered, nil
+}
+
+// Golden compares the Ignition configs rendered from TemplatesDir to the
+// golden files of Dir, <Dir>/<case>/<MachineConfig>.json.
+type Golden struct {
+	TemplatesDir string
+	Dir          string
+	// Update writes the rendered configs to the golden files, and removes
+	// the golden files of the MachineConfigs which aren't rendered anymore,
+	// instead of comparing them, e.g. from an -update flag of the test.
+	Update bool
+}
+
+// Diff renders the templates for c and returns the differences with its
+// golden files, one per MachineConfig, sorted by name.  With Update set, it
+// writes the golden files instead and returns no differences.
+func (g Golden) Diff(c Case) ([]string, error) {
+	rendered, err := Render(g.TemplatesDir, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render the templates for %s: %v", c.Name, err)
+	}
+	dir := filepath.Join(g.Dir, c.Name)
+	golden, err := readGoldenFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if g.Update {
+		return nil, writeGoldenFiles(dir, golden, rendered)
+	}
+
+	names := map[string]bool{}
+	for name := range rendered {
+		names[name] = true
+	}
+	for name := range golden {
+		names[name] = true
+	}
+	var diffs []string
+	for _, name := range sortedNames(names) {
+		path := filepath.Join(dir, name+goldenSuffix)
+		want, hasGolden := golden[name]
+		got, isRendered := rendered[name]
+		switch {
+		case !hasGolden:
+			diffs = append(diffs, fmt.Sprintf("%s: MachineConfig %s has no golden file", path, name))
+		case !isRendered:
+			diffs = append(diffs, fmt.Sprintf("%s: MachineConfig %s isn't rendered anymore", path, name))
+		default:
+			if diff := cmp.Diff(string(want), string(got)); diff != "" {
+				diffs = append(diffs, fmt.Sprintf("%s: rendered Ignition config differs (-golden +rendered):\n%s", path, diff))
+			}
+		}
+	}
+	return diffs, nil
+}
+
+// Check runs a subtest per case, failing on the differences of the case with
+// its golden files.
+func (g Golden) Check(t *testing.T, cases []Case) {
+	t.Helper()
+	if len(cases) == 0 {
+		t.Fatal("no cases to render")
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			diffs, err := g.Diff(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, diff := range diffs {
+				t.Error(diff)
+			}
+			if len(diffs) > 0 {
+				t.Log("run the test with the golden files update enabled if the differences are expected")
+			}
+		})
+	}
+}
+
+// readGoldenFiles reads the golden files of dir, keyed by MachineConfig name.
+// A missing dir has none.
+func readGoldenFiles(dir string) (map[string][]byte, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	golden := map[string][]byte{}
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), goldenSuffix) {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, info.Name()))
+		if err != nil {
+			return nil, err
+		}
+		golden[strings.TrimSuffix(info.Name(), goldenSuffix)] = data
+	}
+	return golden, nil
+}
+
+// writeGoldenFiles replaces the golden files of dir with the rendered ones.
+func writeGoldenFiles(dir string, golden, rendered map[string][]byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for name := range golden {
+		if _, ok := rendered[name]; !ok {
+			if err := os.Remove(filepath.Join(dir, name+goldenSuffix)); err != nil {
+				return err
+			}
+		}
+	}
+	for name, data := range rendered {
+		if err := ioutil.WriteFile(filepath.Join(dir, name+goldenSuffix), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedNames(names map[string]bool) []string {
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
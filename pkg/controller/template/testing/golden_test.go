@@ -0,0 +1,123 @@
+package testing
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+// writeTemplates writes a templates dir rendering a MachineConfig per role,
+// with a file holding the platform of the cluster.
+func writeTemplates(t *testing.T, contents string) string {
+	dir := t.TempDir()
+	for _, role := range []string{"master", "worker"} {
+		path := filepath.Join(dir, role, "00-"+role, "_base", "files", "platform.yaml")
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	}
+	return dir
+}
+
+const platformTemplate = `mode: 0644
+path: "/etc/platform"
+contents:
+  inline: {{.Infra.Status.PlatformStatus.Type}}
+`
+
+func TestLoadControllerConfigs(t *testing.T) {
+	configs, err := LoadControllerConfigs("../test_data")
+	require.NoError(t, err)
+	assert.Contains(t, configs, "aws")
+	assert.Contains(t, configs, "mtu-migration")
+
+	_, err = LoadControllerConfigs(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestMatrix(t *testing.T) {
+	aws, err := LoadControllerConfig("../test_data/controller_config_aws.yaml")
+	require.NoError(t, err)
+	gcp, err := LoadControllerConfig("../test_data/controller_config_gcp.yaml")
+	require.NoError(t, err)
+	configs := map[string]*mcfgv1.ControllerConfig{"aws": aws, "gcp": gcp}
+
+	var names []string
+	for _, c := range Matrix(configs, nil) {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"aws", "gcp"}, names)
+
+	techPreview := &configv1.FeatureGate{Spec: configv1.FeatureGateSpec{FeatureGateSelection: configv1.FeatureGateSelection{FeatureSet: configv1.TechPreviewNoUpgrade}}}
+	names = nil
+	for _, c := range Matrix(configs, map[string]*configv1.FeatureGate{"default": {}, "techpreview": techPreview}) {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"aws-default", "aws-techpreview", "gcp-default", "gcp-techpreview"}, names)
+}
+
+func TestGolden(t *testing.T) {
+	aws, err := LoadControllerConfig("../test_data/controller_config_aws.yaml")
+	require.NoError(t, err)
+	c := Case{Name: "aws", ControllerConfig: aws}
+	goldenDir := t.TempDir()
+	templatesDir := writeTemplates(t, platformTemplate)
+
+	// a stale golden file is removed on update
+	stale := filepath.Join(goldenDir, "aws", "00-infra.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(stale), 0755))
+	require.NoError(t, ioutil.WriteFile(stale, []byte("{}\n"), 0644))
+
+	diffs, err := Golden{TemplatesDir: templatesDir, Dir: goldenDir, Update: true}.Diff(c)
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+	assert.FileExists(t, filepath.Join(goldenDir, "aws", "00-master.json"))
+	assert.FileExists(t, filepath.Join(goldenDir, "aws", "00-worker.json"))
+	assert.NoFileExists(t, stale)
+
+	golden := Golden{TemplatesDir: templatesDir, Dir: goldenDir}
+	golden.Check(t, []Case{c})
+
+	// a change of a template shows up as a diff of the rendered config
+	golden.TemplatesDir = writeTemplates(t, strings.Replace(platformTemplate, "0644", "0600", 1))
+	diffs, err = golden.Diff(c)
+	require.NoError(t, err)
+	require.Len(t, diffs, 2)
+	assert.Contains(t, diffs[0], "00-master.json: rendered Ignition config differs")
+	// 0600, as JSON numbers are decimal
+	assert.Contains(t, diffs[0], "384")
+
+	// so does a MachineConfig without a golden file
+	require.NoError(t, os.Remove(filepath.Join(goldenDir, "aws", "00-worker.json")))
+	diffs, err = Golden{TemplatesDir: templatesDir, Dir: goldenDir}.Diff(c)
+	require.NoError(t, err)
+	require.Len(t, diffs, 1)
+	assert.Contains(t, diffs[0], "MachineConfig 00-worker has no golden file")
+
+	// and a template failing to render
+	_, err = Golden{TemplatesDir: writeTemplates(t, "{{.Missing}"), Dir: goldenDir}.Diff(c)
+	assert.Error(t, err)
+}
+
+func TestTemplatesMatrix(t *testing.T) {
+	configs, err := LoadControllerConfigs("../test_data")
+	require.NoError(t, err)
+	// the oVirt ControllerConfig has no VIPs in its platform status, which
+	// the on-prem templates need
+	delete(configs, "ovirt")
+	techPreview := &configv1.FeatureGate{Spec: configv1.FeatureGateSpec{FeatureGateSelection: configv1.FeatureGateSelection{FeatureSet: configv1.TechPreviewNoUpgrade}}}
+	cases := Matrix(configs, map[string]*configv1.FeatureGate{"default": {}, "techpreview": techPreview})
+
+	// the templates of the repository render the same configs twice
+	golden := Golden{TemplatesDir: "../../../../templates", Dir: t.TempDir(), Update: true}
+	golden.Check(t, cases)
+	golden.Update = false
+	golden.Check(t, cases)
+}
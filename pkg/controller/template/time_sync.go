@@ -0,0 +1,38 @@
+package template
+
+import (
+	"fmt"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// TimeSyncSource is what the nodes synchronize their clock with.
+type TimeSyncSource string
+
+const (
+	// TimeSyncSourceNTP synchronizes the clock with NTP through chronyd.
+	TimeSyncSourceNTP TimeSyncSource = "NTP"
+	// TimeSyncSourcePTP synchronizes the clock with PTP, e.g. through the
+	// linuxptp daemons of the PTP operator, so chronyd mustn't steer it too.
+	TimeSyncSourcePTP TimeSyncSource = "PTP"
+)
+
+// timeSyncSourceFromAnnotations returns the time sync source of the
+// time-sync-source annotation, or def if there's none.
+func timeSyncSourceFromAnnotations(annotations map[string]string, def TimeSyncSource) (TimeSyncSource, error) {
+	switch source := TimeSyncSource(annotations[ctrlcommon.TimeSyncSourceAnnotationKey]); source {
+	case "":
+		return def, nil
+	case TimeSyncSourceNTP, TimeSyncSourcePTP:
+		return source, nil
+	default:
+		return "", fmt.Errorf("invalid %s annotation %q, must be %q or %q", ctrlcommon.TimeSyncSourceAnnotationKey, source, TimeSyncSourceNTP, TimeSyncSourcePTP)
+	}
+}
+
+// isPTPSynced returns whether the nodes the templates are rendered for
+// synchronize their clock with PTP, so that the chrony templates leave it
+// alone.
+func isPTPSynced(cfg RenderConfig) bool {
+	return cfg.TimeSyncSource == TimeSyncSourcePTP
+}
@@ -0,0 +1,109 @@
+package template
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+func TestTimeSyncSourceFromAnnotations(t *testing.T) {
+	for value, want := range map[string]TimeSyncSource{
+		"":    TimeSyncSourceNTP,
+		"NTP": TimeSyncSourceNTP,
+		"PTP": TimeSyncSourcePTP,
+	} {
+		annotations := map[string]string{}
+		if value != "" {
+			annotations[ctrlcommon.TimeSyncSourceAnnotationKey] = value
+		}
+		got, err := timeSyncSourceFromAnnotations(annotations, TimeSyncSourceNTP)
+		if err != nil {
+			t.Fatalf("%q: expected nil error %v", value, err)
+		}
+		if got != want {
+			t.Fatalf("%q: mismatch got: %s want: %s", value, got, want)
+		}
+	}
+
+	_, err := timeSyncSourceFromAnnotations(map[string]string{ctrlcommon.TimeSyncSourceAnnotationKey: "ptp"}, TimeSyncSourceNTP)
+	if err == nil || !strings.Contains(err.Error(), `must be "NTP" or "PTP"`) {
+		t.Fatalf("expected an invalid annotation error, got %v", err)
+	}
+}
+
+// chronydDisabled returns whether the ignition config keeps chronyd from
+// starting.
+func chronydDisabled(units []ign3types.Unit) bool {
+	for _, u := range units {
+		if u.Name != "chronyd.service" {
+			continue
+		}
+		for _, d := range u.Dropins {
+			if d.Contents != nil && strings.Contains(*d.Contents, "ConditionPathExists=/enoent") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestPTPSyncedPools(t *testing.T) {
+	controllerConfig, err := controllerConfigFromFile(configs["aws"])
+	if err != nil {
+		t.Fatalf("failed to get controllerconfig config: %v", err)
+	}
+
+	cases := []struct {
+		name        string
+		source      TimeSyncSource
+		poolConfigs map[string]poolTemplateConfig
+		disabled    map[string]bool
+	}{{
+		name:     "NTP",
+		source:   TimeSyncSourceNTP,
+		disabled: map[string]bool{"00-master": false, "00-worker": false},
+	}, {
+		name:     "PTP cluster",
+		source:   TimeSyncSourcePTP,
+		disabled: map[string]bool{"00-master": true, "00-worker": true},
+	}, {
+		name:        "PTP worker pool",
+		source:      TimeSyncSourceNTP,
+		poolConfigs: map[string]poolTemplateConfig{"worker": {TimeSyncSource: TimeSyncSourcePTP}},
+		disabled:    map[string]bool{"00-master": false, "00-worker": true},
+	}, {
+		name:        "NTP master pool",
+		source:      TimeSyncSourcePTP,
+		poolConfigs: map[string]poolTemplateConfig{"master": {TimeSyncSource: TimeSyncSourceNTP}},
+		disabled:    map[string]bool{"00-master": false, "00-worker": true},
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			renderConfig := &RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true, TimeSyncSource: c.source}
+			cfgs, err := generateTemplateMachineConfigs(context.TODO(), renderConfig, templateDir, c.poolConfigs)
+			if err != nil {
+				t.Fatalf("failed to generate machine configs: %v", err)
+			}
+			disabled := map[string]bool{}
+			for _, cfg := range cfgs {
+				if _, ok := c.disabled[cfg.Name]; !ok {
+					continue
+				}
+				ign, err := ctrlcommon.ParseAndConvertConfig(cfg.Spec.Config.Raw)
+				if err != nil {
+					t.Fatalf("failed to parse Ignition config: %v", err)
+				}
+				disabled[cfg.Name] = chronydDisabled(ign.Systemd.Units)
+			}
+			for name, want := range c.disabled {
+				if disabled[name] != want {
+					t.Errorf("%s: chronyd disabled %v, want %v", name, disabled[name], want)
+				}
+			}
+		})
+	}
+}
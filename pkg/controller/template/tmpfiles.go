@@ -0,0 +1,114 @@
+package template
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/vincent-petithory/dataurl"
+)
+
+const (
+	// tmpfilesDir holds systemd-tmpfiles entries, rendered into tmpfilesConfDir
+	tmpfilesDir = "tmpfiles"
+	// tmpfilesConfDir is where the rendered tmpfiles.d configs are written, see tmpfiles.d(5)
+	tmpfilesConfDir = "/etc/tmpfiles.d"
+)
+
+var (
+	// tmpfilesType matches the type of an entry: a type letter, an optional
+	// "+" for the types supporting it and the modifiers.
+	tmpfilesType = regexp.MustCompile(`^([fwdDevqQpLcbCxXrRzZtThHaA])(\+?)[!\-=~^]*$`)
+	// tmpfilesPlusTypes are the types accepting a "+"
+	tmpfilesPlusTypes = "fwpLcbCaA"
+	tmpfilesMode      = regexp.MustCompile(`^(-|[~:]{0,2}[0-7]{3,4})$`)
+	tmpfilesAge       = regexp.MustCompile(`^(-|~?[0-9][0-9a-z.]*)$`)
+)
+
+// validateTmpfiles checks that data is a valid tmpfiles.d config, see
+// tmpfiles.d(5).  Quoted fields are not supported.
+func validateTmpfiles(data string) error {
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := validateTmpfilesEntry(strings.Fields(line)); err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func validateTmpfilesEntry(fields []string) error {
+	if len(fields) < 2 {
+		return fmt.Errorf("expected at least a type and a path")
+	}
+	m := tmpfilesType.FindStringSubmatch(fields[0])
+	if m == nil {
+		return fmt.Errorf("invalid type %q", fields[0])
+	}
+	if m[2] != "" && !strings.Contains(tmpfilesPlusTypes, m[1]) {
+		return fmt.Errorf("type %q doesn't accept \"+\"", m[1])
+	}
+	if !strings.HasPrefix(fields[1], "/") && !strings.HasPrefix(fields[1], "%") {
+		return fmt.Errorf("path %q must be absolute", fields[1])
+	}
+	if len(fields) > 2 && !tmpfilesMode.MatchString(fields[2]) {
+		return fmt.Errorf("invalid mode %q", fields[2])
+	}
+	if len(fields) > 5 && !tmpfilesAge.MatchString(fields[5]) {
+		return fmt.Errorf("invalid age %q", fields[5])
+	}
+	return nil
+}
+
+// appendTmpfiles validates the rendered tmpfiles templates, keyed by name,
+// and adds them to ignCfg as files in tmpfilesConfDir.  A file template
+// writing the same path is an error.
+func appendTmpfiles(ignCfg *ign3types.Config, tmpfiles map[string]string) error {
+	existing := map[string]bool{}
+	for _, f := range ignCfg.Storage.Files {
+		existing[f.Path] = true
+	}
+	names := []string{}
+	for name := range tmpfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > 0 && ignCfg.Ignition.Version == "" {
+		// the config is empty if the template has no files and units
+		ignCfg.Ignition.Version = ign3types.MaxVersion.String()
+	}
+	for _, name := range names {
+		if filepath.Ext(name) != ".conf" {
+			return fmt.Errorf("tmpfiles template %q must have the .conf extension", name)
+		}
+		if err := validateTmpfiles(tmpfiles[name]); err != nil {
+			return fmt.Errorf("invalid tmpfiles template %q: %w", name, err)
+		}
+		path := filepath.Join(tmpfilesConfDir, name)
+		if existing[path] {
+			return fmt.Errorf("tmpfiles template %q conflicts with the file template writing %s", name, path)
+		}
+		mode := 0644
+		overwrite := true
+		source := dataurl.EncodeBytes([]byte(tmpfiles[name]))
+		ignCfg.Storage.Files = append(ignCfg.Storage.Files, ign3types.File{
+			Node: ign3types.Node{
+				Path:      path,
+				Overwrite: &overwrite,
+			},
+			FileEmbedded1: ign3types.FileEmbedded1{
+				Mode: &mode,
+				Contents: ign3types.Resource{
+					Source: &source,
+				},
+			},
+		})
+	}
+	return nil
+}
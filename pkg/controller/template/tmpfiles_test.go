@@ -0,0 +1,107 @@
+package template
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+func TestValidateTmpfiles(t *testing.T) {
+	valid := `# runtime directories
+d /run/example 0755 root root -
+D! /run/example/cache 0700 - - 1d
+
+L+ /etc/example.conf - - - - /usr/share/example/example.conf
+f+~ /run/example/data 0600 - - - aGVsbG8=
+w- /proc/sys/vm/example - - - - 1
+z %t/example :0644
+`
+	assert.NoError(t, validateTmpfiles(valid))
+
+	for _, entry := range []string{
+		"d",
+		"y /run/example",
+		"d+ /run/example",
+		"d run/example",
+		"d /run/example 0888",
+		"d /run/example rw",
+		"d /run/example 0755 root root soon",
+	} {
+		assert.Error(t, validateTmpfiles("d /run/ok\n"+entry), entry)
+	}
+	err := validateTmpfiles("d /run/ok\nd run/example")
+	assert.EqualError(t, err, `line 2: path "run/example" must be absolute`)
+}
+
+func TestTmpfilesTemplates(t *testing.T) {
+	writeTemplate := func(t *testing.T, path, data string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(data), 0644))
+	}
+	config := &mcfgv1.ControllerConfig{
+		Spec: mcfgv1.ControllerConfigSpec{
+			Infra: &configv1.Infrastructure{
+				Status: configv1.InfrastructureStatus{
+					PlatformStatus: &configv1.PlatformStatus{Type: configv1.AWSPlatformType},
+				},
+			},
+		},
+	}
+	renderConfig := &RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, StrictTemplates: true}
+	generate := func(dir, namePath string) (map[string]string, error) {
+		commonAdded := true
+		mc, err := generateMachineConfigForName(renderConfig, "worker", "00-worker", dir, namePath, &commonAdded)
+		if err != nil {
+			return nil, err
+		}
+		ign, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+		require.NoError(t, err)
+		files := map[string]string{}
+		for _, f := range ign.Storage.Files {
+			data, err := ctrlcommon.GetIgnitionFileDataByPath(&ign, f.Path)
+			require.NoError(t, err)
+			files[f.Path] = string(data)
+			assert.Equal(t, 0644, *f.Mode, f.Path)
+		}
+		return files, nil
+	}
+
+	dir := t.TempDir()
+	namePath := filepath.Join(dir, "worker", "00-worker")
+	writeTemplate(t, filepath.Join(namePath, platformBase, tmpfilesDir, "a.conf"), "d /run/a 0755 - - -\n")
+	writeTemplate(t, filepath.Join(namePath, platformBase, tmpfilesDir, "b.conf"), "d /run/b 0755 - - -\n")
+	writeTemplate(t, filepath.Join(namePath, "aws", tmpfilesDir, "b.conf"), "d /run/b-{{.Infra.Status.PlatformStatus.Type}} 0700 - - -\n")
+	files, err := generate(dir, namePath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"/etc/tmpfiles.d/a.conf": "d /run/a 0755 - - -\n",
+		"/etc/tmpfiles.d/b.conf": "d /run/b-AWS 0700 - - -\n",
+	}, files)
+
+	invalid := t.TempDir()
+	namePath = filepath.Join(invalid, "worker", "00-worker")
+	writeTemplate(t, filepath.Join(namePath, platformBase, tmpfilesDir, "a.conf"), "d run/a\n")
+	_, err = generate(invalid, namePath)
+	assert.Error(t, err)
+
+	wrongName := t.TempDir()
+	namePath = filepath.Join(wrongName, "worker", "00-worker")
+	writeTemplate(t, filepath.Join(namePath, platformBase, tmpfilesDir, "a.yaml"), "d /run/a\n")
+	_, err = generate(wrongName, namePath)
+	assert.Error(t, err)
+
+	conflict := t.TempDir()
+	namePath = filepath.Join(conflict, "worker", "00-worker")
+	writeTemplate(t, filepath.Join(namePath, platformBase, tmpfilesDir, "a.conf"), "d /run/a\n")
+	writeTemplate(t, filepath.Join(namePath, platformBase, filesDir, "a.yaml"), "mode: 0644\npath: /etc/tmpfiles.d/a.conf\ncontents:\n  inline: d /run/b\n")
+	_, err = generate(conflict, namePath)
+	assert.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package template
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// ZoneVIPs are the VIPs the nodes of an availability zone use instead of those
+// of the cluster, for OpenStack clusters spanning zones with distinct provider
+// networks.  Each zone has its own VIPs, held by the nodes of the zone.
+type ZoneVIPs struct {
+	// Zone is the availability zone of the nodes, i.e. the value of their
+	// topology.kubernetes.io/zone label.
+	Zone string `json:"zone"`
+	// APIServerInternalIPs are the API VIPs of the zone, one per IP family.
+	APIServerInternalIPs []string `json:"apiServerInternalIPs"`
+	// IngressIPs are the Ingress VIPs of the zone, one per IP family.
+	IngressIPs []string `json:"ingressIPs"`
+}
+
+// zoneNameRegexp matches the zone names the nodes select their VIPs by, which
+// end up in a shell script.
+var zoneNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ParseZoneVIPs parses the YAML list of ZoneVIPs of the zone VIPs ConfigMap
+// and returns it sorted by zone.
+func ParseZoneVIPs(data []byte) ([]ZoneVIPs, error) {
+	var zones []ZoneVIPs
+	if err := yaml.Unmarshal(data, &zones); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, z := range zones {
+		if !zoneNameRegexp.MatchString(z.Zone) {
+			return nil, fmt.Errorf("invalid zone name %q", z.Zone)
+		}
+		if seen[z.Zone] {
+			return nil, fmt.Errorf("zone %s is listed more than once", z.Zone)
+		}
+		seen[z.Zone] = true
+		if err := validateZoneVIPs(z.APIServerInternalIPs); err != nil {
+			return nil, fmt.Errorf("invalid apiServerInternalIPs of zone %s: %w", z.Zone, err)
+		}
+		if err := validateZoneVIPs(z.IngressIPs); err != nil {
+			return nil, fmt.Errorf("invalid ingressIPs of zone %s: %w", z.Zone, err)
+		}
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Zone < zones[j].Zone })
+	return zones, nil
+}
+
+// validateZoneVIPs checks vips holds one or two IPs, of distinct families.
+func validateZoneVIPs(vips []string) error {
+	if len(vips) == 0 || len(vips) > 2 {
+		return fmt.Errorf("expected one or two VIPs, got %d", len(vips))
+	}
+	for _, vip := range vips {
+		if net.ParseIP(vip) == nil {
+			return fmt.Errorf("%q is not an IP address", vip)
+		}
+	}
+	if len(vips) == 2 && (net.ParseIP(vips[0]).To4() == nil) == (net.ParseIP(vips[1]).To4() == nil) {
+		return fmt.Errorf("%s and %s are of the same IP family", vips[0], vips[1])
+	}
+	return nil
+}
+
+// onPremZoneVIPs is a template function that returns the VIPs of each
+// availability zone, or nothing if the nodes use the VIPs of the cluster.
+func onPremZoneVIPs(cfg RenderConfig) ([]ZoneVIPs, error) {
+	if len(cfg.ZoneVIPs) == 0 {
+		return nil, nil
+	}
+	if cfg.Infra.Status.PlatformStatus == nil || cfg.Infra.Status.PlatformStatus.Type != configv1.OpenStackPlatformType {
+		return nil, fmt.Errorf("zone VIPs are only supported on %s", configv1.OpenStackPlatformType)
+	}
+	return cfg.ZoneVIPs, nil
+}
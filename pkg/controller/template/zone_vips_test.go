@@ -0,0 +1,88 @@
+package template
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+func TestParseZoneVIPs(t *testing.T) {
+	zones, err := ParseZoneVIPs([]byte(`
+- zone: az2
+  apiServerInternalIPs: [10.2.0.5, "fd00:2::5"]
+  ingressIPs: [10.2.0.7]
+- zone: az1
+  apiServerInternalIPs: [10.1.0.5]
+  ingressIPs: [10.1.0.7]
+`))
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	expected := []ZoneVIPs{
+		{Zone: "az1", APIServerInternalIPs: []string{"10.1.0.5"}, IngressIPs: []string{"10.1.0.7"}},
+		{Zone: "az2", APIServerInternalIPs: []string{"10.2.0.5", "fd00:2::5"}, IngressIPs: []string{"10.2.0.7"}},
+	}
+	if !reflect.DeepEqual(zones, expected) {
+		t.Fatalf("mismatch got: %v want: %v", zones, expected)
+	}
+
+	for data, want := range map[string]string{
+		"- zone: az1\n  ingressIPs: [10.1.0.7]\n":                                                                                                                "invalid apiServerInternalIPs of zone az1",
+		"- zone: az1\n  apiServerInternalIPs: [10.1.0.5]\n  ingressIPs: [example.com]\n":                                                                         "\"example.com\" is not an IP address",
+		"- zone: az1\n  apiServerInternalIPs: [10.1.0.5, 10.1.0.6]\n  ingressIPs: [10.1.0.7]\n":                                                                  "of the same IP family",
+		"- zone: \"az1; reboot\"\n  apiServerInternalIPs: [10.1.0.5]\n  ingressIPs: [10.1.0.7]\n":                                                                "invalid zone name",
+		"- zone: az1\n  apiServerInternalIPs: [10.1.0.5]\n  ingressIPs: [10.1.0.7]\n- zone: az1\n  apiServerInternalIPs: [10.1.0.5]\n  ingressIPs: [10.1.0.7]\n": "listed more than once",
+	} {
+		_, err := ParseZoneVIPs([]byte(data))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestZoneVIPsTemplates(t *testing.T) {
+	zones := []ZoneVIPs{{Zone: "az1", APIServerInternalIPs: []string{"10.1.0.5"}, IngressIPs: []string{"10.1.0.7"}}}
+
+	config, err := controllerConfigFromFile(configs["openstack"])
+	if err != nil {
+		t.Fatalf("failed to get controllerconfig config: %v", err)
+	}
+	mcs, err := RunRender(templateDir, config, []byte(`{"dummy": "dummy"}`), nil, nil, zones, nil)
+	if err != nil {
+		t.Fatalf("expected nil error %v", err)
+	}
+	for _, mc := range mcs {
+		if mc.Name != "00-master" && mc.Name != "00-worker" {
+			continue
+		}
+		ign, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+		if err != nil {
+			t.Fatalf("failed to parse Ignition config: %v", err)
+		}
+		script, err := ctrlcommon.GetIgnitionFileDataByPath(&ign, "/usr/local/bin/openstack-zone-vips")
+		if err != nil || !strings.Contains(string(script), `"az1")`) {
+			t.Fatalf("%s: expected the zone VIPs script with zone az1, got %q, %v", mc.Name, script, err)
+		}
+		if !findIgnUnit(ign.Systemd.Units, "openstack-zone-vips.service", t) {
+			t.Fatalf("%s: expected the openstack-zone-vips.service unit", mc.Name)
+		}
+		keepalived, err := ctrlcommon.GetIgnitionFileDataByPath(&ign, "/etc/kubernetes/manifests/keepalived.yaml")
+		if err != nil {
+			t.Fatalf("%s: expected the keepalived manifest: %v", mc.Name, err)
+		}
+		if !strings.Contains(string(keepalived), `--api-vip "${API_VIP}"`) {
+			t.Fatalf("%s: expected keepalived to use the VIPs of the zone", mc.Name)
+		}
+	}
+
+	config, err = controllerConfigFromFile(configs["baremetal"])
+	if err != nil {
+		t.Fatalf("failed to get controllerconfig config: %v", err)
+	}
+	_, err = RunRender(templateDir, config, []byte(`{"dummy": "dummy"}`), nil, nil, zones, nil)
+	if err == nil || !strings.Contains(err.Error(), "zone VIPs are only supported on OpenStack") {
+		t.Fatalf("expected zone VIPs to fail rendering on baremetal, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package updatewave
+
+import (
+	"sort"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+const (
+	workerPool = "worker"
+	masterPool = "master"
+)
+
+// wavePlan is the outcome of a sync of an UpdateWave: the state of its stages
+// and which of its pools must be paused or may update.
+type wavePlan struct {
+	stages []mcfgv1.UpdateWaveStage
+	// current is the index of the first stage that isn't updated, or -1 if all are
+	current int
+	hold    []string
+	release []string
+}
+
+// poolStages orders the pools of a wave into the stages they are rolled out in:
+// the custom pools, e.g. infra, together first, then worker, and master last.
+func poolStages(pools []string) [][]string {
+	var custom []string
+	var worker, master bool
+	seen := map[string]bool{}
+	for _, pool := range pools {
+		if seen[pool] {
+			continue
+		}
+		seen[pool] = true
+		switch pool {
+		case workerPool:
+			worker = true
+		case masterPool:
+			master = true
+		default:
+			custom = append(custom, pool)
+		}
+	}
+	sort.Strings(custom)
+
+	var stages [][]string
+	if len(custom) > 0 {
+		stages = append(stages, custom)
+	}
+	if worker {
+		stages = append(stages, []string{workerPool})
+	}
+	if master {
+		stages = append(stages, []string{masterPool})
+	}
+	return stages
+}
+
+// poolUpdated returns true if all the nodes of the pool run its target config.
+func poolUpdated(pool *mcfgv1.MachineConfigPool) bool {
+	return pool.Spec.Configuration.Name != "" &&
+		pool.Spec.Configuration.Name == pool.Status.Configuration.Name &&
+		mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolUpdated)
+}
+
+// planWave computes the stages of the wave from its pools.  The stages before
+// the first one that isn't updated are done and their pools are released, as
+// are the pools of that stage unless the wave is paused or aborted.  The pools
+// of the following stages are held.  A paused wave holds all its pools, so that
+// config changes applied while it's paused wait for it.
+func planWave(wave *mcfgv1.UpdateWave, pools map[string]*mcfgv1.MachineConfigPool) wavePlan {
+	plan := wavePlan{current: -1}
+	for i, names := range poolStages(wave.Spec.MachineConfigPools) {
+		stage := mcfgv1.UpdateWaveStage{MachineConfigPools: names, State: mcfgv1.UpdateWaveStagePending}
+		updated := true
+		for _, name := range names {
+			if !poolUpdated(pools[name]) {
+				updated = false
+			}
+		}
+
+		release := false
+		switch {
+		case plan.current == -1 && updated:
+			stage.State = mcfgv1.UpdateWaveStageUpdated
+			release = !wave.Spec.Paused
+		case plan.current == -1:
+			plan.current = i
+			if !wave.Spec.Paused && !wave.Spec.Abort {
+				stage.State = mcfgv1.UpdateWaveStageUpdating
+				release = true
+			}
+		}
+		if release {
+			plan.release = append(plan.release, names...)
+		} else {
+			plan.hold = append(plan.hold, names...)
+		}
+		plan.stages = append(plan.stages, stage)
+	}
+	return plan
+}
@@ -0,0 +1,106 @@
+package updatewave
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func newPool(name string, updated bool) *mcfgv1.MachineConfigPool {
+	pool := helpers.NewPool(name)
+	status := corev1.ConditionTrue
+	if !updated {
+		pool.Spec.Configuration.Name = "rendered-" + name + "-2"
+		status = corev1.ConditionFalse
+	}
+	pool.Status.Conditions = append(pool.Status.Conditions, *mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolUpdated, status, "", ""))
+	return pool
+}
+
+func newWave(name string, pools ...string) *mcfgv1.UpdateWave {
+	return &mcfgv1.UpdateWave{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       mcfgv1.UpdateWaveSpec{MachineConfigPools: pools},
+	}
+}
+
+func TestPoolStages(t *testing.T) {
+	assert.Equal(t, [][]string{{"gpu", "infra"}, {"worker"}, {"master"}}, poolStages([]string{"master", "worker", "infra", "gpu", "infra"}))
+	assert.Equal(t, [][]string{{"worker"}, {"master"}}, poolStages([]string{"master", "worker"}))
+	assert.Equal(t, [][]string{{"infra"}}, poolStages([]string{"infra"}))
+	assert.Nil(t, poolStages(nil))
+}
+
+func TestPlanWave(t *testing.T) {
+	stage := func(state mcfgv1.UpdateWaveStageState, pools ...string) mcfgv1.UpdateWaveStage {
+		return mcfgv1.UpdateWaveStage{MachineConfigPools: pools, State: state}
+	}
+
+	tests := []struct {
+		name    string
+		updated map[string]bool
+		paused  bool
+		abort   bool
+		stages  []mcfgv1.UpdateWaveStage
+		current int
+		hold    []string
+		release []string
+	}{{
+		name:    "first stage updating",
+		updated: map[string]bool{"infra": false, "worker": false, "master": false},
+		stages:  []mcfgv1.UpdateWaveStage{stage(mcfgv1.UpdateWaveStageUpdating, "infra"), stage(mcfgv1.UpdateWaveStagePending, "worker"), stage(mcfgv1.UpdateWaveStagePending, "master")},
+		current: 0,
+		hold:    []string{"worker", "master"},
+		release: []string{"infra"},
+	}, {
+		name:    "later stage without changes still waits",
+		updated: map[string]bool{"infra": true, "worker": false, "master": true},
+		stages:  []mcfgv1.UpdateWaveStage{stage(mcfgv1.UpdateWaveStageUpdated, "infra"), stage(mcfgv1.UpdateWaveStageUpdating, "worker"), stage(mcfgv1.UpdateWaveStagePending, "master")},
+		current: 1,
+		hold:    []string{"master"},
+		release: []string{"infra", "worker"},
+	}, {
+		name:    "paused holds all pools",
+		updated: map[string]bool{"infra": true, "worker": false, "master": false},
+		paused:  true,
+		stages:  []mcfgv1.UpdateWaveStage{stage(mcfgv1.UpdateWaveStageUpdated, "infra"), stage(mcfgv1.UpdateWaveStagePending, "worker"), stage(mcfgv1.UpdateWaveStagePending, "master")},
+		current: 1,
+		hold:    []string{"infra", "worker", "master"},
+	}, {
+		name:    "aborted holds the pools that aren't updated",
+		updated: map[string]bool{"infra": true, "worker": false, "master": false},
+		abort:   true,
+		stages:  []mcfgv1.UpdateWaveStage{stage(mcfgv1.UpdateWaveStageUpdated, "infra"), stage(mcfgv1.UpdateWaveStagePending, "worker"), stage(mcfgv1.UpdateWaveStagePending, "master")},
+		current: 1,
+		hold:    []string{"worker", "master"},
+		release: []string{"infra"},
+	}, {
+		name:    "completed",
+		updated: map[string]bool{"infra": true, "worker": true, "master": true},
+		stages:  []mcfgv1.UpdateWaveStage{stage(mcfgv1.UpdateWaveStageUpdated, "infra"), stage(mcfgv1.UpdateWaveStageUpdated, "worker"), stage(mcfgv1.UpdateWaveStageUpdated, "master")},
+		current: -1,
+		release: []string{"infra", "worker", "master"},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wave := newWave("wave", "master", "worker", "infra")
+			wave.Spec.Paused = test.paused
+			wave.Spec.Abort = test.abort
+			pools := map[string]*mcfgv1.MachineConfigPool{}
+			for name, updated := range test.updated {
+				pools[name] = newPool(name, updated)
+			}
+
+			plan := planWave(wave, pools)
+			assert.Equal(t, test.stages, plan.stages)
+			assert.Equal(t, test.current, plan.current)
+			assert.Equal(t, test.hold, plan.hold)
+			assert.Equal(t, test.release, plan.release)
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package updatewave
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	corev1clientset "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+)
+
+const (
+	// maxRetries is the number of times an update wave will be retried before it is dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// an update wave is going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+
+	// PausedByAnnotationKey is set on the pools paused by an update wave to the
+	// name of the wave.  The wave only unpauses the pools carrying it, and
+	// leaves the pools paused by an admin alone.
+	PausedByAnnotationKey = "machineconfiguration.openshift.io/paused-by-update-wave"
+)
+
+// Controller defines the update wave controller, which rolls out the pools of
+// an UpdateWave one stage at a time by pausing and unpausing them.
+type Controller struct {
+	client        mcfgclientset.Interface
+	eventRecorder record.EventRecorder
+
+	syncHandler func(key string) error
+
+	waveLister mcfglistersv1.UpdateWaveLister
+	mcpLister  mcfglistersv1.MachineConfigPoolLister
+
+	waveListerSynced cache.InformerSynced
+	mcpListerSynced  cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+}
+
+// New returns a new update wave controller.
+func New(
+	waveInformer mcfginformersv1.UpdateWaveInformer,
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	kubeClient clientset.Interface,
+	mcfgClient mcfgclientset.Interface,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(&corev1clientset.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	ctrl := &Controller{
+		client:        mcfgClient,
+		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-updatewavecontroller"}),
+		queue:         workqueue.NewNamedRateLimitingQueue(ctrlcommon.ControllerRateLimiter(), "machineconfigcontroller-updatewavecontroller"),
+	}
+
+	waveInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addUpdateWave,
+		UpdateFunc: ctrl.updateUpdateWave,
+		DeleteFunc: ctrl.deleteUpdateWave,
+	})
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: ctrl.updateMachineConfigPool,
+	})
+
+	ctrl.syncHandler = ctrl.syncUpdateWave
+
+	ctrl.waveLister = waveInformer.Lister()
+	ctrl.mcpLister = mcpInformer.Lister()
+	ctrl.waveListerSynced = waveInformer.Informer().HasSynced
+	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
+
+	return ctrl
+}
+
+// Run executes the update wave controller
+func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer ctrl.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, ctrl.waveListerSynced, ctrl.mcpListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-UpdateWaveController")
+	defer glog.Info("Shutting down MachineConfigController-UpdateWaveController")
+
+	for i := 0; i < workers; i++ {
+		go wait.Until(ctrl.worker, time.Second, stopCh)
+	}
+
+	<-stopCh
+}
+
+func (ctrl *Controller) addUpdateWave(obj interface{}) {
+	wave := obj.(*mcfgv1.UpdateWave)
+	glog.V(4).Infof("Adding UpdateWave %s", wave.Name)
+	ctrl.queue.Add(wave.Name)
+}
+
+func (ctrl *Controller) updateUpdateWave(old, cur interface{}) {
+	oldWave := old.(*mcfgv1.UpdateWave)
+	curWave := cur.(*mcfgv1.UpdateWave)
+	if reflect.DeepEqual(oldWave.Spec, curWave.Spec) {
+		return
+	}
+	glog.V(4).Infof("Updating UpdateWave %s", curWave.Name)
+	ctrl.queue.Add(curWave.Name)
+}
+
+func (ctrl *Controller) deleteUpdateWave(obj interface{}) {
+	wave, ok := obj.(*mcfgv1.UpdateWave)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		wave, ok = tombstone.Obj.(*mcfgv1.UpdateWave)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not an UpdateWave %#v", obj))
+			return
+		}
+	}
+	glog.V(4).Infof("Deleting UpdateWave %s", wave.Name)
+	ctrl.queue.Add(wave.Name)
+}
+
+func (ctrl *Controller) updateMachineConfigPool(old, cur interface{}) {
+	pool := cur.(*mcfgv1.MachineConfigPool)
+	if wave, ok := pool.Annotations[PausedByAnnotationKey]; ok {
+		ctrl.queue.Add(wave)
+	}
+	waves, err := ctrl.waveLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("error listing update waves: %v", err)
+		return
+	}
+	for _, wave := range waves {
+		for _, name := range wave.Spec.MachineConfigPools {
+			if name == pool.Name {
+				glog.V(4).Infof("MachineConfigPool %s of UpdateWave %s updated", pool.Name, wave.Name)
+				ctrl.queue.Add(wave.Name)
+				break
+			}
+		}
+	}
+}
+
+// worker runs a worker thread that just dequeues items, processes them, and marks them done.
+// It enforces that the syncHandler is never invoked concurrently with the same key.
+func (ctrl *Controller) worker() {
+	for ctrl.processNextWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextWorkItem() bool {
+	key, quit := ctrl.queue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	err := ctrl.syncHandler(key.(string))
+	ctrl.handleErr(err, key)
+
+	return true
+}
+
+func (ctrl *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.queue.Forget(key)
+		return
+	}
+
+	if ctrl.queue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing UpdateWave %v: %v", key, err)
+		ctrl.queue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping UpdateWave %q out of the queue: %v", key, err)
+	ctrl.queue.Forget(key)
+	ctrl.queue.AddAfter(key, 1*time.Minute)
+}
+
+// syncUpdateWave pauses and unpauses the pools of the update wave with the
+// given name according to its stages, or unpauses the pools it paused if
+// it's gone.  This function is not meant to be invoked concurrently with the same key.
+func (ctrl *Controller) syncUpdateWave(name string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing UpdateWave %q (%v)", name, startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing UpdateWave %q (%v)", name, time.Since(startTime))
+	}()
+
+	wave, err := ctrl.waveLister.Get(name)
+	if errors.IsNotFound(err) {
+		return ctrl.releaseAllPools(name)
+	}
+	if err != nil {
+		return err
+	}
+	if mcfgv1.IsUpdateWaveConditionTrue(wave.Status.Conditions, mcfgv1.UpdateWaveCompleted) {
+		return nil
+	}
+	wave = wave.DeepCopy()
+
+	pools := map[string]*mcfgv1.MachineConfigPool{}
+	for _, poolName := range wave.Spec.MachineConfigPools {
+		pool, err := ctrl.mcpLister.Get(poolName)
+		if errors.IsNotFound(err) {
+			return ctrl.syncDegradedStatus(wave, "PoolNotFound", fmt.Sprintf("MachineConfigPool %s does not exist", poolName))
+		}
+		if err != nil {
+			return err
+		}
+		pools[poolName] = pool
+	}
+	if other, pool, err := ctrl.getConflictingWave(wave); err != nil {
+		return err
+	} else if other != "" {
+		return ctrl.syncDegradedStatus(wave, "Conflict", fmt.Sprintf("MachineConfigPool %s belongs to UpdateWave %s", pool, other))
+	}
+
+	plan := planWave(wave, pools)
+	for _, poolName := range plan.hold {
+		if err := ctrl.holdPool(pools[poolName], wave.Name); err != nil {
+			return err
+		}
+	}
+	for _, poolName := range plan.release {
+		if err := ctrl.releasePool(pools[poolName], wave.Name); err != nil {
+			return err
+		}
+	}
+	return ctrl.syncStatus(wave, plan)
+}
+
+// getConflictingWave returns the name of an older wave that isn't completed and
+// shares a pool with the given one, along with that pool.
+func (ctrl *Controller) getConflictingWave(wave *mcfgv1.UpdateWave) (string, string, error) {
+	waves, err := ctrl.waveLister.List(labels.Everything())
+	if err != nil {
+		return "", "", err
+	}
+	for _, other := range waves {
+		if other.Name == wave.Name || mcfgv1.IsUpdateWaveConditionTrue(other.Status.Conditions, mcfgv1.UpdateWaveCompleted) {
+			continue
+		}
+		if other.CreationTimestamp.After(wave.CreationTimestamp.Time) ||
+			(other.CreationTimestamp.Equal(&wave.CreationTimestamp) && other.Name > wave.Name) {
+			continue
+		}
+		for _, pool := range wave.Spec.MachineConfigPools {
+			for _, otherPool := range other.Spec.MachineConfigPools {
+				if pool == otherPool {
+					return other.Name, pool, nil
+				}
+			}
+		}
+	}
+	return "", "", nil
+}
+
+// holdPool pauses the pool for the wave, unless it's already paused.
+func (ctrl *Controller) holdPool(pool *mcfgv1.MachineConfigPool, wave string) error {
+	if pool.Spec.Paused {
+		return nil
+	}
+	newPool := pool.DeepCopy()
+	newPool.Spec.Paused = true
+	if newPool.Annotations == nil {
+		newPool.Annotations = map[string]string{}
+	}
+	newPool.Annotations[PausedByAnnotationKey] = wave
+	if _, err := ctrl.client.MachineconfigurationV1().MachineConfigPools().Update(context.TODO(), newPool, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	glog.Infof("Pool %s: paused by UpdateWave %s", pool.Name, wave)
+	return nil
+}
+
+// releasePool unpauses the pool if it was paused by the wave.
+func (ctrl *Controller) releasePool(pool *mcfgv1.MachineConfigPool, wave string) error {
+	if pool.Annotations[PausedByAnnotationKey] != wave {
+		return nil
+	}
+	newPool := pool.DeepCopy()
+	newPool.Spec.Paused = false
+	delete(newPool.Annotations, PausedByAnnotationKey)
+	if _, err := ctrl.client.MachineconfigurationV1().MachineConfigPools().Update(context.TODO(), newPool, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	glog.Infof("Pool %s: unpaused by UpdateWave %s", pool.Name, wave)
+	return nil
+}
+
+// releaseAllPools unpauses all the pools paused by the wave, e.g. once it's deleted.
+func (ctrl *Controller) releaseAllPools(wave string) error {
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, pool := range pools {
+		if err := ctrl.releasePool(pool, wave); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ctrl *Controller) syncDegradedStatus(wave *mcfgv1.UpdateWave, reason, message string) error {
+	glog.Warningf("UpdateWave %s is degraded: %s", wave.Name, message)
+	newStatus := wave.Status.DeepCopy()
+	newStatus.ObservedGeneration = wave.Generation
+	mcfgv1.SetUpdateWaveCondition(newStatus, *mcfgv1.NewUpdateWaveCondition(mcfgv1.UpdateWaveDegraded, corev1.ConditionTrue, reason, message))
+	mcfgv1.SetUpdateWaveCondition(newStatus, *mcfgv1.NewUpdateWaveCondition(mcfgv1.UpdateWaveProgressing, corev1.ConditionFalse, reason, ""))
+	return ctrl.updateStatus(wave, newStatus)
+}
+
+func (ctrl *Controller) syncStatus(wave *mcfgv1.UpdateWave, plan wavePlan) error {
+	newStatus := wave.Status.DeepCopy()
+	newStatus.ObservedGeneration = wave.Generation
+	newStatus.Stages = plan.stages
+	mcfgv1.SetUpdateWaveCondition(newStatus, *mcfgv1.NewUpdateWaveCondition(mcfgv1.UpdateWaveDegraded, corev1.ConditionFalse, "", ""))
+
+	var progressing, completed *mcfgv1.UpdateWaveCondition
+	switch {
+	case wave.Spec.Paused:
+		progressing = mcfgv1.NewUpdateWaveCondition(mcfgv1.UpdateWaveProgressing, corev1.ConditionFalse, "Paused", "The wave is paused")
+		completed = mcfgv1.NewUpdateWaveCondition(mcfgv1.UpdateWaveCompleted, corev1.ConditionFalse, "", "")
+	case wave.Spec.Abort:
+		progressing = mcfgv1.NewUpdateWaveCondition(mcfgv1.UpdateWaveProgressing, corev1.ConditionFalse, "Aborted", "The wave is aborted, delete it to unpause its pools")
+		completed = mcfgv1.NewUpdateWaveCondition(mcfgv1.UpdateWaveCompleted, corev1.ConditionFalse, "Aborted", "")
+	case plan.current == -1:
+		progressing = mcfgv1.NewUpdateWaveCondition(mcfgv1.UpdateWaveProgressing, corev1.ConditionFalse, "Completed", "")
+		completed = mcfgv1.NewUpdateWaveCondition(mcfgv1.UpdateWaveCompleted, corev1.ConditionTrue, "", "All the pools of the wave are updated")
+	default:
+		stage := plan.stages[plan.current].MachineConfigPools
+		message := fmt.Sprintf("Updating stage %d of %d: %s", plan.current+1, len(plan.stages), strings.Join(stage, ", "))
+		progressing = mcfgv1.NewUpdateWaveCondition(mcfgv1.UpdateWaveProgressing, corev1.ConditionTrue, "Updating", message)
+		completed = mcfgv1.NewUpdateWaveCondition(mcfgv1.UpdateWaveCompleted, corev1.ConditionFalse, "", "")
+		if !reflect.DeepEqual(wave.Status.Stages, plan.stages) {
+			ctrl.eventRecorder.Event(wave, corev1.EventTypeNormal, "StageUpdating", message)
+		}
+	}
+	mcfgv1.SetUpdateWaveCondition(newStatus, *progressing)
+	mcfgv1.SetUpdateWaveCondition(newStatus, *completed)
+	return ctrl.updateStatus(wave, newStatus)
+}
+
+func (ctrl *Controller) updateStatus(wave *mcfgv1.UpdateWave, newStatus *mcfgv1.UpdateWaveStatus) error {
+	if reflect.DeepEqual(&wave.Status, newStatus) {
+		return nil
+	}
+	wave.Status = *newStatus
+	_, err := ctrl.client.MachineconfigurationV1().UpdateWaves().UpdateStatus(context.TODO(), wave, metav1.UpdateOptions{})
+	return err
+}
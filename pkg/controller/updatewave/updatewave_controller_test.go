@@ -0,0 +1,72 @@
+package updatewave
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func newController(t *testing.T, objects ...runtime.Object) (*Controller, *fake.Clientset) {
+	f := helpers.NewFakeInformers(objects...)
+	ctrl := New(f.Informers.Machineconfiguration().V1().UpdateWaves(), f.Informers.Machineconfiguration().V1().MachineConfigPools(), f.KubeClient, f.Client)
+	ctrl.eventRecorder = &record.FakeRecorder{}
+	f.FillListers(t)
+	return ctrl, f.Client
+}
+
+func getPool(t *testing.T, client *fake.Clientset, name string) *mcfgv1.MachineConfigPool {
+	pool, err := client.MachineconfigurationV1().MachineConfigPools().Get(context.TODO(), name, metav1.GetOptions{})
+	require.NoError(t, err)
+	return pool
+}
+
+func TestSyncUpdateWave(t *testing.T) {
+	wave := newWave("wave", "master", "worker")
+	master := newPool("master", false)
+	worker := newPool("worker", false)
+	ctrl, client := newController(t, wave, master, worker)
+
+	require.NoError(t, ctrl.syncUpdateWave("wave"))
+	// the worker stage rolls out first, master waits
+	assert.False(t, getPool(t, client, "worker").Spec.Paused)
+	master = getPool(t, client, "master")
+	assert.True(t, master.Spec.Paused)
+	assert.Equal(t, "wave", master.Annotations[PausedByAnnotationKey])
+
+	wave, err := client.MachineconfigurationV1().UpdateWaves().Get(context.TODO(), "wave", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, mcfgv1.IsUpdateWaveConditionTrue(wave.Status.Conditions, mcfgv1.UpdateWaveProgressing))
+	assert.Equal(t, mcfgv1.UpdateWaveStageUpdating, wave.Status.Stages[0].State)
+
+	// deleting the wave unpauses the pools it paused
+	ctrl, client = newController(t, master, worker)
+	require.NoError(t, ctrl.syncUpdateWave("wave"))
+	master = getPool(t, client, "master")
+	assert.False(t, master.Spec.Paused)
+	assert.NotContains(t, master.Annotations, PausedByAnnotationKey)
+}
+
+func TestSyncUpdateWaveConflict(t *testing.T) {
+	older := newWave("older", "worker")
+	older.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+	wave := newWave("wave", "worker")
+	wave.CreationTimestamp = metav1.Now()
+	ctrl, client := newController(t, older, wave, newPool("worker", false))
+
+	require.NoError(t, ctrl.syncUpdateWave("wave"))
+	wave, err := client.MachineconfigurationV1().UpdateWaves().Get(context.TODO(), "wave", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, mcfgv1.IsUpdateWaveConditionTrue(wave.Status.Conditions, mcfgv1.UpdateWaveDegraded))
+	assert.Equal(t, "Conflict", mcfgv1.GetUpdateWaveCondition(wave.Status, mcfgv1.UpdateWaveDegraded).Reason)
+	assert.False(t, getPool(t, client, "worker").Spec.Paused)
+}
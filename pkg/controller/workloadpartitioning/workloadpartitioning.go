@@ -0,0 +1,136 @@
+package workloadpartitioning
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	// DefaultAnnotationPrefix is the annotation prefix of the management
+	// workloads unless the pool sets one.
+	DefaultAnnotationPrefix = "resources.workload.openshift.io"
+
+	// activationAnnotation is the pod annotation marking a management
+	// workload, set by the admission plugin of the apiserver on the pods of
+	// the namespaces annotated with workload.openshift.io/allowed=management.
+	activationAnnotation = "target.workload.openshift.io/management"
+
+	crioConfPath    = "/etc/crio/crio.conf.d/01-workload-partitioning"
+	kubeletConfPath = "/etc/kubernetes/openshift-workload-pinning"
+)
+
+// crioConfTemplate is the CRI-O workload of the management workloads: CRI-O
+// runs their containers on the management CPUs, with the CPU shares from the
+// pod annotations in place of the ones of their requests.
+const crioConfTemplate = `[crio.runtime.workloads.management]
+activation_annotation = "%s"
+annotation_prefix = "%s"
+resources = { "cpushares" = 0, "cpuset" = "%s" }
+`
+
+// kubeletConfTemplate tells the kubelet to advertise the management CPUs as
+// the management.workload.openshift.io/cores extended resource, which the
+// admission plugin moves the CPU requests of the management workloads to.
+const kubeletConfTemplate = `{
+  "management": {
+    "cpuset": "%s"
+  }
+}
+`
+
+// generatedConfigKind describes the MachineConfigs generated from the workload partitioning of the pools.
+var generatedConfigKind = ctrlcommon.GeneratedConfigKind{
+	Suffix:        "workload-partitioning",
+	Description:   "workload partitioning",
+	ConditionType: mcfgv1.MachineConfigPoolWorkloadPartitioningDegraded,
+	InvalidReason: "WorkloadPartitioningInvalid",
+}
+
+func annotationPrefix(cfg *mcfgv1.WorkloadPartitioningConfiguration) string {
+	if cfg.AnnotationPrefix == "" {
+		return DefaultAnnotationPrefix
+	}
+	return cfg.AnnotationPrefix
+}
+
+// validateWorkloadPartitioning checks the workload partitioning of a pool.
+func validateWorkloadPartitioning(cfg *mcfgv1.WorkloadPartitioningConfiguration) error {
+	if _, err := ctrlcommon.ParseCPUSet(cfg.ManagementCPUs); err != nil {
+		return fmt.Errorf("invalid managementCPUs: %v", err)
+	}
+	if cfg.AnnotationPrefix != "" {
+		if errs := validation.IsDNS1123Subdomain(cfg.AnnotationPrefix); len(errs) > 0 {
+			return fmt.Errorf("invalid annotationPrefix %q: %s", cfg.AnnotationPrefix, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// validateKubeletReservation checks that the kubelet of the pool reserves the
+// management CPUs, so that the other workloads don't run on them: the
+// KubeletConfigs of the pool which set reservedSystemCPUs must all set it to
+// the management CPUs, and there must be at least one.
+func validateKubeletReservation(pool *mcfgv1.MachineConfigPool, kcs []*mcfgv1.KubeletConfig) error {
+	management, err := ctrlcommon.ParseCPUSet(pool.Spec.WorkloadPartitioning.ManagementCPUs)
+	if err != nil {
+		return err
+	}
+	reserved := false
+	for _, kc := range kcs {
+		selector, err := metav1.LabelSelectorAsSelector(kc.Spec.MachineConfigPoolSelector)
+		if err != nil || selector.Empty() || !selector.Matches(labels.Set(pool.Labels)) {
+			continue
+		}
+		value, err := ctrlcommon.ReservedSystemCPUs(kc)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			continue
+		}
+		cpus, err := ctrlcommon.ParseCPUSet(value)
+		if err != nil {
+			return fmt.Errorf("KubeletConfig %s: invalid reservedSystemCPUs: %v", kc.Name, err)
+		}
+		if !cpus.Equal(management) {
+			return fmt.Errorf("KubeletConfig %s reserves CPUs %s, not the management CPUs %s", kc.Name, value, pool.Spec.WorkloadPartitioning.ManagementCPUs)
+		}
+		reserved = true
+	}
+	if !reserved {
+		return fmt.Errorf("no KubeletConfig of the pool sets reservedSystemCPUs to the management CPUs %s", pool.Spec.WorkloadPartitioning.ManagementCPUs)
+	}
+	return nil
+}
+
+// generateMachineConfig renders the workload partitioning of the pool into a
+// MachineConfig for the pool's role, once the kubelet of the pool reserves
+// the management CPUs.
+func generateMachineConfig(pool *mcfgv1.MachineConfigPool, kcs []*mcfgv1.KubeletConfig) (*mcfgv1.MachineConfig, error) {
+	cfg := pool.Spec.WorkloadPartitioning
+	if err := validateWorkloadPartitioning(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateKubeletReservation(pool, kcs); err != nil {
+		return nil, err
+	}
+
+	ignConfig := ctrlcommon.NewIgnConfig()
+	ignConfig.Storage.Files = append(ignConfig.Storage.Files,
+		ctrlcommon.NewGeneratedFile(crioConfPath, fmt.Sprintf(crioConfTemplate, activationAnnotation, annotationPrefix(cfg), cfg.ManagementCPUs)),
+		ctrlcommon.NewGeneratedFile(kubeletConfPath, fmt.Sprintf(kubeletConfTemplate, cfg.ManagementCPUs)),
+	)
+
+	mc, err := generatedConfigKind.NewMachineConfig(pool, ignConfig)
+	if err != nil {
+		return nil, err
+	}
+	return mc, nil
+}
@@ -0,0 +1,276 @@
+package workloadpartitioning
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	corev1clientset "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+)
+
+const (
+	// maxRetries is the number of times a pool will be retried before it is dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// a pool is going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+)
+
+// Controller defines the workload partitioning controller, which renders the
+// workload partitioning of the pools into MachineConfigs.
+type Controller struct {
+	generated *ctrlcommon.GeneratedConfigs
+
+	syncHandler func(key string) error
+
+	mcpLister mcfglistersv1.MachineConfigPoolLister
+	kcLister  mcfglistersv1.KubeletConfigLister
+
+	mcpListerSynced cache.InformerSynced
+	mcListerSynced  cache.InformerSynced
+	kcListerSynced  cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+}
+
+// New returns a new workload partitioning controller.
+func New(
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	mcInformer mcfginformersv1.MachineConfigInformer,
+	kcInformer mcfginformersv1.KubeletConfigInformer,
+	kubeClient clientset.Interface,
+	mcfgClient mcfgclientset.Interface,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(&corev1clientset.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	ctrl := &Controller{
+		generated: &ctrlcommon.GeneratedConfigs{
+			GeneratedConfigKind: generatedConfigKind,
+			Client:              mcfgClient,
+			EventRecorder:       eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-workloadpartitioningcontroller"}),
+		},
+		queue: workqueue.NewNamedRateLimitingQueue(ctrlcommon.ControllerRateLimiter(), "machineconfigcontroller-workloadpartitioningcontroller"),
+	}
+
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addPool,
+		UpdateFunc: ctrl.updatePool,
+	})
+	mcInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: ctrl.updateMachineConfig,
+		DeleteFunc: ctrl.deleteMachineConfig,
+	})
+	kcInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addKubeletConfig,
+		UpdateFunc: ctrl.updateKubeletConfig,
+		DeleteFunc: ctrl.deleteKubeletConfig,
+	})
+
+	ctrl.syncHandler = ctrl.syncPool
+
+	ctrl.mcpLister = mcpInformer.Lister()
+	ctrl.generated.MCLister = mcInformer.Lister()
+	ctrl.kcLister = kcInformer.Lister()
+	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
+	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
+	ctrl.kcListerSynced = kcInformer.Informer().HasSynced
+
+	return ctrl
+}
+
+// Run executes the workload partitioning controller
+func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer ctrl.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.mcListerSynced, ctrl.kcListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-WorkloadPartitioningController")
+	defer glog.Info("Shutting down MachineConfigController-WorkloadPartitioningController")
+
+	for i := 0; i < workers; i++ {
+		go wait.Until(ctrl.worker, time.Second, stopCh)
+	}
+
+	<-stopCh
+}
+
+func (ctrl *Controller) addPool(obj interface{}) {
+	pool := obj.(*mcfgv1.MachineConfigPool)
+	glog.V(4).Infof("Adding MachineConfigPool %s", pool.Name)
+	ctrl.queue.Add(pool.Name)
+}
+
+func (ctrl *Controller) updatePool(old, cur interface{}) {
+	oldPool := old.(*mcfgv1.MachineConfigPool)
+	curPool := cur.(*mcfgv1.MachineConfigPool)
+	// Pools are updated all the time, only the workload partitioning and the
+	// labels the KubeletConfigs select the pool by matter.
+	if reflect.DeepEqual(oldPool.Spec.WorkloadPartitioning, curPool.Spec.WorkloadPartitioning) && reflect.DeepEqual(oldPool.Labels, curPool.Labels) {
+		return
+	}
+	glog.V(4).Infof("Updating workload partitioning of MachineConfigPool %s", curPool.Name)
+	ctrl.queue.Add(curPool.Name)
+}
+
+func (ctrl *Controller) addKubeletConfig(obj interface{}) {
+	ctrl.enqueuePartitionedPools()
+}
+
+func (ctrl *Controller) updateKubeletConfig(old, cur interface{}) {
+	oldKC := old.(*mcfgv1.KubeletConfig)
+	curKC := cur.(*mcfgv1.KubeletConfig)
+	if reflect.DeepEqual(oldKC.Spec, curKC.Spec) {
+		return
+	}
+	ctrl.enqueuePartitionedPools()
+}
+
+func (ctrl *Controller) deleteKubeletConfig(obj interface{}) {
+	ctrl.enqueuePartitionedPools()
+}
+
+// enqueuePartitionedPools requeues the pools with a workload partitioning
+// when a KubeletConfig changes, as their kubelet must reserve the management
+// CPUs.
+func (ctrl *Controller) enqueuePartitionedPools() {
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't list MachineConfigPools: %w", err))
+		return
+	}
+	for _, pool := range pools {
+		if pool.Spec.WorkloadPartitioning != nil {
+			glog.V(4).Infof("KubeletConfig changed, requeueing MachineConfigPool %s", pool.Name)
+			ctrl.queue.Add(pool.Name)
+		}
+	}
+}
+
+func (ctrl *Controller) updateMachineConfig(old, cur interface{}) {
+	mc := cur.(*mcfgv1.MachineConfig)
+	if pool := generatedConfigKind.OwningPool(mc); pool != "" {
+		glog.V(4).Infof("MachineConfig %s generated for MachineConfigPool %s updated", mc.Name, pool)
+		ctrl.queue.Add(pool)
+	}
+}
+
+func (ctrl *Controller) deleteMachineConfig(obj interface{}) {
+	mc, ok := obj.(*mcfgv1.MachineConfig)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		mc, ok = tombstone.Obj.(*mcfgv1.MachineConfig)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a MachineConfig %#v", obj))
+			return
+		}
+	}
+	if pool := generatedConfigKind.OwningPool(mc); pool != "" {
+		glog.V(4).Infof("MachineConfig %s generated for MachineConfigPool %s deleted", mc.Name, pool)
+		ctrl.queue.Add(pool)
+	}
+}
+
+// worker runs a worker thread that just dequeues items, processes them, and marks them done.
+// It enforces that the syncHandler is never invoked concurrently with the same key.
+func (ctrl *Controller) worker() {
+	for ctrl.processNextWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextWorkItem() bool {
+	key, quit := ctrl.queue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	err := ctrl.syncHandler(key.(string))
+	ctrl.handleErr(err, key)
+
+	return true
+}
+
+func (ctrl *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.queue.Forget(key)
+		return
+	}
+
+	if ctrl.queue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing workload partitioning of MachineConfigPool %v: %v", key, err)
+		ctrl.queue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping MachineConfigPool %q out of the queue: %v", key, err)
+	ctrl.queue.Forget(key)
+	ctrl.queue.AddAfter(key, 1*time.Minute)
+}
+
+// syncPool renders the workload partitioning of the pool with the given name
+// into its MachineConfig, or deletes the MachineConfig if there is none.
+// A deleted pool's MachineConfig is garbage collected through its owner reference.
+// This function is not meant to be invoked concurrently with the same key.
+func (ctrl *Controller) syncPool(name string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing workload partitioning of MachineConfigPool %q (%v)", name, startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing workload partitioning of MachineConfigPool %q (%v)", name, time.Since(startTime))
+	}()
+
+	pool, err := ctrl.mcpLister.Get(name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if pool.Spec.WorkloadPartitioning == nil {
+		return ctrl.generated.Delete(pool)
+	}
+
+	kcs, err := ctrl.kcLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	mc, err := generateMachineConfig(pool, kcs)
+	if err != nil {
+		return ctrl.generated.SyncFailingStatus(pool, err)
+	}
+	if updated, err := ctrl.generated.Apply(pool, mc); err != nil {
+		return err
+	} else if updated {
+		ctrl.generated.EventRecorder.Eventf(pool, corev1.EventTypeNormal, "WorkloadPartitioningApplied", "Generated MachineConfig %s from the workload partitioning", mc.Name)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package workloadpartitioning
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestValidateWorkloadPartitioning(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   mcfgv1.WorkloadPartitioningConfiguration
+		valid bool
+	}{{
+		name:  "default prefix",
+		cfg:   mcfgv1.WorkloadPartitioningConfiguration{ManagementCPUs: "0-1"},
+		valid: true,
+	}, {
+		name:  "custom prefix",
+		cfg:   mcfgv1.WorkloadPartitioningConfiguration{ManagementCPUs: "0-1", AnnotationPrefix: "resources.workload.example.com"},
+		valid: true,
+	}, {
+		name: "invalid cpuset",
+		cfg:  mcfgv1.WorkloadPartitioningConfiguration{ManagementCPUs: "0-1;2"},
+	}, {
+		name: "invalid prefix",
+		cfg:  mcfgv1.WorkloadPartitioningConfiguration{ManagementCPUs: "0-1", AnnotationPrefix: `a" b`},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateWorkloadPartitioning(&test.cfg)
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func newKubeletConfig(name, pool, raw string) *mcfgv1.KubeletConfig {
+	return &mcfgv1.KubeletConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: mcfgv1.KubeletConfigSpec{
+			MachineConfigPoolSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"pools.operator.machineconfiguration.openshift.io/" + pool: ""},
+			},
+			KubeletConfig: &runtime.RawExtension{Raw: []byte(raw)},
+		},
+	}
+}
+
+func TestValidateKubeletReservation(t *testing.T) {
+	pool := helpers.NewPool("infra")
+	pool.Spec.WorkloadPartitioning = &mcfgv1.WorkloadPartitioningConfiguration{ManagementCPUs: "0-1,4"}
+	tests := []struct {
+		name  string
+		kcs   []*mcfgv1.KubeletConfig
+		valid bool
+	}{{
+		name:  "same CPUs",
+		kcs:   []*mcfgv1.KubeletConfig{newKubeletConfig("a", "infra", `{"reservedSystemCPUs":"0,1,4"}`)},
+		valid: true,
+	}, {
+		name: "other KubeletConfigs ignored",
+		kcs: []*mcfgv1.KubeletConfig{
+			newKubeletConfig("a", "infra", `{"reservedSystemCPUs":"0-1,4"}`),
+			newKubeletConfig("b", "infra", `{"maxPods":500}`),
+			newKubeletConfig("c", "worker", `{"reservedSystemCPUs":"2"}`),
+		},
+		valid: true,
+	}, {
+		name: "no KubeletConfig",
+	}, {
+		name: "no reservation",
+		kcs:  []*mcfgv1.KubeletConfig{newKubeletConfig("a", "infra", `{"maxPods":500}`)},
+	}, {
+		name: "other CPUs",
+		kcs:  []*mcfgv1.KubeletConfig{newKubeletConfig("a", "infra", `{"reservedSystemCPUs":"0-1"}`)},
+	}, {
+		name: "conflicting KubeletConfigs",
+		kcs: []*mcfgv1.KubeletConfig{
+			newKubeletConfig("a", "infra", `{"reservedSystemCPUs":"0-1,4"}`),
+			newKubeletConfig("b", "infra", `{"reservedSystemCPUs":"0-3"}`),
+		},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateKubeletReservation(pool, test.kcs)
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestGenerateMachineConfig(t *testing.T) {
+	pool := helpers.NewPool("infra")
+	pool.Spec.WorkloadPartitioning = &mcfgv1.WorkloadPartitioningConfiguration{ManagementCPUs: "0-1,52-53"}
+	kcs := []*mcfgv1.KubeletConfig{newKubeletConfig("a", "infra", `{"reservedSystemCPUs":"0-1,52-53"}`)}
+	mc, err := generateMachineConfig(pool, kcs)
+	require.NoError(t, err)
+	assert.Equal(t, "99-infra-generated-workload-partitioning", mc.Name)
+	assert.Equal(t, "infra", mc.Labels[mcfgv1.MachineConfigRoleLabelKey])
+	assert.Equal(t, "infra", generatedConfigKind.OwningPool(mc))
+
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	require.NoError(t, err)
+	require.Len(t, ignCfg.Storage.Files, 2)
+	conf, err := ctrlcommon.GetIgnitionFileDataByPath(&ignCfg, "/etc/crio/crio.conf.d/01-workload-partitioning")
+	require.NoError(t, err)
+	assert.Equal(t, `[crio.runtime.workloads.management]
+activation_annotation = "target.workload.openshift.io/management"
+annotation_prefix = "resources.workload.openshift.io"
+resources = { "cpushares" = 0, "cpuset" = "0-1,52-53" }
+`, string(conf))
+	conf, err = ctrlcommon.GetIgnitionFileDataByPath(&ignCfg, "/etc/kubernetes/openshift-workload-pinning")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"management":{"cpuset":"0-1,52-53"}}`, string(conf))
+}
@@ -0,0 +1,298 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// bootCounterAttempts is how many times the bootloader boots a new OS
+// deployment which fails its boot health checks before falling back to the
+// previous one, the default of greenboot.
+const bootCounterAttempts = 3
+
+var (
+	// grubenvPath is the GRUB environment block, where greenboot keeps the
+	// boot counter and whether the last boot succeeded.
+	grubenvPath = "/boot/grub2/grubenv"
+	// greenbootFallbackUnitPath is the greenboot unit which makes the previous
+	// deployment the default once the bootloader fell back to it.  The boot
+	// counter isn't armed without it.
+	greenbootFallbackUnitPath = "/usr/lib/systemd/system/greenboot-rpm-ostree-grub2-check-fallback.service"
+	bootFallbackRecordPath    = constants.BootFallbackRecordPath
+
+	// setGrubenv sets variables of the GRUB environment block.
+	setGrubenv = func(vars ...string) error {
+		return withWritableBoot(func() error {
+			return runCmdSync("grub2-editenv", append([]string{grubenvPath, "set"}, vars...)...)
+		})
+	}
+	// rpmOstreeRollback makes the other deployment the default.
+	rpmOstreeRollback = func() error {
+		return runRpmOstree("rollback")
+	}
+)
+
+// bootFallbackRecord is what the daemon records before rebooting into a
+// staged deployment it armed the boot counter for.
+type bootFallbackRecord struct {
+	// Config is the config the node reboots into.
+	Config string `json:"config"`
+	// PreviousConfig is the config of the booted deployment, which the
+	// bootloader falls back to.
+	PreviousConfig string `json:"previousConfig"`
+	// Checksum is the checksum of the staged deployment.
+	Checksum string `json:"checksum"`
+	// PreviousChecksum is the checksum of the booted deployment.
+	PreviousChecksum string `json:"previousChecksum"`
+	// BootID is the boot the counter was armed in.
+	BootID string `json:"bootID"`
+	// FellBack is set once the daemon found the node fell back to the
+	// previous deployment.
+	FellBack bool `json:"fellBack,omitempty"`
+}
+
+// readBootFallbackRecord returns the record at path, or nil if none.
+func readBootFallbackRecord(path string) (*bootFallbackRecord, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	record := &bootFallbackRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	return record, nil
+}
+
+// writeBootFallbackRecord writes record at path.
+func writeBootFallbackRecord(path string, record *bootFallbackRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomicallyWithDefaults(path, data)
+}
+
+// removeBootFallbackRecord removes the record at path, if any.
+func removeBootFallbackRecord(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// parseGrubenv returns the variables of a GRUB environment block.
+func parseGrubenv(data []byte) map[string]string {
+	vars := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if kv := strings.SplitN(line, "=", 2); len(kv) == 2 {
+			vars[kv[0]] = kv[1]
+		}
+	}
+	return vars
+}
+
+// bootSucceeded returns whether greenboot marked the current boot successful,
+// i.e. the node passed its boot health checks.
+func bootSucceeded() (bool, error) {
+	data, err := ioutil.ReadFile(grubenvPath)
+	if err != nil {
+		return false, err
+	}
+	return parseGrubenv(data)["boot_success"] == "1", nil
+}
+
+// armBootCounter arms the boot counter of greenboot before the node reboots
+// into the deployment staged for config, so that the bootloader falls back to
+// the booted deployment if the node keeps failing its boot health checks.
+// Nothing is armed without greenboot, or without a staged deployment, e.g.
+// when only files changed: the previous deployment would run an older OS then.
+func (dn *Daemon) armBootCounter(config string) error {
+	if dn.node == nil || !dn.os.IsCoreOSVariant() {
+		return nil
+	}
+	if _, err := os.Stat(greenbootFallbackUnitPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	previousConfig := dn.node.Annotations[constants.CurrentMachineConfigAnnotationKey]
+	if previousConfig == config {
+		// rolling back to the current config, e.g. after a failed
+		// post-update validation
+		return removeBootFallbackRecord(bootFallbackRecordPath)
+	}
+
+	deployments, err := dn.NodeUpdaterClient.GetDeployments()
+	if err != nil {
+		return err
+	}
+	var booted, staged *RpmOstreeDeployment
+	for i := range deployments {
+		switch {
+		case deployments[i].Booted:
+			booted = &deployments[i]
+		case deployments[i].Staged:
+			staged = &deployments[i]
+		}
+	}
+	if booted == nil || staged == nil {
+		return removeBootFallbackRecord(bootFallbackRecordPath)
+	}
+
+	if err := writeBootFallbackRecord(bootFallbackRecordPath, &bootFallbackRecord{
+		Config:           config,
+		PreviousConfig:   previousConfig,
+		Checksum:         staged.Checksum,
+		PreviousChecksum: booted.Checksum,
+		BootID:           dn.bootID,
+	}); err != nil {
+		return err
+	}
+	if err := setGrubenv(fmt.Sprintf("boot_counter=%d", bootCounterAttempts), "boot_success=0"); err != nil {
+		return fmt.Errorf("failed to arm the boot counter in %s: %w", grubenvPath, err)
+	}
+	dn.logSystem("Armed the boot counter: deployment %s of config %s falls back to deployment %s of config %s after %d failed boots", staged.Checksum, config, booted.Checksum, previousConfig, bootCounterAttempts)
+	return nil
+}
+
+// checkBootFallback finds out on startup whether the bootloader fell back to
+// the deployment the node booted before the daemon armed the boot counter.
+// When it did, the files of the previous config are restored and the config
+// which failed to boot is recorded so that the daemon doesn't update to it
+// again, see recoverFromBootFallback.
+func (dn *Daemon) checkBootFallback(state *stateAndConfigs) error {
+	record, err := readBootFallbackRecord(bootFallbackRecordPath)
+	if err != nil || record == nil || record.FellBack || record.BootID == dn.bootID {
+		return err
+	}
+	booted, err := dn.NodeUpdaterClient.GetBootedDeployment()
+	if err != nil {
+		return err
+	}
+
+	switch booted.Checksum {
+	case record.Checksum:
+		// greenboot may not have run the health checks yet, they're
+		// looked at again on the next start
+		succeeded, err := bootSucceeded()
+		if err != nil || !succeeded {
+			return err
+		}
+		glog.Infof("Deployment %s of config %s booted successfully", record.Checksum, record.Config)
+		return removeBootFallbackRecord(bootFallbackRecordPath)
+	case record.PreviousChecksum:
+		return dn.recoverFromBootFallback(record, state)
+	default:
+		// the node moved on to another deployment since
+		return removeBootFallbackRecord(bootFallbackRecordPath)
+	}
+}
+
+// recoverFromBootFallback brings the node back to the previous config of
+// record after the bootloader fell back to its deployment.  The fallback only
+// rolled back the OS: the daemon wrote the files of the failed config to the
+// /etc of the deployment before the reboot, so they're restored, and the
+// deployment made the default again if greenboot didn't already.  The node is
+// reported as Done on the previous config, with the failed config in its
+// rolledBackConfig annotation, so that it goes Degraded when the daemon
+// refuses to update to it again.
+func (dn *Daemon) recoverFromBootFallback(record *bootFallbackRecord, state *stateAndConfigs) error {
+	ferr := fmt.Errorf("node failed to boot config %s and fell back to the deployment of config %s", record.Config, record.PreviousConfig)
+	dn.logSystem("%v, restoring the files of config %s", ferr, record.PreviousConfig)
+	if dn.recorder != nil {
+		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "BootFallback", ferr.Error())
+	}
+
+	failedConfig, err := dn.mcLister.Get(record.Config)
+	if err != nil {
+		return fmt.Errorf("%v, and getting the config failed: %w", ferr, err)
+	}
+	previousConfig, err := dn.mcLister.Get(record.PreviousConfig)
+	if err != nil {
+		return fmt.Errorf("%v, and getting the config to restore failed: %w", ferr, err)
+	}
+	failedIgnConfig, err := ctrlcommon.ParseAndConvertConfig(failedConfig.Spec.Config.Raw)
+	if err != nil {
+		return fmt.Errorf("parsing failed Ignition config failed: %w", err)
+	}
+	previousIgnConfig, err := ctrlcommon.ParseAndConvertConfig(previousConfig.Spec.Config.Raw)
+	if err != nil {
+		return fmt.Errorf("parsing previous Ignition config failed: %w", err)
+	}
+
+	if err := dn.updateFiles(failedIgnConfig, previousIgnConfig); err != nil {
+		return err
+	}
+	if err := dn.updateSSHKeys(previousIgnConfig.Passwd.Users); err != nil {
+		return err
+	}
+	if _, err := updateBootloader(failedIgnConfig, previousIgnConfig); err != nil {
+		return err
+	}
+	if err := dn.storeCurrentConfigOnDisk(previousConfig); err != nil {
+		return err
+	}
+	if err := removePendingOSImageURLAlias(); err != nil {
+		return err
+	}
+
+	deployments, err := dn.NodeUpdaterClient.GetDeployments()
+	if err != nil {
+		return err
+	}
+	if len(deployments) > 0 && !deployments[0].Booted {
+		glog.Infof("Making the booted deployment %s the default again", record.PreviousChecksum)
+		if err := rpmOstreeRollback(); err != nil {
+			return err
+		}
+	}
+
+	if err := dn.nodeWriter.SetRolledBackConfig(record.Config, dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+		return fmt.Errorf("%v, and recording the rollback failed: %w", ferr, err)
+	}
+	// The node lister may not have seen the annotation yet.
+	dn.node.Annotations[constants.RolledBackConfigAnnotationKey] = record.Config
+	if dn.node.Annotations[constants.CurrentMachineConfigAnnotationKey] != previousConfig.GetName() {
+		// the node completed the update before it failed its health checks
+		if err := dn.nodeWriter.SetDone(dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name, previousConfig.GetName()); err != nil {
+			return errors.Wrap(err, "error setting node's state to Done")
+		}
+	}
+	if state.pendingConfig != nil {
+		if out, err := dn.storePendingState(state.pendingConfig, 0); err != nil {
+			return errors.Wrapf(err, "failed to reset pending config: %s", string(out))
+		}
+		state.pendingConfig = nil
+	}
+	state.currentConfig = previousConfig
+
+	record.FellBack = true
+	return writeBootFallbackRecord(bootFallbackRecordPath, record)
+}
+
+// bootFallbackConfig returns the config the node fell back from, if any.
+func bootFallbackConfig() (string, error) {
+	record, err := readBootFallbackRecord(bootFallbackRecordPath)
+	if err != nil || record == nil || !record.FellBack {
+		return "", err
+	}
+	return record.Config, nil
+}
@@ -0,0 +1,184 @@
+package daemon
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// bootedClientMock is a deploymentsClientMock which also reports the booted
+// deployment among its deployments.
+type bootedClientMock struct {
+	deploymentsClientMock
+}
+
+func (r bootedClientMock) GetBootedDeployment() (*RpmOstreeDeployment, error) {
+	for i := range r.deployments {
+		if r.deployments[i].Booted {
+			return &r.deployments[i], nil
+		}
+	}
+	return nil, errors.New("not currently booted in a deployment")
+}
+
+// withBootFallbackPaths points the boot fallback files at a temporary
+// directory, with greenboot installed, and records the variables set in the
+// GRUB environment block.
+func withBootFallbackPaths(t *testing.T) *[]string {
+	dir := t.TempDir()
+	oldGrubenv, oldUnit, oldRecord, oldSetGrubenv := grubenvPath, greenbootFallbackUnitPath, bootFallbackRecordPath, setGrubenv
+	t.Cleanup(func() {
+		grubenvPath, greenbootFallbackUnitPath, bootFallbackRecordPath, setGrubenv = oldGrubenv, oldUnit, oldRecord, oldSetGrubenv
+	})
+	grubenvPath = filepath.Join(dir, "grubenv")
+	greenbootFallbackUnitPath = filepath.Join(dir, "greenboot-rpm-ostree-grub2-check-fallback.service")
+	bootFallbackRecordPath = filepath.Join(dir, "boot-fallback.json")
+	require.NoError(t, ioutil.WriteFile(greenbootFallbackUnitPath, nil, 0644))
+
+	grubenvVars := &[]string{}
+	setGrubenv = func(vars ...string) error {
+		*grubenvVars = append(*grubenvVars, vars...)
+		return nil
+	}
+	return grubenvVars
+}
+
+func TestParseGrubenv(t *testing.T) {
+	grubenv := "# GRUB Environment Block\nboot_counter=2\nboot_success=0\nmenu_auto_hide=1\n################"
+	assert.Equal(t, map[string]string{
+		"boot_counter":   "2",
+		"boot_success":   "0",
+		"menu_auto_hide": "1",
+	}, parseGrubenv([]byte(grubenv)))
+}
+
+func TestArmBootCounter(t *testing.T) {
+	grubenvVars := withBootFallbackPaths(t)
+	dn := &Daemon{
+		os:     OperatingSystem{ID: "rhcos"},
+		bootID: "boot-0",
+		node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			constants.CurrentMachineConfigAnnotationKey: "rendered-worker-0",
+		}}},
+		NodeUpdaterClient: deploymentsClientMock{deployments: testDeployments(t)},
+	}
+
+	require.NoError(t, dn.armBootCounter("rendered-worker-1"))
+	record, err := readBootFallbackRecord(bootFallbackRecordPath)
+	require.NoError(t, err)
+	assert.Equal(t, &bootFallbackRecord{
+		Config:           "rendered-worker-1",
+		PreviousConfig:   "rendered-worker-0",
+		Checksum:         "layered-new",
+		PreviousChecksum: "old",
+		BootID:           "boot-0",
+	}, record)
+	assert.Equal(t, []string{"boot_counter=3", "boot_success=0"}, *grubenvVars)
+
+	// without a staged deployment there's nothing to fall back from
+	*grubenvVars = nil
+	dn.NodeUpdaterClient = deploymentsClientMock{deployments: testDeployments(t)[:1]}
+	require.NoError(t, dn.armBootCounter("rendered-worker-1"))
+	record, err = readBootFallbackRecord(bootFallbackRecordPath)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+	assert.Empty(t, *grubenvVars)
+
+	// nor without greenboot
+	greenbootFallbackUnitPath = filepath.Join(t.TempDir(), "missing.service")
+	dn.NodeUpdaterClient = deploymentsClientMock{deployments: testDeployments(t)}
+	require.NoError(t, dn.armBootCounter("rendered-worker-1"))
+	record, err = readBootFallbackRecord(bootFallbackRecordPath)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+	assert.Empty(t, *grubenvVars)
+}
+
+func TestCheckBootFallback(t *testing.T) {
+	armed := &bootFallbackRecord{
+		Config:           "rendered-worker-1",
+		PreviousConfig:   "rendered-worker-0",
+		Checksum:         "new",
+		PreviousChecksum: "old",
+		BootID:           "boot-0",
+	}
+	tests := []struct {
+		name     string
+		bootID   string
+		booted   string
+		grubenv  string
+		recorded bool
+	}{{
+		name:     "not rebooted yet",
+		bootID:   "boot-0",
+		booted:   "old",
+		recorded: true,
+	}, {
+		name:     "health checks pending",
+		bootID:   "boot-1",
+		booted:   "new",
+		grubenv:  "boot_counter=2\nboot_success=0\n",
+		recorded: true,
+	}, {
+		name:     "booted successfully",
+		bootID:   "boot-1",
+		booted:   "new",
+		grubenv:  "boot_success=1\n",
+		recorded: false,
+	}, {
+		name:     "moved on to another deployment",
+		bootID:   "boot-1",
+		booted:   "newer",
+		recorded: false,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			withBootFallbackPaths(t)
+			require.NoError(t, writeBootFallbackRecord(bootFallbackRecordPath, armed))
+			require.NoError(t, ioutil.WriteFile(grubenvPath, []byte(test.grubenv), 0644))
+			dn := &Daemon{
+				bootID:            test.bootID,
+				NodeUpdaterClient: bootedClientMock{deploymentsClientMock{deployments: []RpmOstreeDeployment{{Checksum: test.booted, Booted: true}}}},
+			}
+
+			require.NoError(t, dn.checkBootFallback(&stateAndConfigs{}))
+			record, err := readBootFallbackRecord(bootFallbackRecordPath)
+			require.NoError(t, err)
+			if test.recorded {
+				assert.Equal(t, armed, record)
+			} else {
+				assert.Nil(t, record)
+			}
+		})
+	}
+}
+
+func TestCheckRolledBackConfigAfterBootFallback(t *testing.T) {
+	withBootFallbackPaths(t)
+	dn := &Daemon{node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		constants.RolledBackConfigAnnotationKey: "rendered-worker-1",
+	}}}}
+	desired := &mcfgv1.MachineConfig{ObjectMeta: metav1.ObjectMeta{Name: "rendered-worker-1"}}
+
+	err := dn.checkRolledBackConfig(desired)
+	assert.Equal(t, ctrlcommon.ErrorReasonPostUpdateValidation, ctrlcommon.ErrorReason(err))
+
+	require.NoError(t, writeBootFallbackRecord(bootFallbackRecordPath, &bootFallbackRecord{Config: "rendered-worker-1", FellBack: true}))
+	err = dn.checkRolledBackConfig(desired)
+	var fallbackErr *ctrlcommon.BootFallbackError
+	require.True(t, errors.As(err, &fallbackErr), err)
+	assert.Equal(t, ctrlcommon.ErrorReasonBootFallback, ctrlcommon.ErrorReason(err))
+	assert.False(t, isTransientError(err))
+
+	assert.NoError(t, dn.checkRolledBackConfig(&mcfgv1.MachineConfig{ObjectMeta: metav1.ObjectMeta{Name: "rendered-worker-2"}}))
+}
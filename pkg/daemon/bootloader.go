@@ -0,0 +1,129 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/golang/glog"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// bootloaderUserCfgStagedPath is where the bootloader controller stages the
+// GRUB user config of a pool, see pkg/controller/bootloader.
+const bootloaderUserCfgStagedPath = "/etc/machine-config-daemon/bootloader/user.cfg"
+
+var (
+	// grubUserCfgPath is the GRUB user config, from which the grub2 config of
+	// RHCOS reads the password of the root superuser.
+	grubUserCfgPath = "/boot/grub2/user.cfg"
+	bootMountPoint  = "/boot"
+	procMountsPath  = "/proc/mounts"
+
+	// remountBoot remounts /boot with the given option, "rw" or "ro".
+	remountBoot = func(opt string) error {
+		return runCmdSync("mount", "-o", "remount,"+opt, bootMountPoint)
+	}
+)
+
+// isReadOnlyMount returns whether the last filesystem mounted on the mount point is read-only.
+func isReadOnlyMount(mountPoint string) (bool, error) {
+	mounts, err := ioutil.ReadFile(procMountsPath)
+	if err != nil {
+		return false, err
+	}
+	found, readOnly := false, false
+	for _, line := range strings.Split(string(mounts), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[1] != mountPoint {
+			continue
+		}
+		found, readOnly = true, false
+		for _, opt := range strings.Split(fields[3], ",") {
+			if opt == "ro" {
+				readOnly = true
+			}
+		}
+	}
+	if !found {
+		return false, fmt.Errorf("%s is not mounted", mountPoint)
+	}
+	return readOnly, nil
+}
+
+// withWritableBoot runs f with /boot mounted read-write, remounting it
+// read-only again afterwards if it was.
+func withWritableBoot(f func() error) (retErr error) {
+	readOnly, err := isReadOnlyMount(bootMountPoint)
+	if err != nil {
+		return err
+	}
+	if readOnly {
+		if err := remountBoot("rw"); err != nil {
+			return err
+		}
+		defer func() {
+			if err := remountBoot("ro"); err != nil && retErr == nil {
+				retErr = err
+			}
+		}()
+	}
+	return f()
+}
+
+// writeOrRemoveGrubUserCfg writes the GRUB user config, readable by root
+// only, or removes it if data is nil.
+func writeOrRemoveGrubUserCfg(data []byte) error {
+	if data == nil {
+		if err := os.Remove(grubUserCfgPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return writeFileAtomically(grubUserCfgPath, data, defaultDirectoryPermissions, 0600, -1, -1)
+}
+
+// updateBootloader installs the GRUB user config staged by the new config,
+// or removes it if the new config doesn't stage one anymore.  The returned
+// function restores the GRUB user config found on disk, which may not come
+// from the old config, when rolling the update back.
+func updateBootloader(oldIgnConfig, newIgnConfig ign3types.Config) (func() error, error) {
+	noop := func() error { return nil }
+	oldData, err := ctrlcommon.GetIgnitionFileDataByPath(&oldIgnConfig, bootloaderUserCfgStagedPath)
+	if err != nil {
+		return noop, err
+	}
+	newData, err := ctrlcommon.GetIgnitionFileDataByPath(&newIgnConfig, bootloaderUserCfgStagedPath)
+	if err != nil {
+		return noop, err
+	}
+	if bytes.Equal(oldData, newData) {
+		return noop, nil
+	}
+
+	prevData, err := ioutil.ReadFile(grubUserCfgPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return noop, err
+		}
+		prevData = nil
+	}
+
+	if newData == nil {
+		glog.Infof("Removing bootloader password from %s", grubUserCfgPath)
+	} else {
+		glog.Infof("Writing bootloader password to %s", grubUserCfgPath)
+	}
+	if err := withWritableBoot(func() error { return writeOrRemoveGrubUserCfg(newData) }); err != nil {
+		return noop, fmt.Errorf("failed to update %s: %w", grubUserCfgPath, err)
+	}
+
+	return func() error {
+		glog.Infof("Restoring previous %s", grubUserCfgPath)
+		return withWritableBoot(func() error { return writeOrRemoveGrubUserCfg(prevData) })
+	}, nil
+}
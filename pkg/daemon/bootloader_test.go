@@ -0,0 +1,105 @@
+package daemon
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func bootloaderIgnConfig(userCfg string) ign3types.Config {
+	ignCfg := ctrlcommon.NewIgnConfig()
+	if userCfg != "" {
+		ignCfg.Storage.Files = []ign3types.File{helpers.CreateEncodedIgn3File(bootloaderUserCfgStagedPath, userCfg, 0600)}
+	}
+	return ignCfg
+}
+
+// setupBootloaderTest points the bootloader paths to a temporary directory
+// with /boot mounted read-only, and records the remounts.
+func setupBootloaderTest(t *testing.T) *[]string {
+	dir := t.TempDir()
+	origUserCfgPath, origMountsPath, origRemount := grubUserCfgPath, procMountsPath, remountBoot
+	t.Cleanup(func() {
+		grubUserCfgPath, procMountsPath, remountBoot = origUserCfgPath, origMountsPath, origRemount
+	})
+
+	grubUserCfgPath = filepath.Join(dir, "grub2", "user.cfg")
+	procMountsPath = filepath.Join(dir, "mounts")
+	require.NoError(t, ioutil.WriteFile(procMountsPath, []byte("/dev/vda3 /boot ext4 ro,seclabel,relatime 0 0\n"), 0644))
+	remounts := []string{}
+	remountBoot = func(opt string) error {
+		remounts = append(remounts, opt)
+		return nil
+	}
+	return &remounts
+}
+
+func TestIsReadOnlyMount(t *testing.T) {
+	setupBootloaderTest(t)
+	readOnly, err := isReadOnlyMount("/boot")
+	require.NoError(t, err)
+	assert.True(t, readOnly)
+
+	require.NoError(t, ioutil.WriteFile(procMountsPath, []byte("/dev/vda3 /boot ext4 ro 0 0\n/dev/vda3 /boot ext4 rw,relatime 0 0\n"), 0644))
+	readOnly, err = isReadOnlyMount("/boot")
+	require.NoError(t, err)
+	assert.False(t, readOnly)
+
+	_, err = isReadOnlyMount("/sysroot")
+	assert.Error(t, err)
+}
+
+func TestUpdateBootloader(t *testing.T) {
+	remounts := setupBootloaderTest(t)
+
+	// unchanged configs leave /boot alone
+	_, err := updateBootloader(bootloaderIgnConfig("a"), bootloaderIgnConfig("a"))
+	require.NoError(t, err)
+	assert.Empty(t, *remounts)
+
+	restore, err := updateBootloader(bootloaderIgnConfig(""), bootloaderIgnConfig("GRUB2_PASSWORD=a"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rw", "ro"}, *remounts)
+	data, err := ioutil.ReadFile(grubUserCfgPath)
+	require.NoError(t, err)
+	assert.Equal(t, "GRUB2_PASSWORD=a", string(data))
+	fi, err := os.Stat(grubUserCfgPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	// rolling back removes the user config that wasn't there
+	require.NoError(t, restore())
+	_, err = os.Stat(grubUserCfgPath)
+	assert.True(t, os.IsNotExist(err))
+
+	// rolling back restores the user config found on disk rather than the
+	// old config's
+	require.NoError(t, ioutil.WriteFile(grubUserCfgPath, []byte("GRUB2_PASSWORD=manual"), 0600))
+	restore, err = updateBootloader(bootloaderIgnConfig("GRUB2_PASSWORD=a"), bootloaderIgnConfig(""))
+	require.NoError(t, err)
+	_, err = os.Stat(grubUserCfgPath)
+	assert.True(t, os.IsNotExist(err))
+	require.NoError(t, restore())
+	data, err = ioutil.ReadFile(grubUserCfgPath)
+	require.NoError(t, err)
+	assert.Equal(t, "GRUB2_PASSWORD=manual", string(data))
+}
+
+func TestUpdateBootloaderRemountFailure(t *testing.T) {
+	setupBootloaderTest(t)
+	remountBoot = func(opt string) error { return errors.New("mount failed") }
+
+	_, err := updateBootloader(bootloaderIgnConfig(""), bootloaderIgnConfig("GRUB2_PASSWORD=a"))
+	assert.Error(t, err)
+	_, err = os.Stat(grubUserCfgPath)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	ign2types "github.com/coreos/ignition/config/v2_2/types"
@@ -42,6 +43,9 @@ type ConfigDriftMonitorOpts struct {
 	SystemdPath string
 	// Channel to report unknown errors
 	ErrChan chan<- error
+	// How to find the process which modified a file, one of the
+	// DriftAttribution* modes. Defaults to none.
+	DriftAttribution string
 }
 
 // Holds the Config Drift Watcher and ensures we only have a single instance
@@ -57,8 +61,11 @@ type configDriftWatcher struct {
 	ConfigDriftMonitorOpts
 	watcher   *fsnotify.Watcher
 	filePaths sets.String
-	wg        sync.WaitGroup
-	stopCh    chan struct{}
+	// The files as found when the watcher was started, to tell how they changed
+	stamps     map[string]fileStamp
+	attributor processAttributor
+	wg         sync.WaitGroup
+	stopCh     chan struct{}
 }
 
 // Holds a single Config Drift Watcher and starts / stops it as necessary while
@@ -189,6 +196,15 @@ func (c *configDriftWatcher) initialize() error {
 		return fmt.Errorf("could not get file paths from machine config: %w", err)
 	}
 
+	c.stamps = snapshotFiles(c.filePaths)
+
+	// Not knowing who modified a file doesn't prevent us from detecting the
+	// drift, so carry on without.
+	c.attributor, err = newProcessAttributor(c.DriftAttribution, c.filePaths)
+	if err != nil {
+		glog.Warningf("Could not start %s drift attribution, config drifts won't be attributed to a process: %v", c.DriftAttribution, err)
+	}
+
 	// fsnotify (presently) uses inotify instead of fanotify on Linux.
 	// See: https://github.com/fsnotify/fsnotify/issues/114
 	//
@@ -232,6 +248,9 @@ func (c *configDriftWatcher) start() {
 			case <-c.stopCh:
 				// We received a stop signal, shutdown our watcher.
 				c.watcher.Close()
+				if c.attributor != nil {
+					c.attributor.stop()
+				}
 				return
 			}
 		}
@@ -276,12 +295,37 @@ func (c *configDriftWatcher) checkMachineConfigForEvent(event fsnotify.Event) er
 	}
 
 	if err := validateOnDiskState(c.MachineConfig, c.SystemdPath); err != nil {
+		if evidence := c.driftEvidence(event.Name); len(evidence) > 0 {
+			err = fmt.Errorf("%w; %s", err, strings.Join(evidence, "; "))
+		}
 		return configDriftErr(err)
 	}
 
 	return nil
 }
 
+// Describes how the watched files changed since the watcher was started and,
+// if known, which process changed them. The file of the event is included
+// even if its stamp didn't change, as long as its writer is known.
+func (c *configDriftWatcher) driftEvidence(eventPath string) []string {
+	var evidence []string
+	for _, path := range c.filePaths.List() {
+		e := driftEvidence{path: path, before: c.stamps[path], after: statFile(path)}
+		changed := !e.before.equal(e.after)
+		if !changed && path != eventPath {
+			continue
+		}
+		if c.attributor != nil {
+			e.writer = c.attributor.lastWriter(path)
+		}
+		if !changed && e.writer == nil {
+			continue
+		}
+		evidence = append(evidence, e.String())
+	}
+	return evidence
+}
+
 // Finds the paths for all files in a given MachineConfig.
 func getFilePathsFromMachineConfig(mc *mcfgv1.MachineConfig, systemdPath string) (sets.String, error) {
 	ignConfig, err := ctrlcommon.IgnParseWrapper(mc.Spec.Config.Raw)
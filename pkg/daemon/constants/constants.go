@@ -34,6 +34,31 @@ const (
 	MachineConfigDaemonStateUnreconcilable = "Unreconcilable"
 	// MachineConfigDaemonReasonAnnotationKey is set by the daemon when it needs to report a human readable reason for its state. E.g. when state flips to degraded/unreconcilable.
 	MachineConfigDaemonReasonAnnotationKey = "machineconfiguration.openshift.io/reason"
+	// MachineConfigDaemonErrorReasonAnnotationKey is set by the daemon alongside the reason annotation to the class of
+	// the error (e.g. DrainTimeout or OSUpdateError) when it's known, for automation to act on.
+	MachineConfigDaemonErrorReasonAnnotationKey = "machineconfiguration.openshift.io/errorReason"
+	// MachineConfigDaemonRetriesAnnotationKey is set by the daemon to the number of consecutive retries of a transient error.
+	// It is cleared once the node is Done.
+	MachineConfigDaemonRetriesAnnotationKey = "machineconfiguration.openshift.io/transientErrorRetries"
+	// DrainBlockersAnnotationKey is set by the daemon to the JSON list of PodDisruptionBudgets blocking the drain of the node.
+	// It is cleared once the drain succeeds.
+	DrainBlockersAnnotationKey = "machineconfiguration.openshift.io/drainBlockers"
+	// PreRebootNotificationAnnotationKey is set by the node controller to the JSON preRebootNotification of the pool of the node.
+	// MCD uses it to notify the workloads of the node before draining it for a reboot.
+	PreRebootNotificationAnnotationKey = "machineconfiguration.openshift.io/preRebootNotification"
+	// UpdateTaintAnnotationKey is set by the node controller to the JSON updateTaint of the pool it set on the node while updating.
+	// It's used to remove the taint once the update is done, even if the updateTaint of the pool changed meanwhile.
+	UpdateTaintAnnotationKey = "machineconfiguration.openshift.io/updateTaint"
+	// PostUpdateValidationAnnotationKey is set by the node controller to the JSON postUpdateValidation of the pool of the node.
+	// MCD runs its probes after applying a config, before setting the node Done.
+	PostUpdateValidationAnnotationKey = "machineconfiguration.openshift.io/postUpdateValidation"
+	// ImagePruningAnnotationKey is set by the node controller to the JSON imagePruning of the pool of the node.
+	// MCD removes the unused container images once the node is updated and validated.
+	ImagePruningAnnotationKey = "machineconfiguration.openshift.io/imagePruning"
+	// RolledBackConfigAnnotationKey is set by the daemon to the config it rolled back from after it failed its post-update validation,
+	// or after the node fell back to its previous OS deployment because it failed to boot the config.
+	// MCD doesn't update to that config again, and clears it once the node completes an update.
+	RolledBackConfigAnnotationKey = "machineconfiguration.openshift.io/rolledBackConfig"
 	// InitialNodeAnnotationsFilePath defines the path at which it will find the node annotations it needs to set on the node once it comes up for the first time.
 	// The Machine Config Server writes the node annotations to this path.
 	InitialNodeAnnotationsFilePath = "/etc/machine-config-daemon/node-annotations.json"
@@ -49,6 +74,18 @@ const (
 	// For more information, see https://github.com/openshift/pivot/pull/25/commits/c77788a35d7ee4058d1410e89e6c7937bca89f6c#diff-04c6e90faac2675aa89e2176d2eec7d8R44
 	EtcPivotFile = "/etc/pivot/image-pullspec"
 
+	// OSImageURLAliasPath records the osImageURL the booted OSTree commit stands for when the MCD
+	// skipped the rebase to an image with the same commit, since only a rebase changes the custom origin.
+	OSImageURLAliasPath = "/etc/machine-config-daemon/os-image-url-alias.json"
+
+	// PendingOSImageURLAliasPath stages the alias of OSImageURLAliasPath until the update which
+	// skipped the rebase is committed, so it neither applies before the reboot nor survives a rollback.
+	PendingOSImageURLAliasPath = "/etc/machine-config-daemon/os-image-url-alias-pending.json"
+
+	// BootFallbackRecordPath records the OS deployment the MCD armed the boot counter of greenboot for before
+	// rebooting into it, so that it can tell on startup whether the bootloader fell back to the previous one.
+	BootFallbackRecordPath = "/etc/machine-config-daemon/boot-fallback.json"
+
 	// HostSelfBinary is the path where we copy our own binary to the host
 	HostSelfBinary = "/run/bin/machine-config-daemon"
 
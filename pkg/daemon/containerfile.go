@@ -0,0 +1,196 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	// bootcKargsPath is where bootc picks up the kernel arguments baked into an image
+	bootcKargsPath = "/usr/lib/bootc/kargs.d/50-machineconfig.toml"
+	// containerfileConfigLabel records the MachineConfig a config layer was rendered from
+	containerfileConfigLabel = "machineconfiguration.openshift.io/config"
+	// containerfileChunkSize is the maximum length of base64 encoded file
+	// contents written by a single RUN instruction
+	containerfileChunkSize = 64 * 1024
+)
+
+// RenderContainerfile renders a MachineConfig into a Containerfile that layers
+// the config on top of baseImage, for bootc-managed (image mode) hosts where
+// the config is baked into the bootable container image instead of being
+// applied by the MCD.  If baseImage is empty the OSImageURL of the
+// MachineConfig is used.
+//
+// Files, directories, links, systemd units and kernel arguments are rendered.
+// Users and SSH keys live in /var which isn't part of the image, so they're
+// left to Ignition at first boot.  Extensions, kernel types and FIPS can't be
+// expressed as a config layer and are rejected.
+func RenderContainerfile(mc *mcfgv1.MachineConfig, baseImage string) ([]byte, error) {
+	if baseImage == "" {
+		baseImage = mc.Spec.OSImageURL
+	}
+	if baseImage == "" {
+		return nil, fmt.Errorf("no base image given and MachineConfig %s has no OSImageURL", mc.Name)
+	}
+	if len(mc.Spec.Extensions) > 0 {
+		return nil, fmt.Errorf("extensions are not supported in image mode")
+	}
+	if canonicalizeKernelType(mc.Spec.KernelType) != ctrlcommon.KernelTypeDefault {
+		return nil, fmt.Errorf("kernel type %q is not supported in image mode", mc.Spec.KernelType)
+	}
+	if mc.Spec.FIPS {
+		return nil, fmt.Errorf("FIPS is not supported in image mode")
+	}
+
+	ignConfig, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing Ignition config failed: %w", err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Config layer rendered from MachineConfig %s by the machine-config-daemon.\n", mc.Name)
+	fmt.Fprintf(&buf, "FROM %s\n", baseImage)
+
+	for _, d := range ignConfig.Storage.Directories {
+		mode := int(defaultDirectoryPermissions)
+		if d.Mode != nil {
+			mode = fileMode(*d.Mode)
+		}
+		cmds := []string{
+			fmt.Sprintf("mkdir -p %s", shellQuote(d.Path)),
+			fmt.Sprintf("chmod %04o %s", mode, shellQuote(d.Path)),
+		}
+		cmds = append(cmds, chownCmd(d.User, d.Group, d.Path)...)
+		writeRun(&buf, cmds)
+	}
+
+	for _, f := range ignConfig.Storage.Files {
+		if len(f.Append) > 0 {
+			return nil, fmt.Errorf("file %q: appending is not supported in image mode", f.Path)
+		}
+		contents, err := ctrlcommon.DecodeIgnitionFileContents(f.Contents.Source, f.Contents.Compression)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode file %q: %w", f.Path, err)
+		}
+		mode := int(defaultFilePermissions)
+		if f.Mode != nil {
+			mode = fileMode(*f.Mode)
+		}
+		writeFile(&buf, f.Path, contents, mode, f.User, f.Group)
+	}
+
+	for _, l := range ignConfig.Storage.Links {
+		cmds := []string{
+			fmt.Sprintf("mkdir -p %s", shellQuote(filepath.Dir(l.Path))),
+		}
+		if l.Hard != nil && *l.Hard {
+			cmds = append(cmds, fmt.Sprintf("ln -f %s %s", shellQuote(l.Target), shellQuote(l.Path)))
+		} else {
+			cmds = append(cmds, fmt.Sprintf("ln -sfn %s %s", shellQuote(l.Target), shellQuote(l.Path)))
+		}
+		writeRun(&buf, cmds)
+	}
+
+	for _, u := range ignConfig.Systemd.Units {
+		for _, dropin := range u.Dropins {
+			if dropin.Contents == nil || *dropin.Contents == "" {
+				continue
+			}
+			dpath := filepath.Join(pathSystemd, u.Name+".d", dropin.Name)
+			writeFile(&buf, dpath, []byte(*dropin.Contents), int(defaultFilePermissions), ign3types.NodeUser{}, ign3types.NodeGroup{})
+		}
+		if u.Contents != nil && *u.Contents != "" {
+			upath := filepath.Join(pathSystemd, u.Name)
+			writeFile(&buf, upath, []byte(*u.Contents), int(defaultFilePermissions), ign3types.NodeUser{}, ign3types.NodeGroup{})
+		}
+		switch {
+		case u.Mask != nil && *u.Mask:
+			writeRun(&buf, []string{fmt.Sprintf("systemctl mask %s", shellQuote(u.Name))})
+		case u.Enabled != nil && *u.Enabled:
+			writeRun(&buf, []string{fmt.Sprintf("systemctl enable %s", shellQuote(u.Name))})
+		case u.Enabled != nil:
+			writeRun(&buf, []string{fmt.Sprintf("systemctl disable %s", shellQuote(u.Name))})
+		}
+	}
+
+	kargs := parseKernelArguments(mc.Spec.KernelArguments)
+	if len(kargs) > 0 {
+		quoted := make([]string, 0, len(kargs))
+		for _, karg := range kargs {
+			quoted = append(quoted, fmt.Sprintf("%q", karg))
+		}
+		data := []byte(fmt.Sprintf("kargs = [%s]\n", strings.Join(quoted, ", ")))
+		writeFile(&buf, bootcKargsPath, data, int(defaultFilePermissions), ign3types.NodeUser{}, ign3types.NodeGroup{})
+	}
+
+	fmt.Fprintf(&buf, "LABEL %s=%q\n", containerfileConfigLabel, mc.Name)
+	return buf.Bytes(), nil
+}
+
+// writeFile writes the RUN instructions writing contents to path.  The
+// contents are base64 encoded so that they survive the Containerfile syntax
+// unchanged, and big files are written in chunks to stay below the kernel's
+// limit on the length of the command line of a RUN instruction.
+func writeFile(buf *bytes.Buffer, path string, contents []byte, mode int, user ign3types.NodeUser, group ign3types.NodeGroup) {
+	encoded := base64.StdEncoding.EncodeToString(contents)
+	tmpPath := shellQuote(path + ".b64")
+	cmds := []string{fmt.Sprintf("mkdir -p %s", shellQuote(filepath.Dir(path)))}
+	redirect := ">"
+	for len(encoded) > containerfileChunkSize {
+		writeRun(buf, append(cmds, fmt.Sprintf("printf %%s %s %s %s", encoded[:containerfileChunkSize], redirect, tmpPath)))
+		encoded = encoded[containerfileChunkSize:]
+		cmds = nil
+		redirect = ">>"
+	}
+	cmds = append(cmds,
+		fmt.Sprintf("printf %%s %s %s %s", encoded, redirect, tmpPath),
+		fmt.Sprintf("base64 -d %s > %s", tmpPath, shellQuote(path)),
+		fmt.Sprintf("rm %s", tmpPath),
+		fmt.Sprintf("chmod %04o %s", mode, shellQuote(path)),
+	)
+	writeRun(buf, append(cmds, chownCmd(user, group, path)...))
+}
+
+// chownCmd returns the command setting the ownership of path, if any is requested.
+func chownCmd(user ign3types.NodeUser, group ign3types.NodeGroup, path string) []string {
+	var owner, grp string
+	if user.ID != nil {
+		owner = fmt.Sprintf("%d", *user.ID)
+	} else if user.Name != nil {
+		owner = *user.Name
+	}
+	if group.ID != nil {
+		grp = fmt.Sprintf("%d", *group.ID)
+	} else if group.Name != nil {
+		grp = *group.Name
+	}
+	switch {
+	case owner == "" && grp == "":
+		return nil
+	case grp == "":
+		return []string{fmt.Sprintf("chown %s %s", shellQuote(owner), shellQuote(path))}
+	}
+	return []string{fmt.Sprintf("chown %s %s", shellQuote(owner+":"+grp), shellQuote(path))}
+}
+
+func writeRun(buf *bytes.Buffer, cmds []string) {
+	fmt.Fprintf(buf, "RUN %s\n", strings.Join(cmds, " && \\\n    "))
+}
+
+// fileMode strips the file type bits Ignition allows in a mode.
+func fileMode(mode int) int {
+	return mode & 07777
+}
+
+// shellQuote single-quotes s for use in a RUN instruction.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
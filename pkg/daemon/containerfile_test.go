@@ -0,0 +1,76 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/base64"
+	"regexp"
+	"strings"
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestRenderContainerfile(t *testing.T) {
+	enabled := true
+	unit := ign3types.Unit{Name: "foo.service", Contents: helpers.StrToPtr("[Unit]\nDescription=foo\n"), Enabled: &enabled}
+	mc := helpers.NewMachineConfigExtended("rendered-worker-1", nil,
+		[]ign3types.File{helpers.CreateIgn3File("/etc/foo.conf", "data:,foo%20bar", 0600)},
+		[]ign3types.Unit{unit}, []ign3types.SSHAuthorizedKey{"key"}, nil, false, []string{"nosmt foo=bar"}, "", "quay.io/openshift/os@sha256:abc")
+
+	out, err := RenderContainerfile(mc, "")
+	require.NoError(t, err)
+	containerfile := string(out)
+
+	assert.Contains(t, containerfile, "FROM quay.io/openshift/os@sha256:abc\n")
+	assert.Contains(t, containerfile, base64.StdEncoding.EncodeToString([]byte("foo bar")))
+	assert.Contains(t, containerfile, "chmod 0600 '/etc/foo.conf'")
+	assert.Contains(t, containerfile, "'/etc/systemd/system/foo.service'")
+	assert.Contains(t, containerfile, "systemctl enable 'foo.service'")
+	assert.Contains(t, containerfile, base64.StdEncoding.EncodeToString([]byte("kargs = [\"nosmt\", \"foo=bar\"]\n")))
+	assert.Contains(t, containerfile, `LABEL machineconfiguration.openshift.io/config="rendered-worker-1"`)
+	assert.NotContains(t, containerfile, "key")
+
+	out, err = RenderContainerfile(mc, "localhost/base:latest")
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "FROM localhost/base:latest\n")
+}
+
+func TestRenderContainerfileUnsupported(t *testing.T) {
+	mc := helpers.NewMachineConfigExtended("ext", nil, nil, nil, nil, []string{"usbguard"}, false, nil, "", "dummy://")
+	_, err := RenderContainerfile(mc, "")
+	assert.Error(t, err)
+
+	mc = helpers.NewMachineConfigExtended("rt", nil, nil, nil, nil, nil, false, nil, ctrlcommon.KernelTypeRealtime, "dummy://")
+	_, err = RenderContainerfile(mc, "")
+	assert.Error(t, err)
+
+	mc = helpers.NewMachineConfigExtended("fips", nil, nil, nil, nil, nil, true, nil, "", "dummy://")
+	_, err = RenderContainerfile(mc, "")
+	assert.Error(t, err)
+
+	mc = helpers.NewMachineConfig("no-image", nil, "", nil)
+	_, err = RenderContainerfile(mc, "")
+	assert.Error(t, err)
+}
+
+func TestWriteFileChunks(t *testing.T) {
+	contents := bytes.Repeat([]byte("0123456789"), containerfileChunkSize/5)
+	var buf bytes.Buffer
+	writeFile(&buf, "/etc/big", contents, 0644, ign3types.NodeUser{}, ign3types.NodeGroup{})
+
+	runs := strings.Count(buf.String(), "RUN ")
+	assert.Greater(t, runs, 1)
+
+	var encoded strings.Builder
+	for _, m := range regexp.MustCompile(`printf %s (\S+) >>? `).FindAllStringSubmatch(buf.String(), -1) {
+		encoded.WriteString(m[1])
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded.String())
+	require.NoError(t, err)
+	assert.Equal(t, contents, decoded)
+}
@@ -0,0 +1,224 @@
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/golang/glog"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// crioQuarantineDir is where the daemon moves the foreign CRI-O drop-ins
+// overriding settings of the MachineConfig, out of the reach of CRI-O.
+const crioQuarantineDir = "/etc/machine-config-daemon/crio.conf.d-quarantine"
+
+// crioFragmentOwner is an entry of the ownership map of the CRI-O drop-ins
+// the MachineConfigs hold.
+type crioFragmentOwner struct {
+	// matches returns true if the drop-in named name is the owner's
+	matches func(name string) bool
+	owner   string
+}
+
+func nameIs(names ...string) func(string) bool {
+	return func(name string) bool {
+		for _, n := range names {
+			if name == n {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func nameHasPrefix(prefix string) func(string) bool {
+	return func(name string) bool {
+		return strings.HasPrefix(name, prefix)
+	}
+}
+
+// crioFragmentOwners maps the CRI-O drop-ins the controllers render to
+// them.  The other drop-ins of the MachineConfig come from the MachineConfigs
+// of the cluster admin.
+var crioFragmentOwners = []crioFragmentOwner{
+	{matches: nameIs("00-default"), owner: "TemplateController"},
+	{matches: nameHasPrefix("01-ctrcfg-"), owner: "ContainerRuntimeConfig"},
+	{matches: nameIs("01-cgroup-mode"), owner: "CgroupMode"},
+	{matches: nameIs("01-workload-partitioning"), owner: "WorkloadPartitioning"},
+	{matches: nameHasPrefix("90-profile-"), owner: "MachineConfigProfile"},
+}
+
+// ownerOfCrioFragment returns the owner of the CRI-O drop-in at path of a
+// MachineConfig.
+func ownerOfCrioFragment(path string) string {
+	name := filepath.Base(path)
+	for _, o := range crioFragmentOwners {
+		if o.matches(name) {
+			return o.owner
+		}
+	}
+	return "MachineConfig"
+}
+
+// crioSetting is a setting of a CRI-O drop-in of the MachineConfig.
+type crioSetting struct {
+	name string
+	path string
+}
+
+// scanCrioFragments returns the CRI-O drop-ins in dir that aren't files of
+// the Ignition config, each with the settings of the drop-ins of the config it
+// overrides: the ones it sets too, of the drop-ins before it in lexical order.
+// The files of the previous configs, which an update removes, aren't foreign
+// either.
+func scanCrioFragments(dir string, ignConfig *ign3types.Config, previous ...*ign3types.Config) ([]mcfgv1.ForeignCrioFragment, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the settings of the drop-ins of the config, by key
+	owned := map[string][]crioSetting{}
+	for _, f := range ignConfig.Storage.Files {
+		if filepath.Dir(f.Path) != filepath.Clean(dir) {
+			continue
+		}
+		contents, err := ctrlcommon.DecodeIgnitionFileContents(f.Contents.Source, f.Contents.Compression)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", f.Path, err)
+		}
+		conf, err := flattenTOML(contents)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Path, err)
+		}
+		for key := range conf {
+			owned[key] = append(owned[key], crioSetting{name: filepath.Base(f.Path), path: f.Path})
+		}
+	}
+	inConfig := map[string]bool{}
+	for _, cfg := range append([]*ign3types.Config{ignConfig}, previous...) {
+		for _, f := range cfg.Storage.Files {
+			inConfig[f.Path] = true
+		}
+	}
+
+	var foreign []mcfgv1.ForeignCrioFragment
+	for _, info := range infos {
+		path := filepath.Join(dir, info.Name())
+		if info.IsDir() || inConfig[path] {
+			continue
+		}
+		fragment := mcfgv1.ForeignCrioFragment{Path: path}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		conf, err := flattenTOML(data)
+		if err != nil {
+			// CRI-O fails to start on it, which its logs tell
+			glog.Warningf("Foreign CRI-O drop-in %s: %v", path, err)
+		}
+		keys := make([]string, 0, len(conf))
+		for key := range conf {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			for _, s := range owned[key] {
+				if s.name < info.Name() {
+					fragment.Overrides = append(fragment.Overrides, mcfgv1.CrioSettingOverride{Key: key, Path: s.path, Owner: ownerOfCrioFragment(s.path)})
+				}
+			}
+		}
+		foreign = append(foreign, fragment)
+	}
+	return foreign, nil
+}
+
+// quarantineCrioFragments moves the foreign drop-ins overriding settings of
+// the MachineConfig to quarantineDir, and marks them quarantined.  It returns
+// a function moving them back.
+func quarantineCrioFragments(fragments []mcfgv1.ForeignCrioFragment, quarantineDir string) (func() error, error) {
+	var moved []mcfgv1.ForeignCrioFragment
+	restore := func() error {
+		for _, f := range moved {
+			if err := os.Rename(filepath.Join(quarantineDir, filepath.Base(f.Path)), f.Path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for i, f := range fragments {
+		if len(f.Overrides) == 0 {
+			continue
+		}
+		if err := os.MkdirAll(quarantineDir, 0755); err != nil {
+			return restore, err
+		}
+		if err := os.Rename(f.Path, filepath.Join(quarantineDir, filepath.Base(f.Path))); err != nil {
+			return restore, fmt.Errorf("quarantining the CRI-O drop-in %s: %w", f.Path, err)
+		}
+		moved = append(moved, f)
+		fragments[i].Quarantined = true
+	}
+	return restore, nil
+}
+
+// overridingCrioFragments returns true if any of the foreign drop-ins
+// overrides settings of the MachineConfig.
+func overridingCrioFragments(fragments []mcfgv1.ForeignCrioFragment) bool {
+	for _, f := range fragments {
+		if len(f.Overrides) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// logForeignCrioFragments logs the foreign drop-ins and the settings they
+// override.
+func (dn *Daemon) logForeignCrioFragments(fragments []mcfgv1.ForeignCrioFragment) {
+	for _, f := range fragments {
+		if len(f.Overrides) == 0 {
+			glog.Infof("Foreign CRI-O drop-in %s doesn't override settings of the MachineConfig", f.Path)
+			continue
+		}
+		overrides := []string{}
+		for _, o := range f.Overrides {
+			overrides = append(overrides, fmt.Sprintf("%s of %s (%s)", o.Key, o.Path, o.Owner))
+		}
+		verb := "overrides"
+		if f.Quarantined {
+			verb = "was quarantined to " + crioQuarantineDir + ", it overrides"
+		}
+		dn.logSystem("Foreign CRI-O drop-in %s %s %s", f.Path, verb, strings.Join(overrides, ", "))
+	}
+}
+
+// updateForeignCrioFragments scans the CRI-O drop-ins of the node for the
+// ones that aren't part of config, for the MachineConfigNode.  They're only
+// quarantined by updates, which restart CRI-O.
+func (dn *Daemon) updateForeignCrioFragments(config *mcfgv1.MachineConfig) {
+	ignConfig, err := ctrlcommon.ParseAndConvertConfig(config.Spec.Config.Raw)
+	if err != nil {
+		glog.Warningf("Failed to parse the Ignition config of %s: %v", config.Name, err)
+		return
+	}
+	fragments, err := scanCrioFragments(crioDropInDir, &ignConfig)
+	if err != nil {
+		glog.Warningf("Failed to scan the CRI-O drop-ins: %v", err)
+		return
+	}
+	dn.logForeignCrioFragments(fragments)
+	dn.foreignCrioFragments = fragments
+}
@@ -0,0 +1,89 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestOwnerOfCrioFragment(t *testing.T) {
+	for path, owner := range map[string]string{
+		"/etc/crio/crio.conf.d/00-default":               "TemplateController",
+		"/etc/crio/crio.conf.d/01-ctrcfg-logLevel":       "ContainerRuntimeConfig",
+		"/etc/crio/crio.conf.d/01-workload-partitioning": "WorkloadPartitioning",
+		"/etc/crio/crio.conf.d/50-custom":                "MachineConfig",
+	} {
+		assert.Equal(t, owner, ownerOfCrioFragment(path), path)
+	}
+}
+
+func TestScanCrioFragments(t *testing.T) {
+	dir := t.TempDir()
+	owned := filepath.Join(dir, "01-ctrcfg-logLevel")
+	removed := filepath.Join(dir, "01-ctrcfg-pidsLimit")
+	ignConfig := &ign3types.Config{Storage: ign3types.Storage{Files: []ign3types.File{
+		helpers.CreateEncodedIgn3File(owned, "[crio.runtime]\nlog_level = \"debug\"\n", 0644),
+	}}}
+	oldIgnConfig := &ign3types.Config{Storage: ign3types.Storage{Files: []ign3types.File{
+		helpers.CreateEncodedIgn3File(removed, "[crio.runtime]\npids_limit = 2048\n", 0644),
+	}}}
+	for name, contents := range map[string]string{
+		"01-ctrcfg-logLevel":  "[crio.runtime]\nlog_level = \"debug\"\n",
+		"01-ctrcfg-pidsLimit": "[crio.runtime]\npids_limit = 2048\n",
+		"00-early":            "[crio.runtime]\nlog_level = \"info\"\n",
+		"99-override":         "[crio.runtime]\nlog_level = \"info\"\npids_limit = 4096\n",
+		"99-unparseable":      "[crio.runtime\n",
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644))
+	}
+
+	fragments, err := scanCrioFragments(dir, ignConfig, oldIgnConfig)
+	require.NoError(t, err)
+	assert.Equal(t, []mcfgv1.ForeignCrioFragment{
+		{Path: filepath.Join(dir, "00-early")},
+		{
+			Path:      filepath.Join(dir, "99-override"),
+			Overrides: []mcfgv1.CrioSettingOverride{{Key: "crio.runtime.log_level", Path: owned, Owner: "ContainerRuntimeConfig"}},
+		},
+		{Path: filepath.Join(dir, "99-unparseable")},
+	}, fragments)
+	assert.True(t, overridingCrioFragments(fragments))
+
+	fragments, err = scanCrioFragments(filepath.Join(dir, "missing"), ignConfig)
+	assert.NoError(t, err)
+	assert.Empty(t, fragments)
+}
+
+func TestQuarantineCrioFragments(t *testing.T) {
+	dir := t.TempDir()
+	quarantineDir := filepath.Join(t.TempDir(), "quarantine")
+	override := filepath.Join(dir, "99-override")
+	harmless := filepath.Join(dir, "00-early")
+	for _, path := range []string{override, harmless} {
+		require.NoError(t, ioutil.WriteFile(path, []byte("[crio.runtime]\n"), 0644))
+	}
+	fragments := []mcfgv1.ForeignCrioFragment{
+		{Path: harmless},
+		{Path: override, Overrides: []mcfgv1.CrioSettingOverride{{Key: "crio.runtime.log_level"}}},
+	}
+
+	restore, err := quarantineCrioFragments(fragments, quarantineDir)
+	require.NoError(t, err)
+	assert.False(t, fragments[0].Quarantined)
+	assert.True(t, fragments[1].Quarantined)
+	assert.FileExists(t, harmless)
+	assert.FileExists(t, filepath.Join(quarantineDir, "99-override"))
+	_, err = os.Stat(override)
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, restore())
+	assert.FileExists(t, override)
+}
@@ -45,6 +45,7 @@ import (
 	commonconstants "github.com/openshift/machine-config-operator/pkg/constants"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
 	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
 	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
 )
@@ -74,6 +75,9 @@ type Daemon struct {
 	// kubeClient allows interaction with Kubernetes, including the node we are running on.
 	kubeClient kubernetes.Interface
 
+	// mcfgClient publishes the MachineConfigNode of the node.
+	mcfgClient mcfgclientset.Interface
+
 	// recorder sends events to the apiserver
 	recorder record.EventRecorder
 
@@ -93,6 +97,16 @@ type Daemon struct {
 	updateActive     bool
 	updateActiveLock sync.Mutex
 
+	imagePruningActive bool
+	imagePruningLock   sync.Mutex
+
+	// retryPolicy controls how transient sync errors are retried before degrading
+	retryPolicy RetryPolicy
+	// transientErrorAttempts is the number of consecutive retries of transient errors
+	transientErrorAttempts int
+	// lastTransientError is the transient error last retried
+	lastTransientError string
+
 	nodeWriter NodeWriter
 
 	// channel used by callbacks to signal Run() of an error
@@ -127,6 +141,10 @@ type Daemon struct {
 
 	// Config Drift Monitor
 	configDriftMonitor ConfigDriftMonitor
+
+	// foreignCrioFragments are the CRI-O drop-ins of the node that aren't
+	// part of its config, as last scanned
+	foreignCrioFragments []mcfgv1.ForeignCrioFragment
 }
 
 // CoreOSDaemon protects the methods that should only be called on CoreOS variants
@@ -275,13 +293,16 @@ func New(
 func (dn *Daemon) ClusterConnect(
 	name string,
 	kubeClient kubernetes.Interface,
+	mcfgClient mcfgclientset.Interface,
 	mcInformer mcfginformersv1.MachineConfigInformer,
 	nodeInformer coreinformersv1.NodeInformer,
 	kubeletHealthzEnabled bool,
 	kubeletHealthzEndpoint string,
+	retryPolicy RetryPolicy,
 ) {
 	dn.name = name
 	dn.kubeClient = kubeClient
+	dn.mcfgClient = mcfgClient
 
 	dn.nodeWriter = newNodeWriter()
 	go dn.nodeWriter.Run(dn.stopCh)
@@ -314,6 +335,7 @@ func (dn *Daemon) ClusterConnect(
 
 	dn.kubeletHealthzEnabled = kubeletHealthzEnabled
 	dn.kubeletHealthzEndpoint = kubeletHealthzEndpoint
+	dn.retryPolicy = retryPolicy
 
 	dn.drainer = &drain.Helper{
 		Client:              dn.kubeClient,
@@ -368,10 +390,16 @@ func (dn *Daemon) processNextWorkItem() bool {
 
 func (dn *Daemon) handleErr(err error, key interface{}) {
 	if err == nil {
+		dn.resetTransientErrorAttempts()
 		dn.queue.Forget(key)
 		return
 	}
 
+	if dn.retryTransientError(err, key) {
+		return
+	}
+
+	dn.resetTransientErrorAttempts()
 	dn.updateErrorState(err)
 	// This is at V(2) since the updateErrorState() call above ends up logging too
 	glog.V(2).Infof("Error syncing node %v (retries %d): %v", key, dn.queue.NumRequeues(key), err)
@@ -518,6 +546,7 @@ func (dn *Daemon) runPreflightConfigDriftCheck() error {
 	}
 
 	glog.Infof("Preflight config drift check successful (took %s)", time.Since(start))
+	health.setConfigDrift(nil)
 
 	return nil
 }
@@ -741,6 +770,7 @@ func (dn *Daemon) applySSHAccessedAnnotation() error {
 func (dn *Daemon) onConfigDrift(err error) {
 	dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "ConfigDriftDetected", err.Error())
 	glog.Error(err)
+	health.setConfigDrift(err)
 	dn.updateErrorState(err)
 }
 
@@ -761,10 +791,11 @@ func (dn *Daemon) startConfigDriftMonitor() {
 	}
 
 	opts := ConfigDriftMonitorOpts{
-		OnDrift:       dn.onConfigDrift,
-		SystemdPath:   pathSystemd,
-		ErrChan:       dn.exitCh,
-		MachineConfig: currentConfig,
+		OnDrift:          dn.onConfigDrift,
+		SystemdPath:      pathSystemd,
+		ErrChan:          dn.exitCh,
+		MachineConfig:    currentConfig,
+		DriftAttribution: driftAttributionMode,
 	}
 
 	if err := dn.configDriftMonitor.Start(opts); err != nil {
@@ -944,6 +975,7 @@ func (dn *Daemon) getStateAndConfigs(pendingConfigName string) (*stateAndConfigs
 	}
 
 	MCDState.WithLabelValues(state, degradedReason).SetToCurrentTime()
+	health.setState(state, degradedReason)
 
 	return &stateAndConfigs{
 		bootstrapping: bootstrapping,
@@ -1090,6 +1122,11 @@ func removeIgnitionArtifacts() error {
 // Some more background in this PR: https://github.com/openshift/machine-config-operator/pull/245
 //nolint:gocyclo
 func (dn *Daemon) checkStateOnFirstRun() error {
+	// Undo partially applied file writes before looking at the on-disk state.
+	if err := recoverFileTransaction(); err != nil {
+		return err
+	}
+
 	node, err := dn.loadNodeAnnotations(dn.node)
 	if err != nil {
 		return err
@@ -1135,9 +1172,16 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 		if err := dn.finalizeBeforeReboot(state.pendingConfig); err != nil {
 			return err
 		}
+		health.setPendingReboot(state.pendingConfig.GetName())
 		return dn.reboot(fmt.Sprintf("Node will reboot into config %v", state.pendingConfig.GetName()))
 	}
 
+	// The bootloader may have fallen back to the previous deployment if the
+	// node failed its boot health checks.
+	if err := dn.checkBootFallback(state); err != nil {
+		return err
+	}
+
 	if err := dn.detectEarlySSHAccessesFromBoot(); err != nil {
 		return fmt.Errorf("error detecting previous SSH accesses: %v", err)
 	}
@@ -1162,6 +1206,7 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 			if err := dn.finalizeBeforeReboot(state.currentConfig); err != nil {
 				return err
 			}
+			health.setPendingReboot(state.currentConfig.GetName())
 			return dn.reboot(fmt.Sprintf("Node will reboot into config %v", state.currentConfig.GetName()))
 		}
 		glog.Info("No bootstrap pivot required; unlinking bootstrap node annotations")
@@ -1204,6 +1249,9 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 	if state.pendingConfig != nil {
 		glog.Infof("Validating against pending config %s", state.pendingConfig.GetName())
 		expectedConfig = state.pendingConfig
+		if err := dn.applyPendingOSImageURLAlias(); err != nil {
+			return err
+		}
 	} else {
 		glog.Infof("Validating against current config %s", state.currentConfig.GetName())
 		expectedConfig = state.currentConfig
@@ -1241,15 +1289,22 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 
 // updateConfigAndState updates node to desired state, labels nodes as done and uncordon
 func (dn *Daemon) updateConfigAndState(state *stateAndConfigs) (bool, error) {
-	// In the case where we had a pendingConfig, make that now currentConfig.
+	// In the case where we had a pendingConfig, make that now currentConfig
+	// once it passed the post-update validation of the pool, if any.
 	// We update the node annotation, delete the state file, etc.
 	if state.pendingConfig != nil {
+		if err := dn.validateUpdate(state.pendingConfig); err != nil {
+			return false, err
+		}
 		if dn.recorder != nil {
 			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "NodeDone", fmt.Sprintf("Setting node %s, currentConfig %s to Done", dn.node.Name, state.pendingConfig.GetName()))
 		}
 		if err := dn.nodeWriter.SetDone(dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name, state.pendingConfig.GetName()); err != nil {
 			return true, errors.Wrap(err, "error setting node's state to Done")
 		}
+		if err := commitOSImageURLAlias(constants.PendingOSImageURLAliasPath, constants.OSImageURLAliasPath); err != nil {
+			return true, err
+		}
 		if out, err := dn.storePendingState(state.pendingConfig, 0); err != nil {
 			return true, errors.Wrapf(err, "failed to reset pending config: %s", string(out))
 		}
@@ -1276,6 +1331,7 @@ func (dn *Daemon) updateConfigAndState(state *stateAndConfigs) (bool, error) {
 				MCDUpdateState.WithLabelValues("", err.Error()).SetToCurrentTime()
 				return inDesiredConfig, err
 			}
+			dn.startImagePruning()
 		}
 		// If we're degraded here, it means we got an error likely on startup and we retried.
 		// If that's the case, clear it out.
@@ -1288,6 +1344,8 @@ func (dn *Daemon) updateConfigAndState(state *stateAndConfigs) (bool, error) {
 		}
 
 		glog.Infof("In desired config %s", state.currentConfig.GetName())
+		dn.updateForeignCrioFragments(state.currentConfig)
+		dn.updateMachineConfigNode()
 		MCDUpdateState.WithLabelValues(state.currentConfig.GetName(), "").SetToCurrentTime()
 	}
 
@@ -1330,10 +1388,7 @@ func (dn *Daemon) runOnceFromMachineConfig(machineConfig mcfgv1.MachineConfig, c
 // runOnceFromIgnition executes MCD's subset of Ignition functionality in onceFrom mode
 func (dn *Daemon) runOnceFromIgnition(ignConfig ign3types.Config) error {
 	// Execute update without hitting the cluster
-	if err := dn.writeFiles(ignConfig.Storage.Files); err != nil {
-		return err
-	}
-	if err := dn.writeUnits(ignConfig.Systemd.Units); err != nil {
+	if err := dn.writeFilesAndUnits(ignConfig.Storage.Files, ignConfig.Systemd.Units); err != nil {
 		return err
 	}
 	// Unconditionally remove this file in the once-from (classic RHEL)
@@ -1487,6 +1542,10 @@ func (dn *Daemon) triggerUpdateWithMachineConfig(currentConfig, desiredConfig *m
 		}
 	}
 
+	if err := dn.checkRolledBackConfig(desiredConfig); err != nil {
+		return err
+	}
+
 	// Shut down the Config Drift Monitor since we'll be performing an update
 	// and the config will "drift" while the update is occurring.
 	dn.stopConfigDriftMonitor()
@@ -1509,6 +1568,26 @@ func (dn *Daemon) validateOnDiskState(currentConfig *mcfgv1.MachineConfig) error
 	return validateOnDiskState(currentConfig, pathSystemd)
 }
 
+// applyPendingOSImageURLAlias resolves the booted osImageURL with the alias
+// staged by a pending update which skipped the rebase, if any.  The daemon
+// only runs this once it rebooted into the pending config, the alias is
+// committed along with it by updateConfigAndState.
+func (dn *Daemon) applyPendingOSImageURLAlias() error {
+	if !dn.os.IsCoreOSVariant() {
+		return nil
+	}
+	alias, err := readOSImageURLAlias(constants.PendingOSImageURLAliasPath)
+	if err != nil || alias == nil {
+		return err
+	}
+	booted, err := dn.NodeUpdaterClient.GetBootedDeployment()
+	if err != nil {
+		return err
+	}
+	dn.bootedOSImageURL = resolveOSImageURL(booted, customOriginOSImageURL(booted), alias)
+	return nil
+}
+
 // checkOS determines whether the booted system matches the target
 // osImageURL and if not whether we need to take action.  This function
 // returns `true` if no action is required, which is the case if we're
@@ -1540,40 +1619,43 @@ func ValidPath(path string) bool {
 	return false
 }
 
-// senseAndLoadOnceFrom gets a hold of the content for supported onceFrom configurations,
-// parses to verify the type, and returns back the genericInterface, the type description,
-// if it was local or remote, and error.
-func (dn *Daemon) senseAndLoadOnceFrom(onceFrom string) (interface{}, onceFromOrigin, error) {
-	var (
-		content     []byte
-		contentFrom onceFromOrigin
-	)
+// isRemoteConfigSource returns whether a config is read from an http(s) endpoint.
+func isRemoteConfigSource(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// readConfigSource reads a config from a remote endpoint or a local file.
+func readConfigSource(source string) ([]byte, error) {
 	// Read the content from a remote endpoint if requested
 	/* #nosec */
-	if strings.HasPrefix(onceFrom, "http://") || strings.HasPrefix(onceFrom, "https://") {
-		contentFrom = onceFromRemoteConfig
-		resp, err := http.Get(onceFrom)
+	if isRemoteConfigSource(source) {
+		resp, err := http.Get(source)
 		if err != nil {
-			return nil, contentFrom, err
+			return nil, err
 		}
 		defer resp.Body.Close()
 		// Read the body content from the request
-		content, err = ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return nil, contentFrom, err
-		}
+		return ioutil.ReadAll(resp.Body)
+	}
+	// Otherwise read it from a local file
+	absoluteSource, err := filepath.Abs(filepath.Clean(source))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(absoluteSource)
+}
 
-	} else {
-		// Otherwise read it from a local file
-		contentFrom = onceFromLocalConfig
-		absoluteOnceFrom, err := filepath.Abs(filepath.Clean(onceFrom))
-		if err != nil {
-			return nil, contentFrom, err
-		}
-		content, err = ioutil.ReadFile(absoluteOnceFrom)
-		if err != nil {
-			return nil, contentFrom, err
-		}
+// senseAndLoadOnceFrom gets a hold of the content for supported onceFrom configurations,
+// parses to verify the type, and returns back the genericInterface, the type description,
+// if it was local or remote, and error.
+func (dn *Daemon) senseAndLoadOnceFrom(onceFrom string) (interface{}, onceFromOrigin, error) {
+	contentFrom := onceFromLocalConfig
+	if isRemoteConfigSource(onceFrom) {
+		contentFrom = onceFromRemoteConfig
+	}
+	content, err := readConfigSource(onceFrom)
+	if err != nil {
+		return nil, contentFrom, err
 	}
 
 	// Try each supported parser
@@ -151,10 +151,12 @@ func (f *fixture) newController() *Daemon {
 	}
 	d.ClusterConnect("node_name_test",
 		f.kubeclient,
+		f.client,
 		i.Machineconfiguration().V1().MachineConfigs(),
 		k8sI.Core().V1().Nodes(),
 		false,
 		"",
+		DefaultRetryPolicy(),
 	)
 
 	d.mcListerSynced = alwaysReady
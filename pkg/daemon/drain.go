@@ -78,6 +78,8 @@ func (dn *Daemon) cordonOrUncordonNode(desired bool) error {
 func (dn *Daemon) drain() error {
 	failedDrains := 0
 	done := make(chan bool, 1)
+	// left behind if a previous drain of the node timed out
+	drainBlockers := dn.node.Annotations[constants.DrainBlockersAnnotationKey]
 
 	drainer := func() chan error {
 		ret := make(chan error)
@@ -89,6 +91,7 @@ func (dn *Daemon) drain() error {
 				default:
 					if err := drain.RunNodeDrain(dn.drainer, dn.node.Name); err != nil {
 						glog.Infof("Draining failed with: %v, retrying", err)
+						drainBlockers = dn.updateDrainBlockers(drainBlockers)
 						failedDrains++
 						if failedDrains > 5 {
 							time.Sleep(5 * time.Minute)
@@ -97,6 +100,7 @@ func (dn *Daemon) drain() error {
 						}
 						continue
 					}
+					dn.publishDrainBlockers(drainBlockers, "")
 					close(ret)
 					return
 				}
@@ -111,7 +115,7 @@ func (dn *Daemon) drain() error {
 		failMsg := fmt.Sprintf("failed to drain node : %s after 1 hour", dn.node.Name)
 		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "FailedToDrain", failMsg)
 		MCDDrainErr.Set(1)
-		return errors.New(failMsg)
+		return &ctrlcommon.DrainTimeoutError{Err: errors.New(failMsg)}
 	case <-drainer():
 		return nil
 	}
@@ -158,7 +162,20 @@ func isDrainRequired(actions, diffFileSet []string, oldIgnConfig, newIgnConfig i
 	if ctrlcommon.InSlice(postConfigChangeActionReboot, actions) {
 		// Node is going to reboot, we definitely want to perform drain
 		return true, nil
-	} else if ctrlcommon.InSlice(postConfigChangeActionReloadCrio, actions) {
+	} else if ctrlcommon.InSlice(postConfigChangeActionReloadMultipathd, actions) {
+		// Reconfiguring the multipath maps may briefly interrupt I/O to the volumes of the pods
+		return true, nil
+	} else if ctrlcommon.InSlice(postConfigChangeActionReloadCrio, actions) || ctrlcommon.InSlice(postConfigChangeActionRestartCrio, actions) ||
+		ctrlcommon.InSlice(postConfigChangeActionRestartKubelet, actions) {
+		// Restarting crio or the kubelet leaves running containers alone, unless the
+		// kubelet resource manager state is reset and the pods lose their assignments.
+		stale, err := staleKubeletStateFiles(&oldIgnConfig, &newIgnConfig)
+		if err != nil {
+			return false, err
+		}
+		if len(stale) > 0 {
+			return true, nil
+		}
 		// Drain may or may not be necessary in case of container registry config changes.
 		if ctrlcommon.InSlice(constants.ContainerRegistryConfPath, diffFileSet) {
 			isSafe, err := isSafeContainerRegistryConfChanges(oldIgnConfig, newIgnConfig)
@@ -0,0 +1,135 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+// findDrainBlockers returns the PodDisruptionBudgets which allow no
+// disruption of any of the pods, sorted by namespace and name.
+func findDrainBlockers(client kubernetes.Interface, pods []corev1.Pod) ([]mcfgv1.PoolDrainBlocker, error) {
+	podsByNamespace := map[string][]corev1.Pod{}
+	for _, pod := range pods {
+		podsByNamespace[pod.Namespace] = append(podsByNamespace[pod.Namespace], pod)
+	}
+
+	var blockers []mcfgv1.PoolDrainBlocker
+	for namespace, nsPods := range podsByNamespace {
+		pdbs, err := client.PolicyV1().PodDisruptionBudgets(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing PodDisruptionBudgets in namespace %s: %w", namespace, err)
+		}
+		for _, pdb := range pdbs.Items {
+			if pdb.Status.DisruptionsAllowed > 0 {
+				continue
+			}
+			// Like the eviction API, a nil selector matches no pods and an
+			// empty one all pods.
+			selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+			if err != nil {
+				continue
+			}
+			for i := range nsPods {
+				if !selector.Matches(labels.Set(nsPods[i].Labels)) {
+					continue
+				}
+				blockers = append(blockers, mcfgv1.PoolDrainBlocker{
+					Namespace:          pdb.Namespace,
+					Name:               pdb.Name,
+					Owner:              podOwner(client, &nsPods[i]),
+					DisruptionsAllowed: pdb.Status.DisruptionsAllowed,
+				})
+				break
+			}
+		}
+	}
+
+	sort.Slice(blockers, func(i, j int) bool {
+		if blockers[i].Namespace != blockers[j].Namespace {
+			return blockers[i].Namespace < blockers[j].Namespace
+		}
+		return blockers[i].Name < blockers[j].Name
+	})
+	return blockers, nil
+}
+
+// podOwner returns the workload of the pod as Kind/name, following a
+// ReplicaSet to its Deployment, or "" for a bare pod.
+func podOwner(client kubernetes.Interface, pod *corev1.Pod) string {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return ""
+	}
+	if ref.Kind == "ReplicaSet" {
+		rs, err := client.AppsV1().ReplicaSets(pod.Namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+		if err != nil {
+			glog.V(2).Infof("Could not get ReplicaSet %s/%s: %v", pod.Namespace, ref.Name, err)
+		} else if rsRef := metav1.GetControllerOf(rs); rsRef != nil {
+			return rsRef.Kind + "/" + rsRef.Name
+		}
+	}
+	return ref.Kind + "/" + ref.Name
+}
+
+// describeDrainBlockers formats the blockers for logs and events.
+func describeDrainBlockers(blockers []mcfgv1.PoolDrainBlocker) string {
+	var descs []string
+	for _, b := range blockers {
+		desc := fmt.Sprintf("%s/%s", b.Namespace, b.Name)
+		if b.Owner != "" {
+			desc += fmt.Sprintf(" (%s)", b.Owner)
+		}
+		descs = append(descs, desc)
+	}
+	return strings.Join(descs, ", ")
+}
+
+// updateDrainBlockers analyzes the pods still to be evicted after a failed
+// drain and publishes the PodDisruptionBudgets blocking it on the node.
+// published is the value of the annotation, the new value is returned.
+func (dn *Daemon) updateDrainBlockers(published string) string {
+	list, errs := dn.drainer.GetPodsForDeletion(dn.name)
+	if len(errs) > 0 {
+		glog.Warningf("Could not analyze drain blockers: %v", errs)
+		return published
+	}
+	blockers, err := findDrainBlockers(dn.kubeClient, list.Pods())
+	if err != nil {
+		glog.Warningf("Could not analyze drain blockers: %v", err)
+		return published
+	}
+	var annotation string
+	if len(blockers) > 0 {
+		glog.Infof("Drain blocked by PodDisruptionBudgets: %s", describeDrainBlockers(blockers))
+		b, err := json.Marshal(blockers)
+		if err != nil {
+			glog.Warningf("Could not encode drain blockers: %v", err)
+			return published
+		}
+		annotation = string(b)
+	}
+	return dn.publishDrainBlockers(published, annotation)
+}
+
+// publishDrainBlockers sets the drain blockers annotation on the node if it changed.
+func (dn *Daemon) publishDrainBlockers(published, annotation string) string {
+	if annotation == published || dn.nodeWriter == nil {
+		return published
+	}
+	if err := dn.nodeWriter.SetDrainBlockers(annotation, dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+		glog.Errorf("Error setting drain blockers annotation on node %s: %v", dn.name, err)
+		return published
+	}
+	return annotation
+}
@@ -0,0 +1,105 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+func newBlockerPod(namespace, name string, labels map[string]string, owner *metav1.OwnerReference) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    labels,
+		},
+	}
+	if owner != nil {
+		pod.OwnerReferences = []metav1.OwnerReference{*owner}
+	}
+	return pod
+}
+
+func newPDB(namespace, name string, selector *metav1.LabelSelector, disruptionsAllowed int32) *policyv1.PodDisruptionBudget {
+	minAvailable := intstr.FromInt(1)
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector:     selector,
+		},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			DisruptionsAllowed: disruptionsAllowed,
+		},
+	}
+}
+
+func controllerRef(kind, name string) *metav1.OwnerReference {
+	isController := true
+	return &metav1.OwnerReference{Kind: kind, Name: name, Controller: &isController}
+}
+
+func TestFindDrainBlockers(t *testing.T) {
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "shop",
+			Name:            "frontend-7d4b9c",
+			OwnerReferences: []metav1.OwnerReference{*controllerRef("Deployment", "frontend")},
+		},
+	}
+	client := k8sfake.NewSimpleClientset(
+		rs,
+		newPDB("shop", "frontend", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "frontend"}}, 0),
+		newPDB("shop", "backend", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "backend"}}, 1),
+		newPDB("shop", "nothing", nil, 0),
+		newPDB("db", "postgres", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "postgres"}}, 0),
+		newPDB("db", "everything", &metav1.LabelSelector{}, 0),
+		newPDB("other", "unrelated", &metav1.LabelSelector{}, 0),
+	)
+	pods := []corev1.Pod{
+		newBlockerPod("shop", "frontend-7d4b9c-abcde", map[string]string{"app": "frontend"}, controllerRef("ReplicaSet", "frontend-7d4b9c")),
+		newBlockerPod("shop", "frontend-7d4b9c-fghij", map[string]string{"app": "frontend"}, controllerRef("ReplicaSet", "frontend-7d4b9c")),
+		newBlockerPod("shop", "backend-0", map[string]string{"app": "backend"}, controllerRef("StatefulSet", "backend")),
+		newBlockerPod("db", "postgres-0", map[string]string{"app": "postgres"}, controllerRef("StatefulSet", "postgres")),
+	}
+
+	blockers, err := findDrainBlockers(client, pods)
+	require.NoError(t, err)
+	assert.Equal(t, []mcfgv1.PoolDrainBlocker{{
+		Namespace: "db",
+		Name:      "everything",
+		Owner:     "StatefulSet/postgres",
+	}, {
+		Namespace: "db",
+		Name:      "postgres",
+		Owner:     "StatefulSet/postgres",
+	}, {
+		Namespace: "shop",
+		Name:      "frontend",
+		Owner:     "Deployment/frontend",
+	}}, blockers)
+	assert.Equal(t, "db/everything (StatefulSet/postgres), db/postgres (StatefulSet/postgres), shop/frontend (Deployment/frontend)", describeDrainBlockers(blockers))
+}
+
+func TestPodOwner(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+
+	bare := newBlockerPod("ns", "bare", nil, nil)
+	assert.Equal(t, "", podOwner(client, &bare))
+
+	// The ReplicaSet is reported if it can't be followed to its Deployment.
+	orphan := newBlockerPod("ns", "orphan", nil, controllerRef("ReplicaSet", "gone"))
+	assert.Equal(t, "ReplicaSet/gone", podOwner(client, &orphan))
+}
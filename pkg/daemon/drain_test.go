@@ -1,6 +1,8 @@
 package daemon
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
 	"reflect"
 	"testing"
@@ -8,6 +10,7 @@ import (
 	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	"github.com/openshift/machine-config-operator/test/helpers"
 	"github.com/vincent-petithory/dataurl"
 )
@@ -239,6 +242,12 @@ location = "example.com/repo/test-img"
 				},
 			},
 		}}),
+		"mc11": helpers.NewMachineConfig("11-test", nil, "dummy://", []ign3types.File{
+			helpers.NewIgnFile("/etc/kubernetes/kubelet.conf", `{"kind":"KubeletConfiguration","maxPods":250}`),
+		}),
+		"mc12": helpers.NewMachineConfig("12-test", nil, "dummy://", []ign3types.File{
+			helpers.NewIgnFile("/etc/kubernetes/kubelet.conf", `{"kind":"KubeletConfiguration","maxPods":250,"cpuManagerPolicy":"static","reservedSystemCPUs":"0-1"}`),
+		}),
 	}
 
 	tests := []struct {
@@ -261,6 +270,27 @@ location = "example.com/repo/test-img"
 			newConfig:      machineConfigs["mc1"],
 			expectedAction: true,
 		},
+		{
+			// skip drain: crio and kubelet restarts keep running containers
+			actions:        []string{postConfigChangeActionRestartCrio, postConfigChangeActionRestartKubelet},
+			oldConfig:      machineConfigs["mc1"],
+			newConfig:      machineConfigs["mc1"],
+			expectedAction: false,
+		},
+		{
+			// perform drain: kubelet restart resets the cpu manager state
+			actions:        []string{postConfigChangeActionRestartKubelet},
+			oldConfig:      machineConfigs["mc11"],
+			newConfig:      machineConfigs["mc12"],
+			expectedAction: true,
+		},
+		{
+			// perform drain: multipathd reload reconfigures the maps of the pod volumes
+			actions:        []string{postConfigChangeActionReloadCrio, postConfigChangeActionReloadMultipathd},
+			oldConfig:      machineConfigs["mc1"],
+			newConfig:      machineConfigs["mc1"],
+			expectedAction: true,
+		},
 		// below tests are run when only crio reload action is present
 		{
 			// skip drain: no changes in registry config
@@ -367,3 +397,91 @@ location = "example.com/repo/test-img"
 	}
 
 }
+
+// newCompressedIgnFile returns a file of path with contents gzipped, as the
+// render controller compresses the largest files of a too large config.
+func newCompressedIgnFile(t *testing.T, path, contents string) ign3types.File {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(contents)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file := helpers.NewIgnFile(path, "")
+	file.Contents.Source = helpers.StrToPtr(dataurl.EncodeBytes(buf.Bytes()))
+	file.Contents.Compression = helpers.StrToPtr("gzip")
+	return file
+}
+
+func TestCompressedFileChanges(t *testing.T) {
+	registries := `
+unqualified-search-registries = ["example.com"]
+
+[[registry]]
+  prefix = ""
+  location = "example.com/repo/test-img"
+  mirror-by-digest-only = true
+
+  [[registry.mirror]]
+    location = "mirror.com/repo/test-img"
+`
+	tests := []struct {
+		name            string
+		oldFile         ign3types.File
+		newFile         ign3types.File
+		expectedActions []string
+		expectedDrain   bool
+	}{
+		{
+			name:            "kubelet.conf restartable change",
+			oldFile:         newCompressedIgnFile(t, kubeletConfPath, `{"kind":"KubeletConfiguration","maxPods":250}`),
+			newFile:         newCompressedIgnFile(t, kubeletConfPath, `{"kind":"KubeletConfiguration","maxPods":500}`),
+			expectedActions: []string{postConfigChangeActionRestartKubelet},
+			expectedDrain:   false,
+		},
+		{
+			name:            "kubelet.conf cpu manager change",
+			oldFile:         newCompressedIgnFile(t, kubeletConfPath, `{"kind":"KubeletConfiguration","maxPods":250}`),
+			newFile:         newCompressedIgnFile(t, kubeletConfPath, `{"kind":"KubeletConfiguration","maxPods":250,"cpuManagerPolicy":"static","reservedSystemCPUs":"0-1"}`),
+			expectedActions: []string{postConfigChangeActionRestartKubelet},
+			expectedDrain:   true,
+		},
+		{
+			name:    "registries.conf mirror added",
+			oldFile: newCompressedIgnFile(t, constants.ContainerRegistryConfPath, registries),
+			newFile: newCompressedIgnFile(t, constants.ContainerRegistryConfPath, registries+`
+  [[registry.mirror]]
+    location = "mirror1.com/repo/test-img"
+`),
+			expectedActions: []string{postConfigChangeActionReloadCrio},
+			expectedDrain:   false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			oldIgnConfig := ctrlcommon.NewIgnConfig()
+			oldIgnConfig.Storage.Files = []ign3types.File{test.oldFile}
+			newIgnConfig := ctrlcommon.NewIgnConfig()
+			newIgnConfig.Storage.Files = []ign3types.File{test.newFile}
+
+			diffFileSet := ctrlcommon.CalculateConfigFileDiffs(&oldIgnConfig, &newIgnConfig)
+			actions, err := calculatePostConfigChangeActionFromFileDiffs(&oldIgnConfig, &newIgnConfig, diffFileSet)
+			if err != nil {
+				t.Fatalf("classifying the change failed: %v", err)
+			}
+			if !reflect.DeepEqual(test.expectedActions, actions) {
+				t.Errorf("expected actions %v, got %v", test.expectedActions, actions)
+			}
+			drain, err := isDrainRequired(actions, diffFileSet, oldIgnConfig, newIgnConfig)
+			if err != nil {
+				t.Fatalf("determining drain failed: %v", err)
+			}
+			if drain != test.expectedDrain {
+				t.Errorf("expected drain %v, got %v", test.expectedDrain, drain)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// The modes of finding the process which modified a file managed by the
+// MachineConfig when the Config Drift Monitor detects a drift.
+const (
+	// DriftAttributionNone only reports how the file changed.
+	DriftAttributionNone = "none"
+	// DriftAttributionFanotify watches the managed directories with fanotify,
+	// which reports the pid of the writer.
+	DriftAttributionFanotify = "fanotify"
+	// DriftAttributionAuditd adds audit watch rules for the managed files and
+	// searches the audit log for the writer. Requires auditd to be running.
+	DriftAttributionAuditd = "auditd"
+)
+
+// auditKey tags the audit rules added by the daemon.
+const auditKey = "mco-config-drift"
+
+// driftAttributionMode is the mode used by the Config Drift Monitor.
+var driftAttributionMode = DriftAttributionNone
+
+// SetDriftAttributionMode validates and sets the mode used for all Config
+// Drift Monitors started afterwards.
+func SetDriftAttributionMode(mode string) error {
+	switch mode {
+	case DriftAttributionNone, DriftAttributionFanotify, DriftAttributionAuditd:
+	default:
+		return fmt.Errorf("invalid drift attribution mode %q: must be one of %s, %s or %s", mode, DriftAttributionNone, DriftAttributionFanotify, DriftAttributionAuditd)
+	}
+	driftAttributionMode = mode
+	return nil
+}
+
+// fileStamp identifies a version of a file without reading it.
+type fileStamp struct {
+	exists  bool
+	inode   uint64
+	modTime time.Time
+	mode    os.FileMode
+}
+
+func statFile(path string) fileStamp {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{
+		exists:  true,
+		inode:   fileInode(fi),
+		modTime: fi.ModTime(),
+		mode:    fi.Mode(),
+	}
+}
+
+func (s fileStamp) equal(o fileStamp) bool {
+	return s.exists == o.exists && s.inode == o.inode && s.modTime.Equal(o.modTime) && s.mode == o.mode
+}
+
+// snapshotFiles stamps each of the given files.
+func snapshotFiles(paths sets.String) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(paths))
+	for path := range paths {
+		stamps[path] = statFile(path)
+	}
+	return stamps
+}
+
+// fileWriter is a process seen modifying a file.
+type fileWriter struct {
+	pid  int
+	comm string
+	exe  string
+	// auid is the login uid of the process, only known to auditd.
+	auid string
+	time time.Time
+}
+
+func (w fileWriter) String() string {
+	s := fmt.Sprintf("pid %d", w.pid)
+	var details []string
+	if w.comm != "" {
+		details = append(details, fmt.Sprintf("comm %q", w.comm))
+	}
+	if w.exe != "" {
+		details = append(details, fmt.Sprintf("exe %q", w.exe))
+	}
+	if w.auid != "" {
+		details = append(details, fmt.Sprintf("auid %s", w.auid))
+	}
+	if len(details) > 0 {
+		s += " (" + strings.Join(details, ", ") + ")"
+	}
+	if !w.time.IsZero() {
+		s += " at " + w.time.UTC().Format(time.RFC3339)
+	}
+	return s
+}
+
+// driftEvidence describes how a managed file changed, and who changed it.
+type driftEvidence struct {
+	path          string
+	before, after fileStamp
+	writer        *fileWriter
+}
+
+func (e driftEvidence) String() string {
+	var s string
+	switch {
+	case !e.after.exists:
+		s = fmt.Sprintf("%s was removed", e.path)
+	case e.before.exists && e.before.inode != e.after.inode:
+		s = fmt.Sprintf("%s was replaced (inode %d -> %d) at %s", e.path, e.before.inode, e.after.inode, e.after.modTime.UTC().Format(time.RFC3339))
+	case e.before.mode != e.after.mode && e.before.modTime.Equal(e.after.modTime):
+		s = fmt.Sprintf("%s mode changed from %v to %v", e.path, e.before.mode, e.after.mode)
+	default:
+		s = fmt.Sprintf("%s was modified at %s", e.path, e.after.modTime.UTC().Format(time.RFC3339))
+	}
+	if e.writer != nil {
+		s += " by " + e.writer.String()
+	}
+	return s
+}
+
+// processAttributor finds the processes modifying the managed files.
+type processAttributor interface {
+	// lastWriter returns the last process seen modifying path, if any.
+	lastWriter(path string) *fileWriter
+	stop()
+}
+
+// newProcessAttributor starts an attributor for the given files, or returns
+// nil if the mode is DriftAttributionNone.
+func newProcessAttributor(mode string, filePaths sets.String) (processAttributor, error) {
+	switch mode {
+	case DriftAttributionFanotify:
+		return newFanotifyAttributor(filePaths)
+	case DriftAttributionAuditd:
+		return newAuditdAttributor(filePaths)
+	default:
+		return nil, nil
+	}
+}
+
+// auditdAttributor watches the files with audit rules, so the writer can be
+// found in the audit log even if it replaced the file.
+type auditdAttributor struct{}
+
+func newAuditdAttributor(filePaths sets.String) (processAttributor, error) {
+	a := &auditdAttributor{}
+	// Drop the rules left behind by a previous daemon, if any.
+	a.stop()
+	for _, path := range filePaths.List() {
+		if _, err := runGetOut("auditctl", "-w", path, "-p", "wa", "-k", auditKey); err != nil {
+			a.stop()
+			return nil, fmt.Errorf("could not add audit rule: %w", err)
+		}
+	}
+	return a, nil
+}
+
+func (a *auditdAttributor) lastWriter(path string) *fileWriter {
+	// The drift is detected within seconds, so recent (the last 10 minutes)
+	// covers the write.
+	out, err := runGetOut("ausearch", "-k", auditKey, "-f", path, "-ts", "recent")
+	if err != nil {
+		// ausearch also fails if there are no matches.
+		glog.V(2).Infof("No audit records for %s: %v", path, err)
+		return nil
+	}
+	return parseAusearchOutput(string(out))
+}
+
+func (a *auditdAttributor) stop() {
+	if _, err := runGetOut("auditctl", "-D", "-k", auditKey); err != nil {
+		glog.Warningf("Could not remove audit rules: %v", err)
+	}
+}
+
+// parseAusearchOutput returns the process of the last SYSCALL record in the
+// raw output of ausearch.
+func parseAusearchOutput(out string) *fileWriter {
+	var writer *fileWriter
+	for _, line := range strings.Split(out, "\n") {
+		if !strings.HasPrefix(line, "type=SYSCALL ") {
+			continue
+		}
+		w := fileWriter{}
+		for _, field := range strings.Fields(line) {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			value := strings.Trim(kv[1], `"`)
+			switch kv[0] {
+			case "msg":
+				w.time = parseAuditTimestamp(value)
+			case "pid":
+				w.pid, _ = strconv.Atoi(value)
+			case "comm":
+				w.comm = value
+			case "exe":
+				w.exe = value
+			case "auid":
+				w.auid = value
+			}
+		}
+		writer = &w
+	}
+	return writer
+}
+
+// parseAuditTimestamp parses the time of an audit(1646128931.123:456): message.
+func parseAuditTimestamp(msg string) time.Time {
+	msg = strings.TrimPrefix(msg, "audit(")
+	if i := strings.Index(msg, ":"); i >= 0 {
+		msg = msg[:i]
+	}
+	parts := strings.SplitN(msg, ".", 2)
+	secs, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	var millis int64
+	if len(parts) == 2 {
+		millis, _ = strconv.ParseInt(parts[1], 10, 64)
+	}
+	return time.Unix(secs, millis*int64(time.Millisecond))
+}
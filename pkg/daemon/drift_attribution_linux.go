@@ -0,0 +1,124 @@
+//go:build linux
+// +build linux
+
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func fileInode(fi os.FileInfo) uint64 {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return st.Ino
+	}
+	return 0
+}
+
+// fanotifyAttributor records the last writer of each file from fanotify
+// events on the directories of the files. Unlike auditd, fanotify doesn't
+// report a file replaced by a rename, only the writes to the temporary file.
+type fanotifyAttributor struct {
+	file      *os.File
+	filePaths sets.String
+
+	mu      sync.Mutex
+	writers map[string]fileWriter
+	wg      sync.WaitGroup
+}
+
+func newFanotifyAttributor(filePaths sets.String) (processAttributor, error) {
+	fd, err := unix.FanotifyInit(unix.FAN_CLASS_NOTIF|unix.FAN_CLOEXEC|unix.FAN_NONBLOCK, unix.O_RDONLY|unix.O_CLOEXEC|unix.O_LARGEFILE)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize fanotify: %w", err)
+	}
+	for _, dir := range getDirPathsFromFilePaths(filePaths) {
+		if err := unix.FanotifyMark(fd, unix.FAN_MARK_ADD, unix.FAN_MODIFY|unix.FAN_CLOSE_WRITE|unix.FAN_EVENT_ON_CHILD, unix.AT_FDCWD, dir); err != nil {
+			unix.Close(fd)
+			return nil, fmt.Errorf("could not add fanotify mark to dir %q: %w", dir, err)
+		}
+	}
+
+	a := &fanotifyAttributor{
+		// The fd is non-blocking, so closing the file interrupts the reader.
+		file:      os.NewFile(uintptr(fd), "fanotify"),
+		filePaths: filePaths,
+		writers:   map[string]fileWriter{},
+	}
+	a.wg.Add(1)
+	go a.read()
+	return a, nil
+}
+
+func (a *fanotifyAttributor) read() {
+	defer a.wg.Done()
+	buf := make([]byte, 4096)
+	for {
+		n, err := a.file.Read(buf)
+		if err != nil {
+			return
+		}
+		a.handleEvents(buf[:n])
+	}
+}
+
+// sizeofFanotifyEventMetadata is the size of the fixed part of an event.
+const sizeofFanotifyEventMetadata = int(unsafe.Sizeof(unix.FanotifyEventMetadata{}))
+
+func (a *fanotifyAttributor) handleEvents(buf []byte) {
+	self := os.Getpid()
+	for len(buf) >= sizeofFanotifyEventMetadata {
+		event := (*unix.FanotifyEventMetadata)(unsafe.Pointer(&buf[0]))
+		if event.Vers != unix.FANOTIFY_METADATA_VERSION || int(event.Event_len) > len(buf) || int(event.Event_len) < sizeofFanotifyEventMetadata {
+			return
+		}
+		buf = buf[event.Event_len:]
+		if event.Fd < 0 {
+			continue
+		}
+		path, err := os.Readlink(fmt.Sprintf("/proc/self/fd/%d", event.Fd))
+		unix.Close(int(event.Fd))
+		if err != nil || !a.filePaths.Has(path) || int(event.Pid) == self {
+			continue
+		}
+		writer := processInfo(int(event.Pid))
+		a.mu.Lock()
+		a.writers[path] = writer
+		a.mu.Unlock()
+	}
+}
+
+// processInfo looks up a process while it's (hopefully) still running.
+func processInfo(pid int) fileWriter {
+	w := fileWriter{pid: pid, time: time.Now()}
+	if comm, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/comm", pid)); err == nil {
+		w.comm = strings.TrimSpace(string(comm))
+	}
+	if exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid)); err == nil {
+		w.exe = exe
+	}
+	return w
+}
+
+func (a *fanotifyAttributor) lastWriter(path string) *fileWriter {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if w, ok := a.writers[path]; ok {
+		return &w
+	}
+	return nil
+}
+
+func (a *fanotifyAttributor) stop() {
+	a.file.Close()
+	a.wg.Wait()
+}
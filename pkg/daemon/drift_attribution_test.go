@@ -0,0 +1,98 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestSetDriftAttributionMode(t *testing.T) {
+	defer func() { driftAttributionMode = DriftAttributionNone }()
+
+	assert.NoError(t, SetDriftAttributionMode(DriftAttributionAuditd))
+	assert.Equal(t, DriftAttributionAuditd, driftAttributionMode)
+	assert.Error(t, SetDriftAttributionMode("ebpf"))
+	assert.Equal(t, DriftAttributionAuditd, driftAttributionMode)
+}
+
+type fakeAttributor map[string]fileWriter
+
+func (f fakeAttributor) lastWriter(path string) *fileWriter {
+	if w, ok := f[path]; ok {
+		return &w
+	}
+	return nil
+}
+
+func (f fakeAttributor) stop() {}
+
+func TestDriftEvidence(t *testing.T) {
+	dir := t.TempDir()
+	modified := filepath.Join(dir, "modified")
+	replaced := filepath.Join(dir, "replaced")
+	removed := filepath.Join(dir, "removed")
+	chmodded := filepath.Join(dir, "chmodded")
+	untouched := filepath.Join(dir, "untouched")
+	for _, path := range []string{modified, replaced, removed, chmodded, untouched} {
+		require.NoError(t, ioutil.WriteFile(path, []byte("contents"), 0644))
+	}
+
+	c := &configDriftWatcher{filePaths: sets.NewString(modified, replaced, removed, chmodded, untouched)}
+	c.stamps = snapshotFiles(c.filePaths)
+
+	mtime := time.Now().Add(time.Minute)
+	require.NoError(t, ioutil.WriteFile(modified, []byte("other contents"), 0644))
+	require.NoError(t, os.Chtimes(modified, mtime, mtime))
+	require.NoError(t, ioutil.WriteFile(replaced+".tmp", []byte("other contents"), 0644))
+	require.NoError(t, os.Rename(replaced+".tmp", replaced))
+	require.NoError(t, os.Remove(removed))
+	require.NoError(t, os.Chmod(chmodded, 0600))
+
+	evidence := c.driftEvidence(modified)
+	require.Len(t, evidence, 4)
+	assert.Equal(t, chmodded+" mode changed from -rw-r--r-- to -rw-------", evidence[0])
+	assert.Equal(t, modified+" was modified at "+mtime.UTC().Format(time.RFC3339), evidence[1])
+	assert.Equal(t, removed+" was removed", evidence[2])
+	assert.Regexp(t, `^`+replaced+` was replaced \(inode \d+ -> \d+\) at `, evidence[3])
+
+	// The writer of the file of the event is reported even if it wrote the
+	// same stamp back.
+	c.attributor = fakeAttributor{
+		modified:  {pid: 4242, comm: "falcon-sensor", exe: "/opt/CrowdStrike/falcon-sensor"},
+		untouched: {pid: 4243, comm: "vi"},
+	}
+	evidence = c.driftEvidence(untouched)
+	require.Len(t, evidence, 5)
+	assert.Equal(t, modified+` was modified at `+mtime.UTC().Format(time.RFC3339)+` by pid 4242 (comm "falcon-sensor", exe "/opt/CrowdStrike/falcon-sensor")`, evidence[1])
+	assert.Equal(t, untouched+` was modified at `+c.stamps[untouched].modTime.UTC().Format(time.RFC3339)+` by pid 4243 (comm "vi")`, evidence[4])
+}
+
+func TestParseAusearchOutput(t *testing.T) {
+	out := `----
+time->Tue Mar  1 10:02:11 2022
+type=PROCTITLE msg=audit(1646128931.123:456): proctitle="/opt/CrowdStrike/falcon-sensor"
+type=PATH msg=audit(1646128931.123:456): item=1 name="/etc/foo" inode=1234 nametype=CREATE
+type=SYSCALL msg=audit(1646128931.123:456): arch=c000003e syscall=257 success=yes exit=3 ppid=1 pid=4242 auid=4294967295 uid=0 gid=0 tty=(none) ses=4294967295 comm="falcon-sensor" exe="/opt/CrowdStrike/falcon-sensor" subj=system_u:system_r:unconfined_service_t:s0 key="mco-config-drift"
+----
+time->Tue Mar  1 10:05:00 2022
+type=SYSCALL msg=audit(1646129100.500:470): arch=c000003e syscall=82 success=yes exit=0 ppid=3012 pid=3015 auid=1000 uid=0 gid=0 tty=pts0 ses=3 comm="vi" exe="/usr/bin/vi" key="mco-config-drift"
+`
+	w := parseAusearchOutput(out)
+	require.NotNil(t, w)
+	assert.Equal(t, fileWriter{
+		pid:  3015,
+		comm: "vi",
+		exe:  "/usr/bin/vi",
+		auid: "1000",
+		time: time.Unix(1646129100, int64(500*time.Millisecond)),
+	}, *w)
+	assert.Equal(t, `pid 3015 (comm "vi", exe "/usr/bin/vi", auid 1000) at 2022-03-01T10:05:00Z`, w.String())
+
+	assert.Nil(t, parseAusearchOutput("<no matches>\n"))
+}
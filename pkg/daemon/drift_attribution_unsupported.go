@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func fileInode(fi os.FileInfo) uint64 {
+	return 0
+}
+
+func newFanotifyAttributor(filePaths sets.String) (processAttributor, error) {
+	return nil, fmt.Errorf("fanotify is only supported on linux")
+}
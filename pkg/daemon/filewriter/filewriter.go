@@ -0,0 +1,85 @@
+// Package filewriter contains the file writing engine used by the MCD to lay
+// down files and systemd units on the host.  Single files are written
+// atomically (write to a temporary file, fsync, rename, fsync the parent
+// directory), and groups of writes and unit enablement changes can be wrapped
+// in a Transaction which keeps an on-disk rollback journal so that a daemon
+// killed mid-apply never leaves the host with only part of a config written.
+package filewriter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/renameio"
+)
+
+const (
+	// DefaultDirectoryPermissions houses the default mode to use when no directory permissions are provided
+	DefaultDirectoryPermissions os.FileMode = 0755
+	// DefaultFilePermissions houses the default mode to use when no file permissions are provided
+	DefaultFilePermissions os.FileMode = 0644
+)
+
+// WriteFileAtomicallyWithDefaults writes fpath atomically using the default
+// directory and file permissions and without changing ownership.
+func WriteFileAtomicallyWithDefaults(fpath string, b []byte) error {
+	return WriteFileAtomically(fpath, b, DefaultDirectoryPermissions, DefaultFilePermissions, -1, -1)
+}
+
+// WriteFileAtomically uses the renameio package to provide atomic file writing, we can't use renameio.WriteFile
+// directly since we need to 1) Chown 2) go through a buffer since files provided can be big.
+// A uid or gid of -1 leaves the ownership of the new file untouched.
+func WriteFileAtomically(fpath string, b []byte, dirMode, fileMode os.FileMode, uid, gid int) error {
+	dir := filepath.Dir(fpath)
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return fmt.Errorf("failed to create directory %q: %w", dir, err)
+	}
+	t, err := renameio.TempFile(dir, fpath)
+	if err != nil {
+		return err
+	}
+	defer t.Cleanup()
+	// Set permissions before writing data, in case the data is sensitive.
+	if err := t.Chmod(fileMode); err != nil {
+		return err
+	}
+	w := bufio.NewWriter(t)
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if uid != -1 && gid != -1 {
+		if err := t.Chown(uid, gid); err != nil {
+			return err
+		}
+		// chown clears the setuid and setgid bits
+		if fileMode&(os.ModeSetuid|os.ModeSetgid) != 0 {
+			if err := t.Chmod(fileMode); err != nil {
+				return err
+			}
+		}
+	}
+	// CloseAtomicallyReplace fsyncs the temporary file before renaming it
+	// into place; the rename itself is only durable once the directory is synced.
+	if err := t.CloseAtomicallyReplace(); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// syncDir fsyncs a directory so that renames and unlinks of its entries are durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync directory %q: %w", dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,379 @@
+package filewriter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/golang/glog"
+	"github.com/google/renameio"
+)
+
+const (
+	journalFileName = "journal.json"
+	backupsDirName  = "backups"
+	journalFileMode = 0600
+	journalDirMode  = 0700
+
+	// journalModeBits are the bits of the mode of a path restored on rollback.
+	journalModeBits = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+)
+
+// systemctl runs systemctl, it's replaced in tests.
+var systemctl = func(args ...string) ([]byte, error) {
+	return exec.Command("systemctl", args...).CombinedOutput()
+}
+
+// journalEntry records the state of a single path, or the enablement of a
+// systemd unit, before the first time a transaction touched it, which is
+// everything needed to put it back.
+type journalEntry struct {
+	Path string `json:"path,omitempty"`
+	// Existed is false if the path did not exist before the transaction,
+	// in which case rolling back removes it.
+	Existed bool `json:"existed"`
+	// Symlink holds the link target if the path was a symlink.
+	Symlink string `json:"symlink,omitempty"`
+	// Dir is true if the path was an empty directory.
+	Dir bool `json:"dir,omitempty"`
+	// Backup is the name of the copy of the original contents in the backups directory.
+	Backup string `json:"backup,omitempty"`
+	// Mode holds the permissions and the setuid, setgid and sticky bits.
+	Mode os.FileMode `json:"mode,omitempty"`
+	UID  int         `json:"uid"`
+	GID  int         `json:"gid"`
+	// Unit is the name of the systemd unit whose enablement was recorded.
+	Unit string `json:"unit,omitempty"`
+	// UnitState is the output of systemctl is-enabled for Unit, empty if
+	// the unit didn't exist.
+	UnitState string `json:"unitState,omitempty"`
+}
+
+type journal struct {
+	Entries []journalEntry `json:"entries"`
+}
+
+// Transaction groups a set of file writes, symlinks and removals so that they
+// either all take effect or none of them do.  Before a path is modified for
+// the first time its original state is saved into the journal directory and
+// the journal is synced to disk; Commit discards the journal while Rollback
+// (or Recover, after a crash) uses it to restore every touched path.
+//
+// The enablement of the systemd units the transaction enables, disables or
+// presets is journaled too, see RecordUnits.  Directories created as a side
+// effect of writing a file are not removed on rollback.
+type Transaction struct {
+	journalDir string
+	journal    journal
+	recorded   map[string]bool
+	done       bool
+}
+
+// Begin starts a new transaction keeping its rollback journal in journalDir.
+// It fails if journalDir holds the journal of an unfinished transaction; call
+// Recover first to roll that one back.
+func Begin(journalDir string) (*Transaction, error) {
+	if _, err := os.Stat(filepath.Join(journalDir, journalFileName)); err == nil {
+		return nil, fmt.Errorf("unfinished transaction found in %q", journalDir)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(journalDir, backupsDirName), journalDirMode); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory %q: %w", journalDir, err)
+	}
+	t := &Transaction{
+		journalDir: journalDir,
+		recorded:   make(map[string]bool),
+	}
+	if err := t.flushJournal(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// WriteFile atomically writes fpath as part of the transaction, see WriteFileAtomically.
+func (t *Transaction) WriteFile(fpath string, b []byte, dirMode, fileMode os.FileMode, uid, gid int) error {
+	if err := t.record(fpath, false); err != nil {
+		return err
+	}
+	return WriteFileAtomically(fpath, b, dirMode, fileMode, uid, gid)
+}
+
+// WriteFileWithDefaults is WriteFile using the default permissions and ownership.
+func (t *Transaction) WriteFileWithDefaults(fpath string, b []byte) error {
+	return t.WriteFile(fpath, b, DefaultDirectoryPermissions, DefaultFilePermissions, -1, -1)
+}
+
+// Symlink atomically replaces fpath with a symlink to target as part of the transaction.
+func (t *Transaction) Symlink(target, fpath string) error {
+	if err := t.record(fpath, false); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fpath), DefaultDirectoryPermissions); err != nil {
+		return err
+	}
+	if err := renameio.Symlink(target, fpath); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(fpath))
+}
+
+// Remove deletes fpath, a file, symlink or empty directory, as part of the
+// transaction.  Removing a path that does not exist is not an error.
+func (t *Transaction) Remove(fpath string) error {
+	if err := t.record(fpath, true); err != nil {
+		return err
+	}
+	if err := os.Remove(fpath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return syncDir(filepath.Dir(fpath))
+}
+
+// RecordUnits saves the enablement of the systemd units into the journal, so
+// that rolling back enables or disables them again.  It must be called before
+// enabling, disabling or presetting them.
+func (t *Transaction) RecordUnits(units ...string) error {
+	if t.done {
+		return fmt.Errorf("transaction already finished")
+	}
+	for _, unit := range units {
+		key := "unit:" + unit
+		if t.recorded[key] {
+			continue
+		}
+		// is-enabled exits non-zero for disabled units, the state is in the output
+		out, err := systemctl("is-enabled", unit)
+		state := strings.TrimSpace(string(out))
+		if err != nil && (state == "not-found" || strings.Contains(state, "No such file or directory")) {
+			state = ""
+		} else if err != nil && state == "" {
+			return fmt.Errorf("failed to get the state of unit %q: %w", unit, err)
+		}
+		if err := t.appendEntry(journalEntry{Unit: unit, UnitState: state, UID: -1, GID: -1}); err != nil {
+			return err
+		}
+		t.recorded[key] = true
+	}
+	return nil
+}
+
+// Commit makes all changes of the transaction permanent by discarding its journal.
+func (t *Transaction) Commit() error {
+	if t.done {
+		return fmt.Errorf("transaction already finished")
+	}
+	t.done = true
+	return discardJournal(t.journalDir)
+}
+
+// Rollback restores every path touched by the transaction to its original state.
+// If restoring fails the journal is kept so that Recover can retry later.
+func (t *Transaction) Rollback() error {
+	if t.done {
+		return fmt.Errorf("transaction already finished")
+	}
+	t.done = true
+	return rollback(t.journalDir, t.journal)
+}
+
+// Recover rolls back an unfinished transaction left behind in journalDir, e.g.
+// because the process was killed mid-apply.  It returns true if a transaction
+// was found.
+func Recover(journalDir string) (bool, error) {
+	data, err := ioutil.ReadFile(filepath.Join(journalDir, journalFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	var j journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return true, fmt.Errorf("failed to parse journal in %q: %w", journalDir, err)
+	}
+	glog.Infof("Rolling back unfinished file transaction touching %d paths", len(j.Entries))
+	return true, rollback(journalDir, j)
+}
+
+// record saves the current state of fpath into the journal, unless the
+// transaction already did so.  The journal is durable on disk before this
+// returns, so it's safe to modify fpath afterwards.
+func (t *Transaction) record(fpath string, allowDir bool) error {
+	if t.done {
+		return fmt.Errorf("transaction already finished")
+	}
+	if t.recorded[fpath] {
+		return nil
+	}
+	entry := journalEntry{Path: fpath, UID: -1, GID: -1}
+	fi, err := os.Lstat(fpath)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return err
+	case fi.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(fpath)
+		if err != nil {
+			return err
+		}
+		entry.Existed = true
+		entry.Symlink = target
+	case fi.Mode().IsRegular():
+		data, err := ioutil.ReadFile(fpath)
+		if err != nil {
+			return err
+		}
+		entry.Existed = true
+		entry.Backup = strconv.Itoa(len(t.journal.Entries))
+		entry.Mode = fi.Mode() & journalModeBits
+		if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+			entry.UID = int(st.Uid)
+			entry.GID = int(st.Gid)
+		}
+		if err := WriteFileAtomically(t.backupPath(entry.Backup), data, journalDirMode, journalFileMode, -1, -1); err != nil {
+			return fmt.Errorf("failed to back up %q: %w", fpath, err)
+		}
+	case fi.IsDir() && allowDir:
+		// only empty directories can be removed, so there is no content to back up
+		entry.Existed = true
+		entry.Dir = true
+		entry.Mode = fi.Mode() & journalModeBits
+		if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+			entry.UID = int(st.Uid)
+			entry.GID = int(st.Gid)
+		}
+	default:
+		return fmt.Errorf("refusing to replace %q: not a regular file or symlink", fpath)
+	}
+
+	if err := t.appendEntry(entry); err != nil {
+		return err
+	}
+	t.recorded[fpath] = true
+	return nil
+}
+
+// appendEntry adds entry to the journal and syncs it to disk.
+func (t *Transaction) appendEntry(entry journalEntry) error {
+	t.journal.Entries = append(t.journal.Entries, entry)
+	if err := t.flushJournal(); err != nil {
+		t.journal.Entries = t.journal.Entries[:len(t.journal.Entries)-1]
+		return err
+	}
+	return nil
+}
+
+func (t *Transaction) flushJournal() error {
+	data, err := json.Marshal(t.journal)
+	if err != nil {
+		return err
+	}
+	if err := WriteFileAtomically(filepath.Join(t.journalDir, journalFileName), data, journalDirMode, journalFileMode, -1, -1); err != nil {
+		return fmt.Errorf("failed to write transaction journal: %w", err)
+	}
+	return nil
+}
+
+func (t *Transaction) backupPath(name string) string {
+	return filepath.Join(t.journalDir, backupsDirName, name)
+}
+
+// rollback restores the entries of j in reverse order and discards the journal.
+func rollback(journalDir string, j journal) error {
+	for i := len(j.Entries) - 1; i >= 0; i-- {
+		e := j.Entries[i]
+		if e.Unit != "" {
+			if err := restoreUnit(e); err != nil {
+				return fmt.Errorf("failed to restore the enablement of unit %q: %w", e.Unit, err)
+			}
+			continue
+		}
+		if err := restoreEntry(journalDir, e); err != nil {
+			return fmt.Errorf("failed to restore %q: %w", e.Path, err)
+		}
+	}
+	return discardJournal(journalDir)
+}
+
+func restoreEntry(journalDir string, e journalEntry) error {
+	switch {
+	case !e.Existed:
+		if err := os.Remove(e.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return syncDir(filepath.Dir(e.Path))
+	case e.Symlink != "":
+		if err := renameio.Symlink(e.Symlink, e.Path); err != nil {
+			return err
+		}
+		return syncDir(filepath.Dir(e.Path))
+	case e.Dir:
+		if err := os.Mkdir(e.Path, e.Mode); err != nil && !os.IsExist(err) {
+			return err
+		}
+		if e.UID != -1 && e.GID != -1 {
+			if err := os.Chown(e.Path, e.UID, e.GID); err != nil {
+				return err
+			}
+		}
+		// chmod after chown, which clears the setuid and setgid bits, and
+		// the umask applied by mkdir
+		if err := os.Chmod(e.Path, e.Mode); err != nil {
+			return err
+		}
+		return syncDir(filepath.Dir(e.Path))
+	default:
+		data, err := ioutil.ReadFile(filepath.Join(journalDir, backupsDirName, e.Backup))
+		if err != nil {
+			return err
+		}
+		return WriteFileAtomically(e.Path, data, DefaultDirectoryPermissions, e.Mode, e.UID, e.GID)
+	}
+}
+
+// restoreUnit enables or disables a unit again.  The entries of its files are
+// older, so they are restored after it, while its unit file is still in place.
+// Other states, e.g. static or masked units, are not changed by enabling or
+// disabling them.
+func restoreUnit(e journalEntry) error {
+	var verb string
+	switch e.UnitState {
+	case "enabled":
+		verb = "enable"
+	case "disabled", "":
+		verb = "disable"
+	default:
+		return nil
+	}
+	if out, err := systemctl(verb, e.Unit); err != nil {
+		if e.UnitState == "" {
+			// the unit was new, it may not have been written yet
+			glog.Infof("Could not disable new unit %s: %s", e.Unit, out)
+			return nil
+		}
+		return fmt.Errorf("%s: %w", out, err)
+	}
+	return nil
+}
+
+// discardJournal removes the journal file first, which is the point at which
+// the transaction is considered finished, and then cleans up the backups.
+func discardJournal(journalDir string) error {
+	if err := os.Remove(filepath.Join(journalDir, journalFileName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := syncDir(journalDir); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(journalDir, backupsDirName))
+}
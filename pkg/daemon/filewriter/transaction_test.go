@@ -0,0 +1,213 @@
+package filewriter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTree creates a scratch root with a pre-existing file and symlink,
+// and returns the root along with the journal directory to use.
+func setupTree(t testing.TB) (string, string) {
+	root, err := ioutil.TempDir("", "filewriter")
+	require.Nil(t, err)
+	require.Nil(t, ioutil.WriteFile(filepath.Join(root, "existing"), []byte("original"), 0640))
+	require.Nil(t, os.Symlink("existing", filepath.Join(root, "link")))
+	return root, filepath.Join(root, "journal")
+}
+
+func assertOriginalTree(t testing.TB, root string) {
+	data, err := ioutil.ReadFile(filepath.Join(root, "existing"))
+	require.Nil(t, err)
+	assert.Equal(t, "original", string(data))
+	fi, err := os.Stat(filepath.Join(root, "existing"))
+	require.Nil(t, err)
+	assert.Equal(t, os.FileMode(0640), fi.Mode().Perm())
+
+	target, err := os.Readlink(filepath.Join(root, "link"))
+	require.Nil(t, err)
+	assert.Equal(t, "existing", target)
+
+	_, err = os.Lstat(filepath.Join(root, "new"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(root, "journal", journalFileName))
+	assert.True(t, os.IsNotExist(err))
+}
+
+// applyChanges touches every kind of path the daemon writes.
+func applyChanges(t testing.TB, tx *Transaction, root string, contents []byte) {
+	require.Nil(t, tx.WriteFile(filepath.Join(root, "existing"), contents, DefaultDirectoryPermissions, 0600, -1, -1))
+	require.Nil(t, tx.WriteFileWithDefaults(filepath.Join(root, "new"), contents))
+	// Touching a path twice must keep the first backup.
+	require.Nil(t, tx.WriteFileWithDefaults(filepath.Join(root, "existing"), append(contents, 'x')))
+	require.Nil(t, tx.Symlink("/dev/null", filepath.Join(root, "link")))
+	require.Nil(t, tx.Remove(filepath.Join(root, "missing")))
+}
+
+func TestTransactionCommit(t *testing.T) {
+	root, journalDir := setupTree(t)
+	defer os.RemoveAll(root)
+
+	tx, err := Begin(journalDir)
+	require.Nil(t, err)
+	applyChanges(t, tx, root, []byte("new"))
+	require.Nil(t, tx.Remove(filepath.Join(root, "link")))
+	require.Nil(t, tx.Commit())
+
+	data, err := ioutil.ReadFile(filepath.Join(root, "existing"))
+	require.Nil(t, err)
+	assert.Equal(t, "newx", string(data))
+	_, err = os.Lstat(filepath.Join(root, "link"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(journalDir, journalFileName))
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NotNil(t, tx.Rollback())
+	found, err := Recover(journalDir)
+	assert.Nil(t, err)
+	assert.False(t, found)
+}
+
+func TestTransactionRollback(t *testing.T) {
+	root, journalDir := setupTree(t)
+	defer os.RemoveAll(root)
+
+	tx, err := Begin(journalDir)
+	require.Nil(t, err)
+	applyChanges(t, tx, root, []byte("new"))
+	require.Nil(t, tx.Rollback())
+
+	assertOriginalTree(t, root)
+	assert.NotNil(t, tx.WriteFileWithDefaults(filepath.Join(root, "new"), nil))
+}
+
+func TestTransactionRecover(t *testing.T) {
+	root, journalDir := setupTree(t)
+	defer os.RemoveAll(root)
+
+	// Simulate the process dying mid-apply: the transaction is never finished.
+	tx, err := Begin(journalDir)
+	require.Nil(t, err)
+	applyChanges(t, tx, root, []byte("new"))
+
+	_, err = Begin(journalDir)
+	assert.NotNil(t, err)
+
+	found, err := Recover(journalDir)
+	require.Nil(t, err)
+	assert.True(t, found)
+	assertOriginalTree(t, root)
+
+	tx, err = Begin(journalDir)
+	require.Nil(t, err)
+	require.Nil(t, tx.Commit())
+}
+
+func TestTransactionRefusesDirectories(t *testing.T) {
+	root, journalDir := setupTree(t)
+	defer os.RemoveAll(root)
+
+	tx, err := Begin(journalDir)
+	require.Nil(t, err)
+	assert.NotNil(t, tx.WriteFileWithDefaults(root, []byte("x")))
+	require.Nil(t, tx.Rollback())
+}
+
+func TestTransactionRestoresSpecialBits(t *testing.T) {
+	root, journalDir := setupTree(t)
+	defer os.RemoveAll(root)
+	path := filepath.Join(root, "existing")
+	require.Nil(t, os.Chmod(path, 0750|os.ModeSetuid|os.ModeSetgid))
+
+	tx, err := Begin(journalDir)
+	require.Nil(t, err)
+	require.Nil(t, tx.WriteFileWithDefaults(path, []byte("new")))
+	require.Nil(t, tx.Rollback())
+
+	fi, err := os.Stat(path)
+	require.Nil(t, err)
+	assert.Equal(t, 0750|os.ModeSetuid|os.ModeSetgid, fi.Mode()&journalModeBits)
+}
+
+func TestTransactionRemoveDirectory(t *testing.T) {
+	root, journalDir := setupTree(t)
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "unit.d")
+	require.Nil(t, os.Mkdir(dir, 0750))
+	require.Nil(t, os.Chmod(dir, 0750|os.ModeSticky))
+
+	tx, err := Begin(journalDir)
+	require.Nil(t, err)
+	require.Nil(t, tx.Remove(dir))
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+	require.Nil(t, tx.Rollback())
+
+	fi, err := os.Stat(dir)
+	require.Nil(t, err)
+	assert.True(t, fi.IsDir())
+	assert.Equal(t, 0750|os.ModeSticky, fi.Mode()&journalModeBits)
+}
+
+func TestTransactionRollsBackUnits(t *testing.T) {
+	root, journalDir := setupTree(t)
+	defer os.RemoveAll(root)
+
+	states := map[string]string{"enabled.service": "enabled", "disabled.service": "disabled", "static.service": "static"}
+	var calls []string
+	defer func(orig func(...string) ([]byte, error)) { systemctl = orig }(systemctl)
+	systemctl = func(args ...string) ([]byte, error) {
+		if args[0] == "is-enabled" {
+			state, ok := states[args[1]]
+			if !ok {
+				return []byte("Failed to get unit file state for " + args[1] + ": No such file or directory\n"), fmt.Errorf("exit status 1")
+			}
+			if state != "enabled" {
+				return []byte(state + "\n"), fmt.Errorf("exit status 1")
+			}
+			return []byte(state + "\n"), nil
+		}
+		calls = append(calls, strings.Join(args, " "))
+		return nil, nil
+	}
+
+	tx, err := Begin(journalDir)
+	require.Nil(t, err)
+	require.Nil(t, tx.RecordUnits("enabled.service", "disabled.service", "static.service", "new.service"))
+	// recording a unit twice keeps its first state
+	states["enabled.service"] = "disabled"
+	require.Nil(t, tx.RecordUnits("enabled.service"))
+	require.Nil(t, tx.Rollback())
+
+	assert.Equal(t, []string{"disable new.service", "disable disabled.service", "enable enabled.service"}, calls)
+}
+
+func FuzzTransactionRecover(f *testing.F) {
+	f.Add([]byte("new"), 0)
+	f.Add([]byte{}, 3)
+	f.Add([]byte("original"), 5)
+	f.Fuzz(func(t *testing.T, contents []byte, steps int) {
+		root, journalDir := setupTree(t)
+		defer os.RemoveAll(root)
+
+		paths := []string{"existing", "new", "link", "existing", "missing"}
+		tx, err := Begin(journalDir)
+		require.Nil(t, err)
+		// Crash after an arbitrary number of operations.
+		for i := 0; i < len(paths) && i < steps; i++ {
+			require.Nil(t, tx.WriteFile(filepath.Join(root, paths[i]), contents, DefaultDirectoryPermissions, 0600, -1, -1))
+		}
+		found, err := Recover(journalDir)
+		require.Nil(t, err)
+		assert.True(t, found)
+		assertOriginalTree(t, root)
+		_, err = os.Lstat(filepath.Join(root, "missing"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
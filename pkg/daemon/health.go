@@ -0,0 +1,131 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// DefaultHealthBindAddress is the port for the health listener
+const DefaultHealthBindAddress = "127.0.0.1:8798"
+
+// HealthStatus is the machine-readable state of the daemon served by the
+// health listener, for external probes such as node-problem-detector.
+type HealthStatus struct {
+	// State is the state of the daemon, e.g. Done, Working or Degraded
+	State string `json:"state"`
+	// LastError is the error the daemon is Degraded or Unreconcilable on
+	LastError string `json:"lastError,omitempty"`
+	// PendingReboot is true once the daemon has initiated a reboot into PendingConfig
+	PendingReboot bool   `json:"pendingReboot"`
+	PendingConfig string `json:"pendingConfig,omitempty"`
+	// ConfigDrift is true if the on-disk state drifted from the current config
+	ConfigDrift      bool   `json:"configDrift"`
+	ConfigDriftError string `json:"configDriftError,omitempty"`
+	// LastTransitionTime is when State last changed
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+	// Prerequisites are the checks of the node the daemon ran on startup
+	Prerequisites PrerequisiteChecks `json:"prerequisites,omitempty"`
+}
+
+// Healthy returns false if the daemon is Degraded or Unreconcilable, the
+// on-disk state drifted, or the node doesn't meet the prerequisites.
+func (s HealthStatus) Healthy() bool {
+	return s.State != constants.MachineConfigDaemonStateDegraded &&
+		s.State != constants.MachineConfigDaemonStateUnreconcilable &&
+		!s.ConfigDrift && s.Prerequisites.Err() == nil
+}
+
+type healthRecorder struct {
+	lock   sync.Mutex
+	status HealthStatus
+}
+
+var health = &healthRecorder{}
+
+func (h *healthRecorder) setState(state, lastError string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.status.State != state {
+		h.status.LastTransitionTime = time.Now().UTC()
+	}
+	h.status.State = state
+	h.status.LastError = lastError
+	// Done means the node runs its config, and its on-disk state was (re)written
+	if state == constants.MachineConfigDaemonStateDone {
+		h.status.PendingReboot = false
+		h.status.PendingConfig = ""
+		h.status.ConfigDrift = false
+		h.status.ConfigDriftError = ""
+	}
+}
+
+func (h *healthRecorder) setPendingReboot(config string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.status.PendingReboot = true
+	h.status.PendingConfig = config
+}
+
+func (h *healthRecorder) setConfigDrift(err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.status.ConfigDrift = err != nil
+	h.status.ConfigDriftError = ""
+	if err != nil {
+		h.status.ConfigDriftError = err.Error()
+	}
+}
+
+func (h *healthRecorder) setPrerequisites(checks PrerequisiteChecks) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.status.Prerequisites = checks
+}
+
+func (h *healthRecorder) get() HealthStatus {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.status
+}
+
+// ServeHTTP writes the HealthStatus as JSON, with a 503 status code if the
+// daemon isn't healthy.
+func (h *healthRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	status := h.get()
+	w.Header().Set("Content-Type", "application/json")
+	if !status.Healthy() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		glog.Errorf("error writing health status: %v", err)
+	}
+}
+
+// StartHealthListener serves the HealthStatus of the daemon on /health via http
+func StartHealthListener(addr string, stopCh chan struct{}) {
+	if addr == "" {
+		addr = DefaultHealthBindAddress
+	}
+
+	glog.Infof("Starting health listener on %s", addr)
+	mux := http.NewServeMux()
+	mux.Handle("/health", health)
+	s := http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			glog.Errorf("health listener exited with error: %v", err)
+		}
+	}()
+	<-stopCh
+	if err := s.Shutdown(context.Background()); err != http.ErrServerClosed {
+		glog.Errorf("error stopping health listener: %v", err)
+	}
+}
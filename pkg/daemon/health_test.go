@@ -0,0 +1,60 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+func TestHealthStatus(t *testing.T) {
+	h := &healthRecorder{}
+	get := func() (int, HealthStatus) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		var status HealthStatus
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+		return rec.Code, status
+	}
+
+	h.setState(constants.MachineConfigDaemonStateWorking, "")
+	h.setPendingReboot("rendered-worker-2")
+	code, status := get()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, constants.MachineConfigDaemonStateWorking, status.State)
+	assert.True(t, status.PendingReboot)
+	assert.Equal(t, "rendered-worker-2", status.PendingConfig)
+	assert.False(t, status.LastTransitionTime.IsZero())
+
+	h.setState(constants.MachineConfigDaemonStateDone, "")
+	code, status = get()
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, status.PendingReboot)
+	assert.Empty(t, status.PendingConfig)
+
+	h.setConfigDrift(fmt.Errorf("content mismatch for file /etc/foo"))
+	h.setState(constants.MachineConfigDaemonStateDegraded, "content mismatch for file /etc/foo")
+	code, status = get()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.True(t, status.ConfigDrift)
+	assert.Equal(t, "content mismatch for file /etc/foo", status.ConfigDriftError)
+	assert.Equal(t, "content mismatch for file /etc/foo", status.LastError)
+
+	h.setState(constants.MachineConfigDaemonStateDone, "")
+	code, status = get()
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, status.ConfigDrift)
+	assert.Empty(t, status.LastError)
+
+	h.setPrerequisites(PrerequisiteChecks{{Name: "SELinux", Message: "SELinux is disabled"}})
+	code, status = get()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "SELinux", status.Prerequisites[0].Name)
+}
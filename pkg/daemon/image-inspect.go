@@ -61,7 +61,7 @@ func imageInspect(imageName string) (*types.ImageInspectInfo, error) {
 	)
 
 	ctx := context.Background()
-	sys := &types.SystemContext{AuthFilePath: kubeletAuthFile}
+	sys := &types.SystemContext{AuthFilePath: imageRuntime.AuthFile}
 
 	if err := retryIfNecessary(ctx, func() error {
 		src, err = newDockerImageSource(ctx, sys, imageName)
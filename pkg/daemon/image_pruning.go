@@ -0,0 +1,238 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// criImage is an image of `crictl images -o json`.
+type criImage struct {
+	ID          string   `json:"id"`
+	RepoTags    []string `json:"repoTags"`
+	RepoDigests []string `json:"repoDigests"`
+	// Size is a number of bytes, as a string.
+	Size string `json:"size"`
+	// Pinned is set on the images CRI-O pins, e.g. the pause image.
+	Pinned bool `json:"pinned"`
+}
+
+// imagePruning returns the ImagePruning the node controller copied from the
+// pool of the node, or nil if there is none.
+func imagePruning(node *corev1.Node) (*mcfgv1.ImagePruning, error) {
+	if node == nil || node.Annotations[constants.ImagePruningAnnotationKey] == "" {
+		return nil, nil
+	}
+	policy := &mcfgv1.ImagePruning{}
+	if err := json.Unmarshal([]byte(node.Annotations[constants.ImagePruningAnnotationKey]), policy); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", constants.ImagePruningAnnotationKey, err)
+	}
+	return policy, nil
+}
+
+// imageRepository returns the repository of the image reference ref, without
+// its tag or digest.
+func imageRepository(ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+	// a colon after the last slash starts the tag, before it's the port of the registry
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref = ref[:i]
+	}
+	return ref
+}
+
+// imageMatches returns true if the tags or digests of img match the
+// reference ref, or if ref has no tag or digest, their repository does.
+func imageMatches(img criImage, ref string) bool {
+	anyTag := imageRepository(ref) == ref
+	for _, name := range append(append([]string{}, img.RepoTags...), img.RepoDigests...) {
+		if name == ref || (anyTag && imageRepository(name) == ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// imageUsedBy returns true if the image reference ref, an image or the
+// image ID of a container, is img.
+func imageUsedBy(img criImage, ref string) bool {
+	if ref == "" {
+		return false
+	}
+	if strings.TrimPrefix(ref, "sha256:") == strings.TrimPrefix(img.ID, "sha256:") {
+		return true
+	}
+	for _, name := range append(append([]string{}, img.RepoTags...), img.RepoDigests...) {
+		if name == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// podImages returns the images of the containers of the pods, both those of
+// their spec, which the kubelet may not have started yet, and the image IDs
+// of their statuses.
+func podImages(pods []corev1.Pod) []string {
+	var refs []string
+	for _, pod := range pods {
+		for _, c := range pod.Spec.InitContainers {
+			refs = append(refs, c.Image)
+		}
+		for _, c := range pod.Spec.Containers {
+			refs = append(refs, c.Image)
+		}
+		for _, c := range pod.Spec.EphemeralContainers {
+			refs = append(refs, c.Image)
+		}
+		for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses, pod.Status.EphemeralContainerStatuses} {
+			for _, status := range statuses {
+				refs = append(refs, status.Image, status.ImageID)
+			}
+		}
+	}
+	return refs
+}
+
+// releaseImages returns the images of the release the cluster runs, which
+// the node needs regardless of the pods currently bound to it.
+func releaseImages(cc *mcfgv1.ControllerConfig) []string {
+	refs := []string{cc.Spec.ReleaseImage, cc.Spec.OSImageURL}
+	for _, ref := range cc.Spec.Images {
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// unusedImages returns the images which aren't pinned by CRI-O or by the
+// policy, nor referenced by any of refs.
+func unusedImages(images []criImage, refs []string, policy *mcfgv1.ImagePruning) []criImage {
+	var unused []criImage
+	for _, img := range images {
+		keep := img.Pinned
+		for _, ref := range policy.PinnedImages {
+			keep = keep || imageMatches(img, ref)
+		}
+		for _, ref := range refs {
+			keep = keep || imageUsedBy(img, ref)
+		}
+		if !keep {
+			unused = append(unused, img)
+		}
+	}
+	return unused
+}
+
+// listCRIImages returns the images of CRI-O.
+func listCRIImages() ([]criImage, error) {
+	out, err := runGetOut("crictl", "images", "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("listing images: %w", err)
+	}
+	var images struct {
+		Images []criImage `json:"images"`
+	}
+	if err := json.Unmarshal(out, &images); err != nil {
+		return nil, fmt.Errorf("parsing images: %w", err)
+	}
+	return images.Images, nil
+}
+
+// usedImages returns the images the node needs: those of the pods bound to
+// it and those of the release.  The containers CRI-O runs can't tell, as
+// right after the reboot of an update the kubelet didn't start the pods of
+// the node yet.
+func (dn *Daemon) usedImages(node *corev1.Node) ([]string, error) {
+	pods, err := dn.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + node.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+	cc, err := dn.mcfgClient.MachineconfigurationV1().ControllerConfigs().Get(context.TODO(), ctrlcommon.ControllerConfigName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting release images: %w", err)
+	}
+	return append(podImages(pods.Items), releaseImages(cc)...), nil
+}
+
+// startImagePruning prunes the unused images of the node in the background,
+// unless a previous pruning is still running.
+func (dn *Daemon) startImagePruning() {
+	dn.imagePruningLock.Lock()
+	defer dn.imagePruningLock.Unlock()
+	if dn.imagePruningActive || dn.node == nil || dn.kubeClient == nil || dn.mcfgClient == nil {
+		return
+	}
+	dn.imagePruningActive = true
+	node := dn.node.DeepCopy()
+	go func() {
+		dn.pruneImages(node)
+		dn.imagePruningLock.Lock()
+		dn.imagePruningActive = false
+		dn.imagePruningLock.Unlock()
+	}()
+}
+
+// pruneImages removes the unused container images from the node, if the pool
+// of the node asks for it, once it completed an update.  Pruning is best
+// effort: failures are reported as events but don't fail the update, which is
+// already done.  Nothing is removed if the images in use can't be listed.
+func (dn *Daemon) pruneImages(node *corev1.Node) {
+	policy, err := imagePruning(node)
+	if err != nil {
+		glog.Warningf("Not pruning images: %v", err)
+		return
+	}
+	if policy == nil {
+		return
+	}
+	images, err := listCRIImages()
+	if err == nil {
+		var used []string
+		used, err = dn.usedImages(node)
+		images = unusedImages(images, used, policy)
+	}
+	if err != nil {
+		dn.recorder.Eventf(getNodeRef(node), corev1.EventTypeWarning, "ImagePruningFailed", "Failed to prune unused images: %v", err)
+		return
+	}
+
+	var removed int
+	var freed int64
+	var failed []string
+	for _, img := range images {
+		// A container may have started with the image since the pods were
+		// listed, crictl then refuses to remove it.
+		if err := runCmdSync("crictl", "rmi", img.ID); err != nil {
+			failed = append(failed, img.ID)
+			continue
+		}
+		removed++
+		if size, err := strconv.ParseInt(img.Size, 10, 64); err == nil {
+			freed += size
+		}
+	}
+	freedQuantity := resource.NewQuantity(freed, resource.BinarySI)
+	dn.logSystem("Removed %d unused images, freeing %s", removed, freedQuantity)
+	if len(failed) > 0 {
+		dn.recorder.Eventf(getNodeRef(node), corev1.EventTypeWarning, "ImagePruningFailed", "Removed %d unused images, failed to remove %d: %s", removed, len(failed), strings.Join(failed, ", "))
+		return
+	}
+	if removed > 0 {
+		dn.recorder.Eventf(getNodeRef(node), corev1.EventTypeNormal, "ImagesPruned", "Removed %d unused images, freeing %s", removed, freedQuantity)
+	}
+}
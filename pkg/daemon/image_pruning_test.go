@@ -0,0 +1,87 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+func TestImagePruning(t *testing.T) {
+	policy, err := imagePruning(&corev1.Node{})
+	require.NoError(t, err)
+	assert.Nil(t, policy)
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		constants.ImagePruningAnnotationKey: `{"pinnedImages":["quay.io/edge/agent"]}`,
+	}}}
+	policy, err = imagePruning(node)
+	require.NoError(t, err)
+	assert.Equal(t, &mcfgv1.ImagePruning{PinnedImages: []string{"quay.io/edge/agent"}}, policy)
+
+	node.Annotations[constants.ImagePruningAnnotationKey] = "{"
+	_, err = imagePruning(node)
+	assert.Error(t, err)
+}
+
+func TestImageRepository(t *testing.T) {
+	for ref, want := range map[string]string{
+		"quay.io/edge/agent":                    "quay.io/edge/agent",
+		"quay.io/edge/agent:v1":                 "quay.io/edge/agent",
+		"quay.io/edge/agent@sha256:0123":        "quay.io/edge/agent",
+		"registry:5000/edge/agent":              "registry:5000/edge/agent",
+		"registry:5000/edge/agent:v1":           "registry:5000/edge/agent",
+		"registry:5000/edge/agent:v1@sha256:01": "registry:5000/edge/agent",
+	} {
+		assert.Equal(t, want, imageRepository(ref), ref)
+	}
+}
+
+func TestImageMatches(t *testing.T) {
+	img := criImage{
+		ID:          "sha256:abc",
+		RepoTags:    []string{"quay.io/edge/agent:v1"},
+		RepoDigests: []string{"quay.io/edge/agent@sha256:0123"},
+	}
+	assert.True(t, imageMatches(img, "quay.io/edge/agent"))
+	assert.True(t, imageMatches(img, "quay.io/edge/agent:v1"))
+	assert.True(t, imageMatches(img, "quay.io/edge/agent@sha256:0123"))
+	assert.False(t, imageMatches(img, "quay.io/edge/agent:v2"))
+	assert.False(t, imageMatches(img, "quay.io/edge/agen"))
+	assert.False(t, imageMatches(img, "quay.io/edge"))
+}
+
+func TestUnusedImages(t *testing.T) {
+	images := []criImage{
+		{ID: "pause", RepoTags: []string{"quay.io/openshift/pause:latest"}, Pinned: true},
+		{ID: "agent", RepoTags: []string{"quay.io/edge/agent:v1"}},
+		{ID: "sha256:running", RepoTags: []string{"quay.io/app/web:v2"}},
+		{ID: "pending", RepoDigests: []string{"quay.io/app/job@sha256:0123"}},
+		{ID: "release", RepoDigests: []string{"quay.io/openshift/release@sha256:4567"}},
+		{ID: "old", RepoTags: []string{"quay.io/app/web:v1"}},
+	}
+	pods := []corev1.Pod{{
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Image: "quay.io/app/job@sha256:0123"}},
+			Containers:     []corev1.Container{{Image: "quay.io/app/web:latest"}},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{ImageID: "sha256:running"}},
+		},
+	}}
+	cc := &mcfgv1.ControllerConfig{Spec: mcfgv1.ControllerConfigSpec{
+		Images: map[string]string{"machineConfigOperator": "quay.io/openshift/release@sha256:4567"},
+	}}
+	refs := append(podImages(pods), releaseImages(cc)...)
+
+	unused := unusedImages(images, refs, &mcfgv1.ImagePruning{PinnedImages: []string{"quay.io/edge/agent"}})
+	assert.Equal(t, []criImage{images[5]}, unused)
+
+	unused = unusedImages(images, nil, &mcfgv1.ImagePruning{})
+	assert.Equal(t, images[1:], unused)
+}
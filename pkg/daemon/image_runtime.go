@@ -0,0 +1,69 @@
+package daemon
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// ImageRuntimeOptions controls which registry credentials and which podman
+// service the daemon uses for its own image operations, i.e. pulling and
+// extracting the OS image.  This is useful e.g. when a pull-through cache is
+// reachable through a podman service listening on a nonstandard socket.
+type ImageRuntimeOptions struct {
+	// AuthFile is the registry auth file used when pulling images.
+	AuthFile string
+	// PodmanURL, if set, makes the daemon run podman as a remote client of the
+	// podman service at this URL (e.g. unix:///run/podman/cache.sock) instead
+	// of using the local container storage.
+	PodmanURL string
+}
+
+// DefaultImageRuntimeOptions returns the options used unless overridden on the command line.
+func DefaultImageRuntimeOptions() ImageRuntimeOptions {
+	return ImageRuntimeOptions{
+		AuthFile: kubeletAuthFile,
+	}
+}
+
+// imageRuntime holds the options used by the image operations.
+var imageRuntime = DefaultImageRuntimeOptions()
+
+// SetImageRuntimeOptions validates and sets the options used for all
+// subsequent image operations of the daemon.
+func SetImageRuntimeOptions(opts ImageRuntimeOptions) error {
+	if opts.AuthFile == "" {
+		return fmt.Errorf("image pull auth file must not be empty")
+	}
+	if opts.PodmanURL != "" {
+		u, err := url.Parse(opts.PodmanURL)
+		if err != nil {
+			return fmt.Errorf("invalid podman URL %q: %w", opts.PodmanURL, err)
+		}
+		switch u.Scheme {
+		case "unix", "tcp", "ssh":
+		default:
+			return fmt.Errorf("invalid podman URL %q: scheme must be one of unix, tcp or ssh", opts.PodmanURL)
+		}
+	}
+	imageRuntime = opts
+	return nil
+}
+
+// authFile returns the registry auth file to use, or an empty string if it
+// doesn't exist and pulls should be anonymous.
+func (o ImageRuntimeOptions) authFile() string {
+	if _, err := os.Stat(o.AuthFile); err != nil {
+		return ""
+	}
+	return o.AuthFile
+}
+
+// podmanArgs returns the arguments for a podman invocation, pointing podman
+// to the configured service if any.
+func (o ImageRuntimeOptions) podmanArgs(args ...string) []string {
+	if o.PodmanURL == "" {
+		return args
+	}
+	return append([]string{"--remote", "--url", o.PodmanURL}, args...)
+}
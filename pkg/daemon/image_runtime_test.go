@@ -0,0 +1,43 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetImageRuntimeOptions(t *testing.T) {
+	defer func() { imageRuntime = DefaultImageRuntimeOptions() }()
+
+	tests := []struct {
+		name    string
+		opts    ImageRuntimeOptions
+		wantErr bool
+	}{
+		{name: "default", opts: DefaultImageRuntimeOptions()},
+		{name: "unix socket", opts: ImageRuntimeOptions{AuthFile: "/etc/cache/auth.json", PodmanURL: "unix:///run/podman/cache.sock"}},
+		{name: "empty auth file", opts: ImageRuntimeOptions{}, wantErr: true},
+		{name: "bad scheme", opts: ImageRuntimeOptions{AuthFile: kubeletAuthFile, PodmanURL: "http://localhost:8080"}, wantErr: true},
+		{name: "plain path", opts: ImageRuntimeOptions{AuthFile: kubeletAuthFile, PodmanURL: "/run/podman/podman.sock"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			imageRuntime = DefaultImageRuntimeOptions()
+			err := SetImageRuntimeOptions(test.opts)
+			if test.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, DefaultImageRuntimeOptions(), imageRuntime)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.opts, imageRuntime)
+		})
+	}
+}
+
+func TestPodmanArgs(t *testing.T) {
+	assert.Equal(t, []string{"pull", "-q", "foo"}, DefaultImageRuntimeOptions().podmanArgs("pull", "-q", "foo"))
+
+	opts := ImageRuntimeOptions{AuthFile: kubeletAuthFile, PodmanURL: "unix:///run/podman/cache.sock"}
+	assert.Equal(t, []string{"--remote", "--url", "unix:///run/podman/cache.sock", "pull", "-q", "foo"}, opts.podmanArgs("pull", "-q", "foo"))
+}
@@ -0,0 +1,176 @@
+package daemon
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/ghodss/yaml"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const kubeletUnitName = "kubelet.service"
+
+// kubeletSupport is the range of kubelet minor versions supporting a flag or
+// a feature gate.
+type kubeletSupport struct {
+	// added is the first minor version supporting it, 0 if all do
+	added int
+	// removed is the first minor version no longer supporting it, 0 if none
+	removed int
+}
+
+// kubeletFlagSupport lists the kubelet flags which only some of the kubelet
+// versions a node can run with a config accept.  The kubelet fails to start
+// with a flag it doesn't know.
+var kubeletFlagSupport = map[string]kubeletSupport{
+	"--cni-bin-dir":                       {removed: 24},
+	"--cni-cache-dir":                     {removed: 24},
+	"--cni-conf-dir":                      {removed: 24},
+	"--container-runtime":                 {removed: 27},
+	"--docker-endpoint":                   {removed: 24},
+	"--image-credential-provider-bin-dir": {added: 20},
+	"--image-credential-provider-config":  {added: 20},
+	"--image-pull-progress-deadline":      {removed: 24},
+	"--network-plugin":                    {removed: 24},
+}
+
+// kubeletFeatureGateSupport lists the feature gates which only some of the
+// kubelet versions know.  The kubelet fails to start with an unrecognized
+// feature gate in its config.
+var kubeletFeatureGateSupport = map[string]kubeletSupport{
+	"DynamicKubeletConfig": {removed: 26},
+}
+
+// kubeletMinorVersion returns the minor version of a kubelet version, e.g.
+// 24 for v1.24.0+9546431.
+func kubeletMinorVersion(version string) (int, error) {
+	tokens := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(tokens) < 2 {
+		return 0, fmt.Errorf("incorrect version syntax: %q", version)
+	}
+	minor, err := strconv.Atoi(tokens[1])
+	if err != nil {
+		return 0, fmt.Errorf("incorrect version syntax: %q", version)
+	}
+	return minor, nil
+}
+
+// kubeletFlags returns the flags of the ExecStart command lines of the
+// kubelet unit of the config and its dropins.
+func kubeletFlags(ignCfg *ign3types.Config) []string {
+	var flags []string
+	for _, u := range ignCfg.Systemd.Units {
+		if u.Name != kubeletUnitName {
+			continue
+		}
+		contents := []*string{u.Contents}
+		for _, d := range u.Dropins {
+			contents = append(contents, d.Contents)
+		}
+		for _, c := range contents {
+			if c == nil {
+				continue
+			}
+			for _, line := range strings.Split(strings.ReplaceAll(*c, "\\\n", " "), "\n") {
+				line = strings.TrimSpace(line)
+				if !strings.HasPrefix(line, "ExecStart=") {
+					continue
+				}
+				for _, field := range strings.Fields(line) {
+					if strings.HasPrefix(field, "--") {
+						flags = append(flags, strings.SplitN(field, "=", 2)[0])
+					}
+				}
+			}
+		}
+	}
+	return flags
+}
+
+// kubeletFeatureGates returns the feature gates set in the kubelet config
+// file of the config.
+func kubeletFeatureGates(ignCfg *ign3types.Config) ([]string, error) {
+	data, err := ctrlcommon.GetIgnitionFileDataByPath(ignCfg, kubeletConfPath)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var kubeletConfig struct {
+		FeatureGates map[string]bool `json:"featureGates"`
+	}
+	if err := yaml.Unmarshal(data, &kubeletConfig); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", kubeletConfPath, err)
+	}
+	var gates []string
+	for gate := range kubeletConfig.FeatureGates {
+		gates = append(gates, gate)
+	}
+	return gates, nil
+}
+
+// unsupported describes why the kubelet minor version doesn't support the
+// flag or feature gate, or returns "" if it does.
+func (s kubeletSupport) unsupported(kind, name string, minor int) string {
+	if s.added != 0 && minor < s.added {
+		return fmt.Sprintf("%s %s was added in kubelet 1.%d", kind, name, s.added)
+	}
+	if s.removed != 0 && minor >= s.removed {
+		return fmt.Sprintf("%s %s was removed in kubelet 1.%d", kind, name, s.removed)
+	}
+	return ""
+}
+
+// kubeletIncompatibilities returns the kubelet flags and feature gates of
+// the config the kubelet minor version doesn't support.
+func kubeletIncompatibilities(ignCfg *ign3types.Config, minor int) ([]string, error) {
+	var problems []string
+	for _, flag := range kubeletFlags(ignCfg) {
+		if problem := kubeletFlagSupport[flag].unsupported("flag", flag, minor); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	gates, err := kubeletFeatureGates(ignCfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, gate := range gates {
+		if problem := kubeletFeatureGateSupport[gate].unsupported("feature gate", gate, minor); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
+
+// checkKubeletCompatibility returns a KubeletIncompatibleError if the new
+// config uses kubelet flags or feature gates the kubelet version doesn't
+// support, e.g. when a pool is rolled back to an older config while the node
+// keeps its newer OS.  Incompatibilities already in the old config are left
+// out, so that a config which doesn't touch the kubelet isn't refused.
+func checkKubeletCompatibility(oldIgnConfig, newIgnConfig *ign3types.Config, newConfigName, kubeletVersion string) error {
+	minor, err := kubeletMinorVersion(kubeletVersion)
+	if err != nil {
+		return err
+	}
+	oldProblems, err := kubeletIncompatibilities(oldIgnConfig, minor)
+	if err != nil {
+		return err
+	}
+	newProblems, err := kubeletIncompatibilities(newIgnConfig, minor)
+	if err != nil {
+		return err
+	}
+	var problems []string
+	for _, problem := range newProblems {
+		if !ctrlcommon.InSlice(problem, oldProblems) {
+			problems = append(problems, problem)
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ctrlcommon.KubeletIncompatibleError{Err: fmt.Errorf("config %s is incompatible with kubelet %s of the node: %s", newConfigName, kubeletVersion, strings.Join(problems, ", "))}
+}
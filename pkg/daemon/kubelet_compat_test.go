@@ -0,0 +1,61 @@
+package daemon
+
+import (
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func newKubeletIgnConfig(flags, kubeletConf string) *ign3types.Config {
+	unit := "[Service]\nExecStartPre=/bin/mkdir --parents /etc/kubernetes/manifests\nExecStart=/usr/bin/hyperkube \\\n    kubelet \\\n      --config=/etc/kubernetes/kubelet.conf \\\n      " + flags + "\n"
+	ignCfg := ctrlcommon.NewIgnConfig()
+	ignCfg.Systemd.Units = []ign3types.Unit{{Name: kubeletUnitName, Contents: &unit}}
+	if kubeletConf != "" {
+		ignCfg.Storage.Files = []ign3types.File{helpers.CreateIgn3File(kubeletConfPath, dataurl.EncodeBytes([]byte(kubeletConf)), 0644)}
+	}
+	return &ignCfg
+}
+
+func TestKubeletMinorVersion(t *testing.T) {
+	minor, err := kubeletMinorVersion("v1.24.0+9546431")
+	require.NoError(t, err)
+	assert.Equal(t, 24, minor)
+
+	_, err = kubeletMinorVersion("v1")
+	assert.Error(t, err)
+}
+
+func TestKubeletFlags(t *testing.T) {
+	ignCfg := newKubeletIgnConfig("--container-runtime=remote \\\n      --node-ip=${KUBELET_NODE_IP}", "")
+	dropin := "[Service]\nExecStart=\nExecStart=/usr/bin/hyperkube kubelet --network-plugin=cni\n"
+	ignCfg.Systemd.Units[0].Dropins = []ign3types.Dropin{{Name: "10-cni.conf", Contents: &dropin}}
+	assert.Equal(t, []string{"--config", "--container-runtime", "--node-ip", "--network-plugin"}, kubeletFlags(ignCfg))
+}
+
+func TestCheckKubeletCompatibility(t *testing.T) {
+	current := newKubeletIgnConfig("--node-ip=${KUBELET_NODE_IP}", "apiVersion: kubelet.config.k8s.io/v1beta1\nkind: KubeletConfiguration\n")
+	rolledBack := newKubeletIgnConfig("--container-runtime=remote --node-ip=${KUBELET_NODE_IP}", "apiVersion: kubelet.config.k8s.io/v1beta1\nkind: KubeletConfiguration\nfeatureGates:\n  DynamicKubeletConfig: false\n")
+
+	// the older config works with the kubelet it came with
+	require.NoError(t, checkKubeletCompatibility(current, rolledBack, "rendered-worker-old", "v1.25.2+5533733"))
+
+	// but not with a newer one
+	err := checkKubeletCompatibility(current, rolledBack, "rendered-worker-old", "v1.27.3+4aaeaec")
+	require.Error(t, err)
+	assert.Equal(t, ctrlcommon.ErrorReasonKubeletIncompatible, ctrlcommon.ErrorReason(err))
+	assert.Equal(t, "config rendered-worker-old is incompatible with kubelet v1.27.3+4aaeaec of the node: feature gate DynamicKubeletConfig was removed in kubelet 1.26, flag --container-runtime was removed in kubelet 1.27", err.Error())
+
+	// a newer config on an older kubelet
+	newer := newKubeletIgnConfig("--image-credential-provider-config=/etc/kubernetes/credential-providers.yaml", "")
+	err = checkKubeletCompatibility(current, newer, "rendered-worker-new", "v1.19.0")
+	assert.Contains(t, err.Error(), "flag --image-credential-provider-config was added in kubelet 1.20")
+
+	// incompatibilities the node already has don't block other changes
+	require.NoError(t, checkKubeletCompatibility(rolledBack, rolledBack, "rendered-worker-old", "v1.27.3+4aaeaec"))
+}
@@ -0,0 +1,114 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// osDeployment returns the OSDeployment of the rpm-ostree deployment d.
+func osDeployment(d *RpmOstreeDeployment, alias *osImageURLAlias) *mcfgv1.OSDeployment {
+	deployment := &mcfgv1.OSDeployment{
+		OSImageURL:      resolveOSImageURL(d, customOriginOSImageURL(d), alias),
+		Version:         d.Version,
+		Checksum:        d.Checksum,
+		LayeredPackages: d.Packages,
+		LocalPackages:   d.RequestedLocalPackages,
+	}
+	if d.BaseChecksum != d.Checksum {
+		deployment.BaseChecksum = d.BaseChecksum
+	}
+	return deployment
+}
+
+// machineConfigNodeStatus returns the status of the MachineConfigNode of a
+// node with the rpm-ostree deployments, leaving its lastUpdated unset.  A
+// staged deployment is only finalized, i.e. written to the bootloader, when
+// the node shuts down.
+func machineConfigNodeStatus(deployments []RpmOstreeDeployment, alias *osImageURLAlias) mcfgv1.MachineConfigNodeStatus {
+	status := mcfgv1.MachineConfigNodeStatus{}
+	for i := range deployments {
+		d := &deployments[i]
+		switch {
+		case d.Booted:
+			status.BootedDeployment = osDeployment(d, alias)
+		case d.Staged:
+			status.StagedDeployment = osDeployment(d, alias)
+			status.PendingFinalization = true
+		}
+	}
+	return status
+}
+
+// newMachineConfigNode returns the MachineConfigNode of node, owned by the
+// node so that it's garbage collected with it.
+func newMachineConfigNode(node *corev1.Node) *mcfgv1.MachineConfigNode {
+	return &mcfgv1.MachineConfigNode{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: node.Name,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "v1",
+				Kind:       "Node",
+				Name:       node.Name,
+				UID:        node.UID,
+			}},
+		},
+		Spec: mcfgv1.MachineConfigNodeSpec{Node: node.Name},
+	}
+}
+
+// syncMachineConfigNode publishes the rpm-ostree deployments of the node and
+// the retries of transient sync errors in the status of its
+// MachineConfigNode, creating it if needed.
+func (dn *Daemon) syncMachineConfigNode() error {
+	if dn.mcfgClient == nil || dn.node == nil {
+		return nil
+	}
+	deployments, err := dn.NodeUpdaterClient.GetDeployments()
+	if err != nil {
+		return fmt.Errorf("reading rpm-ostree status: %w", err)
+	}
+	alias, err := readOSImageURLAlias(constants.OSImageURLAliasPath)
+	if err != nil {
+		return err
+	}
+	status := machineConfigNodeStatus(deployments, alias)
+	status.ForeignCrioFragments = dn.foreignCrioFragments
+	status.TransientErrorRetries = int32(dn.transientErrorAttempts)
+	status.LastTransientError = dn.lastTransientError
+
+	client := dn.mcfgClient.MachineconfigurationV1().MachineConfigNodes()
+	mcn, err := client.Get(context.TODO(), dn.node.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		mcn, err = client.Create(context.TODO(), newMachineConfigNode(dn.node), metav1.CreateOptions{})
+	}
+	if err != nil {
+		return err
+	}
+	status.LastUpdated = mcn.Status.LastUpdated
+	if equality.Semantic.DeepEqual(mcn.Status, status) {
+		return nil
+	}
+	status.LastUpdated = metav1.Now()
+	mcn = mcn.DeepCopy()
+	mcn.Status = status
+	_, err = client.UpdateStatus(context.TODO(), mcn, metav1.UpdateOptions{})
+	return err
+}
+
+// updateMachineConfigNode publishes the OS state of the node like
+// syncMachineConfigNode.  It's only informational, failures don't fail the
+// sync.
+func (dn *Daemon) updateMachineConfigNode() {
+	if err := dn.syncMachineConfigNode(); err != nil {
+		glog.Warningf("Failed to update the MachineConfigNode of the node: %v", err)
+	}
+}
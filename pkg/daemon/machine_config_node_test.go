@@ -0,0 +1,131 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+)
+
+const testRpmOstreeStatus = `{
+  "deployments": [
+    {
+      "id": "rhcos-staged",
+      "osname": "rhcos",
+      "checksum": "layered-new",
+      "base-checksum": "new",
+      "version": "411.86.202210041459-0",
+      "booted": false,
+      "staged": true,
+      "custom-origin": ["pivot://quay.io/openshift/os@sha256:new", "Managed by machine-config-operator"],
+      "packages": ["usbguard"],
+      "requested-local-packages": ["my-agent-1.0-1.x86_64"]
+    },
+    {
+      "id": "rhcos-booted",
+      "osname": "rhcos",
+      "checksum": "old",
+      "version": "411.86.202209211811-0",
+      "booted": true,
+      "staged": false,
+      "custom-origin": ["pivot://quay.io/openshift/os@sha256:old", "Managed by machine-config-operator"]
+    },
+    {
+      "id": "rhcos-rollback",
+      "osname": "rhcos",
+      "checksum": "older",
+      "booted": false
+    }
+  ],
+  "transaction": null
+}`
+
+// deploymentsClientMock is a RpmOstreeClientMock listing deployments.
+type deploymentsClientMock struct {
+	RpmOstreeClientMock
+	deployments []RpmOstreeDeployment
+}
+
+func (r deploymentsClientMock) GetDeployments() ([]RpmOstreeDeployment, error) {
+	return r.deployments, nil
+}
+
+func testDeployments(t *testing.T) []RpmOstreeDeployment {
+	var state rpmOstreeState
+	require.NoError(t, json.Unmarshal([]byte(testRpmOstreeStatus), &state))
+	return state.Deployments
+}
+
+func TestMachineConfigNodeStatus(t *testing.T) {
+	status := machineConfigNodeStatus(testDeployments(t), nil)
+	assert.Equal(t, &mcfgv1.OSDeployment{
+		OSImageURL: "quay.io/openshift/os@sha256:old",
+		Version:    "411.86.202209211811-0",
+		Checksum:   "old",
+	}, status.BootedDeployment)
+	assert.Equal(t, &mcfgv1.OSDeployment{
+		OSImageURL:      "quay.io/openshift/os@sha256:new",
+		Version:         "411.86.202210041459-0",
+		Checksum:        "layered-new",
+		BaseChecksum:    "new",
+		LayeredPackages: []string{"usbguard"},
+		LocalPackages:   []string{"my-agent-1.0-1.x86_64"},
+	}, status.StagedDeployment)
+	assert.True(t, status.PendingFinalization)
+
+	status = machineConfigNodeStatus(testDeployments(t)[1:], nil)
+	assert.NotNil(t, status.BootedDeployment)
+	assert.Nil(t, status.StagedDeployment)
+	assert.False(t, status.PendingFinalization)
+}
+
+func TestMachineConfigNodeStatusAlias(t *testing.T) {
+	alias := &osImageURLAlias{Checksum: "old", Origin: "quay.io/openshift/os@sha256:old", OSImageURL: "quay.io/openshift/os@sha256:extensions"}
+	status := machineConfigNodeStatus(testDeployments(t), alias)
+	assert.Equal(t, "quay.io/openshift/os@sha256:extensions", status.BootedDeployment.OSImageURL)
+	assert.Equal(t, "quay.io/openshift/os@sha256:new", status.StagedDeployment.OSImageURL)
+}
+
+func TestSyncMachineConfigNode(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-0", UID: "uid"}}
+	updater := &deploymentsClientMock{deployments: testDeployments(t)}
+	dn := &Daemon{mcfgClient: client, node: node, NodeUpdaterClient: updater}
+
+	require.NoError(t, dn.syncMachineConfigNode())
+	mcn, err := client.MachineconfigurationV1().MachineConfigNodes().Get(context.TODO(), "worker-0", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "worker-0", mcn.Spec.Node)
+	require.Len(t, mcn.OwnerReferences, 1)
+	assert.Equal(t, "Node", mcn.OwnerReferences[0].Kind)
+	assert.Equal(t, node.UID, mcn.OwnerReferences[0].UID)
+	assert.True(t, mcn.Status.PendingFinalization)
+	assert.False(t, mcn.Status.LastUpdated.IsZero())
+
+	// an unchanged status isn't written again
+	client.ClearActions()
+	require.NoError(t, dn.syncMachineConfigNode())
+	for _, action := range client.Actions() {
+		assert.Equal(t, "get", action.GetVerb())
+	}
+
+	// the node rebooted into the staged deployment
+	deployments := testDeployments(t)
+	deployments[0].Staged = false
+	deployments[0].Booted = true
+	deployments[1].Booted = false
+	updater.deployments = deployments
+	require.NoError(t, dn.syncMachineConfigNode())
+	mcn, err = client.MachineconfigurationV1().MachineConfigNodes().Get(context.TODO(), "worker-0", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "layered-new", mcn.Status.BootedDeployment.Checksum)
+	assert.Nil(t, mcn.Status.StagedDeployment)
+	assert.False(t, mcn.Status.PendingFinalization)
+}
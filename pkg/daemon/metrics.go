@@ -68,6 +68,13 @@ var (
 			Help: "completed update config or error",
 		}, []string{"config", "err"})
 
+	// MCDTransientErrorRetries counts sync retries due to transient errors
+	MCDTransientErrorRetries = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "mcd_transient_error_retries",
+			Help: "sync retries due to transient errors before degrading",
+		})
+
 	metricsList = []prometheus.Collector{
 		HostOS,
 		MCDSSHAccessed,
@@ -77,6 +84,7 @@ var (
 		KubeletHealthState,
 		MCDRebootErr,
 		MCDUpdateState,
+		MCDTransientErrorRetries,
 	}
 )
 
@@ -0,0 +1,256 @@
+package daemon
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// staticPodManifestsDir is where the kubelet picks up static pods
+const staticPodManifestsDir = "/etc/kubernetes/manifests"
+
+// OfflineManifest lists the external references of a rendered MachineConfig,
+// i.e. everything a node needs besides the config itself to apply it.  It's
+// meant to be fed to mirroring tooling for air-gapped clusters.  The manifest
+// only depends on the MachineConfig and is sorted, so the same config always
+// produces the same manifest.
+type OfflineManifest struct {
+	// MachineConfig is the name of the MachineConfig.
+	MachineConfig string `json:"machineConfig"`
+	// ConfigHash is the sha256 of the spec of the MachineConfig, pinning the
+	// manifest to the exact config it was produced from.
+	ConfigHash string `json:"configHash"`
+	// OSImage is the OSImageURL of the MachineConfig, if any.
+	OSImage *OfflineImage `json:"osImage,omitempty"`
+	// Images are the images referenced by static pods and systemd units.
+	Images []OfflineImage `json:"images,omitempty"`
+	// Binaries are the executables run by systemd units which aren't written
+	// by the config, so they're expected to be shipped by the OS image.
+	Binaries []OfflineBinary `json:"binaries,omitempty"`
+	// Extensions are installed from the extensions container of the OS image.
+	Extensions []string `json:"extensions,omitempty"`
+}
+
+// OfflineImage is an image referenced by a MachineConfig.
+type OfflineImage struct {
+	Image string `json:"image"`
+	// Digest is empty if the image is referenced by tag only.
+	Digest string `json:"digest,omitempty"`
+	// ReferencedBy are the files and units referencing the image.
+	ReferencedBy []string `json:"referencedBy,omitempty"`
+}
+
+// OfflineBinary is an executable expected on the host by a MachineConfig.
+type OfflineBinary struct {
+	Path string `json:"path"`
+	// ReferencedBy are the units running the executable.
+	ReferencedBy []string `json:"referencedBy"`
+}
+
+// Unpinned returns the images of the manifest which aren't referenced by
+// digest, so mirroring them doesn't guarantee the node gets the same content.
+func (m *OfflineManifest) Unpinned() []string {
+	var unpinned []string
+	if m.OSImage != nil && m.OSImage.Digest == "" {
+		unpinned = append(unpinned, m.OSImage.Image)
+	}
+	for _, image := range m.Images {
+		if image.Digest == "" {
+			unpinned = append(unpinned, image.Image)
+		}
+	}
+	return unpinned
+}
+
+// BuildOfflineManifest collects the external references of a rendered
+// MachineConfig: its OSImageURL, the images of the static pods written to
+// /etc/kubernetes/manifests, the fully qualified tagged or digested image
+// references in systemd units, the executables run by the units and the
+// extensions.
+func BuildOfflineManifest(mc *mcfgv1.MachineConfig) (*OfflineManifest, error) {
+	spec, err := json.Marshal(mc.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("could not hash MachineConfig %s: %w", mc.Name, err)
+	}
+	manifest := &OfflineManifest{
+		MachineConfig: mc.Name,
+		ConfigHash:    fmt.Sprintf("sha256:%x", sha256.Sum256(spec)),
+	}
+
+	if mc.Spec.OSImageURL != "" {
+		image, err := offlineImage(mc.Spec.OSImageURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid osImageURL: %w", err)
+		}
+		manifest.OSImage = &image
+	}
+
+	ignConfig, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing Ignition config failed: %w", err)
+	}
+
+	// written tracks what the config provides itself, it's not expected on the host.
+	written := sets.NewString()
+	for _, f := range ignConfig.Storage.Files {
+		written.Insert(f.Path)
+	}
+	for _, l := range ignConfig.Storage.Links {
+		written.Insert(l.Path)
+	}
+
+	images := map[string]sets.String{}
+	binaries := map[string]sets.String{}
+
+	for _, f := range ignConfig.Storage.Files {
+		if filepath.Dir(f.Path) != staticPodManifestsDir {
+			continue
+		}
+		contents, err := ctrlcommon.DecodeIgnitionFileContents(f.Contents.Source, f.Contents.Compression)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode file %q: %w", f.Path, err)
+		}
+		podImages, err := staticPodImages(contents)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse static pod %q: %w", f.Path, err)
+		}
+		for _, image := range podImages {
+			addReference(images, image, "file "+f.Path)
+		}
+	}
+
+	for _, u := range ignConfig.Systemd.Units {
+		contents := []string{}
+		if u.Contents != nil {
+			contents = append(contents, *u.Contents)
+		}
+		for _, dropin := range u.Dropins {
+			if dropin.Contents != nil {
+				contents = append(contents, *dropin.Contents)
+			}
+		}
+		for _, c := range contents {
+			unitImages, unitBinaries := scanUnit(c)
+			for _, image := range unitImages {
+				addReference(images, image, "unit "+u.Name)
+			}
+			for _, binary := range unitBinaries {
+				if !written.Has(binary) {
+					addReference(binaries, binary, "unit "+u.Name)
+				}
+			}
+		}
+	}
+
+	for _, ref := range sets.StringKeySet(images).List() {
+		image, err := offlineImage(ref)
+		if err != nil {
+			return nil, err
+		}
+		image.ReferencedBy = images[ref].List()
+		manifest.Images = append(manifest.Images, image)
+	}
+	for _, path := range sets.StringKeySet(binaries).List() {
+		manifest.Binaries = append(manifest.Binaries, OfflineBinary{Path: path, ReferencedBy: binaries[path].List()})
+	}
+	if len(mc.Spec.Extensions) > 0 {
+		manifest.Extensions = sets.NewString(mc.Spec.Extensions...).List()
+	}
+	return manifest, nil
+}
+
+func addReference(refs map[string]sets.String, key, referencedBy string) {
+	if refs[key] == nil {
+		refs[key] = sets.NewString()
+	}
+	refs[key].Insert(referencedBy)
+}
+
+func offlineImage(ref string) (OfflineImage, error) {
+	parsed, err := reference.Parse(ref)
+	if err != nil {
+		return OfflineImage{}, fmt.Errorf("could not parse image %q: %w", ref, err)
+	}
+	image := OfflineImage{Image: ref}
+	if digested, ok := parsed.(reference.Digested); ok {
+		image.Digest = digested.Digest().String()
+	}
+	return image, nil
+}
+
+// staticPodImages returns the images of the containers of a static pod manifest.
+func staticPodImages(contents []byte) ([]string, error) {
+	var pod corev1.Pod
+	if err := yaml.Unmarshal(contents, &pod); err != nil {
+		return nil, err
+	}
+	var images []string
+	for _, c := range pod.Spec.InitContainers {
+		images = append(images, c.Image)
+	}
+	for _, c := range pod.Spec.Containers {
+		images = append(images, c.Image)
+	}
+	return images, nil
+}
+
+// scanUnit returns the image references in a unit and the executables of its
+// Exec lines.  Only fully qualified references with a tag or digest are
+// recognized as images, to avoid mistaking e.g. host:port/path for one.
+func scanUnit(contents string) (images, binaries []string) {
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		fields := strings.Fields(value)
+		if strings.HasPrefix(key, "Exec") && len(fields) > 0 {
+			// systemd allows prefixing the executable with special characters
+			binary := strings.TrimLeft(fields[0], "-@:+!|")
+			if filepath.IsAbs(binary) {
+				binaries = append(binaries, binary)
+			}
+		}
+		for _, field := range fields {
+			field = strings.Trim(field, `"'`)
+			if i := strings.LastIndex(field, "="); i >= 0 {
+				field = field[i+1:]
+			}
+			if isQualifiedImage(field) {
+				images = append(images, field)
+			}
+		}
+	}
+	return images, binaries
+}
+
+func isQualifiedImage(s string) bool {
+	if !strings.Contains(s, "/") {
+		return false
+	}
+	// ParseNamed rejects references which aren't fully qualified.
+	named, err := reference.ParseNamed(s)
+	if err != nil {
+		return false
+	}
+	_, tagged := named.(reference.Tagged)
+	_, digested := named.(reference.Digested)
+	return tagged || digested
+}
@@ -0,0 +1,97 @@
+package daemon
+
+import (
+	"net/url"
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+const testDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+
+func TestBuildOfflineManifest(t *testing.T) {
+	staticPod := `apiVersion: v1
+kind: Pod
+metadata:
+  name: keepalived
+spec:
+  initContainers:
+  - name: render-config
+    image: quay.io/openshift/baremetal-runtimecfg@` + testDigest + `
+  containers:
+  - name: keepalived
+    image: quay.io/openshift/keepalived:v4.10
+`
+	files := []ign3types.File{
+		helpers.CreateIgn3File("/etc/kubernetes/manifests/keepalived.yaml", "data:,"+url.PathEscape(staticPod), 0644),
+		helpers.CreateIgn3File("/usr/local/bin/pull.sh", "data:,true", 0755),
+		helpers.CreateIgn3File("/etc/foo.conf", "data:,image%3Dquay.io%2Fignored%3Alatest", 0644),
+	}
+	units := []ign3types.Unit{{
+		Name: "pull.service",
+		Contents: helpers.StrToPtr(`[Unit]
+Description=Pull registry.example.com:5000/tools/debug:1.0 in advance
+[Service]
+Environment="IMAGE=quay.io/openshift/keepalived:v4.10"
+ExecStartPre=-/usr/bin/podman pull --authfile=/var/lib/kubelet/config.json quay.io/openshift/baremetal-runtimecfg@` + testDigest + `
+ExecStart=/usr/local/bin/pull.sh
+ExecStartPost=/usr/bin/curl -k api-int.example.com:6443/readyz
+# ExecStop=/usr/bin/commented
+`),
+		Dropins: []ign3types.Dropin{{
+			Name:     "10-stop.conf",
+			Contents: helpers.StrToPtr("[Service]\nExecStop=/usr/bin/podman stop pull\n"),
+		}},
+	}}
+	mc := helpers.NewMachineConfigExtended("rendered-worker-1", nil, files, units, nil, []string{"usbguard", "kernel-devel"}, false, nil, "", "quay.io/openshift/os@"+testDigest)
+
+	manifest, err := BuildOfflineManifest(mc)
+	require.NoError(t, err)
+	assert.Equal(t, "rendered-worker-1", manifest.MachineConfig)
+	assert.Regexp(t, `^sha256:[0-9a-f]{64}$`, manifest.ConfigHash)
+	assert.Equal(t, &OfflineImage{Image: "quay.io/openshift/os@" + testDigest, Digest: testDigest}, manifest.OSImage)
+	assert.Equal(t, []OfflineImage{{
+		Image:        "quay.io/openshift/baremetal-runtimecfg@" + testDigest,
+		Digest:       testDigest,
+		ReferencedBy: []string{"file /etc/kubernetes/manifests/keepalived.yaml", "unit pull.service"},
+	}, {
+		Image:        "quay.io/openshift/keepalived:v4.10",
+		ReferencedBy: []string{"file /etc/kubernetes/manifests/keepalived.yaml", "unit pull.service"},
+	}, {
+		Image:        "registry.example.com:5000/tools/debug:1.0",
+		ReferencedBy: []string{"unit pull.service"},
+	}}, manifest.Images)
+	assert.Equal(t, []OfflineBinary{{
+		Path:         "/usr/bin/curl",
+		ReferencedBy: []string{"unit pull.service"},
+	}, {
+		Path:         "/usr/bin/podman",
+		ReferencedBy: []string{"unit pull.service"},
+	}}, manifest.Binaries)
+	assert.Equal(t, []string{"kernel-devel", "usbguard"}, manifest.Extensions)
+	assert.Equal(t, []string{"quay.io/openshift/keepalived:v4.10", "registry.example.com:5000/tools/debug:1.0"}, manifest.Unpinned())
+
+	// The manifest only depends on the config.
+	other := mc.DeepCopy()
+	other.UID = "other"
+	again, err := BuildOfflineManifest(other)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, again)
+
+	other.Spec.OSImageURL = "quay.io/openshift/os:latest"
+	changed, err := BuildOfflineManifest(other)
+	require.NoError(t, err)
+	assert.NotEqual(t, manifest.ConfigHash, changed.ConfigHash)
+	assert.Equal(t, []string{"quay.io/openshift/os:latest", "quay.io/openshift/keepalived:v4.10", "registry.example.com:5000/tools/debug:1.0"}, changed.Unpinned())
+}
+
+func TestBuildOfflineManifestInvalidStaticPod(t *testing.T) {
+	files := []ign3types.File{helpers.CreateIgn3File("/etc/kubernetes/manifests/broken.yaml", "data:,%7B", 0644)}
+	mc := helpers.NewMachineConfigExtended("rendered-worker-1", nil, files, nil, nil, nil, false, nil, "", "")
+	_, err := BuildOfflineManifest(mc)
+	assert.Error(t, err)
+}
@@ -0,0 +1,381 @@
+package daemon
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/ghodss/yaml"
+	"github.com/golang/glog"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+const (
+	crioDropInDir       = "/etc/crio/crio.conf.d/"
+	registriesDropInDir = "/etc/containers/registries.conf.d/"
+	kubeletConfPath     = "/etc/kubernetes/kubelet.conf"
+	multipathConfPath   = "/etc/multipath.conf"
+	nmConfDir           = "/etc/NetworkManager/conf.d/"
+)
+
+// fileChangeClassifier returns the action needed to apply a change of a
+// file from oldData to newData.  Either may be nil if the file is added or removed.
+type fileChangeClassifier func(oldData, newData []byte) (string, error)
+
+// fileChangeRule is an entry of the change-classification table.
+type fileChangeRule struct {
+	// matches returns true if the rule applies to path
+	matches func(path string) bool
+	// classify decides the action for a change of a matching file
+	classify fileChangeClassifier
+}
+
+func pathIs(paths ...string) func(string) bool {
+	return func(path string) bool {
+		for _, p := range paths {
+			if path == p {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func isHostMTUConf(path string) bool {
+	return strings.HasPrefix(path, nmConfDir) && strings.HasSuffix(path, "-mtu.conf")
+}
+
+func always(action string) fileChangeClassifier {
+	return func(_, _ []byte) (string, error) {
+		return action, nil
+	}
+}
+
+// fileChangeRules is the change-classification table consulted for every
+// changed file; files not matching any rule require a reboot.
+var fileChangeRules = []fileChangeRule{
+	{
+		matches:  pathIs("/etc/kubernetes/kubelet-ca.crt", "/var/lib/kubelet/config.json"),
+		classify: always(postConfigChangeActionNone),
+	},
+	{
+		matches:  pathIs(constants.ContainerRegistryConfPath, GPGNoRebootPath, "/etc/containers/policy.json"),
+		classify: always(postConfigChangeActionReloadCrio),
+	},
+	{
+		// crio re-reads the registries.conf.d drop-ins together with registries.conf
+		matches:  func(path string) bool { return strings.HasPrefix(path, registriesDropInDir) },
+		classify: always(postConfigChangeActionReloadCrio),
+	},
+	{
+		matches:  func(path string) bool { return strings.HasPrefix(path, crioDropInDir) },
+		classify: classifyCrioConfigChange,
+	},
+	{
+		matches:  pathIs(kubeletConfPath),
+		classify: classifyKubeletConfigChange,
+	},
+	{
+		matches:  pathIs(multipathConfPath),
+		classify: classifyMultipathConfigChange,
+	},
+	{
+		// The MTU keyfiles of a pool's hostMTU: NetworkManager only applies an
+		// MTU when activating a connection, and reactivating the uplink cuts the
+		// node off, so they're applied by rebooting, as the MTU migration expects.
+		matches:  isHostMTUConf,
+		classify: always(postConfigChangeActionReboot),
+	},
+	{
+		// GRUB reads the staged bootloader password only once update()
+		// installed it in /boot, and only on the next boot anyway.
+		matches:  pathIs(bootloaderUserCfgStagedPath),
+		classify: always(postConfigChangeActionNone),
+	},
+}
+
+// crioReloadableKeys are the crio.conf options crio picks up on SIGHUP, see
+// the "CONFIGURATION RELOAD" section of crio(8).
+var crioReloadableKeys = map[string]bool{
+	"crio.runtime.log_level":           true,
+	"crio.runtime.log_filter":          true,
+	"crio.runtime.seccomp_profile":     true,
+	"crio.runtime.apparmor_profile":    true,
+	"crio.image.pause_image":           true,
+	"crio.image.pause_command":         true,
+	"crio.image.pause_image_auth_file": true,
+	"crio.image.signature_policy":      true,
+}
+
+// kubeletRestartableKeys are the KubeletConfiguration fields that are safe
+// to apply by restarting the kubelet.  Resource manager fields are also applied
+// by a restart, after resetting their state, see kubeletStateFiles.  Anything
+// touching cgroups or the node's identity still requires a reboot.
+var kubeletRestartableKeys = map[string]bool{
+	"containerLogMaxFiles":             true,
+	"containerLogMaxSize":              true,
+	"eventBurst":                       true,
+	"eventRecordQPS":                   true,
+	"evictionHard":                     true,
+	"evictionMaxPodGracePeriod":        true,
+	"evictionPressureTransitionPeriod": true,
+	"evictionSoft":                     true,
+	"evictionSoftGracePeriod":          true,
+	"imageGCHighThresholdPercent":      true,
+	"imageGCLowThresholdPercent":       true,
+	"imageMinimumGCAge":                true,
+	"kubeAPIBurst":                     true,
+	"kubeAPIQPS":                       true,
+	"logging":                          true,
+	"maxPods":                          true,
+	"nodeStatusReportFrequency":        true,
+	"nodeStatusUpdateFrequency":        true,
+	"podsPerCore":                      true,
+	"registryBurst":                    true,
+	"registryPullQPS":                  true,
+	"serializeImagePulls":              true,
+}
+
+// classifyCrioConfigChange reloads crio if only reloadable options changed and restarts it otherwise.
+func classifyCrioConfigChange(oldData, newData []byte) (string, error) {
+	oldConf, err := flattenTOML(oldData)
+	if err != nil {
+		return "", err
+	}
+	newConf, err := flattenTOML(newData)
+	if err != nil {
+		return "", err
+	}
+	for _, key := range changedKeys(oldConf, newConf) {
+		if !crioReloadableKeys[key] {
+			glog.V(2).Infof("crio option %s changed, crio needs a restart", key)
+			return postConfigChangeActionRestartCrio, nil
+		}
+	}
+	return postConfigChangeActionReloadCrio, nil
+}
+
+// classifyMultipathConfigChange reloads multipathd if its config changed, multipathd
+// re-reads it and reconfigures its maps on reload.  Enabling or disabling
+// multipath, i.e. adding or removing the config, requires a reboot.
+func classifyMultipathConfigChange(oldData, newData []byte) (string, error) {
+	if oldData == nil || newData == nil {
+		return postConfigChangeActionReboot, nil
+	}
+	return postConfigChangeActionReloadMultipathd, nil
+}
+
+// kubeletResourceManagerKeys are the KubeletConfiguration fields of the CPU,
+// memory and topology managers.  They're applied by draining the node and
+// restarting the kubelet once the checkpoints they invalidate are removed.
+var kubeletResourceManagerKeys = map[string]bool{
+	"cpuManagerPolicy":          true,
+	"cpuManagerPolicyOptions":   true,
+	"cpuManagerReconcilePeriod": true,
+	"memoryManagerPolicy":       true,
+	"reservedMemory":            true,
+	"reservedSystemCPUs":        true,
+	"topologyManagerPolicy":     true,
+	"topologyManagerScope":      true,
+}
+
+// kubeletStateFiles are the checkpoints of the kubelet resource managers and
+// the KubeletConfiguration fields they're computed from.  The kubelet refuses
+// to start if a checkpoint doesn't match its config, so it's removed when any
+// of these fields change, whether the change reboots the node or not.
+var kubeletStateFiles = []struct {
+	path   string
+	fields []string
+}{
+	{
+		path:   "/var/lib/kubelet/cpu_manager_state",
+		fields: []string{"cpuManagerPolicy", "cpuManagerPolicyOptions", "reservedSystemCPUs", "kubeReserved", "systemReserved"},
+	},
+	{
+		path:   "/var/lib/kubelet/memory_manager_state",
+		fields: []string{"memoryManagerPolicy", "reservedMemory"},
+	},
+}
+
+func parseKubeletConfig(data []byte) (map[string]interface{}, error) {
+	conf := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return nil, fmt.Errorf("parsing kubelet config: %w", err)
+	}
+	return conf, nil
+}
+
+// classifyKubeletConfigChange restarts the kubelet if only restartable fields changed and reboots otherwise.
+func classifyKubeletConfigChange(oldData, newData []byte) (string, error) {
+	if oldData == nil || newData == nil {
+		return postConfigChangeActionReboot, nil
+	}
+	oldConf, err := parseKubeletConfig(oldData)
+	if err != nil {
+		return "", err
+	}
+	newConf, err := parseKubeletConfig(newData)
+	if err != nil {
+		return "", err
+	}
+	for _, key := range changedKeys(oldConf, newConf) {
+		if !kubeletRestartableKeys[key] && !kubeletResourceManagerKeys[key] {
+			glog.V(2).Infof("kubelet config field %s changed, a reboot is required", key)
+			return postConfigChangeActionReboot, nil
+		}
+	}
+	return postConfigChangeActionRestartKubelet, nil
+}
+
+// staleKubeletStateFiles returns the kubelet resource manager checkpoints
+// invalidated by the change of the kubelet config between the two configs.
+func staleKubeletStateFiles(oldIgnConfig, newIgnConfig *ign3types.Config) ([]string, error) {
+	oldData, err := ctrlcommon.GetIgnitionFileDataByPath(oldIgnConfig, kubeletConfPath)
+	if err != nil {
+		return nil, err
+	}
+	newData, err := ctrlcommon.GetIgnitionFileDataByPath(newIgnConfig, kubeletConfPath)
+	if err != nil {
+		return nil, err
+	}
+	if oldData == nil || newData == nil {
+		return nil, nil
+	}
+	oldConf, err := parseKubeletConfig(oldData)
+	if err != nil {
+		return nil, err
+	}
+	newConf, err := parseKubeletConfig(newData)
+	if err != nil {
+		return nil, err
+	}
+	changed := map[string]bool{}
+	for _, key := range changedKeys(oldConf, newConf) {
+		changed[key] = true
+	}
+	stale := []string{}
+	for _, state := range kubeletStateFiles {
+		for _, field := range state.fields {
+			if changed[field] {
+				stale = append(stale, state.path)
+				break
+			}
+		}
+	}
+	return stale, nil
+}
+
+// flattenTOML parses a TOML document into a map keyed by the dotted path of each value.
+func flattenTOML(data []byte) (map[string]interface{}, error) {
+	tree := map[string]interface{}{}
+	if _, err := toml.Decode(string(data), &tree); err != nil {
+		return nil, fmt.Errorf("parsing TOML: %w", err)
+	}
+	flat := map[string]interface{}{}
+	var flatten func(prefix string, m map[string]interface{})
+	flatten = func(prefix string, m map[string]interface{}) {
+		for k, v := range m {
+			if sub, ok := v.(map[string]interface{}); ok {
+				flatten(prefix+k+".", sub)
+				continue
+			}
+			flat[prefix+k] = v
+		}
+	}
+	flatten("", tree)
+	return flat, nil
+}
+
+// changedKeys returns the sorted keys whose values differ between a and b.
+func changedKeys(a, b map[string]interface{}) []string {
+	keys := []string{}
+	for k, v := range a {
+		if !reflect.DeepEqual(v, b[k]) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// postConfigChangeActionOrder is the order in which the actions are performed:
+// a service is reloaded or restarted after the services it depends on, crio
+// and multipathd before the kubelet, which needs the container runtime and
+// the storage they set up.
+var postConfigChangeActionOrder = []string{
+	postConfigChangeActionNone,
+	postConfigChangeActionReloadCrio,
+	postConfigChangeActionRestartCrio,
+	postConfigChangeActionReloadMultipathd,
+	postConfigChangeActionRestartKubelet,
+}
+
+// reducePostConfigChangeActions drops the actions made redundant by more
+// disruptive ones: a reboot supersedes everything, a crio restart also
+// reloads its config and "none" only remains if nothing else is needed.
+func reducePostConfigChangeActions(actions map[string]bool) []string {
+	if actions[postConfigChangeActionReboot] {
+		return []string{postConfigChangeActionReboot}
+	}
+	if actions[postConfigChangeActionRestartCrio] {
+		delete(actions, postConfigChangeActionReloadCrio)
+	}
+	if len(actions) > 1 {
+		delete(actions, postConfigChangeActionNone)
+	}
+	reduced := []string{}
+	for _, action := range postConfigChangeActionOrder {
+		if actions[action] {
+			reduced = append(reduced, action)
+		}
+	}
+	if len(reduced) == 0 {
+		return []string{postConfigChangeActionNone}
+	}
+	return reduced
+}
+
+// addPostConfigChangeAction adds action to the reduced actions, unless the
+// node reboots anyway.
+func addPostConfigChangeAction(actions []string, action string) []string {
+	set := map[string]bool{action: true}
+	for _, a := range actions {
+		set[a] = true
+	}
+	return reducePostConfigChangeActions(set)
+}
+
+// classifyFileChange looks up the action needed to apply the change of path
+// between the two configs in the change-classification table.
+func classifyFileChange(oldIgnConfig, newIgnConfig *ign3types.Config, path string) (string, error) {
+	for _, rule := range fileChangeRules {
+		if !rule.matches(path) {
+			continue
+		}
+		oldData, err := ctrlcommon.GetIgnitionFileDataByPath(oldIgnConfig, path)
+		if err != nil {
+			return "", err
+		}
+		newData, err := ctrlcommon.GetIgnitionFileDataByPath(newIgnConfig, path)
+		if err != nil {
+			return "", err
+		}
+		action, err := rule.classify(oldData, newData)
+		if err != nil {
+			return "", fmt.Errorf("classifying change to %s: %w", path, err)
+		}
+		return action, nil
+	}
+	return postConfigChangeActionReboot, nil
+}
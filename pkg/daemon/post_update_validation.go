@@ -0,0 +1,200 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+const (
+	// defaultPostUpdateValidationTimeout is how long failing probes are
+	// retried unless the pool sets a timeout.
+	defaultPostUpdateValidationTimeout = 5 * time.Minute
+	// postUpdateValidationInterval is the delay between two runs of the probes.
+	postUpdateValidationInterval = 10 * time.Second
+	// validationProbeTimeout bounds a single run of a probe.
+	validationProbeTimeout = 30 * time.Second
+)
+
+// postUpdateValidation returns the PostUpdateValidation the node controller
+// copied from the pool of the node, or nil if there is none.
+func postUpdateValidation(node *corev1.Node) (*mcfgv1.PostUpdateValidation, error) {
+	if node == nil || node.Annotations[constants.PostUpdateValidationAnnotationKey] == "" {
+		return nil, nil
+	}
+	policy := &mcfgv1.PostUpdateValidation{}
+	if err := json.Unmarshal([]byte(node.Annotations[constants.PostUpdateValidationAnnotationKey]), policy); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", constants.PostUpdateValidationAnnotationKey, err)
+	}
+	return policy, nil
+}
+
+// runValidationProbe runs a single probe on the node.
+func runValidationProbe(probe mcfgv1.ValidationProbe) error {
+	set := 0
+	for _, isSet := range []bool{probe.SystemdUnit != "", probe.URL != "", len(probe.Command) > 0} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of systemdUnit, url and command must be set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), validationProbeTimeout)
+	defer cancel()
+	switch {
+	case probe.SystemdUnit != "":
+		// is-active prints the state of the unit, e.g. failed or activating
+		out, err := exec.CommandContext(ctx, "systemctl", "is-active", probe.SystemdUnit).Output()
+		if err != nil {
+			return fmt.Errorf("unit %s is not active: %s", probe.SystemdUnit, strings.TrimSpace(string(out)))
+		}
+	case probe.URL != "":
+		u, err := loopbackURL(probe.URL)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("unexpected status %s", resp.Status)
+		}
+	default:
+		out, err := exec.CommandContext(ctx, probe.Command[0], probe.Command[1:]...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s: %w: %.500s", probe.Command[0], err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// runValidationProbes runs the probes in order until they all pass, or the
+// timeout expires.  It returns the error of the last failing probe.
+func runValidationProbes(probes []mcfgv1.ValidationProbe, timeout, interval time.Duration) error {
+	var lastErr error
+	err := wait.PollImmediate(interval, timeout, func() (bool, error) {
+		for _, probe := range probes {
+			if err := runValidationProbe(probe); err != nil {
+				lastErr = fmt.Errorf("probe %s failed: %w", probe.Name, err)
+				glog.Infof("Post-update validation: %v", lastErr)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil && lastErr != nil {
+		return lastErr
+	}
+	return err
+}
+
+// validateUpdate runs the post-update validation probes of the pool of the
+// node once it applied pendingConfig, before the node is set Done.  When they
+// fail, the node is either degraded or rolled back to its previous config, as
+// configured by the pool.  Nothing is validated if pendingConfig isn't an
+// update, e.g. after a rollback.
+func (dn *Daemon) validateUpdate(pendingConfig *mcfgv1.MachineConfig) error {
+	currentConfigName := dn.node.Annotations[constants.CurrentMachineConfigAnnotationKey]
+	if currentConfigName == pendingConfig.GetName() {
+		return nil
+	}
+	policy, err := postUpdateValidation(dn.node)
+	if err != nil {
+		return err
+	}
+	if policy != nil {
+		timeout := defaultPostUpdateValidationTimeout
+		if policy.Timeout != nil {
+			timeout = policy.Timeout.Duration
+		}
+		dn.logSystem("Validating config %s with %d probes", pendingConfig.GetName(), len(policy.Probes))
+		if err := runValidationProbes(policy.Probes, timeout, postUpdateValidationInterval); err != nil {
+			verr := &ctrlcommon.PostUpdateValidationError{Err: fmt.Errorf("config %s failed its post-update validation: %w", pendingConfig.GetName(), err)}
+			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "PostUpdateValidationFailed", verr.Error())
+			if policy.FailurePolicy != mcfgv1.PostUpdateValidationFailurePolicyRollback {
+				return verr
+			}
+			return dn.rollBackUpdate(currentConfigName, pendingConfig, verr)
+		}
+		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "PostUpdateValidationPassed", "Config %s passed its post-update validation", pendingConfig.GetName())
+	}
+
+	// The node moved on from the config it rolled back from.
+	if dn.node.Annotations[constants.RolledBackConfigAnnotationKey] != "" {
+		if err := dn.nodeWriter.SetRolledBackConfig("", dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+			return fmt.Errorf("clearing %s annotation: %w", constants.RolledBackConfigAnnotationKey, err)
+		}
+		if fellBackFrom, err := bootFallbackConfig(); err != nil {
+			return err
+		} else if fellBackFrom != "" {
+			if err := removeBootFallbackRecord(bootFallbackRecordPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rollBackUpdate applies the previous config of the node again after
+// pendingConfig failed its post-update validation with verr, and records
+// pendingConfig so the daemon doesn't update to it again.  It only returns
+// if the rollback didn't need a reboot, or failed.
+func (dn *Daemon) rollBackUpdate(previousConfigName string, pendingConfig *mcfgv1.MachineConfig, verr error) error {
+	previousConfig, err := dn.mcLister.Get(previousConfigName)
+	if err != nil {
+		return fmt.Errorf("%v, and getting config %s to roll back to failed: %w", verr, previousConfigName, err)
+	}
+	if err := dn.nodeWriter.SetRolledBackConfig(pendingConfig.GetName(), dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); err != nil {
+		return fmt.Errorf("%v, and recording the rollback failed: %w", verr, err)
+	}
+	// The node lister may not have seen the annotation yet.
+	dn.node.Annotations[constants.RolledBackConfigAnnotationKey] = pendingConfig.GetName()
+
+	dn.logSystem("Rolling back from config %s to %s: %v", pendingConfig.GetName(), previousConfigName, verr)
+	dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "PostUpdateValidationRollBack", "Rolling back from config %s to %s", pendingConfig.GetName(), previousConfigName)
+	if err := dn.update(pendingConfig, previousConfig); err != nil {
+		return err
+	}
+	return verr
+}
+
+// checkRolledBackConfig returns an error if desiredConfig was rolled back
+// after failing its post-update validation, or if the node fell back from it
+// after failing to boot it.
+func (dn *Daemon) checkRolledBackConfig(desiredConfig *mcfgv1.MachineConfig) error {
+	if dn.node == nil {
+		return nil
+	}
+	rolledBack := dn.node.Annotations[constants.RolledBackConfigAnnotationKey]
+	if rolledBack == "" || rolledBack != desiredConfig.GetName() {
+		return nil
+	}
+	fellBackFrom, err := bootFallbackConfig()
+	if err != nil {
+		return err
+	}
+	if fellBackFrom == rolledBack {
+		return &ctrlcommon.BootFallbackError{Err: fmt.Errorf("node fell back from config %s after failing to boot it, not updating to it again", rolledBack)}
+	}
+	return &ctrlcommon.PostUpdateValidationError{Err: fmt.Errorf("config %s was rolled back after failing its post-update validation, not updating to it again", rolledBack)}
+}
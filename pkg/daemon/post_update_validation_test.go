@@ -0,0 +1,130 @@
+package daemon
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+func TestPostUpdateValidation(t *testing.T) {
+	policy, err := postUpdateValidation(&corev1.Node{})
+	require.NoError(t, err)
+	assert.Nil(t, policy)
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		constants.PostUpdateValidationAnnotationKey: `{"probes":[{"name":"kubelet","systemdUnit":"kubelet.service"}],"timeout":"2m","failurePolicy":"Rollback"}`,
+	}}}
+	policy, err = postUpdateValidation(node)
+	require.NoError(t, err)
+	assert.Equal(t, &mcfgv1.PostUpdateValidation{
+		Probes:        []mcfgv1.ValidationProbe{{Name: "kubelet", SystemdUnit: "kubelet.service"}},
+		Timeout:       &metav1.Duration{Duration: 2 * time.Minute},
+		FailurePolicy: mcfgv1.PostUpdateValidationFailurePolicyRollback,
+	}, policy)
+
+	node.Annotations[constants.PostUpdateValidationAnnotationKey] = "{"
+	_, err = postUpdateValidation(node)
+	assert.Error(t, err)
+}
+
+func TestRunValidationProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		probe mcfgv1.ValidationProbe
+		err   bool
+	}{{
+		probe: mcfgv1.ValidationProbe{Name: "ok", Command: []string{"true"}},
+	}, {
+		probe: mcfgv1.ValidationProbe{Name: "exit status", Command: []string{"false"}},
+		err:   true,
+	}, {
+		probe: mcfgv1.ValidationProbe{Name: "healthy", URL: server.URL + "/healthz"},
+	}, {
+		probe: mcfgv1.ValidationProbe{Name: "unhealthy", URL: server.URL + "/readyz"},
+		err:   true,
+	}, {
+		probe: mcfgv1.ValidationProbe{Name: "not loopback", URL: "http://10.0.0.1/healthz"},
+		err:   true,
+	}, {
+		probe: mcfgv1.ValidationProbe{Name: "none"},
+		err:   true,
+	}, {
+		probe: mcfgv1.ValidationProbe{Name: "several", SystemdUnit: "kubelet.service", Command: []string{"true"}},
+		err:   true,
+	}}
+	for _, test := range tests {
+		t.Run(test.probe.Name, func(t *testing.T) {
+			err := runValidationProbe(test.probe)
+			if test.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRunValidationProbes(t *testing.T) {
+	assert.NoError(t, runValidationProbes([]mcfgv1.ValidationProbe{{Name: "a", Command: []string{"true"}}}, time.Second, time.Millisecond))
+
+	err := runValidationProbes([]mcfgv1.ValidationProbe{
+		{Name: "a", Command: []string{"true"}},
+		{Name: "b", Command: []string{"false"}},
+	}, 10*time.Millisecond, time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "probe b failed")
+}
+
+func TestValidateUpdate(t *testing.T) {
+	newDaemon := func(annotations map[string]string) *Daemon {
+		annotations[constants.CurrentMachineConfigAnnotationKey] = "rendered-worker-0"
+		return &Daemon{
+			name:     "node-0",
+			node:     &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: annotations}},
+			recorder: record.NewFakeRecorder(10),
+		}
+	}
+	pending := &mcfgv1.MachineConfig{ObjectMeta: metav1.ObjectMeta{Name: "rendered-worker-1"}}
+	failing := `{"probes":[{"name":"check","command":["false"]}],"timeout":"1ms"}`
+
+	// no validation configured
+	assert.NoError(t, newDaemon(map[string]string{}).validateUpdate(pending))
+
+	// a failing probe degrades the node
+	err := newDaemon(map[string]string{constants.PostUpdateValidationAnnotationKey: failing}).validateUpdate(pending)
+	var validationErr *ctrlcommon.PostUpdateValidationError
+	require.True(t, errors.As(err, &validationErr), err)
+	assert.Equal(t, ctrlcommon.ErrorReasonPostUpdateValidation, ctrlcommon.ErrorReason(err))
+	assert.False(t, isTransientError(err))
+
+	// the config the node is already on isn't validated again, e.g. after a rollback
+	pending.Name = "rendered-worker-0"
+	assert.NoError(t, newDaemon(map[string]string{constants.PostUpdateValidationAnnotationKey: failing}).validateUpdate(pending))
+}
+
+func TestCheckRolledBackConfig(t *testing.T) {
+	dn := &Daemon{node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		constants.RolledBackConfigAnnotationKey: "rendered-worker-1",
+	}}}}
+	assert.Error(t, dn.checkRolledBackConfig(&mcfgv1.MachineConfig{ObjectMeta: metav1.ObjectMeta{Name: "rendered-worker-1"}}))
+	assert.NoError(t, dn.checkRolledBackConfig(&mcfgv1.MachineConfig{ObjectMeta: metav1.ObjectMeta{Name: "rendered-worker-2"}}))
+}
@@ -0,0 +1,254 @@
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/golang/glog"
+)
+
+const (
+	// selinuxEnforcePath holds 1 if SELinux enforces its policy, 0 if it's
+	// permissive, and doesn't exist if SELinux is disabled.
+	selinuxEnforcePath = "/sys/fs/selinux/enforce"
+
+	// minRpmOstreeVersion is the oldest rpm-ostree the daemon supports, the one
+	// of the oldest RHCOS it updates from.  Older ones lack options the daemon
+	// passes, e.g. rebase --custom-origin-url.
+	minRpmOstreeVersion = "2020.7"
+)
+
+// requiredBinaries are the binaries the daemon runs on all the nodes.
+var requiredBinaries = []string{"systemctl", "systemd-run", "journalctl", "logger", "chcon"}
+
+// requiredCoreOSBinaries are the binaries the daemon runs on the CoreOS nodes
+// to manage their OS.
+var requiredCoreOSBinaries = []string{"rpm-ostree", "ostree", "podman"}
+
+// PrerequisiteCheck is the result of a check of what the daemon assumes of
+// the node it runs on.
+type PrerequisiteCheck struct {
+	// Name is the name of the check, e.g. RpmOstree
+	Name string `json:"name"`
+	// Passed is true if the node meets the prerequisite
+	Passed bool `json:"passed"`
+	// Message describes what was found, and how to fix the node if it
+	// didn't pass
+	Message string `json:"message"`
+}
+
+// PrerequisiteChecks are the results of the checks of CheckPrerequisites.
+type PrerequisiteChecks []PrerequisiteCheck
+
+// Err returns an error listing the checks that didn't pass, or nil if they
+// all did.
+func (checks PrerequisiteChecks) Err() error {
+	var failed []string
+	for _, c := range checks {
+		if !c.Passed {
+			failed = append(failed, fmt.Sprintf("%s: %s", c.Name, c.Message))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("the node doesn't meet the prerequisites of the machine-config-daemon: %s", strings.Join(failed, "; "))
+}
+
+// prerequisiteChecker checks the prerequisites through its functions, so
+// that tests can fake the node.
+type prerequisiteChecker struct {
+	hostOS           func() (OperatingSystem, error)
+	lookPath         func(string) (string, error)
+	readFile         func(string) ([]byte, error)
+	rpmOstreeVersion func() ([]byte, error)
+	// stateDir is the directory the daemon writes its state in on the node
+	stateDir string
+}
+
+func newPrerequisiteChecker() *prerequisiteChecker {
+	return &prerequisiteChecker{
+		hostOS:   GetHostRunningOS,
+		lookPath: exec.LookPath,
+		readFile: ioutil.ReadFile,
+		rpmOstreeVersion: func() ([]byte, error) {
+			return exec.Command("rpm-ostree", "--version").Output()
+		},
+		stateDir: filepath.Dir(currentConfigPath),
+	}
+}
+
+// CheckPrerequisites checks what the daemon assumes of the node, once it
+// chrooted into its root: a supported OS, a writable root, the binaries it
+// runs, a recent enough rpm-ostree on CoreOS, and SELinux.  The results are
+// logged and served by the health listener.
+func CheckPrerequisites() PrerequisiteChecks {
+	checks := newPrerequisiteChecker().check()
+	for _, c := range checks {
+		if c.Passed {
+			glog.Infof("Prerequisite %s met: %s", c.Name, c.Message)
+		} else {
+			glog.Errorf("Prerequisite %s not met: %s", c.Name, c.Message)
+		}
+	}
+	health.setPrerequisites(checks)
+	return checks
+}
+
+func (p *prerequisiteChecker) check() PrerequisiteChecks {
+	hostos, osCheck := p.checkOS()
+	checks := PrerequisiteChecks{osCheck, p.checkRootMount(), p.checkBinaries(hostos)}
+	if hostos.IsCoreOSVariant() {
+		checks = append(checks, p.checkRpmOstree())
+	}
+	return append(checks, p.checkSELinux())
+}
+
+func prerequisitePassed(name, format string, args ...interface{}) PrerequisiteCheck {
+	return PrerequisiteCheck{Name: name, Passed: true, Message: fmt.Sprintf(format, args...)}
+}
+
+func prerequisiteFailed(name, format string, args ...interface{}) PrerequisiteCheck {
+	return PrerequisiteCheck{Name: name, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *prerequisiteChecker) checkOS() (OperatingSystem, PrerequisiteCheck) {
+	const name = "OperatingSystem"
+	hostos, err := p.hostOS()
+	if err != nil {
+		return hostos, prerequisiteFailed(name, "reading os-release: %v; check that the root of the node is mounted at the --root-mount of the daemon", err)
+	}
+	switch {
+	case hostos.IsCoreOSVariant(), hostos.ID == "rhel", hostos.ID == "centos":
+		return hostos, prerequisitePassed(name, "%s %s", hostos.ID, hostos.VersionID)
+	default:
+		return hostos, prerequisiteFailed(name, "%s %s isn't supported, the machine-config-daemon manages RHCOS and FCOS nodes, and RHEL or CentOS ones", hostos.ID, hostos.VersionID)
+	}
+}
+
+// checkRootMount checks that the daemon can write its state on the root of
+// the node, which the daemonset mounts read-write.
+func (p *prerequisiteChecker) checkRootMount() PrerequisiteCheck {
+	const name = "RootMount"
+	if err := os.MkdirAll(p.stateDir, 0755); err != nil {
+		return prerequisiteFailed(name, "creating %s: %v; the root of the node has to be mounted read-write", p.stateDir, err)
+	}
+	f, err := ioutil.TempFile(p.stateDir, ".prerequisites")
+	if err != nil {
+		return prerequisiteFailed(name, "writing to %s: %v; the root of the node has to be mounted read-write", p.stateDir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return prerequisitePassed(name, "%s is writable", p.stateDir)
+}
+
+func (p *prerequisiteChecker) checkBinaries(hostos OperatingSystem) PrerequisiteCheck {
+	const name = "RequiredBinaries"
+	binaries := requiredBinaries
+	if hostos.IsCoreOSVariant() {
+		binaries = append(append([]string{}, binaries...), requiredCoreOSBinaries...)
+	}
+	var missing []string
+	for _, b := range binaries {
+		if _, err := p.lookPath(b); err != nil {
+			missing = append(missing, b)
+		}
+	}
+	if len(missing) > 0 {
+		return prerequisiteFailed(name, "%s not found in PATH=%s; install them on the node", strings.Join(missing, ", "), os.Getenv("PATH"))
+	}
+	return prerequisitePassed(name, "%s found", strings.Join(binaries, ", "))
+}
+
+func (p *prerequisiteChecker) checkRpmOstree() PrerequisiteCheck {
+	const name = "RpmOstree"
+	out, err := p.rpmOstreeVersion()
+	if err != nil {
+		return prerequisiteFailed(name, "running rpm-ostree --version: %v; check that rpm-ostreed can start on the node", err)
+	}
+	version, err := parseRpmOstreeVersion(out)
+	if err != nil {
+		return prerequisiteFailed(name, "%v", err)
+	}
+	older, err := versionOlder(version, minRpmOstreeVersion)
+	if err != nil {
+		return prerequisiteFailed(name, "%v", err)
+	}
+	if older {
+		return prerequisiteFailed(name, "version %s is older than %s; update the OS of the node", version, minRpmOstreeVersion)
+	}
+	return prerequisitePassed(name, "version %s", version)
+}
+
+// parseRpmOstreeVersion returns the version in the output of
+// `rpm-ostree --version`, e.g. 2022.10.
+func parseRpmOstreeVersion(out []byte) (string, error) {
+	var v struct {
+		RpmOstree struct {
+			Version string `json:"Version"`
+		} `json:"rpm-ostree"`
+	}
+	if err := yaml.Unmarshal(out, &v); err != nil {
+		return "", fmt.Errorf("parsing rpm-ostree --version: %w", err)
+	}
+	if v.RpmOstree.Version == "" {
+		return "", fmt.Errorf("no version in rpm-ostree --version: %q", out)
+	}
+	return v.RpmOstree.Version, nil
+}
+
+// versionOlder returns true if the dot separated version a is older than b.
+// Only the leading numbers of the components are compared, e.g. 2022.10 of
+// 2022.10.86-gd8ef35fb.
+func versionOlder(a, b string) (bool, error) {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, err := leadingNumber(as[i])
+		if err != nil {
+			return false, fmt.Errorf("invalid version %q: %w", a, err)
+		}
+		bn, err := leadingNumber(bs[i])
+		if err != nil {
+			return false, fmt.Errorf("invalid version %q: %w", b, err)
+		}
+		if an != bn {
+			return an < bn, nil
+		}
+	}
+	return len(as) < len(bs), nil
+}
+
+func leadingNumber(s string) (int, error) {
+	end := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if end == -1 {
+		end = len(s)
+	}
+	return strconv.Atoi(s[:end])
+}
+
+// checkSELinux checks that SELinux is enabled, so that the files the daemon
+// writes are labeled.  It may be permissive.
+func (p *prerequisiteChecker) checkSELinux() PrerequisiteCheck {
+	const name = "SELinux"
+	enforce, err := p.readFile(selinuxEnforcePath)
+	if os.IsNotExist(err) {
+		return prerequisiteFailed(name, "SELinux is disabled; boot the node with SELinux enabled, OpenShift doesn't support disabling it")
+	}
+	if err != nil {
+		return prerequisiteFailed(name, "reading %s: %v", selinuxEnforcePath, err)
+	}
+	switch strings.TrimSpace(string(enforce)) {
+	case "1":
+		return prerequisitePassed(name, "enforcing")
+	case "0":
+		return prerequisitePassed(name, "permissive")
+	default:
+		return prerequisiteFailed(name, "unexpected %s: %q", selinuxEnforcePath, enforce)
+	}
+}
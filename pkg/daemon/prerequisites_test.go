@@ -0,0 +1,114 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rpmOstreeVersionOutput = `rpm-ostree:
+ Version: '2022.10'
+ Git: 3d5c9d9c4b39fa9b5b1e2d5fb2c0e6f3f2a4b1c8
+ Features:
+  - rust
+  - compose
+`
+
+func newTestPrerequisiteChecker(t *testing.T, hostos OperatingSystem) *prerequisiteChecker {
+	return &prerequisiteChecker{
+		hostOS:           func() (OperatingSystem, error) { return hostos, nil },
+		lookPath:         func(name string) (string, error) { return "/usr/bin/" + name, nil },
+		readFile:         func(string) ([]byte, error) { return []byte("1\n"), nil },
+		rpmOstreeVersion: func() ([]byte, error) { return []byte(rpmOstreeVersionOutput), nil },
+		stateDir:         filepath.Join(t.TempDir(), "machine-config-daemon"),
+	}
+}
+
+func findPrerequisite(checks PrerequisiteChecks, name string) *PrerequisiteCheck {
+	for i := range checks {
+		if checks[i].Name == name {
+			return &checks[i]
+		}
+	}
+	return nil
+}
+
+func TestCheckPrerequisites(t *testing.T) {
+	rhcos := OperatingSystem{ID: "rhcos", VersionID: "4.11"}
+
+	checks := newTestPrerequisiteChecker(t, rhcos).check()
+	require.NoError(t, checks.Err())
+	assert.Len(t, checks, 5)
+	assert.Equal(t, "version 2022.10", findPrerequisite(checks, "RpmOstree").Message)
+	assert.Equal(t, "enforcing", findPrerequisite(checks, "SELinux").Message)
+
+	// traditional RHEL nodes don't need rpm-ostree
+	p := newTestPrerequisiteChecker(t, OperatingSystem{ID: "rhel", VersionID: "8.6"})
+	p.lookPath = func(name string) (string, error) {
+		if name == "rpm-ostree" {
+			return "", fmt.Errorf("not found")
+		}
+		return "/usr/bin/" + name, nil
+	}
+	checks = p.check()
+	require.NoError(t, checks.Err())
+	assert.Nil(t, findPrerequisite(checks, "RpmOstree"))
+
+	p = newTestPrerequisiteChecker(t, OperatingSystem{ID: "ubuntu", VersionID: "22.04"})
+	assert.False(t, findPrerequisite(p.check(), "OperatingSystem").Passed)
+
+	p = newTestPrerequisiteChecker(t, rhcos)
+	p.lookPath = func(name string) (string, error) {
+		if name == "podman" || name == "chcon" {
+			return "", fmt.Errorf("not found")
+		}
+		return "/usr/bin/" + name, nil
+	}
+	p.rpmOstreeVersion = func() ([]byte, error) { return []byte("rpm-ostree:\n Version: '2019.3'\n"), nil }
+	p.readFile = func(string) ([]byte, error) { return nil, os.ErrNotExist }
+	checks = p.check()
+	err := checks.Err()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "RequiredBinaries: chcon, podman not found")
+	assert.Contains(t, err.Error(), "RpmOstree: version 2019.3 is older than 2020.7")
+	assert.Contains(t, err.Error(), "SELinux: SELinux is disabled")
+	assert.True(t, findPrerequisite(checks, "OperatingSystem").Passed)
+}
+
+func TestCheckRootMount(t *testing.T) {
+	p := newTestPrerequisiteChecker(t, OperatingSystem{})
+	assert.True(t, p.checkRootMount().Passed)
+	entries, err := os.ReadDir(p.stateDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+	p.stateDir = filepath.Join(file, "machine-config-daemon")
+	assert.False(t, p.checkRootMount().Passed)
+}
+
+func TestVersionOlder(t *testing.T) {
+	for _, c := range []struct {
+		a, b  string
+		older bool
+	}{
+		{"2020.7", "2020.7", false},
+		{"2020.6", "2020.7", true},
+		{"2020.10", "2020.7", false},
+		{"2019.12", "2020.7", true},
+		{"2022.10.86-gd8ef35fb", "2020.7", false},
+		{"2020", "2020.7", true},
+	} {
+		older, err := versionOlder(c.a, c.b)
+		require.NoError(t, err)
+		assert.Equal(t, c.older, older, "%s < %s", c.a, c.b)
+	}
+
+	_, err := versionOlder("latest", "2020.7")
+	assert.Error(t, err)
+}
@@ -0,0 +1,146 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// rebootNotificationTimeout bounds the request to the url of a PreRebootNotification.
+const rebootNotificationTimeout = 10 * time.Second
+
+// preRebootNotification returns the PreRebootNotification the node controller
+// copied from the pool of the node, or nil if there is none.
+func preRebootNotification(node *corev1.Node) (*mcfgv1.PreRebootNotification, error) {
+	if node == nil || node.Annotations[constants.PreRebootNotificationAnnotationKey] == "" {
+		return nil, nil
+	}
+	policy := &mcfgv1.PreRebootNotification{}
+	if err := json.Unmarshal([]byte(node.Annotations[constants.PreRebootNotificationAnnotationKey]), policy); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", constants.PreRebootNotificationAnnotationKey, err)
+	}
+	return policy, nil
+}
+
+// notifyBeforeReboot notifies the workloads of the node that it will be
+// drained and rebooted into configName, as configured by the pool of the
+// node, and waits for the lead time.  Notifications are best effort, so
+// failures are only reported as events.
+func (dn *Daemon) notifyBeforeReboot(configName string) {
+	if dn.kubeClient == nil {
+		return
+	}
+	policy, err := preRebootNotification(dn.node)
+	if err != nil {
+		glog.Warningf("Not notifying workloads before reboot: %v", err)
+		return
+	}
+	if policy == nil {
+		return
+	}
+
+	drainTime := metav1.NewTime(time.Now().Add(policy.LeadTime.Duration))
+	if policy.PodAnnotation != "" {
+		if err := annotateNodePods(dn.kubeClient, dn.node.Name, policy.PodAnnotation, drainTime.UTC().Format(time.RFC3339)); err != nil {
+			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "PreRebootNotificationFailed", "Failed to annotate pods: %v", err)
+		}
+	}
+	if policy.URL != "" {
+		event := mcfgv1.PreRebootNotificationEvent{Node: dn.node.Name, MachineConfig: configName, DrainTime: drainTime}
+		if err := postRebootNotification(policy.URL, event); err != nil {
+			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "PreRebootNotificationFailed", "Failed to notify %s: %v", policy.URL, err)
+		}
+	}
+	dn.logSystem("Notified workloads of the reboot into %s, waiting %v before draining", configName, policy.LeadTime.Duration)
+	dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "PreRebootNotification", "Notified workloads, draining at %s", drainTime.UTC().Format(time.RFC3339))
+	time.Sleep(policy.LeadTime.Duration)
+}
+
+// annotateNodePods sets an annotation on the running pods of a node.  Mirror
+// pods of static pods are skipped as their annotations can't be changed.
+func annotateNodePods(client kubernetes.Interface, nodeName, key, value string) error {
+	pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + nodeName,
+	})
+	if err != nil {
+		return fmt.Errorf("listing pods: %w", err)
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{key: value},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, pod := range pods.Items {
+		if _, mirror := pod.Annotations[corev1.MirrorPodAnnotationKey]; mirror {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if _, err := client.CoreV1().Pods(pod.Namespace).Patch(context.TODO(), pod.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			glog.Warningf("Failed to annotate pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			failed = append(failed, pod.Namespace+"/"+pod.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to annotate pods %v", failed)
+	}
+	return nil
+}
+
+// loopbackURL parses rawURL, which must be an http url on a loopback host.
+func loopbackURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" {
+		return nil, fmt.Errorf("unsupported scheme %q, must be http", u.Scheme)
+	}
+	if host := u.Hostname(); host != "localhost" {
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			return nil, fmt.Errorf("host %q is not a loopback address", host)
+		}
+	}
+	return u, nil
+}
+
+// postRebootNotification POSTs the event as JSON to a loopback http url.
+func postRebootNotification(rawURL string, event mcfgv1.PreRebootNotificationEvent) error {
+	u, err := loopbackURL(rawURL)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: rebootNotificationTimeout}
+	resp, err := client.Post(u.String(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
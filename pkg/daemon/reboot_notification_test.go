@@ -0,0 +1,88 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+func TestPreRebootNotification(t *testing.T) {
+	policy, err := preRebootNotification(&corev1.Node{})
+	require.NoError(t, err)
+	assert.Nil(t, policy)
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		constants.PreRebootNotificationAnnotationKey: `{"leadTime":"2m","url":"http://127.0.0.1:9090/reboot"}`,
+	}}}
+	policy, err = preRebootNotification(node)
+	require.NoError(t, err)
+	assert.Equal(t, &mcfgv1.PreRebootNotification{LeadTime: metav1.Duration{Duration: 2 * time.Minute}, URL: "http://127.0.0.1:9090/reboot"}, policy)
+
+	node.Annotations[constants.PreRebootNotificationAnnotationKey] = "{"
+	_, err = preRebootNotification(node)
+	assert.Error(t, err)
+}
+
+func TestAnnotateNodePods(t *testing.T) {
+	pod := func(name string, phase corev1.PodPhase, annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: name, Annotations: annotations},
+			Spec:       corev1.PodSpec{NodeName: "node-0"},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	client := k8sfake.NewSimpleClientset(
+		pod("running", corev1.PodRunning, nil),
+		pod("done", corev1.PodSucceeded, nil),
+		pod("static", corev1.PodRunning, map[string]string{corev1.MirrorPodAnnotationKey: "abc"}),
+	)
+
+	require.NoError(t, annotateNodePods(client, "node-0", "example.com/reboot", "2022-05-01T10:00:00Z"))
+
+	for name, annotated := range map[string]bool{"running": true, "done": false, "static": false} {
+		p, err := client.CoreV1().Pods("app").Get(context.TODO(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		_, ok := p.Annotations["example.com/reboot"]
+		assert.Equal(t, annotated, ok, name)
+	}
+}
+
+func TestPostRebootNotification(t *testing.T) {
+	var received mcfgv1.PreRebootNotificationEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	event := mcfgv1.PreRebootNotificationEvent{
+		Node:          "node-0",
+		MachineConfig: "rendered-worker-1",
+		DrainTime:     metav1.NewTime(time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)),
+	}
+	require.NoError(t, postRebootNotification(server.URL+"/reboot", event))
+	assert.Equal(t, event.MachineConfig, received.MachineConfig)
+	assert.True(t, event.DrainTime.Equal(&received.DrainTime))
+
+	for _, u := range []string{"https://127.0.0.1/reboot", "http://10.0.0.1/reboot", "http://example.com/reboot"} {
+		assert.Error(t, postRebootNotification(u, event), u)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	assert.Error(t, postRebootNotification(failing.URL, event))
+}
@@ -0,0 +1,89 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// RunReprovision re-applies a rendered MachineConfig to the node from
+// scratch, to repair a node whose files, units, SSH keys or kernel arguments
+// drifted from it.  Unlike an update, it doesn't diff against the config the
+// node is in: every file and unit of the config is rewritten, and units are
+// enabled or disabled again.  Kernel arguments of the config missing from the
+// deployment the node boots next are appended, so running it again, even
+// before rebooting, is a no-op besides rewriting the same contents.  The OS
+// image, extensions and kernel type are left alone.
+//
+// If mc is nil, the config the daemon last applied, stored on disk, is used.
+// Callers passing a config must make sure it is the current config of the
+// node, as it replaces the one on disk.  The node reboots afterwards unless
+// skipReboot is set.
+func (dn *Daemon) RunReprovision(mc *mcfgv1.MachineConfig, skipReboot bool) error {
+	if mc == nil {
+		var err error
+		if mc, err = dn.getCurrentConfigOnDisk(); err != nil {
+			return errors.Wrapf(err, "failed to read the current config from %s", dn.currentConfigPath)
+		}
+	}
+	ignConfig, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	if err != nil {
+		return fmt.Errorf("parsing Ignition config failed: %w", err)
+	}
+	var missingKargs []string
+	if len(mc.Spec.KernelArguments) > 0 && dn.os.IsCoreOSVariant() {
+		// compare with the kernel arguments of the pending deployment, which
+		// already has those appended by an earlier run with skipReboot
+		kargs, err := runGetOut("rpm-ostree", "kargs")
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the kernel arguments of the deployment")
+		}
+		missingKargs = missingKernelArguments(mc.Spec.KernelArguments, string(kargs))
+	}
+
+	dn.logSystem("Reprovisioning node from config %s", mc.GetName())
+	if err := dn.writeFilesAndUnits(ignConfig.Storage.Files, ignConfig.Systemd.Units); err != nil {
+		return err
+	}
+	if err := dn.updateSSHKeys(ignConfig.Passwd.Users); err != nil {
+		return err
+	}
+	if len(mc.Spec.KernelArguments) > 0 && !dn.os.IsCoreOSVariant() {
+		glog.Warningf("Not appending kernel arguments %v: updating kernel arguments on non-CoreOS nodes is not supported", mc.Spec.KernelArguments)
+	}
+	if len(missingKargs) > 0 {
+		args := []string{"kargs"}
+		for _, arg := range missingKargs {
+			args = append(args, "--append="+arg)
+		}
+		dn.logSystem("Running rpm-ostree %v", args)
+		if err := runRpmOstree(args...); err != nil {
+			return err
+		}
+	}
+	if err := dn.storeCurrentConfigOnDisk(mc); err != nil {
+		return err
+	}
+
+	dn.skipReboot = skipReboot
+	return dn.reboot(fmt.Sprintf("Node reprovisioned from config %s", mc.GetName()))
+}
+
+// missingKernelArguments returns the kernel arguments of a config that are
+// not in the given kernel arguments of a deployment.
+func missingKernelArguments(kernelArguments []string, deploymentKargs string) []string {
+	current := sets.NewString(strings.Fields(deploymentKargs)...)
+	missing := []string{}
+	for _, arg := range parseKernelArguments(kernelArguments) {
+		if !current.Has(arg) {
+			missing = append(missing, arg)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,17 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissingKernelArguments(t *testing.T) {
+	deploymentKargs := "rw nosmt hugepagesz=1G hugepages=2 ostree=/ostree/boot.1/rhcos/0\n"
+	kargs := []string{"nosmt", "hugepagesz=1G hugepages=2", "audit=1 quiet"}
+
+	assert.Equal(t, []string{"audit=1", "quiet"}, missingKernelArguments(kargs, deploymentKargs))
+	// once appended, nothing is missing
+	assert.Empty(t, missingKernelArguments(kargs, deploymentKargs+" audit=1 quiet"))
+	assert.Empty(t, missingKernelArguments(nil, deploymentKargs))
+}
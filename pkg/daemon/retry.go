@@ -0,0 +1,133 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	errors "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	// defaultTransientErrorRetries is how many times in a row we retry a sync
+	// failing with a transient error before marking the node Degraded.
+	defaultTransientErrorRetries = 5
+	// defaultTransientErrorBackoff is the delay before the first retry of a
+	// transient error; it doubles with every further attempt.
+	defaultTransientErrorBackoff = 10 * time.Second
+	// defaultMaxTransientErrorBackoff caps the delay between retries.
+	defaultMaxTransientErrorBackoff = 5 * time.Minute
+)
+
+// transientErrorMarkers are substrings of errors that are known to go away on
+// their own, e.g. a registry blip while pulling the OS image or rpm-ostree
+// being busy with another transaction.
+var transientErrorMarkers = []string{
+	"Transaction in progress",
+	"connection refused",
+	"connection reset by peer",
+	"i/o timeout",
+	"TLS handshake timeout",
+	"no such host",
+	"503 Service Unavailable",
+	"429 Too Many Requests",
+	"unexpected EOF",
+}
+
+// RetryPolicy controls how the daemon handles sync errors that are likely
+// transient: instead of immediately going Degraded it retries with an
+// exponential backoff until the retry budget is exhausted.
+type RetryPolicy struct {
+	// Budget is the number of consecutive retries allowed for transient errors.
+	// Zero means transient errors degrade the node right away.
+	Budget int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between retries.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy used unless overridden on the command line.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Budget:         defaultTransientErrorRetries,
+		InitialBackoff: defaultTransientErrorBackoff,
+		MaxBackoff:     defaultMaxTransientErrorBackoff,
+	}
+}
+
+// backoff returns the delay before the given retry attempt (starting at 1).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return delay
+}
+
+// isTransientError returns true if err is worth retrying before degrading the node.
+func isTransientError(err error) bool {
+	if err == nil || errors.Cause(err) == errUnreconcilable {
+		return false
+	}
+	// The probes were already retried until the validation timed out.
+	var validationErr *ctrlcommon.PostUpdateValidationError
+	if errors.As(err, &validationErr) {
+		return false
+	}
+	msg := err.Error()
+	for _, marker := range transientErrorMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryTransientError schedules a delayed retry of key if err is transient and
+// the retry budget isn't used up yet, recording the attempt on the node and in
+// its MachineConfigNode.
+// It returns false if the error should be reported as usual instead.
+func (dn *Daemon) retryTransientError(err error, key interface{}) bool {
+	if !isTransientError(err) || dn.transientErrorAttempts >= dn.retryPolicy.Budget {
+		return false
+	}
+	dn.transientErrorAttempts++
+	dn.lastTransientError = fmt.Sprintf("%.2000s", err.Error())
+	delay := dn.retryPolicy.backoff(dn.transientErrorAttempts)
+	glog.Warningf("Transient error syncing node (attempt %d of %d), retrying in %v: %v", dn.transientErrorAttempts, dn.retryPolicy.Budget, delay, err)
+	MCDTransientErrorRetries.Inc()
+	if dn.recorder != nil && dn.node != nil {
+		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "TransientError", "Retrying in %v (attempt %d of %d): %v", delay, dn.transientErrorAttempts, dn.retryPolicy.Budget, err)
+	}
+	if dn.nodeWriter != nil {
+		if werr := dn.nodeWriter.SetRetrying(err, dn.transientErrorAttempts, dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name); werr != nil {
+			glog.Errorf("Error recording retry attempt on node %s: %v", dn.name, werr)
+		}
+	}
+	dn.updateMachineConfigNode()
+	dn.queue.AddAfter(key, delay)
+	return true
+}
+
+// resetTransientErrorAttempts restores the retry budget once a sync succeeded
+// or its error was reported, clearing the attempts in the MachineConfigNode.
+// The node annotation is cleared by SetDone.
+func (dn *Daemon) resetTransientErrorAttempts() {
+	if dn.transientErrorAttempts == 0 {
+		return
+	}
+	dn.transientErrorAttempts = 0
+	dn.lastTransientError = ""
+	dn.updateMachineConfigNode()
+}
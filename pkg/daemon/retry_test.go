@@ -0,0 +1,98 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	errors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{Budget: 10, InitialBackoff: 10 * time.Second, MaxBackoff: time.Minute}
+	assert.Equal(t, 10*time.Second, p.backoff(1))
+	assert.Equal(t, 20*time.Second, p.backoff(2))
+	assert.Equal(t, 40*time.Second, p.backoff(3))
+	assert.Equal(t, time.Minute, p.backoff(4))
+	assert.Equal(t, time.Minute, p.backoff(100))
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		err       error
+		transient bool
+	}{
+		{nil, false},
+		{fmt.Errorf("failed to write file"), false},
+		{errors.Wrap(errUnreconcilable, "connection refused"), false},
+		{fmt.Errorf("error running rpm-ostree rebase: error: Transaction in progress: (null)"), true},
+		{fmt.Errorf("pulling image: dial tcp 10.0.0.1:5000: i/o timeout"), true},
+		{errors.Wrap(fmt.Errorf("read: connection reset by peer"), "extracting OS image"), true},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.transient, isTransientError(test.err), "%v", test.err)
+	}
+}
+
+func TestRetryTransientError(t *testing.T) {
+	dn := &Daemon{
+		retryPolicy: RetryPolicy{Budget: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+		queue:       workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+	}
+	defer dn.queue.ShutDown()
+
+	transient := fmt.Errorf("dial tcp: i/o timeout")
+	assert.False(t, dn.retryTransientError(fmt.Errorf("permanent"), "node"))
+	assert.True(t, dn.retryTransientError(transient, "node"))
+	assert.True(t, dn.retryTransientError(transient, "node"))
+	assert.Equal(t, 2, dn.transientErrorAttempts)
+	// The budget is used up, the error has to be reported.
+	assert.False(t, dn.retryTransientError(transient, "node"))
+
+	// The retry was queued after the backoff.
+	key, _ := dn.queue.Get()
+	assert.Equal(t, "node", key)
+
+	dn.retryPolicy.Budget = 0
+	dn.transientErrorAttempts = 0
+	assert.False(t, dn.retryTransientError(transient, "node"))
+}
+
+func TestRetryTransientErrorMachineConfigNode(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	dn := &Daemon{
+		retryPolicy:       RetryPolicy{Budget: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+		queue:             workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		mcfgClient:        client,
+		node:              &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-0"}},
+		NodeUpdaterClient: &deploymentsClientMock{deployments: testDeployments(t)},
+	}
+	defer dn.queue.ShutDown()
+	getStatus := func() mcfgv1.MachineConfigNodeStatus {
+		mcn, err := client.MachineconfigurationV1().MachineConfigNodes().Get(context.TODO(), "worker-0", metav1.GetOptions{})
+		require.NoError(t, err)
+		return mcn.Status
+	}
+
+	assert.True(t, dn.retryTransientError(fmt.Errorf("pulling image: dial tcp: i/o timeout"), "worker-0"))
+	assert.True(t, dn.retryTransientError(fmt.Errorf("rpm-ostree: Transaction in progress"), "worker-0"))
+	status := getStatus()
+	assert.Equal(t, int32(2), status.TransientErrorRetries)
+	assert.Equal(t, "rpm-ostree: Transaction in progress", status.LastTransientError)
+
+	// a successful sync clears the attempts
+	dn.handleErr(nil, "worker-0")
+	status = getStatus()
+	assert.Zero(t, status.TransientErrorRetries)
+	assert.Empty(t, status.LastTransientError)
+	assert.Zero(t, dn.transientErrorAttempts)
+}
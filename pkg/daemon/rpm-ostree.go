@@ -3,6 +3,7 @@ package daemon
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/containers/image/v5/types"
 	"github.com/golang/glog"
 	"github.com/opencontainers/go-digest"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	pivotutils "github.com/openshift/machine-config-operator/pkg/daemon/pivot/utils"
 	"github.com/pkg/errors"
 )
@@ -18,7 +20,7 @@ import (
 const (
 	// the number of times to retry commands that pull data from the network
 	numRetriesNetCommands = 5
-	// Pull secret.  Written by the machine-config-operator
+	// Pull secret.  Written by the machine-config-operator, default for ImageRuntimeOptions.AuthFile
 	kubeletAuthFile = "/var/lib/kubelet/config.json"
 )
 
@@ -36,11 +38,16 @@ type RpmOstreeDeployment struct {
 	OSName       string   `json:"osname"`
 	Serial       int32    `json:"serial"`
 	Checksum     string   `json:"checksum"`
+	BaseChecksum string   `json:"base-checksum"`
 	Version      string   `json:"version"`
 	Timestamp    uint64   `json:"timestamp"`
 	Booted       bool     `json:"booted"`
+	Staged       bool     `json:"staged"`
 	Origin       string   `json:"origin"`
 	CustomOrigin []string `json:"custom-origin"`
+	// Packages are the layered packages of the deployment.
+	Packages               []string `json:"packages"`
+	RequestedLocalPackages []string `json:"requested-local-packages"`
 }
 
 // imageInspection is a public implementation of
@@ -58,6 +65,28 @@ type imageInspection struct {
 	Layers        []string
 }
 
+// osImageURLAlias is the osImageURL a booted OSTree commit stands for after
+// an update to an image which only differed by its layered packages, e.g. an
+// extensions-only bump, skipped the rebase and left the custom origin of the
+// deployment unchanged.
+type osImageURLAlias struct {
+	// Checksum is the base checksum of the deployment.
+	Checksum string `json:"checksum"`
+	// Origin is the image URL of the custom origin of the deployment.
+	Origin string `json:"origin"`
+	// OSImageURL is the image URL the deployment stands for.
+	OSImageURL string `json:"osImageURL"`
+}
+
+// baseChecksum returns the checksum of the OSTree commit of the deployment,
+// without its layered packages.
+func (d *RpmOstreeDeployment) baseChecksum() string {
+	if d.BaseChecksum != "" {
+		return d.BaseChecksum
+	}
+	return d.Checksum
+}
+
 // NodeUpdaterClient is an interface describing how to interact with the host
 // around content deployment
 type NodeUpdaterClient interface {
@@ -66,6 +95,7 @@ type NodeUpdaterClient interface {
 	GetBootedOSImageURL() (string, string, error)
 	Rebase(string, string) (bool, error)
 	GetBootedDeployment() (*RpmOstreeDeployment, error)
+	GetDeployments() ([]RpmOstreeDeployment, error)
 }
 
 // RpmOstreeClient provides all RpmOstree related methods in one structure.
@@ -153,6 +183,15 @@ func (r *RpmOstreeClient) GetBootedDeployment() (*RpmOstreeDeployment, error) {
 	return nil, fmt.Errorf("not currently booted in a deployment")
 }
 
+// GetDeployments returns all the deployments of the node
+func (r *RpmOstreeClient) GetDeployments() ([]RpmOstreeDeployment, error) {
+	status, err := r.loadStatus()
+	if err != nil {
+		return nil, err
+	}
+	return status.Deployments, nil
+}
+
 // GetStatus returns multi-line human-readable text describing system status
 func (r *RpmOstreeClient) GetStatus() (string, error) {
 	output, err := runGetOut("rpm-ostree", "status")
@@ -172,27 +211,106 @@ func (r *RpmOstreeClient) GetBootedOSImageURL() (string, string, error) {
 		return "", "", err
 	}
 
+	alias, err := readOSImageURLAlias(constants.OSImageURLAliasPath)
+	if err != nil {
+		return "", "", err
+	}
+	return resolveOSImageURL(bootedDeployment, customOriginOSImageURL(bootedDeployment), alias), bootedDeployment.Version, nil
+}
+
+// customOriginOSImageURL returns the image URL of the custom origin of the
+// deployment, or the empty string if it wasn't rebased to an image.
+func customOriginOSImageURL(deployment *RpmOstreeDeployment) string {
 	// the canonical image URL is stored in the custom origin field.
-	osImageURL := ""
-	if len(bootedDeployment.CustomOrigin) > 0 {
-		if strings.HasPrefix(bootedDeployment.CustomOrigin[0], "pivot://") {
-			osImageURL = bootedDeployment.CustomOrigin[0][len("pivot://"):]
-		}
+	if len(deployment.CustomOrigin) > 0 && strings.HasPrefix(deployment.CustomOrigin[0], "pivot://") {
+		return deployment.CustomOrigin[0][len("pivot://"):]
 	}
+	return ""
+}
 
-	return osImageURL, bootedDeployment.Version, nil
+// resolveOSImageURL returns the image URL the deployment with the custom
+// origin osImageURL stands for: the one of the alias if it was recorded for
+// this deployment, osImageURL otherwise.
+func resolveOSImageURL(deployment *RpmOstreeDeployment, osImageURL string, alias *osImageURLAlias) string {
+	if alias == nil || osImageURL == "" {
+		return osImageURL
+	}
+	if alias.Checksum != deployment.baseChecksum() || alias.Origin != osImageURL {
+		// the alias is stale, the deployment was rebased since
+		return osImageURL
+	}
+	return alias.OSImageURL
+}
+
+// readOSImageURLAlias returns the alias recorded at path, or nil if none.
+func readOSImageURLAlias(path string) (*osImageURLAlias, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	alias := &osImageURLAlias{}
+	if err := json.Unmarshal(data, alias); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	return alias, nil
+}
+
+// writeOSImageURLAlias records at path that the deployment stands for imgURL.
+func writeOSImageURLAlias(path string, deployment *RpmOstreeDeployment, imgURL string) error {
+	origin := ""
+	if len(deployment.CustomOrigin) > 0 {
+		origin = strings.TrimPrefix(deployment.CustomOrigin[0], "pivot://")
+	}
+	data, err := json.Marshal(osImageURLAlias{
+		Checksum:   deployment.baseChecksum(),
+		Origin:     origin,
+		OSImageURL: imgURL,
+	})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomicallyWithDefaults(path, data)
+}
+
+// commitOSImageURLAlias makes the alias staged at pendingPath, if any, the
+// one recorded at path, once the update which staged it is committed.
+func commitOSImageURLAlias(pendingPath, path string) error {
+	if err := os.Rename(pendingPath, path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to commit %s", pendingPath)
+	}
+	return nil
+}
+
+// removePendingOSImageURLAlias discards the alias staged by an update which
+// skipped the rebase.
+func removePendingOSImageURLAlias() error {
+	if err := os.Remove(constants.PendingOSImageURLAliasPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// onlyLayeredPackagesDiffer returns true if rebasing the deployment to the
+// OSTree commit ostreeCsum of a new image would only change its layered
+// packages, e.g. for an image bumping only the extensions.  The deployment
+// must have been rebased to an image before, so it has a custom origin.
+func onlyLayeredPackagesDiffer(deployment *RpmOstreeDeployment, previousPivot, ostreeCsum string) bool {
+	return previousPivot != "" && ostreeCsum != "" && ostreeCsum == deployment.baseChecksum()
 }
 
 func podmanInspect(imgURL string) (imgdata *imageInspection, err error) {
 	// Pull the container image if not already available
 	var authArgs []string
-	if _, err := os.Stat(kubeletAuthFile); err == nil {
-		authArgs = append(authArgs, "--authfile", kubeletAuthFile)
+	if authFile := imageRuntime.authFile(); authFile != "" {
+		authArgs = append(authArgs, "--authfile", authFile)
 	}
 	args := []string{"pull", "-q"}
 	args = append(args, authArgs...)
 	args = append(args, imgURL)
-	_, err = pivotutils.RunExt(numRetriesNetCommands, "podman", args...)
+	_, err = pivotutils.RunExt(numRetriesNetCommands, "podman", imageRuntime.podmanArgs(args...)...)
 	if err != nil {
 		return
 	}
@@ -200,7 +318,7 @@ func podmanInspect(imgURL string) (imgdata *imageInspection, err error) {
 	inspectArgs := []string{"inspect", "--type=image"}
 	inspectArgs = append(inspectArgs, fmt.Sprintf("%s", imgURL))
 	var output []byte
-	output, err = runGetOut("podman", inspectArgs...)
+	output, err = runGetOut("podman", imageRuntime.podmanArgs(inspectArgs...)...)
 	if err != nil {
 		return
 	}
@@ -254,7 +372,7 @@ func (r *RpmOstreeClient) Rebase(imgURL, osImageContentDir string) (changed bool
 		ostreeVersion = imageData.Labels["version"]
 	}
 	// We may have pulled in OSContainer image as fallback during podmanCopy() or podmanInspect()
-	defer exec.Command("podman", "rmi", imgURL).Run()
+	defer exec.Command("podman", imageRuntime.podmanArgs("rmi", imgURL)...).Run()
 
 	repo := fmt.Sprintf("%s/srv/repo", osImageContentDir)
 
@@ -292,6 +410,18 @@ func (r *RpmOstreeClient) Rebase(imgURL, osImageContentDir string) (changed bool
 		}
 	}
 
+	if onlyLayeredPackagesDiffer(defaultDeployment, previousPivot, ostreeCsum) {
+		// The base OS is already deployed, a rebase would only redeploy it.  The
+		// layered packages are updated from the extensions repository of the new
+		// image by applyExtensions instead.
+		glog.Infof("Image %s has the booted OSTree commit %s, skipping rebase", imgURL, ostreeCsum)
+		err = writeOSImageURLAlias(constants.PendingOSImageURLAliasPath, defaultDeployment, imgURL)
+		return
+	}
+	if err = removePendingOSImageURLAlias(); err != nil {
+		return
+	}
+
 	// This will be what will be displayed in `rpm-ostree status` as the "origin spec"
 	customURL := fmt.Sprintf("pivot://%s", imgURL)
 	glog.Infof("Executing rebase from repo path %s with customImageURL %s and checksum %s", repo, customURL, ostreeCsum)
@@ -6,6 +6,14 @@ package daemon
  * client.
  */
 
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
 // GetBootedOSImageURLReturn is a structure used for testing. The fields correspond with the
 // return values in GetBootedOSImageURL implementations.
 type GetBootedOSImageURLReturn struct {
@@ -46,3 +54,68 @@ func (r RpmOstreeClientMock) GetStatus() (string, error) {
 func (r RpmOstreeClientMock) GetBootedDeployment() (*RpmOstreeDeployment, error) {
 	return &RpmOstreeDeployment{}, nil
 }
+
+func (r RpmOstreeClientMock) GetDeployments() ([]RpmOstreeDeployment, error) {
+	return []RpmOstreeDeployment{{Booted: true}}, nil
+}
+
+func TestOnlyLayeredPackagesDiffer(t *testing.T) {
+	layered := &RpmOstreeDeployment{Checksum: "layered", BaseChecksum: "base"}
+	assert.True(t, onlyLayeredPackagesDiffer(layered, "registry/os@sha256:old", "base"))
+	assert.False(t, onlyLayeredPackagesDiffer(layered, "registry/os@sha256:old", "layered"))
+	assert.False(t, onlyLayeredPackagesDiffer(layered, "registry/os@sha256:old", "new"))
+	// never rebased to an image, e.g. on first boot
+	assert.False(t, onlyLayeredPackagesDiffer(layered, "", "base"))
+
+	plain := &RpmOstreeDeployment{Checksum: "base"}
+	assert.True(t, onlyLayeredPackagesDiffer(plain, "registry/os@sha256:old", "base"))
+	assert.False(t, onlyLayeredPackagesDiffer(plain, "registry/os@sha256:old", ""))
+}
+
+func TestOSImageURLAlias(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "os-image-url-alias.json")
+	alias, err := readOSImageURLAlias(path)
+	require.NoError(t, err)
+	assert.Nil(t, alias)
+
+	deployment := &RpmOstreeDeployment{
+		Checksum:     "layered",
+		BaseChecksum: "base",
+		CustomOrigin: []string{"pivot://registry/os@sha256:old", "Managed by machine-config-operator"},
+	}
+	assert.Equal(t, "registry/os@sha256:old", resolveOSImageURL(deployment, "registry/os@sha256:old", alias))
+
+	require.NoError(t, writeOSImageURLAlias(path, deployment, "registry/os@sha256:new"))
+	alias, err = readOSImageURLAlias(path)
+	require.NoError(t, err)
+	assert.Equal(t, &osImageURLAlias{Checksum: "base", Origin: "registry/os@sha256:old", OSImageURL: "registry/os@sha256:new"}, alias)
+	assert.Equal(t, "registry/os@sha256:new", resolveOSImageURL(deployment, "registry/os@sha256:old", alias))
+
+	// the deployment was rebased since
+	rebased := &RpmOstreeDeployment{Checksum: "other", CustomOrigin: []string{"pivot://registry/os@sha256:other"}}
+	assert.Equal(t, "registry/os@sha256:other", resolveOSImageURL(rebased, "registry/os@sha256:other", alias))
+	rebased = &RpmOstreeDeployment{Checksum: "other", CustomOrigin: []string{"pivot://registry/os@sha256:old"}}
+	assert.Equal(t, "registry/os@sha256:old", resolveOSImageURL(rebased, "registry/os@sha256:old", alias))
+}
+
+func TestCommitOSImageURLAlias(t *testing.T) {
+	dir := t.TempDir()
+	pending := filepath.Join(dir, "os-image-url-alias-pending.json")
+	path := filepath.Join(dir, "os-image-url-alias.json")
+
+	// nothing staged
+	require.NoError(t, commitOSImageURLAlias(pending, path))
+	alias, err := readOSImageURLAlias(path)
+	require.NoError(t, err)
+	assert.Nil(t, alias)
+
+	deployment := &RpmOstreeDeployment{BaseChecksum: "base", CustomOrigin: []string{"pivot://registry/os@sha256:old"}}
+	require.NoError(t, writeOSImageURLAlias(pending, deployment, "registry/os@sha256:new"))
+	require.NoError(t, commitOSImageURLAlias(pending, path))
+	alias, err = readOSImageURLAlias(path)
+	require.NoError(t, err)
+	assert.Equal(t, "registry/os@sha256:new", alias.OSImageURL)
+	alias, err = readOSImageURLAlias(pending)
+	require.NoError(t, err)
+	assert.Nil(t, alias)
+}
@@ -1,7 +1,6 @@
 package daemon
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
 	"io/ioutil"
@@ -18,7 +17,6 @@ import (
 	"github.com/clarketm/json"
 	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
 	"github.com/golang/glog"
-	"github.com/google/renameio"
 	errors "github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,15 +26,16 @@ import (
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/pkg/daemon/filewriter"
 	pivottypes "github.com/openshift/machine-config-operator/pkg/daemon/pivot/types"
 	pivotutils "github.com/openshift/machine-config-operator/pkg/daemon/pivot/utils"
 )
 
 const (
 	// defaultDirectoryPermissions houses the default mode to use when no directory permissions are provided
-	defaultDirectoryPermissions os.FileMode = 0755
+	defaultDirectoryPermissions = filewriter.DefaultDirectoryPermissions
 	// defaultFilePermissions houses the default mode to use when no file permissions are provided
-	defaultFilePermissions os.FileMode = 0644
+	defaultFilePermissions = filewriter.DefaultFilePermissions
 	// SSH Keys for user "core" will only be written at /home/core/.ssh
 	coreUserSSHPath = "/home/core/.ssh/"
 	// fipsFile is the file to check if FIPS is enabled
@@ -50,9 +49,19 @@ const (
 	postConfigChangeActionNone = "none"
 	// The "reload crio" action will run "systemctl reload crio"
 	postConfigChangeActionReloadCrio = "reload crio"
+	// The "restart crio" action will run "systemctl restart crio", for crio options that can't be reloaded
+	postConfigChangeActionRestartCrio = "restart crio"
+	// The "restart kubelet" action will run "systemctl restart kubelet", for kubelet config changes
+	// that are safe to apply without a reboot
+	postConfigChangeActionRestartKubelet = "restart kubelet"
+	// The "reload multipathd" action will run "systemctl reload multipathd", for changes to multipath.conf
+	postConfigChangeActionReloadMultipathd = "reload multipathd"
 	// Rebooting is still the default scenario for any other change
 	postConfigChangeActionReboot = "reboot"
 
+	// kubeletStateBackupSuffix is appended to the kubelet resource manager checkpoints moved aside by resetKubeletState
+	kubeletStateBackupSuffix = ".mcd-backup"
+
 	// GPGNoRebootPath is the path MCO expects will contain GPG key updates. MCO will attempt to only reload crio for
 	// changes to this path. Note that other files added to the parent directory will not be handled specially
 	GPGNoRebootPath = "/etc/machine-config-daemon/no-reboot/containers-gpg.pub"
@@ -61,41 +70,16 @@ const (
 var (
 	origParentDirPath   = filepath.Join("/etc", "machine-config-daemon", "orig")
 	noOrigParentDirPath = filepath.Join("/etc", "machine-config-daemon", "noorig")
+	// fileTransactionDirPath holds the rollback journal of the file and unit writes currently being applied
+	fileTransactionDirPath = filepath.Join("/etc", "machine-config-daemon", "transaction")
 )
 
 func writeFileAtomicallyWithDefaults(fpath string, b []byte) error {
-	return writeFileAtomically(fpath, b, defaultDirectoryPermissions, defaultFilePermissions, -1, -1)
+	return filewriter.WriteFileAtomicallyWithDefaults(fpath, b)
 }
 
-// writeFileAtomically uses the renameio package to provide atomic file writing, we can't use renameio.WriteFile
-// directly since we need to 1) Chown 2) go through a buffer since files provided can be big
 func writeFileAtomically(fpath string, b []byte, dirMode, fileMode os.FileMode, uid, gid int) error {
-	dir := filepath.Dir(fpath)
-	if err := os.MkdirAll(dir, dirMode); err != nil {
-		return fmt.Errorf("failed to create directory %q: %v", dir, err)
-	}
-	t, err := renameio.TempFile(dir, fpath)
-	if err != nil {
-		return err
-	}
-	defer t.Cleanup()
-	// Set permissions before writing data, in case the data is sensitive.
-	if err := t.Chmod(fileMode); err != nil {
-		return err
-	}
-	w := bufio.NewWriter(t)
-	if _, err := w.Write(b); err != nil {
-		return err
-	}
-	if err := w.Flush(); err != nil {
-		return err
-	}
-	if uid != -1 && gid != -1 {
-		if err := t.Chown(uid, gid); err != nil {
-			return err
-		}
-	}
-	return t.CloseAtomicallyReplace()
+	return filewriter.WriteFileAtomically(fpath, b, dirMode, fileMode, uid, gid)
 }
 
 func getNodeRef(node *corev1.Node) *corev1.ObjectReference {
@@ -106,17 +90,87 @@ func getNodeRef(node *corev1.Node) *corev1.ObjectReference {
 	}
 }
 
-func reloadService(name string) error {
-	return runCmdSync("systemctl", "reload", name)
+// runServiceAction runs a reload or restart post config change action, e.g.
+// "restart crio" runs "systemctl restart crio".
+var runServiceAction = func(action string) error {
+	return runCmdSync("systemctl", strings.Fields(action)...)
+}
+
+// applyServiceActions reloads or restarts the services the actions ask for
+// in postConfigChangeActionOrder, so that a service only picks up the new
+// config once the services it depends on did.  It stops at the first failure
+// and returns the actions it ran, including the failed one, for
+// rollbackServiceActions to run again once the files are rolled back.
+func (dn *Daemon) applyServiceActions(actions []string, configName string) ([]string, error) {
+	applied := []string{}
+	for _, action := range postConfigChangeActionOrder {
+		if action == postConfigChangeActionNone || !ctrlcommon.InSlice(action, actions) {
+			continue
+		}
+		applied = append(applied, action)
+		verb, serviceName := splitServiceAction(action)
+
+		if err := runServiceAction(action); err != nil {
+			if dn.recorder != nil {
+				dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "FailedService"+strings.Title(verb), fmt.Sprintf("%s %s service failed. Error: %v", strings.Title(verb)+"ing", serviceName, err))
+			}
+			return applied, fmt.Errorf("Could not apply update: %sing %s failed. Error: %v", verb, serviceName, err)
+		}
+
+		if dn.recorder != nil {
+			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "SkipReboot", "Config changes do not require reboot. Service %s was %sed.", serviceName, verb)
+		}
+		dn.logSystem("%s %sed successfully! Desired config %s has been applied, skipping reboot", serviceName, verb, configName)
+	}
+	return applied, nil
+}
+
+// rollbackServiceActions runs the service actions of a failed update again,
+// in postConfigChangeActionOrder, once its files are rolled back so that the
+// services run with the same config again.  Unlike applyServiceActions it
+// doesn't stop at the first failure.
+func (dn *Daemon) rollbackServiceActions(actions []string, configName string) error {
+	failed := []string{}
+	for _, action := range postConfigChangeActionOrder {
+		if action == postConfigChangeActionNone || !ctrlcommon.InSlice(action, actions) {
+			continue
+		}
+		if err := runServiceAction(action); err != nil {
+			failed = append(failed, err.Error())
+			continue
+		}
+		verb, serviceName := splitServiceAction(action)
+		dn.logSystem("%s %sed to roll back to config %s", serviceName, verb, configName)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s", strings.Join(failed, "; "))
+	}
+	if dn.recorder != nil {
+		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "ServicesRolledBack", "Services %v rolled back to config %s", actions, configName)
+	}
+	return nil
+}
+
+// splitServiceAction returns the systemctl verb and the service of a reload
+// or restart post config change action.
+func splitServiceAction(action string) (string, string) {
+	fields := strings.Fields(action)
+	return fields[0], fields[1]
 }
 
 // performPostConfigChangeAction takes action based on what postConfigChangeAction has been asked.
-// For non-reboot action, it applies configuration, updates node's config and state.
+// For non-reboot action, the services were reloaded or restarted by applyServiceActions already,
+// it updates node's config and state.
 // In the end uncordon node to schedule workload.
-// If at any point an error occurs, we reboot the node so that node has correct configuration.
 func (dn *Daemon) performPostConfigChangeAction(postConfigChangeActions []string, configName string) error {
 	if ctrlcommon.InSlice(postConfigChangeActionReboot, postConfigChangeActions) {
 		dn.logSystem("Rebooting node")
+		if err := dn.armBootCounter(configName); err != nil {
+			// the update itself is fine, the node just won't fall back if it fails to boot
+			dn.logSystem("Failed to arm the boot counter: %v", err)
+		}
+		health.setPendingReboot(configName)
+		dn.updateMachineConfigNode()
 		return dn.reboot(fmt.Sprintf("Node will reboot into config %s", configName))
 	}
 
@@ -127,22 +181,6 @@ func (dn *Daemon) performPostConfigChangeAction(postConfigChangeActions []string
 		dn.logSystem("Node has Desired Config %s, skipping reboot", configName)
 	}
 
-	if ctrlcommon.InSlice(postConfigChangeActionReloadCrio, postConfigChangeActions) {
-		serviceName := "crio"
-
-		if err := reloadService(serviceName); err != nil {
-			if dn.recorder != nil {
-				dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "FailedServiceReload", fmt.Sprintf("Reloading %s service failed. Error: %v", serviceName, err))
-			}
-			return fmt.Errorf("Could not apply update: reloading %s configuration failed. Error: %v", serviceName, err)
-		}
-
-		if dn.recorder != nil {
-			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "SkipReboot", "Config changes do not require reboot. Service %s was reloaded.", serviceName)
-		}
-		dn.logSystem("%s config reloaded successfully! Desired config %s has been applied, skipping reboot", serviceName, configName)
-	}
-
 	// We are here, which means reboot was not needed to apply the configuration.
 
 	// Get current state of node, in case of an error reboot
@@ -240,8 +278,8 @@ func addExtensionsRepo(osImageContentDir string) error {
 // podmanRemove kills and removes a container
 func podmanRemove(cid string) {
 	// Ignore errors here
-	exec.Command("podman", "kill", cid).Run()
-	exec.Command("podman", "rm", "-f", cid).Run()
+	exec.Command("podman", imageRuntime.podmanArgs("kill", cid)...).Run()
+	exec.Command("podman", imageRuntime.podmanArgs("rm", "-f", cid)...).Run()
 }
 
 func podmanCopy(imgURL, osImageContentDir string) (err error) {
@@ -250,13 +288,13 @@ func podmanCopy(imgURL, osImageContentDir string) (err error) {
 
 	// Pull the container image
 	var authArgs []string
-	if _, err := os.Stat(kubeletAuthFile); err == nil {
-		authArgs = append(authArgs, "--authfile", kubeletAuthFile)
+	if authFile := imageRuntime.authFile(); authFile != "" {
+		authArgs = append(authArgs, "--authfile", authFile)
 	}
 	args := []string{"pull", "-q"}
 	args = append(args, authArgs...)
 	args = append(args, imgURL)
-	_, err = pivotutils.RunExtBackground(numRetriesNetCommands, "podman", args...)
+	_, err = pivotutils.RunExtBackground(numRetriesNetCommands, "podman", imageRuntime.podmanArgs(args...)...)
 	if err != nil {
 		return
 	}
@@ -264,7 +302,7 @@ func podmanCopy(imgURL, osImageContentDir string) (err error) {
 	// create a container
 	var cidBuf []byte
 	containerName := pivottypes.PivotNamePrefix + string(uuid.NewUUID())
-	cidBuf, err = runGetOut("podman", "create", "--net=none", "--annotation=org.openshift.machineconfigoperator.pivot=true", "--name", containerName, imgURL)
+	cidBuf, err = runGetOut("podman", imageRuntime.podmanArgs("create", "--net=none", "--annotation=org.openshift.machineconfigoperator.pivot=true", "--name", containerName, imgURL)...)
 	if err != nil {
 		return
 	}
@@ -275,7 +313,7 @@ func podmanCopy(imgURL, osImageContentDir string) (err error) {
 	// copy the content from create container locally into a temp directory under /run/machine-os-content/
 	cid := strings.TrimSpace(string(cidBuf))
 	args = []string{"cp", fmt.Sprintf("%s:/", cid), osImageContentDir}
-	_, err = pivotutils.RunExtBackground(numRetriesNetCommands, "podman", args...)
+	_, err = pivotutils.RunExtBackground(numRetriesNetCommands, "podman", imageRuntime.podmanArgs(args...)...)
 	if err != nil {
 		return
 	}
@@ -296,8 +334,8 @@ func podmanCopy(imgURL, osImageContentDir string) (err error) {
 // into the container. See the MCD daemonset.
 func ExtractOSImage(imgURL string) (osImageContentDir string, err error) {
 	var registryConfig []string
-	if _, err := os.Stat(kubeletAuthFile); err == nil {
-		registryConfig = append(registryConfig, "--registry-config", kubeletAuthFile)
+	if authFile := imageRuntime.authFile(); authFile != "" {
+		registryConfig = append(registryConfig, "--registry-config", authFile)
 	}
 	if err = os.MkdirAll(osImageContentBaseDir, 0755); err != nil {
 		err = fmt.Errorf("error creating directory %s: %v", osImageContentBaseDir, err)
@@ -393,6 +431,10 @@ func (dn *CoreOSDaemon) applyOSChanges(mcDiff machineConfigDiff, oldConfig, newC
 				retErr = errors.Wrapf(retErr, "error removing staged deployment: %v", err)
 				return
 			}
+			if err := removePendingOSImageURLAlias(); err != nil {
+				retErr = errors.Wrapf(retErr, "error removing staged osImageURL alias: %v", err)
+				return
+			}
 		}
 	}()
 
@@ -420,32 +462,23 @@ func (dn *CoreOSDaemon) applyOSChanges(mcDiff machineConfigDiff, oldConfig, newC
 
 }
 
-func calculatePostConfigChangeActionFromFileDiffs(diffFileSet []string) (actions []string) {
-	filesPostConfigChangeActionNone := []string{
-		"/etc/kubernetes/kubelet-ca.crt",
-		"/var/lib/kubelet/config.json",
-	}
-	filesPostConfigChangeActionReloadCrio := []string{
-		constants.ContainerRegistryConfPath,
-		GPGNoRebootPath,
-		"/etc/containers/policy.json",
-	}
-
-	actions = []string{postConfigChangeActionNone}
+func calculatePostConfigChangeActionFromFileDiffs(oldIgnConfig, newIgnConfig *ign3types.Config, diffFileSet []string) ([]string, error) {
+	actions := map[string]bool{}
 	for _, path := range diffFileSet {
-		if ctrlcommon.InSlice(path, filesPostConfigChangeActionNone) {
-			continue
-		} else if ctrlcommon.InSlice(path, filesPostConfigChangeActionReloadCrio) {
-			actions = []string{postConfigChangeActionReloadCrio}
-		} else {
-			actions = []string{postConfigChangeActionReboot}
-			return
+		action, err := classifyFileChange(oldIgnConfig, newIgnConfig, path)
+		if err != nil {
+			return []string{}, err
 		}
+		if action == postConfigChangeActionReboot {
+			glog.Infof("Change to %s requires a reboot", path)
+			return []string{postConfigChangeActionReboot}, nil
+		}
+		actions[action] = true
 	}
-	return
+	return reducePostConfigChangeActions(actions), nil
 }
 
-func calculatePostConfigChangeAction(diff *machineConfigDiff, diffFileSet []string) ([]string, error) {
+func calculatePostConfigChangeAction(diff *machineConfigDiff, oldIgnConfig, newIgnConfig *ign3types.Config, diffFileSet []string) ([]string, error) {
 	// If a machine-config-daemon-force file is present, it means the user wants to
 	// move to desired state without additional validation. We will reboot the node in
 	// this case regardless of what MachineConfig diff is.
@@ -463,7 +496,7 @@ func calculatePostConfigChangeAction(diff *machineConfigDiff, diffFileSet []stri
 	}
 
 	// We don't actually have to consider ssh keys changes, which is the only section of passwd that is allowed to change
-	return calculatePostConfigChangeActionFromFileDiffs(diffFileSet), nil
+	return calculatePostConfigChangeActionFromFileDiffs(oldIgnConfig, newIgnConfig, diffFileSet)
 }
 
 // update the node to the provided node configuration.
@@ -519,14 +552,39 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig) (retErr err
 		return errors.Wrapf(errUnreconcilable, "%v", wrappedErr)
 	}
 
+	// The kubelet the node runs after the update is only known when the OS
+	// stays the same.  Check it before draining so that a refused config
+	// leaves the node as it is.
+	if !diff.osUpdate && dn.node != nil && dn.node.Status.NodeInfo.KubeletVersion != "" {
+		if err := checkKubeletCompatibility(&oldIgnConfig, &newIgnConfig, newConfigName, dn.node.Status.NodeInfo.KubeletVersion); err != nil {
+			return err
+		}
+	}
+
 	dn.logSystem("Starting update from %s to %s: %+v", oldConfigName, newConfigName, diff)
 
 	diffFileSet := ctrlcommon.CalculateConfigFileDiffs(&oldIgnConfig, &newIgnConfig)
-	actions, err := calculatePostConfigChangeAction(diff, diffFileSet)
+	actions, err := calculatePostConfigChangeAction(diff, &oldIgnConfig, &newIgnConfig, diffFileSet)
 	if err != nil {
 		return err
 	}
 
+	// The CRI-O drop-ins that aren't part of the config mustn't override its
+	// settings.  They're quarantined once the files are written, and CRI-O
+	// restarted without them.
+	foreignCrioFragments, err := scanCrioFragments(crioDropInDir, &newIgnConfig, &oldIgnConfig)
+	if err != nil {
+		return fmt.Errorf("scanning the CRI-O drop-ins: %w", err)
+	}
+	if overridingCrioFragments(foreignCrioFragments) {
+		actions = addPostConfigChangeAction(actions, postConfigChangeActionRestartCrio)
+	}
+
+	// Give the workloads some notice before draining the node for a reboot
+	if ctrlcommon.InSlice(postConfigChangeActionReboot, actions) {
+		dn.notifyBeforeReboot(newConfigName)
+	}
+
 	// Check and perform node drain if required
 	drain, err := isDrainRequired(actions, diffFileSet, oldIgnConfig, newIgnConfig)
 	if err != nil {
@@ -540,6 +598,18 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig) (retErr err
 		glog.Info("Changes do not require drain, skipping.")
 	}
 
+	// The services reloaded or restarted for the update run with the old files
+	// again if it fails.  Deferred first so that it runs after the rollbacks below.
+	serviceActions := []string{}
+	defer func() {
+		if retErr != nil && len(serviceActions) > 0 {
+			if err := dn.rollbackServiceActions(serviceActions, oldConfigName); err != nil {
+				retErr = errors.Wrapf(retErr, "error rolling back service actions %v", err)
+				return
+			}
+		}
+	}()
+
 	// update files on disk that need updating
 	if err := dn.updateFiles(oldIgnConfig, newIgnConfig); err != nil {
 		return err
@@ -554,6 +624,21 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig) (retErr err
 		}
 	}()
 
+	restoreCrioFragments, err := quarantineCrioFragments(foreignCrioFragments, crioQuarantineDir)
+	defer func() {
+		if retErr != nil {
+			if err := restoreCrioFragments(); err != nil {
+				retErr = errors.Wrapf(retErr, "error rolling back the quarantine of CRI-O drop-ins %v", err)
+				return
+			}
+		}
+	}()
+	if err != nil {
+		return err
+	}
+	dn.logForeignCrioFragments(foreignCrioFragments)
+	dn.foreignCrioFragments = foreignCrioFragments
+
 	if err := dn.updateSSHKeys(newIgnConfig.Passwd.Users); err != nil {
 		return err
 	}
@@ -567,10 +652,26 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig) (retErr err
 		}
 	}()
 
+	if dn.os.IsCoreOSVariant() {
+		restoreBootloader, err := updateBootloader(oldIgnConfig, newIgnConfig)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			if retErr != nil {
+				if err := restoreBootloader(); err != nil {
+					retErr = errors.Wrapf(retErr, "error rolling back bootloader password %v", err)
+					return
+				}
+			}
+		}()
+	}
+
 	if dn.os.IsCoreOSVariant() {
 		coreOSDaemon := CoreOSDaemon{dn}
 		if err := coreOSDaemon.applyOSChanges(*diff, oldConfig, newConfig); err != nil {
-			return err
+			return &ctrlcommon.OSUpdateError{Err: err}
 		}
 
 		defer func() {
@@ -607,9 +708,68 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig) (retErr err
 		return err
 	}
 
+	staleKubeletState, err := staleKubeletStateFiles(&oldIgnConfig, &newIgnConfig)
+	if err != nil {
+		return err
+	}
+	if len(staleKubeletState) > 0 {
+		if err := dn.resetKubeletState(staleKubeletState); err != nil {
+			return err
+		}
+		// the kubelet is stopped, start it again if the update fails
+		serviceActions = append(serviceActions, postConfigChangeActionRestartKubelet)
+	}
+
+	applied, err := dn.applyServiceActions(actions, newConfigName)
+	serviceActions = append(serviceActions, applied...)
+	if err != nil {
+		return err
+	}
+
 	return dn.performPostConfigChangeAction(actions, newConfig.GetName())
 }
 
+// resetKubeletState stops the kubelet and moves the given resource manager
+// checkpoints aside to <path>.mcd-backup, so that the kubelet starts with the
+// new config instead of crash looping on a checkpoint that doesn't match it.
+// The kubelet is started again by the post config change action, either a
+// kubelet restart or a reboot.  If moving a checkpoint fails, the ones already
+// moved are restored and the kubelet is started again.
+func (dn *Daemon) resetKubeletState(stateFiles []string) (retErr error) {
+	dn.logSystem("Stopping kubelet to reset its state %v", stateFiles)
+	if err := runCmdSync("systemctl", "stop", "kubelet"); err != nil {
+		return errors.Wrap(err, "stopping kubelet")
+	}
+	moved := []string{}
+	defer func() {
+		if retErr == nil {
+			return
+		}
+		for _, path := range moved {
+			if err := os.Rename(path+kubeletStateBackupSuffix, path); err != nil {
+				glog.Errorf("Restoring kubelet state %s: %v", path, err)
+			}
+		}
+		if err := runCmdSync("systemctl", "start", "kubelet"); err != nil {
+			retErr = errors.Wrapf(retErr, "error starting kubelet again: %v", err)
+		}
+	}()
+	for _, path := range stateFiles {
+		if err := os.Rename(path, path+kubeletStateBackupSuffix); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return errors.Wrapf(err, "backing up kubelet state %s", path)
+		}
+		moved = append(moved, path)
+		glog.Infof("Moved kubelet state %s to %s%s", path, path, kubeletStateBackupSuffix)
+	}
+	if dn.recorder != nil {
+		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "KubeletStateReset", "Reset kubelet state %v invalidated by the kubelet config change", stateFiles)
+	}
+	return nil
+}
+
 // machineConfigDiff represents an ad-hoc difference between two MachineConfig objects.
 // At some point this may change into holding just the files/units that changed
 // and the MCO would just operate on that.  For now we're just doing this to get
@@ -964,7 +1124,7 @@ func (dn *Daemon) generateExtensionsArgs(oldConfig, newConfig *mcfgv1.MachineCon
 	extArgs := []string{"update"}
 
 	if dn.os.IsRHCOS() {
-		extensions := getSupportedExtensions()
+		extensions := ctrlcommon.SupportedExtensions()
 		for _, ext := range added {
 			for _, pkg := range extensions[ext] {
 				extArgs = append(extArgs, "--install", pkg)
@@ -994,23 +1154,8 @@ func (dn *Daemon) generateExtensionsArgs(oldConfig, newConfig *mcfgv1.MachineCon
 	return extArgs
 }
 
-// Returns list of extensions possible to install on a CoreOS based system.
-func getSupportedExtensions() map[string][]string {
-	// In future when list of extensions grow, it will make
-	// more sense to populate it in a dynamic way.
-
-	// These are RHCOS supported extensions.
-	// Each extension keeps a list of packages required to get enabled on host.
-	return map[string][]string{
-		"usbguard":             {"usbguard"},
-		"kerberos":             {"krb5-workstation", "libkadm5"},
-		"kernel-devel":         {"kernel-devel", "kernel-headers"},
-		"sandboxed-containers": {"kata-containers"},
-	}
-}
-
 func validateExtensions(exts []string) error {
-	supportedExtensions := getSupportedExtensions()
+	supportedExtensions := ctrlcommon.SupportedExtensions()
 	invalidExts := []string{}
 	for _, ext := range exts {
 		if _, ok := supportedExtensions[ext]; !ok {
@@ -1112,15 +1257,57 @@ func (dn *CoreOSDaemon) switchKernel(oldConfig, newConfig *mcfgv1.MachineConfig)
 // touched.
 func (dn *Daemon) updateFiles(oldIgnConfig, newIgnConfig ign3types.Config) error {
 	glog.Info("Updating files")
-	if err := dn.writeFiles(newIgnConfig.Storage.Files); err != nil {
+	if err := dn.writeFilesAndUnits(newIgnConfig.Storage.Files, newIgnConfig.Systemd.Units); err != nil {
 		return err
 	}
-	if err := dn.writeUnits(newIgnConfig.Systemd.Units); err != nil {
+	if err := dn.deleteStaleData(oldIgnConfig, newIgnConfig); err != nil {
 		return err
 	}
-	if err := dn.deleteStaleData(oldIgnConfig, newIgnConfig); err != nil {
+	return nil
+}
+
+// writeFilesAndUnits writes files and units within a single file transaction, so
+// that either all of them end up on disk or, on failure or if the daemon is killed
+// midway, none of them do.
+func (dn *Daemon) writeFilesAndUnits(files []ign3types.File, units []ign3types.Unit) (retErr error) {
+	tx, err := beginFileTransaction()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			if err := tx.Rollback(); err != nil {
+				retErr = errors.Wrapf(retErr, "error rolling back file transaction %v", err)
+			}
+		}
+	}()
+	if err := dn.writeFiles(tx, files); err != nil {
 		return err
 	}
+	if err := dn.writeUnits(tx, units); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// beginFileTransaction starts a new file transaction, first rolling back any
+// transaction left unfinished by a previous daemon instance.
+func beginFileTransaction() (*filewriter.Transaction, error) {
+	if err := recoverFileTransaction(); err != nil {
+		return nil, err
+	}
+	return filewriter.Begin(fileTransactionDirPath)
+}
+
+// recoverFileTransaction rolls back file writes of an update that was interrupted midway.
+func recoverFileTransaction() error {
+	found, err := filewriter.Recover(fileTransactionDirPath)
+	if err != nil {
+		return errors.Wrapf(err, "recovering interrupted file transaction")
+	}
+	if found {
+		glog.Info("Rolled back file writes of an interrupted update")
+	}
 	return nil
 }
 
@@ -1385,7 +1572,7 @@ func (dn *Daemon) presetUnit(unit ign3types.Unit) error {
 }
 
 // write dropins to disk
-func (dn *Daemon) writeDropins(u ign3types.Unit) error {
+func (dn *Daemon) writeDropins(tx *filewriter.Transaction, u ign3types.Unit) error {
 	for i := range u.Dropins {
 		dpath := filepath.Join(pathSystemd, u.Name+".d", u.Dropins[i].Name)
 		if u.Dropins[i].Contents == nil || *u.Dropins[i].Contents == "" {
@@ -1397,7 +1584,7 @@ func (dn *Daemon) writeDropins(u ign3types.Unit) error {
 				return err
 			}
 			glog.Infof("Removing %q, updated file has zero length", dpath)
-			if err := os.Remove(dpath); err != nil {
+			if err := tx.Remove(dpath); err != nil {
 				return err
 			}
 			continue
@@ -1410,7 +1597,7 @@ func (dn *Daemon) writeDropins(u ign3types.Unit) error {
 				return err
 			}
 		}
-		if err := writeFileAtomicallyWithDefaults(dpath, []byte(*u.Dropins[i].Contents)); err != nil {
+		if err := tx.WriteFileWithDefaults(dpath, []byte(*u.Dropins[i].Contents)); err != nil {
 			return fmt.Errorf("failed to write systemd unit dropin %q: %v", u.Dropins[i].Name, err)
 		}
 
@@ -1420,11 +1607,11 @@ func (dn *Daemon) writeDropins(u ign3types.Unit) error {
 }
 
 // writeUnits writes the systemd units to disk
-func (dn *Daemon) writeUnits(units []ign3types.Unit) error {
+func (dn *Daemon) writeUnits(tx *filewriter.Transaction, units []ign3types.Unit) error {
 	var enabledUnits []string
 	var disabledUnits []string
 	for _, u := range units {
-		if err := dn.writeDropins(u); err != nil {
+		if err := dn.writeDropins(tx, u); err != nil {
 			return err
 		}
 
@@ -1434,12 +1621,12 @@ func (dn *Daemon) writeUnits(units []ign3types.Unit) error {
 			// if the unit is masked, symlink fpath to /dev/null and continue
 
 			glog.V(2).Info("Systemd unit masked")
-			if err := os.RemoveAll(fpath); err != nil {
+			if err := tx.Remove(fpath); err != nil {
 				return fmt.Errorf("failed to remove unit %q: %v", u.Name, err)
 			}
 			glog.V(2).Infof("Removed unit %q", u.Name)
 
-			if err := renameio.Symlink(pathDevNull, fpath); err != nil {
+			if err := tx.Symlink(pathDevNull, fpath); err != nil {
 				return fmt.Errorf("failed to symlink unit %q to %s: %v", u.Name, pathDevNull, err)
 			}
 			glog.V(2).Infof("Created symlink unit %q to %s", u.Name, pathDevNull)
@@ -1455,7 +1642,7 @@ func (dn *Daemon) writeUnits(units []ign3types.Unit) error {
 					return err
 				}
 			}
-			if err := writeFileAtomicallyWithDefaults(fpath, []byte(*u.Contents)); err != nil {
+			if err := tx.WriteFileWithDefaults(fpath, []byte(*u.Contents)); err != nil {
 				return fmt.Errorf("failed to write systemd unit %q: %v", u.Name, err)
 			}
 
@@ -1467,7 +1654,7 @@ func (dn *Daemon) writeUnits(units []ign3types.Unit) error {
 			// Contents, and the current one does not, the previous content will not get cleaned up. For now we're ignoring some
 			// of those edge cases rather than introducing more complexity.
 			glog.V(2).Infof("Ensuring systemd unit %q has no mask at %q", u.Name, fpath)
-			if err := os.RemoveAll(fpath); err != nil {
+			if err := tx.Remove(fpath); err != nil {
 				return fmt.Errorf("failed to cleanup %s: %v", fpath, err)
 			}
 		}
@@ -1492,6 +1679,9 @@ func (dn *Daemon) writeUnits(units []ign3types.Unit) error {
 				disabledUnits = append(disabledUnits, u.Name)
 			}
 		} else {
+			if err := tx.RecordUnits(u.Name); err != nil {
+				return err
+			}
 			if err := dn.presetUnit(u); err != nil {
 				// Don't fail here, since a unit may have a dropin referencing a nonexisting actual unit
 				glog.Infof("Could not reset unit preset for %s, skipping. (Error msg: %v)", u.Name, err)
@@ -1499,6 +1689,9 @@ func (dn *Daemon) writeUnits(units []ign3types.Unit) error {
 		}
 	}
 
+	if err := tx.RecordUnits(append(enabledUnits, disabledUnits...)...); err != nil {
+		return err
+	}
 	if len(enabledUnits) > 0 {
 		if err := dn.enableUnits(enabledUnits); err != nil {
 			return err
@@ -1514,7 +1707,7 @@ func (dn *Daemon) writeUnits(units []ign3types.Unit) error {
 
 // writeFiles writes the given files to disk.
 // it doesn't fetch remote files and expects a flattened config file.
-func (dn *Daemon) writeFiles(files []ign3types.File) error {
+func (dn *Daemon) writeFiles(tx *filewriter.Transaction, files []ign3types.File) error {
 	for _, file := range files {
 		glog.Infof("Writing file %q", file.Path)
 
@@ -1542,7 +1735,7 @@ func (dn *Daemon) writeFiles(files []ign3types.File) error {
 		if err := createOrigFile(file.Path, file.Path); err != nil {
 			return err
 		}
-		if err := writeFileAtomically(file.Path, decodedContents, defaultDirectoryPermissions, mode, uid, gid); err != nil {
+		if err := tx.WriteFile(file.Path, decodedContents, defaultDirectoryPermissions, mode, uid, gid); err != nil {
 			return err
 		}
 	}
@@ -56,15 +56,18 @@ func setupTempDirWithEtc(t *testing.T) (string, func()) {
 
 	oldOrigParentDirPath := origParentDirPath
 	oldNoOrigParentDirPath := noOrigParentDirPath
+	oldFileTransactionDirPath := fileTransactionDirPath
 
 	// Override these package variables so files get written to our testing location
 	origParentDirPath = filepath.Join(testDir, origParentDirPath)
 	noOrigParentDirPath = filepath.Join(testDir, noOrigParentDirPath)
+	fileTransactionDirPath = filepath.Join(testDir, fileTransactionDirPath)
 
 	return testDir, func() {
 		// Make sure path variables get put back for other tests
 		origParentDirPath = oldOrigParentDirPath
 		noOrigParentDirPath = oldNoOrigParentDirPath
+		fileTransactionDirPath = oldFileTransactionDirPath
 	}
 }
 
@@ -449,7 +452,7 @@ func TestWriteFiles(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			err := d.writeFiles(test.files)
+			err := d.writeFilesAndUnits(test.files, nil)
 			assert.Equal(t, test.expectedErr, err)
 			if test.expectedContents != nil {
 				fileContents, err := os.ReadFile(filePath)
@@ -459,6 +462,22 @@ func TestWriteFiles(t *testing.T) {
 			os.RemoveAll(filePath)
 		})
 	}
+
+	// A failure partway through must not leave earlier files written.
+	otherPath := filepath.Join(testDir, "other")
+	err = d.writeFilesAndUnits([]ign3types.File{
+		{
+			Node:          ign3types.Node{Path: otherPath, User: node.User, Group: node.Group},
+			FileEmbedded1: ign3types.FileEmbedded1{Contents: ign3types.Resource{Source: &encodedContents}, Mode: &mode},
+		},
+		{
+			Node:          node,
+			FileEmbedded1: ign3types.FileEmbedded1{Contents: ign3types.Resource{Source: &encodedContents, Compression: helpers.StrToPtr("xz")}, Mode: &mode},
+		},
+	}, nil)
+	assert.NotNil(t, err)
+	_, err = os.Stat(otherPath)
+	assert.True(t, os.IsNotExist(err))
 }
 
 func TestUpdateSSHKeys(t *testing.T) {
@@ -583,6 +602,8 @@ func TestCalculatePostConfigChangeAction(t *testing.T) {
 		"pullsecret2":     helpers.NewIgnFile("/var/lib/kubelet/config.json", "kubelet conf 2\n"),
 		"registries1":     helpers.NewIgnFile("/etc/containers/registries.conf", "registries content 1\n"),
 		"registries2":     helpers.NewIgnFile("/etc/containers/registries.conf", "registries content 2\n"),
+		"regdropin1":      helpers.NewIgnFile("/etc/containers/registries.conf.d/02-ctrcfg-registries.conf", "[aliases]\n  ubi8 = \"registry.example.com/ubi8/ubi\"\n"),
+		"regdropin2":      helpers.NewIgnFile("/etc/containers/registries.conf.d/02-ctrcfg-registries.conf", "[aliases]\n  ubi8 = \"mirror.example.com/ubi8/ubi\"\n"),
 		"randomfile1":     helpers.NewIgnFile("/etc/random-reboot-file", "test\n"),
 		"randomfile2":     helpers.NewIgnFile("/etc/random-reboot-file", "test 2\n"),
 		"kubeletCA1":      helpers.NewIgnFile("/etc/kubernetes/kubelet-ca.crt", "kubeletCA1\n"),
@@ -591,6 +612,18 @@ func TestCalculatePostConfigChangeAction(t *testing.T) {
 		"policy2":         helpers.NewIgnFile("/etc/containers/policy.json", "policy2"),
 		"containers-gpg1": helpers.NewIgnFile("/etc/machine-config-daemon/no-reboot/containers-gpg.pub", "containers-gpg1"),
 		"containers-gpg2": helpers.NewIgnFile("/etc/machine-config-daemon/no-reboot/containers-gpg.pub", "containers-gpg2"),
+		"crio-loglevel1":  helpers.NewIgnFile("/etc/crio/crio.conf.d/01-ctrcfg-logLevel", "[crio.runtime]\nlog_level = \"info\"\n"),
+		"crio-loglevel2":  helpers.NewIgnFile("/etc/crio/crio.conf.d/01-ctrcfg-logLevel", "[crio.runtime]\nlog_level = \"debug\"\n"),
+		"crio-pidslimit1": helpers.NewIgnFile("/etc/crio/crio.conf.d/01-ctrcfg-pidsLimit", "[crio.runtime]\npids_limit = 1024\n"),
+		"crio-pidslimit2": helpers.NewIgnFile("/etc/crio/crio.conf.d/01-ctrcfg-pidsLimit", "[crio.runtime]\npids_limit = 2048\n"),
+		"kubelet1":        helpers.NewIgnFile("/etc/kubernetes/kubelet.conf", `{"kind":"KubeletConfiguration","maxPods":250,"cgroupDriver":"systemd"}`),
+		"kubelet2":        helpers.NewIgnFile("/etc/kubernetes/kubelet.conf", `{"kind":"KubeletConfiguration","maxPods":500,"cgroupDriver":"systemd"}`),
+		"kubelet3":        helpers.NewIgnFile("/etc/kubernetes/kubelet.conf", `{"kind":"KubeletConfiguration","maxPods":250,"cgroupDriver":"cgroupfs"}`),
+		"kubelet4":        helpers.NewIgnFile("/etc/kubernetes/kubelet.conf", `{"kind":"KubeletConfiguration","maxPods":250,"cgroupDriver":"systemd","cpuManagerPolicy":"static"}`),
+		"multipath1":      helpers.NewIgnFile("/etc/multipath.conf", "defaults {\n\tuser_friendly_names yes\n}\n"),
+		"multipath2":      helpers.NewIgnFile("/etc/multipath.conf", "defaults {\n\tuser_friendly_names no\n}\n"),
+		"hostMTU1":        helpers.NewIgnFile("/etc/NetworkManager/conf.d/99-ens3-mtu.conf", "[connection-ens3-mtu]\nmatch-device=interface-name:ens3\nethernet.mtu=1500\n"),
+		"hostMTU2":        helpers.NewIgnFile("/etc/NetworkManager/conf.d/99-ens3-mtu.conf", "[connection-ens3-mtu]\nmatch-device=interface-name:ens3\nethernet.mtu=9000\n"),
 	}
 
 	tests := []struct {
@@ -622,6 +655,30 @@ func TestCalculatePostConfigChangeAction(t *testing.T) {
 			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["registries2"]}),
 			expectedAction: []string{postConfigChangeActionReloadCrio},
 		},
+		{
+			// test that a registries drop-in change is reload
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["regdropin1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["regdropin2"]}),
+			expectedAction: []string{postConfigChangeActionReloadCrio},
+		},
+		{
+			// test that a multipath.conf change is multipathd reload
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["multipath1"], files["registries1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["multipath2"], files["registries2"]}),
+			expectedAction: []string{postConfigChangeActionReloadCrio, postConfigChangeActionReloadMultipathd},
+		},
+		{
+			// test that adding multipath.conf is reboot
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["multipath1"]}),
+			expectedAction: []string{postConfigChangeActionReboot},
+		},
+		{
+			// test that a host MTU change is reboot, even alongside rebootless changes
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["hostMTU1"], files["registries1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["hostMTU2"], files["registries2"]}),
+			expectedAction: []string{postConfigChangeActionReboot},
+		},
 		{
 			// test that a kubelet CA change is none
 			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["kubeletCA1"]}),
@@ -670,6 +727,42 @@ func TestCalculatePostConfigChangeAction(t *testing.T) {
 			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["containers-gpg2"]}),
 			expectedAction: []string{postConfigChangeActionReloadCrio},
 		},
+		{
+			// test that a crio log level change is crio reload
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["crio-loglevel1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["crio-loglevel2"]}),
+			expectedAction: []string{postConfigChangeActionReloadCrio},
+		},
+		{
+			// test that a crio pids limit change is crio restart, which supersedes the reload
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["crio-loglevel1"], files["crio-pidslimit1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["crio-loglevel2"], files["crio-pidslimit2"]}),
+			expectedAction: []string{postConfigChangeActionRestartCrio},
+		},
+		{
+			// test that a maxPods change is kubelet restart, combined with a crio reload
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["kubelet1"], files["registries1"], files["pullsecret1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["kubelet2"], files["registries2"], files["pullsecret2"]}),
+			expectedAction: []string{postConfigChangeActionReloadCrio, postConfigChangeActionRestartKubelet},
+		},
+		{
+			// test that a cgroup driver change is reboot
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["kubelet1"], files["crio-pidslimit1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["kubelet3"], files["crio-pidslimit2"]}),
+			expectedAction: []string{postConfigChangeActionReboot},
+		},
+		{
+			// test that a cpu manager policy change is kubelet restart, its state is reset separately
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["kubelet1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["kubelet4"]}),
+			expectedAction: []string{postConfigChangeActionRestartKubelet},
+		},
+		{
+			// test that adding a kubelet config is reboot
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["kubelet1"]}),
+			expectedAction: []string{postConfigChangeActionReboot},
+		},
 	}
 
 	for idx, test := range tests {
@@ -687,7 +780,7 @@ func TestCalculatePostConfigChangeAction(t *testing.T) {
 				t.Errorf("error creating machineConfigDiff: %v", err)
 			}
 			diffFileSet := ctrlcommon.CalculateConfigFileDiffs(&oldIgnConfig, &newIgnConfig)
-			calculatedAction, err := calculatePostConfigChangeAction(mcDiff, diffFileSet)
+			calculatedAction, err := calculatePostConfigChangeAction(mcDiff, &oldIgnConfig, &newIgnConfig, diffFileSet)
 
 			if !reflect.DeepEqual(test.expectedAction, calculatedAction) {
 				t.Errorf("Failed calculating config change action: expected: %v but result is: %v. Error: %v", test.expectedAction, calculatedAction, err)
@@ -696,6 +789,48 @@ func TestCalculatePostConfigChangeAction(t *testing.T) {
 	}
 }
 
+func TestStaleKubeletStateFiles(t *testing.T) {
+	kubeletConf := func(conf string) *ign3types.Config {
+		cfg := ctrlcommon.NewIgnConfig()
+		cfg.Storage.Files = append(cfg.Storage.Files, helpers.NewIgnFile("/etc/kubernetes/kubelet.conf", conf))
+		return &cfg
+	}
+	base := kubeletConf(`{"kind":"KubeletConfiguration","maxPods":250}`)
+
+	tests := []struct {
+		name     string
+		newConf  *ign3types.Config
+		expected []string
+	}{{
+		name:     "unrelated change",
+		newConf:  kubeletConf(`{"kind":"KubeletConfiguration","maxPods":500}`),
+		expected: []string{},
+	}, {
+		name:     "cpu manager policy",
+		newConf:  kubeletConf(`{"kind":"KubeletConfiguration","maxPods":250,"cpuManagerPolicy":"static","reservedSystemCPUs":"0-1"}`),
+		expected: []string{"/var/lib/kubelet/cpu_manager_state"},
+	}, {
+		name:     "memory manager policy",
+		newConf:  kubeletConf(`{"kind":"KubeletConfiguration","maxPods":250,"memoryManagerPolicy":"Static","reservedMemory":[{"numaNode":0,"limits":{"memory":"1Gi"}}]}`),
+		expected: []string{"/var/lib/kubelet/memory_manager_state"},
+	}, {
+		name:     "topology manager policy",
+		newConf:  kubeletConf(`{"kind":"KubeletConfiguration","maxPods":250,"topologyManagerPolicy":"single-numa-node"}`),
+		expected: []string{},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stale, err := staleKubeletStateFiles(base, test.newConf)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(test.expected, stale) {
+				t.Errorf("expected %v, got %v", test.expected, stale)
+			}
+		})
+	}
+}
+
 // checkReconcilableResults is a shortcut for verifying results that should be reconcilable
 func checkReconcilableResults(t *testing.T, key string, reconcilableError error) {
 	if reconcilableError != nil {
@@ -703,6 +838,49 @@ func checkReconcilableResults(t *testing.T, key string, reconcilableError error)
 	}
 }
 
+func TestApplyServiceActions(t *testing.T) {
+	ran := []string{}
+	failing := ""
+	defer func(orig func(string) error) { runServiceAction = orig }(runServiceAction)
+	runServiceAction = func(action string) error {
+		ran = append(ran, action)
+		if action == failing {
+			return fmt.Errorf("%s failed", action)
+		}
+		return nil
+	}
+	d := newMockDaemon()
+	actions := []string{postConfigChangeActionRestartKubelet, postConfigChangeActionReloadMultipathd, postConfigChangeActionRestartCrio}
+
+	// services are restarted after the ones they depend on
+	applied, err := d.applyServiceActions(actions, "rendered-2")
+	require.NoError(t, err)
+	order := []string{postConfigChangeActionRestartCrio, postConfigChangeActionReloadMultipathd, postConfigChangeActionRestartKubelet}
+	assert.Equal(t, order, applied)
+	assert.Equal(t, order, ran)
+
+	// a failure stops the update, the failed action is rolled back too
+	ran = []string{}
+	failing = postConfigChangeActionReloadMultipathd
+	applied, err = d.applyServiceActions(actions, "rendered-2")
+	require.Error(t, err)
+	assert.Equal(t, []string{postConfigChangeActionRestartCrio, postConfigChangeActionReloadMultipathd}, applied)
+	assert.Equal(t, applied, ran)
+
+	// the rollback runs every action, in order, even if one fails
+	ran = []string{}
+	failing = postConfigChangeActionRestartCrio
+	err = d.rollbackServiceActions(append([]string{postConfigChangeActionRestartKubelet}, applied...), "rendered-1")
+	assert.EqualError(t, err, "restart crio failed")
+	assert.Equal(t, order, ran)
+
+	ran = []string{}
+	applied, err = d.applyServiceActions([]string{postConfigChangeActionNone}, "rendered-2")
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	assert.Empty(t, ran)
+}
+
 // checkIrreconcilableResults is a shortcut for verifing results that should be irreconcilable
 func checkIrreconcilableResults(t *testing.T, key string, reconcilableError error) {
 	if reconcilableError == nil {
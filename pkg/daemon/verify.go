@@ -0,0 +1,268 @@
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	mcoResourceRead "github.com/openshift/machine-config-operator/lib/resourceread"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// VerifyResult is the outcome of a single check of a VerifyReport.
+type VerifyResult string
+
+const (
+	// VerifyPass means the node matches the config.
+	VerifyPass VerifyResult = "Pass"
+	// VerifyFail means the node drifted from the config.
+	VerifyFail VerifyResult = "Fail"
+	// VerifyError means the check couldn't be performed.
+	VerifyError VerifyResult = "Error"
+	// VerifySkipped means the check doesn't apply to the node.
+	VerifySkipped VerifyResult = "Skipped"
+)
+
+// The kinds of checks of a VerifyReport.
+const (
+	VerifyKindFile           = "File"
+	VerifyKindUnit           = "Unit"
+	VerifyKindDropin         = "Dropin"
+	VerifyKindKernelArgument = "KernelArgument"
+	VerifyKindOSImage        = "OSImage"
+)
+
+// VerifyReport is the result of auditing the state of a node against a
+// rendered MachineConfig.  Unlike the validation of the daemon, which stops at
+// the first drift, it records every check.
+type VerifyReport struct {
+	// MachineConfig is the name of the MachineConfig.
+	MachineConfig string `json:"machineConfig"`
+	// Compliant is true if no check failed or errored.
+	Compliant bool `json:"compliant"`
+	// Checks are in the order of the config: files, units, kernel arguments and the OS image.
+	Checks []VerifyCheck `json:"checks"`
+}
+
+// VerifyCheck is a single check of a VerifyReport.
+type VerifyCheck struct {
+	// Kind is what was checked, e.g. File or Unit.
+	Kind string `json:"kind"`
+	// Name is the path of a file, the name of a unit or dropin, the kernel
+	// argument or the OS image.
+	Name   string       `json:"name"`
+	Result VerifyResult `json:"result"`
+	// Message explains a result other than Pass.
+	Message string `json:"message,omitempty"`
+}
+
+// VerifyOptions configures VerifyNode.
+type VerifyOptions struct {
+	// Root is where the root filesystem of the node is mounted, e.g. /host
+	// in a debug pod.  The OS image is only checked if it's /.
+	Root string
+	// BootedOSImageURL returns the OSImageURL the node is booted into.
+	// Defaults to asking rpm-ostree, skipping the check on non-CoreOS hosts.
+	BootedOSImageURL func() (string, error)
+	// UnitEnabled returns whether a unit is enabled.  Defaults to systemctl is-enabled.
+	UnitEnabled func(root, unit string) (bool, error)
+}
+
+// errNotCoreOS skips the OS image check
+var errNotCoreOS = errors.New("not booted into a CoreOS variant")
+
+// LoadMachineConfig reads a MachineConfig, in YAML or JSON, from a local file or an http(s) URL.
+func LoadMachineConfig(source string) (*mcfgv1.MachineConfig, error) {
+	content, err := readConfigSource(source)
+	if err != nil {
+		return nil, err
+	}
+	mc, err := mcoResourceRead.ReadMachineConfigV1(content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s is not a MachineConfig", source)
+	}
+	return mc, nil
+}
+
+// VerifyNode audits the files, units, kernel arguments and OS image of the
+// node against the MachineConfig.
+func VerifyNode(mc *mcfgv1.MachineConfig, opts VerifyOptions) (*VerifyReport, error) {
+	if opts.Root == "" {
+		opts.Root = "/"
+	}
+	if opts.BootedOSImageURL == nil {
+		opts.BootedOSImageURL = hostBootedOSImageURL
+	}
+	if opts.UnitEnabled == nil {
+		opts.UnitEnabled = systemctlIsEnabled
+	}
+
+	ignConfig, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing Ignition config failed: %w", err)
+	}
+
+	report := &VerifyReport{MachineConfig: mc.Name}
+	for _, f := range ignConfig.Storage.Files {
+		report.add(VerifyKindFile, f.Path, verifyFile(opts.Root, f))
+	}
+	systemdPath := filepath.Join(opts.Root, pathSystemd)
+	for _, u := range ignConfig.Systemd.Units {
+		for _, dropin := range u.Dropins {
+			report.add(VerifyKindDropin, u.Name+"/"+dropin.Name, checkV3Dropin(systemdPath, u, dropin))
+		}
+		report.add(VerifyKindUnit, u.Name, verifyUnit(systemdPath, opts, u))
+	}
+	report.addKernelArguments(opts.Root, mc.Spec.KernelArguments)
+	report.addOSImage(opts, mc.Spec.OSImageURL)
+
+	report.Compliant = true
+	for _, check := range report.Checks {
+		if check.Result == VerifyFail || check.Result == VerifyError {
+			report.Compliant = false
+		}
+	}
+	return report, nil
+}
+
+// add records a check that passed if err is nil and failed otherwise.
+func (r *VerifyReport) add(kind, name string, err error) {
+	check := VerifyCheck{Kind: kind, Name: name, Result: VerifyPass}
+	if err != nil {
+		check.Result = VerifyFail
+		check.Message = err.Error()
+	}
+	r.Checks = append(r.Checks, check)
+}
+
+func verifyFile(root string, f ign3types.File) error {
+	if len(f.Append) > 0 {
+		return fmt.Errorf("append is not supported")
+	}
+	mode := defaultFilePermissions
+	if f.Mode != nil {
+		mode = os.FileMode(*f.Mode)
+	}
+	contents, err := ctrlcommon.DecodeIgnitionFileContents(f.Contents.Source, f.Contents.Compression)
+	if err != nil {
+		return fmt.Errorf("couldn't decode file %q: %w", f.Path, err)
+	}
+	return checkFileContentsAndMode(filepath.Join(root, f.Path), contents, mode)
+}
+
+// verifyUnit checks the contents, masking and enablement of a unit.
+func verifyUnit(systemdPath string, opts VerifyOptions, u ign3types.Unit) error {
+	path := getIgn3SystemdUnitPath(systemdPath, u)
+	if u.Mask != nil && *u.Mask {
+		// the link is relative to the root of the node, don't resolve it
+		link, err := os.Readlink(path)
+		if err != nil {
+			return errors.Wrapf(err, "unit is not masked")
+		}
+		if link != pathDevNull {
+			return errors.Errorf("unit is not masked: %q links to %q", path, link)
+		}
+		return nil
+	}
+	if u.Contents != nil && *u.Contents != "" {
+		if err := checkFileContentsAndMode(path, []byte(*u.Contents), defaultFilePermissions); err != nil {
+			return err
+		}
+	}
+	if u.Enabled != nil {
+		enabled, err := opts.UnitEnabled(opts.Root, u.Name)
+		if err != nil {
+			return errors.Wrapf(err, "checking whether unit is enabled")
+		}
+		if enabled != *u.Enabled {
+			return errors.Errorf("expected enabled=%t, have enabled=%t", *u.Enabled, enabled)
+		}
+	}
+	return nil
+}
+
+// addKernelArguments checks each kernel argument of the config is on the
+// command line of the booted kernel.
+func (r *VerifyReport) addKernelArguments(root string, kernelArguments []string) {
+	kargs := parseKernelArguments(kernelArguments)
+	if len(kargs) == 0 {
+		return
+	}
+	content, err := ioutil.ReadFile(filepath.Join(root, CmdLineFile))
+	if err != nil {
+		for _, karg := range kargs {
+			r.Checks = append(r.Checks, VerifyCheck{Kind: VerifyKindKernelArgument, Name: karg, Result: VerifyError, Message: err.Error()})
+		}
+		return
+	}
+	cmdline := sets.NewString(strings.Fields(string(content))...)
+	for _, karg := range kargs {
+		var err error
+		if !cmdline.Has(karg) {
+			err = fmt.Errorf("not on the kernel command line")
+		}
+		r.add(VerifyKindKernelArgument, karg, err)
+	}
+}
+
+// addOSImage checks the node is booted into the OSImageURL of the config.
+func (r *VerifyReport) addOSImage(opts VerifyOptions, osImageURL string) {
+	if osImageURL == "" {
+		return
+	}
+	check := VerifyCheck{Kind: VerifyKindOSImage, Name: osImageURL}
+	if filepath.Clean(opts.Root) != "/" {
+		check.Result = VerifySkipped
+		check.Message = "the OS image can only be checked on the node itself"
+		r.Checks = append(r.Checks, check)
+		return
+	}
+	switch booted, err := opts.BootedOSImageURL(); {
+	case err == errNotCoreOS:
+		check.Result = VerifySkipped
+		check.Message = err.Error()
+	case err != nil:
+		check.Result = VerifyError
+		check.Message = err.Error()
+	case booted != osImageURL:
+		check.Result = VerifyFail
+		check.Message = fmt.Sprintf("booted into %q", booted)
+	default:
+		check.Result = VerifyPass
+	}
+	r.Checks = append(r.Checks, check)
+}
+
+func hostBootedOSImageURL() (string, error) {
+	hostOS, err := GetHostRunningOS()
+	if err != nil {
+		return "", err
+	}
+	if !hostOS.IsCoreOSVariant() {
+		return "", errNotCoreOS
+	}
+	osImageURL, _, err := NewNodeUpdaterClient().GetBootedOSImageURL()
+	return osImageURL, err
+}
+
+// systemctlIsEnabled returns whether systemctl considers the unit enabled.
+func systemctlIsEnabled(root, unit string) (bool, error) {
+	out, err := exec.Command("systemctl", "--root", root, "is-enabled", unit).Output()
+	state := strings.TrimSpace(string(out))
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok && state != "" {
+			// is-enabled exits non-zero for disabled units
+			return false, nil
+		}
+		return false, err
+	}
+	return state == "enabled" || state == "enabled-runtime", nil
+}
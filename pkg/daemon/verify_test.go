@@ -0,0 +1,111 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestVerifyNode(t *testing.T) {
+	root, err := ioutil.TempDir("", "verify")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	write := func(path, contents string, mode os.FileMode) {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), mode))
+		require.NoError(t, os.Chmod(path, mode))
+	}
+	write("/etc/good.conf", "good", 0644)
+	write("/etc/drifted.conf", "edited", 0644)
+	write("/usr/local/bin/script.sh", "true", 0644)
+	write("/etc/systemd/system/good.service", "[Service]\n", 0644)
+	write("/etc/systemd/system/good.service.d/10-foo.conf", "[Service]\nFoo=1\n", 0644)
+	write("/proc/cmdline", "BOOT_IMAGE=/vmlinuz nosmt console=ttyS0\n", 0444)
+	require.NoError(t, os.Symlink(pathDevNull, filepath.Join(root, "/etc/systemd/system/masked.service")))
+
+	files := []ign3types.File{
+		helpers.CreateIgn3File("/etc/good.conf", "data:,good", 0644),
+		helpers.CreateIgn3File("/etc/drifted.conf", "data:,original", 0644),
+		helpers.CreateIgn3File("/usr/local/bin/script.sh", "data:,true", 0755),
+		helpers.CreateIgn3File("/etc/missing.conf", "data:,missing", 0644),
+	}
+	units := []ign3types.Unit{{
+		Name:     "good.service",
+		Contents: helpers.StrToPtr("[Service]\n"),
+		Enabled:  helpers.BoolToPtr(true),
+		Dropins:  []ign3types.Dropin{{Name: "10-foo.conf", Contents: helpers.StrToPtr("[Service]\nFoo=1\n")}},
+	}, {
+		Name: "masked.service",
+		Mask: helpers.BoolToPtr(true),
+	}, {
+		Name:    "disabled.service",
+		Enabled: helpers.BoolToPtr(false),
+	}}
+	mc := helpers.NewMachineConfigExtended("rendered-worker-1", nil, files, units, nil, nil, false, []string{"nosmt console=ttyS0", "audit=1"}, "", "quay.io/openshift/os@"+testDigest)
+
+	opts := VerifyOptions{
+		Root: root,
+		UnitEnabled: func(_, unit string) (bool, error) {
+			return unit == "good.service" || unit == "disabled.service", nil
+		},
+	}
+	report, err := VerifyNode(mc, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "rendered-worker-1", report.MachineConfig)
+	assert.False(t, report.Compliant)
+
+	results := map[string]VerifyResult{}
+	for _, check := range report.Checks {
+		results[check.Kind+" "+check.Name] = check.Result
+		if check.Result == VerifyPass {
+			assert.Empty(t, check.Message)
+		} else {
+			assert.NotEmpty(t, check.Message, check.Name)
+		}
+	}
+	assert.Equal(t, map[string]VerifyResult{
+		"File /etc/good.conf":                        VerifyPass,
+		"File /etc/drifted.conf":                     VerifyFail,
+		"File /usr/local/bin/script.sh":              VerifyFail,
+		"File /etc/missing.conf":                     VerifyFail,
+		"Dropin good.service/10-foo.conf":            VerifyPass,
+		"Unit good.service":                          VerifyPass,
+		"Unit masked.service":                        VerifyPass,
+		"Unit disabled.service":                      VerifyFail,
+		"KernelArgument nosmt":                       VerifyPass,
+		"KernelArgument console=ttyS0":               VerifyPass,
+		"KernelArgument audit=1":                     VerifyFail,
+		"OSImage quay.io/openshift/os@" + testDigest: VerifySkipped,
+	}, results)
+}
+
+func TestVerifyNodeOSImage(t *testing.T) {
+	mc := helpers.NewMachineConfigExtended("rendered-worker-1", nil, nil, nil, nil, nil, false, nil, "", "quay.io/openshift/os@"+testDigest)
+
+	booted := func(url string, err error) VerifyOptions {
+		return VerifyOptions{BootedOSImageURL: func() (string, error) { return url, err }}
+	}
+	report, err := VerifyNode(mc, booted("quay.io/openshift/os@"+testDigest, nil))
+	require.NoError(t, err)
+	assert.True(t, report.Compliant)
+	assert.Equal(t, []VerifyCheck{{Kind: VerifyKindOSImage, Name: "quay.io/openshift/os@" + testDigest, Result: VerifyPass}}, report.Checks)
+
+	report, err = VerifyNode(mc, booted("quay.io/openshift/os@sha256:2222", nil))
+	require.NoError(t, err)
+	assert.False(t, report.Compliant)
+	assert.Equal(t, VerifyFail, report.Checks[0].Result)
+
+	report, err = VerifyNode(mc, booted("", errNotCoreOS))
+	require.NoError(t, err)
+	assert.True(t, report.Compliant)
+	assert.Equal(t, VerifySkipped, report.Checks[0].Result)
+}
@@ -2,9 +2,11 @@ package daemon
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/golang/glog"
 	"github.com/openshift/machine-config-operator/internal"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	corev1 "k8s.io/api/core/v1"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -42,7 +44,10 @@ type NodeWriter interface {
 	SetWorking(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
 	SetUnreconcilable(err error, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
 	SetDegraded(err error, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetRetrying(err error, attempt int, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
 	SetSSHAccessed(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetDrainBlockers(blockers string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetRolledBackConfig(config string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
 }
 
 // newNodeWriter Create a new NodeWriter
@@ -72,9 +77,15 @@ func (nw *clusterNodeWriter) SetDone(client corev1client.NodeInterface, lister c
 		constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
 		constants.CurrentMachineConfigAnnotationKey:     dcAnnotation,
 		// clear out any Degraded/Unreconcilable reason
-		constants.MachineConfigDaemonReasonAnnotationKey: "",
+		constants.MachineConfigDaemonReasonAnnotationKey:      "",
+		constants.MachineConfigDaemonErrorReasonAnnotationKey: "",
+		// and any retries of transient errors
+		constants.MachineConfigDaemonRetriesAnnotationKey: "",
+		// and any PodDisruptionBudgets which blocked the drain
+		constants.DrainBlockersAnnotationKey: "",
 	}
 	MCDState.WithLabelValues(constants.MachineConfigDaemonStateDone, "").SetToCurrentTime()
+	health.setState(constants.MachineConfigDaemonStateDone, "")
 	respChan := make(chan error, 1)
 	nw.writer <- message{
 		client:          client,
@@ -92,6 +103,7 @@ func (nw *clusterNodeWriter) SetWorking(client corev1client.NodeInterface, liste
 		constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateWorking,
 	}
 	MCDState.WithLabelValues(constants.MachineConfigDaemonStateWorking, "").SetToCurrentTime()
+	health.setState(constants.MachineConfigDaemonStateWorking, "")
 	respChan := make(chan error, 1)
 	nw.writer <- message{
 		client:          client,
@@ -110,10 +122,12 @@ func (nw *clusterNodeWriter) SetUnreconcilable(err error, client corev1client.No
 	// annotation size limit (256 kb) at any point
 	truncatedErr := fmt.Sprintf("%.2000s", err.Error())
 	annos := map[string]string{
-		constants.MachineConfigDaemonStateAnnotationKey:  constants.MachineConfigDaemonStateUnreconcilable,
-		constants.MachineConfigDaemonReasonAnnotationKey: truncatedErr,
+		constants.MachineConfigDaemonStateAnnotationKey:       constants.MachineConfigDaemonStateUnreconcilable,
+		constants.MachineConfigDaemonReasonAnnotationKey:      truncatedErr,
+		constants.MachineConfigDaemonErrorReasonAnnotationKey: ctrlcommon.ErrorReason(err),
 	}
 	MCDState.WithLabelValues(constants.MachineConfigDaemonStateUnreconcilable, truncatedErr).SetToCurrentTime()
+	health.setState(constants.MachineConfigDaemonStateUnreconcilable, truncatedErr)
 	respChan := make(chan error, 1)
 	nw.writer <- message{
 		client:          client,
@@ -137,10 +151,12 @@ func (nw *clusterNodeWriter) SetDegraded(err error, client corev1client.NodeInte
 	// annotation size limit (256 kb) at any point
 	truncatedErr := fmt.Sprintf("%.2000s", err.Error())
 	annos := map[string]string{
-		constants.MachineConfigDaemonStateAnnotationKey:  constants.MachineConfigDaemonStateDegraded,
-		constants.MachineConfigDaemonReasonAnnotationKey: truncatedErr,
+		constants.MachineConfigDaemonStateAnnotationKey:       constants.MachineConfigDaemonStateDegraded,
+		constants.MachineConfigDaemonReasonAnnotationKey:      truncatedErr,
+		constants.MachineConfigDaemonErrorReasonAnnotationKey: ctrlcommon.ErrorReason(err),
 	}
 	MCDState.WithLabelValues(constants.MachineConfigDaemonStateDegraded, truncatedErr).SetToCurrentTime()
+	health.setState(constants.MachineConfigDaemonStateDegraded, truncatedErr)
 	respChan := make(chan error, 1)
 	nw.writer <- message{
 		client:          client,
@@ -156,6 +172,27 @@ func (nw *clusterNodeWriter) SetDegraded(err error, client corev1client.NodeInte
 	return clientErr
 }
 
+// SetRetrying records a retry of a transient error, leaving the state untouched.
+// The reason annotation is set so that the error is visible while retrying.
+func (nw *clusterNodeWriter) SetRetrying(err error, attempt int, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
+	// truncatedErr caps error message at a reasonable length to limit the risk of hitting the total
+	// annotation size limit (256 kb) at any point
+	truncatedErr := fmt.Sprintf("%.2000s", err.Error())
+	annos := map[string]string{
+		constants.MachineConfigDaemonRetriesAnnotationKey: strconv.Itoa(attempt),
+		constants.MachineConfigDaemonReasonAnnotationKey:  truncatedErr,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
 // SetSSHAccessed sets the ssh annotation to accessed
 func (nw *clusterNodeWriter) SetSSHAccessed(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
 	MCDSSHAccessed.Inc()
@@ -173,6 +210,40 @@ func (nw *clusterNodeWriter) SetSSHAccessed(client corev1client.NodeInterface, l
 	return <-respChan
 }
 
+// SetDrainBlockers records the PodDisruptionBudgets blocking the drain, leaving the state untouched.
+// An empty blockers clears the annotation.
+func (nw *clusterNodeWriter) SetDrainBlockers(blockers string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
+	annos := map[string]string{
+		constants.DrainBlockersAnnotationKey: blockers,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
+// SetRolledBackConfig records the config rolled back after it failed its post-update validation, leaving the state untouched.
+// An empty config clears the annotation.
+func (nw *clusterNodeWriter) SetRolledBackConfig(config string, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
+	annos := map[string]string{
+		constants.RolledBackConfigAnnotationKey: config,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
 func setNodeAnnotations(client corev1client.NodeInterface, lister corev1lister.NodeLister, nodeName string, m map[string]string) (*corev1.Node, error) {
 	node, err := internal.UpdateNodeRetry(client, lister, nodeName, func(node *corev1.Node) {
 		for k, v := range m {
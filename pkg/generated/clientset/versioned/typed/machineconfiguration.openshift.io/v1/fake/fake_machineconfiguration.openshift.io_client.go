@@ -28,10 +28,30 @@ func (c *FakeMachineconfigurationV1) MachineConfigs() v1.MachineConfigInterface
 	return &FakeMachineConfigs{c}
 }
 
+func (c *FakeMachineconfigurationV1) MachineConfigFreezes() v1.MachineConfigFreezeInterface {
+	return &FakeMachineConfigFreezes{c}
+}
+
+func (c *FakeMachineconfigurationV1) MachineConfigNodes() v1.MachineConfigNodeInterface {
+	return &FakeMachineConfigNodes{c}
+}
+
 func (c *FakeMachineconfigurationV1) MachineConfigPools() v1.MachineConfigPoolInterface {
 	return &FakeMachineConfigPools{c}
 }
 
+func (c *FakeMachineconfigurationV1) MachineConfigProfiles() v1.MachineConfigProfileInterface {
+	return &FakeMachineConfigProfiles{c}
+}
+
+func (c *FakeMachineconfigurationV1) MaintenanceTasks() v1.MaintenanceTaskInterface {
+	return &FakeMaintenanceTasks{c}
+}
+
+func (c *FakeMachineconfigurationV1) UpdateWaves() v1.UpdateWaveInterface {
+	return &FakeUpdateWaves{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeMachineconfigurationV1) RESTClient() rest.Interface {
@@ -10,4 +10,14 @@ type KubeletConfigExpansion interface{}
 
 type MachineConfigExpansion interface{}
 
+type MachineConfigFreezeExpansion interface{}
+
+type MachineConfigNodeExpansion interface{}
+
 type MachineConfigPoolExpansion interface{}
+
+type MachineConfigProfileExpansion interface{}
+
+type MaintenanceTaskExpansion interface{}
+
+type UpdateWaveExpansion interface{}
@@ -16,7 +16,12 @@ type MachineconfigurationV1Interface interface {
 	ControllerConfigsGetter
 	KubeletConfigsGetter
 	MachineConfigsGetter
+	MachineConfigFreezesGetter
+	MachineConfigNodesGetter
 	MachineConfigPoolsGetter
+	MachineConfigProfilesGetter
+	MaintenanceTasksGetter
+	UpdateWavesGetter
 }
 
 // MachineconfigurationV1Client is used to interact with features provided by the machineconfiguration.openshift.io group.
@@ -40,10 +45,30 @@ func (c *MachineconfigurationV1Client) MachineConfigs() MachineConfigInterface {
 	return newMachineConfigs(c)
 }
 
+func (c *MachineconfigurationV1Client) MachineConfigFreezes() MachineConfigFreezeInterface {
+	return newMachineConfigFreezes(c)
+}
+
+func (c *MachineconfigurationV1Client) MachineConfigNodes() MachineConfigNodeInterface {
+	return newMachineConfigNodes(c)
+}
+
 func (c *MachineconfigurationV1Client) MachineConfigPools() MachineConfigPoolInterface {
 	return newMachineConfigPools(c)
 }
 
+func (c *MachineconfigurationV1Client) MachineConfigProfiles() MachineConfigProfileInterface {
+	return newMachineConfigProfiles(c)
+}
+
+func (c *MachineconfigurationV1Client) MaintenanceTasks() MaintenanceTaskInterface {
+	return newMaintenanceTasks(c)
+}
+
+func (c *MachineconfigurationV1Client) UpdateWaves() UpdateWaveInterface {
+	return newUpdateWaves(c)
+}
+
 // NewForConfig creates a new MachineconfigurationV1Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
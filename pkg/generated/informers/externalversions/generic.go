@@ -45,8 +45,18 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().KubeletConfigs().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("machineconfigs"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().MachineConfigs().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("machineconfigfreezes"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().MachineConfigFreezes().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("machineconfignodes"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().MachineConfigNodes().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("machineconfigpools"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().MachineConfigPools().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("machineconfigprofiles"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().MachineConfigProfiles().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("maintenancetasks"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().MaintenanceTasks().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("updatewaves"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().UpdateWaves().Informer()}, nil
 
 	}
 
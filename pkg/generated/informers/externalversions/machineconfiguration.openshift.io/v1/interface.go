@@ -16,8 +16,18 @@ type Interface interface {
 	KubeletConfigs() KubeletConfigInformer
 	// MachineConfigs returns a MachineConfigInformer.
 	MachineConfigs() MachineConfigInformer
+	// MachineConfigFreezes returns a MachineConfigFreezeInformer.
+	MachineConfigFreezes() MachineConfigFreezeInformer
+	// MachineConfigNodes returns a MachineConfigNodeInformer.
+	MachineConfigNodes() MachineConfigNodeInformer
 	// MachineConfigPools returns a MachineConfigPoolInformer.
 	MachineConfigPools() MachineConfigPoolInformer
+	// MachineConfigProfiles returns a MachineConfigProfileInformer.
+	MachineConfigProfiles() MachineConfigProfileInformer
+	// MaintenanceTasks returns a MaintenanceTaskInformer.
+	MaintenanceTasks() MaintenanceTaskInformer
+	// UpdateWaves returns a UpdateWaveInformer.
+	UpdateWaves() UpdateWaveInformer
 }
 
 type version struct {
@@ -51,7 +61,32 @@ func (v *version) MachineConfigs() MachineConfigInformer {
 	return &machineConfigInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
 
+// MachineConfigFreezes returns a MachineConfigFreezeInformer.
+func (v *version) MachineConfigFreezes() MachineConfigFreezeInformer {
+	return &machineConfigFreezeInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// MachineConfigNodes returns a MachineConfigNodeInformer.
+func (v *version) MachineConfigNodes() MachineConfigNodeInformer {
+	return &machineConfigNodeInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // MachineConfigPools returns a MachineConfigPoolInformer.
 func (v *version) MachineConfigPools() MachineConfigPoolInformer {
 	return &machineConfigPoolInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
+
+// MachineConfigProfiles returns a MachineConfigProfileInformer.
+func (v *version) MachineConfigProfiles() MachineConfigProfileInformer {
+	return &machineConfigProfileInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// MaintenanceTasks returns a MaintenanceTaskInformer.
+func (v *version) MaintenanceTasks() MaintenanceTaskInformer {
+	return &maintenanceTaskInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// UpdateWaves returns a UpdateWaveInformer.
+func (v *version) UpdateWaves() UpdateWaveInformer {
+	return &updateWaveInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -18,6 +18,26 @@ type KubeletConfigListerExpansion interface{}
 // MachineConfigLister.
 type MachineConfigListerExpansion interface{}
 
+// MachineConfigFreezeListerExpansion allows custom methods to be added to
+// MachineConfigFreezeLister.
+type MachineConfigFreezeListerExpansion interface{}
+
+// MachineConfigNodeListerExpansion allows custom methods to be added to
+// MachineConfigNodeLister.
+type MachineConfigNodeListerExpansion interface{}
+
 // MachineConfigPoolListerExpansion allows custom methods to be added to
 // MachineConfigPoolLister.
 type MachineConfigPoolListerExpansion interface{}
+
+// MachineConfigProfileListerExpansion allows custom methods to be added to
+// MachineConfigProfileLister.
+type MachineConfigProfileListerExpansion interface{}
+
+// MaintenanceTaskListerExpansion allows custom methods to be added to
+// MaintenanceTaskLister.
+type MaintenanceTaskListerExpansion interface{}
+
+// UpdateWaveListerExpansion allows custom methods to be added to
+// UpdateWaveLister.
+type UpdateWaveListerExpansion interface{}
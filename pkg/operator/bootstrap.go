@@ -100,6 +100,18 @@ func RenderBootstrap(
 		return err
 	}
 
+	obji, err = runtime.Decode(scheme.Codecs.UniversalDecoder(corev1.SchemeGroupVersion), filesData[clusterConfigConfigMapFile])
+	if err != nil {
+		return err
+	}
+	clusterConfig, ok := obji.(*corev1.ConfigMap)
+	if !ok {
+		return fmt.Errorf("expected *corev1.ConfigMap found %T", obji)
+	}
+	if spec.FIPS, err = installConfigFIPS(clusterConfig); err != nil {
+		return err
+	}
+
 	additionalTrustBundleData, err := ioutil.ReadFile(additionalTrustBundleFile)
 	if err != nil && !os.IsNotExist(err) {
 		return err
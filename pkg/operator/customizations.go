@@ -0,0 +1,262 @@
+package operator
+
+import (
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	templatectrl "github.com/openshift/machine-config-operator/pkg/controller/template"
+)
+
+const (
+	// customizationsConfigMapName is the name of the optional configmap in the MCO namespace
+	// holding user customizations of the workloads managed by the operator.
+	customizationsConfigMapName = ctrlcommon.CustomizationsConfigMapName
+	// customizationsConfigMapKey is the key of the customizations in the configmap.
+	customizationsConfigMapKey = ctrlcommon.CustomizationsConfigMapKey
+
+	mccWorkloadName = "machine-config-controller"
+	mcdWorkloadName = "machine-config-daemon"
+	mcsWorkloadName = "machine-config-server"
+)
+
+// workloadCustomization is the constrained set of changes users may make to
+// a DaemonSet or Deployment managed by the operator.  Anything else is
+// reverted on every sync like before.
+type workloadCustomization struct {
+	// NodeSelector is merged into the pod's node selector.  Only supported for
+	// the controller, the daemon and server have to run on every node of their role.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations are added to the pod's tolerations.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// ResourceRequests override the resource requests of the workload's main container.
+	ResourceRequests corev1.ResourceList `json:"resourceRequests,omitempty"`
+}
+
+// operatorCustomizations holds the customizations for each managed workload.
+type operatorCustomizations struct {
+	MachineConfigController *workloadCustomization `json:"machineConfigController,omitempty"`
+	MachineConfigDaemon     *workloadCustomization `json:"machineConfigDaemon,omitempty"`
+	MachineConfigServer     *workloadCustomization `json:"machineConfigServer,omitempty"`
+
+	// TemplatesImage is a digest-pinned image or OCI artifact holding the
+	// template tree, used by the controller instead of the templates shipped
+	// in its image.  This allows fixing templates without a new release.
+	TemplatesImage string `json:"templatesImage,omitempty"`
+
+	// ControllerTuning tunes the informer resyncs and workqueues of the controller.
+	ControllerTuning *controllerTuning `json:"controllerTuning,omitempty"`
+
+	// RemoveLegacyBootstrapToken removes the long-lived token of the
+	// node-bootstrapper service account once the controller rotates
+	// short-lived ones.  The controller deletes it, and the operator stops
+	// recreating it.
+	RemoveLegacyBootstrapToken bool `json:"removeLegacyBootstrapToken,omitempty"`
+}
+
+// controllerTuning holds the ctrlcommon.ControllerTuning knobs to override,
+// passed to the controller as flags.  Unset knobs keep their default.
+type controllerTuning struct {
+	ResyncPeriod   *metav1.Duration `json:"resyncPeriod,omitempty"`
+	QueueBaseDelay *metav1.Duration `json:"queueBaseDelay,omitempty"`
+	QueueMaxDelay  *metav1.Duration `json:"queueMaxDelay,omitempty"`
+	QueueQPS       *int             `json:"queueQPS,omitempty"`
+	QueueBurst     *int             `json:"queueBurst,omitempty"`
+}
+
+// getCustomizations reads the user customizations, if any, from the MCO namespace.
+func (optr *Operator) getCustomizations() (*operatorCustomizations, error) {
+	cm, err := optr.mcoCmLister.ConfigMaps(optr.namespace).Get(customizationsConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c, err := parseCustomizations([]byte(cm.Data[customizationsConfigMapKey]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid configmap %s/%s: %w", optr.namespace, customizationsConfigMapName, err)
+	}
+	return c, nil
+}
+
+// parseCustomizations parses and validates the customizations from their YAML representation.
+func parseCustomizations(data []byte) (*operatorCustomizations, error) {
+	c := &operatorCustomizations{}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	if err := c.MachineConfigController.validate(true); err != nil {
+		return nil, fmt.Errorf("machineConfigController: %w", err)
+	}
+	if err := c.MachineConfigDaemon.validate(false); err != nil {
+		return nil, fmt.Errorf("machineConfigDaemon: %w", err)
+	}
+	if err := c.MachineConfigServer.validate(false); err != nil {
+		return nil, fmt.Errorf("machineConfigServer: %w", err)
+	}
+	if c.TemplatesImage != "" {
+		if _, err := templatectrl.ParseTemplatesImage(c.TemplatesImage); err != nil {
+			return nil, fmt.Errorf("templatesImage: %w", err)
+		}
+	}
+	if c.ControllerTuning != nil {
+		if err := c.ControllerTuning.tuning().Validate(); err != nil {
+			return nil, fmt.Errorf("controllerTuning: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// tuning returns the default tuning with the knobs of t.
+func (t *controllerTuning) tuning() ctrlcommon.ControllerTuning {
+	tuning := ctrlcommon.DefaultControllerTuning
+	if t.ResyncPeriod != nil {
+		tuning.ResyncPeriod = t.ResyncPeriod.Duration
+	}
+	if t.QueueBaseDelay != nil {
+		tuning.QueueBaseDelay = t.QueueBaseDelay.Duration
+	}
+	if t.QueueMaxDelay != nil {
+		tuning.QueueMaxDelay = t.QueueMaxDelay.Duration
+	}
+	if t.QueueQPS != nil {
+		tuning.QueueQPS = *t.QueueQPS
+	}
+	if t.QueueBurst != nil {
+		tuning.QueueBurst = *t.QueueBurst
+	}
+	return tuning
+}
+
+// args returns the controller flags setting the knobs of t.
+func (t *controllerTuning) args() []string {
+	args := []string{}
+	if t.ResyncPeriod != nil {
+		args = append(args, "--resync-period="+t.ResyncPeriod.Duration.String())
+	}
+	if t.QueueBaseDelay != nil {
+		args = append(args, "--queue-base-delay="+t.QueueBaseDelay.Duration.String())
+	}
+	if t.QueueMaxDelay != nil {
+		args = append(args, "--queue-max-delay="+t.QueueMaxDelay.Duration.String())
+	}
+	if t.QueueQPS != nil {
+		args = append(args, fmt.Sprintf("--queue-qps=%d", *t.QueueQPS))
+	}
+	if t.QueueBurst != nil {
+		args = append(args, fmt.Sprintf("--queue-burst=%d", *t.QueueBurst))
+	}
+	return args
+}
+
+func (w *workloadCustomization) validate(allowNodeSelector bool) error {
+	if w == nil {
+		return nil
+	}
+	if len(w.NodeSelector) > 0 && !allowNodeSelector {
+		return fmt.Errorf("nodeSelector is not supported")
+	}
+	for name, q := range w.ResourceRequests {
+		if name != corev1.ResourceCPU && name != corev1.ResourceMemory {
+			return fmt.Errorf("unsupported resource request %q, only cpu and memory are supported", name)
+		}
+		if q.Sign() <= 0 {
+			return fmt.Errorf("resource request %q must be positive", name)
+		}
+	}
+	for _, t := range w.Tolerations {
+		switch t.Operator {
+		case corev1.TolerationOpExists:
+			if t.Value != "" {
+				return fmt.Errorf("toleration with operator %q must not have a value", t.Operator)
+			}
+		case corev1.TolerationOpEqual, "":
+			if t.Key == "" {
+				return fmt.Errorf("toleration with operator %q must have a key", corev1.TolerationOpEqual)
+			}
+		default:
+			return fmt.Errorf("unsupported toleration operator %q", t.Operator)
+		}
+	}
+	return nil
+}
+
+// forWorkload returns the customization for the named workload, if any.
+func (c *operatorCustomizations) forWorkload(name string) *workloadCustomization {
+	if c == nil {
+		return nil
+	}
+	switch name {
+	case mccWorkloadName:
+		return c.MachineConfigController
+	case mcdWorkloadName:
+		return c.MachineConfigDaemon
+	case mcsWorkloadName:
+		return c.MachineConfigServer
+	}
+	return nil
+}
+
+// applyCustomizations applies the customization of the named workload to its rendered pod spec.
+func (c *operatorCustomizations) applyCustomizations(name string, spec *corev1.PodSpec) {
+	if c != nil && name == mccWorkloadName {
+		args := []string{}
+		if c.TemplatesImage != "" {
+			args = append(args, "--templates-image="+c.TemplatesImage)
+		}
+		if c.ControllerTuning != nil {
+			args = append(args, c.ControllerTuning.args()...)
+		}
+		for i := range spec.Containers {
+			if spec.Containers[i].Name == name {
+				spec.Containers[i].Args = append(spec.Containers[i].Args, args...)
+			}
+		}
+	}
+	w := c.forWorkload(name)
+	if w == nil {
+		return
+	}
+	// the pod spec was rendered from the manifest, so the node selector and
+	// tolerations end up being the ones of the manifest plus the customization,
+	// and those removed from the customization are dropped on the next sync
+	if len(w.NodeSelector) > 0 && spec.NodeSelector == nil {
+		spec.NodeSelector = map[string]string{}
+	}
+	for k, v := range w.NodeSelector {
+		spec.NodeSelector[k] = v
+	}
+	for _, t := range w.Tolerations {
+		if !hasToleration(spec.Tolerations, t) {
+			spec.Tolerations = append(spec.Tolerations, t)
+		}
+	}
+	for i := range spec.Containers {
+		container := &spec.Containers[i]
+		if container.Name != name {
+			continue
+		}
+		if len(w.ResourceRequests) > 0 && container.Resources.Requests == nil {
+			container.Resources.Requests = corev1.ResourceList{}
+		}
+		for res, q := range w.ResourceRequests {
+			container.Resources.Requests[res] = q
+		}
+	}
+}
+
+// hasToleration returns whether the tolerations contain one equal to t.
+func hasToleration(tolerations []corev1.Toleration, t corev1.Toleration) bool {
+	for _, existing := range tolerations {
+		if equality.Semantic.DeepEqual(existing, t) {
+			return true
+		}
+	}
+	return false
+}
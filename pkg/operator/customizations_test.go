@@ -0,0 +1,291 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+
+	"github.com/openshift/machine-config-operator/lib/resourcemerge"
+	"github.com/openshift/machine-config-operator/pkg/controller/bootstrapcredentials"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+func TestParseCustomizations(t *testing.T) {
+	cases := []struct {
+		name        string
+		data        string
+		expectError bool
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "valid",
+			data: `
+machineConfigController:
+  nodeSelector:
+    node-role.kubernetes.io/infra: ""
+  tolerations:
+  - key: node-role.kubernetes.io/infra
+    operator: Exists
+    effect: NoSchedule
+  resourceRequests:
+    cpu: 50m
+    memory: 100Mi
+machineConfigDaemon:
+  resourceRequests:
+    memory: 200Mi
+`,
+		},
+		{
+			name:        "node selector on daemon",
+			data:        "machineConfigDaemon:\n  nodeSelector:\n    foo: bar\n",
+			expectError: true,
+		},
+		{
+			name:        "unsupported resource",
+			data:        "machineConfigServer:\n  resourceRequests:\n    ephemeral-storage: 1Gi\n",
+			expectError: true,
+		},
+		{
+			name:        "negative request",
+			data:        "machineConfigServer:\n  resourceRequests:\n    cpu: -1\n",
+			expectError: true,
+		},
+		{
+			name: "controller tuning",
+			data: "controllerTuning:\n  resyncPeriod: 1h\n  queueQPS: 5\n",
+		},
+		{
+			name:        "controller tuning out of bounds",
+			data:        "controllerTuning:\n  resyncPeriod: 1m\n",
+			expectError: true,
+		},
+		{
+			name:        "controller tuning burst below the default QPS",
+			data:        "controllerTuning:\n  queueBurst: 5\n",
+			expectError: true,
+		},
+		{
+			name:        "equal toleration without key",
+			data:        "machineConfigController:\n  tolerations:\n  - operator: Equal\n    value: foo\n",
+			expectError: true,
+		},
+		{
+			name: "templates image",
+			data: "templatesImage: quay.io/example/mco-templates@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef\n",
+		},
+		{
+			name:        "templates image by tag",
+			data:        "templatesImage: quay.io/example/mco-templates:latest\n",
+			expectError: true,
+		},
+		{
+			name:        "unknown toleration operator",
+			data:        "machineConfigController:\n  tolerations:\n  - key: foo\n    operator: Maybe\n",
+			expectError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseCustomizations([]byte(tc.data))
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestApplyCustomizations(t *testing.T) {
+	c, err := parseCustomizations([]byte(`
+machineConfigController:
+  nodeSelector:
+    node-role.kubernetes.io/infra: ""
+  tolerations:
+  - key: node-role.kubernetes.io/infra
+    operator: Exists
+    effect: NoSchedule
+  resourceRequests:
+    cpu: 50m
+`))
+	require.NoError(t, err)
+
+	config := &renderConfig{
+		TargetNamespace: "openshift-machine-config-operator",
+		Images:          &RenderConfigImages{MachineConfigOperator: "mco", OauthProxy: "oauth-proxy"},
+	}
+	mccBytes, err := renderAsset(config, "manifests/machineconfigcontroller/deployment.yaml")
+	require.NoError(t, err)
+	mcc := resourceread.ReadDeploymentV1OrDie(mccBytes)
+	manifest := mcc.DeepCopy()
+	c.applyCustomizations(mcc.Name, &mcc.Spec.Template.Spec)
+
+	spec := mcc.Spec.Template.Spec
+	assert.Equal(t, "", spec.NodeSelector["node-role.kubernetes.io/infra"])
+	assert.Contains(t, spec.NodeSelector, "node-role.kubernetes.io/master")
+
+	// dropping the customization drops its node selector from the workload
+	existing := mcc.DeepCopy()
+	modified := resourcemerge.BoolPtr(false)
+	resourcemerge.EnsureDeployment(modified, existing, *manifest)
+	assert.True(t, *modified)
+	assert.Equal(t, manifest.Spec.Template.Spec.NodeSelector, existing.Spec.Template.Spec.NodeSelector)
+	assert.Contains(t, spec.Tolerations, corev1.Toleration{Key: "node-role.kubernetes.io/infra", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule})
+	for _, container := range spec.Containers {
+		if container.Name == mccWorkloadName {
+			assert.Equal(t, resource.MustParse("50m"), container.Resources.Requests[corev1.ResourceCPU])
+			// requests that weren't customized are kept
+			assert.Contains(t, container.Resources.Requests, corev1.ResourceMemory)
+		} else {
+			assert.NotEqual(t, resource.MustParse("50m"), container.Resources.Requests[corev1.ResourceCPU])
+		}
+	}
+
+	// workloads without customizations are left alone
+	mcdBytes, err := renderAsset(config, mcdDaemonsetManifestPath)
+	require.NoError(t, err)
+	mcd := resourceread.ReadDaemonSetV1OrDie(mcdBytes)
+	expected := mcd.DeepCopy()
+	c.applyCustomizations(mcd.Name, &mcd.Spec.Template.Spec)
+	assert.Equal(t, expected, mcd)
+
+	var none *operatorCustomizations
+	none.applyCustomizations(mcd.Name, &mcd.Spec.Template.Spec)
+	assert.Equal(t, expected, mcd)
+}
+
+func TestApplyCustomizationsTolerations(t *testing.T) {
+	// the first toleration is also in the manifest, the second shares its key
+	c, err := parseCustomizations([]byte(`
+machineConfigController:
+  tolerations:
+  - key: node-role.kubernetes.io/master
+    operator: Exists
+    effect: NoSchedule
+  - key: node-role.kubernetes.io/master
+    operator: Exists
+    effect: NoExecute
+`))
+	require.NoError(t, err)
+
+	config := &renderConfig{
+		TargetNamespace: "openshift-machine-config-operator",
+		Images:          &RenderConfigImages{MachineConfigOperator: "mco", OauthProxy: "oauth-proxy"},
+	}
+	mccBytes, err := renderAsset(config, "manifests/machineconfigcontroller/deployment.yaml")
+	require.NoError(t, err)
+	manifest := resourceread.ReadDeploymentV1OrDie(mccBytes)
+	mcc := manifest.DeepCopy()
+	c.applyCustomizations(mcc.Name, &mcc.Spec.Template.Spec)
+
+	tolerations := mcc.Spec.Template.Spec.Tolerations
+	assert.Len(t, tolerations, len(manifest.Spec.Template.Spec.Tolerations)+1)
+	assert.Contains(t, tolerations, corev1.Toleration{Key: "node-role.kubernetes.io/master", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute})
+
+	// dropping the customization drops its toleration from the workload
+	existing := mcc.DeepCopy()
+	resourcemerge.EnsureDeployment(resourcemerge.BoolPtr(false), existing, *manifest)
+	assert.Equal(t, manifest.Spec.Template.Spec.Tolerations, existing.Spec.Template.Spec.Tolerations)
+}
+
+func TestApplyCustomizationsTemplatesImage(t *testing.T) {
+	image := "quay.io/example/mco-templates@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	c, err := parseCustomizations([]byte("templatesImage: " + image + "\n"))
+	require.NoError(t, err)
+
+	config := &renderConfig{
+		TargetNamespace: "openshift-machine-config-operator",
+		Images:          &RenderConfigImages{MachineConfigOperator: "mco", OauthProxy: "oauth-proxy"},
+	}
+	mccBytes, err := renderAsset(config, "manifests/machineconfigcontroller/deployment.yaml")
+	require.NoError(t, err)
+	mcc := resourceread.ReadDeploymentV1OrDie(mccBytes)
+	c.applyCustomizations(mcc.Name, &mcc.Spec.Template.Spec)
+
+	for _, container := range mcc.Spec.Template.Spec.Containers {
+		if container.Name == mccWorkloadName {
+			assert.Contains(t, container.Args, "--templates-image="+image)
+		} else {
+			assert.NotContains(t, container.Args, "--templates-image="+image)
+		}
+	}
+
+	// only the controller renders templates
+	mcdBytes, err := renderAsset(config, mcdDaemonsetManifestPath)
+	require.NoError(t, err)
+	mcd := resourceread.ReadDaemonSetV1OrDie(mcdBytes)
+	expected := mcd.DeepCopy()
+	c.applyCustomizations(mcd.Name, &mcd.Spec.Template.Spec)
+	assert.Equal(t, expected, mcd)
+}
+
+func TestApplyCustomizationsControllerTuning(t *testing.T) {
+	c, err := parseCustomizations([]byte("controllerTuning:\n  resyncPeriod: 2h\n  queueMaxDelay: 5m\n  queueQPS: 20\n  queueBurst: 200\n"))
+	require.NoError(t, err)
+
+	config := &renderConfig{
+		TargetNamespace: "openshift-machine-config-operator",
+		Images:          &RenderConfigImages{MachineConfigOperator: "mco", OauthProxy: "oauth-proxy"},
+	}
+	mccBytes, err := renderAsset(config, "manifests/machineconfigcontroller/deployment.yaml")
+	require.NoError(t, err)
+	mcc := resourceread.ReadDeploymentV1OrDie(mccBytes)
+	expected := mcc.DeepCopy()
+	c.applyCustomizations(mcc.Name, &mcc.Spec.Template.Spec)
+
+	for i, container := range mcc.Spec.Template.Spec.Containers {
+		args := expected.Spec.Template.Spec.Containers[i].Args
+		if container.Name == mccWorkloadName {
+			args = append(args, "--resync-period=2h0m0s", "--queue-max-delay=5m0s", "--queue-qps=20", "--queue-burst=200")
+		}
+		assert.Equal(t, args, container.Args, container.Name)
+	}
+}
+
+func TestKeepLegacyBootstrapToken(t *testing.T) {
+	credentials := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: bootstrapcredentials.SecretName, Namespace: ctrlcommon.MCONamespace}}
+	cases := []struct {
+		name           string
+		customizations *operatorCustomizations
+		objects        []runtime.Object
+		keep           bool
+	}{{
+		name:    "no customizations",
+		objects: []runtime.Object{credentials},
+		keep:    true,
+	}, {
+		name:           "not opted in",
+		customizations: &operatorCustomizations{},
+		objects:        []runtime.Object{credentials},
+		keep:           true,
+	}, {
+		name:           "opted in before the credentials exist",
+		customizations: &operatorCustomizations{RemoveLegacyBootstrapToken: true},
+		keep:           true,
+	}, {
+		name:           "opted in",
+		customizations: &operatorCustomizations{RemoveLegacyBootstrapToken: true},
+		objects:        []runtime.Object{credentials},
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			optr := &Operator{
+				namespace:  ctrlcommon.MCONamespace,
+				kubeClient: fake.NewSimpleClientset(c.objects...),
+			}
+			keep, err := optr.keepLegacyBootstrapToken(&renderConfig{customizations: c.customizations})
+			require.NoError(t, err)
+			assert.Equal(t, c.keep, keep)
+		})
+	}
+}
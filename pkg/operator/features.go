@@ -0,0 +1,43 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/version"
+)
+
+const (
+	// supportedFeaturesConfigMapName is the name of the configmap in the MCO namespace
+	// publishing the MachineConfig features supported by the running operator.
+	supportedFeaturesConfigMapName = "machine-config-supported-features"
+	// supportedFeaturesConfigMapKey is the key of the JSON feature schema in the configmap.
+	supportedFeaturesConfigMapKey = "schema.json"
+)
+
+// syncSupportedFeatures publishes the feature schema of the operator, so
+// GitOps pipelines and validation tools can check MachineConfigs against the
+// version of the cluster before applying them.
+func (optr *Operator) syncSupportedFeatures(_ *renderConfig) error {
+	releaseVersion, _ := optr.vStore.Get("operator")
+	schema, err := json.MarshalIndent(ctrlcommon.NewFeatureSchema(releaseVersion, version.Hash), "", "  ")
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      supportedFeaturesConfigMapName,
+			Namespace: optr.namespace,
+		},
+		Data: map[string]string{
+			supportedFeaturesConfigMapKey: string(schema),
+		},
+	}
+	_, _, err = resourceapply.ApplyConfigMap(context.TODO(), optr.kubeClient.CoreV1(), optr.libgoRecorder, cm)
+	return err
+}
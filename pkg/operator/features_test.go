@@ -0,0 +1,33 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+func TestSyncSupportedFeatures(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	optr := &Operator{
+		namespace:     ctrlcommon.MCONamespace,
+		kubeClient:    client,
+		libgoRecorder: events.NewInMemoryRecorder("test"),
+		vStore:        newVersionStore(),
+	}
+	optr.vStore.Set("operator", "4.10.0")
+
+	require.NoError(t, optr.syncSupportedFeatures(nil))
+	cm, err := client.CoreV1().ConfigMaps(ctrlcommon.MCONamespace).Get(context.TODO(), supportedFeaturesConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	var schema ctrlcommon.FeatureSchema
+	require.NoError(t, json.Unmarshal([]byte(cm.Data[supportedFeaturesConfigMapKey]), &schema))
+	assert.Equal(t, ctrlcommon.NewFeatureSchema("4.10.0", schema.OperatorVersion), schema)
+}
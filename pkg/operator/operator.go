@@ -74,6 +74,7 @@ type Operator struct {
 
 	crdLister        apiextlistersv1.CustomResourceDefinitionLister
 	mcpLister        mcfglistersv1.MachineConfigPoolLister
+	mcfLister        mcfglistersv1.MachineConfigFreezeLister
 	ccLister         mcfglistersv1.ControllerConfigLister
 	mcLister         mcfglistersv1.MachineConfigLister
 	deployLister     appslisterv1.DeploymentLister
@@ -86,6 +87,7 @@ type Operator struct {
 	oseKubeAPILister corelisterv1.ConfigMapLister
 	nodeLister       corelisterv1.NodeLister
 	dnsLister        configlistersv1.DNSLister
+	imgLister        configlistersv1.ImageLister
 
 	crdListerSynced                  cache.InformerSynced
 	deployListerSynced               cache.InformerSynced
@@ -93,6 +95,7 @@ type Operator struct {
 	infraListerSynced                cache.InformerSynced
 	networkListerSynced              cache.InformerSynced
 	mcpListerSynced                  cache.InformerSynced
+	mcfListerSynced                  cache.InformerSynced
 	ccListerSynced                   cache.InformerSynced
 	mcListerSynced                   cache.InformerSynced
 	mcoCmListerSynced                cache.InformerSynced
@@ -104,6 +107,7 @@ type Operator struct {
 	oseKubeAPIListerSynced           cache.InformerSynced
 	nodeListerSynced                 cache.InformerSynced
 	dnsListerSynced                  cache.InformerSynced
+	imgListerSynced                  cache.InformerSynced
 	maoSecretInformerSynced          cache.InformerSynced
 
 	// queue only ever has one item, but it has nice error handling backoff/retry semantics
@@ -118,6 +122,7 @@ type Operator struct {
 func New(
 	namespace, name, imagesFile string,
 	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	mcfInformer mcfginformersv1.MachineConfigFreezeInformer,
 	mcInformer mcfginformersv1.MachineConfigInformer,
 	controllerConfigInformer mcfginformersv1.ControllerConfigInformer,
 	serviceAccountInfomer coreinformersv1.ServiceAccountInformer,
@@ -132,6 +137,7 @@ func New(
 	networkInformer configinformersv1.NetworkInformer,
 	proxyInformer configinformersv1.ProxyInformer,
 	dnsInformer configinformersv1.DNSInformer,
+	imgInformer configinformersv1.ImageInformer,
 	client mcfgclientset.Interface,
 	kubeClient kubernetes.Interface,
 	apiExtClient apiextclientset.Interface,
@@ -175,10 +181,12 @@ func New(
 		infraInformer.Informer(),
 		networkInformer.Informer(),
 		mcpInformer.Informer(),
+		mcfInformer.Informer(),
 		proxyInformer.Informer(),
 		oseKubeAPIInformer.Informer(),
 		nodeInformer.Informer(),
 		dnsInformer.Informer(),
+		imgInformer.Informer(),
 		maoSecretInformer.Informer(),
 	} {
 		i.AddEventHandler(optr.eventHandler())
@@ -190,6 +198,8 @@ func New(
 	optr.clusterCmListerSynced = clusterCmInfomer.Informer().HasSynced
 	optr.mcpLister = mcpInformer.Lister()
 	optr.mcpListerSynced = mcpInformer.Informer().HasSynced
+	optr.mcfLister = mcfInformer.Lister()
+	optr.mcfListerSynced = mcfInformer.Informer().HasSynced
 	optr.ccLister = controllerConfigInformer.Lister()
 	optr.ccListerSynced = controllerConfigInformer.Informer().HasSynced
 	optr.mcLister = mcInformer.Lister()
@@ -219,6 +229,8 @@ func New(
 	optr.networkListerSynced = networkInformer.Informer().HasSynced
 	optr.dnsLister = dnsInformer.Lister()
 	optr.dnsListerSynced = dnsInformer.Informer().HasSynced
+	optr.imgLister = imgInformer.Lister()
+	optr.imgListerSynced = imgInformer.Informer().HasSynced
 
 	optr.vStore.Set("operator", os.Getenv("RELEASE_VERSION"))
 
@@ -257,8 +269,10 @@ func (optr *Operator) Run(workers int, stopCh <-chan struct{}) {
 		optr.oseKubeAPIListerSynced,
 		optr.nodeListerSynced,
 		optr.mcpListerSynced,
+		optr.mcfListerSynced,
 		optr.mcListerSynced,
-		optr.dnsListerSynced) {
+		optr.dnsListerSynced,
+		optr.imgListerSynced) {
 		glog.Error("failed to sync caches")
 		return
 	}
@@ -362,6 +376,7 @@ func (optr *Operator) sync(key string) error {
 		{"MachineConfigDaemon", optr.syncMachineConfigDaemon},
 		{"MachineConfigController", optr.syncMachineConfigController},
 		{"MachineConfigServer", optr.syncMachineConfigServer},
+		{"SupportedFeatures", optr.syncSupportedFeatures},
 		// this check must always run last since it makes sure the pools are in sync/upgrading correctly
 		{"RequiredPools", optr.syncRequiredMachineConfigPools},
 	}
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net"
+	"runtime"
 	"strings"
 	"text/template"
 
@@ -32,6 +33,9 @@ type renderConfig struct {
 	Infra                  configv1.Infrastructure
 	Constants              map[string]string
 	PointerConfig          string
+
+	// customizations are applied to the managed workloads after rendering
+	customizations *operatorCustomizations
 }
 
 type assetRenderer struct {
@@ -88,7 +92,7 @@ func renderAsset(config *renderConfig, path string) ([]byte, error) {
 	}
 
 	if config.Constants == nil {
-		config.Constants = constants.ConstantsByName
+		config.Constants = constants.TemplateConstants()
 	}
 
 	asset.addTemplateFuncs()
@@ -141,6 +145,7 @@ func createDiscoveredControllerConfigSpec(infra *configv1.Infrastructure, networ
 
 	ccSpec := &mcfgv1.ControllerConfigSpec{
 		ClusterDNSIP:        dnsIP,
+		ServiceNetwork:      append([]string{}, network.Spec.ServiceNetwork...),
 		IPFamilies:          ipFamilies,
 		CloudProviderConfig: "",
 		// EtcdDiscoveryDomain is unused and deprecated in favour of using Infra.Status.EtcdDiscoveryDomain directly
@@ -149,8 +154,10 @@ func createDiscoveredControllerConfigSpec(infra *configv1.Infrastructure, networ
 		// Platform is unused and deprecated in favour of using Infra.Status.PlatformStatus.Type directly
 		// Still populating it here for now until it will be removed eventually
 		Platform: platform,
-		Infra:    infra,
-		DNS:      dns,
+		// The payload images are built for the architecture of the cluster.
+		Architecture: nodeArchitecture(runtime.GOARCH),
+		Infra:        infra,
+		DNS:          dns,
 	}
 	if network.Status.NetworkType == "" {
 		// At install time, when CNO has not started, status is unset, use the value in spec.
@@ -181,6 +188,20 @@ func createDiscoveredControllerConfigSpec(infra *configv1.Infrastructure, networ
 	return ccSpec, nil
 }
 
+// nodeArchitecture returns the architecture, as reported by uname -m, of the
+// nodes running binaries built for GOARCH goarch.
+func nodeArchitecture(goarch string) string {
+	switch goarch {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "aarch64"
+	default:
+		// s390x and ppc64le have the same names
+		return goarch
+	}
+}
+
 func clusterDNSIP(iprange string) (string, error) {
 	_, network, err := net.ParseCIDR(iprange)
 	if err != nil {
@@ -2,6 +2,8 @@ package operator
 
 import (
 	"fmt"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -110,6 +112,25 @@ func TestRenderAsset(t *testing.T) {
 			TargetNamespace: "testing-namespace",
 		},
 		Error: true,
+	}, {
+		// Test that the controller gets the proxy config too, for the image mirror checks
+		Path: "manifests/machineconfigcontroller/deployment.yaml",
+		RenderConfig: &renderConfig{
+			TargetNamespace: "testing-namespace",
+			Images: &RenderConfigImages{
+				MachineConfigOperator: "mco-operator-image",
+			},
+			ControllerConfig: mcfgv1.ControllerConfigSpec{
+				Proxy: &configv1.ProxyStatus{
+					HTTPProxy: "http://i.am.a.proxy.server",
+					NoProxy:   ".cluster.local",
+				},
+			},
+		},
+		FindExpected: []string{
+			"- name: HTTP_PROXY\n          value: http://i.am.a.proxy.server",
+			"- name: NO_PROXY\n          value: \".cluster.local\"",
+		},
 	}, {
 		// Test that machineconfigdaemon DaemonSets are rendered correctly with proxy config
 		Path: "manifests/machineconfigdaemon/daemonset.yaml",
@@ -187,6 +208,17 @@ func TestRenderAsset(t *testing.T) {
 	}
 }
 
+func TestNodeArchitecture(t *testing.T) {
+	for goarch, arch := range map[string]string{
+		"amd64":   "x86_64",
+		"arm64":   "aarch64",
+		"s390x":   "s390x",
+		"ppc64le": "ppc64le",
+	} {
+		assert.Equal(t, arch, nodeArchitecture(goarch), goarch)
+	}
+}
+
 func TestCreateDiscoveredControllerConfigSpec(t *testing.T) {
 	tests := []struct {
 		Infra   *configv1.Infrastructure
@@ -281,6 +313,12 @@ func TestCreateDiscoveredControllerConfigSpec(t *testing.T) {
 			if etcdDomain != testDomain {
 				t.Fatalf("%s failed: got = %s want = %s", desc, etcdDomain, testDomain)
 			}
+			if arch := nodeArchitecture(runtime.GOARCH); controllerConfigSpec.Architecture != arch {
+				t.Fatalf("%s failed: got = %s want = %s", desc, controllerConfigSpec.Architecture, arch)
+			}
+			if !reflect.DeepEqual(controllerConfigSpec.ServiceNetwork, test.Network.Spec.ServiceNetwork) {
+				t.Fatalf("%s failed: got = %v want = %v", desc, controllerConfigSpec.ServiceNetwork, test.Network.Spec.ServiceNetwork)
+			}
 			if test.Proxy != nil {
 				testURL := test.Proxy.Status.HTTPProxy
 				controllerURL := controllerConfigSpec.Proxy.HTTPProxy
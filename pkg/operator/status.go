@@ -72,6 +72,10 @@ func (optr *Operator) syncRelatedObjects() error {
 		{Group: "machineconfiguration.openshift.io", Resource: "controllerconfigs"},
 		{Group: "machineconfiguration.openshift.io", Resource: "kubeletconfigs"},
 		{Group: "machineconfiguration.openshift.io", Resource: "containerruntimeconfigs"},
+		{Group: "machineconfiguration.openshift.io", Resource: "updatewaves"},
+		{Group: "machineconfiguration.openshift.io", Resource: "maintenancetasks"},
+		{Group: "machineconfiguration.openshift.io", Resource: "machineconfigprofiles"},
+		{Group: "machineconfiguration.openshift.io", Resource: "machineconfignodes"},
 		{Group: "machineconfiguration.openshift.io", Resource: "machineconfigs"},
 		// gathered because the machineconfigs created container bootstrap credentials and node configuration that gets reflected via the API and is needed for debugging
 		{Group: "", Resource: "nodes"},
@@ -294,6 +298,29 @@ func (optr *Operator) syncUpgradeableStatus() error {
 		Status: configv1.ConditionTrue,
 		Reason: asExpectedReason,
 	}
+
+	// a cluster-wide freeze is an explicit admin decision during an incident
+	// and gets top billing over everything else
+	freeze, err := ctrlcommon.GetClusterFreeze(optr.mcfLister)
+	if err != nil {
+		return err
+	}
+	if freeze != nil {
+		coStatus.Status = configv1.ConditionFalse
+		coStatus.Reason = "ClusterFrozen"
+		coStatus.Message = freeze.String()
+		if !isUpgradeableFrozen(co) {
+			mcoObjectRef := &corev1.ObjectReference{
+				Kind:      co.Kind,
+				Name:      co.Name,
+				Namespace: co.Namespace,
+				UID:       co.GetUID(),
+			}
+			optr.eventRecorder.Eventf(mcoObjectRef, corev1.EventTypeWarning, coStatus.Reason, coStatus.Message)
+		}
+		return optr.updateStatus(co, coStatus)
+	}
+
 	var updating, degraded bool
 	for _, pool := range pools {
 		// collect updating status but continue to check each pool to see if any pool is degraded
@@ -352,6 +379,12 @@ func (optr *Operator) syncUpgradeableStatus() error {
 	return optr.updateStatus(co, coStatus)
 }
 
+// isUpgradeableFrozen returns true if the ClusterOperator already reports the cluster-wide freeze.
+func isUpgradeableFrozen(co *configv1.ClusterOperator) bool {
+	cond := cov1helpers.FindStatusCondition(co.Status.Conditions, configv1.OperatorUpgradeable)
+	return cond != nil && cond.Reason == "ClusterFrozen"
+}
+
 // isKubeletSkewSupported checks the version skew of kube-apiserver and node kubelet version.
 // Returns the skew status. version skew > 2 is not supported.
 func (optr *Operator) isKubeletSkewSupported(pools []*v1.MachineConfigPool) (skewStatus string, coStatus configv1.ClusterOperatorStatusCondition, err error) {
@@ -537,6 +570,9 @@ func (optr *Operator) setOperatorStatusExtension(status *configv1.ClusterOperato
 	if statusErr != nil {
 		statuses["lastSyncError"] = statusErr.Error()
 	}
+	if freeze, err := ctrlcommon.GetClusterFreeze(optr.mcfLister); err == nil && freeze != nil {
+		statuses["freeze"] = freeze.String()
+	}
 	raw, err := json.Marshal(statuses)
 	if err != nil {
 		glog.Error(err)
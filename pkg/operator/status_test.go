@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	corelisterv1 "k8s.io/client-go/listers/core/v1"
 	clientgotesting "k8s.io/client-go/testing"
@@ -24,6 +25,7 @@ import (
 	cov1helpers "github.com/openshift/library-go/pkg/config/clusteroperator/v1helpers"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
 	"github.com/openshift/machine-config-operator/test/helpers"
 )
 
@@ -601,6 +603,8 @@ func TestOperatorSyncStatus(t *testing.T) {
 
 		nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 		optr.nodeLister = corelisterv1.NewNodeLister(nodeIndexer)
+		optr.mcoCmLister = corelisterv1.NewConfigMapLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}))
+		optr.mcfLister = mcfglistersv1.NewMachineConfigFreezeLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}))
 		nodeIndexer.Add(&corev1.Node{
 			ObjectMeta: metav1.ObjectMeta{Name: "first-node", Labels: map[string]string{"node-role/worker": ""}},
 			Status: corev1.NodeStatus{
@@ -672,6 +676,8 @@ func TestInClusterBringUpStayOnErr(t *testing.T) {
 	}
 	nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 	optr.nodeLister = corelisterv1.NewNodeLister(nodeIndexer)
+	optr.mcoCmLister = corelisterv1.NewConfigMapLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}))
+	optr.mcfLister = mcfglistersv1.NewMachineConfigFreezeLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}))
 	nodeIndexer.Add(&corev1.Node{
 		ObjectMeta: metav1.ObjectMeta{Name: "first-node", Labels: map[string]string{"node-role/worker": ""}},
 		Status: corev1.NodeStatus{
@@ -734,6 +740,8 @@ func TestKubeletSkewUnSupported(t *testing.T) {
 	}
 	nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 	optr.nodeLister = corelisterv1.NewNodeLister(nodeIndexer)
+	optr.mcoCmLister = corelisterv1.NewConfigMapLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}))
+	optr.mcfLister = mcfglistersv1.NewMachineConfigFreezeLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}))
 	nodeIndexer.Add(&corev1.Node{
 		ObjectMeta: metav1.ObjectMeta{Name: "first-node", Labels: map[string]string{"node-role/worker": ""}},
 		Status: corev1.NodeStatus{
@@ -822,6 +830,8 @@ func TestCustomPoolKubeletSkewUnSupported(t *testing.T) {
 	}
 	nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 	optr.nodeLister = corelisterv1.NewNodeLister(nodeIndexer)
+	optr.mcoCmLister = corelisterv1.NewConfigMapLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}))
+	optr.mcfLister = mcfglistersv1.NewMachineConfigFreezeLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}))
 	nodeIndexer.Add(&corev1.Node{
 		ObjectMeta: metav1.ObjectMeta{Name: "custom", Labels: map[string]string{"node-role/custom": ""}},
 		Status: corev1.NodeStatus{
@@ -908,6 +918,8 @@ func TestKubeletSkewSupported(t *testing.T) {
 	}
 	nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 	optr.nodeLister = corelisterv1.NewNodeLister(nodeIndexer)
+	optr.mcoCmLister = corelisterv1.NewConfigMapLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}))
+	optr.mcfLister = mcfglistersv1.NewMachineConfigFreezeLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}))
 	nodeIndexer.Add(&corev1.Node{
 		ObjectMeta: metav1.ObjectMeta{Name: "first-node", Labels: map[string]string{"node-role/worker": ""}},
 		Status: corev1.NodeStatus{
@@ -1007,3 +1019,57 @@ func TestGetMinorKubeletVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestClusterFrozenNotUpgradeable(t *testing.T) {
+	optr := &Operator{
+		eventRecorder: &record.FakeRecorder{},
+	}
+	optr.vStore = newVersionStore()
+	optr.vStore.Set("operator", "test-version")
+	optr.mcpLister = &mockMCPLister{
+		pools: []*mcfgv1.MachineConfigPool{
+			helpers.NewMachineConfigPool("master", nil, helpers.MasterSelector, "v0"),
+			helpers.NewMachineConfigPool("workers", nil, helpers.WorkerSelector, "v0"),
+		},
+	}
+	nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	optr.nodeLister = corelisterv1.NewNodeLister(nodeIndexer)
+	mcfIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	optr.mcfLister = mcfglistersv1.NewMachineConfigFreezeLister(mcfIndexer)
+	frozenAt := metav1.NewTime(time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC))
+	mcfIndexer.Add(&mcfgv1.MachineConfigFreeze{
+		ObjectMeta: metav1.ObjectMeta{Name: mcfgv1.MachineConfigFreezeName},
+		Spec: mcfgv1.MachineConfigFreezeSpec{
+			Frozen:   true,
+			Reason:   "incident 1234",
+			FrozenBy: "alice",
+			FrozenAt: &frozenAt,
+		},
+	})
+
+	co := &configv1.ClusterOperator{}
+	fakeClient := fakeconfigclientset.NewSimpleClientset(co)
+	optr.configClient = fakeClient
+
+	err := optr.syncUpgradeableStatus()
+	assert.Nil(t, err)
+
+	var lastUpdate clientgotesting.UpdateAction
+	for _, action := range fakeClient.Actions() {
+		if action.GetVerb() == "update" {
+			lastUpdate = action.(clientgotesting.UpdateAction)
+		}
+	}
+	if lastUpdate == nil {
+		t.Fatal("missing update")
+	}
+	operatorStatus := lastUpdate.GetObject().(*configv1.ClusterOperator)
+	upgradeable := cov1helpers.FindStatusCondition(operatorStatus.Status.Conditions, configv1.OperatorUpgradeable)
+	if upgradeable == nil {
+		t.Fatal("missing condition")
+	}
+	assert.Equal(t, configv1.ConditionFalse, upgradeable.Status)
+	assert.Equal(t, "ClusterFrozen", upgradeable.Reason)
+	assert.Equal(t, "Node updates are frozen cluster-wide by alice since 2022-03-01T12:00:00Z: incident 1234", upgradeable.Message)
+	assert.Contains(t, string(operatorStatus.Status.Extension.Raw), "incident 1234")
+}
@@ -14,8 +14,11 @@ import (
 	"strings"
 	"time"
 
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/ghodss/yaml"
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
+	"github.com/vincent-petithory/dataurl"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -34,6 +37,7 @@ import (
 	mcoResourceRead "github.com/openshift/machine-config-operator/lib/resourceread"
 	"github.com/openshift/machine-config-operator/manifests"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/controller/bootstrapcredentials"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	templatectrl "github.com/openshift/machine-config-operator/pkg/controller/template"
 	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
@@ -43,6 +47,14 @@ import (
 
 const (
 	requiredForUpgradeMachineConfigPoolLabelKey = "operator.machineconfiguration.openshift.io/required-for-upgrade"
+
+	// pointerIgnitionPoolLabelKey is set on the pointer Ignition secrets to the name of their pool.
+	pointerIgnitionPoolLabelKey = "machineconfiguration.openshift.io/pointer-ignition-pool"
+
+	// clusterConfigConfigMapName is the ConfigMap in kube-system holding the
+	// install-config of the cluster under installConfigKey.
+	clusterConfigConfigMapName = "cluster-config-v1"
+	installConfigKey           = "install-config"
 )
 
 var (
@@ -300,10 +312,37 @@ func (optr *Operator) syncRenderConfig(_ *renderConfig) error {
 	}
 	spec.AdditionalTrustBundle = trustBundle
 
+	// the image config is only populated once the image registry operator is running
+	image, err := optr.imgLister.Get("cluster")
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if image != nil && (image.Status.InternalRegistryHostname != "" || len(image.Status.ExternalRegistryHostnames) > 0) {
+		spec.Image = &image.Status
+	}
+
 	if err := optr.syncCloudConfig(spec, infra); err != nil {
 		return err
 	}
 
+	// FIPS mode is set at install time, on clusters installed without the
+	// ConfigMap it's off
+	clusterConfig, err := optr.clusterCmLister.ConfigMaps("kube-system").Get(clusterConfigConfigMapName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if clusterConfig != nil {
+		if spec.FIPS, err = installConfigFIPS(clusterConfig); err != nil {
+			return err
+		}
+	}
+
+	cgroupMode, err := optr.getCgroupMode()
+	if err != nil {
+		return err
+	}
+	spec.CgroupMode = cgroupMode
+
 	spec.KubeAPIServerServingCAData = kubeAPIServerServingCABytes
 	spec.RootCAData = bundle
 	spec.PullSecret = &corev1.ObjectReference{Namespace: "openshift-config", Name: "pull-secret"}
@@ -333,8 +372,14 @@ func (optr *Operator) syncRenderConfig(_ *renderConfig) error {
 		return err
 	}
 
+	customizations, err := optr.getCustomizations()
+	if err != nil {
+		return err
+	}
+
 	// create renderConfig
 	optr.renderConfig = getRenderConfig(optr.namespace, string(kubeAPIServerServingCABytes), spec, &imgs.RenderConfigImages, infra.Status.APIServerInternalURL, pointerConfigData)
+	optr.renderConfig.customizations = customizations
 	return nil
 }
 
@@ -442,8 +487,79 @@ func (optr *Operator) syncMachineConfigPools(config *renderConfig) error {
 		if err != nil {
 			return err
 		}
+
+		if err := optr.applyPointerIgnitionSecret(pool.Name, pointerConfigData); err != nil {
+			return err
+		}
 	}
 
+	return optr.prunePointerIgnitionSecrets(pools)
+}
+
+// applyPointerIgnitionSecret applies the secret holding the pointer Ignition
+// config of the pool, along with the MCS URL and CA it points to, for
+// provisioning tools which don't go through the machine-api user-data.
+func (optr *Operator) applyPointerIgnitionSecret(pool string, pointerConfigData []byte) error {
+	var pointerConfig ign3types.Config
+	if err := json.Unmarshal(pointerConfigData, &pointerConfig); err != nil {
+		return fmt.Errorf("failed to parse pointer config of pool %s: %w", pool, err)
+	}
+	if len(pointerConfig.Ignition.Config.Merge) != 1 || pointerConfig.Ignition.Config.Merge[0].Source == nil {
+		return fmt.Errorf("pointer config of pool %s doesn't merge a single config", pool)
+	}
+	var ca []byte
+	for _, res := range pointerConfig.Ignition.Security.TLS.CertificateAuthorities {
+		if res.Source == nil {
+			continue
+		}
+		du, err := dataurl.DecodeString(*res.Source)
+		if err != nil {
+			return fmt.Errorf("failed to decode CA of the pointer config of pool %s: %w", pool, err)
+		}
+		ca = append(ca, du.Data...)
+	}
+
+	asset := newAssetRenderer("manifests/pointer_ignition_secret.yaml")
+	if err := asset.read(); err != nil {
+		return err
+	}
+	secretBytes, err := asset.render(struct{ Role, PointerConfig, URL, CA string }{
+		pool,
+		base64.StdEncoding.EncodeToString(pointerConfigData),
+		base64.StdEncoding.EncodeToString([]byte(*pointerConfig.Ignition.Config.Merge[0].Source)),
+		base64.StdEncoding.EncodeToString(ca),
+	})
+	if err != nil {
+		return err
+	}
+	secret := resourceread.ReadSecretV1OrDie(secretBytes)
+	_, _, err = resourceapply.ApplySecret(context.TODO(), optr.kubeClient.CoreV1(), optr.libgoRecorder, secret)
+	return err
+}
+
+// prunePointerIgnitionSecrets deletes the pointer Ignition secrets of pools
+// which no longer exist.
+func (optr *Operator) prunePointerIgnitionSecrets(pools []*mcfgv1.MachineConfigPool) error {
+	poolNames := sets.NewString()
+	for _, pool := range pools {
+		poolNames.Insert(pool.Name)
+	}
+	secrets, err := optr.kubeClient.CoreV1().Secrets(ctrlcommon.MCONamespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: pointerIgnitionPoolLabelKey,
+	})
+	if err != nil {
+		return err
+	}
+	for _, secret := range secrets.Items {
+		if poolNames.Has(secret.Labels[pointerIgnitionPoolLabelKey]) {
+			continue
+		}
+		glog.Infof("Deleting pointer Ignition secret %s of deleted pool %s", secret.Name, secret.Labels[pointerIgnitionPoolLabelKey])
+		err := optr.kubeClient.CoreV1().Secrets(ctrlcommon.MCONamespace).Delete(context.TODO(), secret.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -514,6 +630,7 @@ func (optr *Operator) applyManifests(config *renderConfig, paths manifestPaths)
 			return err
 		}
 		d := resourceread.ReadDaemonSetV1OrDie(dBytes)
+		config.customizations.applyCustomizations(d.Name, &d.Spec.Template.Spec)
 		_, updated, err := mcoResourceApply.ApplyDaemonSet(optr.kubeClient.AppsV1(), d)
 		if err != nil {
 			return err
@@ -552,6 +669,7 @@ func (optr *Operator) syncMachineConfigController(config *renderConfig) error {
 		return err
 	}
 	mcc := resourceread.ReadDeploymentV1OrDie(mccBytes)
+	config.customizations.applyCustomizations(mcc.Name, &mcc.Spec.Template.Spec)
 
 	_, updated, err := mcoResourceApply.ApplyDeployment(optr.kubeClient.AppsV1(), mcc)
 	if err != nil {
@@ -633,11 +751,15 @@ func (optr *Operator) syncMachineConfigServer(config *renderConfig) error {
 			mcsServiceAccountManifestPath,
 			mcsNodeBootstrapperServiceAccountManifestPath,
 		},
-		secrets: []string{
-			mcsNodeBootstrapperTokenManifestPath,
-		},
 		daemonset: mcsDaemonsetManifestPath,
 	}
+	keepLegacyToken, err := optr.keepLegacyBootstrapToken(config)
+	if err != nil {
+		return err
+	}
+	if keepLegacyToken {
+		paths.secrets = []string{mcsNodeBootstrapperTokenManifestPath}
+	}
 	if err := optr.applyManifests(config, paths); err != nil {
 		return fmt.Errorf("failed to apply machine config server manifests: %w", err)
 	}
@@ -645,6 +767,23 @@ func (optr *Operator) syncMachineConfigServer(config *renderConfig) error {
 	return nil
 }
 
+// keepLegacyBootstrapToken returns whether the long-lived node-bootstrapper
+// token Secret is applied: unless the customizations opt into removing it, and
+// until the controller created the rotated credentials which replace it.
+func (optr *Operator) keepLegacyBootstrapToken(config *renderConfig) (bool, error) {
+	if config.customizations == nil || !config.customizations.RemoveLegacyBootstrapToken {
+		return true, nil
+	}
+	_, err := optr.kubeClient.CoreV1().Secrets(optr.namespace).Get(context.TODO(), bootstrapcredentials.SecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
 // syncRequiredMachineConfigPools ensures that all the nodes in machineconfigpools labeled with requiredForUpgradeMachineConfigPoolLabelKey
 // have updated to the latest configuration.
 func (optr *Operator) syncRequiredMachineConfigPools(_ *renderConfig) error {
@@ -902,6 +1041,22 @@ func getCloudConfigFromConfigMap(cm *corev1.ConfigMap, key string) (string, erro
 	return "", fmt.Errorf("%s not found in %s/%s", key, cm.Namespace, cm.Name)
 }
 
+// installConfigFIPS returns whether the install-config in the
+// cluster-config-v1 ConfigMap enables FIPS mode.
+func installConfigFIPS(cm *corev1.ConfigMap) (bool, error) {
+	data, ok := cm.Data[installConfigKey]
+	if !ok {
+		return false, fmt.Errorf("%s not found in %s/%s", installConfigKey, cm.Namespace, cm.Name)
+	}
+	var installConfig struct {
+		FIPS bool `json:"fips"`
+	}
+	if err := yaml.Unmarshal([]byte(data), &installConfig); err != nil {
+		return false, fmt.Errorf("parsing the %s of %s/%s: %w", installConfigKey, cm.Namespace, cm.Name, err)
+	}
+	return installConfig.FIPS, nil
+}
+
 // getGlobalConfig gets global configuration for the cluster, namely, the Infrastructure and Network types.
 // Each type of global configuration is named `cluster` for easy discovery in the cluster.
 func (optr *Operator) getGlobalConfig() (*configv1.Infrastructure, *configv1.Network, *configv1.Proxy, *configv1.DNS, error) {
@@ -924,6 +1079,21 @@ func (optr *Operator) getGlobalConfig() (*configv1.Infrastructure, *configv1.Net
 	return infra, network, proxy, dns, nil
 }
 
+// getCgroupMode returns the cgroup mode of the cluster node config, or "" if
+// there is none.  The vendored config client doesn't have a typed client for
+// nodes.config.openshift.io, so it's read through the REST client.
+func (optr *Operator) getCgroupMode() (configv1.CgroupMode, error) {
+	nodeConfig := &configv1.Node{}
+	err := optr.configClient.ConfigV1().RESTClient().Get().Resource("nodes").Name("cluster").Do(context.TODO()).Into(nodeConfig)
+	if apierrors.IsNotFound(err) {
+		return configv1.CgroupModeEmpty, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("getting the cluster node config: %w", err)
+	}
+	return nodeConfig.Spec.CgroupMode, nil
+}
+
 func getRenderConfig(tnamespace, kubeAPIServerServingCA string, ccSpec *mcfgv1.ControllerConfigSpec, imgs *RenderConfigImages, apiServerURL string, pointerConfigData []byte) *renderConfig {
 	return &renderConfig{
 		TargetNamespace:        tnamespace,
@@ -1,16 +1,21 @@
 package operator
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
 
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 )
 
 func TestSyncCloudConfig(t *testing.T) {
@@ -154,3 +159,65 @@ func withCABundle(caBundle string) kubeCloudConfigOption {
 		kubeCloudConfig.Data["ca-bundle.pem"] = caBundle
 	}
 }
+
+func TestSyncPointerIgnitionSecrets(t *testing.T) {
+	stale := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "infra-pointer-ignition",
+			Namespace: ctrlcommon.MCONamespace,
+			Labels:    map[string]string{pointerIgnitionPoolLabelKey: "infra"},
+		},
+	}
+	client := fake.NewSimpleClientset(stale)
+	optr := &Operator{
+		kubeClient:    client,
+		libgoRecorder: events.NewInMemoryRecorder("test"),
+	}
+
+	pointerConfig, err := ctrlcommon.PointerConfig("10.0.0.1:22623", []byte("test-ca"))
+	require.NoError(t, err)
+	pointerConfigTemplate, err := json.Marshal(pointerConfig)
+	require.NoError(t, err)
+	asset := newAssetRenderer("pointer-config")
+	asset.templateData = string(pointerConfigTemplate)
+	pointerConfigData, err := asset.render(struct{ Role string }{"worker"})
+	require.NoError(t, err)
+
+	require.NoError(t, optr.applyPointerIgnitionSecret("worker", pointerConfigData))
+	secret, err := client.CoreV1().Secrets(ctrlcommon.MCONamespace).Get(context.TODO(), "worker-pointer-ignition", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "worker", secret.Labels[pointerIgnitionPoolLabelKey])
+	assert.JSONEq(t, string(pointerConfigData), string(secret.Data["pointer.ign"]))
+	assert.Equal(t, "https://10.0.0.1:22623/config/worker", string(secret.Data["url"]))
+	assert.Equal(t, "test-ca", string(secret.Data["ca.crt"]))
+
+	worker := &mcfgv1.MachineConfigPool{ObjectMeta: metav1.ObjectMeta{Name: "worker"}}
+	require.NoError(t, optr.prunePointerIgnitionSecrets([]*mcfgv1.MachineConfigPool{worker}))
+	_, err = client.CoreV1().Secrets(ctrlcommon.MCONamespace).Get(context.TODO(), "worker-pointer-ignition", metav1.GetOptions{})
+	assert.NoError(t, err)
+	_, err = client.CoreV1().Secrets(ctrlcommon.MCONamespace).Get(context.TODO(), "infra-pointer-ignition", metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func TestInstallConfigFIPS(t *testing.T) {
+	newClusterConfig := func(installConfig string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: clusterConfigConfigMapName},
+			Data:       map[string]string{installConfigKey: installConfig},
+		}
+	}
+
+	fips, err := installConfigFIPS(newClusterConfig("apiVersion: v1\nbaseDomain: example.com\nfips: true\nmetadata:\n  name: test\n"))
+	require.NoError(t, err)
+	assert.True(t, fips)
+
+	fips, err = installConfigFIPS(newClusterConfig("apiVersion: v1\nbaseDomain: example.com\n"))
+	require.NoError(t, err)
+	assert.False(t, fips)
+
+	_, err = installConfigFIPS(newClusterConfig("fips: [true"))
+	assert.Error(t, err)
+
+	_, err = installConfigFIPS(&corev1.ConfigMap{})
+	assert.Error(t, err)
+}
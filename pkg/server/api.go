@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/clarketm/json"
 	"github.com/coreos/go-semver/semver"
@@ -15,6 +17,7 @@ import (
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 
+	"github.com/openshift/machine-config-operator/pkg/controller/butane"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 )
 
@@ -33,7 +36,9 @@ type poolRequest struct {
 // APIServer provides the HTTP(s) endpoint
 // for providing the machine configs.
 type APIServer struct {
-	handler  http.Handler
+	server   *http.Server
+	health   *healthHandler
+	host     string
 	port     int
 	insecure bool
 	cert     string
@@ -44,13 +49,16 @@ type APIServer struct {
 // that runs the Machine Config Server as a
 // handler.
 func NewAPIServer(a *APIHandler, p int, is bool, c, k string) *APIServer {
+	health := &healthHandler{}
 	mux := http.NewServeMux()
 	mux.Handle("/config/", a)
-	mux.Handle("/healthz", &healthHandler{})
+	mux.Handle("/butane/", &butaneHandler{server: a.server})
+	mux.Handle("/healthz", health)
 	mux.Handle("/", &defaultHandler{})
 
 	return &APIServer{
-		handler:  mux,
+		server:   getHTTPServerCfg("", mux),
+		health:   health,
 		port:     p,
 		insecure: is,
 		cert:     c,
@@ -58,9 +66,18 @@ func NewAPIServer(a *APIHandler, p int, is bool, c, k string) *APIServer {
 	}
 }
 
-// Serve launches the API Server.
+// NewLocalAPIServer initializes a new API server
+// serving without TLS on localhost only, for development.
+func NewLocalAPIServer(a *APIHandler, p int) *APIServer {
+	s := NewAPIServer(a, p, true, "", "")
+	s.host = "127.0.0.1"
+	return s
+}
+
+// Serve launches the API Server.  It returns once the server is shut down.
 func (a *APIServer) Serve() {
-	mcs := getHTTPServerCfg(fmt.Sprintf(":%v", a.port), a.handler)
+	mcs := a.server
+	mcs.Addr = fmt.Sprintf("%s:%v", a.host, a.port)
 
 	glog.Infof("Launching server on %s", mcs.Addr)
 	if a.insecure {
@@ -75,6 +92,19 @@ func (a *APIServer) Serve() {
 	}
 }
 
+// Drain makes /healthz fail, while still serving configs, so that the load
+// balancers and the readiness probe take the server out of rotation before
+// it shuts down.
+func (a *APIServer) Drain() {
+	atomic.StoreInt32(&a.health.draining, 1)
+}
+
+// Shutdown stops accepting connections and waits for the requests in flight
+// to complete, or for ctx to be done.
+func (a *APIServer) Shutdown(ctx context.Context) error {
+	return a.server.Shutdown(ctx)
+}
+
 // APIHandler is the HTTP Handler for the
 // Machine Config Server.
 type APIHandler struct {
@@ -183,7 +213,71 @@ func (sh *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-type healthHandler struct{}
+// butaneHandler serves the config of a pool as a Butane config, with the
+// file contents decoded, so that it can be reviewed.
+type butaneHandler struct {
+	server Server
+}
+
+// ServeHTTP handles /butane/<pool> requests.
+func (sh *butaneHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	poolName := path.Base(r.URL.Path)
+	glog.Infof("Butane config of pool %s requested by address:%q User-Agent:%q", poolName, r.RemoteAddr, r.Header.Get("User-Agent"))
+
+	cr := poolRequest{
+		machineConfigPool: poolName,
+		version:           semver.New("3.2.0"),
+	}
+	conf, err := sh.server.GetConfig(cr)
+	if err != nil {
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(http.StatusInternalServerError)
+		glog.Errorf("couldn't get config for req: %v, error: %v", cr, err)
+		return
+	}
+	if conf == nil {
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(conf.Raw)
+	if err != nil {
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(http.StatusInternalServerError)
+		glog.Errorf("couldn't parse config for req: %v, error: %v", cr, err)
+		return
+	}
+	data, err := butane.ExportIgnition(&ignCfg)
+	if err != nil {
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(http.StatusInternalServerError)
+		glog.Errorf("couldn't export config for req: %v, error: %v", cr, err)
+		return
+	}
+
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	w.Header().Set("Content-Type", "application/yaml")
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if _, err := w.Write(data); err != nil {
+		glog.Errorf("failed to write %v response: %v", cr, err)
+	}
+}
+
+// healthHandler reports whether the server is healthy, i.e. not draining.
+type healthHandler struct {
+	draining int32
+}
 
 type acceptHeaderValue struct {
 	MIMEType    string
@@ -311,6 +405,10 @@ func detectSpecVersionFromAcceptHeader(acceptHeader string) (*semver.Version, er
 func (h *healthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Length", "0")
 	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		if atomic.LoadInt32(&h.draining) != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		return
 	}
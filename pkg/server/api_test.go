@@ -1,14 +1,17 @@
 package server
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/stretchr/testify/assert"
@@ -287,6 +290,92 @@ func TestAPIHandler(t *testing.T) {
 	}
 }
 
+func TestButaneHandler(t *testing.T) {
+	ignCfg := ctrlcommon.NewIgnConfig()
+	ignCfg.Storage.Files = append(ignCfg.Storage.Files, helpers.CreateIgn3File("/etc/example.conf", "data:,key%3Dvalue%0A", 0644))
+	conf := &runtime.RawExtension{Raw: helpers.MarshalOrDie(ignCfg)}
+
+	scenarios := []scenario{
+		{
+			name:    "get butane path that exists",
+			request: httptest.NewRequest(http.MethodGet, "http://testrequest/butane/master", nil),
+			serverFunc: func(pr poolRequest) (*runtime.RawExtension, error) {
+				assert.Equal(t, "master", pr.machineConfigPool)
+				return conf, nil
+			},
+			checkResponse: func(t *testing.T, response *http.Response) {
+				checkStatus(t, response, http.StatusOK)
+				checkContentType(t, response, "application/yaml")
+				body, err := ioutil.ReadAll(response.Body)
+				require.NoError(t, err)
+				assert.Contains(t, string(body), "variant: fcos\n")
+				assert.Contains(t, string(body), "- path: /etc/example.conf\n      contents:\n        inline: |\n          key=value\n")
+				checkContentLength(t, response, len(body))
+			},
+		},
+		{
+			name:    "head butane path that exists",
+			request: httptest.NewRequest(http.MethodHead, "http://testrequest/butane/master", nil),
+			serverFunc: func(poolRequest) (*runtime.RawExtension, error) {
+				return conf, nil
+			},
+			checkResponse: func(t *testing.T, response *http.Response) {
+				checkStatus(t, response, http.StatusOK)
+				checkContentType(t, response, "application/yaml")
+				checkBodyLength(t, response, 0)
+			},
+		},
+		{
+			name:    "get butane path that does not exist",
+			request: httptest.NewRequest(http.MethodGet, "http://testrequest/butane/does-not-exist", nil),
+			serverFunc: func(poolRequest) (*runtime.RawExtension, error) {
+				return nil, nil
+			},
+			checkResponse: func(t *testing.T, response *http.Response) {
+				checkStatus(t, response, http.StatusNotFound)
+				checkContentLength(t, response, 0)
+				checkBodyLength(t, response, 0)
+			},
+		},
+		{
+			name:    "get butane path with server error",
+			request: httptest.NewRequest(http.MethodGet, "http://testrequest/butane/master", nil),
+			serverFunc: func(poolRequest) (*runtime.RawExtension, error) {
+				return nil, fmt.Errorf("not acceptable")
+			},
+			checkResponse: func(t *testing.T, response *http.Response) {
+				checkStatus(t, response, http.StatusInternalServerError)
+				checkContentLength(t, response, 0)
+				checkBodyLength(t, response, 0)
+			},
+		},
+		{
+			name:    "post butane path that exists",
+			request: httptest.NewRequest(http.MethodPost, "http://testrequest/butane/master", nil),
+			serverFunc: func(poolRequest) (*runtime.RawExtension, error) {
+				return conf, nil
+			},
+			checkResponse: func(t *testing.T, response *http.Response) {
+				checkStatus(t, response, http.StatusMethodNotAllowed)
+				checkContentLength(t, response, 0)
+				checkBodyLength(t, response, 0)
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler := &butaneHandler{server: &mockServer{GetConfigFn: scenario.serverFunc}}
+			handler.ServeHTTP(w, scenario.request)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			scenario.checkResponse(t, resp)
+		})
+	}
+}
+
 func TestHealthzHandler(t *testing.T) {
 	scenarios := []scenario{
 		{
@@ -434,6 +523,19 @@ func TestAPIServer(t *testing.T) {
 				checkBodyLength(t, response, expectedContentLength)
 			},
 		},
+		{
+			name:    "get butane path that exists",
+			request: httptest.NewRequest(http.MethodGet, "http://testrequest/butane/master", nil),
+			serverFunc: func(poolRequest) (*runtime.RawExtension, error) {
+				return &runtime.RawExtension{
+					Raw: helpers.MarshalOrDie(ctrlcommon.NewIgnConfig()),
+				}, nil
+			},
+			checkResponse: func(t *testing.T, response *http.Response) {
+				checkStatus(t, response, http.StatusOK)
+				checkContentType(t, response, "application/yaml")
+			},
+		},
 		{
 			name:    "head config path that exists",
 			request: setAcceptHeaderOnReq(httptest.NewRequest(http.MethodHead, "http://testrequest/config/master", nil)),
@@ -555,7 +657,7 @@ func TestAPIServer(t *testing.T) {
 				GetConfigFn: scenario.serverFunc,
 			}
 			server := NewAPIServer(NewServerAPIHandler(ms), 0, false, "", "")
-			server.handler.ServeHTTP(w, scenario.request)
+			server.server.Handler.ServeHTTP(w, scenario.request)
 
 			resp := w.Result()
 			defer resp.Body.Close()
@@ -564,6 +666,65 @@ func TestAPIServer(t *testing.T) {
 	}
 }
 
+func TestAPIServerDrainAndShutdown(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+
+	inFlight := make(chan struct{})
+	release := make(chan struct{})
+	ms := &mockServer{
+		GetConfigFn: func(poolRequest) (*runtime.RawExtension, error) {
+			close(inFlight)
+			<-release
+			return &runtime.RawExtension{Raw: helpers.MarshalOrDie(ctrlcommon.NewIgnConfig())}, nil
+		},
+	}
+	server := NewLocalAPIServer(NewServerAPIHandler(ms), port)
+	go server.Serve()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d", port)
+	healthz := func() int {
+		resp, err := http.Get(url + "/healthz")
+		if err != nil {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	require.Eventually(t, func() bool { return healthz() == http.StatusOK }, 5*time.Second, 10*time.Millisecond)
+
+	configStatus := make(chan int)
+	go func() {
+		resp, err := http.Get(url + "/config/worker")
+		if err != nil {
+			configStatus <- 0
+			return
+		}
+		resp.Body.Close()
+		configStatus <- resp.StatusCode
+	}()
+	<-inFlight
+
+	// draining fails the health check but keeps serving
+	server.Drain()
+	assert.Equal(t, http.StatusServiceUnavailable, healthz())
+
+	// shutting down waits for the config being served
+	shutdown := make(chan error)
+	go func() { shutdown <- server.Shutdown(context.Background()) }()
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned with a request in flight: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	assert.Equal(t, http.StatusOK, <-configStatus)
+	assert.NoError(t, <-shutdown)
+	assert.Equal(t, 0, healthz())
+}
+
 func TestAPIServerCiphers(t *testing.T) {
 	certData, err := tls.X509KeyPair(
 		[]byte(`-----BEGIN CERTIFICATE-----
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 
 	yaml "github.com/ghodss/yaml"
@@ -26,6 +27,12 @@ const (
 
 	//nolint:gosec
 	bootstrapTokenDir = "/etc/mcs/bootstrap-token"
+
+	// bootstrapCredentialsDir holds the short-lived token rotated by the
+	// machine-config-controller, used instead of the token in
+	// bootstrapTokenDir once it exists.
+	//nolint:gosec
+	bootstrapCredentialsDir = "/etc/mcs/bootstrap-credentials"
 )
 
 // ensure clusterServer implements the
@@ -54,8 +61,29 @@ func NewClusterServer(kubeConfig, apiserverURL string) (Server, error) {
 
 	mc := v1.NewForConfigOrDie(restConfig)
 	return &clusterServer{
-		machineClient:  mc,
-		kubeconfigFunc: func() ([]byte, []byte, error) { return kubeconfigFromSecret(bootstrapTokenDir, apiserverURL) },
+		machineClient: mc,
+		kubeconfigFunc: func() ([]byte, []byte, error) {
+			return bootstrapKubeconfig(bootstrapCredentialsDir, bootstrapTokenDir, apiserverURL)
+		},
+	}, nil
+}
+
+// NewDevClusterServer is a NewClusterServer for development, run outside
+// of the cluster with a kubeconfig.  It doesn't have the bootstrap token, the
+// configs it serves have a kubeconfig of apiserverURL without credentials.
+func NewDevClusterServer(kubeConfig, apiserverURL string) (Server, error) {
+	restConfig, err := getClientConfig(kubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create Kubernetes rest client: %v", err)
+	}
+
+	mc := v1.NewForConfigOrDie(restConfig)
+	return &clusterServer{
+		machineClient: mc,
+		kubeconfigFunc: func() ([]byte, []byte, error) {
+			kcData, err := buildKubeconfig(apiserverURL, nil, "")
+			return kcData, nil, err
+		},
 	}, nil
 }
 
@@ -125,6 +153,26 @@ func kubeconfigFromSecret(secretDir, apiserverURL string) ([]byte, []byte, error
 		return nil, nil, fmt.Errorf("Failed to read %s: %v", tokenFile, err)
 	}
 
+	kcData, err := buildKubeconfig(apiserverURL, caData, string(token))
+	if err != nil {
+		return nil, nil, err
+	}
+	return kcData, caData, nil
+}
+
+// bootstrapKubeconfig creates a kubeconfig with the rotated credentials in
+// credentialsDir, or with the long-lived token in tokenDir until they exist.
+// The files are read on every request as the kubelet updates them in place
+// when the credentials are rotated.
+func bootstrapKubeconfig(credentialsDir, tokenDir, apiserverURL string) ([]byte, []byte, error) {
+	if _, err := os.Stat(filepath.Join(credentialsDir, corev1.ServiceAccountTokenKey)); err == nil {
+		return kubeconfigFromSecret(credentialsDir, apiserverURL)
+	}
+	return kubeconfigFromSecret(tokenDir, apiserverURL)
+}
+
+// buildKubeconfig returns the kubelet kubeconfig of apiserverURL.
+func buildKubeconfig(apiserverURL string, caData []byte, token string) ([]byte, error) {
 	kubeconfig := clientcmdv1.Config{
 		Clusters: []clientcmdv1.NamedCluster{{
 			Name: "local",
@@ -136,7 +184,7 @@ func kubeconfigFromSecret(secretDir, apiserverURL string) ([]byte, []byte, error
 		AuthInfos: []clientcmdv1.NamedAuthInfo{{
 			Name: "kubelet",
 			AuthInfo: clientcmdv1.AuthInfo{
-				Token: string(token),
+				Token: token,
 			},
 		}},
 		Contexts: []clientcmdv1.NamedContext{{
@@ -148,9 +196,5 @@ func kubeconfigFromSecret(secretDir, apiserverURL string) ([]byte, []byte, error
 		}},
 		CurrentContext: "kubelet",
 	}
-	kcData, err := yaml.Marshal(kubeconfig)
-	if err != nil {
-		return nil, nil, err
-	}
-	return kcData, caData, nil
+	return yaml.Marshal(kubeconfig)
 }
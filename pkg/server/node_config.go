@@ -0,0 +1,144 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+
+	igntypes "github.com/coreos/ignition/v2/config/v3_2/types"
+	corev1 "k8s.io/api/core/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// ServerOrigin is the origin of the files the MCS appends to the configs it serves.
+const ServerOrigin = "machine-config-server"
+
+// NodeConfig is the Ignition config a node should have: the rendered config
+// it's targeted to, as the MCS would serve it to provision the node.
+type NodeConfig struct {
+	// Node is the name of the node.
+	Node string `json:"node"`
+	// Pool is the pool the node belongs to.
+	Pool string `json:"pool"`
+	// Architecture is the architecture the node reports.
+	Architecture string `json:"architecture"`
+	// MachineConfig is the rendered config the node should have: its desired
+	// config, or the target config of its pool if the MCD didn't annotate it yet.
+	MachineConfig string `json:"machineConfig"`
+	// CurrentMachineConfig is the rendered config the MCD last applied to the node.
+	CurrentMachineConfig string `json:"currentMachineConfig,omitempty"`
+	// Sources are the MachineConfigs merged into MachineConfig, if the pool still lists them.
+	Sources []string `json:"sources,omitempty"`
+	// Origins maps the path of every file and the name of every unit of the
+	// config to the source it comes from, or to ServerOrigin.  Empty without sources.
+	Origins map[string]string `json:"origins,omitempty"`
+	// KernelArguments, Extensions, KernelType and FIPS are the settings of
+	// MachineConfig outside of the Ignition config.
+	KernelArguments []string `json:"kernelArguments,omitempty"`
+	Extensions      []string `json:"extensions,omitempty"`
+	KernelType      string   `json:"kernelType,omitempty"`
+	FIPS            bool     `json:"fips"`
+	// Ignition is the Ignition config, with the files the MCS appends but
+	// the kubeconfig, which only holds the bootstrap credentials.
+	Ignition igntypes.Config `json:"ignition"`
+}
+
+// GetNodeConfig returns the config the node of pool should have.  getConfig
+// fetches a MachineConfig by name, for the rendered config and its sources.
+func GetNodeConfig(node *corev1.Node, pool *mcfgv1.MachineConfigPool, getConfig func(name string) (*mcfgv1.MachineConfig, error)) (*NodeConfig, error) {
+	name := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]
+	if name == "" {
+		name = pool.Spec.Configuration.Name
+	}
+	if name == "" {
+		return nil, fmt.Errorf("node %s has no desired config and pool %s has no target config yet", node.Name, pool.Name)
+	}
+	mc, err := getConfig(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch config %s: %w", name, err)
+	}
+	ignConf, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing Ignition config of %s failed with error: %v", name, err)
+	}
+
+	nc := &NodeConfig{
+		Node:                 node.Name,
+		Pool:                 pool.Name,
+		Architecture:         node.Status.NodeInfo.Architecture,
+		MachineConfig:        name,
+		CurrentMachineConfig: node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey],
+		KernelArguments:      mc.Spec.KernelArguments,
+		Extensions:           mc.Spec.Extensions,
+		KernelType:           mc.Spec.KernelType,
+		FIPS:                 mc.Spec.FIPS,
+	}
+	// the pool lists the sources of its current and target configs only
+	for _, conf := range []mcfgv1.MachineConfigPoolStatusConfiguration{pool.Spec.Configuration, pool.Status.Configuration} {
+		if conf.Name == name {
+			for _, source := range conf.Source {
+				nc.Sources = append(nc.Sources, source.Name)
+			}
+			break
+		}
+	}
+	if len(nc.Sources) > 0 {
+		if nc.Origins, err = configOrigins(nc.Sources, getConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	// as served by the MCS, see getAppenders
+	appenders := []appenderFunc{
+		func(cfg *igntypes.Config, mc *mcfgv1.MachineConfig) error {
+			return appendNodeAnnotations(cfg, name)
+		},
+		appendInitialMachineConfig,
+		func(cfg *igntypes.Config, mc *mcfgv1.MachineConfig) error {
+			return appendEncapsulated(cfg, mc, nil)
+		},
+	}
+	for _, a := range appenders {
+		if err := a(&ignConf, mc); err != nil {
+			return nil, err
+		}
+	}
+	if nc.Origins != nil {
+		for _, path := range []string{daemonconsts.InitialNodeAnnotationsFilePath, machineConfigContentPath, daemonconsts.MachineConfigEncapsulatedPath} {
+			nc.Origins[path] = ServerOrigin
+		}
+	}
+	nc.Ignition = ignConf
+	return nc, nil
+}
+
+// configOrigins maps the files and units of the merge of sources to the
+// source they come from.  Sources are merged in name order, later ones
+// overriding earlier ones, as the render controller does.
+func configOrigins(sources []string, getConfig func(name string) (*mcfgv1.MachineConfig, error)) (map[string]string, error) {
+	sorted := append([]string{}, sources...)
+	sort.Strings(sorted)
+	origins := map[string]string{}
+	for _, source := range sorted {
+		mc, err := getConfig(source)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch source config %s: %w", source, err)
+		}
+		if mc.Spec.Config.Raw == nil {
+			continue
+		}
+		ignConf, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("parsing Ignition config of %s failed with error: %v", source, err)
+		}
+		for _, f := range ignConf.Storage.Files {
+			origins[f.Path] = source
+		}
+		for _, u := range ignConf.Systemd.Units {
+			origins[u.Name] = source
+		}
+	}
+	return origins, nil
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestGetNodeConfig(t *testing.T) {
+	base := helpers.NewMachineConfig("00-worker", nil, "", []ign3types.File{
+		helpers.NewIgnFile("/etc/base", "base"),
+		helpers.NewIgnFile("/etc/overridden", "base"),
+	})
+	custom := helpers.NewMachineConfig("99-worker-custom", nil, "", []ign3types.File{
+		helpers.NewIgnFile("/etc/overridden", "custom"),
+	})
+	custom.Spec.KernelArguments = []string{"nosmt"}
+	rendered, err := ctrlcommon.MergeMachineConfigs([]*mcfgv1.MachineConfig{base, custom}, "")
+	require.NoError(t, err)
+	rendered.Name = "rendered-worker-2"
+	old := rendered.DeepCopy()
+	old.Name = "rendered-worker-1"
+	configs := map[string]*mcfgv1.MachineConfig{}
+	for _, mc := range []*mcfgv1.MachineConfig{base, custom, rendered, old} {
+		configs[mc.Name] = mc
+	}
+	getConfig := func(name string) (*mcfgv1.MachineConfig, error) {
+		if mc, ok := configs[name]; ok {
+			return mc, nil
+		}
+		return nil, fmt.Errorf("%s not found", name)
+	}
+
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-2")
+	pool.Spec.Configuration.Source = []corev1.ObjectReference{{Name: "00-worker"}, {Name: "99-worker-custom"}}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: map[string]string{
+			daemonconsts.CurrentMachineConfigAnnotationKey: "rendered-worker-1",
+			daemonconsts.DesiredMachineConfigAnnotationKey: "rendered-worker-2",
+		}},
+		Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{Architecture: "arm64"}},
+	}
+
+	nc, err := GetNodeConfig(node, pool, getConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "rendered-worker-2", nc.MachineConfig)
+	assert.Equal(t, "rendered-worker-1", nc.CurrentMachineConfig)
+	assert.Equal(t, "arm64", nc.Architecture)
+	assert.Equal(t, []string{"00-worker", "99-worker-custom"}, nc.Sources)
+	assert.Equal(t, []string{"nosmt"}, nc.KernelArguments)
+	assert.Equal(t, map[string]string{
+		"/etc/base":       "00-worker",
+		"/etc/overridden": "99-worker-custom",
+		daemonconsts.InitialNodeAnnotationsFilePath: ServerOrigin,
+		machineConfigContentPath:                    ServerOrigin,
+		daemonconsts.MachineConfigEncapsulatedPath:  ServerOrigin,
+	}, nc.Origins)
+
+	files := map[string]string{}
+	for _, f := range nc.Ignition.Storage.Files {
+		files[f.Path], err = getDecodedContent(*f.Contents.Source)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "custom", files["/etc/overridden"])
+	anno, err := getNodeAnnotation("rendered-worker-2")
+	require.NoError(t, err)
+	assert.Equal(t, anno, files[daemonconsts.InitialNodeAnnotationsFilePath])
+	assert.NotContains(t, files, defaultMachineKubeConfPath)
+
+	// a node still in the previous config of the pool, whose sources are
+	// no longer listed
+	node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] = "rendered-worker-1"
+	nc, err = GetNodeConfig(node, pool, getConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "rendered-worker-1", nc.MachineConfig)
+	assert.Empty(t, nc.Sources)
+	assert.Nil(t, nc.Origins)
+
+	// a new node the MCD didn't annotate yet gets the target config of the pool
+	delete(node.Annotations, daemonconsts.DesiredMachineConfigAnnotationKey)
+	nc, err = GetNodeConfig(node, pool, getConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "rendered-worker-2", nc.MachineConfig)
+
+	_, err = GetNodeConfig(node, helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, ""), getConfig)
+	assert.Error(t, err)
+}
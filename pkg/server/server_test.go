@@ -452,3 +452,58 @@ func TestKubeconfigFromSecret(t *testing.T) {
 		})
 	}
 }
+
+func TestBootstrapKubeconfig(t *testing.T) {
+	credentialsDir := t.TempDir()
+	legacyToken, err := ioutil.ReadFile(filepath.Join(testDir, corev1.ServiceAccountTokenKey))
+	if err != nil {
+		t.Fatalf("Error reading test token file: %v", err)
+	}
+
+	// the long-lived token is used until the rotated credentials exist
+	kc, _, err := bootstrapKubeconfig(credentialsDir, testDir, "api.tt.testing")
+	if err != nil {
+		t.Fatalf("expected err to be nil, received: %v", err)
+	}
+	if !strings.Contains(string(kc), strings.TrimSpace(string(legacyToken))) {
+		t.Fatalf("Kubeconfig does not contain the long-lived token")
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(credentialsDir, corev1.ServiceAccountRootCAKey), []byte("rotated-ca"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(credentialsDir, corev1.ServiceAccountTokenKey), []byte("rotated-token"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	kc, ca, err := bootstrapKubeconfig(credentialsDir, testDir, "api.tt.testing")
+	if err != nil {
+		t.Fatalf("expected err to be nil, received: %v", err)
+	}
+	if !strings.Contains(string(kc), "rotated-token") || string(ca) != "rotated-ca" {
+		t.Fatalf("Kubeconfig does not contain the rotated credentials: %s", kc)
+	}
+}
+
+func TestDevClusterServer(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	kcData, err := buildKubeconfig("https://api.tt.testing:6443", nil, "admin-token")
+	if err != nil {
+		t.Fatalf("expected err to be nil, received: %v", err)
+	}
+	if err := ioutil.WriteFile(kubeconfig, kcData, 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewDevClusterServer(kubeconfig, "https://api.tt.testing:6443")
+	if err != nil {
+		t.Fatalf("expected err to be nil, received: %v", err)
+	}
+	kc, ca, err := s.(*clusterServer).kubeconfigFunc()
+	if err != nil {
+		t.Fatalf("expected err to be nil, received: %v", err)
+	}
+	assert.Nil(t, ca)
+	str := string(kc)
+	assert.Contains(t, str, "server: https://api.tt.testing:6443")
+	assert.NotContains(t, str, "token")
+	assert.NotContains(t, str, "certificate-authority-data")
+}
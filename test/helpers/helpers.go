@@ -151,6 +151,13 @@ func NewMachineConfigPool(name string, mcSelector, nodeSelector *metav1.LabelSel
 	}
 }
 
+// NewPool returns a MCP selecting the nodes of role name, currently on
+// rendered-<name>-1
+func NewPool(name string) *mcfgv1.MachineConfigPool {
+	nodeSelector := metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/"+name, "")
+	return NewMachineConfigPool(name, nil, nodeSelector, "rendered-"+name+"-1")
+}
+
 // NewIgnFile returns a simple ignition3 file from just path and file contents
 func NewIgnFile(path, contents string) ign3types.File {
 	return ign3types.File{
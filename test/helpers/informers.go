@@ -0,0 +1,73 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	kubescheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	mcfgscheme "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	informers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
+)
+
+// FakeInformers are fake clients holding a set of objects, and informer
+// factories on top of them, to test the sync of a controller without
+// running its informers.
+type FakeInformers struct {
+	Client        *fake.Clientset
+	KubeClient    *k8sfake.Clientset
+	Informers     informers.SharedInformerFactory
+	KubeInformers kubeinformers.SharedInformerFactory
+
+	objects []runtime.Object
+}
+
+// NewFakeInformers returns FakeInformers holding the MCO objects in Client
+// and the Kubernetes ones in KubeClient.
+func NewFakeInformers(objects ...runtime.Object) *FakeInformers {
+	var mcfgObjects, kubeObjects []runtime.Object
+	for _, obj := range objects {
+		if _, _, err := mcfgscheme.Scheme.ObjectKinds(obj); err == nil {
+			mcfgObjects = append(mcfgObjects, obj)
+		} else {
+			kubeObjects = append(kubeObjects, obj)
+		}
+	}
+	client := fake.NewSimpleClientset(mcfgObjects...)
+	kubeClient := k8sfake.NewSimpleClientset(kubeObjects...)
+	return &FakeInformers{
+		Client:        client,
+		KubeClient:    kubeClient,
+		Informers:     informers.NewSharedInformerFactory(client, 0),
+		KubeInformers: kubeinformers.NewSharedInformerFactory(kubeClient, 0),
+		objects:       objects,
+	}
+}
+
+// FillListers adds the objects to the listers of their informers.  Call it
+// once the controller is created, so the informers it uses exist.
+func (f *FakeInformers) FillListers(t *testing.T) {
+	for _, obj := range f.objects {
+		var informer cache.SharedIndexInformer
+		if gvks, _, err := mcfgscheme.Scheme.ObjectKinds(obj); err == nil {
+			gvr, _ := meta.UnsafeGuessKindToResource(gvks[0])
+			generic, err := f.Informers.ForResource(gvr)
+			require.NoError(t, err)
+			informer = generic.Informer()
+		} else {
+			gvks, _, err := kubescheme.Scheme.ObjectKinds(obj)
+			require.NoError(t, err)
+			gvr, _ := meta.UnsafeGuessKindToResource(gvks[0])
+			generic, err := f.KubeInformers.ForResource(gvr)
+			require.NoError(t, err)
+			informer = generic.Informer()
+		}
+		require.NoError(t, informer.GetIndexer().Add(obj))
+	}
+}